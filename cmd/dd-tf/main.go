@@ -1,33 +1,115 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/AD7six/dd-tf/internal/commands/config"
 	"github.com/AD7six/dd-tf/internal/commands/dashboards"
+	"github.com/AD7six/dd-tf/internal/commands/drift"
 	"github.com/AD7six/dd-tf/internal/commands/monitors"
+	"github.com/AD7six/dd-tf/internal/commands/report"
+	"github.com/AD7six/dd-tf/internal/commands/resources"
+	"github.com/AD7six/dd-tf/internal/commands/restore"
+	"github.com/AD7six/dd-tf/internal/commands/tf"
+	"github.com/AD7six/dd-tf/internal/commands/validate"
+	"github.com/AD7six/dd-tf/internal/commands/verify"
 	"github.com/AD7six/dd-tf/internal/commands/version"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
 	"github.com/AD7six/dd-tf/internal/logging"
 	"github.com/spf13/cobra"
 )
 
-var verbose bool
+var (
+	verbose     bool
+	stats       bool
+	annotations string
+)
+
+// interruptGrace is how long an interrupted command has to finish its
+// in-flight downloads and writes before the process exits anyway.
+const interruptGrace = 5 * time.Second
 
 func main() {
 	root := &cobra.Command{
 		Use:   "dd-tf",
 		Short: "Datadog Terraform management CLI",
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Errors are printed below, except differences reported by --exit-code
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			if verbose {
 				logging.InitLogger("debug")
 			}
+			return logging.EnableAnnotations(annotations)
 		},
 	}
 
 	root.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose/debug output (shows curl commands)")
+	root.PersistentFlags().BoolVar(&stats, "stats", false, "Print a summary of the API requests made when the command ends")
+	root.PersistentFlags().StringVar(&annotations, "annotations", "", "Also emit warnings/errors as CI annotations on stdout: github or none (default: github when GITHUB_ACTIONS=true)")
 
 	root.AddCommand(config.NewConfigCmd())
-	root.AddCommand(dashboards.NewDashboardsCmd())
-	root.AddCommand(monitors.NewMonitorsCmd())
+	root.AddCommand(resources.NewCmds(map[string][]*cobra.Command{
+		"dashboards": {dashboards.NewSplitCmd(), dashboards.NewJoinCmd()},
+		"monitors":   {monitors.NewLintCmd(), monitors.NewValidateCmd(), monitors.NewDeleteCmd()},
+	})...)
+	root.AddCommand(drift.NewDriftCmd())
+	root.AddCommand(report.NewReportCmd())
+	root.AddCommand(restore.NewRestoreCmd())
+	root.AddCommand(tf.NewTfCmd())
+	root.AddCommand(validate.NewValidateCmd())
+	root.AddCommand(verify.NewVerifyCmd())
 	root.AddCommand(version.NewVersionCmd())
 
-	cobra.CheckErr(root.Execute())
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		// A second signal now stops the process at once
+		stop()
+		time.Sleep(interruptGrace)
+		fmt.Fprintln(os.Stderr, "Error: interrupted, in-flight work didn't finish in time")
+		os.Exit(resource.ExitInterrupted)
+	}()
+
+	cmd, err := root.ExecuteContextC(ctx)
+	if ctx.Err() != nil && (cmd == nil || cmd.Annotations[resource.AnnotationRunsUntilInterrupted] == "") {
+		err = &resource.InterruptedError{Err: err}
+	}
+	logStats()
+	if err != nil {
+		var diffErr *resource.DifferencesError
+		if !errors.As(err, &diffErr) {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
+		os.Exit(resource.ExitCode(err))
+	}
+}
+
+// logStats logs the API requests made by the command, if it made any: at
+// info level with --stats, else at debug level.
+func logStats() {
+	s, ok := internalhttp.SharedStats()
+	if !ok {
+		return
+	}
+	level := slog.LevelDebug
+	if stats {
+		level = slog.LevelInfo
+	}
+	logging.Logger.Log(context.Background(), level, "api stats",
+		"requests", s.Requests,
+		"retries", s.Retries,
+		"rate_limited", s.RateLimited,
+		"rate_limit_wait", s.RateLimitWait.Round(time.Millisecond),
+		"latency_p50", s.LatencyP50,
+		"latency_p95", s.LatencyP95,
+	)
 }
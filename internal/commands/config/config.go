@@ -32,6 +32,13 @@ func NewConfigCmd() *cobra.Command {
 	return cmd
 }
 
+// secretSettings are masked like the Datadog keys.
+var secretSettings = map[string]bool{
+	"AWS_SECRET_ACCESS_KEY":             true,
+	"AWS_SESSION_TOKEN":                 true,
+	"AWS_CONTAINER_AUTHORIZATION_TOKEN": true,
+}
+
 func displaySettings(s *internalconfig.Settings) {
 	v := reflect.ValueOf(*s)
 	t := reflect.TypeOf(*s)
@@ -86,6 +93,10 @@ func displaySettings(s *internalconfig.Settings) {
 			continue
 		}
 
+		if secretSettings[envName] && value.String() != "" {
+			cliOptions = append(cliOptions, kv{key: envName, value: utils.MaskSecret(value.String())})
+			continue
+		}
 		cliOptions = append(cliOptions, kv{key: envName, value: value.Interface()})
 	}
 
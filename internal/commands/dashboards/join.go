@@ -0,0 +1,57 @@
+package dashboards
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AD7six/dd-tf/internal/datadog/dashboards"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// NewJoinCmd creates the join command, which reassembles a dashboard split
+// by "dashboards split".
+func NewJoinCmd() *cobra.Command {
+	var path, out string
+
+	cmd := &cobra.Command{
+		Use:   "join",
+		Short: "Reassemble a split dashboard into a single file",
+		Long: `Read dashboard.json and the numbered widget files in --path and write the
+dashboard with its widgets in prefix order to --out, or to stdout without
+--out. Joining an unchanged split gives back the original file.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if out == "" {
+				logging.ReserveStdout()
+			}
+			return runJoin(path, out)
+		},
+	}
+
+	cmd.Flags().StringVar(&path, "path", "", "Directory written by \"dashboards split\"")
+	cmd.Flags().StringVar(&out, "out", "", "Dashboard JSON file to write (default: stdout)")
+	cmd.MarkFlagRequired("path")
+
+	return cmd
+}
+
+func runJoin(path, out string) error {
+	raw, err := dashboards.ReadSplit(storage.FileBackend{}, path)
+	if err != nil {
+		return fmt.Errorf("failed to join %s: %w", path, err)
+	}
+	if out == "" {
+		data, err := storage.FormatJSON(raw)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := storage.WriteRawJSONFile(out, raw); err != nil {
+		return err
+	}
+	logging.Logger.Info("dashboard joined", "path", path, "out", out)
+	return nil
+}
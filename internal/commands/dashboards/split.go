@@ -0,0 +1,49 @@
+// Package dashboards holds the dashboard-only subcommands, which work on
+// local files rather than the API.
+package dashboards
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AD7six/dd-tf/internal/datadog/dashboards"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+// NewSplitCmd creates the split command, which decomposes a downloaded
+// dashboard into a skeleton plus one file per top-level widget.
+func NewSplitCmd() *cobra.Command {
+	var path, out string
+
+	cmd := &cobra.Command{
+		Use:   "split",
+		Short: "Split a dashboard file into one file per widget",
+		Long: `Write a skeleton dashboard.json (everything except the widgets) plus one
+numbered file per top-level widget, e.g. 001-group.json, into --out. Group
+widgets keep their nested widgets inline. Widget files from an earlier split
+are replaced. "dashboards join" reassembles them.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSplit(path, out)
+		},
+	}
+
+	cmd.Flags().StringVar(&path, "path", "", "Dashboard JSON file to split")
+	cmd.Flags().StringVar(&out, "out", "", "Directory to write the skeleton and widget files to")
+	cmd.MarkFlagRequired("path")
+	cmd.MarkFlagRequired("out")
+
+	return cmd
+}
+
+func runSplit(path, out string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := dashboards.WriteSplit(out, raw); err != nil {
+		return fmt.Errorf("failed to split %s: %w", path, err)
+	}
+	logging.Logger.Info("dashboard split", "path", path, "out", out)
+	return nil
+}
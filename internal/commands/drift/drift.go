@@ -0,0 +1,166 @@
+// Package drift holds the drift command, which compares every downloaded
+// dashboard and monitor with Datadog.
+package drift
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/AD7six/dd-tf/internal/commands/version"
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/dashboards"
+	"github.com/AD7six/dd-tf/internal/datadog/monitors"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/templating"
+	"github.com/AD7six/dd-tf/internal/drift"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/notify"
+	"github.com/AD7six/dd-tf/internal/report"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// NewDriftCmd creates the drift command.
+func NewDriftCmd() *cobra.Command {
+	var (
+		format       string
+		reportFormat string
+		exitCode     bool
+		notifyOpts   notify.Options
+	)
+
+	cmd := &cobra.Command{
+		Use:   "drift",
+		Short: "Report how downloaded dashboards and monitors differ from Datadog",
+		Long: `Compare every file under the dashboards and monitors path templates'
+directories with the remote resource with the same id, both normalized as
+downloads are, and list every dashboard and monitor in Datadog to find those
+without a file. The report counts the resources in sync, and lists those
+that drifted, with the fields changed, and those missing remotely or
+locally.
+
+--format markdown writes the report as Markdown tables, ready to paste into
+an issue or a review document. The command exits non-zero if something
+couldn't be compared or listed; with --exit-code it exits 0 when everything
+is in sync, 1 when something differs and 2 on errors, like git diff.
+
+--report-format junit|sarif writes a JUnit or SARIF report instead of
+--format's, for CI test and code-scanning views.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := runDrift(cmd.Context(), format, reportFormat, exitCode, notifyOpts)
+			if exitCode {
+				return resource.WithExitCode(err)
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", drift.FormatTable, "Output format: table, json or markdown")
+	cmd.Flags().StringVar(&reportFormat, "report-format", "", "Write a junit or sarif report instead of --format's")
+	cmd.Flags().BoolVar(&exitCode, "exit-code", false, "Exit 1 if anything differs and 2 on errors, 0 if everything is in sync")
+	notify.AddFlags(cmd, &notifyOpts)
+
+	return cmd
+}
+
+func runDrift(ctx context.Context, format, reportFormat string, exitCode bool, notifyOpts notify.Options) error {
+	start := time.Now()
+	switch format {
+	case drift.FormatTable, drift.FormatJSON, drift.FormatMarkdown:
+	default:
+		return fmt.Errorf("invalid --format %q (expected table, json or markdown)", format)
+	}
+	switch reportFormat {
+	case "", report.FormatJUnit, report.FormatSARIF:
+	default:
+		return fmt.Errorf("invalid --report-format %q (expected junit or sarif)", reportFormat)
+	}
+	logging.ReserveStdout()
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+	backend, err := storage.NewBackend(settings)
+	if err != nil {
+		return err
+	}
+	client := internalhttp.GetHTTPClient(settings)
+
+	sources := []drift.Source{
+		{
+			Kind:   resource.KindDashboard,
+			Dir:    templating.ExtractStaticPrefix(settings.DashboardsPathTemplate),
+			IDKind: resource.IDString,
+			Ignore: dashboards.ServerFields,
+			Compare: func(ctx context.Context, path string) ([]byte, []byte, error) {
+				return dashboards.CompareDashboard(ctx, client, settings, backend, path, "")
+			},
+			List: func(ctx context.Context) (map[string]bool, error) {
+				summaries, err := dashboards.ListDashboards(ctx, client, settings)
+				if err != nil {
+					return nil, err
+				}
+				ids := make(map[string]bool, len(summaries))
+				for _, s := range summaries {
+					ids[s.ID] = true
+				}
+				return ids, nil
+			},
+		},
+		{
+			Kind:   resource.KindMonitor,
+			Dir:    templating.ExtractStaticPrefix(settings.MonitorsPathTemplate),
+			IDKind: resource.IDNumeric,
+			Ignore: monitors.ReadOnlyFields,
+			Compare: func(ctx context.Context, path string) ([]byte, []byte, error) {
+				return monitors.CompareMonitor(ctx, client, settings, backend, path, 0)
+			},
+			List: func(ctx context.Context) (map[string]bool, error) {
+				return monitors.ListMonitorIDs(ctx, client, settings)
+			},
+		},
+	}
+	for _, src := range sources {
+		if src.Dir == "" {
+			return fmt.Errorf("the %s path template has no static directory to scan", src.Kind)
+		}
+	}
+
+	result := drift.Check(ctx, backend, sources)
+	if reportFormat != "" {
+		r := result.Report()
+		r.Version = version.Version
+		err = report.Write(os.Stdout, reportFormat, r)
+	} else {
+		err = drift.Write(os.Stdout, format, result)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	result.LogEntries()
+	logging.Logger.Info("drift checked",
+		"in_sync", result.Count(drift.InSync),
+		"drifted", result.Count(drift.Drifted),
+		"missing_remote", result.Count(drift.MissingRemote),
+		"missing_local", result.Count(drift.MissingLocal),
+		"failed", result.Count(drift.Failed))
+	var drifted, failedIDs []string
+	for _, e := range result.Entries {
+		switch e.Status {
+		case drift.InSync:
+		case drift.Failed:
+			if e.ID != "" {
+				failedIDs = append(failedIDs, e.ID)
+			}
+		default:
+			drifted = append(drifted, e.ID)
+		}
+	}
+	notify.Finish(notifyOpts, notify.NewSummary("drift", len(result.Entries), failedIDs, drifted, result.Count(drift.Failed), time.Since(start)))
+	return result.Err(exitCode)
+}
@@ -0,0 +1,168 @@
+package monitors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/monitors"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/templating"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/AD7six/dd-tf/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// deleteOptions are the flags of the delete command.
+type deleteOptions struct {
+	ids         string
+	paths       []string
+	force       bool
+	removeLocal bool
+	limit       *int // nil: MAX_RESOURCES
+}
+
+// NewDeleteCmd creates the delete command, which deletes monitors in
+// Datadog and optionally their local files.
+func NewDeleteCmd() *cobra.Command {
+	var (
+		opts         deleteOptions
+		maxResources int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete monitors in Datadog",
+		Long: `Delete the monitors given by --id, or by the id in each local file under
+--path. A monitor referenced by a composite monitor can't be deleted: the
+error names the composite, and --force deletes it anyway.
+
+With --remove-local, the local file of each deleted monitor (found under the
+monitors path template's directory, or given by --path) is removed too, with
+its companion files.
+
+With MAX_RESOURCES (or --max-resources) set, selecting more monitors than
+that deletes nothing.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("max-resources") {
+				if maxResources < 0 {
+					return fmt.Errorf("--max-resources must be 0 (no limit) or more")
+				}
+				opts.limit = &maxResources
+			}
+			return runDelete(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.ids, "id", "", "Monitor ID(s) to delete (comma-separated integers)")
+	cmd.Flags().StringSliceVar(&opts.paths, "path", nil, "Local monitor file(s) or directories whose monitors to delete")
+	cmd.Flags().BoolVar(&opts.force, "force", false, "Delete monitors even if composite monitors or SLOs reference them")
+	cmd.Flags().BoolVar(&opts.removeLocal, "remove-local", false, "Also remove the local files of deleted monitors")
+	cmd.Flags().IntVar(&maxResources, "max-resources", 0, "Abort before deleting anything when more than this many monitors are selected (default: MAX_RESOURCES)")
+
+	return cmd
+}
+
+func runDelete(ctx context.Context, opts deleteOptions) error {
+	if opts.ids == "" && len(opts.paths) == 0 {
+		return fmt.Errorf("please specify --id or --path")
+	}
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+	backend := storage.FileBackend{}
+
+	// local maps the IDs to delete to their local file, if known
+	local := map[int]string{}
+	for _, s := range utils.ParseCommaSeparatedIDs(opts.ids) {
+		id, err := strconv.Atoi(s)
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid monitor ID %q", s)
+		}
+		local[id] = ""
+	}
+	if opts.removeLocal && len(local) > 0 {
+		dir := templating.ExtractStaticPrefix(settings.MonitorsPathTemplate)
+		found, err := resource.ExtractLocalIDs(backend, dir, "id", resource.IDNumeric)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to scan %s: %w", dir, err)
+		}
+		for s, path := range found {
+			id, _ := strconv.Atoi(s)
+			if _, ok := local[id]; ok {
+				local[id] = path
+			}
+		}
+	}
+	for _, path := range opts.paths {
+		found, err := resource.ExtractLocalIDs(backend, path, "id", resource.IDNumeric)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if len(found) == 0 {
+			return fmt.Errorf("%s: no file with a valid id", path)
+		}
+		for s, p := range found {
+			id, _ := strconv.Atoi(s)
+			local[id] = p
+		}
+	}
+
+	ids := make([]int, 0, len(local))
+	for id := range local {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	if opts.limit != nil {
+		settings.MaxResources = *opts.limit
+	}
+	if err := resource.CheckLimit("monitors", "deleted", len(ids), settings.MaxResources); err != nil {
+		return err
+	}
+
+	client := internalhttp.GetHTTPClient(settings)
+	var (
+		deleted int
+		errs    []error
+	)
+	for _, id := range ids {
+		if err := monitors.DeleteMonitor(ctx, client, settings, id, opts.force); err != nil {
+			logging.Logger.Error("delete failed", "id", id, "error", err)
+			errs = append(errs, &resource.TargetError{ID: strconv.Itoa(id), Path: local[id], Err: err})
+			continue
+		}
+		deleted++
+		logging.Logger.Info("monitor deleted", "id", id)
+		if opts.removeLocal && local[id] != "" {
+			if err := removeLocal(local[id]); err != nil {
+				errs = append(errs, &resource.TargetError{ID: strconv.Itoa(id), Path: local[id], Err: err})
+			}
+		}
+	}
+
+	logging.Logger.Info("monitors deleted", "deleted", deleted, "failed", len(errs))
+	if len(errs) > 0 {
+		return &resource.FailedError{Kind: "monitor", Verb: "delete", Errs: errs}
+	}
+	return nil
+}
+
+// removeLocal removes a deleted monitor's file and any companion files.
+func removeLocal(path string) error {
+	removed, err := storage.RemoveWithCompanions(path)
+	for _, p := range removed {
+		logging.Logger.Info("local file removed", "path", p)
+	}
+	if err != nil {
+		return fmt.Errorf("deleted, but %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+// Package monitors holds the subcommands that only apply to monitors.
+package monitors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/commands/version"
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/policy"
+	"github.com/AD7six/dd-tf/internal/report"
+	"github.com/spf13/cobra"
+)
+
+// NewLintCmd creates the lint command, which checks local monitors against
+// the organisation's policy file.
+func NewLintCmd() *cobra.Command {
+	var (
+		path       string
+		policyPath string
+		format     string
+		initPolicy bool
+		disable    []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Check local monitors against a policy file (offline)",
+		Long: `Check every monitor under --path against the rules in --policy: required
+tag keys, allowed priorities, regexes the message must match, forbidden
+notification targets, email addresses notified directly, which monitors
+must enable notify_no_data and the bounds of renotify_interval. Violations
+are listed grouped by rule and the exit code is non-zero. --disable skips
+rules by ID, e.g. --disable priority,email-target.
+
+--init writes a starter policy to --policy to edit.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if initPolicy {
+				return runInit(policyPath)
+			}
+			return runLint(path, policyPath, format, disable)
+		},
+	}
+
+	cmd.Flags().StringVar(&path, "path", "", "Directory to check (default: DATA_DIR)")
+	cmd.Flags().StringVar(&policyPath, "policy", policy.DefaultPath, "Policy file")
+	cmd.Flags().StringVar(&format, "format", report.FormatText, "Output format: text, json, junit or sarif")
+	cmd.Flags().BoolVar(&initPolicy, "init", false, "Write a starter policy file and exit")
+	cmd.Flags().StringSliceVar(&disable, "disable", nil, "Rule IDs not to check (comma-separated or repeated): "+strings.Join(policy.Rules(), ", "))
+
+	return cmd
+}
+
+func runInit(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(policy.Starter), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	logging.Logger.Info("policy written", "path", path)
+	return nil
+}
+
+func runLint(path, policyPath, format string, disable []string) error {
+	switch format {
+	case report.FormatText, report.FormatJSON, report.FormatJUnit, report.FormatSARIF:
+	default:
+		return fmt.Errorf("invalid --format %q (expected text, json, junit or sarif)", format)
+	}
+	if format != report.FormatText {
+		logging.ReserveStdout()
+	}
+
+	if _, err := config.LoadOfflineSettings(); err != nil {
+		return err
+	}
+	if path == "" {
+		path = os.Getenv("DATA_DIR")
+	}
+
+	p, err := policy.Load(policyPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("no policy at %s (create one with --init)", policyPath)
+	}
+	if err != nil {
+		return err
+	}
+	if err := p.Disable(disable...); err != nil {
+		return err
+	}
+
+	result, err := policy.Lint(path, p)
+	if err != nil {
+		return err
+	}
+	if format == report.FormatText {
+		err = writeByRule(os.Stdout, result.Violations)
+	} else {
+		err = report.Write(os.Stdout, format, toReport(result))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	if len(result.Violations) > 0 {
+		return fmt.Errorf("%d policy violation(s) found in %s", len(result.Violations), path)
+	}
+	logging.Logger.Info("linted", "path", path, "monitors", len(result.Files))
+	return nil
+}
+
+// writeByRule lists violations under a heading per rule, in rule order.
+func writeByRule(w io.Writer, violations []policy.Violation) error {
+	byRule := map[string][]policy.Violation{}
+	for _, v := range violations {
+		byRule[v.Rule] = append(byRule[v.Rule], v)
+	}
+	rules := make([]string, 0, len(byRule))
+	for rule := range byRule {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+
+	for _, rule := range rules {
+		if _, err := fmt.Fprintf(w, "%s (%d):\n", rule, len(byRule[rule])); err != nil {
+			return err
+		}
+		for _, v := range byRule[rule] {
+			if _, err := fmt.Fprintf(w, "  %s: %s\n", v.Path, v.Message); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// toReport converts a lint result into the shared report shape, one item
+// per monitor with its violations as findings.
+func toReport(result *policy.Result) *report.Result {
+	items := make([]report.Item, 0, len(result.Files))
+	index := make(map[string]int, len(result.Files))
+	for _, f := range result.Files {
+		index[f.Path] = len(items)
+		items = append(items, report.Item{Path: f.Path, Kind: resource.KindMonitor, ID: f.ID})
+	}
+	for _, v := range result.Violations {
+		i := index[v.Path]
+		items[i].Findings = append(items[i].Findings, report.Finding{Rule: v.Rule, Message: v.Message})
+	}
+	return &report.Result{Command: "monitors lint", Version: version.Version, Items: items}
+}
@@ -0,0 +1,164 @@
+package monitors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/monitors"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// validateWorkers is how many monitors are validated at once.
+const validateWorkers = 4
+
+// NewValidateCmd creates the validate command, which checks local monitors
+// with the API's validation endpoint.
+func NewValidateCmd() *cobra.Command {
+	var path string
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check local monitors with the Datadog API without changing them",
+		Long: `Send every monitor under --path (a file or a directory) to Datadog's
+monitor validation endpoint, which checks a definition without creating or
+changing anything. Monitors are sent as push would send them. Each file is
+reported as passing or failing, with the API's error messages, and the exit
+code is non-zero if any failed.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runValidate(cmd.Context(), path)
+		},
+	}
+
+	cmd.Flags().StringVar(&path, "path", "", "Monitor file or directory to validate")
+	cmd.MarkFlagRequired("path")
+
+	return cmd
+}
+
+// validation is the outcome of validating one file.
+type validation struct {
+	path     string
+	messages []string // empty if the monitor passed
+}
+
+func runValidate(ctx context.Context, path string) error {
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+	paths, err := monitorFiles(path)
+	if err != nil {
+		return err
+	}
+	client := internalhttp.GetHTTPClient(settings)
+
+	results := make([]validation, len(paths))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < validateWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				results[i] = validateFile(ctx, client, settings, paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	failed := 0
+	for _, r := range results {
+		if len(r.messages) > 0 {
+			failed++
+		}
+	}
+	if err := writeValidations(os.Stdout, results); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d monitor(s) failed validation", failed, len(results))
+	}
+	logging.Logger.Info("validated", "path", path, "monitors", len(results))
+	return nil
+}
+
+// monitorFiles returns the monitor files under path, a file or directory,
+// in path order.
+func monitorFiles(path string) ([]string, error) {
+	files := storage.FileBackend{}
+	all, err := files.List(path)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, p := range all {
+		if !strings.HasSuffix(p, ".json") || storage.IsSidecarPath(p) {
+			continue
+		}
+		data, err := files.Read(p)
+		if err != nil {
+			return nil, err
+		}
+		var content map[string]any
+		// Invalid files are kept, to be reported as failures
+		if json.Unmarshal(data, &content) == nil && resource.GuessKind(content) != resource.KindMonitor {
+			continue
+		}
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// validateFile validates one monitor file, turning any error into messages.
+func validateFile(ctx context.Context, client resource.WriteClient, settings *config.Settings, path string) validation {
+	raw, err := os.ReadFile(path)
+	if err == nil {
+		err = monitors.ValidateMonitor(ctx, client, settings, raw)
+	}
+	if err == nil {
+		return validation{path: path}
+	}
+	var apiErr *resource.APIError
+	if errors.As(err, &apiErr) && len(apiErr.Messages()) > 0 {
+		return validation{path: path, messages: apiErr.Messages()}
+	}
+	return validation{path: path, messages: []string{err.Error()}}
+}
+
+// writeValidations lists each file as PASS or FAIL, with the reasons for
+// failures indented under it.
+func writeValidations(w io.Writer, results []validation) error {
+	for _, r := range results {
+		status := "PASS"
+		if len(r.messages) > 0 {
+			status = "FAIL"
+		}
+		if _, err := fmt.Fprintf(w, "%s %s\n", status, r.path); err != nil {
+			return err
+		}
+		for _, m := range r.messages {
+			if _, err := fmt.Fprintf(w, "  %s\n", m); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/dashboards"
+	"github.com/AD7six/dd-tf/internal/duplicates"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+// NewDuplicatesCmd creates the duplicates report, which groups dashboards
+// that look like copies of each other.
+func NewDuplicatesCmd() *cobra.Command {
+	var (
+		path   string
+		format string
+		remote bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "duplicates",
+		Short: "Group dashboards with the same title or the same widgets",
+		Long: `Group the dashboards under --path that have exactly the same title, and
+those with the same widgets once widget IDs are ignored, listing each group's
+IDs, paths and modification dates so owners can consolidate them.
+
+--remote groups by title using the dashboards list endpoint instead of local
+files, without downloading any dashboard. Content isn't compared remotely.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if remote && cmd.Flags().Changed("path") {
+				return fmt.Errorf("--path and --remote can't be combined")
+			}
+			return runDuplicates(cmd, path, format, remote)
+		},
+	}
+
+	cmd.Flags().StringVar(&path, "path", "", "Directory to scan (default: DATA_DIR)")
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table or json")
+	cmd.Flags().BoolVar(&remote, "remote", false, "Group dashboards by title from the API list instead of local files")
+
+	return cmd
+}
+
+func runDuplicates(cmd *cobra.Command, path, format string, remote bool) error {
+	if format != "table" && format != "json" {
+		return fmt.Errorf("invalid --format %q (expected table or json)", format)
+	}
+	logging.ReserveStdout()
+
+	var groups []duplicates.Group
+	if remote {
+		settings, err := config.LoadSettings()
+		if err != nil {
+			return err
+		}
+		summaries, err := dashboards.ListDashboards(cmd.Context(), internalhttp.GetHTTPClient(settings), settings)
+		if err != nil {
+			return err
+		}
+		list := make([]duplicates.Dashboard, 0, len(summaries))
+		for _, s := range summaries {
+			list = append(list, duplicates.Dashboard{ID: s.ID, Title: s.Title, ModifiedAt: s.ModifiedAt})
+		}
+		groups = duplicates.ByTitle(list)
+	} else {
+		if _, err := config.LoadOfflineSettings(); err != nil {
+			return err
+		}
+		if path == "" {
+			path = os.Getenv("DATA_DIR")
+		}
+		var err error
+		if groups, err = duplicates.Run(path); err != nil {
+			return err
+		}
+	}
+
+	if format == "json" {
+		if groups == nil {
+			groups = []duplicates.Group{}
+		}
+		return writeJSON(os.Stdout, groups)
+	}
+	return writeDuplicatesTable(os.Stdout, groups)
+}
+
+// writeDuplicatesTable prints one row per dashboard, numbering the groups.
+// Content hashes are shortened; the JSON output has them in full.
+func writeDuplicatesTable(w io.Writer, groups []duplicates.Group) error {
+	rows := [][]string{{"group", "match", "key", "id", "modified", "path"}}
+	for i, g := range groups {
+		key := g.Key
+		if g.Match == duplicates.MatchContent && len(key) > 12 {
+			key = key[:12]
+		}
+		for _, d := range g.Dashboards {
+			rows = append(rows, []string{strconv.Itoa(i + 1), g.Match, key, d.ID, d.ModifiedAt, d.Path})
+		}
+	}
+	return writeRows(w, "table", rows)
+}
@@ -0,0 +1,105 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/references"
+	"github.com/spf13/cobra"
+)
+
+// NewReferencesCmd creates the references report, which lists references
+// between resources whose target isn't in the local files.
+func NewReferencesCmd() *cobra.Command {
+	var (
+		path        string
+		format      string
+		checkRemote bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "references",
+		Short: "List references to monitors, SLOs and dashboards that aren't downloaded",
+		Long: `Walk the files under --path, extract the IDs they use to refer to other
+resources (monitors in alert graph and alert value widgets, SLOs in SLO
+widgets, dashboards and monitors in custom links, monitors in composite
+monitor queries, SLOs in SLO alert queries) and list the references to
+resources that aren't in the local files.
+
+SLOs aren't downloaded by dd-tf, so SLO references are "unchecked" unless
+--check-remote looks them up. With --check-remote, references missing locally
+are looked up with the API and reported as "missing" if they don't exist
+there either.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReferences(cmd.Context(), path, format, checkRemote)
+		},
+	}
+
+	cmd.Flags().StringVar(&path, "path", "", "Directory to scan (default: DATA_DIR)")
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table or json")
+	cmd.Flags().BoolVar(&checkRemote, "check-remote", false, "Look up references missing locally with the API")
+
+	return cmd
+}
+
+func runReferences(ctx context.Context, path, format string, checkRemote bool) error {
+	if format != "table" && format != "json" {
+		return fmt.Errorf("invalid --format %q (expected table or json)", format)
+	}
+	logging.ReserveStdout()
+
+	var (
+		settings *config.Settings
+		err      error
+		opts     references.Options
+	)
+	if checkRemote {
+		settings, err = config.LoadSettings()
+	} else {
+		settings, err = config.LoadOfflineSettings()
+	}
+	if err != nil {
+		return err
+	}
+	if checkRemote {
+		opts.Remote = references.RemoteChecker(internalhttp.GetHTTPClient(settings), settings)
+	}
+	if path == "" {
+		path = os.Getenv("DATA_DIR")
+	}
+
+	result, err := references.Run(ctx, path, opts)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return writeJSON(os.Stdout, result)
+	}
+	return writeReferencesTable(os.Stdout, result)
+}
+
+// writeReferencesTable prints one reference per line, grouped by file.
+func writeReferencesTable(w io.Writer, result references.Result) error {
+	paths := make([]string, 0, len(result))
+	for p := range result {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tKIND\tID\tSTATUS\tFIELD")
+	for _, p := range paths {
+		for _, ref := range result[p] {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", p, ref.Kind, ref.ID, ref.Status, ref.Field)
+		}
+	}
+	return tw.Flush()
+}
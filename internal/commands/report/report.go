@@ -0,0 +1,19 @@
+// Package report holds the report subcommands, which summarise local
+// exports.
+package report
+
+import "github.com/spf13/cobra"
+
+// NewReportCmd creates the report command and its subcommands.
+func NewReportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Summarise downloaded resources",
+	}
+
+	cmd.AddCommand(NewReferencesCmd())
+	cmd.AddCommand(NewDuplicatesCmd())
+	cmd.AddCommand(NewTagsCmd())
+
+	return cmd
+}
@@ -0,0 +1,156 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/tagreport"
+	"github.com/AD7six/dd-tf/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// missingValue stands for resources without a key in table and CSV output.
+const missingValue = "(missing)"
+
+// NewTagsCmd creates the tags report, which counts tag values across local
+// dashboards and monitors.
+func NewTagsCmd() *cobra.Command {
+	var (
+		path    string
+		key     string
+		format  string
+		require string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "tags",
+		Short: "Count tag keys and values across downloaded dashboards and monitors",
+		Long: `Aggregate the key:value tags of every dashboard and monitor under --path and
+print, per tag key, each value with the number of resources using it and the
+number of resources without the key. --key limits the report to one key.
+
+--require team,env instead lists every resource missing one of the keys, and
+exits non-zero if there are any.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTags(path, key, format, require)
+		},
+	}
+
+	cmd.Flags().StringVar(&path, "path", "", "Directory to scan (default: DATA_DIR)")
+	cmd.Flags().StringVar(&key, "key", "", "Only report this tag key")
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table, json or csv")
+	cmd.Flags().StringVar(&require, "require", "", "Comma-separated tag keys; list resources missing any of them")
+
+	return cmd
+}
+
+func runTags(path, key, format, require string) error {
+	switch format {
+	case "table", "json", "csv":
+	default:
+		return fmt.Errorf("invalid --format %q (expected table, json or csv)", format)
+	}
+	logging.ReserveStdout()
+
+	if _, err := config.LoadOfflineSettings(); err != nil {
+		return err
+	}
+	if path == "" {
+		path = os.Getenv("DATA_DIR")
+	}
+
+	required := utils.ParseCommaSeparatedIDs(require)
+	result, err := tagreport.Run(path, tagreport.Options{Key: key, Require: required})
+	if err != nil {
+		return err
+	}
+
+	if len(required) > 0 {
+		if err := writeMissingTags(os.Stdout, format, result.Missing); err != nil {
+			return err
+		}
+		if len(result.Missing) > 0 {
+			return fmt.Errorf("%d resource(s) missing required tags", len(result.Missing))
+		}
+		return nil
+	}
+	return writeTagUsage(os.Stdout, format, result)
+}
+
+// writeTagUsage prints one row per key and value, plus a row counting the
+// resources without the key.
+func writeTagUsage(w io.Writer, format string, result *tagreport.Result) error {
+	if format == "json" {
+		return writeJSON(w, result)
+	}
+	rows := [][]string{{"key", "value", "count"}}
+	for _, usage := range result.Keys {
+		for _, v := range usage.Values {
+			rows = append(rows, []string{usage.Key, v.Value, strconv.Itoa(v.Count)})
+		}
+		rows = append(rows, []string{usage.Key, missingValue, strconv.Itoa(usage.Missing)})
+	}
+	if err := writeRows(w, format, rows); err != nil {
+		return err
+	}
+	if format == "table" {
+		fmt.Fprintf(w, "\n%d resource(s), %d without any key:value tag\n", result.Resources, result.Untagged)
+	}
+	return nil
+}
+
+// writeMissingTags prints one row per resource missing required keys.
+func writeMissingTags(w io.Writer, format string, missing []tagreport.Missing) error {
+	if format == "json" {
+		if missing == nil {
+			missing = []tagreport.Missing{}
+		}
+		return writeJSON(w, missing)
+	}
+	rows := [][]string{{"path", "kind", "id", "missing"}}
+	for _, m := range missing {
+		rows = append(rows, []string{m.Path, m.Kind, m.ID, strings.Join(m.Keys, ",")})
+	}
+	return writeRows(w, format, rows)
+}
+
+// writeRows prints rows, the first being the header, as an aligned table or
+// as CSV.
+func writeRows(w io.Writer, format string, rows [][]string) error {
+	if format == "csv" {
+		cw := csv.NewWriter(w)
+		if err := cw.WriteAll(rows); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+		return nil
+	}
+	rows[0] = upper(rows[0])
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func upper(row []string) []string {
+	out := make([]string, len(row))
+	for i, s := range row {
+		out[i] = strings.ToUpper(s)
+	}
+	return out
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,421 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AD7six/dd-tf/internal/commands/version"
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/diff"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/templating"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/notify"
+	"github.com/AD7six/dd-tf/internal/report"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// diffWorkers is how many resources are fetched and compared at once.
+const diffWorkers = 4
+
+// Diff output formats accepted by --format.
+const (
+	formatUnified   = "unified"
+	formatJSONPatch = "json-patch"
+)
+
+// diffOptions are the flags of the diff command.
+type diffOptions struct {
+	resource.PushOptions
+	Against   string // Remote ID to compare the single selected file with
+	ExitCode  bool
+	Format    string // formatUnified or formatJSONPatch
+	Direction string // A diff.Direction, for formatJSONPatch
+	Report    string // report.FormatJUnit or report.FormatSARIF, replacing Format's output
+	Notify    notify.Options
+}
+
+// NewDiffCmd creates the diff command for a kind, which compares downloaded
+// files with the remote resources.
+func NewDiffCmd(k resource.Kind, c resource.Comparable) *cobra.Command {
+	var opts diffOptions
+
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show how downloaded " + k.Plural() + " differ from Datadog",
+		Long: `Compare local ` + k.Name() + ` files with the remote ` + k.Plural() + ` with the same IDs,
+both normalized as downloads are and ignoring the fields Datadog sets itself,
+and print a unified diff for each one that differs. Select files with --path
+(files or directories), --id (looked up under the path template's directory)
+or --all (every file under it).
+
+` + strings.ToUpper(k.Plural()[:1]) + k.Plural()[1:] + ` that match are reported as in sync, and those that only exist
+locally or only remotely are listed; with --all every remote ` + k.Name() + ` is
+checked for a local file. --against compares a single --path file with the
+remote ` + k.Name() + ` with another ID, e.g. a clone with its original. With
+--exit-code the command exits 0 when every ` + k.Name() + ` is in sync, 1 when any
+differs and 2 on errors, like git diff.
+
+--format json-patch prints a JSON array with each ` + k.Name() + `'s status and
+the RFC 6902 operations turning the remote ` + k.Name() + ` into the local one, or
+the reverse with --direction local-to-remote. Lists are patched item by
+item, by index. --report-format junit|sarif writes a JUnit or SARIF report
+instead, for CI test and code-scanning views.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateIDs(k, opts.IDs); err != nil {
+				return err
+			}
+			if opts.Against != "" {
+				if len(opts.Paths) != 1 || opts.IDs != "" || opts.All {
+					return fmt.Errorf("--against needs a single --path file, and no --id or --all")
+				}
+				if err := validateIDs(k, opts.Against); err != nil {
+					return err
+				}
+			}
+			cmd.SilenceUsage = true
+			err := runDiff(cmd.Context(), k, c, opts)
+			if opts.ExitCode {
+				return resource.WithExitCode(err)
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&opts.Paths, "path", nil, "Local "+k.Name()+" file(s) or directories to compare (comma-separated or repeated)")
+	cmd.Flags().StringVar(&opts.IDs, "id", "", strings.ToUpper(k.Name()[:1])+k.Name()[1:]+" ID(s) to compare with their downloaded files (comma-separated)")
+	cmd.Flags().BoolVar(&opts.All, "all", false, "Compare every downloaded "+k.Name()+", and list remote ones not downloaded")
+	cmd.Flags().StringVar(&opts.Against, "against", "", "Compare the --path file with the remote "+k.Name()+" with this ID instead of the file's")
+	cmd.Flags().StringVar(&opts.Format, "format", formatUnified, "Output format: unified or json-patch")
+	cmd.Flags().StringVar(&opts.Direction, "direction", string(diff.RemoteToLocal), "Which side json-patch operations apply to: remote-to-local or local-to-remote")
+	cmd.Flags().StringVar(&opts.Report, "report-format", "", "Write a junit or sarif report instead of --format's")
+	cmd.Flags().BoolVar(&opts.ExitCode, "exit-code", false, "Exit 1 if any "+k.Name()+" differs and 2 on errors, 0 if all are in sync")
+	notify.AddFlags(cmd, &opts.Notify)
+
+	return cmd
+}
+
+// comparison is the result of comparing one resource.
+type comparison struct {
+	ID      string           `json:"id"`
+	Against string           `json:"against,omitempty"` // The remote ID compared with, if not ID
+	Path    string           `json:"path,omitempty"`
+	Status  string           `json:"status"` // "in sync", "differs", "only local" or "only remote"
+	Diff    string           `json:"-"`
+	Summary string           `json:"-"` // The fields changed, with --report-format
+	Patch   []diff.Operation `json:"patch,omitempty"`
+}
+
+func runDiff(ctx context.Context, k resource.Kind, c resource.Comparable, opts diffOptions) error {
+	start := time.Now()
+	switch opts.Format {
+	case formatUnified, formatJSONPatch:
+	default:
+		return fmt.Errorf("invalid --format %q (expected %s or %s)", opts.Format, formatUnified, formatJSONPatch)
+	}
+	direction, err := diff.ParseDirection(opts.Direction)
+	if err != nil {
+		return err
+	}
+	switch opts.Report {
+	case "", report.FormatJUnit, report.FormatSARIF:
+	default:
+		return fmt.Errorf("invalid --report-format %q (expected junit or sarif)", opts.Report)
+	}
+	logging.ReserveStdout()
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+	backend, err := storage.NewBackend(settings)
+	if err != nil {
+		return err
+	}
+	dir := templating.ExtractStaticPrefix(k.PathTemplate(settings))
+	if dir == "" && (opts.All || opts.IDs != "") {
+		return fmt.Errorf("path template %q has no static directory to scan; use --path", k.PathTemplate(settings))
+	}
+	client := internalhttp.GetHTTPClient(settings)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	targets, err := resource.PushTargets(ctx, backend, dir, k.IDKind(), opts.PushOptions)
+	if err != nil {
+		return err
+	}
+	if opts.Against != "" {
+		if targets, err = singleTarget(targets); err != nil {
+			return err
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		results []comparison
+		errs    []error
+		local   = map[string]bool{}
+		wg      sync.WaitGroup
+		work    = make(chan resource.Target[string])
+	)
+	record := func(result comparison, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		results = append(results, result)
+	}
+	compare := func(ctx context.Context, target resource.Target[string]) (comparison, error) {
+		local, remote, err := c.Compare(ctx, client, settings, backend, target.Path, opts.Against)
+		if err == nil {
+			return diffTarget(target, opts, direction, local, remote, c.IgnoredFields())
+		}
+		if errors.Is(err, resource.ErrNotFound) && opts.Against == "" {
+			return comparison{ID: target.ID, Path: target.Path, Status: "only local"}, nil
+		}
+		return comparison{}, &resource.TargetError{ID: target.ID, Path: target.Path, Err: err}
+	}
+	for i := 0; i < diffWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for target := range work {
+				record(compare(ctx, target))
+			}
+		}()
+	}
+	for result := range targets {
+		if result.Err != nil {
+			var targetErr *resource.TargetError
+			if errors.As(result.Err, &targetErr) && targetErr.ID != "" && errors.Is(result.Err, resource.ErrNotFound) {
+				// An --id with no local file
+				local[targetErr.ID] = true
+				record(remoteOnly(ctx, k, client, settings, targetErr.ID))
+				continue
+			}
+			record(comparison{}, result.Err)
+			continue
+		}
+		local[result.Target.ID] = true
+		work <- result.Target
+	}
+	close(work)
+	wg.Wait()
+
+	if opts.All && len(errs) == 0 {
+		remote, err := listRemoteIDs(ctx, k, client, settings)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list %s: %w", k.Plural(), err))
+		}
+		for id := range remote {
+			if !local[id] {
+				results = append(results, comparison{ID: id, Status: "only remote"})
+			}
+		}
+	}
+
+	if opts.Report != "" {
+		err = report.Write(os.Stdout, opts.Report, comparisonsReport(k, results, errs))
+	} else {
+		err = writeComparisons(os.Stdout, opts.Format, results)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write diffs: %w", err)
+	}
+	for _, err := range errs {
+		var targetErr *resource.TargetError
+		if errors.As(err, &targetErr) && targetErr.Path != "" {
+			logging.Logger.Error("failed to compare "+k.Name(), "path", targetErr.Path, "error", err)
+			continue
+		}
+		logging.Logger.Error("failed to compare "+k.Name(), "error", err)
+	}
+	name := strings.ToUpper(k.Name()[:1]) + k.Name()[1:]
+	var drifted []string
+	for _, r := range results {
+		switch r.Status {
+		case "in sync":
+			continue
+		case "differs":
+			logging.Logger.Error(name+" "+r.ID+" drifted from remote", "path", r.Path)
+		case "only local":
+			logging.Logger.Warn(name+" "+r.ID+" only exists locally, deleted from remote", "path", r.Path)
+		case "only remote":
+			logging.Logger.Warn(name + " " + r.ID + " only exists remotely, not downloaded")
+		}
+		drifted = append(drifted, r.ID)
+	}
+	notify.Finish(opts.Notify, notify.NewSummary(k.Plural()+" diff", len(results)+len(errs), resource.TargetErrorIDs(errs), drifted, len(errs), time.Since(start)))
+	if len(errs) > 0 {
+		return &resource.FailedError{Kind: k.Name(), Verb: "diff", Errs: errs}
+	}
+	if differ := countDifferences(results); opts.ExitCode && differ > 0 {
+		return &resource.DifferencesError{Kind: k.Name(), Count: differ}
+	}
+	return nil
+}
+
+// singleTarget returns a channel with the only target in targets, or an
+// error if there are none or several, as --against compares one file.
+func singleTarget(targets <-chan resource.TargetResult[string]) (<-chan resource.TargetResult[string], error) {
+	var all []resource.TargetResult[string]
+	for result := range targets {
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		all = append(all, result)
+	}
+	if len(all) != 1 {
+		return nil, fmt.Errorf("--against compares a single file, but --path selected %d", len(all))
+	}
+	ch := make(chan resource.TargetResult[string], 1)
+	ch <- all[0]
+	close(ch)
+	return ch, nil
+}
+
+// diffTarget compares the normalized local and remote resources of target,
+// as a unified diff or a JSON Patch depending on the format.
+func diffTarget(target resource.Target[string], opts diffOptions, direction diff.Direction, local, remote []byte, ignore []string) (comparison, error) {
+	result := comparison{ID: target.ID, Against: opts.Against, Path: target.Path, Status: "differs"}
+	var (
+		differs bool
+		err     error
+	)
+	if opts.Report != "" {
+		if result.Summary, err = diff.Summary(remote, local, ignore...); err != nil {
+			return result, &resource.TargetError{ID: target.ID, Path: target.Path, Err: err}
+		}
+	}
+	if opts.Format == formatJSONPatch {
+		if direction == diff.LocalToRemote {
+			local, remote = remote, local
+		}
+		result.Patch, err = diff.Patch(remote, local, ignore...)
+		differs = len(result.Patch) > 0
+	} else {
+		remoteName, localName := "remote "+target.Path, target.Path
+		if opts.Against != "" {
+			// Both IDs, so a reviewer sees what was compared
+			remoteName, localName = "remote "+opts.Against, fmt.Sprintf("%s (%s)", target.Path, target.ID)
+		}
+		result.Diff, err = diff.JSON(remoteName, localName, remote, local, ignore...)
+		differs = result.Diff != ""
+	}
+	if err != nil {
+		return result, &resource.TargetError{ID: target.ID, Path: target.Path, Err: err}
+	}
+	if !differs {
+		result.Status = "in sync"
+	}
+	return result, nil
+}
+
+// remoteOnly checks that the resource id, which has no local file, exists
+// remotely.
+func remoteOnly(ctx context.Context, k resource.Kind, client resource.HTTPClient, settings *config.Settings, id string) (comparison, error) {
+	if _, err := k.Fetch(ctx, client, settings, id); err != nil {
+		if errors.Is(err, resource.ErrNotFound) {
+			err = fmt.Errorf("not found locally or remotely: %w", err)
+		}
+		return comparison{}, &resource.TargetError{ID: id, Err: err}
+	}
+	return comparison{ID: id, Status: "only remote"}, nil
+}
+
+// countDifferences returns how many resources differ or only exist on one
+// side.
+func countDifferences(results []comparison) int {
+	n := 0
+	for _, r := range results {
+		if r.Status != "in sync" {
+			n++
+		}
+	}
+	return n
+}
+
+// sortComparisons sorts results by path then ID, with remote-only resources,
+// without a path, last.
+func sortComparisons(results []comparison) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Path != results[j].Path {
+			return results[j].Path == "" || (results[i].Path != "" && results[i].Path < results[j].Path)
+		}
+		return results[i].ID < results[j].ID
+	})
+}
+
+// comparisonsReport converts results and the failures to compare into the
+// shared report shape for --report-format, with the rule ids drift uses.
+func comparisonsReport(k resource.Kind, results []comparison, errs []error) *report.Result {
+	sortComparisons(results)
+	name := strings.ToUpper(k.Name()[:1]) + k.Name()[1:]
+	items := make([]report.Item, 0, len(results)+len(errs))
+	for _, r := range results {
+		item := report.Item{Path: r.Path, Kind: k.Name(), ID: r.ID}
+		switch r.Status {
+		case "differs":
+			item.Findings = []report.Finding{{Rule: "drifted", Message: name + " " + r.ID + " drifted from remote: " + r.Summary}}
+		case "only local":
+			item.Findings = []report.Finding{{Rule: "missing-remote", Message: name + " " + r.ID + " only exists locally, deleted from remote"}}
+		case "only remote":
+			item.Findings = []report.Finding{{Rule: "missing-local", Message: name + " " + r.ID + " only exists remotely, not downloaded"}}
+		}
+		items = append(items, item)
+	}
+	for _, err := range errs {
+		item := report.Item{Kind: k.Name(), Findings: []report.Finding{{Rule: "compare-failed", Message: err.Error()}}}
+		var targetErr *resource.TargetError
+		if errors.As(err, &targetErr) {
+			item.Path, item.ID = targetErr.Path, targetErr.ID
+		}
+		items = append(items, item)
+	}
+	return &report.Result{Command: k.Plural() + " diff", Version: version.Version, Items: items}
+}
+
+// writeComparisons prints each diff, or the resource's status, in path
+// order, then the resources only found remotely in ID order. The json-patch
+// format prints them all as one JSON array.
+func writeComparisons(w io.Writer, format string, results []comparison) error {
+	sortComparisons(results)
+	if format == formatJSONPatch {
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if results == nil {
+			results = []comparison{}
+		}
+		return enc.Encode(results)
+	}
+	for _, r := range results {
+		var err error
+		switch {
+		case r.Diff != "":
+			_, err = io.WriteString(w, r.Diff)
+		case r.Path == "":
+			_, err = fmt.Fprintf(w, "%s: %s\n", r.ID, r.Status)
+		case r.Against != "":
+			_, err = fmt.Fprintf(w, "%s (%s) against remote %s: %s\n", r.Path, r.ID, r.Against, r.Status)
+		default:
+			_, err = fmt.Fprintf(w, "%s: %s\n", r.Path, r.Status)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
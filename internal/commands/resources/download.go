@@ -0,0 +1,352 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AD7six/dd-tf/internal/commands/version"
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/git"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/notify"
+	"github.com/AD7six/dd-tf/internal/statsd"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/AD7six/dd-tf/internal/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// NewDownloadCmd creates the download command for a kind. It supports
+// selecting resources by ID (--id), team (--team), tags (--tags), all
+// resources (--all), updating existing files (--update), and any flags the
+// kind adds itself.
+func NewDownloadCmd(k resource.Kind) *cobra.Command {
+	var (
+		opts          resource.BaseDownloadOptions
+		kindFlags     *pflag.FlagSet
+		downloadFlags = pflag.NewFlagSet(k.Name()+" download", pflag.ContinueOnError)
+		downloader    = resource.Downloader[string]{Kind: k.Name()}
+		notifyOpts    notify.Options
+		gitOpts       git.Options
+		stdoutFlag    bool
+		archivePath   string
+		maxResources  int
+		managed       managedOptions
+		watch         bool
+		interval      time.Duration
+		noCache       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "download",
+		Short: "Download Datadog " + k.Plural() + " by ID, team, tags, or all",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if stdoutFlag && archivePath != "" {
+				return fmt.Errorf("--stdout and --archive can't be combined")
+			}
+			if gitOpts.Commit && (stdoutFlag || archivePath != "") {
+				return fmt.Errorf("--git-commit can't be combined with --stdout or --archive")
+			}
+			if downloader.DryRun && (stdoutFlag || archivePath != "" || gitOpts.Commit) {
+				return fmt.Errorf("--dry-run can't be combined with --stdout, --archive or --git-commit")
+			}
+			if err := validateIDs(k, opts.IDs); err != nil {
+				return err
+			}
+			if managed.Only && (opts.IDs != "" || opts.Update) {
+				return fmt.Errorf("--managed-only can't be combined with --id or --update")
+			}
+			if watch && (stdoutFlag || archivePath != "" || downloader.DryRun || gitOpts.Commit) {
+				return fmt.Errorf("--watch can't be combined with --stdout, --archive, --dry-run or --git-commit")
+			}
+			if watch && interval <= 0 {
+				return fmt.Errorf("--interval must be more than 0")
+			}
+			if stdoutFlag {
+				return runStdout(cmd.Context(), k, opts, kindFlags, downloadFlags)
+			}
+			ctx := cmd.Context()
+			if noCache {
+				ctx = internalhttp.WithoutCache(ctx)
+			}
+			var limit *int // nil: MAX_RESOURCES
+			if cmd.Flags().Changed("max-resources") {
+				if maxResources < 0 {
+					return fmt.Errorf("--max-resources must be 0 (no limit) or more")
+				}
+				limit = &maxResources
+			}
+			if watch {
+				// Interrupting is how a watch stops, not a failure
+				if cmd.Annotations == nil {
+					cmd.Annotations = map[string]string{}
+				}
+				cmd.Annotations[resource.AnnotationRunsUntilInterrupted] = "true"
+				return runWatch(ctx, k, opts, kindFlags, downloadFlags, downloader, interval, limit, managed)
+			}
+			return runDownload(ctx, k, opts, kindFlags, downloadFlags, downloader, notifyOpts, archivePath, gitOpts, limit, managed)
+		},
+	}
+
+	kindFlags = addSelectionFlags(cmd, k, &opts)
+	cmd.Flags().StringVar(&opts.OutputPath, "output", "", outputHelp(k))
+	cmd.Flags().BoolVar(&stdoutFlag, "stdout", false, "Print a single "+k.Name()+" (requires one --id) to stdout instead of writing a file")
+	cmd.Flags().StringVar(&archivePath, "archive", "", "Write all "+k.Plural()+" into this .tar.gz (with a manifest.json) instead of individual files")
+	cmd.Flags().BoolVar(&downloader.DryRun, "dry-run", false, "List the "+k.Plural()+" that would be downloaded without downloading them")
+	cmd.Flags().BoolVarP(&downloader.Quiet, "quiet", "q", false, "Only log failures, not each "+k.Name())
+	cmd.Flags().IntVar(&maxResources, "max-resources", 0, "Abort before downloading anything when more than this many "+k.Plural()+" are selected, except with --all (default: MAX_RESOURCES)")
+	cmd.Flags().BoolVar(&watch, "watch", false, "Keep running, polling every --interval and downloading the "+k.Plural()+" modified since the last poll")
+	cmd.Flags().DurationVar(&interval, "interval", 5*time.Minute, "Time between polls with --watch")
+	cmd.Flags().BoolVar(&noCache, "no-cache", false, "Download every "+k.Name()+" in full, even if unchanged since last downloaded (HTTP_CACHE; dashboards only)")
+	cmd.Flags().BoolVar(&downloader.Strict, "strict-permissions", false, "Fail the run for "+k.Plural()+" the API key isn't allowed to read (403), instead of only listing them")
+	resource.AddTagFlags(downloadFlags, true)
+	if flagger, ok := k.(resource.DownloadFlagger); ok {
+		flagger.AddDownloadFlags(downloadFlags)
+	}
+	cmd.Flags().AddFlagSet(downloadFlags)
+	if _, ok := k.(resource.Pushable); ok {
+		cmd.Flags().BoolVar(&managed.Only, "managed-only", false, "Only select "+k.Plural()+" carrying the managed tag, as added by push")
+		cmd.Flags().StringVar(&managed.Tag, "managed-tag", "", "The managed tag for --managed-only (default: MANAGED_TAG)")
+	}
+	notify.AddFlags(cmd, &notifyOpts)
+	git.AddFlags(cmd, &gitOpts)
+
+	return cmd
+}
+
+func runDownload(ctx context.Context, k resource.Kind, opts resource.BaseDownloadOptions, kindFlags, downloadFlags *pflag.FlagSet, downloader resource.Downloader[string], notifyOpts notify.Options, archivePath string, gitOpts git.Options, maxResources *int, managed managedOptions) error {
+	start := time.Now()
+	command := k.Plural() + " download"
+	var archive *storage.ArchiveBackend
+	var tracker *storage.WriteTracker
+	settings, err := loadSettings(k, downloadFlags)
+	if err != nil {
+		notify.Finish(notifyOpts, notify.NewSummary(command, 0, nil, nil, 1, time.Since(start)))
+		return err
+	}
+	if opts.OutputPath == "" && k.PathTemplate(settings) == "" {
+		return fmt.Errorf("no path template configured for %s; set --output", k.Plural())
+	}
+	if maxResources != nil {
+		settings.MaxResources = *maxResources
+	}
+	// --all lifts the cap even when --managed-only narrows it
+	all := opts.All
+	if err := managed.apply(&opts, settings); err != nil {
+		return err
+	}
+	client := internalhttp.GetHTTPClient(settings)
+	if archivePath != "" {
+		archive, err = storage.StartArchive(archivePath, settings)
+		if err != nil {
+			return err
+		}
+		defer archive.Abort()
+		// The archive needs every file, changed or not
+		ctx = internalhttp.WithoutCache(ctx)
+	}
+	if gitOpts.Commit && settings.StorageBackend == "s3" {
+		return fmt.Errorf("--git-commit requires the file storage backend")
+	}
+	// Metrics count unchanged writes, so they need the tracker too
+	if gitOpts.Commit || settings.StatsdAddr != "" {
+		tracker, err = storage.TrackWrites(settings)
+		if err != nil {
+			return err
+		}
+		defer storage.Override(nil)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	targetsCh, err := k.Targets(ctx, client, settings, opts, kindFlags)
+	if err == nil && !all {
+		// --all is explicit about selecting everything; the cap is for
+		// filters that select more than intended
+		targetsCh, err = resource.LimitTargets(ctx, cancel, targetsCh, k.Plural(), "downloaded", settings.MaxResources)
+	}
+	if err != nil {
+		notify.Finish(notifyOpts, notify.NewSummary(command, 0, nil, nil, 1, time.Since(start)))
+		return err
+	}
+
+	downloader.FormatID = func(id string) string { return id }
+	downloader.FailUntagged = settings.OnMissingRequiredTag == config.OnMissingTagFail
+	downloader.Download = func(ctx context.Context, target resource.Target[string]) (string, error) {
+		return k.Download(ctx, client, settings, target, opts.OutputPath)
+	}
+	summary := downloader.Run(ctx, targetsCh)
+	if err := client.SaveCache(); err != nil {
+		logging.Logger.Warn("failed to save HTTP cache", "error", err)
+	}
+	if len(summary.NotModified) > 0 {
+		logging.Logger.Info(k.Plural()+" not modified since last downloaded", "count", len(summary.NotModified))
+	}
+	if summary.Dependencies > 0 {
+		logging.Logger.Info("dependencies selected", k.Plural(), summary.Dependencies)
+	}
+
+	failedCompanion := 0
+	if companion, ok := k.(resource.CompanionDownloader); ok && !downloader.DryRun {
+		if path, err := companion.DownloadCompanion(ctx, client, settings); err != nil {
+			failedCompanion++
+			summary.Errors = append(summary.Errors, err)
+			logging.Logger.Error("failed to download "+k.Plural()+" companion file", "error", err)
+		} else if !downloader.Quiet {
+			logging.Logger.Info(k.Plural()+" companion file saved", "path", path)
+		}
+	}
+
+	if len(summary.Restricted) > 0 {
+		sort.Strings(summary.Restricted)
+		logging.Logger.Warn("restricted "+k.Plural()+": the API key isn't allowed to read them", "count", len(summary.Restricted), "ids", strings.Join(summary.Restricted, ","))
+	}
+
+	logUntagged(k, summary.Untagged)
+
+	failed := summary.Failed() + failedCompanion
+	var archiveErr error
+	if archive != nil {
+		manifest := storage.ArchiveManifest{Command: command, Version: version.Version, FailedIDs: summary.FailedIDs}
+		if archiveErr = archive.Close(manifest); archiveErr != nil {
+			failed++
+		} else {
+			logging.Logger.Info("archive written", "path", archivePath, k.Plural(), len(summary.Downloaded))
+		}
+	}
+	if gitOpts.Commit {
+		if err := git.CommitRun(gitOpts, command, tracker.Paths()); err != nil {
+			failed++
+			logging.Logger.Error("failed to commit changes", "error", err)
+		}
+	}
+	runSummary := notify.NewSummary(command, summary.Total, summary.FailedIDs, nil, failed, time.Since(start))
+	if !downloader.Strict {
+		runSummary = runSummary.WithRestricted(summary.Restricted, time.Since(start))
+	}
+	notify.Finish(notifyOpts, runSummary)
+	sendMetrics(settings, k, client.Stats(), summary, failed, tracker, time.Since(start))
+	if archiveErr != nil {
+		return fmt.Errorf("failed to write archive: %w", archiveErr)
+	}
+	if failed > 0 {
+		return &resource.FailedError{Kind: k.Name(), Errs: summary.Errors}
+	}
+
+	return nil
+}
+
+// runStdout prints a single resource to stdout. Logs already go to stderr;
+// anything else that would write to stdout is redirected so the output can
+// be piped.
+func runStdout(ctx context.Context, k resource.Kind, opts resource.BaseDownloadOptions, kindFlags, downloadFlags *pflag.FlagSet) error {
+	ids := utils.ParseCommaSeparatedIDs(opts.IDs)
+	if len(ids) != 1 || opts.All || opts.Update || opts.Team != "" || opts.Tags != "" || anyChanged(kindFlags) {
+		return fmt.Errorf("--stdout requires exactly one --id and no other selection flags")
+	}
+	logging.ReserveStdout()
+
+	settings, err := loadSettings(k, downloadFlags)
+	if err != nil {
+		return err
+	}
+	data, err := k.Fetch(ctx, internalhttp.GetHTTPClient(settings), settings, ids[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", ids[0], err)
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// managedOptions are the --managed-only and --managed-tag flags of kinds
+// that can be pushed.
+type managedOptions struct {
+	Only bool
+	Tag  string
+}
+
+// apply narrows the selection to resources with the managed tag, which is
+// --managed-tag or else MANAGED_TAG. Every other filter still applies; --all
+// is only a starting point.
+func (m managedOptions) apply(opts *resource.BaseDownloadOptions, settings *config.Settings) error {
+	if !m.Only {
+		return nil
+	}
+	tag := m.Tag
+	if tag == "" {
+		tag = settings.ManagedTag
+	}
+	if tag == "" {
+		return fmt.Errorf("--managed-only requires --managed-tag or MANAGED_TAG")
+	}
+	if opts.Tags != "" {
+		opts.Tags += ","
+	}
+	opts.Tags += tag
+	opts.All = false
+	return nil
+}
+
+// loadSettings loads the settings and applies the kind's download flags.
+func loadSettings(k resource.Kind, downloadFlags *pflag.FlagSet) (*config.Settings, error) {
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	if err := resource.ApplyTagFlags(settings, downloadFlags); err != nil {
+		return nil, err
+	}
+	if flagger, ok := k.(resource.DownloadFlagger); ok {
+		if err := flagger.ApplyDownloadFlags(settings, downloadFlags); err != nil {
+			return nil, err
+		}
+	}
+	return settings, nil
+}
+
+// logUntagged lists the resources missing a required tag, with their titles,
+// so someone can go and tag them in Datadog.
+func logUntagged(k resource.Kind, untagged []resource.Untagged) {
+	if len(untagged) == 0 {
+		return
+	}
+	sort.Slice(untagged, func(i, j int) bool { return untagged[i].ID < untagged[j].ID })
+	logging.Logger.Warn(k.Plural()+" missing required tags", "count", len(untagged))
+	for _, u := range untagged {
+		attrs := []any{"id", u.ID, "title", u.Title, "missing", strings.Join(u.Missing, ",")}
+		if u.Path != "" {
+			attrs = append(attrs, "quarantined", u.Path)
+		}
+		logging.Logger.Warn("untagged "+k.Name(), attrs...)
+	}
+}
+
+// sendMetrics sends the run's counters and duration to STATSD_ADDR, if set.
+// Failing to send only logs a warning.
+func sendMetrics(settings *config.Settings, k resource.Kind, stats internalhttp.Stats, summary resource.Summary, failed int, tracker *storage.WriteTracker, duration time.Duration) {
+	client, err := statsd.New(settings.StatsdAddr, statsd.Tag("command", "download"), statsd.Tag("resource", k.Plural()), statsd.Tag("site", settings.Site))
+	if err != nil {
+		logging.Logger.Warn("metrics disabled", "error", err)
+		return
+	}
+	defer client.Close()
+
+	unchanged := 0
+	for _, d := range summary.Downloaded {
+		if tracker != nil && tracker.Unchanged(d.Path) {
+			unchanged++
+		}
+	}
+	client.Count("ddtf.downloads.success", int64(len(summary.Downloaded)))
+	client.Count("ddtf.downloads.failed", int64(failed))
+	client.Count("ddtf.downloads.unchanged", int64(unchanged))
+	client.Count("ddtf.http.requests", stats.Requests)
+	client.Count("ddtf.http.rate_limited", stats.RateLimited)
+	client.Timing("ddtf.run.duration", duration)
+}
@@ -0,0 +1,20 @@
+package resources
+
+// Resource kinds register themselves with resource.Register from init, so
+// importing a kind's package here is all it takes to generate its commands.
+import (
+	_ "github.com/AD7six/dd-tf/internal/datadog/dashboardlists"
+	_ "github.com/AD7six/dd-tf/internal/datadog/dashboards"
+	_ "github.com/AD7six/dd-tf/internal/datadog/downtimes"
+	_ "github.com/AD7six/dd-tf/internal/datadog/integrations"
+	_ "github.com/AD7six/dd-tf/internal/datadog/logs"
+	_ "github.com/AD7six/dd-tf/internal/datadog/metrics"
+	_ "github.com/AD7six/dd-tf/internal/datadog/monitors"
+	_ "github.com/AD7six/dd-tf/internal/datadog/roles"
+	_ "github.com/AD7six/dd-tf/internal/datadog/sds"
+	_ "github.com/AD7six/dd-tf/internal/datadog/security"
+	_ "github.com/AD7six/dd-tf/internal/datadog/services"
+	_ "github.com/AD7six/dd-tf/internal/datadog/synthetics"
+	_ "github.com/AD7six/dd-tf/internal/datadog/teams"
+	_ "github.com/AD7six/dd-tf/internal/datadog/users"
+)
@@ -0,0 +1,74 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// NewListCmd creates the list command for a kind. It takes the same
+// selection flags as download and prints one selected ID per line, followed
+// by a tab and the local path when it is known without downloading.
+func NewListCmd(k resource.Kind) *cobra.Command {
+	var (
+		opts      resource.BaseDownloadOptions
+		kindFlags *pflag.FlagSet
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the IDs of Datadog " + k.Plural() + " by ID, team, tags, or all",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateIDs(k, opts.IDs); err != nil {
+				return err
+			}
+			return runList(cmd.Context(), k, opts, kindFlags)
+		},
+	}
+
+	kindFlags = addSelectionFlags(cmd, k, &opts)
+	cmd.Flags().StringVar(&opts.OutputPath, "output", "", outputHelp(k))
+
+	return cmd
+}
+
+func runList(ctx context.Context, k resource.Kind, opts resource.BaseDownloadOptions, kindFlags *pflag.FlagSet) error {
+	logging.ReserveStdout()
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+	targets, err := k.Targets(ctx, internalhttp.GetHTTPClient(settings), settings, opts, kindFlags)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for result := range targets {
+		if result.Err != nil {
+			logging.Logger.Error("list failed", "error", result.Err)
+			errs = append(errs, result.Err)
+			continue
+		}
+		if result.Target.Path != "" {
+			fmt.Fprintf(os.Stdout, "%s\t%s\n", result.Target.ID, result.Target.Path)
+		} else {
+			fmt.Fprintln(os.Stdout, result.Target.ID)
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if len(errs) > 0 {
+		return &resource.FailedError{Kind: k.Name(), Errs: errs}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package resources
+
+import (
+	"fmt"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/templating"
+	"github.com/AD7six/dd-tf/internal/layout"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+// NewMigrateLayoutCmd creates the migrate-layout command for a kind, which
+// moves downloaded files to the paths the current path template gives them.
+func NewMigrateLayoutCmd(k resource.Kind, computer resource.PathComputer) *cobra.Command {
+	var (
+		from   string
+		dryRun bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "migrate-layout",
+		Short: "Move downloaded " + k.Plural() + " to the current path template",
+		Long: `Scan the downloaded ` + k.Plural() + ` and move each file to the path the current path
+template gives it, computed from the file's content without calling the API.
+Files are found under the static prefix of --from (the old template), or of
+the current template. Files whose destination already exists, or that would
+share a destination, are left in place and reported. Directories left empty
+are removed. Files without a tag the template uses move to its "none"
+fallback directory, with a warning.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMigrateLayout(k, computer, from, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "Old path template, to find files outside the current template's directory")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the moves without making them")
+
+	return cmd
+}
+
+func runMigrateLayout(k resource.Kind, computer resource.PathComputer, from string, dryRun bool) error {
+	settings, err := config.LoadOfflineSettings()
+	if err != nil {
+		return err
+	}
+	if settings.StorageBackend == "s3" {
+		return fmt.Errorf("migrate-layout requires the file storage backend")
+	}
+	if from == "" {
+		from = k.PathTemplate(settings)
+	}
+	dir := templating.ExtractStaticPrefix(from)
+	if dir == "" {
+		return fmt.Errorf("path template %q has no static directory to scan", from)
+	}
+
+	result, err := layout.Migrate(layout.Options{
+		Dir:  dir,
+		Kind: k.Name(),
+		Compute: func(raw []byte) (string, []string, error) {
+			return computer.ComputePath(settings, raw)
+		},
+		DryRun: dryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, move := range result.Moves {
+		if dryRun {
+			logging.Logger.Info("would move", "from", move.From, "to", move.To)
+		} else {
+			logging.Logger.Info("moved", "from", move.From, "to", move.To)
+		}
+	}
+	for _, move := range result.Collisions {
+		logging.Logger.Error("destination already taken; not moved", "from", move.From, "to", move.To)
+	}
+	for _, err := range result.Errors {
+		logging.Logger.Error("failed to migrate", "error", err)
+	}
+	logging.Logger.Info("migrate-layout complete", "kind", k.Plural(), "moved", len(result.Moves), "unchanged", result.Unchanged, "collisions", len(result.Collisions), "errors", len(result.Errors), "dry_run", dryRun)
+
+	if n := len(result.Collisions) + len(result.Errors); n > 0 {
+		return fmt.Errorf("%d %s could not be moved", n, k.Plural())
+	}
+	return nil
+}
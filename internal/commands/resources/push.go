@@ -0,0 +1,251 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/templating"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/layout"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/notify"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// NewPushCmd creates the push command for a kind, which sends downloaded
+// files back to Datadog, replacing the remote resources with the same IDs.
+func NewPushCmd(k resource.Kind, p resource.Pushable) *cobra.Command {
+	var (
+		opts     resource.PushOptions
+		flags    resource.PushFlags
+		tagFlags = pflag.NewFlagSet(k.Name()+" push", pflag.ContinueOnError)
+		pusher   = resource.Pusher{Kind: k.Name(), Workers: resource.DefaultPushWorkers}
+
+		maxResources int
+		notifyOpts   notify.Options
+	)
+
+	cmd := &cobra.Command{
+		Use:   "push",
+		Short: "Push downloaded " + k.Plural() + " to Datadog",
+		Long: `Send local ` + k.Name() + ` files to Datadog, replacing the ` + k.Plural() + ` with the same
+IDs. Select files with --path (files or directories), --id (looked up under
+the path template's directory) or --all (every file under it). Each file must
+be valid JSON with a valid id. A ` + k.Name() + ` that doesn't exist remotely is
+created, and its file's id changed to the new one, unless --no-create is set.
+Each remote ` + k.Name() + ` is fetched first and left alone if it already matches
+its file, both normalized; --force pushes every file without checking.
+A ` + k.Name() + ` modified remotely since it was downloaded is a conflict and isn't
+pushed, unless --overwrite is set. --managed-tag (default: MANAGED_TAG) is
+added to the tags of every ` + k.Name() + ` pushed. A file missing one of
+--require-tags (default: REQUIRED_TAGS) is rejected before any API call.
+With MAX_RESOURCES (or --max-resources) set, selecting more files than that
+pushes nothing, except with --all.
+
+With --dry-run nothing is sent: each remote ` + k.Name() + ` is fetched and compared
+with its file, both normalized, and a unified diff is printed for each one
+that would change.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateIDs(k, opts.IDs); err != nil {
+				return err
+			}
+			if pusher.Workers < 1 {
+				return fmt.Errorf("--concurrency must be at least 1")
+			}
+			// Failed pushes aren't usage errors
+			cmd.SilenceUsage = true
+			var limit *int // nil: MAX_RESOURCES
+			if cmd.Flags().Changed("max-resources") {
+				if maxResources < 0 {
+					return fmt.Errorf("--max-resources must be 0 (no limit) or more")
+				}
+				limit = &maxResources
+			}
+			pusher.DryRun = flags.DryRun
+			return runPush(cmd.Context(), k, p, opts, flags, tagFlags, pusher, limit, notifyOpts)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&opts.Paths, "path", nil, "Local "+k.Name()+" file(s) or directories to push (comma-separated or repeated)")
+	cmd.Flags().StringVar(&opts.IDs, "id", "", strings.ToUpper(k.Name()[:1])+k.Name()[1:]+" ID(s) to push from their downloaded files (comma-separated)")
+	cmd.Flags().BoolVar(&opts.All, "all", false, "Push every downloaded "+k.Name())
+	cmd.Flags().BoolVar(&flags.NoCreate, "no-create", false, "Fail instead of creating "+k.Plural()+" that don't exist remotely")
+	cmd.Flags().BoolVar(&flags.Force, "force", false, "Push every "+k.Name()+" without checking whether it changed")
+	cmd.Flags().BoolVar(&flags.Overwrite, "overwrite", false, "Push "+k.Plural()+" even if they were modified remotely since they were downloaded")
+	cmd.Flags().BoolVar(&flags.DryRun, "dry-run", false, "Print a diff of what would change instead of pushing")
+	cmd.Flags().StringVar(&flags.ManagedTag, "managed-tag", "", "Tag to add to every "+k.Name()+" pushed, e.g. managed-by:dd-tf (default: MANAGED_TAG)")
+	cmd.Flags().IntVar(&pusher.Workers, "concurrency", resource.DefaultPushWorkers, "Number of "+k.Plural()+" to push at once")
+	cmd.Flags().BoolVarP(&pusher.Quiet, "quiet", "q", false, "Only log failures, not each "+k.Name())
+	cmd.Flags().IntVar(&maxResources, "max-resources", 0, "Abort before pushing anything when more than this many "+k.Plural()+" are selected, except with --all (default: MAX_RESOURCES)")
+	resource.AddTagFlags(tagFlags, false)
+	cmd.Flags().AddFlagSet(tagFlags)
+	notify.AddFlags(cmd, &notifyOpts)
+
+	return cmd
+}
+
+func runPush(ctx context.Context, k resource.Kind, p resource.Pushable, opts resource.PushOptions, flags resource.PushFlags, tagFlags *pflag.FlagSet, pusher resource.Pusher, maxResources *int, notifyOpts notify.Options) error {
+	start := time.Now()
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+	if maxResources != nil {
+		settings.MaxResources = *maxResources
+	}
+	if err := resource.ApplyTagFlags(settings, tagFlags); err != nil {
+		return err
+	}
+	backend, err := storage.NewBackend(settings)
+	if err != nil {
+		return err
+	}
+	dir := templating.ExtractStaticPrefix(k.PathTemplate(settings))
+	if dir == "" && (opts.All || opts.IDs != "") {
+		return fmt.Errorf("path template %q has no static directory to scan; use --path", k.PathTemplate(settings))
+	}
+	if flags.ManagedTag == "" {
+		flags.ManagedTag = settings.ManagedTag
+	}
+	client := internalhttp.GetHTTPClient(settings)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	targets, err := resource.PushTargets(ctx, backend, dir, k.IDKind(), opts)
+	if err == nil && !opts.All {
+		// As for download, --all is explicit about selecting everything
+		targets, err = resource.LimitTargets(ctx, cancel, targets, k.Plural(), "pushed", settings.MaxResources)
+	}
+	if err != nil {
+		return err
+	}
+	computer, rehome := k.(resource.PathComputer)
+	pusher.Push = func(ctx context.Context, target resource.Target[string]) (resource.PushResult, error) {
+		result, err := p.Push(ctx, client, settings, target, flags)
+		if err == nil && rehome && result.Action == resource.PushCreated && result.NewID != nil {
+			// The file now holds the new id, so it belongs at the new id's path
+			to, err := layout.Rehome(backend, target.Path, func(raw []byte) (string, []string, error) {
+				return computer.ComputePath(settings, raw)
+			})
+			if err != nil {
+				logging.Logger.Warn("created, but the file couldn't be moved to its new id's path; move it, or run migrate-layout", "path", target.Path, "id", resource.FormatRawID(result.NewID), "error", err)
+			} else if to != target.Path {
+				logging.Logger.Info("moved to its new id's path", "path", target.Path, "to", to)
+			}
+		}
+		return result, err
+	}
+	summary := pusher.Run(ctx, targets)
+
+	msg := k.Plural() + " pushed"
+	if flags.DryRun {
+		if err := writeDiffs(os.Stdout, summary.Pushed); err != nil {
+			return fmt.Errorf("failed to write diffs: %w", err)
+		}
+		msg = k.Plural() + " compared (dry run)"
+	}
+	if err := writePushSummary(os.Stdout, summary); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	logging.Logger.Info(msg,
+		"created", summary.Count(resource.PushCreated),
+		"updated", summary.Count(resource.PushUpdated),
+		"unchanged", summary.Count(resource.PushUnchanged),
+		"conflicts", len(summary.Conflicts()),
+		"failed", summary.Failed()-len(summary.Conflicts()))
+	// Drifted: modified remotely since downloaded, or, in a dry run, would change
+	drifted := resource.TargetErrorIDs(summary.Conflicts())
+	if flags.DryRun {
+		for _, p := range summary.Pushed {
+			if p.Action != resource.PushUnchanged {
+				drifted = append(drifted, p.ID)
+			}
+		}
+	}
+	command := k.Plural() + " push"
+	if flags.DryRun {
+		command += " --dry-run"
+	}
+	notify.Finish(notifyOpts, notify.NewSummary(command, summary.Total, summary.FailedIDs, drifted, summary.Failed(), time.Since(start)))
+	if summary.Failed() > 0 {
+		return &resource.FailedError{Kind: k.Name(), Verb: "push", Errs: summary.Errors}
+	}
+	return nil
+}
+
+// writeDiffs prints the diff of each file that would change, in path order,
+// and lists those that wouldn't as skipped.
+func writeDiffs(w io.Writer, pushed []resource.Pushed) error {
+	sort.Slice(pushed, func(i, j int) bool { return pushed[i].Path < pushed[j].Path })
+	for _, p := range pushed {
+		var err error
+		if p.Action == resource.PushUnchanged {
+			_, err = fmt.Fprintf(w, "skipped %s: no changes\n", p.Path)
+		} else {
+			_, err = io.WriteString(w, p.Diff)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePushSummary prints how many files were created, updated, unchanged,
+// in conflict or failed, then each failure with its file and error.
+func writePushSummary(w io.Writer, summary resource.PushSummary) error {
+	conflicts := len(summary.Conflicts())
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RESULT\tCOUNT")
+	fmt.Fprintf(tw, "created\t%d\n", summary.Count(resource.PushCreated))
+	fmt.Fprintf(tw, "updated\t%d\n", summary.Count(resource.PushUpdated))
+	fmt.Fprintf(tw, "unchanged\t%d\n", summary.Count(resource.PushUnchanged))
+	fmt.Fprintf(tw, "conflicts\t%d\n", conflicts)
+	fmt.Fprintf(tw, "failed\t%d\n", summary.Failed()-conflicts)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if summary.Failed() == 0 {
+		return nil
+	}
+
+	type failure struct{ file, err string }
+	failures := make([]failure, 0, summary.Failed())
+	for _, err := range summary.Errors {
+		f := failure{file: "-", err: err.Error()}
+		var targetErr *resource.TargetError
+		if errors.As(err, &targetErr) {
+			f.file, f.err = targetErr.Path, targetErr.Err.Error()
+			if f.file == "" {
+				f.file = targetErr.ID
+			}
+		}
+		var apiErr *resource.APIError
+		if errors.As(err, &apiErr) && len(apiErr.Messages()) > 0 {
+			// Keep the context around the API error, but not its raw body
+			f.err = strings.Replace(f.err, apiErr.Error(), fmt.Sprintf("API error: %s: %s", apiErr.Status, strings.Join(apiErr.Messages(), "; ")), 1)
+		}
+		f.err = strings.Join(strings.Fields(f.err), " ")
+		failures = append(failures, f)
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].file < failures[j].file })
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tERROR")
+	for _, f := range failures {
+		fmt.Fprintf(tw, "%s\t%s\n", f.file, f.err)
+	}
+	return tw.Flush()
+}
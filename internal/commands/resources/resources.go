@@ -0,0 +1,117 @@
+// Package resources generates the commands for each registered resource
+// kind, e.g. "dd-tf dashboards download".
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// NewCmds returns a parent command for every registered kind. Kinds
+// implementing resource.Grouper are nested under a command named after their
+// group, e.g. "synthetics". extra adds kind-specific subcommands, keyed by the
+// kind's plural name.
+func NewCmds(extra map[string][]*cobra.Command) []*cobra.Command {
+	var cmds []*cobra.Command
+	groups := map[string]*cobra.Command{}
+	for _, k := range resource.Kinds() {
+		kindCmd := NewKindCmd(k, extra[k.Plural()]...)
+		grouper, ok := k.(resource.Grouper)
+		if !ok || grouper.Group() == "" {
+			cmds = append(cmds, kindCmd)
+			continue
+		}
+		group, ok := groups[grouper.Group()]
+		if !ok {
+			group = &cobra.Command{
+				Use:   grouper.Group(),
+				Short: "Manage Datadog " + grouper.Group() + " resources",
+			}
+			groups[grouper.Group()] = group
+			cmds = append(cmds, group)
+		}
+		group.AddCommand(kindCmd)
+	}
+	return cmds
+}
+
+// NewKindCmd creates the parent command for a kind, e.g. "dashboards", with
+// its download and list subcommands, migrate-layout if the kind can compute
+// paths offline, push and sync if it can send files back, diff if its files
+// can be compared with Datadog, plus any extra ones. Sync is limited to those
+// kinds as their files are the ones managed from git, keyed by a top-level
+// id.
+func NewKindCmd(k resource.Kind, extra ...*cobra.Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   k.Plural(),
+		Short: "Manage Datadog " + k.Plural(),
+	}
+
+	cmd.AddCommand(NewDownloadCmd(k))
+	cmd.AddCommand(NewListCmd(k))
+	if computer, ok := k.(resource.PathComputer); ok {
+		cmd.AddCommand(NewMigrateLayoutCmd(k, computer))
+	}
+	if pushable, ok := k.(resource.Pushable); ok {
+		cmd.AddCommand(NewPushCmd(k, pushable))
+		cmd.AddCommand(NewSyncCmd(k))
+	}
+	if comparable, ok := k.(resource.Comparable); ok {
+		cmd.AddCommand(NewDiffCmd(k, comparable))
+	}
+	cmd.AddCommand(extra...)
+
+	return cmd
+}
+
+// addSelectionFlags registers the flags that select which resources a
+// command acts on, returning the flag set holding the kind's own flags.
+func addSelectionFlags(cmd *cobra.Command, k resource.Kind, opts *resource.BaseDownloadOptions) *pflag.FlagSet {
+	cmd.Flags().BoolVar(&opts.All, "all", false, "Select all "+k.Plural())
+	cmd.Flags().BoolVar(&opts.Update, "update", false, "Select already-downloaded "+k.Plural()+" (scans existing files)")
+	cmd.Flags().StringVar(&opts.Team, "team", "", "Team name (convenience for tag 'team:x')")
+	cmd.Flags().StringVar(&opts.Tags, "tags", "", "Comma-separated list of tags to filter "+k.Plural())
+	cmd.Flags().StringVar(&opts.IDs, "id", "", strings.ToUpper(k.Name()[:1])+k.Name()[1:]+" ID(s) (comma-separated)")
+
+	kindFlags := pflag.NewFlagSet(k.Name(), pflag.ContinueOnError)
+	k.AddFlags(kindFlags)
+	cmd.Flags().AddFlagSet(kindFlags)
+	return kindFlags
+}
+
+// outputHelp describes the --output flag with the kind's placeholders.
+func outputHelp(k resource.Kind) string {
+	var placeholders []string
+	for p := range k.Builtins() {
+		placeholders = append(placeholders, p)
+	}
+	sort.Strings(placeholders)
+	return fmt.Sprintf("Output path template (supports %s, {team}, {any-tag} and {ANY_ENV_VAR})", strings.Join(placeholders, ", "))
+}
+
+// validateIDs checks --id values against the kind's ID type.
+func validateIDs(k resource.Kind, ids string) error {
+	if k.IDKind() != resource.IDNumeric {
+		return nil
+	}
+	for _, id := range utils.ParseCommaSeparatedIDs(ids) {
+		if _, err := strconv.Atoi(id); err != nil {
+			return fmt.Errorf("invalid %s ID: %s", k.Name(), id)
+		}
+	}
+	return nil
+}
+
+// anyChanged reports whether any flag in flags was set.
+func anyChanged(flags *pflag.FlagSet) bool {
+	changed := false
+	flags.VisitAll(func(f *pflag.Flag) { changed = changed || f.Changed })
+	return changed
+}
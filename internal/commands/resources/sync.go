@@ -0,0 +1,284 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/templating"
+	"github.com/AD7six/dd-tf/internal/git"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// NewSyncCmd creates the sync command for a kind, which downloads the
+// selected resources and then removes the local files of resources that no
+// longer exist remotely.
+func NewSyncCmd(k resource.Kind) *cobra.Command {
+	var (
+		opts          resource.BaseDownloadOptions
+		kindFlags     *pflag.FlagSet
+		downloadFlags = pflag.NewFlagSet(k.Name()+" sync", pflag.ContinueOnError)
+		downloader    = resource.Downloader[string]{Kind: k.Name()}
+		gitOpts       git.Options
+		keepOrphans   bool
+		maxResources  int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Download " + k.Plural() + " and remove local files deleted in Datadog",
+		Long: `Download the selected ` + k.Plural() + `, as download does, then compare the
+IDs of the files under the path template's directory with every ` + k.Name() + `
+in Datadog, whatever the selection. Files whose ` + k.Name() + ` no longer exists
+are removed, with their companion files; --keep-orphans only lists them.
+Files outside the path template's directory are never removed, and nothing is
+removed if the remote ` + k.Plural() + ` can't all be listed.
+
+With MAX_RESOURCES (or --max-resources) set, selecting more ` + k.Plural() + ` than
+that downloads nothing, except with --all, and finding more files to remove
+than that removes none, even with --all.
+
+A summary of the files added, updated, unchanged and removed is printed at
+the end.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateIDs(k, opts.IDs); err != nil {
+				return err
+			}
+			var limit *int // nil: MAX_RESOURCES
+			if cmd.Flags().Changed("max-resources") {
+				if maxResources < 0 {
+					return fmt.Errorf("--max-resources must be 0 (no limit) or more")
+				}
+				limit = &maxResources
+			}
+			cmd.SilenceUsage = true
+			return runSync(cmd.Context(), k, opts, kindFlags, downloadFlags, downloader, gitOpts, keepOrphans, limit)
+		},
+	}
+
+	kindFlags = addSelectionFlags(cmd, k, &opts)
+	cmd.Flags().StringVar(&opts.OutputPath, "output", "", outputHelp(k))
+	cmd.Flags().BoolVar(&keepOrphans, "keep-orphans", false, "List local "+k.Name()+" files deleted in Datadog instead of removing them")
+	cmd.Flags().BoolVarP(&downloader.Quiet, "quiet", "q", false, "Only log failures and removals, not each "+k.Name())
+	cmd.Flags().IntVar(&maxResources, "max-resources", 0, "Abort before downloading anything when more than this many "+k.Plural()+" are selected, except with --all, and before removing anything when more files than this are orphaned (default: MAX_RESOURCES)")
+	resource.AddTagFlags(downloadFlags, true)
+	if flagger, ok := k.(resource.DownloadFlagger); ok {
+		flagger.AddDownloadFlags(downloadFlags)
+	}
+	cmd.Flags().AddFlagSet(downloadFlags)
+	git.AddFlags(cmd, &gitOpts)
+
+	return cmd
+}
+
+// syncSummary counts what a sync did to the local files.
+type syncSummary struct {
+	Added, Updated, Unchanged int
+	Orphans                   int  // Local files of resources deleted remotely
+	Removed                   bool // Whether the orphans were removed
+	Failed                    int
+}
+
+func runSync(ctx context.Context, k resource.Kind, opts resource.BaseDownloadOptions, kindFlags, downloadFlags *pflag.FlagSet, downloader resource.Downloader[string], gitOpts git.Options, keepOrphans bool, maxResources *int) error {
+	settings, err := loadSettings(k, downloadFlags)
+	if err != nil {
+		return err
+	}
+	if maxResources != nil {
+		settings.MaxResources = *maxResources
+	}
+	if settings.StorageBackend != "" && settings.StorageBackend != "file" {
+		return fmt.Errorf("sync requires the file storage backend")
+	}
+	template := opts.OutputPath
+	if template == "" {
+		template = k.PathTemplate(settings)
+	}
+	dir := templating.ExtractStaticPrefix(template)
+	if dir == "" {
+		return fmt.Errorf("path template %q has no static directory to sync", template)
+	}
+	client := internalhttp.GetHTTPClient(settings)
+	tracker, err := storage.TrackWrites(settings)
+	if err != nil {
+		return err
+	}
+	defer storage.Override(nil)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	targetsCh, err := k.Targets(ctx, client, settings, opts, kindFlags)
+	if err == nil && !opts.All {
+		targetsCh, err = resource.LimitTargets(ctx, cancel, targetsCh, k.Plural(), "downloaded", settings.MaxResources)
+	}
+	if err != nil {
+		return err
+	}
+
+	// With --all and no other filter the selection is every remote resource,
+	// so it doubles as the list to prune against
+	complete := opts.All && opts.Team == "" && opts.Tags == "" && !anyChanged(kindFlags)
+	var (
+		mu       sync.Mutex
+		selected = map[string]bool{}
+	)
+	downloader.FormatID = func(id string) string { return id }
+	downloader.Hooks.OnTargetDiscovered = func(target resource.Target[string]) {
+		mu.Lock()
+		selected[target.ID] = true
+		mu.Unlock()
+	}
+	downloader.Hooks.OnError = func(target resource.Target[string], err error) {
+		if target.ID == "" {
+			// A target generation error: the selection is incomplete
+			mu.Lock()
+			complete = false
+			mu.Unlock()
+		}
+	}
+	downloader.Download = func(ctx context.Context, target resource.Target[string]) (string, error) {
+		return k.Download(ctx, client, settings, target, opts.OutputPath)
+	}
+	summary := downloader.Run(ctx, targetsCh)
+	if err := client.SaveCache(); err != nil {
+		logging.Logger.Warn("failed to save HTTP cache", "error", err)
+	}
+	logUntagged(k, summary.Untagged)
+
+	// Not modified since last downloaded (HTTP_CACHE): not written again
+	result := syncSummary{Removed: !keepOrphans, Unchanged: len(summary.NotModified)}
+	for _, d := range summary.Downloaded {
+		switch {
+		case tracker.Created(d.Path):
+			result.Added++
+		case tracker.Unchanged(d.Path):
+			result.Unchanged++
+		default:
+			result.Updated++
+		}
+	}
+
+	remote := selected
+	if !complete {
+		remote, err = listRemoteIDs(ctx, k, client, settings)
+	}
+	var removed []string
+	if err != nil {
+		err = fmt.Errorf("not pruning, failed to list %s: %w", k.Plural(), err)
+		summary.Errors = append(summary.Errors, err)
+		logging.Logger.Error(err.Error())
+	} else {
+		var errs []error
+		removed, errs = pruneOrphans(k, dir, remote, keepOrphans, settings.MaxResources, &result)
+		summary.Errors = append(summary.Errors, errs...)
+	}
+
+	if gitOpts.Commit {
+		if err := git.CommitRun(gitOpts, k.Plural()+" sync", append(tracker.Paths(), removed...)); err != nil {
+			summary.Errors = append(summary.Errors, err)
+			logging.Logger.Error("failed to commit changes", "error", err)
+		}
+	}
+	result.Failed = summary.Failed()
+
+	if err := writeSyncSummary(os.Stdout, result); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	logging.Logger.Info(k.Plural()+" synced",
+		"added", result.Added,
+		"updated", result.Updated,
+		"unchanged", result.Unchanged,
+		"orphans", result.Orphans,
+		"failed", result.Failed)
+	if result.Failed > 0 {
+		return &resource.FailedError{Kind: k.Name(), Verb: "sync", Errs: summary.Errors}
+	}
+	return nil
+}
+
+// listRemoteIDs returns the ID of every remote resource of kind k, with none
+// of the kind's filters applied.
+func listRemoteIDs(ctx context.Context, k resource.Kind, client resource.HTTPClient, settings *config.Settings) (map[string]bool, error) {
+	flags := pflag.NewFlagSet(k.Name(), pflag.ContinueOnError)
+	k.AddFlags(flags)
+	targets, err := k.Targets(ctx, client, settings, resource.BaseDownloadOptions{All: true}, flags)
+	if err != nil {
+		return nil, err
+	}
+	return resource.CollectIDs(targets)
+}
+
+// pruneOrphans removes, or with keepOrphans only logs, the files under dir
+// whose IDs aren't in remote, counting them in result. More than max orphans
+// (if max > 0) are all kept. It returns the paths removed and any failures.
+func pruneOrphans(k resource.Kind, dir string, remote map[string]bool, keepOrphans bool, max int, result *syncSummary) ([]string, []error) {
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	local, err := resource.ExtractLocalIDs(storage.FileBackend{}, dir, "id", k.IDKind())
+	if err != nil {
+		return nil, []error{fmt.Errorf("failed to scan %s: %w", dir, err)}
+	}
+	orphans := resource.FindOrphans(local, remote, dir)
+	if len(remote) == 0 && len(orphans) > 0 {
+		// An empty list is more likely an API or permission problem than
+		// every resource having been deleted
+		err := fmt.Errorf("not pruning, Datadog returned no %s but %d files exist locally", k.Plural(), len(orphans))
+		logging.Logger.Error(err.Error())
+		return nil, []error{err}
+	}
+	if !keepOrphans && max > 0 && len(orphans) > max {
+		err := fmt.Errorf("not pruning, %d %s files are orphaned, more than %d; raise --max-resources (MAX_RESOURCES) or list them with --keep-orphans", len(orphans), k.Name(), max)
+		logging.Logger.Error(err.Error())
+		return nil, []error{err}
+	}
+
+	var (
+		removed []string
+		errs    []error
+	)
+	for _, orphan := range orphans {
+		result.Orphans++
+		if keepOrphans {
+			logging.Logger.Warn(k.Name()+" deleted remotely", "id", orphan.ID, "path", orphan.Path)
+			continue
+		}
+		paths, err := storage.RemoveWithCompanions(orphan.Path)
+		removed = append(removed, paths...)
+		if err != nil {
+			logging.Logger.Error("failed to remove "+k.Name(), "id", orphan.ID, "error", err)
+			errs = append(errs, &resource.TargetError{ID: orphan.ID, Path: orphan.Path, Err: err})
+			continue
+		}
+		logging.Logger.Info(k.Name()+" removed", "id", orphan.ID, "path", orphan.Path)
+	}
+	return removed, errs
+}
+
+// writeSyncSummary prints how many files were added, updated, unchanged,
+// removed (or orphaned, with --keep-orphans) and failed.
+func writeSyncSummary(w io.Writer, result syncSummary) error {
+	orphans := "removed"
+	if !result.Removed {
+		orphans = "orphaned"
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RESULT\tCOUNT")
+	fmt.Fprintf(tw, "added\t%d\n", result.Added)
+	fmt.Fprintf(tw, "updated\t%d\n", result.Updated)
+	fmt.Fprintf(tw, "unchanged\t%d\n", result.Unchanged)
+	fmt.Fprintf(tw, "%s\t%d\n", orphans, result.Orphans)
+	fmt.Fprintf(tw, "failed\t%d\n", result.Failed)
+	return tw.Flush()
+}
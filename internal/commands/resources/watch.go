@@ -0,0 +1,84 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/spf13/pflag"
+)
+
+// runWatch downloads the selected resources, then polls for them every
+// interval and only downloads those whose modification time changed. It runs
+// until interrupted, letting the downloads already started finish.
+func runWatch(ctx context.Context, k resource.Kind, opts resource.BaseDownloadOptions, kindFlags, downloadFlags *pflag.FlagSet, downloader resource.Downloader[string], interval time.Duration, maxResources *int, managed managedOptions) error {
+	settings, err := loadSettings(k, downloadFlags)
+	if err != nil {
+		return err
+	}
+	if opts.OutputPath == "" && k.PathTemplate(settings) == "" {
+		return fmt.Errorf("no path template configured for %s; set --output", k.Plural())
+	}
+	if maxResources != nil {
+		settings.MaxResources = *maxResources
+	}
+	all := opts.All
+	if err := managed.apply(&opts, settings); err != nil {
+		return err
+	}
+	client := internalhttp.GetHTTPClient(settings)
+	// Polls already skip unchanged resources by modification time
+	ctx = internalhttp.WithoutCache(ctx)
+
+	var changes resource.ChangeTracker
+	downloader.FormatID = func(id string) string { return id }
+	downloader.FailUntagged = settings.OnMissingRequiredTag == config.OnMissingTagFail
+	downloader.Download = func(ctx context.Context, target resource.Target[string]) (string, error) {
+		return k.Download(ctx, client, settings, target, opts.OutputPath)
+	}
+	downloader.Hooks.OnDownloaded = func(target resource.Target[string], _ string) {
+		changes.Record(target)
+	}
+
+	wait := interval
+	for cycle := 1; ; cycle++ {
+		rateLimited := client.Stats().RateLimited
+		summary := watchCycle(ctx, k, client, settings, opts, kindFlags, downloader, &changes, all)
+		logUntagged(k, summary.Untagged)
+
+		// Back off while rate limited, and never poll during a pause
+		wait = resource.NextInterval(interval, wait, client.Stats().RateLimited > rateLimited)
+		if paused := time.Until(client.PausedUntil()); paused > wait {
+			wait = paused
+		}
+		logging.Logger.Info(k.Plural()+" polled", "cycle", cycle, "changed", len(summary.Downloaded), "failed", summary.Failed(), "next", wait)
+
+		select {
+		case <-ctx.Done():
+			logging.Logger.Info("watch stopped")
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// watchCycle downloads the selected resources that changed since they were
+// last written. Once ctx is done no more are started, but those already
+// started aren't cancelled, so no write is cut short.
+func watchCycle(ctx context.Context, k resource.Kind, client resource.HTTPClient, settings *config.Settings, opts resource.BaseDownloadOptions, kindFlags *pflag.FlagSet, downloader resource.Downloader[string], changes *resource.ChangeTracker, all bool) resource.Summary {
+	cycleCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	targets, err := k.Targets(cycleCtx, client, settings, opts, kindFlags)
+	if err == nil && !all {
+		targets, err = resource.LimitTargets(cycleCtx, cancel, targets, k.Plural(), "downloaded", settings.MaxResources)
+	}
+	if err != nil {
+		logging.Logger.Error("failed to select "+k.Plural(), "error", err)
+		return resource.Summary{Errors: []error{err}}
+	}
+	return downloader.Run(cycleCtx, changes.Filter(ctx, targets))
+}
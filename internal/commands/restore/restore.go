@@ -0,0 +1,67 @@
+package restore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// NewRestoreCmd creates the restore command, which unpacks an archive
+// written by `download --archive`.
+func NewRestoreCmd() *cobra.Command {
+	var (
+		archivePath string
+		dir         string
+		force       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Unpack a download archive into the data directory",
+		Long: `Unpack a .tar.gz written by "download --archive". Entries are restored at
+their original (template) paths relative to --dir. Existing files with
+different content are only replaced after confirmation, or with --force.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRestore(archivePath, dir, force, cmd.InOrStdin(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().StringVar(&archivePath, "archive", "", "Archive to restore (.tar.gz)")
+	cmd.Flags().StringVar(&dir, "dir", ".", "Directory to restore into; archive paths are relative to it")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite existing files without asking")
+	cmd.MarkFlagRequired("archive")
+
+	return cmd
+}
+
+func runRestore(archivePath, dir string, force bool, in io.Reader, prompt io.Writer) error {
+	reader := bufio.NewReader(in)
+	overwriteAll := force
+	confirm := func(path string) bool {
+		if overwriteAll {
+			return true
+		}
+		fmt.Fprintf(prompt, "Overwrite %s? [y/N/a] ", path)
+		answer, _ := reader.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return true
+		case "a", "all":
+			overwriteAll = true
+			return true
+		}
+		return false
+	}
+
+	result, err := storage.RestoreArchive(archivePath, dir, confirm)
+	if err != nil {
+		return fmt.Errorf("failed to restore %s: %w", archivePath, err)
+	}
+	logging.Logger.Info("archive restored", "restored", result.Restored, "unchanged", result.Unchanged, "skipped", result.Skipped)
+	return nil
+}
@@ -0,0 +1,166 @@
+package tf
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/dashboards"
+	"github.com/AD7six/dd-tf/internal/datadog/monitors"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/terraform"
+	"github.com/spf13/cobra"
+)
+
+// checkStateOptions are the flags of the check-state command.
+type checkStateOptions struct {
+	State    string // State file, or "-" for stdin
+	Local    bool
+	Remote   bool
+	Module   string
+	Format   string
+	ExitCode bool
+}
+
+// NewCheckStateCmd creates the check-state command, which compares the
+// dashboards and monitors in a Terraform state with the downloaded and
+// remote ones.
+func NewCheckStateCmd() *cobra.Command {
+	var (
+		selection selectionFlags
+		opts      checkStateOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "check-state",
+		Short: "Compare a Terraform state with downloaded and remote dashboards and monitors",
+		Long: `Read the dashboards and monitors managed in a Terraform state, from a state
+file or from terraform show -json on stdin, and compare their IDs with the
+files under --path and, with --remote, with every dashboard and monitor in
+Datadog. Each resource is reported as:
+
+  managed         in the state, and downloaded or in Datadog
+  missing-local   in the state, but not downloaded
+  missing-remote  in the state, but deleted from Datadog (--remote)
+  unmanaged       downloaded or in Datadog, but not in the state
+  mismatched      a downloaded file's address, as import-blocks names it,
+                  is in the state with another ID
+
+--format json lists the resources by status, for scripts and CI, e.g.
+jq -e '[.unmanaged[] | select(.kind == "monitor")] | length == 0', then
+under "moved" the managed resources whose files import-blocks now gives
+another address in --module, for tf generate removals. With
+--exit-code the command exits 0 when everything is managed, 1 otherwise and
+2 on errors, like git diff.`,
+		Example: `  dd-tf tf check-state --state terraform.tfstate
+  terraform show -json | dd-tf tf check-state --remote --format json`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !opts.Local && !opts.Remote {
+				return fmt.Errorf("nothing to compare the state with: --local=false needs --remote")
+			}
+			err := runCheckState(cmd.Context(), cmd.InOrStdin(), selection, opts)
+			if opts.ExitCode {
+				return resource.WithExitCode(err)
+			}
+			return err
+		},
+	}
+
+	selection.addFlags(cmd)
+	cmd.Flags().StringVar(&opts.State, "state", "-", `State file, or terraform show -json output; "-" reads stdin`)
+	cmd.Flags().BoolVar(&opts.Local, "local", true, "Compare the state with the files under --path")
+	cmd.Flags().BoolVar(&opts.Remote, "remote", false, "Compare the state with the dashboards and monitors in Datadog")
+	cmd.Flags().StringVar(&opts.Module, "module", "", "Module the downloaded files' resources are declared in, e.g. monitors or a.b")
+	cmd.Flags().StringVar(&opts.Format, "format", terraform.FormatTable, "Output format: table or json")
+	cmd.Flags().BoolVar(&opts.ExitCode, "exit-code", false, "Exit 1 if any resource isn't managed and 2 on errors, 0 otherwise")
+
+	return cmd
+}
+
+func runCheckState(ctx context.Context, stdin io.Reader, selection selectionFlags, opts checkStateOptions) error {
+	switch opts.Format {
+	case terraform.FormatTable, terraform.FormatJSON:
+	default:
+		return fmt.Errorf("invalid --format %q (expected table or json)", opts.Format)
+	}
+	logging.ReserveStdout()
+
+	state, err := readState(stdin, opts.State)
+	if err != nil {
+		return err
+	}
+	var local []terraform.Resource
+	if opts.Local {
+		if _, local, err = selection.scan(); err != nil {
+			return err
+		}
+		if local == nil {
+			local = []terraform.Resource{}
+		}
+	}
+	var remote map[string]map[string]bool
+	if opts.Remote {
+		if remote, err = listRemoteIDs(ctx); err != nil {
+			return err
+		}
+	}
+
+	result := terraform.CheckState(state, local, remote, opts.Module)
+	if err := terraform.WriteStateResult(os.Stdout, opts.Format, result); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	logging.Logger.Info("state checked",
+		"managed", result.Count(terraform.Managed),
+		"missing_local", result.Count(terraform.MissingLocal),
+		"missing_remote", result.Count(terraform.MissingRemote),
+		"unmanaged", result.Count(terraform.Unmanaged),
+		"mismatched", result.Count(terraform.Mismatched))
+	if opts.ExitCode {
+		return result.Err()
+	}
+	return nil
+}
+
+// readState reads the state from path, or from stdin for "-".
+func readState(stdin io.Reader, path string) ([]terraform.StateResource, error) {
+	if path == "-" {
+		return terraform.ReadState(stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+	defer f.Close()
+	state, err := terraform.ReadState(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return state, nil
+}
+
+// listRemoteIDs returns the ID of every dashboard and monitor in Datadog,
+// by kind.
+func listRemoteIDs(ctx context.Context) (map[string]map[string]bool, error) {
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	client := internalhttp.GetHTTPClient(settings)
+	summaries, err := dashboards.ListDashboards(ctx, client, settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dashboards: %w", err)
+	}
+	ids := map[string]map[string]bool{resource.KindDashboard: {}}
+	for _, s := range summaries {
+		ids[resource.KindDashboard][s.ID] = true
+	}
+	if ids[resource.KindMonitor], err = monitors.ListMonitorIDs(ctx, client, settings); err != nil {
+		return nil, fmt.Errorf("failed to list monitors: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,245 @@
+package tf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/monitors"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/AD7six/dd-tf/internal/terraform"
+	"github.com/spf13/cobra"
+)
+
+// NewGenerateCmd creates the generate command and its subcommands.
+func NewGenerateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate Terraform resources wrapping downloaded JSON files, and removed blocks",
+	}
+
+	cmd.AddCommand(newGenerateKindCmd(resource.KindDashboard, "dashboards"))
+	cmd.AddCommand(newGenerateKindCmd(resource.KindMonitor, "monitors"))
+	cmd.AddCommand(newRemovalsCmd())
+
+	return cmd
+}
+
+// generateOptions are the flags of a generate subcommand.
+type generateOptions struct {
+	terraform.GenerateOptions
+	SingleFile bool
+	WithRoot   bool // With --layout per-team, also write the root main.tf
+	DryRun     bool
+	NoCopy     bool // Monitors only: read downloaded files, which must be clean
+}
+
+// newGenerateKindCmd creates the generate subcommand for a kind.
+func newGenerateKindCmd(kind, plural string) *cobra.Command {
+	var (
+		selection = selectionFlags{kind: kind, jsonTypes: true}
+		opts      generateOptions
+	)
+
+	long := `Write a .tf file next to each ` + kind + ` under --path, declaring it as a
+` + terraform.JSONTypes[kind] + ` resource reading the file:
+
+  resource "` + terraform.JSONTypes[kind] + `" "web_cpu_usage" {
+    ` + kind + ` = file("${path.module}/abc-def-ghi.json")
+  }
+
+Resources are named as by import-blocks, given the same --name-template.
+The file's path is relative to --module-root, the directory of the
+Terraform module using it, by default the .tf file's own. --single-file
+writes one ` + plural + `.tf per directory instead, with its resources in name
+order. Files are only written when their content changes, and have no
+timestamps, so running this in CI only changes what changed. --dry-run
+lists the files that would be written instead.
+
+--layout per-team writes the .tf files to a module per team instead,
+modules/{team}/ under --out-dir, from each ` + kind + `'s team tag; those
+without one go to modules/` + terraform.UnassignedModule + `/ and are counted in the summary.
+--with-root also writes the --out-dir main.tf calling every module there.`
+	if kind == resource.KindMonitor {
+		long += `
+
+The provider rejects the fields the API sets itself, such as id and
+modified, which downloaded monitors keep for push and diff. Each monitor is
+normalized as downloads are and written without them to a
+.terraform.json copy, which the resource reads. With --no-copy the
+downloaded files are read instead, and the command fails if any isn't
+already clean.`
+	}
+
+	cmd := &cobra.Command{
+		Use:          plural,
+		Short:        "Write a " + terraform.JSONTypes[kind] + " resource for each downloaded " + kind,
+		Long:         long,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch opts.Layout {
+			case terraform.LayoutFiles:
+				if opts.WithRoot || opts.OutDir != "" {
+					return fmt.Errorf("--with-root and --out-dir need --layout %s", terraform.LayoutPerTeam)
+				}
+			case terraform.LayoutPerTeam:
+				if opts.ModuleRoot != "" {
+					return fmt.Errorf("--module-root can't be used with --layout %s, each module is its own root", terraform.LayoutPerTeam)
+				}
+			default:
+				return fmt.Errorf("invalid --layout %q (expected %s or %s)", opts.Layout, terraform.LayoutFiles, terraform.LayoutPerTeam)
+			}
+			if opts.SingleFile {
+				opts.GenerateOptions.SingleFile = plural + ".tf"
+			}
+			return runGenerate(kind, plural, selection, opts)
+		},
+	}
+
+	selection.addFlags(cmd)
+	cmd.Flags().StringVar(&opts.ModuleRoot, "module-root", "", "Directory of the Terraform module, which file() paths are relative to (default: each .tf file's directory)")
+	cmd.Flags().BoolVar(&opts.SingleFile, "single-file", false, "Write one "+plural+".tf per directory instead of a file per "+kind)
+	cmd.Flags().StringVar(&opts.Layout, "layout", terraform.LayoutFiles, "Where to write .tf files: files, next to the JSON files, or per-team, a module per team tag")
+	cmd.Flags().StringVar(&opts.OutDir, "out-dir", "", "With --layout per-team, the directory holding modules/ (default: the working directory)")
+	cmd.Flags().BoolVar(&opts.WithRoot, "with-root", false, "With --layout per-team, also write a main.tf calling each module")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "List the files that would be written without writing them")
+	if kind == resource.KindMonitor {
+		cmd.Flags().BoolVar(&opts.NoCopy, "no-copy", false, "Read the downloaded files instead of clean copies, failing if any has read-only fields")
+	}
+
+	return cmd
+}
+
+func runGenerate(kind, plural string, selection selectionFlags, opts generateOptions) error {
+	if opts.DryRun {
+		logging.ReserveStdout()
+	}
+	settings, resources, err := selection.scan()
+	if err != nil {
+		return err
+	}
+
+	var files []terraform.File
+	if kind == resource.KindMonitor {
+		if files, err = cleanMonitors(settings, resources, opts.NoCopy); err != nil {
+			return err
+		}
+	}
+	generated, err := terraform.Generate(resources, opts.GenerateOptions)
+	if err != nil {
+		return err
+	}
+	files = append(files, generated...)
+	unassigned := 0
+	if opts.Layout == terraform.LayoutPerTeam {
+		for _, r := range resources {
+			if terraform.TeamModule(r) == terraform.UnassignedModule {
+				unassigned++
+				logging.Logger.Warn(kind+" has no team tag", "id", r.ID, "path", r.Path, "module", terraform.UnassignedModule)
+			}
+		}
+		if opts.WithRoot {
+			root, err := rootModules(opts.OutDir, resources)
+			if err != nil {
+				return err
+			}
+			files = append(files, root)
+		}
+	}
+
+	written := 0
+	for _, f := range files {
+		existing, err := os.ReadFile(f.Path)
+		if err == nil && bytes.Equal(existing, f.Content) {
+			continue
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		written++
+		if opts.DryRun {
+			action := "update"
+			if err != nil {
+				action = "create"
+			}
+			fmt.Printf("%s %s\n", action, f.Path)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(f.Path), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(f.Path, f.Content, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+		logging.Logger.Info("wrote", "path", f.Path, "resources", len(f.Resources))
+	}
+	summary := []any{"resources", len(resources), "files", len(files), "written", written, "unchanged", len(files) - written, "dry_run", opts.DryRun}
+	if opts.Layout == terraform.LayoutPerTeam {
+		summary = append(summary, "unassigned", unassigned)
+	}
+	logging.Logger.Info("generated "+plural, summary...)
+	return nil
+}
+
+// rootModules returns the root main.tf calling the module of each resource
+// and every module already under outDir, so generating dashboards then
+// monitors gives the same file as the other way round.
+func rootModules(outDir string, resources []terraform.Resource) (terraform.File, error) {
+	seen := map[string]bool{}
+	entries, err := os.ReadDir(filepath.Join(outDir, "modules"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return terraform.File{}, err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			seen[e.Name()] = true
+		}
+	}
+	for _, r := range resources {
+		seen[terraform.TeamModule(r)] = true
+	}
+	modules := make([]string, 0, len(seen))
+	for m := range seen {
+		modules = append(modules, m)
+	}
+	return terraform.RootModules(outDir, modules), nil
+}
+
+// cleanMonitors returns the clean copy of each monitor, as the provider
+// takes it, and points its resource at it. With noCopy it instead checks
+// that every downloaded file is already clean.
+func cleanMonitors(settings *config.Settings, resources []terraform.Resource, noCopy bool) ([]terraform.File, error) {
+	var (
+		files []terraform.File
+		dirty []string
+	)
+	for i, r := range resources {
+		raw, err := os.ReadFile(r.Path)
+		if err != nil {
+			return nil, err
+		}
+		clean, err := monitors.TerraformMonitor(raw, settings)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", r.Path, err)
+		}
+		if noCopy {
+			if formatted, err := storage.FormatJSON(raw); err != nil || !bytes.Equal(formatted, clean) {
+				dirty = append(dirty, r.Path)
+			}
+			continue
+		}
+		resources[i].JSONPath = storage.TerraformPath(r.Path)
+		files = append(files, terraform.File{Path: resources[i].JSONPath, Content: clean, Resources: resources[i : i+1]})
+	}
+	if len(dirty) > 0 {
+		return nil, fmt.Errorf("%d monitor(s) have fields the provider rejects; run without --no-copy, or clean: %s", len(dirty), strings.Join(dirty, ", "))
+	}
+	return files, nil
+}
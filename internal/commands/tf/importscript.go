@@ -0,0 +1,55 @@
+package tf
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/terraform"
+	"github.com/spf13/cobra"
+)
+
+// NewImportScriptCmd creates the import-script command, which prints a
+// terraform import command for each downloaded dashboard and monitor.
+func NewImportScriptCmd() *cobra.Command {
+	var (
+		selection selectionFlags
+		opts      terraform.ScriptOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import-script",
+		Short: "Print terraform import commands for downloaded dashboards and monitors",
+		Long: `Print a terraform import command for every dashboard and monitor under
+--path, for Terraform versions before 1.5, which have no import blocks:
+
+  terraform import 'module.monitors.datadog_monitor.web_cpu_usage' 12345
+
+Resources get the same addresses as with import-blocks, given the same
+--name-template and --type. --module puts them in a module, with dots
+separating nested modules (team.monitors is module.team.module.monitors).
+Addresses are single-quoted for the shell; --quote-ids quotes IDs too.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImportScript(selection, opts)
+		},
+	}
+
+	selection.addFlags(cmd)
+	cmd.Flags().StringVar(&opts.Module, "module", "", "Module the resources are in, e.g. monitors (default: the root module)")
+	cmd.Flags().BoolVar(&opts.QuoteIDs, "quote-ids", false, "Single-quote IDs for the shell")
+
+	return cmd
+}
+
+func runImportScript(selection selectionFlags, opts terraform.ScriptOptions) error {
+	logging.ReserveStdout()
+	_, resources, err := selection.scan()
+	if err != nil {
+		return err
+	}
+	if err := terraform.WriteImportScript(os.Stdout, resources, opts); err != nil {
+		return fmt.Errorf("failed to write import commands: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package tf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/terraform"
+	"github.com/spf13/cobra"
+)
+
+// removalsOptions are the flags of the removals command.
+type removalsOptions struct {
+	From    string // Report file, or "-" for stdin
+	Module  string
+	StateRm bool
+	Out     string
+}
+
+// newRemovalsCmd creates the generate removals command, which turns the
+// deleted and moved resources of a check-state or drift report into state
+// changes.
+func newRemovalsCmd() *cobra.Command {
+	var (
+		selection selectionFlags
+		opts      removalsOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "removals",
+		Short: "Write removed blocks for dashboards and monitors deleted from Datadog",
+		Long: `Read a tf check-state or drift report written with --format json, from
+--from or stdin, and write a Terraform 1.7+ removed block for each dashboard
+and monitor deleted from Datadog, so Terraform forgets it rather than trying
+to recreate it:
+
+  removed {
+    from = datadog_monitor.web_cpu_usage
+
+    lifecycle {
+      destroy = false
+    }
+  }
+
+--state-rm prints terraform state rm commands instead, for older versions.
+A check-state report gives each resource's address. A drift report only
+gives its file, which must still be under --path: run drift before sync
+removes it. The address is then the one import-blocks gives the file, in
+--module.
+
+The managed resources of a check-state report whose files now have another
+address, e.g. after a path or name template change, are listed at the end
+as notes mapping the state's address to the new one.`,
+		Example: `  dd-tf tf check-state --remote --format json --state terraform.tfstate > check.json
+  dd-tf tf generate removals --from check.json --out removed.tf
+  dd-tf drift --format json | dd-tf tf generate removals --state-rm | sh`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRemovals(cmd.InOrStdin(), selection, opts)
+		},
+	}
+
+	selection.addFlags(cmd)
+	cmd.Flags().StringVar(&opts.From, "from", "-", `check-state or drift JSON report; "-" reads stdin`)
+	cmd.Flags().StringVar(&opts.Module, "module", "", "Module the downloaded files' resources are declared in, for a drift report")
+	cmd.Flags().BoolVar(&opts.StateRm, "state-rm", false, "Print terraform state rm commands instead of removed blocks")
+	cmd.Flags().StringVar(&opts.Out, "out", "", "File to write to (default: stdout)")
+
+	return cmd
+}
+
+func runRemovals(stdin io.Reader, selection selectionFlags, opts removalsOptions) error {
+	if opts.Out == "" {
+		logging.ReserveStdout()
+	}
+	findings, err := readFindings(stdin, opts.From)
+	if err != nil {
+		return err
+	}
+	var local []terraform.Resource
+	for _, e := range findings.MissingRemote {
+		if e.Address == "" {
+			if _, local, err = selection.scan(); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	plan := terraform.PlanRemovals(findings, local, opts.Module)
+	write := terraform.WriteRemovedBlocks
+	if opts.StateRm {
+		write = terraform.WriteStateRmScript
+	}
+	var buf bytes.Buffer
+	if err := write(&buf, plan); err != nil {
+		return fmt.Errorf("failed to write removals: %w", err)
+	}
+	if opts.Out == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(opts.Out, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", opts.Out, err)
+	}
+	logging.Logger.Info("wrote removals", "path", opts.Out, "removed", len(plan.Removed), "moved", len(plan.Moved))
+	return nil
+}
+
+// readFindings reads the report from path, or from stdin for "-".
+func readFindings(stdin io.Reader, path string) (terraform.Findings, error) {
+	if path == "-" {
+		return terraform.ReadFindings(stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return terraform.Findings{}, fmt.Errorf("failed to read report: %w", err)
+	}
+	defer f.Close()
+	findings, err := terraform.ReadFindings(f)
+	if err != nil {
+		return terraform.Findings{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return findings, nil
+}
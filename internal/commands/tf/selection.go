@@ -0,0 +1,73 @@
+package tf
+
+import (
+	"os"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/terraform"
+	"github.com/AD7six/dd-tf/internal/terraform/naming"
+	"github.com/spf13/cobra"
+)
+
+// selectionFlags are the flags selecting local files and naming their
+// resources, shared so every tf command gives a resource the same address.
+type selectionFlags struct {
+	path         string
+	nameTemplate string
+	types        []string
+	kind         string // If set, only resources of this kind are selected
+	jsonTypes    bool   // Declare resources as terraform.JSONTypes, without --type
+}
+
+func (f *selectionFlags) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.path, "path", "", "Directory to scan (default: DATA_DIR)")
+	cmd.Flags().StringVar(&f.nameTemplate, "name-template", "", "Template resource names are rendered from, e.g. {team}_{title} (default: TF_RESOURCE_NAME_TEMPLATE)")
+	if !f.jsonTypes {
+		cmd.Flags().StringSliceVar(&f.types, "type", nil, "Terraform resource type of a kind, e.g. dashboard=datadog_dashboard (repeatable)")
+	}
+}
+
+// scan loads the settings, then reads the resources under the path,
+// DATA_DIR by default, and names them.
+func (f *selectionFlags) scan() (*config.Settings, []terraform.Resource, error) {
+	settings, err := config.LoadOfflineSettings()
+	if err != nil {
+		return nil, nil, err
+	}
+	path := f.path
+	if path == "" {
+		path = os.Getenv("DATA_DIR")
+	}
+	types := terraform.JSONTypes
+	if !f.jsonTypes {
+		if types, err = terraform.ParseTypes(f.types); err != nil {
+			return nil, nil, err
+		}
+	}
+	template := f.nameTemplate
+	if template == "" {
+		template = settings.TFResourceNameTemplate
+	}
+	namer, err := naming.New(template)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources, err := terraform.Scan(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	terraform.SetTypes(resources, types)
+	if err := terraform.AssignNames(resources, namer); err != nil {
+		return nil, nil, err
+	}
+	if f.kind == "" {
+		return settings, resources, nil
+	}
+	selected := resources[:0]
+	for _, r := range resources {
+		if r.Kind == f.kind {
+			selected = append(selected, r)
+		}
+	}
+	return settings, selected, nil
+}
@@ -0,0 +1,21 @@
+// Package tf holds the tf subcommands, which generate Terraform
+// configuration from downloaded resources.
+package tf
+
+import "github.com/spf13/cobra"
+
+// NewTfCmd creates the tf command and its subcommands.
+func NewTfCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tf",
+		Short: "Generate Terraform configuration from downloaded resources",
+	}
+
+	cmd.AddCommand(NewImportBlocksCmd())
+	cmd.AddCommand(NewImportScriptCmd())
+	cmd.AddCommand(NewGenerateCmd())
+	cmd.AddCommand(NewCheckStateCmd())
+	cmd.AddCommand(NewUnmanagedCmd())
+
+	return cmd
+}
@@ -0,0 +1,201 @@
+package tf
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/dashboards"
+	"github.com/AD7six/dd-tf/internal/datadog/monitors"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/terraform"
+	"github.com/spf13/cobra"
+)
+
+// fetchWorkers is how many unmanaged dashboards are fetched at once for
+// their tags.
+const fetchWorkers = 4
+
+// unmanagedOptions are the flags of the unmanaged command.
+type unmanagedOptions struct {
+	State  string // State file, or "-" for stdin
+	Team   string
+	Tags   string
+	Format string
+}
+
+// NewUnmanagedCmd creates the unmanaged command, which lists the dashboards
+// and monitors in Datadog that aren't in a Terraform state.
+func NewUnmanagedCmd() *cobra.Command {
+	var opts unmanagedOptions
+
+	cmd := &cobra.Command{
+		Use:   "unmanaged",
+		Short: "List the dashboards and monitors in Datadog that aren't in a Terraform state",
+		Long: `List every dashboard and monitor in Datadog whose ID isn't in a Terraform
+state, read from a state file or from terraform show -json on stdin, with its
+title, author and tags. A summary line per kind, e.g. "73/210 monitors
+unmanaged", tracks the migration to Terraform.
+
+--team and --tags scope the report, and its totals, to the resources with
+those tags, as for download. --format markdown writes the report as a
+Markdown table, ready to paste into an issue.`,
+		Example: `  dd-tf tf unmanaged --state terraform.tfstate --team web
+  terraform show -json | dd-tf tf unmanaged --format markdown`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUnmanaged(cmd.Context(), cmd.InOrStdin(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.State, "state", "-", `State file, or terraform show -json output; "-" reads stdin`)
+	cmd.Flags().StringVar(&opts.Team, "team", "", "Only report resources tagged team:<team>")
+	cmd.Flags().StringVar(&opts.Tags, "tags", "", "Only report resources with all of these comma-separated tags")
+	cmd.Flags().StringVar(&opts.Format, "format", terraform.FormatTable, "Output format: table, json or markdown")
+
+	return cmd
+}
+
+func runUnmanaged(ctx context.Context, stdin io.Reader, opts unmanagedOptions) error {
+	switch opts.Format {
+	case terraform.FormatTable, terraform.FormatJSON, terraform.FormatMarkdown:
+	default:
+		return fmt.Errorf("invalid --format %q (expected table, json or markdown)", opts.Format)
+	}
+	logging.ReserveStdout()
+
+	state, err := readState(stdin, opts.State)
+	if err != nil {
+		return err
+	}
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+	client := internalhttp.GetHTTPClient(settings)
+	filter := resource.BaseDownloadOptions{All: true, Team: opts.Team, Tags: opts.Tags}
+
+	remote := map[string][]terraform.RemoteResource{}
+	if remote[resource.KindDashboard], err = listRemoteDashboards(ctx, client, settings, state, filter); err != nil {
+		return err
+	}
+	if remote[resource.KindMonitor], err = listRemoteMonitors(ctx, client, settings, filter); err != nil {
+		return err
+	}
+
+	report := terraform.FindUnmanaged(state, remote)
+	if err := terraform.WriteUnmanaged(os.Stdout, opts.Format, report); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	for _, c := range report.Counts {
+		logging.Logger.Info("unmanaged resources listed", "kind", c.Kind, "total", c.Total, "unmanaged", c.Unmanaged)
+	}
+	return nil
+}
+
+// listRemoteMonitors lists the monitors matching filter. The list endpoint
+// has every field reported, so no monitor is fetched individually.
+func listRemoteMonitors(ctx context.Context, client resource.HTTPClient, settings *config.Settings, filter resource.BaseDownloadOptions) ([]terraform.RemoteResource, error) {
+	targets, err := monitors.GenerateMonitorTargets(ctx, client, settings, monitors.DownloadOptions{BaseDownloadOptions: filter})
+	if err != nil {
+		return nil, err
+	}
+	var list []terraform.RemoteResource
+	for t := range targets {
+		if t.Err != nil {
+			return nil, fmt.Errorf("failed to list monitors: %w", t.Err)
+		}
+		r, err := terraform.DecodeRemote(resource.KindMonitor, strconv.Itoa(t.Target.ID), t.Target.Data)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, nil
+}
+
+// listRemoteDashboards lists the dashboards matching filter. The list
+// endpoint has no tags, so with --team or --tags every dashboard is fetched
+// to filter it, as download does; otherwise only the unmanaged ones are.
+func listRemoteDashboards(ctx context.Context, client resource.HTTPClient, settings *config.Settings, state []terraform.StateResource, filter resource.BaseDownloadOptions) ([]terraform.RemoteResource, error) {
+	if filter.Team != "" || filter.Tags != "" {
+		filter.All = false
+		targets, err := dashboards.GenerateDashboardTargets(ctx, client, settings, dashboards.DownloadOptions{BaseDownloadOptions: filter})
+		if err != nil {
+			return nil, err
+		}
+		var list []terraform.RemoteResource
+		for t := range targets {
+			if t.Err != nil {
+				return nil, fmt.Errorf("failed to list dashboards: %w", t.Err)
+			}
+			r, err := terraform.DecodeRemote(resource.KindDashboard, t.Target.ID, t.Target.Data)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, r)
+		}
+		return list, nil
+	}
+
+	summaries, err := dashboards.ListDashboards(ctx, client, settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dashboards: %w", err)
+	}
+	managed := map[string]bool{}
+	for _, s := range state {
+		if s.Kind == resource.KindDashboard {
+			managed[s.ID] = true
+		}
+	}
+	list := make([]terraform.RemoteResource, len(summaries))
+	var (
+		wg   sync.WaitGroup
+		work = make(chan int)
+	)
+	for i := 0; i < fetchWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				list[i] = describeDashboard(ctx, client, settings, summaries[i])
+			}
+		}()
+	}
+	for i, s := range summaries {
+		if managed[s.ID] {
+			list[i] = terraform.RemoteResource{Kind: resource.KindDashboard, ID: s.ID, Title: s.Title, Author: s.AuthorHandle, Tags: []string{}}
+			continue
+		}
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	return list, ctx.Err()
+}
+
+// describeDashboard fetches an unmanaged dashboard for its tags. If that
+// fails, the dashboard is still reported from its summary, without tags.
+func describeDashboard(ctx context.Context, client resource.HTTPClient, settings *config.Settings, s dashboards.DashboardSummary) terraform.RemoteResource {
+	fallback := terraform.RemoteResource{Kind: resource.KindDashboard, ID: s.ID, Title: s.Title, Author: s.AuthorHandle, Tags: []string{}}
+	raw, err := dashboards.FetchDashboardJSON(ctx, client, settings, s.ID)
+	if err != nil {
+		logging.Logger.Warn("failed to fetch dashboard tags", "id", s.ID, "error", err)
+		return fallback
+	}
+	r, err := terraform.DecodeRemote(resource.KindDashboard, s.ID, raw)
+	if err != nil {
+		logging.Logger.Warn("failed to fetch dashboard tags", "id", s.ID, "error", err)
+		return fallback
+	}
+	if r.Author == "" {
+		r.Author = s.AuthorHandle
+	}
+	return r
+}
@@ -0,0 +1,131 @@
+// Package validate holds the validate command, which checks local files
+// against JSON Schemas.
+package validate
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AD7six/dd-tf/internal/commands/version"
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/dashboards"
+	"github.com/AD7six/dd-tf/internal/datadog/monitors"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/report"
+	"github.com/AD7six/dd-tf/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+// NewValidateCmd creates the validate command.
+func NewValidateCmd() *cobra.Command {
+	var (
+		path   string
+		format string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate local dashboards and monitors against JSON Schemas (offline)",
+		Long: `Validate every JSON file under --path against the JSON Schema for its
+kind, reporting the JSON pointer and message of each violation. Exits
+non-zero if any file doesn't match, so hand-edited files fail in CI rather
+than at upload time.
+
+Files are also checked for a well-formed id (xxx-xxx-xxx for dashboards, a
+positive integer for monitors) and key:value tags. Every problem is
+reported, not just the first; no API call is made, so no API keys are
+needed.
+
+Schemas for dashboards and monitors are built in. Put dashboard.json or
+monitor.json in SCHEMA_DIR to replace them. Properties a schema doesn't list
+are only logged as warnings: the API adds fields faster than any schema.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runValidate(path, format)
+		},
+	}
+
+	cmd.Flags().StringVar(&path, "path", "", "Directory to check (default: DATA_DIR)")
+	cmd.Flags().StringVar(&format, "format", report.FormatText, "Output format: text, json, junit or sarif")
+
+	return cmd
+}
+
+func runValidate(path, format string) error {
+	switch format {
+	case report.FormatText, report.FormatJSON, report.FormatJUnit, report.FormatSARIF:
+	default:
+		return fmt.Errorf("invalid --format %q (expected text, json, junit or sarif)", format)
+	}
+	if format != report.FormatText {
+		logging.ReserveStdout()
+	}
+
+	settings, err := config.LoadOfflineSettings()
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		path = os.Getenv("DATA_DIR")
+	}
+
+	opts := schema.Options{
+		Schemas: map[string]*schema.Schema{},
+		PathTemplates: map[string]string{
+			resource.KindDashboard: settings.DashboardsPathTemplate,
+			resource.KindMonitor:   settings.MonitorsPathTemplate,
+		},
+		Checks: map[string][]schema.Check{
+			resource.KindDashboard: {schema.CheckID(dashboards.ValidateID), schema.CheckTags},
+			resource.KindMonitor:   {schema.CheckID(monitors.ValidateID), schema.CheckTags},
+		},
+	}
+	for kind := range opts.PathTemplates {
+		if opts.Schemas[kind], err = schema.Load(kind, settings.SchemaDir); err != nil {
+			return fmt.Errorf("failed to load %s schema: %w", kind, err)
+		}
+	}
+
+	files, err := schema.Run(path, opts)
+	if err != nil {
+		return err
+	}
+	result := toReport(files)
+	if err := report.Write(os.Stdout, format, result); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	if n := result.Failures(); n > 0 {
+		return fmt.Errorf("%d file(s) in %s failed validation", n, path)
+	}
+	logging.Logger.Info("validated", "path", path, "files", len(files))
+	return nil
+}
+
+// toReport converts the validated files into the shared report shape. Unknown
+// properties are logged as warnings instead of reported.
+func toReport(files []schema.File) *report.Result {
+	items := make([]report.Item, 0, len(files))
+	for _, f := range files {
+		item := report.Item{Path: f.Path, Kind: f.Kind, ID: f.ID}
+		switch {
+		case f.Invalid != nil:
+			item.Findings = append(item.Findings, report.Finding{Rule: "invalid-json", Message: f.Invalid.Error()})
+		case f.Kind == "":
+			item.Findings = append(item.Findings, report.Finding{Rule: "unknown-shape", Message: "not recognised as a dashboard or monitor"})
+		}
+		for _, v := range f.Violations {
+			if v.Unknown {
+				logging.Logger.Warn("unknown property", "path", f.Path, "pointer", v.Pointer)
+				continue
+			}
+			rule := v.Rule
+			if rule == "" {
+				rule = "schema"
+			}
+			item.Findings = append(item.Findings, report.Finding{Rule: rule, Message: v.String()})
+		}
+		items = append(items, item)
+	}
+	return &report.Result{Command: "validate", Version: version.Version, Items: items}
+}
@@ -0,0 +1,108 @@
+package verify
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AD7six/dd-tf/internal/commands/version"
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/report"
+	"github.com/AD7six/dd-tf/internal/verify"
+	"github.com/spf13/cobra"
+)
+
+// NewVerifyCmd creates the verify command, which checks downloaded files
+// without network access so it can run in pre-commit hooks and CI.
+func NewVerifyCmd() *cobra.Command {
+	var (
+		path         string
+		fix          bool
+		reportFormat string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check downloaded JSON files (offline, for pre-commit and CI)",
+		Long: `Check every JSON file under --path: it must parse, look like a dashboard or
+monitor, have an id consistent with its file name, stay under HTTP_MAX_BODY_SIZE,
+not share its id with another file, not contain anything that looks like a
+credential, and be formatted the way dd-tf writes files. Exits non-zero if
+anything is found. --fix re-formats files; other problems need fixing by hand.
+
+--report-format json|junit|sarif prints a machine-readable report instead of
+one line per problem, for CI test and code-scanning views.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVerify(path, fix, reportFormat)
+		},
+	}
+
+	cmd.Flags().StringVar(&path, "path", "", "Directory to check (default: DATA_DIR)")
+	cmd.Flags().BoolVar(&fix, "fix", false, "Re-format files through the canonical writer")
+	cmd.Flags().StringVar(&reportFormat, "report-format", report.FormatText, "Output format: text, json, junit or sarif")
+
+	return cmd
+}
+
+func runVerify(path string, fix bool, reportFormat string) error {
+	switch reportFormat {
+	case report.FormatText, report.FormatJSON, report.FormatJUnit, report.FormatSARIF:
+	default:
+		return fmt.Errorf("invalid --report-format %q (expected text, json, junit or sarif)", reportFormat)
+	}
+	if reportFormat != report.FormatText {
+		logging.ReserveStdout()
+	}
+
+	settings, err := config.LoadOfflineSettings()
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		path = os.Getenv("DATA_DIR")
+	}
+
+	result, err := verify.Run(path, verify.Options{
+		MaxSize: settings.HTTPMaxBodySize,
+		PathTemplates: map[string]string{
+			resource.KindDashboard: settings.DashboardsPathTemplate,
+			resource.KindMonitor:   settings.MonitorsPathTemplate,
+		},
+		Fix: fix,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := report.Write(os.Stdout, reportFormat, toReport(result)); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	if len(result.Violations) > 0 {
+		return fmt.Errorf("%d problem(s) found in %s", len(result.Violations), path)
+	}
+	logging.Logger.Info("verified", "path", path)
+	return nil
+}
+
+// toReport converts a verify result into the shared report shape, one item
+// per file with its violations as findings.
+func toReport(result *verify.Result) *report.Result {
+	items := make([]report.Item, 0, len(result.Files))
+	index := make(map[string]int, len(result.Files))
+	for _, f := range result.Files {
+		index[f.Path] = len(items)
+		items = append(items, report.Item{Path: f.Path, Kind: f.Kind, ID: f.ID})
+	}
+	for _, v := range result.Violations {
+		i, ok := index[v.Path]
+		if !ok {
+			index[v.Path] = len(items)
+			i = len(items)
+			items = append(items, report.Item{Path: v.Path})
+		}
+		items[i].Findings = append(items[i].Findings, report.Finding{Rule: v.Rule, Message: v.Message})
+	}
+	return &report.Result{Command: "verify", Version: version.Version, Items: items}
+}
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	_ "embed"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -15,23 +18,157 @@ import (
 //go:embed defaults.env
 var embeddedDefaults string
 
+// Values accepted by ON_MISSING_REQUIRED_TAG.
+const (
+	OnMissingTagSkip       = "skip"
+	OnMissingTagQuarantine = "quarantine"
+	OnMissingTagFail       = "fail"
+)
+
+// Defaults and bounds of HTTP_MAX_CONCURRENCY and HTTP_RETRIES, and the
+// default of HTTP_RATE_LIMIT_THRESHOLD.
+const (
+	DefaultHTTPMaxConcurrency     = 8
+	MaxHTTPConcurrency            = 64
+	DefaultHTTPRetries            = 3
+	MaxHTTPRetries                = 10
+	DefaultHTTPRateLimitThreshold = 10
+)
+
 // Settings contains configuration for the Datadog API client and dashboard management.
 type Settings struct {
-	APIKey                 string        `env:"DD_API_KEY"`               // Required, Datadog API key
-	AppKey                 string        `env:"DD_APP_KEY"`               // Required, Datadog application key
-	Site                   string        `env:"DD_SITE"`                  // Datadog site (e.g., datadoghq.com). Used to build https://api.{Site}
-	DashboardsPathTemplate string        `env:"DASHBOARDS_PATH_TEMPLATE"` // Path template for dashboard full path, defaults to "data/dashboards/{id}.json"
-	MonitorsPathTemplate   string        `env:"MONITORS_PATH_TEMPLATE"`   // Path template for monitor full path, defaults to "data/monitors/{id}.json"
-	HTTPTimeout            time.Duration `env:"HTTP_TIMEOUT"`             // HTTP client timeout, defaults to 60 seconds
-	HTTPMaxBodySize        int64         `env:"HTTP_MAX_BODY_SIZE"`       // Maximum allowed API response body size in bytes, defaults to 10MB
-	PageSize               int           `env:"PAGE_SIZE"`                // Number of results per page for index endpoints, defaults to 1000
+	APIKey                                 string        `env:"DD_API_KEY"`                                 // Required, Datadog API key
+	AppKey                                 string        `env:"DD_APP_KEY"`                                 // Required, Datadog application key
+	Site                                   string        `env:"DD_SITE"`                                    // Datadog site (e.g., datadoghq.com). Used to build https://api.{Site}
+	DataDir                                string        `env:"DATA_DIR"`                                   // Directory downloads are written under by default, defaults to "data"
+	DashboardsPathTemplate                 string        `env:"DASHBOARDS_PATH_TEMPLATE"`                   // Path template for dashboard full path, defaults to "data/dashboards/{id}.json"
+	DashboardListsPathTemplate             string        `env:"DASHBOARD_LISTS_PATH_TEMPLATE"`              // Path template for dashboard lists, defaults to "data/dashboard-lists/{id}-{name}.json"
+	MonitorsPathTemplate                   string        `env:"MONITORS_PATH_TEMPLATE"`                     // Path template for monitor full path, defaults to "data/monitors/{id}.json"
+	DowntimesPathTemplate                  string        `env:"DOWNTIMES_PATH_TEMPLATE"`                    // Path template for downtimes, defaults to "data/downtimes/{id}.json"
+	SyntheticsPrivateLocationsPathTemplate string        `env:"SYNTHETICS_PRIVATE_LOCATIONS_PATH_TEMPLATE"` // Path template for Synthetics private locations, defaults to "data/synthetics/private-locations/{id}.json"
+	SyntheticsVariablesPathTemplate        string        `env:"SYNTHETICS_VARIABLES_PATH_TEMPLATE"`         // Path template for Synthetics global variables, defaults to "data/synthetics/variables/{id}.json"
+	LogsPipelinesPathTemplate              string        `env:"LOGS_PIPELINES_PATH_TEMPLATE"`               // Path template for log pipelines, defaults to "data/logs/pipelines/{id}.json"
+	LogsIndexesPathTemplate                string        `env:"LOGS_INDEXES_PATH_TEMPLATE"`                 // Path template for log indexes, defaults to "data/logs/indexes/{name}.json"
+	LogsMetricsPathTemplate                string        `env:"LOGS_METRICS_PATH_TEMPLATE"`                 // Path template for log-based metrics, defaults to "data/logs/metrics/{id}.json"
+	LogsArchivesPathTemplate               string        `env:"LOGS_ARCHIVES_PATH_TEMPLATE"`                // Path template for log archives, defaults to "data/logs/archives/{id}.json"
+	LogsArchiveOrderPath                   string        `env:"LOGS_ARCHIVE_ORDER_PATH"`                    // Path of the log archive order, defaults to "data/logs/archive-order.json"
+	SecurityAgentRulesPathTemplate         string        `env:"SECURITY_AGENT_RULES_PATH_TEMPLATE"`         // Path template for CWS agent rules, defaults to "data/security/agent-rules/{id}.json"
+	ServicesPathTemplate                   string        `env:"SERVICES_PATH_TEMPLATE"`                     // Path template for service definitions, defaults to "data/services/{dd-service}.json"
+	MetricsPathTemplate                    string        `env:"METRICS_PATH_TEMPLATE"`                      // Path template for metric metadata, defaults to "data/metrics/{metric_name}.json"
+	UsersPathTemplate                      string        `env:"USERS_PATH_TEMPLATE"`                        // Path template for users, defaults to "data/users/{handle}.json"
+	RolesPathTemplate                      string        `env:"ROLES_PATH_TEMPLATE"`                        // Path template for roles, defaults to "data/roles/{name}.json"
+	TeamsPathTemplate                      string        `env:"TEAMS_PATH_TEMPLATE"`                        // Path template for teams, defaults to "data/teams/{handle}.json"
+	SDSPathTemplate                        string        `env:"SDS_PATH_TEMPLATE"`                          // Path template for Sensitive Data Scanner groups, defaults to "data/sds/{name}/config.json"
+	SDSFlatPath                            string        `env:"SDS_FLAT_PATH"`                              // Path of the whole Sensitive Data Scanner configuration with SDS_FLAT, defaults to "data/sds/config.json"
+	WebhooksPathTemplate                   string        `env:"WEBHOOKS_PATH_TEMPLATE"`                     // Path template for webhooks, defaults to "data/integrations/webhooks/{name}.json"
+	AWSPathTemplate                        string        `env:"AWS_PATH_TEMPLATE"`                          // Path template for AWS integration accounts, defaults to "data/integrations/aws/{account_id}.json"
+	HTTPTimeout                            time.Duration `env:"HTTP_TIMEOUT"`                               // HTTP client timeout, defaults to 60 seconds
+	HTTPMaxBodySize                        int64         `env:"HTTP_MAX_BODY_SIZE"`                         // Maximum allowed API response body size in bytes, defaults to 10MB
+	HTTPMaxConcurrency                     int           `env:"HTTP_MAX_CONCURRENCY"`                       // Maximum concurrent API requests, 1 to 64, defaults to 8
+	HTTPRetries                            int           `env:"HTTP_RETRIES"`                               // Retries of a failed API request, 0 to 10, defaults to 3
+	HTTPRateLimitThreshold                 int           `env:"HTTP_RATE_LIMIT_THRESHOLD"`                  // Percent of the rate limit left below which requests are spaced out, 0 (off) to 100, defaults to 10
+	HTTPProxy                              string        `env:"DD_TF_PROXY"`                                // Proxy URL for every API request, overriding HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	HTTPCABundle                           string        `env:"HTTP_CA_BUNDLE"`                             // PEM file of CA certificates trusted on top of the system ones
+	HTTPTLSInsecureSkipVerify              bool          `env:"HTTP_TLS_INSECURE_SKIP_VERIFY"`              // Don't verify the API's TLS certificate, defaults to false
+	HTTPCache                              bool          `env:"HTTP_CACHE"`                                 // Keep the ETags of downloaded dashboards to skip unchanged ones, defaults to true
+	PageSize                               int           `env:"PAGE_SIZE"`                                  // Number of results per page for index endpoints, defaults to 1000
+	DashboardsPageSize                     int           `env:"DASHBOARDS_PAGE_SIZE"`                       // Page size override for the dashboards list, defaults to PAGE_SIZE
+	MonitorsPageSize                       int           `env:"MONITORS_PAGE_SIZE"`                         // Page size override for the monitors list, defaults to PAGE_SIZE
+	ParallelListPages                      bool          `env:"PARALLEL_LIST_PAGES"`                        // Fetch list pages after the first concurrently, defaults to false
+	MaxResources                           int           `env:"MAX_RESOURCES"`                              // Abort downloads, pushes, deletes and sync prunes of more resources than this, 0 (the default) for no limit
+	MonitorsIncludeRuntime                 bool          `env:"MONITORS_INCLUDE_RUNTIME"`                   // Keep runtime fields such as matching_downtimes on monitors, defaults to false
+	MonitorsStripFields                    []string      `env:"MONITORS_STRIP_FIELDS"`                      // Dot-paths of fields removed from monitors when downloading, comparing and pushing
+	MonitorsGroupStates                    string        `env:"MONITORS_GROUP_STATES"`                      // Store monitor group states: "all", "alert", "warn" or empty (disabled)
+	MonitorsWithRestrictionPolicy          bool          `env:"MONITORS_WITH_RESTRICTION_POLICY"`           // Also write the restriction policy of each downloaded monitor, defaults to false
+	DashboardsStripWidgetIDs               bool          `env:"DASHBOARDS_STRIP_WIDGET_IDS"`                // Remove widget IDs from downloaded dashboards, defaults to false
+	DashboardsStripFields                  []string      `env:"DASHBOARDS_STRIP_FIELDS"`                    // Dot-paths of fields removed from dashboards when downloading, comparing and pushing
+	DashboardsSplitPresets                 bool          `env:"DASHBOARDS_SPLIT_PRESETS"`                   // Write template variable presets to a sibling .presets.json file, defaults to false
+	DashboardsWriteSummary                 bool          `env:"DASHBOARDS_WRITE_SUMMARY"`                   // Write a Markdown summary next to each downloaded dashboard, defaults to false
+	DashboardsWithRestrictionPolicy        bool          `env:"DASHBOARDS_WITH_RESTRICTION_POLICY"`         // Also write the restriction policy of each downloaded dashboard, defaults to false
+	SyntheticsRedactSecure                 bool          `env:"SYNTHETICS_REDACT_SECURE"`                   // Replace the value of secure Synthetics global variables with a placeholder, defaults to true
+	SDSFlat                                bool          `env:"SDS_FLAT"`                                   // Write the whole Sensitive Data Scanner configuration to SDS_FLAT_PATH, defaults to false
+	WebhooksRedactAuthorization            bool          `env:"WEBHOOKS_REDACT_AUTHORIZATION"`              // Replace the value of Authorization headers in webhook custom headers with a placeholder, defaults to true
+	RequiredTags                           []string      `env:"REQUIRED_TAGS"`                              // Tag keys every downloaded resource must have, empty disables the check
+	OnMissingRequiredTag                   string        `env:"ON_MISSING_REQUIRED_TAG"`                    // What to do with resources missing a required tag: "skip", "quarantine" or "fail", defaults to "skip"
+	SchemaDir                              string        `env:"SCHEMA_DIR"`                                 // Directory of <kind>.json schemas overriding the embedded ones for validate
+	ManagedTag                             string        `env:"MANAGED_TAG"`                                // Tag added to every pushed resource, e.g. "managed-by:dd-tf", empty disables it
+	TFResourceNameTemplate                 string        `env:"TF_RESOURCE_NAME_TEMPLATE"`                  // Template Terraform resource names are rendered from by dd-tf tf, defaults to "{team}_{title}"
+	Fixtures                               string        `env:"DD_TF_FIXTURES"`                             // Fixture mode: "record", "replay" or empty (disabled)
+	FixturesDir                            string        `env:"DD_TF_FIXTURES_DIR"`                         // Directory for recorded fixtures, defaults to "fixtures"
+	NotifyURL                              string        `env:"NOTIFY_URL"`                                 // URL to POST a run summary to, empty disables notifications
+	NotifyOn                               string        `env:"NOTIFY_ON"`                                  // When to notify: "always", "failure" or "drift", defaults to "always"
+	NotifyTimeout                          time.Duration `env:"NOTIFY_TIMEOUT"`                             // Notification request timeout, defaults to 10 seconds
+	StatsdAddr                             string        `env:"STATSD_ADDR"`                                // DogStatsD host:port to send run metrics to, empty disables metrics
+	StorageBackend                         string        `env:"STORAGE_BACKEND"`                            // Where resources are written: "file" or "s3", defaults to "file"
+	StorageS3Bucket                        string        `env:"STORAGE_S3_BUCKET"`                          // S3 bucket, required for the s3 backend
+	StorageS3Prefix                        string        `env:"STORAGE_S3_PREFIX"`                          // Key prefix prepended to template paths
+	StorageS3Region                        string        `env:"STORAGE_S3_REGION"`                          // S3 region, defaults to AWS_REGION then us-east-1
+	StorageS3Endpoint                      string        `env:"STORAGE_S3_ENDPOINT"`                        // Custom endpoint for S3-compatible stores (path-style)
+	AWSAccessKeyID                         string        `env:"AWS_ACCESS_KEY_ID"`                          // Static credentials for the s3 backend, with AWSSecretAccessKey
+	AWSSecretAccessKey                     string        `env:"AWS_SECRET_ACCESS_KEY"`                      // Static credentials for the s3 backend, with AWSAccessKeyID
+	AWSSessionToken                        string        `env:"AWS_SESSION_TOKEN"`                          // Optional, for temporary static credentials
+	AWSWebIdentityTokenFile                string        `env:"AWS_WEB_IDENTITY_TOKEN_FILE"`                // Web identity token exchanged for AWSRoleARN's credentials, e.g. EKS IRSA
+	AWSRoleARN                             string        `env:"AWS_ROLE_ARN"`                               // Role assumed with AWSWebIdentityTokenFile
+	AWSRoleSessionName                     string        `env:"AWS_ROLE_SESSION_NAME"`                      // Session name of the assumed role, defaults to dd-tf-<timestamp>
+	AWSContainerCredentialsRelativeURI     string        `env:"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"`     // ECS task role credentials path on 169.254.170.2
+	AWSContainerCredentialsFullURI         string        `env:"AWS_CONTAINER_CREDENTIALS_FULL_URI"`         // Container credentials endpoint, e.g. EKS Pod Identity
+	AWSContainerAuthorizationToken         string        `env:"AWS_CONTAINER_AUTHORIZATION_TOKEN"`          // Authorization header for the container credentials endpoint
+	AWSContainerAuthorizationTokenFile     string        `env:"AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"`     // File holding AWSContainerAuthorizationToken, read on each refresh
+	AWSEC2MetadataDisabled                 bool          `env:"AWS_EC2_METADATA_DISABLED"`                  // Don't fall back to EC2 instance role credentials
+}
+
+// APIBaseURL returns the Datadog API base URL, https://api.{Site}. A Site
+// that already includes a scheme (e.g. a local test server) is used as is.
+func (s *Settings) APIBaseURL() string {
+	if strings.HasPrefix(s.Site, "http://") || strings.HasPrefix(s.Site, "https://") {
+		return strings.TrimSuffix(s.Site, "/")
+	}
+	return "https://api." + s.Site
+}
+
+// AppBaseURL returns the Datadog web app base URL: https://app.{Site} for the
+// original sites such as datadoghq.com, and https://{Site} for sites that
+// already name a region, such as us5.datadoghq.com. A Site with a scheme is
+// used as is.
+func (s *Settings) AppBaseURL() string {
+	if strings.HasPrefix(s.Site, "http://") || strings.HasPrefix(s.Site, "https://") {
+		return strings.TrimSuffix(s.Site, "/")
+	}
+	if strings.Count(s.Site, ".") > 1 {
+		return "https://" + s.Site
+	}
+	return "https://app." + s.Site
 }
 
 // LoadSettings loads configuration from environment variables and optional .env file.
 // Embedded defaults are loaded first, then .env file (if present) overrides them.
 // Required environment variables: DD_API_KEY, DD_APP_KEY.
-// Optional variables: DD_SITE, DATA_DIR, DASHBOARDS_PATH_TEMPLATE, MONITORS_PATH_TEMPLATE, HTTP_TIMEOUT, HTTP_MAX_BODY_SIZE, PAGE_SIZE.
+// Optional variables: DD_SITE, DATA_DIR, DASHBOARDS_PATH_TEMPLATE, DASHBOARD_LISTS_PATH_TEMPLATE, MONITORS_PATH_TEMPLATE, DOWNTIMES_PATH_TEMPLATE,
+// SYNTHETICS_PRIVATE_LOCATIONS_PATH_TEMPLATE, SYNTHETICS_VARIABLES_PATH_TEMPLATE, LOGS_PIPELINES_PATH_TEMPLATE, LOGS_INDEXES_PATH_TEMPLATE,
+// LOGS_METRICS_PATH_TEMPLATE, LOGS_ARCHIVES_PATH_TEMPLATE, LOGS_ARCHIVE_ORDER_PATH, SECURITY_AGENT_RULES_PATH_TEMPLATE, SERVICES_PATH_TEMPLATE,
+// METRICS_PATH_TEMPLATE, USERS_PATH_TEMPLATE, ROLES_PATH_TEMPLATE, TEAMS_PATH_TEMPLATE, SDS_PATH_TEMPLATE, SDS_FLAT_PATH, WEBHOOKS_PATH_TEMPLATE,
+// AWS_PATH_TEMPLATE, HTTP_TIMEOUT, HTTP_MAX_BODY_SIZE, HTTP_MAX_CONCURRENCY, HTTP_RETRIES, HTTP_RATE_LIMIT_THRESHOLD, DD_TF_PROXY, HTTP_CA_BUNDLE,
+// HTTP_TLS_INSECURE_SKIP_VERIFY, HTTP_CACHE, PAGE_SIZE, DASHBOARDS_PAGE_SIZE, MONITORS_PAGE_SIZE, PARALLEL_LIST_PAGES, MAX_RESOURCES,
+// MONITORS_INCLUDE_RUNTIME, MONITORS_STRIP_FIELDS, MONITORS_GROUP_STATES, MONITORS_WITH_RESTRICTION_POLICY, DASHBOARDS_STRIP_WIDGET_IDS,
+// DASHBOARDS_STRIP_FIELDS, DASHBOARDS_SPLIT_PRESETS, DASHBOARDS_WRITE_SUMMARY, DASHBOARDS_WITH_RESTRICTION_POLICY, SYNTHETICS_REDACT_SECURE,
+// SDS_FLAT, WEBHOOKS_REDACT_AUTHORIZATION, REQUIRED_TAGS, ON_MISSING_REQUIRED_TAG, SCHEMA_DIR, MANAGED_TAG, TF_RESOURCE_NAME_TEMPLATE,
+// DD_TF_FIXTURES, DD_TF_FIXTURES_DIR, NOTIFY_URL, NOTIFY_ON, NOTIFY_TIMEOUT, STATSD_ADDR, STORAGE_BACKEND, STORAGE_S3_BUCKET, STORAGE_S3_PREFIX, STORAGE_S3_REGION,
+// STORAGE_S3_ENDPOINT, AWS_REGION, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_WEB_IDENTITY_TOKEN_FILE, AWS_ROLE_ARN,
+// AWS_ROLE_SESSION_NAME, AWS_CONTAINER_CREDENTIALS_RELATIVE_URI, AWS_CONTAINER_CREDENTIALS_FULL_URI, AWS_CONTAINER_AUTHORIZATION_TOKEN,
+// AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE, AWS_EC2_METADATA_DISABLED.
+// In fixture replay mode no API requests are made, so DD_API_KEY and
+// DD_APP_KEY are not required.
 func LoadSettings() (*Settings, error) {
+	return loadSettings(true)
+}
+
+// LoadOfflineSettings is LoadSettings for commands that never call the API
+// (e.g. verify, restore): DD_API_KEY and DD_APP_KEY are not required.
+func LoadOfflineSettings() (*Settings, error) {
+	return loadSettings(false)
+}
+
+func loadSettings(requireKeys bool) (*Settings, error) {
 	envMap, err := GetDefaultEnv()
 	if err != nil {
 		return nil, fmt.Errorf("error parsing embedded defaults: %w", err)
@@ -52,39 +189,286 @@ func LoadSettings() (*Settings, error) {
 		}
 	}
 
-	apiKey, err := getEnvRequired("DD_API_KEY")
+	return settingsFrom(os.LookupEnv, requireKeys)
+}
+
+// Defaults returns the embedded default settings without reading the
+// environment or a .env file. API keys are left empty.
+func Defaults() (*Settings, error) {
+	envMap, err := GetDefaultEnv()
 	if err != nil {
+		return nil, fmt.Errorf("error parsing embedded defaults: %w", err)
+	}
+	lookup := func(key string) (string, bool) {
+		v, ok := envMap[key]
+		return v, ok
+	}
+	return settingsFrom(lookup, false)
+}
+
+// settingsFrom builds Settings from the variables returned by lookup.
+func settingsFrom(lookup func(string) (string, bool), requireKeys bool) (*Settings, error) {
+	getenv := func(key string) string {
+		v, _ := lookup(key)
+		return v
+	}
+
+	fixtures := strings.ToLower(strings.TrimSpace(getenv("DD_TF_FIXTURES")))
+	switch fixtures {
+	case "", "record", "replay":
+	default:
+		return nil, fmt.Errorf("DD_TF_FIXTURES must be \"record\" or \"replay\", got %q", fixtures)
+	}
+	fixturesDir := getenv("DD_TF_FIXTURES_DIR")
+	if fixtures != "" && fixturesDir == "" {
+		return nil, fmt.Errorf("DD_TF_FIXTURES_DIR must be set with DD_TF_FIXTURES=%s", fixtures)
+	}
+	if fixtures == "replay" {
+		// Replaying runs offline, without keys: never fall back to the API
+		if _, err := os.ReadDir(fixturesDir); err != nil {
+			return nil, fmt.Errorf("DD_TF_FIXTURES=replay needs a readable DD_TF_FIXTURES_DIR: %w", err)
+		}
+	}
+
+	requireKeys = requireKeys && fixtures != "replay"
+	apiKey, err := getEnvRequired(lookup, "DD_API_KEY")
+	if err != nil && requireKeys {
 		return nil, err
 	}
-	appKey, err := getEnvRequired("DD_APP_KEY")
-	if err != nil {
+	appKey, err := getEnvRequired(lookup, "DD_APP_KEY")
+	if err != nil && requireKeys {
 		return nil, err
 	}
 
-	site := os.Getenv("DD_SITE")
+	site := getenv("DD_SITE")
 	site = strings.TrimSpace(strings.ToLower(site))
 	if strings.HasPrefix(site, "api.") {
 		logging.Logger.Warn("DD_SITE should not have prefix 'api.', removing", "site", site)
 		site = strings.TrimPrefix(site, "api.")
 	}
 
-	dashboardsPathTemplate := os.Getenv("DASHBOARDS_PATH_TEMPLATE")
-	monitorsPathTemplate := os.Getenv("MONITORS_PATH_TEMPLATE")
+	dashboardsPathTemplate := getenv("DASHBOARDS_PATH_TEMPLATE")
+	monitorsPathTemplate := getenv("MONITORS_PATH_TEMPLATE")
+	syntheticsPrivateLocationsPathTemplate := getenv("SYNTHETICS_PRIVATE_LOCATIONS_PATH_TEMPLATE")
 
-	httpTimeout := time.Duration(getEnvInt("HTTP_TIMEOUT", 0)) * time.Second
-	HTTPMaxBodySize := int64(getEnvInt("HTTP_MAX_BODY_SIZE", 0))
-	pageSize := getEnvInt("PAGE_SIZE", 0)
+	httpTimeout := time.Duration(getEnvInt(lookup, "HTTP_TIMEOUT", 0)) * time.Second
+	HTTPMaxBodySize := int64(getEnvInt(lookup, "HTTP_MAX_BODY_SIZE", 0))
+	httpMaxConcurrency := getEnvInt(lookup, "HTTP_MAX_CONCURRENCY", DefaultHTTPMaxConcurrency)
+	if httpMaxConcurrency < 1 || httpMaxConcurrency > MaxHTTPConcurrency {
+		return nil, fmt.Errorf("HTTP_MAX_CONCURRENCY must be between 1 and %d, got %d", MaxHTTPConcurrency, httpMaxConcurrency)
+	}
+	httpRetries := getEnvInt(lookup, "HTTP_RETRIES", DefaultHTTPRetries)
+	if httpRetries < 0 || httpRetries > MaxHTTPRetries {
+		return nil, fmt.Errorf("HTTP_RETRIES must be between 0 and %d, got %d", MaxHTTPRetries, httpRetries)
+	}
+	httpRateLimitThreshold := getEnvInt(lookup, "HTTP_RATE_LIMIT_THRESHOLD", DefaultHTTPRateLimitThreshold)
+	if httpRateLimitThreshold < 0 || httpRateLimitThreshold > 100 {
+		return nil, fmt.Errorf("HTTP_RATE_LIMIT_THRESHOLD must be a percentage between 0 and 100, got %d", httpRateLimitThreshold)
+	}
+	httpProxy := strings.TrimSpace(getenv("DD_TF_PROXY"))
+	if httpProxy != "" {
+		u, err := url.Parse(httpProxy)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") {
+			return nil, fmt.Errorf("DD_TF_PROXY must be an http, https or socks5 URL such as http://proxy.example.com:3128, got %q", httpProxy)
+		}
+	}
+	pageSize := getEnvInt(lookup, "PAGE_SIZE", 0)
+	dashboardsPageSize := getEnvInt(lookup, "DASHBOARDS_PAGE_SIZE", pageSize)
+	monitorsPageSize := getEnvInt(lookup, "MONITORS_PAGE_SIZE", pageSize)
+	parallelListPages := getEnvBool(lookup, "PARALLEL_LIST_PAGES", false)
+	maxResources := getEnvInt(lookup, "MAX_RESOURCES", 0)
+	if maxResources < 0 {
+		return nil, fmt.Errorf("MAX_RESOURCES must be 0 (no limit) or more, got %d", maxResources)
+	}
+	notifyTimeout := time.Duration(getEnvInt(lookup, "NOTIFY_TIMEOUT", 0)) * time.Second
 
-	return &Settings{
-		APIKey:                 apiKey,
-		AppKey:                 appKey,
-		Site:                   site,
-		DashboardsPathTemplate: dashboardsPathTemplate,
-		MonitorsPathTemplate:   monitorsPathTemplate,
-		HTTPTimeout:            httpTimeout,
-		HTTPMaxBodySize:        HTTPMaxBodySize,
-		PageSize:               pageSize,
-	}, nil
+	storageBackend := strings.ToLower(strings.TrimSpace(getenv("STORAGE_BACKEND")))
+	switch storageBackend {
+	case "", "file", "s3":
+	default:
+		return nil, fmt.Errorf("STORAGE_BACKEND must be \"file\" or \"s3\", got %q", storageBackend)
+	}
+	groupStates := strings.ToLower(strings.TrimSpace(getenv("MONITORS_GROUP_STATES")))
+	if err := ValidateGroupStates(groupStates); err != nil {
+		return nil, fmt.Errorf("MONITORS_GROUP_STATES: %w", err)
+	}
+	onMissingRequiredTag := strings.ToLower(strings.TrimSpace(getenv("ON_MISSING_REQUIRED_TAG")))
+	switch onMissingRequiredTag {
+	case "":
+		onMissingRequiredTag = OnMissingTagSkip
+	case OnMissingTagSkip, OnMissingTagQuarantine, OnMissingTagFail:
+	default:
+		return nil, fmt.Errorf("ON_MISSING_REQUIRED_TAG must be %q, %q or %q, got %q", OnMissingTagSkip, OnMissingTagQuarantine, OnMissingTagFail, onMissingRequiredTag)
+	}
+	s3Region := getenv("STORAGE_S3_REGION")
+	if s3Region == "" {
+		s3Region = getenv("AWS_REGION")
+	}
+	if storageBackend == "s3" {
+		if err := validateAWSCredentials(lookup); err != nil {
+			return nil, err
+		}
+	}
+
+	settings := &Settings{
+		APIKey:                                 apiKey,
+		AppKey:                                 appKey,
+		Site:                                   site,
+		DataDir:                                getenv("DATA_DIR"),
+		DashboardsPathTemplate:                 dashboardsPathTemplate,
+		DashboardListsPathTemplate:             getenv("DASHBOARD_LISTS_PATH_TEMPLATE"),
+		MonitorsPathTemplate:                   monitorsPathTemplate,
+		DowntimesPathTemplate:                  getenv("DOWNTIMES_PATH_TEMPLATE"),
+		SyntheticsPrivateLocationsPathTemplate: syntheticsPrivateLocationsPathTemplate,
+		SyntheticsVariablesPathTemplate:        getenv("SYNTHETICS_VARIABLES_PATH_TEMPLATE"),
+		LogsPipelinesPathTemplate:              getenv("LOGS_PIPELINES_PATH_TEMPLATE"),
+		LogsIndexesPathTemplate:                getenv("LOGS_INDEXES_PATH_TEMPLATE"),
+		LogsMetricsPathTemplate:                getenv("LOGS_METRICS_PATH_TEMPLATE"),
+		LogsArchivesPathTemplate:               getenv("LOGS_ARCHIVES_PATH_TEMPLATE"),
+		LogsArchiveOrderPath:                   getenv("LOGS_ARCHIVE_ORDER_PATH"),
+		SecurityAgentRulesPathTemplate:         getenv("SECURITY_AGENT_RULES_PATH_TEMPLATE"),
+		ServicesPathTemplate:                   getenv("SERVICES_PATH_TEMPLATE"),
+		MetricsPathTemplate:                    getenv("METRICS_PATH_TEMPLATE"),
+		UsersPathTemplate:                      getenv("USERS_PATH_TEMPLATE"),
+		RolesPathTemplate:                      getenv("ROLES_PATH_TEMPLATE"),
+		TeamsPathTemplate:                      getenv("TEAMS_PATH_TEMPLATE"),
+		SDSPathTemplate:                        getenv("SDS_PATH_TEMPLATE"),
+		SDSFlatPath:                            getenv("SDS_FLAT_PATH"),
+		WebhooksPathTemplate:                   getenv("WEBHOOKS_PATH_TEMPLATE"),
+		AWSPathTemplate:                        getenv("AWS_PATH_TEMPLATE"),
+		HTTPTimeout:                            httpTimeout,
+		HTTPMaxBodySize:                        HTTPMaxBodySize,
+		HTTPMaxConcurrency:                     httpMaxConcurrency,
+		HTTPRetries:                            httpRetries,
+		HTTPRateLimitThreshold:                 httpRateLimitThreshold,
+		HTTPProxy:                              httpProxy,
+		HTTPCABundle:                           strings.TrimSpace(getenv("HTTP_CA_BUNDLE")),
+		HTTPTLSInsecureSkipVerify:              getEnvBool(lookup, "HTTP_TLS_INSECURE_SKIP_VERIFY", false),
+		HTTPCache:                              getEnvBool(lookup, "HTTP_CACHE", true),
+		PageSize:                               pageSize,
+		DashboardsPageSize:                     dashboardsPageSize,
+		MonitorsPageSize:                       monitorsPageSize,
+		ParallelListPages:                      parallelListPages,
+		MaxResources:                           maxResources,
+		MonitorsIncludeRuntime:                 getEnvBool(lookup, "MONITORS_INCLUDE_RUNTIME", false),
+		MonitorsStripFields:                    getEnvList(lookup, "MONITORS_STRIP_FIELDS"),
+		MonitorsGroupStates:                    groupStates,
+		MonitorsWithRestrictionPolicy:          getEnvBool(lookup, "MONITORS_WITH_RESTRICTION_POLICY", false),
+		DashboardsStripWidgetIDs:               getEnvBool(lookup, "DASHBOARDS_STRIP_WIDGET_IDS", false),
+		DashboardsStripFields:                  getEnvList(lookup, "DASHBOARDS_STRIP_FIELDS"),
+		DashboardsSplitPresets:                 getEnvBool(lookup, "DASHBOARDS_SPLIT_PRESETS", false),
+		DashboardsWriteSummary:                 getEnvBool(lookup, "DASHBOARDS_WRITE_SUMMARY", false),
+		DashboardsWithRestrictionPolicy:        getEnvBool(lookup, "DASHBOARDS_WITH_RESTRICTION_POLICY", false),
+		SyntheticsRedactSecure:                 getEnvBool(lookup, "SYNTHETICS_REDACT_SECURE", true),
+		SDSFlat:                                getEnvBool(lookup, "SDS_FLAT", false),
+		WebhooksRedactAuthorization:            getEnvBool(lookup, "WEBHOOKS_REDACT_AUTHORIZATION", true),
+		RequiredTags:                           getEnvList(lookup, "REQUIRED_TAGS"),
+		OnMissingRequiredTag:                   onMissingRequiredTag,
+		SchemaDir:                              getenv("SCHEMA_DIR"),
+		ManagedTag:                             strings.TrimSpace(getenv("MANAGED_TAG")),
+		TFResourceNameTemplate:                 strings.TrimSpace(getenv("TF_RESOURCE_NAME_TEMPLATE")),
+		Fixtures:                               fixtures,
+		FixturesDir:                            fixturesDir,
+		NotifyURL:                              getenv("NOTIFY_URL"),
+		NotifyOn:                               strings.ToLower(strings.TrimSpace(getenv("NOTIFY_ON"))),
+		NotifyTimeout:                          notifyTimeout,
+		StatsdAddr:                             strings.TrimSpace(getenv("STATSD_ADDR")),
+		StorageBackend:                         storageBackend,
+		StorageS3Bucket:                        getenv("STORAGE_S3_BUCKET"),
+		StorageS3Prefix:                        getenv("STORAGE_S3_PREFIX"),
+		StorageS3Region:                        s3Region,
+		StorageS3Endpoint:                      getenv("STORAGE_S3_ENDPOINT"),
+		AWSAccessKeyID:                         getenv("AWS_ACCESS_KEY_ID"),
+		AWSSecretAccessKey:                     getenv("AWS_SECRET_ACCESS_KEY"),
+		AWSSessionToken:                        getenv("AWS_SESSION_TOKEN"),
+		AWSWebIdentityTokenFile:                getenv("AWS_WEB_IDENTITY_TOKEN_FILE"),
+		AWSRoleARN:                             getenv("AWS_ROLE_ARN"),
+		AWSRoleSessionName:                     getenv("AWS_ROLE_SESSION_NAME"),
+		AWSContainerCredentialsRelativeURI:     getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"),
+		AWSContainerCredentialsFullURI:         getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI"),
+		AWSContainerAuthorizationToken:         getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"),
+		AWSContainerAuthorizationTokenFile:     getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"),
+		AWSEC2MetadataDisabled:                 getEnvBool(lookup, "AWS_EC2_METADATA_DISABLED", false),
+	}
+	// Fail now rather than at the first request
+	if _, err := settings.TLSConfig(); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// TLSConfig returns the TLS configuration of API requests: the system CA
+// certificates plus those of HTTPCABundle, and no verification at all with
+// HTTPTLSInsecureSkipVerify. It is nil if neither is set.
+func (s *Settings) TLSConfig() (*tls.Config, error) {
+	if s.HTTPCABundle == "" && !s.HTTPTLSInsecureSkipVerify {
+		return nil, nil
+	}
+	cfg := &tls.Config{InsecureSkipVerify: s.HTTPTLSInsecureSkipVerify}
+	if s.HTTPCABundle == "" {
+		return cfg, nil
+	}
+	pem, err := os.ReadFile(s.HTTPCABundle)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP_CA_BUNDLE: failed to read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("HTTP_CA_BUNDLE: no PEM certificates found in %s", s.HTTPCABundle)
+	}
+	cfg.RootCAs = pool
+	return cfg, nil
+}
+
+// validateAWSCredentials checks the credential settings of the s3 storage
+// backend. They are only checked with STORAGE_BACKEND=s3, as other tools
+// share them.
+func validateAWSCredentials(lookup func(string) (string, bool)) error {
+	getenv := func(key string) string {
+		v, _ := lookup(key)
+		return strings.TrimSpace(v)
+	}
+	if (getenv("AWS_ACCESS_KEY_ID") == "") != (getenv("AWS_SECRET_ACCESS_KEY") == "") {
+		return fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set together")
+	}
+	if (getenv("AWS_WEB_IDENTITY_TOKEN_FILE") == "") != (getenv("AWS_ROLE_ARN") == "") {
+		return fmt.Errorf("AWS_WEB_IDENTITY_TOKEN_FILE and AWS_ROLE_ARN must be set together")
+	}
+	if rel := getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); rel != "" && !strings.HasPrefix(rel, "/") {
+		return fmt.Errorf("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI must be a path starting with /, got %q", rel)
+	}
+	if full := getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI"); full != "" {
+		u, err := url.Parse(full)
+		if err != nil || u.Host == "" || (u.Scheme != "https" && !(u.Scheme == "http" && containerCredentialsHosts[u.Hostname()])) {
+			return fmt.Errorf("AWS_CONTAINER_CREDENTIALS_FULL_URI must be an https URL, or http on a loopback or container credentials address, got %q", full)
+		}
+	}
+	return nil
+}
+
+// containerCredentialsHosts are the hosts a container credentials endpoint
+// may be reached on over plain http: the ECS and EKS Pod Identity agents,
+// and loopback. The authorization token must not go anywhere else.
+var containerCredentialsHosts = map[string]bool{
+	"localhost":      true,
+	"127.0.0.1":      true,
+	"::1":            true,
+	"169.254.170.2":  true,
+	"169.254.170.23": true,
+	"fd00:ec2::23":   true,
+}
+
+// ValidateGroupStates checks a MONITORS_GROUP_STATES / --with-group-states
+// value: "all", "alert", "warn", or empty to not store group states.
+func ValidateGroupStates(v string) error {
+	switch v {
+	case "", "all", "alert", "warn":
+		return nil
+	}
+	return fmt.Errorf("must be \"all\", \"alert\" or \"warn\", got %q", v)
 }
 
 func GetDefaultEnv() (map[string]string, error) {
@@ -92,16 +476,16 @@ func GetDefaultEnv() (map[string]string, error) {
 }
 
 // get the env variable or raise an error
-func getEnvRequired(key string) (string, error) {
-	if v, ok := os.LookupEnv(key); ok && v != "" {
+func getEnvRequired(lookup func(string) (string, bool), key string) (string, error) {
+	if v, ok := lookup(key); ok && v != "" {
 		return v, nil
 	}
 	return "", fmt.Errorf("%s environment variable must be set", key)
 }
 
 // getEnvInt returns an integer env var, defaulting when unset/empty or invalid.
-func getEnvInt(key string, def int) int {
-	v, ok := os.LookupEnv(key)
+func getEnvInt(lookup func(string) (string, bool), key string, def int) int {
+	v, ok := lookup(key)
 	if !ok || v == "" {
 		return def
 	}
@@ -110,3 +494,32 @@ func getEnvInt(key string, def int) int {
 	}
 	return def
 }
+
+// getEnvList returns a comma-separated env var as a list, without blank
+// entries. "none" is an empty list, so a list with a default can be emptied.
+func getEnvList(lookup func(string) (string, bool), key string) []string {
+	v, _ := lookup(key)
+	if strings.EqualFold(strings.TrimSpace(v), "none") {
+		return nil
+	}
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvBool returns a boolean env var, defaulting when unset/empty or invalid.
+// Accepts the values understood by strconv.ParseBool (1, t, true, 0, f, false, ...).
+func getEnvBool(lookup func(string) (string, bool), key string, def bool) bool {
+	v, ok := lookup(key)
+	if !ok || v == "" {
+		return def
+	}
+	if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+		return b
+	}
+	return def
+}
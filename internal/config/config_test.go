@@ -1,8 +1,13 @@
 package config
 
 import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -57,7 +62,7 @@ func TestGetEnvRequired(t *testing.T) {
 				os.Setenv(tt.key, tt.envVal)
 			}
 
-			got, err := getEnvRequired(tt.key)
+			got, err := getEnvRequired(os.LookupEnv, tt.key)
 			if tt.wantError {
 				if err == nil {
 					t.Errorf("getEnvRequired(%q) expected error, got nil", tt.key)
@@ -83,6 +88,21 @@ func TestLoadSettings(t *testing.T) {
 		os.Unsetenv("DATA_DIR")
 		os.Unsetenv("DASHBOARDS_PATH_TEMPLATE")
 		os.Unsetenv("HTTP_TIMEOUT")
+		os.Unsetenv("PAGE_SIZE")
+		os.Unsetenv("MONITORS_PAGE_SIZE")
+		os.Unsetenv("DD_TF_FIXTURES")
+		os.Unsetenv("DD_TF_FIXTURES_DIR")
+		os.Unsetenv("MONITORS_INCLUDE_RUNTIME")
+		os.Unsetenv("MONITORS_GROUP_STATES")
+		os.Unsetenv("REQUIRED_TAGS")
+		os.Unsetenv("MONITORS_STRIP_FIELDS")
+		os.Unsetenv("DASHBOARDS_STRIP_FIELDS")
+		os.Unsetenv("ON_MISSING_REQUIRED_TAG")
+		os.Unsetenv("MAX_RESOURCES")
+		os.Unsetenv("HTTP_MAX_CONCURRENCY")
+		os.Unsetenv("HTTP_RETRIES")
+		os.Unsetenv("DD_TF_PROXY")
+		os.Unsetenv("HTTP_CA_BUNDLE")
 	}
 	cleanup()
 	defer cleanup()
@@ -107,6 +127,52 @@ func TestLoadSettings(t *testing.T) {
 		}
 	})
 
+	t.Run("replay fixtures do not require keys", func(t *testing.T) {
+		os.Setenv("DD_TF_FIXTURES", "replay")
+		os.Setenv("DD_TF_FIXTURES_DIR", t.TempDir())
+		defer cleanup()
+
+		got, err := LoadSettings()
+		if err != nil {
+			t.Fatalf("LoadSettings() unexpected error: %v", err)
+		}
+		if got.Fixtures != "replay" {
+			t.Errorf("LoadSettings().Fixtures = %q, want replay", got.Fixtures)
+		}
+	})
+
+	t.Run("offline settings do not require keys", func(t *testing.T) {
+		defer cleanup()
+
+		if _, err := LoadSettings(); err == nil {
+			t.Fatal("LoadSettings() expected error without keys")
+		}
+		if _, err := LoadOfflineSettings(); err != nil {
+			t.Errorf("LoadOfflineSettings() unexpected error: %v", err)
+		}
+	})
+
+	t.Run("replay fixtures require a readable directory", func(t *testing.T) {
+		os.Setenv("DD_TF_FIXTURES", "replay")
+		os.Setenv("DD_TF_FIXTURES_DIR", filepath.Join(t.TempDir(), "missing"))
+		defer cleanup()
+
+		if _, err := LoadSettings(); err == nil || !strings.Contains(err.Error(), "DD_TF_FIXTURES_DIR") {
+			t.Errorf("LoadSettings() error = %v, want a DD_TF_FIXTURES_DIR error", err)
+		}
+	})
+
+	t.Run("rejects unknown fixtures mode", func(t *testing.T) {
+		os.Setenv("DD_API_KEY", "test_api_key")
+		os.Setenv("DD_APP_KEY", "test_app_key")
+		os.Setenv("DD_TF_FIXTURES", "rewind")
+		defer cleanup()
+
+		if _, err := LoadSettings(); err == nil {
+			t.Error("LoadSettings() expected error for invalid DD_TF_FIXTURES, got nil")
+		}
+	})
+
 	t.Run("uses defaults when only required vars set", func(t *testing.T) {
 		os.Setenv("DD_API_KEY", "test_api_key")
 		os.Setenv("DD_APP_KEY", "test_app_key")
@@ -118,14 +184,50 @@ func TestLoadSettings(t *testing.T) {
 		}
 
 		want := &Settings{
-			APIKey:                 "test_api_key",
-			AppKey:                 "test_app_key",
-			Site:                   "datadoghq.com",
-			DashboardsPathTemplate: "data/dashboards/{id}.json",
-			MonitorsPathTemplate:   "data/monitors/{id}.json",
-			HTTPTimeout:            60 * time.Second,
-			HTTPMaxBodySize:        10 * 1024 * 1024, // 10MB
-			PageSize:               1000,
+			APIKey:                                 "test_api_key",
+			AppKey:                                 "test_app_key",
+			Site:                                   "datadoghq.com",
+			DataDir:                                "data",
+			DashboardsPathTemplate:                 "data/dashboards/{id}.json",
+			DashboardListsPathTemplate:             "data/dashboard-lists/{id}-{name}.json",
+			MonitorsPathTemplate:                   "data/monitors/{id}.json",
+			DowntimesPathTemplate:                  "data/downtimes/{id}.json",
+			SyntheticsPrivateLocationsPathTemplate: "data/synthetics/private-locations/{id}.json",
+			LogsMetricsPathTemplate:                "data/logs/metrics/{id}.json",
+			LogsArchivesPathTemplate:               "data/logs/archives/{id}.json",
+			LogsArchiveOrderPath:                   "data/logs/archive-order.json",
+			SecurityAgentRulesPathTemplate:         "data/security/agent-rules/{id}.json",
+			ServicesPathTemplate:                   "data/services/{dd-service}.json",
+			MetricsPathTemplate:                    "data/metrics/{metric_name}.json",
+			UsersPathTemplate:                      "data/users/{handle}.json",
+			RolesPathTemplate:                      "data/roles/{name}.json",
+			TeamsPathTemplate:                      "data/teams/{handle}.json",
+			SDSPathTemplate:                        "data/sds/{name}/config.json",
+			SDSFlatPath:                            "data/sds/config.json",
+			WebhooksPathTemplate:                   "data/integrations/webhooks/{name}.json",
+			AWSPathTemplate:                        "data/integrations/aws/{account_id}.json",
+			LogsIndexesPathTemplate:                "data/logs/indexes/{name}.json",
+			LogsPipelinesPathTemplate:              "data/logs/pipelines/{id}.json",
+			SyntheticsVariablesPathTemplate:        "data/synthetics/variables/{id}.json",
+			HTTPTimeout:                            60 * time.Second,
+			HTTPMaxBodySize:                        10 * 1024 * 1024, // 10MB
+			HTTPMaxConcurrency:                     8,
+			HTTPRetries:                            3,
+			HTTPRateLimitThreshold:                 10,
+			HTTPCache:                              true,
+			PageSize:                               1000,
+			DashboardsPageSize:                     1000,
+			MonitorsPageSize:                       1000,
+			MonitorsStripFields:                    []string{"overall_state", "overall_state_modified", "created", "creator"},
+			DashboardsStripFields:                  []string{"author_handle", "author_name", "url", "created_at"},
+			SyntheticsRedactSecure:                 true,
+			WebhooksRedactAuthorization:            true,
+			OnMissingRequiredTag:                   OnMissingTagSkip,
+			TFResourceNameTemplate:                 "{team}_{title}",
+			FixturesDir:                            "fixtures",
+			NotifyOn:                               "always",
+			NotifyTimeout:                          10 * time.Second,
+			StorageBackend:                         "file",
 		}
 
 		if !reflect.DeepEqual(got, want) {
@@ -149,6 +251,203 @@ func TestLoadSettings(t *testing.T) {
 		}
 	})
 
+	t.Run("parses MONITORS_INCLUDE_RUNTIME", func(t *testing.T) {
+		os.Setenv("DD_API_KEY", "test_api_key")
+		os.Setenv("DD_APP_KEY", "test_app_key")
+		os.Setenv("MONITORS_INCLUDE_RUNTIME", "true")
+		defer cleanup()
+
+		got, err := LoadSettings()
+		if err != nil {
+			t.Fatalf("LoadSettings() unexpected error: %v", err)
+		}
+		if !got.MonitorsIncludeRuntime {
+			t.Error("LoadSettings().MonitorsIncludeRuntime = false, want true")
+		}
+	})
+
+	t.Run("validates MONITORS_GROUP_STATES", func(t *testing.T) {
+		os.Setenv("DD_API_KEY", "test_api_key")
+		os.Setenv("DD_APP_KEY", "test_app_key")
+		os.Setenv("MONITORS_GROUP_STATES", "Alert")
+		defer cleanup()
+
+		got, err := LoadSettings()
+		if err != nil {
+			t.Fatalf("LoadSettings() unexpected error: %v", err)
+		}
+		if got.MonitorsGroupStates != "alert" {
+			t.Errorf("LoadSettings().MonitorsGroupStates = %q, want alert", got.MonitorsGroupStates)
+		}
+
+		os.Setenv("MONITORS_GROUP_STATES", "ok")
+		if _, err := LoadSettings(); err == nil {
+			t.Error("LoadSettings() expected error for invalid MONITORS_GROUP_STATES, got nil")
+		}
+	})
+
+	t.Run("parses strip fields", func(t *testing.T) {
+		os.Setenv("DD_API_KEY", "test_api_key")
+		os.Setenv("DD_APP_KEY", "test_app_key")
+		os.Setenv("MONITORS_STRIP_FIELDS", "created, options.silenced")
+		os.Setenv("DASHBOARDS_STRIP_FIELDS", "None")
+		defer cleanup()
+
+		got, err := LoadSettings()
+		if err != nil {
+			t.Fatalf("LoadSettings() unexpected error: %v", err)
+		}
+		if want := []string{"created", "options.silenced"}; !reflect.DeepEqual(got.MonitorsStripFields, want) {
+			t.Errorf("LoadSettings().MonitorsStripFields = %q, want %q", got.MonitorsStripFields, want)
+		}
+		if got.DashboardsStripFields != nil {
+			t.Errorf("LoadSettings().DashboardsStripFields = %q, want none", got.DashboardsStripFields)
+		}
+	})
+
+	t.Run("parses REQUIRED_TAGS and ON_MISSING_REQUIRED_TAG", func(t *testing.T) {
+		os.Setenv("DD_API_KEY", "test_api_key")
+		os.Setenv("DD_APP_KEY", "test_app_key")
+		os.Setenv("REQUIRED_TAGS", " team, env,,")
+		defer cleanup()
+
+		got, err := LoadSettings()
+		if err != nil {
+			t.Fatalf("LoadSettings() unexpected error: %v", err)
+		}
+		if want := []string{"team", "env"}; !reflect.DeepEqual(got.RequiredTags, want) {
+			t.Errorf("LoadSettings().RequiredTags = %q, want %q", got.RequiredTags, want)
+		}
+		if got.OnMissingRequiredTag != OnMissingTagSkip {
+			t.Errorf("LoadSettings().OnMissingRequiredTag = %q, want %q", got.OnMissingRequiredTag, OnMissingTagSkip)
+		}
+
+		os.Setenv("ON_MISSING_REQUIRED_TAG", "Quarantine")
+		if got, err = LoadSettings(); err != nil || got.OnMissingRequiredTag != OnMissingTagQuarantine {
+			t.Errorf("LoadSettings() = %v, %v, want quarantine", got, err)
+		}
+		os.Setenv("ON_MISSING_REQUIRED_TAG", "ignore")
+		if _, err := LoadSettings(); err == nil {
+			t.Error("LoadSettings() expected error for invalid ON_MISSING_REQUIRED_TAG, got nil")
+		}
+	})
+
+	t.Run("parses MAX_RESOURCES", func(t *testing.T) {
+		os.Setenv("DD_API_KEY", "test_api_key")
+		os.Setenv("DD_APP_KEY", "test_app_key")
+		os.Setenv("MAX_RESOURCES", "50")
+		defer cleanup()
+
+		got, err := LoadSettings()
+		if err != nil {
+			t.Fatalf("LoadSettings() unexpected error: %v", err)
+		}
+		if got.MaxResources != 50 {
+			t.Errorf("LoadSettings().MaxResources = %d, want 50", got.MaxResources)
+		}
+
+		os.Setenv("MAX_RESOURCES", "-1")
+		if _, err := LoadSettings(); err == nil {
+			t.Error("LoadSettings() expected error for negative MAX_RESOURCES, got nil")
+		}
+	})
+
+	t.Run("parses and bounds HTTP_MAX_CONCURRENCY and HTTP_RETRIES", func(t *testing.T) {
+		os.Setenv("DD_API_KEY", "test_api_key")
+		os.Setenv("DD_APP_KEY", "test_app_key")
+		os.Setenv("HTTP_MAX_CONCURRENCY", "32")
+		os.Setenv("HTTP_RETRIES", "0")
+		defer cleanup()
+
+		got, err := LoadSettings()
+		if err != nil {
+			t.Fatalf("LoadSettings() unexpected error: %v", err)
+		}
+		if got.HTTPMaxConcurrency != 32 || got.HTTPRetries != 0 {
+			t.Errorf("LoadSettings() concurrency, retries = %d, %d, want 32, 0", got.HTTPMaxConcurrency, got.HTTPRetries)
+		}
+
+		for key, value := range map[string]string{"HTTP_MAX_CONCURRENCY": "0", "HTTP_RETRIES": "11"} {
+			os.Setenv(key, value)
+			if _, err := LoadSettings(); err == nil {
+				t.Errorf("LoadSettings() expected error for %s=%s, got nil", key, value)
+			}
+			os.Unsetenv(key)
+		}
+	})
+
+	t.Run("validates DD_TF_PROXY", func(t *testing.T) {
+		os.Setenv("DD_API_KEY", "test_api_key")
+		os.Setenv("DD_APP_KEY", "test_app_key")
+		os.Setenv("DD_TF_PROXY", "http://proxy.example.com:3128")
+		defer cleanup()
+
+		got, err := LoadSettings()
+		if err != nil {
+			t.Fatalf("LoadSettings() unexpected error: %v", err)
+		}
+		if got.HTTPProxy != "http://proxy.example.com:3128" {
+			t.Errorf("LoadSettings().HTTPProxy = %q", got.HTTPProxy)
+		}
+
+		for _, invalid := range []string{"proxy.example.com:3128", "ftp://proxy.example.com"} {
+			os.Setenv("DD_TF_PROXY", invalid)
+			if _, err := LoadSettings(); err == nil {
+				t.Errorf("LoadSettings() expected error for DD_TF_PROXY=%s, got nil", invalid)
+			}
+		}
+	})
+
+	t.Run("validates HTTP_CA_BUNDLE", func(t *testing.T) {
+		os.Setenv("DD_API_KEY", "test_api_key")
+		os.Setenv("DD_APP_KEY", "test_app_key")
+		defer cleanup()
+
+		server := httptest.NewTLSServer(http.NotFoundHandler())
+		server.Close()
+		dir := t.TempDir()
+		valid := filepath.Join(dir, "ca.pem")
+		invalid := filepath.Join(dir, "invalid.pem")
+		os.WriteFile(valid, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o644)
+		os.WriteFile(invalid, []byte("not a certificate"), 0o644)
+
+		os.Setenv("HTTP_CA_BUNDLE", valid)
+		got, err := LoadSettings()
+		if err != nil {
+			t.Fatalf("LoadSettings() unexpected error: %v", err)
+		}
+		if cfg, _ := got.TLSConfig(); cfg == nil || cfg.RootCAs == nil {
+			t.Errorf("TLSConfig() = %v, want the bundle's CAs", cfg)
+		}
+
+		for _, path := range []string{filepath.Join(dir, "missing.pem"), invalid} {
+			os.Setenv("HTTP_CA_BUNDLE", path)
+			if _, err := LoadSettings(); err == nil || !strings.Contains(err.Error(), "HTTP_CA_BUNDLE") {
+				t.Errorf("LoadSettings() error = %v for HTTP_CA_BUNDLE=%s, want an HTTP_CA_BUNDLE error", err, path)
+			}
+		}
+	})
+
+	t.Run("per-resource page sizes override PAGE_SIZE", func(t *testing.T) {
+		os.Setenv("DD_API_KEY", "test_api_key")
+		os.Setenv("DD_APP_KEY", "test_app_key")
+		os.Setenv("PAGE_SIZE", "200")
+		os.Setenv("MONITORS_PAGE_SIZE", "50")
+		defer cleanup()
+
+		got, err := LoadSettings()
+		if err != nil {
+			t.Fatalf("LoadSettings() unexpected error: %v", err)
+		}
+
+		if got.DashboardsPageSize != 200 {
+			t.Errorf("LoadSettings().DashboardsPageSize = %d, want 200 (PAGE_SIZE)", got.DashboardsPageSize)
+		}
+		if got.MonitorsPageSize != 50 {
+			t.Errorf("LoadSettings().MonitorsPageSize = %d, want 50", got.MonitorsPageSize)
+		}
+	})
+
 	t.Run("uses default timeout for invalid HTTP_TIMEOUT", func(t *testing.T) {
 		os.Setenv("DD_API_KEY", "test_api_key")
 		os.Setenv("DD_APP_KEY", "test_app_key")
@@ -181,4 +480,137 @@ func TestLoadSettings(t *testing.T) {
 			t.Errorf("LoadSettings().HTTPTimeout = %v, want 0s", got.HTTPTimeout)
 		}
 	})
+
+	t.Run("validates AWS credentials for the s3 backend", func(t *testing.T) {
+		t.Setenv("DD_API_KEY", "test_api_key")
+		t.Setenv("DD_APP_KEY", "test_app_key")
+		t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+		t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", "http://169.254.170.23/v1/credentials")
+		t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE", "/var/run/secrets/token")
+		defer cleanup()
+
+		// Other tools share these: only checked for the s3 backend
+		t.Setenv("STORAGE_BACKEND", "file")
+		if _, err := LoadSettings(); err != nil {
+			t.Fatalf("LoadSettings() unexpected error: %v", err)
+		}
+		t.Setenv("STORAGE_BACKEND", "s3")
+		if _, err := LoadSettings(); err == nil {
+			t.Error("LoadSettings() expected error for AWS_ACCESS_KEY_ID without AWS_SECRET_ACCESS_KEY")
+		}
+
+		t.Setenv("AWS_ACCESS_KEY_ID", "")
+		got, err := LoadSettings()
+		if err != nil {
+			t.Fatalf("LoadSettings() unexpected error: %v", err)
+		}
+		if got.AWSContainerCredentialsFullURI != "http://169.254.170.23/v1/credentials" || got.AWSContainerAuthorizationTokenFile != "/var/run/secrets/token" {
+			t.Errorf("LoadSettings() = %+v, want the container credentials settings", got)
+		}
+
+		for key, value := range map[string]string{
+			"AWS_CONTAINER_CREDENTIALS_FULL_URI":     "http://credentials.example.com/",
+			"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI": "v2/credentials",
+			"AWS_WEB_IDENTITY_TOKEN_FILE":            "/var/run/secrets/eks/token",
+		} {
+			t.Run(key, func(t *testing.T) {
+				t.Setenv(key, value)
+				if _, err := LoadSettings(); err == nil {
+					t.Errorf("LoadSettings() expected error for %s=%s", key, value)
+				}
+			})
+		}
+	})
+}
+
+func TestDefaults(t *testing.T) {
+	t.Setenv("DD_SITE", "datadoghq.eu")
+	t.Setenv("DD_API_KEY", "from-env")
+
+	got, err := Defaults()
+	if err != nil {
+		t.Fatalf("Defaults() unexpected error: %v", err)
+	}
+	if got.Site != "datadoghq.com" {
+		t.Errorf("Defaults().Site = %q, want the embedded default", got.Site)
+	}
+	if got.APIKey != "" {
+		t.Errorf("Defaults().APIKey = %q, want empty", got.APIKey)
+	}
+	if got.DashboardsPathTemplate != "data/dashboards/{id}.json" {
+		t.Errorf("Defaults().DashboardsPathTemplate = %q", got.DashboardsPathTemplate)
+	}
+	if got.HTTPTimeout != 60*time.Second {
+		t.Errorf("Defaults().HTTPTimeout = %v, want 60s", got.HTTPTimeout)
+	}
+}
+
+func TestAPIBaseURL(t *testing.T) {
+	tests := []struct {
+		site string
+		want string
+	}{
+		{"datadoghq.com", "https://api.datadoghq.com"},
+		{"us5.datadoghq.com", "https://api.us5.datadoghq.com"},
+		{"http://127.0.0.1:8080/", "http://127.0.0.1:8080"},
+	}
+	for _, tt := range tests {
+		s := &Settings{Site: tt.site}
+		if got := s.APIBaseURL(); got != tt.want {
+			t.Errorf("APIBaseURL() for %q = %q, want %q", tt.site, got, tt.want)
+		}
+	}
+}
+
+func TestAppBaseURL(t *testing.T) {
+	tests := []struct {
+		site string
+		want string
+	}{
+		{"datadoghq.com", "https://app.datadoghq.com"},
+		{"datadoghq.eu", "https://app.datadoghq.eu"},
+		{"us5.datadoghq.com", "https://us5.datadoghq.com"},
+		{"http://127.0.0.1:8080/", "http://127.0.0.1:8080"},
+	}
+	for _, tt := range tests {
+		s := &Settings{Site: tt.site}
+		if got := s.AppBaseURL(); got != tt.want {
+			t.Errorf("AppBaseURL() for %q = %q, want %q", tt.site, got, tt.want)
+		}
+	}
+}
+
+func TestGetEnvBool(t *testing.T) {
+	tests := []struct {
+		name   string
+		envVal string
+		setEnv bool
+		def    bool
+		want   bool
+	}{
+		{name: "unset uses default", setEnv: false, def: true, want: true},
+		{name: "empty uses default", envVal: "", setEnv: true, def: true, want: true},
+		{name: "true", envVal: "true", setEnv: true, def: false, want: true},
+		{name: "numeric true", envVal: "1", setEnv: true, def: false, want: true},
+		{name: "false", envVal: "false", setEnv: true, def: true, want: false},
+		{name: "whitespace trimmed", envVal: " true ", setEnv: true, def: false, want: true},
+		{name: "invalid uses default", envVal: "yes please", setEnv: true, def: true, want: true},
+	}
+
+	const key = "TEST_GET_ENV_BOOL"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Unsetenv(key)
+			defer os.Unsetenv(key)
+
+			if tt.setEnv {
+				os.Setenv(key, tt.envVal)
+			}
+
+			if got := getEnvBool(os.LookupEnv, key, tt.def); got != tt.want {
+				t.Errorf("getEnvBool(%q, %v) = %v, want %v", tt.envVal, tt.def, got, tt.want)
+			}
+		})
+	}
 }
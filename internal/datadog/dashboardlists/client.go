@@ -0,0 +1,99 @@
+// Package dashboardlists downloads Datadog manual dashboard lists together
+// with the dashboards in them.
+package dashboardlists
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+)
+
+func init() {
+	resource.Register(Kind)
+}
+
+// Kind registers manual dashboard lists as "dd-tf dashboard-lists". Each list
+// is written with its "dashboards" set to the list's members, fetched from
+// the v2 API as the v1 list endpoints leave it empty.
+var Kind = &resource.ListKind{
+	KindName:   "dashboard list",
+	PluralName: "dashboard-lists",
+	IDType:     resource.IDNumeric,
+	Template: func(settings *config.Settings) string {
+		return settings.DashboardListsPathTemplate
+	},
+	List: listDashboardLists,
+	Get:  fetchDashboardList,
+}
+
+// Member is a dashboard in a list, as the v2 API identifies it.
+type Member struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+func listDashboardLists(ctx context.Context, client resource.HTTPClient, settings *config.Settings) ([]json.RawMessage, error) {
+	raw, err := resource.FetchRawFromAPI(ctx, client, settings.APIBaseURL()+"/api/v1/dashboard/lists/manual", settings)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		DashboardLists []json.RawMessage `json:"dashboard_lists"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode dashboard lists: %w", err)
+	}
+	lists := make([]json.RawMessage, 0, len(resp.DashboardLists))
+	for _, list := range resp.DashboardLists {
+		list, err := withMembers(ctx, client, settings, list)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+	return lists, nil
+}
+
+func fetchDashboardList(ctx context.Context, client resource.HTTPClient, settings *config.Settings, id string) (json.RawMessage, error) {
+	raw, err := resource.FetchRawFromAPI(ctx, client, settings.APIBaseURL()+"/api/v1/dashboard/lists/manual/"+id, settings)
+	if err != nil {
+		return nil, err
+	}
+	return withMembers(ctx, client, settings, raw)
+}
+
+// withMembers sets the "dashboards" of a list to its members.
+func withMembers(ctx context.Context, client resource.HTTPClient, settings *config.Settings, list json.RawMessage) (json.RawMessage, error) {
+	meta, err := resource.DecodeMeta(list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode dashboard list: %w", err)
+	}
+	members, err := FetchMembers(ctx, client, settings, meta.ID)
+	if err != nil {
+		return nil, fmt.Errorf("dashboard list %s: %w", meta.ID, err)
+	}
+	membersJSON, err := json.Marshal(members)
+	if err != nil {
+		return nil, err
+	}
+	return resource.SetField(list, "dashboards", membersJSON)
+}
+
+// FetchMembers returns the dashboards in list id, in the list's order.
+func FetchMembers(ctx context.Context, client resource.HTTPClient, settings *config.Settings, id string) ([]Member, error) {
+	raw, err := resource.FetchRawFromAPI(ctx, client, settings.APIBaseURL()+"/api/v2/dashboard/lists/manual/"+id+"/dashboards", settings)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Dashboards []Member `json:"dashboards"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode dashboard list members: %w", err)
+	}
+	members := make([]Member, 0, len(resp.Dashboards))
+	return append(members, resp.Dashboards...), nil
+}
@@ -0,0 +1,103 @@
+package dashboardlists
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/resource/resourcetest"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/dashboard/lists/manual":
+			w.Write([]byte(`{"dashboard_lists":[` +
+				`{"id":101,"name":"Team A / Services","dashboard_count":2,"dashboards":null},` +
+				`{"id":102,"name":"Empty","dashboard_count":0,"dashboards":null}]}`))
+		case "/api/v1/dashboard/lists/manual/101":
+			w.Write([]byte(`{"id":101,"name":"Team A / Services","dashboard_count":2,"dashboards":null}`))
+		case "/api/v2/dashboard/lists/manual/101/dashboards":
+			w.Write([]byte(`{"dashboards":[{"id":"abc-def-ghi","type":"custom_timeboard","title":"Web"},{"id":"jkl-mno-pqr","type":"custom_screenboard","title":"API"}],"total":2}`))
+		case "/api/v2/dashboard/lists/manual/102/dashboards":
+			w.Write([]byte(`{"dashboards":[],"total":0}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestKind_DownloadAll(t *testing.T) {
+	server := newTestServer(t)
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                       server.URL,
+		DashboardListsPathTemplate: filepath.Join(dir, "{id}-{name}.json"),
+		HTTPMaxBodySize:            4096,
+	}
+
+	paths := resourcetest.DownloadAll(t, Kind, settings, resource.BaseDownloadOptions{All: true})
+	want := []string{filepath.Join(dir, "101-Team-A-Services.json"), filepath.Join(dir, "102-Empty.json")}
+	if len(paths) != 2 || paths[0] != want[0] || paths[1] != want[1] {
+		t.Fatalf("download --all wrote %v, want %v", paths, want)
+	}
+
+	written, err := os.ReadFile(want[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := `{
+  "id": 101,
+  "name": "Team A / Services",
+  "dashboard_count": 2,
+  "dashboards": [
+    {
+      "id": "abc-def-ghi",
+      "type": "custom_timeboard"
+    },
+    {
+      "id": "jkl-mno-pqr",
+      "type": "custom_screenboard"
+    }
+  ]
+}
+`
+	if string(written) != wantJSON {
+		t.Errorf("list written as\n%s\nwant\n%s", written, wantJSON)
+	}
+	empty, _ := os.ReadFile(want[1])
+	if want := "\"dashboards\": []"; !strings.Contains(string(empty), want) {
+		t.Errorf("empty list written as %s, want it to contain %s", empty, want)
+	}
+}
+
+func TestKind_UpdateByIntID(t *testing.T) {
+	server := newTestServer(t)
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                       server.URL,
+		DashboardListsPathTemplate: filepath.Join(dir, "{id}-{name}.json"),
+		HTTPMaxBodySize:            4096,
+	}
+	existing := filepath.Join(dir, "services.json")
+	if err := os.WriteFile(existing, []byte(`{"id":101,"name":"old"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := resourcetest.DownloadAll(t, Kind, settings, resource.BaseDownloadOptions{Update: true})
+	if len(paths) != 1 || paths[0] != existing {
+		t.Fatalf("download --update wrote %v, want [%s]", paths, existing)
+	}
+	written, _ := os.ReadFile(existing)
+	if !strings.Contains(string(written), "abc-def-ghi") {
+		t.Errorf("list written as %s, want its members", written)
+	}
+}
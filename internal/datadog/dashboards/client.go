@@ -1,10 +1,13 @@
 package dashboards
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"os"
 	"regexp"
 	"strings"
 
@@ -33,56 +36,89 @@ type DownloadOptions struct {
 	resource.BaseDownloadOptions // Embedded common options
 }
 
-// fetchAndFilterDashboards fetches dashboards from the Datadog API, optionally filtered by tags.
-// If fullData is true, returns complete dashboard data; if false, returns minimal data (just IDs).
-func fetchAndFilterDashboards(filterTags []string, fullData bool) (map[string]map[string]any, error) {
-	settings, err := config.LoadSettings()
-	if err != nil {
-		return nil, err
-	}
+// DashboardMeta is the subset of dashboard fields needed for filtering and
+// path templating. It is decoded from the raw payload with a targeted struct
+// so the full dashboard never needs to be materialised as a map.
+type DashboardMeta struct {
+	ID    string   `json:"id"`
+	Title string   `json:"title"`
+	Tags  []string `json:"tags"`
+	List  string   `json:"-"` // Manual dashboard list name, looked up for {list}
+}
 
-	client := internalhttp.GetHTTPClient(settings)
+// DashboardSummary holds the fields returned for each dashboard by the list
+// endpoint. Everything except tags is available without fetching the full
+// dashboard.
+type DashboardSummary struct {
+	ID           string `json:"id"`
+	Title        string `json:"title"`
+	AuthorHandle string `json:"author_handle"`
+	CreatedAt    string `json:"created_at"`
+	ModifiedAt   string `json:"modified_at"`
+	URL          string `json:"url"`
+}
+
+// fetchAndFilterDashboards pages through the dashboards list endpoint and
+// calls emit for every dashboard, optionally filtered by tags.
+// If fullData is false and there are no filter tags, only the list summaries
+// are emitted (data is nil). Otherwise each dashboard is fetched individually and emitted
+// with its complete data as soon as it matches; payloads that don't match are
+// dropped straight away so memory use stays bounded by a single dashboard.
+func fetchAndFilterDashboards(ctx context.Context, client resource.HTTPClient, apiBase string, settings *config.Settings, filterTags []string, fullData bool, emit func(summary DashboardSummary, data json.RawMessage)) error {
+	needDetail := len(filterTags) > 0 || fullData
 
 	// Fetch all dashboard IDs with pagination
 	// Dashboards API uses 'start' and 'count' parameters for pagination
-	var allDashboardIDs []string
-	pagination := resource.NewOffsetPagination(settings.PageSize)
+	pagination := resource.NewOffsetPagination(settings.DashboardsPageSize)
 	for {
-		url := pagination.FormatOffsetURL(fmt.Sprintf("https://api.%s/api/v1/dashboard", settings.Site))
-		resp, err := client.Get(url)
+		url := pagination.FormatOffsetURL(apiBase + "/api/v1/dashboard")
+		resp, err := client.GetWithContext(ctx, url)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch dashboards (start=%d): %w", pagination.Start, err)
+			return fmt.Errorf("failed to fetch dashboards (start=%d): %w", pagination.Start, err)
 		}
 
-		if resp.StatusCode != http.StatusOK {
-			body, err := io.ReadAll(io.LimitReader(resp.Body, settings.HTTPMaxBodySize))
+		if err := resource.CheckResponse(resp, settings); err != nil {
 			resp.Body.Close()
-			if err != nil {
-				return nil, fmt.Errorf("API error %s (start=%d) (failed to read response body: %w)", resp.Status, pagination.Start, err)
+			if pagination.ShrinkAfterError(err) {
+				logging.Logger.Warn("page size rejected by API, retrying with a smaller page", "count", pagination.Count, "error", err)
+				continue
 			}
-			return nil, fmt.Errorf("API error (start=%d): %s\n%s", pagination.Start, resp.Status, string(body))
+			return fmt.Errorf("dashboards list (start=%d): %w", pagination.Start, err)
 		}
 
-		// Parse response to get dashboard IDs
+		// Parse response to get dashboard summaries
 		var result struct {
-			Dashboards []struct {
-				ID string `json:"id"`
-			} `json:"dashboards"`
+			Dashboards []DashboardSummary `json:"dashboards"`
 		}
 
 		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 			resp.Body.Close()
-			return nil, fmt.Errorf("failed to decode response (start=%d): %w", pagination.Start, err)
+			return fmt.Errorf("failed to decode response (start=%d): %w", pagination.Start, err)
 		}
 		resp.Body.Close()
+		logging.Logger.Debug("fetched dashboards page", "start", pagination.Start, "count", pagination.Count, "returned", len(result.Dashboards))
 
 		if len(result.Dashboards) == 0 {
 			break
 		}
 
 		for _, dashboard := range result.Dashboards {
-			if dashboard.ID != "" {
-				allDashboardIDs = append(allDashboardIDs, dashboard.ID)
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if dashboard.ID == "" {
+				continue
+			}
+			// If no filtering and we don't need full data, the summary will do
+			if !needDetail {
+				emit(dashboard, nil)
+				continue
+			}
+			if data, ok := fetchMatchingDashboard(ctx, client, apiBase, settings, dashboard.ID, filterTags); ok {
+				if !fullData {
+					data = nil
+				}
+				emit(dashboard, data)
 			}
 		}
 
@@ -92,64 +128,43 @@ func fetchAndFilterDashboards(filterTags []string, fullData bool) (map[string]ma
 		}
 	}
 
-	// If no filtering and we don't need full data, return early with just IDs
-	if len(filterTags) == 0 && !fullData {
-		dashboards := make(map[string]map[string]any, len(allDashboardIDs))
-		for _, id := range allDashboardIDs {
-			dashboards[id] = nil // No data needed, just ID
-		}
-		return dashboards, nil
-	}
-
-	// Fetch individual dashboards when filtering or when full data is needed
-	dashboards := make(map[string]map[string]any)
-	for _, id := range allDashboardIDs {
-
-		// Fetch full dashboard to get tags (and potentially cache the data)
-		dashboardURL := fmt.Sprintf("https://api.%s/api/v1/dashboard/%s", settings.Site, id)
-		dashResp, err := client.Get(dashboardURL)
-		if err != nil {
-			logging.Logger.Warn("failed to fetch dashboard", "id", id, "error", err)
-			continue
-		}
+	return nil
+}
 
-		if dashResp.StatusCode != http.StatusOK {
-			dashResp.Body.Close()
-			logging.Logger.Warn("failed to fetch dashboard", "id", id, "status", dashResp.Status)
-			continue
-		}
+// ListDashboards returns the list endpoint summary of every dashboard,
+// without fetching any dashboard individually.
+func ListDashboards(ctx context.Context, client resource.HTTPClient, settings *config.Settings) ([]DashboardSummary, error) {
+	var summaries []DashboardSummary
+	err := fetchAndFilterDashboards(ctx, client, settings.APIBaseURL(), settings, nil, false, func(summary DashboardSummary, _ json.RawMessage) {
+		summaries = append(summaries, summary)
+	})
+	return summaries, err
+}
 
-		var dashData map[string]any
-		if err := json.NewDecoder(dashResp.Body).Decode(&dashData); err != nil {
-			dashResp.Body.Close()
-			logging.Logger.Warn("failed to decode dashboard", "id", id, "error", err)
-			continue
-		}
-		dashResp.Body.Close()
-
-		// Extract tags for filtering
-		var tags []string
-		if tagsInterface, ok := dashData["tags"]; ok {
-			if tagsArray, ok := tagsInterface.([]interface{}); ok {
-				for _, tag := range tagsArray {
-					if tagStr, ok := tag.(string); ok {
-						tags = append(tags, tagStr)
-					}
-				}
-			}
-		}
+// fetchMatchingDashboard fetches a single dashboard and reports whether it
+// has all of filterTags. Fetch and decode failures are logged and treated as
+// non-matching.
+func fetchMatchingDashboard(ctx context.Context, client resource.HTTPClient, apiBase string, settings *config.Settings, id string, filterTags []string) (json.RawMessage, bool) {
+	// Fetch full dashboard to get tags (and potentially cache the data)
+	dashboardURL := fmt.Sprintf("%s/api/v1/dashboard/%s", apiBase, id)
+	dashData, err := resource.FetchRawFromAPI(ctx, client, dashboardURL, settings)
+	if err != nil {
+		logging.Logger.Warn("failed to fetch dashboard", "id", id, "error", err)
+		return nil, false
+	}
 
-		// Check if dashboard has all required filter tags
-		if templating.HasAllTagsSlice(tags, filterTags) {
-			if fullData {
-				dashboards[id] = dashData
-			} else {
-				dashboards[id] = nil // Just store the ID
-			}
-		}
+	// Decode just the tags for filtering
+	var meta DashboardMeta
+	if err := json.Unmarshal(dashData, &meta); err != nil {
+		logging.Logger.Warn("failed to decode dashboard", "id", id, "error", err)
+		return nil, false
 	}
 
-	return dashboards, nil
+	// Check if dashboard has all required filter tags
+	if !templating.HasAllTagsSlice(meta.Tags, filterTags) {
+		return nil, false
+	}
+	return dashData, true
 }
 
 // normalizezDashboardID validates that the dashboard ID follows the expected
@@ -165,31 +180,44 @@ func normalizezDashboardID(id string) (string, error) {
 	return strings.ToLower(id), nil
 }
 
+// ValidateID checks a dashboard id decoded from a local file, as
+// "dd-tf validate" does. Values that aren't strings are left to the schema.
+func ValidateID(id any) error {
+	s, ok := id.(string)
+	if !ok {
+		return nil
+	}
+	_, err := normalizezDashboardID(s)
+	return err
+}
+
 // GenerateDashboardTargets returns a channel that yields dashboard IDs and target paths.
 // For --update mode, uses existing file paths. For other modes, computes paths from pattern.
 // Errors during target generation are returned as part of DashboardTargetResult.
-func GenerateDashboardTargets(opts DownloadOptions) (<-chan DashboardTargetResult, error) {
+// The producer stops, and closes the channel, once ctx is done.
+func GenerateDashboardTargets(ctx context.Context, client resource.HTTPClient, settings *config.Settings, opts DownloadOptions) (<-chan DashboardTargetResult, error) {
 	out := make(chan DashboardTargetResult)
 
-	settings, err := config.LoadSettings()
-	if err != nil {
-		close(out)
-		return nil, err
-	}
-
 	// --update: scan existing dashboard files and use their paths
 	if opts.Update {
 		go func() {
 			defer close(out)
 			// Extract the static directory prefix from the path template
 			dashboardsDir := templating.ExtractStaticPrefix(settings.DashboardsPathTemplate)
-			idToPath, err := storage.ExtractIDsFromJSONFiles(dashboardsDir)
+			backend, err := storage.NewBackend(settings)
+			if err != nil {
+				resource.Send(ctx, out, DashboardTargetResult{Err: err})
+				return
+			}
+			idToPath, err := storage.ExtractIDs(backend, dashboardsDir)
 			if err != nil {
-				out <- DashboardTargetResult{Err: fmt.Errorf("failed to scan directory: %w", err)}
+				resource.Send(ctx, out, DashboardTargetResult{Err: fmt.Errorf("failed to scan directory: %w", err)})
 				return
 			}
 			for id, path := range idToPath {
-				out <- DashboardTargetResult{Target: DashboardTarget{ID: id, Path: path}}
+				if !resource.Send(ctx, out, DashboardTargetResult{Target: DashboardTarget{ID: id, Path: path}}) {
+					return
+				}
 			}
 		}()
 		return out, nil
@@ -199,14 +227,11 @@ func GenerateDashboardTargets(opts DownloadOptions) (<-chan DashboardTargetResul
 	if opts.All {
 		go func() {
 			defer close(out)
-			dashboards, err := fetchAndFilterDashboards(nil, false)
+			err := allDashboardTargets(ctx, client, settings.APIBaseURL(), settings, opts.OutputPath, func(target DashboardTarget) {
+				resource.Send(ctx, out, DashboardTargetResult{Target: target})
+			})
 			if err != nil {
-				out <- DashboardTargetResult{Err: fmt.Errorf("failed to fetch all dashboards: %w", err)}
-				return
-			}
-			for id := range dashboards {
-				// Path will be computed in download function with actual title
-				out <- DashboardTargetResult{Target: DashboardTarget{ID: id, Path: ""}} // empty path means use pattern
+				resource.Send(ctx, out, DashboardTargetResult{Err: fmt.Errorf("failed to fetch all dashboards: %w", err)})
 			}
 		}()
 		return out, nil
@@ -222,13 +247,17 @@ func GenerateDashboardTargets(opts DownloadOptions) (<-chan DashboardTargetResul
 				// Validate dashboard ID format
 				normalizedId, err := normalizezDashboardID(id)
 				if err != nil {
-					out <- DashboardTargetResult{Err: fmt.Errorf("invalid dashboard ID %q: %w", id, err)}
+					if !resource.Send(ctx, out, DashboardTargetResult{Err: fmt.Errorf("invalid dashboard ID %q: %w", id, err)}) {
+						return
+					}
 					continue
 				}
 
 				// Empty path means use pattern. Path will be computed in
 				// download function with actual title
-				out <- DashboardTargetResult{Target: DashboardTarget{ID: normalizedId, Path: ""}}
+				if !resource.Send(ctx, out, DashboardTargetResult{Target: DashboardTarget{ID: normalizedId, Path: ""}}) {
+					return
+				}
 			}
 		}()
 		return out, nil
@@ -251,18 +280,19 @@ func GenerateDashboardTargets(opts DownloadOptions) (<-chan DashboardTargetResul
 	if len(filterTags) > 0 {
 		go func() {
 			defer close(out)
-			dashboards, err := fetchAndFilterDashboards(filterTags, true)
+			found := 0
+			err := fetchAndFilterDashboards(ctx, client, settings.APIBaseURL(), settings, filterTags, true, func(summary DashboardSummary, data json.RawMessage) {
+				found++
+				// Include cached data to avoid duplicate API call
+				resource.Send(ctx, out, DashboardTargetResult{Target: DashboardTarget{ID: summary.ID, Path: "", Data: data, Modified: summary.ModifiedAt}})
+			})
 			if err != nil {
-				out <- DashboardTargetResult{Err: fmt.Errorf("failed to fetch dashboards by tags: %w", err)}
+				resource.Send(ctx, out, DashboardTargetResult{Err: fmt.Errorf("failed to fetch dashboards by tags: %w", err)})
 				return
 			}
-			if len(dashboards) == 0 {
+			if found == 0 {
 				logging.Logger.Warn("no dashboards found with tags", "tags", filterTags)
 			}
-			for id, data := range dashboards {
-				// Include cached data to avoid duplicate API call
-				out <- DashboardTargetResult{Target: DashboardTarget{ID: id, Path: "", Data: data}}
-			}
 		}()
 		return out, nil
 	}
@@ -271,61 +301,256 @@ func GenerateDashboardTargets(opts DownloadOptions) (<-chan DashboardTargetResul
 	return nil, fmt.Errorf("please specify --id, --all, --team, --tags, or --update")
 }
 
+// allDashboardTargets lists every dashboard and calls emit with a target for
+// each. When the path template only needs fields present in the list summary
+// (no tag or {list} placeholders), the path is computed straight from the summary so
+// the download doesn't have to derive it from the full payload. Otherwise the
+// path is left empty and computed at download time.
+func allDashboardTargets(ctx context.Context, client resource.HTTPClient, apiBase string, settings *config.Settings, outputPath string, emit func(DashboardTarget)) error {
+	pattern := outputPath
+	if pattern == "" {
+		pattern = settings.DashboardsPathTemplate
+	}
+	fromSummary := !templating.ReferencesTags(pattern, templating.BuildDashboardBuiltins()) && !referencesList(pattern)
+
+	return fetchAndFilterDashboards(ctx, client, apiBase, settings, nil, false, func(summary DashboardSummary, _ json.RawMessage) {
+		target := DashboardTarget{ID: summary.ID, Modified: summary.ModifiedAt} // empty path means use pattern
+		if fromSummary {
+			path, err := ComputeDashboardPath(settings, DashboardMeta{ID: summary.ID, Title: summary.Title}, outputPath)
+			if err != nil {
+				logging.Logger.Warn("failed to compute path from summary", "id", summary.ID, "error", err)
+			} else {
+				target.Path = path
+			}
+		}
+		emit(target)
+	})
+}
+
 // DownloadDashboardWithOptions fetches a dashboard and writes it to the specified path.
 // Uses cached data from target.Data if available to avoid duplicate API calls.
 // If target.Path is empty, computes the path using the configured pattern or outputPath override.
-func DownloadDashboardWithOptions(target DashboardTarget, outputPath string) error {
+// Returns the path written. A dashboard missing one of REQUIRED_TAGS returns a
+// *resource.UntaggedError, as for monitors.DownloadMonitorWithOptions. When
+// target.Path is already a file the fetch is conditional, and a dashboard
+// unchanged since it was written returns resource.ErrNotModified.
+func DownloadDashboardWithOptions(ctx context.Context, client resource.HTTPClient, settings *config.Settings, target DashboardTarget, outputPath string) (string, error) {
 	normalizedId, err := normalizezDashboardID(target.ID)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	target.ID = normalizedId
 
-	settings, err := config.LoadSettings()
-	if err != nil {
-		return err
-	}
-
-	var result map[string]any
+	var (
+		raw         json.RawMessage
+		conditional *internalhttp.ConditionalGet
+	)
 
 	// Use cached data if available (from tag filtering)
 	if target.Data != nil {
-		result = target.Data
+		raw = target.Data
 	} else {
-		// Fetch from API
-		client := internalhttp.GetHTTPClient(settings)
-		url := fmt.Sprintf("https://api.%s/api/v1/dashboard/%s", settings.Site, target.ID)
+		// Fetch from API, only if changed when the file is already there
+		fetchCtx := ctx
+		if target.Path != "" && (settings.StorageBackend == "" || settings.StorageBackend == "file") {
+			fetchCtx, conditional = internalhttp.Conditional(ctx, etagKey(settings, target.ID))
+			if _, err := os.Stat(target.Path); err != nil {
+				fetchCtx = internalhttp.WithoutCache(fetchCtx)
+			}
+		}
 		var err error
-		result, err = resource.FetchResourceFromAPI(client, url, settings)
+		raw, err = fetchDashboard(fetchCtx, client, settings, target.ID)
+		if errors.Is(err, resource.ErrNotModified) {
+			if settings.DashboardsWithRestrictionPolicy {
+				// Policies change independently of the dashboard
+				if _, err := resource.DownloadRestrictionPolicy(ctx, client, settings, "dashboard", target.ID, target.Path); err != nil {
+					return "", err
+				}
+			}
+			return target.Path, err
+		}
 		if err != nil {
-			return err
+			return "", err
 		}
 	}
+	raw, err = NormalizeDashboard(raw, settings)
+	if err != nil {
+		return "", err
+	}
+
+	var meta DashboardMeta
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return "", fmt.Errorf("failed to decode dashboard: %w", err)
+	}
 
 	// Compute path if not provided (--update uses existing path)
 	targetPath := target.Path
 	if targetPath == "" {
-		var err error
-		targetPath, err = ComputeDashboardPath(settings, result, outputPath)
+		pattern := outputPath
+		if pattern == "" {
+			pattern = settings.DashboardsPathTemplate
+		}
+		if referencesList(pattern) {
+			meta.List, err = listName(ctx, client, settings, meta.ID)
+			if err != nil {
+				return "", err
+			}
+		}
+		targetPath, err = ComputeDashboardPath(settings, meta, outputPath)
 		if err != nil {
-			return err
+			return "", err
+		}
+	}
+
+	untagged := resource.CheckRequiredTags(settings, meta.Title, templating.ExtractTagMap(meta.Tags, false))
+	if untagged != nil {
+		if settings.OnMissingRequiredTag != config.OnMissingTagQuarantine {
+			return "", untagged
+		}
+		template := outputPath
+		if template == "" {
+			template = settings.DashboardsPathTemplate
+		}
+		untagged.Path = resource.QuarantinePath(template, targetPath)
+		targetPath = untagged.Path
+	}
+
+	// Write JSON file, preserving the API's key order
+	backend, err := storage.NewBackend(settings)
+	if err != nil {
+		return "", err
+	}
+	if err := writeDashboard(backend, targetPath, raw, settings.DashboardsSplitPresets); err != nil {
+		return "", err
+	}
+	if settings.DashboardsWriteSummary {
+		if err := writeSummary(backend, targetPath, raw); err != nil {
+			return "", err
+		}
+	}
+	if settings.DashboardsWithRestrictionPolicy {
+		if _, err := resource.DownloadRestrictionPolicy(ctx, client, settings, "dashboard", meta.ID, targetPath); err != nil {
+			return "", err
 		}
 	}
 
-	// Write JSON file
-	if err := storage.WriteJSONFile(targetPath, result); err != nil {
-		return err
+	if untagged != nil {
+		return targetPath, untagged
+	}
+	// Only now is the file up to date with the response's ETag
+	if conditional != nil {
+		if err := conditional.Commit(); err != nil {
+			logging.Logger.Warn("ignoring HTTP cache", "error", err)
+		}
 	}
+	return targetPath, nil
+}
 
-	logging.Logger.Info("dashboard saved", "path", targetPath)
-	return nil
+// etagKey is the ETag cache key of dashboard id: its URL, and a hash of the
+// settings its file depends on, so that changing them downloads dashboards
+// in full again.
+func etagKey(settings *config.Settings, id string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%q %t %t %t %q %q", settings.DashboardsStripFields, settings.DashboardsStripWidgetIDs,
+		settings.DashboardsSplitPresets, settings.DashboardsWriteSummary, settings.RequiredTags, settings.OnMissingRequiredTag)
+	return fmt.Sprintf("%s/api/v1/dashboard/%s#%x", settings.APIBaseURL(), id, h.Sum(nil)[:8])
+}
+
+// FetchDashboardJSON fetches a single dashboard and returns it formatted
+// exactly as it would be written to a file.
+func FetchDashboardJSON(ctx context.Context, client resource.HTTPClient, settings *config.Settings, id string) ([]byte, error) {
+	normalizedId, err := normalizezDashboardID(id)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := fetchDashboard(ctx, client, settings, normalizedId)
+	if err != nil {
+		return nil, err
+	}
+	raw, err = NormalizeDashboard(raw, settings)
+	if err != nil {
+		return nil, err
+	}
+	return storage.FormatJSON(raw)
+}
+
+// fetchDashboard fetches the raw JSON for a single dashboard.
+func fetchDashboard(ctx context.Context, client resource.HTTPClient, settings *config.Settings, id string) (json.RawMessage, error) {
+	url := fmt.Sprintf("%s/api/v1/dashboard/%s", settings.APIBaseURL(), id)
+	return resource.FetchRawFromAPI(ctx, client, url, settings)
+}
+
+// NormalizeDashboard removes settings.DashboardsStripFields, and widget IDs
+// when settings.DashboardsStripWidgetIDs is set; they're reassigned by
+// Datadog and churn on every edit. Dashboards are normalized with it when
+// downloaded, compared and pushed, so both sides of a comparison must use the
+// same settings.
+func NormalizeDashboard(raw json.RawMessage, settings *config.Settings) (json.RawMessage, error) {
+	raw, err := resource.StripPaths(raw, settings.DashboardsStripFields...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to strip fields: %w", err)
+	}
+	if !settings.DashboardsStripWidgetIDs {
+		return raw, nil
+	}
+	raw, err = resource.RewriteObject(raw, func(key string, value json.RawMessage) (json.RawMessage, bool, error) {
+		if key != "widgets" {
+			return value, true, nil
+		}
+		value, err := stripWidgetIDs(value)
+		return value, true, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to strip widget IDs: %w", err)
+	}
+	return raw, nil
+}
+
+// stripWidgetIDs removes the id of each widget in a widgets array, recursing
+// into the definition.widgets of group widgets. Other keys, including
+// definition fields such as alert_id, are left alone.
+func stripWidgetIDs(widgets json.RawMessage) (json.RawMessage, error) {
+	if !isJSON(widgets, '[') {
+		return widgets, nil
+	}
+	return resource.RewriteArray(widgets, func(widget json.RawMessage) (json.RawMessage, error) {
+		if !isJSON(widget, '{') {
+			return widget, nil
+		}
+		return resource.RewriteObject(widget, func(key string, value json.RawMessage) (json.RawMessage, bool, error) {
+			switch key {
+			case "id":
+				return nil, false, nil
+			case "definition":
+				if !isJSON(value, '{') {
+					return value, true, nil
+				}
+				value, err := resource.RewriteObject(value, func(key string, value json.RawMessage) (json.RawMessage, bool, error) {
+					if key != "widgets" {
+						return value, true, nil
+					}
+					value, err := stripWidgetIDs(value)
+					return value, true, err
+				})
+				return value, true, err
+			}
+			return value, true, nil
+		})
+	})
+}
+
+// isJSON reports whether raw is a JSON value starting with delim, e.g. '{'.
+func isJSON(raw json.RawMessage, delim byte) bool {
+	trimmed := bytes.TrimSpace(raw)
+	return len(trimmed) > 0 && trimmed[0] == delim
 }
 
 // dashboardTemplateData holds the data available in path templates
 type dashboardTemplateData struct {
 	ID    string
 	Title string
+	List  string
 	Tags  map[string]string
 }
 
@@ -334,8 +559,9 @@ type dashboardTemplateData struct {
 //
 //	{{.ID}} - dashboard ID
 //	{{.Title}} - sanitized dashboard title
+//	{{.List}} - sanitized dashboard.List, "none" if empty
 //	{{.Tags.x}} - value of "x" tag (empty if not found)
-func ComputeDashboardPath(settings *config.Settings, dashboard map[string]any, outputPath string) (string, error) {
+func ComputeDashboardPath(settings *config.Settings, dashboard DashboardMeta, outputPath string) (string, error) {
 	// Use outputPath override if provided, otherwise use setting
 	pattern := outputPath
 	if pattern == "" {
@@ -347,25 +573,31 @@ func ComputeDashboardPath(settings *config.Settings, dashboard map[string]any, o
 	pattern = templating.TranslatePlaceholders(pattern, templating.BuildDashboardBuiltins())
 
 	// Extract and sanitize tags from dashboard
-	tagMap := templating.ExtractTagMap(dashboard["tags"], true)
+	tagMap := templating.ExtractTagMap(dashboard.Tags, true)
 
 	// Extract ID - required field
-	id, ok := dashboard["id"].(string)
-	if !ok || id == "" {
+	id := dashboard.ID
+	if id == "" {
 		return "", fmt.Errorf("dashboard missing valid 'id' field")
 	}
 
 	// Extract title - use placeholder if missing
-	title, ok := dashboard["title"].(string)
-	if !ok || title == "" {
+	title := dashboard.Title
+	if title == "" {
 		logging.Logger.Warn("dashboard missing title; using placeholder", "id", id)
 		title = "untitled"
 	}
 
+	list := "none"
+	if dashboard.List != "" {
+		list = storage.SanitizeFilename(dashboard.List)
+	}
+
 	// Build template data
 	data := dashboardTemplateData{
 		ID:    id,
 		Title: storage.SanitizeFilename(title),
+		List:  list,
 		Tags:  tagMap,
 	}
 
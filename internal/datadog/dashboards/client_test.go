@@ -1,20 +1,39 @@
 package dashboards
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
 )
 
+// newTestClient returns an unshared Datadog client; tests point it at an
+// httptest server through the request URL.
+func newTestClient() resource.HTTPClient {
+	return internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+}
+
 func TestComputeDashboardPath_MissingFields(t *testing.T) {
 	settings := &config.Settings{
 		DashboardsPathTemplate: "data/dashboards/{id}-{title}.json",
 	}
 
 	t.Run("missing id field", func(t *testing.T) {
-		dashboard := map[string]any{
-			"title": "Test Dashboard",
+		dashboard := DashboardMeta{
+			Title: "Test Dashboard",
 		}
 
 		_, err := ComputeDashboardPath(settings, dashboard, "")
@@ -26,8 +45,8 @@ func TestComputeDashboardPath_MissingFields(t *testing.T) {
 	})
 
 	t.Run("missing title field", func(t *testing.T) {
-		dashboard := map[string]any{
-			"id": "abc-123",
+		dashboard := DashboardMeta{
+			ID: "abc-123",
 		}
 
 		path, err := ComputeDashboardPath(settings, dashboard, "")
@@ -44,9 +63,9 @@ func TestComputeDashboardPath_MissingFields(t *testing.T) {
 	})
 
 	t.Run("valid fields work normally", func(t *testing.T) {
-		dashboard := map[string]any{
-			"id":    "valid-123",
-			"title": "My Dashboard",
+		dashboard := DashboardMeta{
+			ID:    "valid-123",
+			Title: "My Dashboard",
 		}
 
 		path, err := ComputeDashboardPath(settings, dashboard, "")
@@ -67,10 +86,10 @@ func TestComputeDashboardPath_WithTags(t *testing.T) {
 	}
 
 	t.Run("with valid tags", func(t *testing.T) {
-		dashboard := map[string]any{
-			"id":    "dash-123",
-			"title": "Team Dashboard",
-			"tags":  []interface{}{"team:platform", "env:prod"},
+		dashboard := DashboardMeta{
+			ID:    "dash-123",
+			Title: "Team Dashboard",
+			Tags:  []string{"team:platform", "env:prod"},
 		}
 
 		path, err := ComputeDashboardPath(settings, dashboard, "")
@@ -87,10 +106,10 @@ func TestComputeDashboardPath_WithTags(t *testing.T) {
 	})
 
 	t.Run("missing tag uses default", func(t *testing.T) {
-		dashboard := map[string]any{
-			"id":    "dash-456",
-			"title": "No Team Dashboard",
-			"tags":  []interface{}{"env:prod"},
+		dashboard := DashboardMeta{
+			ID:    "dash-456",
+			Title: "No Team Dashboard",
+			Tags:  []string{"env:prod"},
 		}
 
 		path, err := ComputeDashboardPath(settings, dashboard, "")
@@ -110,9 +129,9 @@ func TestComputeDashboardPath_WithOutputOverride(t *testing.T) {
 		DashboardsPathTemplate: "data/dashboards/{id}.json", // Default pattern
 	}
 
-	dashboard := map[string]any{
-		"id":    "override-123",
-		"title": "Override Test",
+	dashboard := DashboardMeta{
+		ID:    "override-123",
+		Title: "Override Test",
 	}
 
 	t.Run("uses output override when provided", func(t *testing.T) {
@@ -204,3 +223,463 @@ func TestNormalizezDashboardID(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateID(t *testing.T) {
+	for _, id := range []any{"abc-def-ghi", "ABC-DEF-GHI", 123} {
+		if err := ValidateID(id); err != nil {
+			t.Errorf("ValidateID(%v) error = %v", id, err)
+		}
+	}
+	for _, id := range []any{"", "abc-def", "abc_def_ghi"} {
+		if err := ValidateID(id); err == nil {
+			t.Errorf("ValidateID(%q) expected an error", id)
+		}
+	}
+}
+
+// newDashboardsServer serves a dashboards list and per-dashboard details for
+// the given id -> tags map, recording each request in events.
+func newDashboardsServer(t *testing.T, ids []string, tags map[string][]string, record func(string)) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/dashboard" {
+			record("list")
+			var summaries []map[string]string
+			if r.URL.Query().Get("start") == "0" {
+				for _, id := range ids {
+					summaries = append(summaries, map[string]string{"id": id, "title": "Dash " + id})
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]any{"dashboards": summaries})
+			return
+		}
+		id := strings.TrimPrefix(r.URL.Path, "/api/v1/dashboard/")
+		record("fetch:" + id)
+		json.NewEncoder(w).Encode(map[string]any{"id": id, "title": "Dash " + id, "tags": tags[id]})
+	}))
+}
+
+func TestFetchAndFilterDashboards_StreamsMatches(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	record := func(e string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}
+
+	ids := []string{"aaa-aaa-aaa", "bbb-bbb-bbb", "ccc-ccc-ccc"}
+	tags := map[string][]string{
+		"aaa-aaa-aaa": {"team:platform"},
+		"bbb-bbb-bbb": {"team:web"},
+		"ccc-ccc-ccc": {"team:platform", "env:prod"},
+	}
+	server := newDashboardsServer(t, ids, tags, record)
+	defer server.Close()
+
+	settings := &config.Settings{DashboardsPageSize: 100, HTTPMaxBodySize: 1024}
+	emitted := map[string]json.RawMessage{}
+	err := fetchAndFilterDashboards(context.Background(), newTestClient(), server.URL, settings, []string{"team:platform"}, true, func(summary DashboardSummary, data json.RawMessage) {
+		record("emit:" + summary.ID)
+		emitted[summary.ID] = data
+	})
+	if err != nil {
+		t.Fatalf("fetchAndFilterDashboards() error = %v", err)
+	}
+
+	if len(emitted) != 2 || emitted["aaa-aaa-aaa"] == nil || emitted["ccc-ccc-ccc"] == nil {
+		t.Fatalf("unexpected emitted dashboards: %v", emitted)
+	}
+	if _, ok := emitted["bbb-bbb-bbb"]; ok {
+		t.Errorf("non-matching dashboard should not be emitted")
+	}
+
+	index := func(e string) int {
+		for i, got := range events {
+			if got == e {
+				return i
+			}
+		}
+		t.Fatalf("event %q not recorded: %v", e, events)
+		return -1
+	}
+	if index("emit:aaa-aaa-aaa") > index("fetch:ccc-ccc-ccc") {
+		t.Errorf("first match should be emitted before later dashboards are fetched: %v", events)
+	}
+}
+
+func TestFetchAndFilterDashboards_IDsOnly(t *testing.T) {
+	var fetches int
+	server := newDashboardsServer(t, []string{"aaa-aaa-aaa", "bbb-bbb-bbb"}, nil, func(e string) {
+		if strings.HasPrefix(e, "fetch:") {
+			fetches++
+		}
+	})
+	defer server.Close()
+
+	settings := &config.Settings{DashboardsPageSize: 100, HTTPMaxBodySize: 1024}
+	var got []string
+	err := fetchAndFilterDashboards(context.Background(), newTestClient(), server.URL, settings, nil, false, func(summary DashboardSummary, data json.RawMessage) {
+		if data != nil {
+			t.Errorf("expected no data for %s", summary.ID)
+		}
+		got = append(got, summary.ID)
+	})
+	if err != nil {
+		t.Fatalf("fetchAndFilterDashboards() error = %v", err)
+	}
+	if fmt.Sprint(got) != "[aaa-aaa-aaa bbb-bbb-bbb]" {
+		t.Errorf("got ids %v", got)
+	}
+	if fetches != 0 {
+		t.Errorf("expected no per-dashboard fetches, got %d", fetches)
+	}
+}
+
+func TestAllDashboardTargets_PathFromSummary(t *testing.T) {
+	ids := []string{"aaa-aaa-aaa", "bbb-bbb-bbb"}
+
+	t.Run("template without tags skips detail endpoint", func(t *testing.T) {
+		var fetches int
+		server := newDashboardsServer(t, ids, nil, func(e string) {
+			if strings.HasPrefix(e, "fetch:") {
+				fetches++
+			}
+		})
+		defer server.Close()
+
+		settings := &config.Settings{
+			DashboardsPathTemplate: "data/dashboards/{id}-{title}.json",
+			DashboardsPageSize:     100,
+			HTTPMaxBodySize:        1024,
+		}
+		var paths []string
+		err := allDashboardTargets(context.Background(), newTestClient(), server.URL, settings, "", func(target DashboardTarget) {
+			paths = append(paths, target.Path)
+		})
+		if err != nil {
+			t.Fatalf("allDashboardTargets() error = %v", err)
+		}
+
+		if fetches != 0 {
+			t.Errorf("expected no detail requests, got %d", fetches)
+		}
+		want := "[data/dashboards/aaa-aaa-aaa-Dash-aaa-aaa-aaa.json data/dashboards/bbb-bbb-bbb-Dash-bbb-bbb-bbb.json]"
+		if fmt.Sprint(paths) != want {
+			t.Errorf("paths = %v, want %v", paths, want)
+		}
+	})
+
+	t.Run("template with tags defers path to download", func(t *testing.T) {
+		server := newDashboardsServer(t, ids, nil, func(string) {})
+		defer server.Close()
+
+		settings := &config.Settings{
+			DashboardsPathTemplate: "data/dashboards/{team}/{id}.json",
+			DashboardsPageSize:     100,
+			HTTPMaxBodySize:        1024,
+		}
+		err := allDashboardTargets(context.Background(), newTestClient(), server.URL, settings, "", func(target DashboardTarget) {
+			if target.Path != "" {
+				t.Errorf("expected empty path for %s, got %s", target.ID, target.Path)
+			}
+		})
+		if err != nil {
+			t.Fatalf("allDashboardTargets() error = %v", err)
+		}
+	})
+}
+
+func TestGenerateDashboardTargets_Tags(t *testing.T) {
+	ids := []string{"aaa-aaa-aaa", "bbb-bbb-bbb"}
+	tags := map[string][]string{"aaa-aaa-aaa": {"team:platform"}, "bbb-bbb-bbb": {"team:web"}}
+	server := newDashboardsServer(t, ids, tags, func(string) {})
+	defer server.Close()
+
+	settings := &config.Settings{Site: server.URL, DashboardsPageSize: 100, HTTPMaxBodySize: 1024}
+	targets, err := GenerateDashboardTargets(context.Background(), newTestClient(), settings, DownloadOptions{
+		BaseDownloadOptions: resource.BaseDownloadOptions{Team: "platform"},
+	})
+	if err != nil {
+		t.Fatalf("GenerateDashboardTargets() error = %v", err)
+	}
+
+	var got []DashboardTarget
+	for result := range targets {
+		if result.Err != nil {
+			t.Fatalf("target error: %v", result.Err)
+		}
+		got = append(got, result.Target)
+	}
+	if len(got) != 1 || got[0].ID != "aaa-aaa-aaa" || got[0].Data == nil {
+		t.Errorf("targets = %+v, want aaa-aaa-aaa with cached data", got)
+	}
+}
+
+func TestGenerateDashboardTargets_StopsOnCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/dashboard" {
+			json.NewEncoder(w).Encode(map[string]any{"id": strings.TrimPrefix(r.URL.Path, "/api/v1/dashboard/"), "tags": []string{"team:web"}})
+			return
+		}
+		// The second list page hangs until the request is cancelled
+		if r.URL.Query().Get("start") != "0" {
+			<-r.Context().Done()
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"dashboards": []map[string]string{{"id": "aaa-aaa-aaa"}, {"id": "bbb-bbb-bbb"}}})
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		opts resource.BaseDownloadOptions
+	}{
+		{"all", resource.BaseDownloadOptions{All: true}},
+		{"tags", resource.BaseDownloadOptions{Tags: "team:web"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			settings := &config.Settings{Site: server.URL, DashboardsPageSize: 2, HTTPMaxBodySize: 1024}
+			targets, err := GenerateDashboardTargets(ctx, newTestClient(), settings, DownloadOptions{BaseDownloadOptions: tt.opts})
+			if err != nil {
+				t.Fatalf("GenerateDashboardTargets() error = %v", err)
+			}
+
+			// Take one target, then leave the producer blocked on either the
+			// next send or the hanging page request
+			if first := <-targets; first.Err != nil {
+				t.Fatalf("first target error: %v", first.Err)
+			}
+			cancel()
+
+			timeout := time.After(2 * time.Second)
+			for {
+				select {
+				case _, ok := <-targets:
+					if !ok {
+						return
+					}
+				case <-timeout:
+					t.Fatal("producer did not stop after cancel")
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateDashboardTargets_NoSelection(t *testing.T) {
+	if _, err := GenerateDashboardTargets(context.Background(), newTestClient(), &config.Settings{}, DownloadOptions{}); err == nil {
+		t.Error("GenerateDashboardTargets() expected error without a selection")
+	}
+}
+
+func TestDownloadDashboardWithOptions(t *testing.T) {
+	server := newDashboardsServer(t, nil, map[string][]string{"abc-def-ghi": {"team:platform"}}, func(string) {})
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                   server.URL,
+		DashboardsPathTemplate: filepath.Join(dir, "{team}", "{id}.json"),
+		HTTPMaxBodySize:        1024,
+	}
+	path, err := DownloadDashboardWithOptions(context.Background(), newTestClient(), settings, DashboardTarget{ID: "ABC-DEF-GHI"}, "")
+	if err != nil {
+		t.Fatalf("DownloadDashboardWithOptions() error = %v", err)
+	}
+	if want := filepath.Join(dir, "platform", "abc-def-ghi.json"); path != want {
+		t.Errorf("DownloadDashboardWithOptions() path = %q, want %q", path, want)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("dashboard file not written: %v", err)
+	}
+}
+
+func TestDownloadDashboardWithOptions_NotModified(t *testing.T) {
+	var conditional []bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-None-Match") != "")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"id":"abc-def-ghi","title":"Web","widgets":[]}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := &config.Settings{Site: server.URL, DashboardsPathTemplate: filepath.Join(dir, "{id}.json"), HTTPMaxBodySize: 1024}
+	client := internalhttp.New("test-api-key", "test-app-key", internalhttp.WithETagCache(internalhttp.NewETagCache(filepath.Join(dir, ".dd-tf-cache.json"))))
+	target := DashboardTarget{ID: "abc-def-ghi", Path: filepath.Join(dir, "abc-def-ghi.json")}
+	download := func() error {
+		t.Helper()
+		path, err := DownloadDashboardWithOptions(context.Background(), client, settings, target, "")
+		if path != target.Path {
+			t.Errorf("DownloadDashboardWithOptions() path = %q, want %q", path, target.Path)
+		}
+		return err
+	}
+
+	if err := download(); err != nil {
+		t.Fatal(err)
+	}
+	if err := download(); !errors.Is(err, resource.ErrNotModified) {
+		t.Errorf("second download error = %v, want ErrNotModified", err)
+	}
+	// A deleted file is fetched in full again
+	os.Remove(target.Path)
+	if err := download(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(target.Path); err != nil {
+		t.Errorf("dashboard file not written: %v", err)
+	}
+	// Changed settings rewrite the file
+	settings.DashboardsStripWidgetIDs = true
+	if err := download(); err != nil {
+		t.Fatal(err)
+	}
+	// A dashboard that failed isn't skipped next time
+	settings.RequiredTags = []string{"team"}
+	settings.OnMissingRequiredTag = config.OnMissingTagFail
+	for i := 0; i < 2; i++ {
+		var untagged *resource.UntaggedError
+		if _, err := DownloadDashboardWithOptions(context.Background(), client, settings, target, ""); !errors.As(err, &untagged) {
+			t.Errorf("untagged download %d error = %v, want *UntaggedError", i+1, err)
+		}
+	}
+	if fmt.Sprint(conditional) != "[false true false false false false]" {
+		t.Errorf("conditional requests = %v, want [false true false false false false]", conditional)
+	}
+}
+
+// replaySettings returns settings that serve the recorded fixtures in
+// testdata/fixtures.
+func replaySettings(pathTemplate string) *config.Settings {
+	return &config.Settings{
+		Site:                   "datadoghq.com",
+		DashboardsPathTemplate: pathTemplate,
+		DashboardsPageSize:     1000,
+		HTTPMaxBodySize:        10 * 1024 * 1024,
+		Fixtures:               "replay",
+		FixturesDir:            filepath.Join("testdata", "fixtures"),
+	}
+}
+
+// TestDownloadAllDashboards_Replay runs --all end to end against the
+// recorded fixtures in testdata/fixtures.
+func TestDownloadAllDashboards_Replay(t *testing.T) {
+	outDir := t.TempDir()
+	settings := replaySettings(filepath.Join(outDir, "{team}", "{id}.json"))
+	client := internalhttp.NewClient(settings)
+
+	targets, err := GenerateDashboardTargets(context.Background(), client, settings, DownloadOptions{BaseDownloadOptions: resource.BaseDownloadOptions{All: true}})
+	if err != nil {
+		t.Fatalf("GenerateDashboardTargets() error = %v", err)
+	}
+	for result := range targets {
+		if result.Err != nil {
+			t.Fatalf("target error: %v", result.Err)
+		}
+		if _, err := DownloadDashboardWithOptions(context.Background(), client, settings, result.Target, ""); err != nil {
+			t.Fatalf("DownloadDashboardWithOptions(%s) error = %v", result.Target.ID, err)
+		}
+	}
+
+	for _, path := range []string{
+		filepath.Join(outDir, "platform", "abc-def-ghi.json"),
+		filepath.Join(outDir, "web", "xyz-uvw-rst.json"),
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("expected dashboard file: %v", err)
+			continue
+		}
+		var meta DashboardMeta
+		if err := json.Unmarshal(data, &meta); err != nil {
+			t.Errorf("%s is not valid JSON: %v", path, err)
+		}
+	}
+}
+
+func TestFetchDashboardJSON_Replay(t *testing.T) {
+	settings := replaySettings("")
+
+	got, err := FetchDashboardJSON(context.Background(), internalhttp.NewClient(settings), settings, "ABC-DEF-GHI")
+	if err != nil {
+		t.Fatalf("FetchDashboardJSON() error = %v", err)
+	}
+	if !strings.HasPrefix(string(got), "{\n  \"id\": \"abc-def-ghi\",") || !strings.HasSuffix(string(got), "}\n") {
+		t.Errorf("FetchDashboardJSON() not formatted like a written file:\n%s", got)
+	}
+}
+
+func TestNormalizeDashboard_StripWidgetIDs(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "nested-widgets.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unchanged, err := NormalizeDashboard(raw, &config.Settings{})
+	if err != nil || string(unchanged) != string(raw) {
+		t.Errorf("NormalizeDashboard() without DashboardsStripWidgetIDs = %s, %v, want unchanged", unchanged, err)
+	}
+
+	got, err := NormalizeDashboard(raw, &config.Settings{DashboardsStripWidgetIDs: true})
+	if err != nil {
+		t.Fatalf("NormalizeDashboard() error = %v", err)
+	}
+	for _, id := range []string{"1001", "1002", "1003", "1004", "1005"} {
+		if strings.Contains(string(got), id) {
+			t.Errorf("widget id %s not stripped: %s", id, got)
+		}
+	}
+	for _, kept := range []string{`"id":"abc-def-ghi"`, `"alert_id":"12345"`, `"content":"deep"`, `"layout":{`} {
+		if !strings.Contains(string(compactJSON(t, got)), kept) {
+			t.Errorf("NormalizeDashboard() lost %s: %s", kept, got)
+		}
+	}
+	if !strings.HasPrefix(string(compactJSON(t, got)), `{"id":"abc-def-ghi","title":"Nested widgets","widgets":[{"definition":{"type":"group"`) {
+		t.Errorf("NormalizeDashboard() changed key order: %s", got)
+	}
+}
+
+func TestDownloadDashboardWithOptions_StripWidgetIDs(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "nested-widgets.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	settings := &config.Settings{
+		DashboardsPathTemplate:   filepath.Join(dir, "{id}.json"),
+		DashboardsStripWidgetIDs: true,
+	}
+	path, err := DownloadDashboardWithOptions(context.Background(), newTestClient(), settings, DashboardTarget{ID: "abc-def-ghi", Data: raw}, "")
+	if err != nil {
+		t.Fatalf("DownloadDashboardWithOptions() error = %v", err)
+	}
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(written), "1004") {
+		t.Errorf("written dashboard still has nested widget id: %s", written)
+	}
+	if !strings.Contains(string(written), `"abc-def-ghi"`) {
+		t.Errorf("written dashboard lost its id: %s", written)
+	}
+}
+
+// compactJSON removes insignificant whitespace from raw.
+func compactJSON(t *testing.T, raw []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		t.Fatalf("json.Compact() error = %v", err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,47 @@
+package dashboards
+
+import (
+	"context"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/diff"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+// CompareDashboard returns the dashboard in the file at path and the remote
+// dashboard with the same id, or with remoteID if it isn't empty, both
+// normalized as downloads are. Compare them ignoring the ServerFields. A
+// dashboard that doesn't exist remotely returns an error wrapping
+// resource.ErrNotFound.
+func CompareDashboard(ctx context.Context, client resource.HTTPClient, settings *config.Settings, backend storage.Backend, path, remoteID string) (local, remote []byte, err error) {
+	raw, err := ReadDashboard(backend, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	id, err := localDashboardID(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	if local, err = NormalizeDashboard(raw, settings); err != nil {
+		return nil, nil, err
+	}
+	if remoteID == "" {
+		remoteID = id
+	}
+	if remote, err = FetchDashboardJSON(ctx, client, settings, remoteID); err != nil {
+		return nil, nil, err
+	}
+	return local, remote, nil
+}
+
+// DiffDashboard compares the dashboard in the file at path with the remote
+// dashboard with the same id, as CompareDashboard does, and returns the
+// unified diff from the remote one to the file, or "" if they match.
+func DiffDashboard(ctx context.Context, client resource.HTTPClient, settings *config.Settings, backend storage.Backend, path string) (string, error) {
+	local, remote, err := CompareDashboard(ctx, client, settings, backend, path, "")
+	if err != nil {
+		return "", err
+	}
+	return diff.JSON("remote "+path, path, remote, local, ServerFields...)
+}
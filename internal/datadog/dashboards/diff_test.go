@@ -0,0 +1,72 @@
+package dashboards
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+func TestDiffDashboard(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/dashboard/abc-def-ghi":
+			w.Write([]byte(`{"id":"abc-def-ghi","title":"Web","modified_at":"2024-03-01","tags":["team:web"],"widgets":[{"id":123,"definition":{"type":"note"}}],"template_variable_presets":[{"name":"prod"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 4096, DashboardsStripWidgetIDs: true}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// Presets in their own file, no widget IDs and an older modified_at
+	// still match
+	path := write("abc-def-ghi.json", `{"id":"abc-def-ghi","title":"Web","modified_at":"2024-01-01","tags":["team:web"],"widgets":[{"definition":{"type":"note"}}]}`)
+	write("abc-def-ghi.presets.json", `[{"name":"prod"}]`)
+	got, err := DiffDashboard(context.Background(), client, settings, storage.FileBackend{}, path)
+	if err != nil || got != "" {
+		t.Errorf("DiffDashboard() = %q, %v, want in sync", got, err)
+	}
+
+	write("abc-def-ghi.json", `{"id":"abc-def-ghi","title":"Web frontends","tags":["team:web"],"widgets":[{"definition":{"type":"note"}}]}`)
+	got, err = DiffDashboard(context.Background(), client, settings, storage.FileBackend{}, path)
+	if err != nil {
+		t.Fatalf("DiffDashboard() error = %v", err)
+	}
+	if !strings.Contains(got, "-  \"title\": \"Web\",\n+  \"title\": \"Web frontends\",\n") {
+		t.Errorf("DiffDashboard() =\n%s\nwant the title change", got)
+	}
+
+	missing := write("xyz-xyz-xyz.json", `{"id":"xyz-xyz-xyz","title":"Gone"}`)
+	if _, err := DiffDashboard(context.Background(), client, settings, storage.FileBackend{}, missing); !errors.Is(err, resource.ErrNotFound) {
+		t.Errorf("DiffDashboard() of a dashboard missing remotely: error = %v, want ErrNotFound", err)
+	}
+
+	// A clone compared with its original
+	local, remote, err := CompareDashboard(context.Background(), client, settings, storage.FileBackend{}, missing, "abc-def-ghi")
+	if err != nil {
+		t.Fatalf("CompareDashboard(against abc-def-ghi) error = %v", err)
+	}
+	if !strings.Contains(string(local), `"xyz-xyz-xyz"`) || !strings.Contains(string(remote), `"abc-def-ghi"`) {
+		t.Errorf("CompareDashboard(against abc-def-ghi) = %s, %s, want the clone and the original", local, remote)
+	}
+}
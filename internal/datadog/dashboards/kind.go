@@ -0,0 +1,98 @@
+package dashboards
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/templating"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	resource.Register(Kind{})
+}
+
+// Kind registers dashboards with the generic resource commands.
+type Kind struct{}
+
+func (Kind) Name() string            { return resource.KindDashboard }
+func (Kind) Plural() string          { return "dashboards" }
+func (Kind) IDKind() resource.IDKind { return resource.IDString }
+
+func (Kind) PathTemplate(settings *config.Settings) string {
+	return settings.DashboardsPathTemplate
+}
+
+func (Kind) Builtins() map[string]string {
+	return templating.BuildDashboardBuiltins()
+}
+
+// AddFlags adds nothing: dashboards only use the shared selection flags.
+func (Kind) AddFlags(*pflag.FlagSet) {}
+
+// AddDownloadFlags adds --strip-widget-ids and --with-restriction-policy.
+func (Kind) AddDownloadFlags(flags *pflag.FlagSet) {
+	flags.Bool("strip-widget-ids", false, "Remove widget IDs from downloaded dashboards (default: DASHBOARDS_STRIP_WIDGET_IDS)")
+	flags.Bool("with-restriction-policy", false, "Also write each dashboard's restriction policy next to it, as <name>.policy.json (default: DASHBOARDS_WITH_RESTRICTION_POLICY)")
+}
+
+func (Kind) ApplyDownloadFlags(settings *config.Settings, flags *pflag.FlagSet) error {
+	if flags.Changed("strip-widget-ids") {
+		strip, err := flags.GetBool("strip-widget-ids")
+		if err != nil {
+			return err
+		}
+		settings.DashboardsStripWidgetIDs = strip
+	}
+	if flags.Changed("with-restriction-policy") {
+		with, err := flags.GetBool("with-restriction-policy")
+		if err != nil {
+			return err
+		}
+		settings.DashboardsWithRestrictionPolicy = with
+	}
+	return nil
+}
+
+func (Kind) Targets(ctx context.Context, client resource.HTTPClient, settings *config.Settings, opts resource.BaseDownloadOptions, _ *pflag.FlagSet) (<-chan resource.TargetResult[string], error) {
+	return GenerateDashboardTargets(ctx, client, settings, DownloadOptions{BaseDownloadOptions: opts})
+}
+
+func (Kind) ComputePath(settings *config.Settings, raw []byte) (string, []string, error) {
+	if referencesList(settings.DashboardsPathTemplate) {
+		// List membership isn't part of the dashboard's content
+		return "", nil, fmt.Errorf("the {list} placeholder needs the API; download the dashboards again instead")
+	}
+	var meta DashboardMeta
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return "", nil, fmt.Errorf("failed to decode dashboard: %w", err)
+	}
+	path, err := ComputeDashboardPath(settings, meta, "")
+	if err != nil {
+		return "", nil, err
+	}
+	missing := templating.MissingTags(settings.DashboardsPathTemplate, templating.BuildDashboardBuiltins(), templating.ExtractTagMap(meta.Tags, true))
+	return path, missing, nil
+}
+
+func (Kind) Fetch(ctx context.Context, client resource.HTTPClient, settings *config.Settings, id string) ([]byte, error) {
+	return FetchDashboardJSON(ctx, client, settings, id)
+}
+
+func (Kind) Download(ctx context.Context, client resource.HTTPClient, settings *config.Settings, target resource.Target[string], outputPath string) (string, error) {
+	return DownloadDashboardWithOptions(ctx, client, settings, target, outputPath)
+}
+
+func (Kind) Push(ctx context.Context, client resource.WriteClient, settings *config.Settings, target resource.Target[string], flags resource.PushFlags) (resource.PushResult, error) {
+	return PushDashboard(ctx, client, settings, target, flags)
+}
+
+func (Kind) Compare(ctx context.Context, client resource.HTTPClient, settings *config.Settings, backend storage.Backend, path, against string) ([]byte, []byte, error) {
+	return CompareDashboard(ctx, client, settings, backend, path, against)
+}
+
+func (Kind) IgnoredFields() []string { return ServerFields }
@@ -0,0 +1,197 @@
+package dashboards
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+// SkeletonName is the file in a split dashboard directory holding everything
+// except the widgets.
+const SkeletonName = storage.SplitSkeletonName
+
+// widgetFileRegex matches widget files in a split dashboard directory, e.g.
+// "001-timeseries.json". The number sets the widget's position.
+var widgetFileRegex = storage.SplitWidgetRegex
+
+// WidgetFile is one top-level widget of a split dashboard.
+type WidgetFile struct {
+	Name string // e.g. "001-timeseries.json"
+	Data json.RawMessage
+}
+
+// SplitWidgets splits a dashboard into a skeleton, with an empty widgets
+// array keeping its place, and one file per top-level widget. Group widgets
+// keep their nested widgets inline. Files are numbered with zero-padded
+// prefixes so they sort in dashboard order.
+func SplitWidgets(raw json.RawMessage) (skeleton json.RawMessage, widgets []WidgetFile, err error) {
+	var list []json.RawMessage
+	skeleton, err = resource.RewriteObject(raw, func(key string, value json.RawMessage) (json.RawMessage, bool, error) {
+		if key != "widgets" {
+			return value, true, nil
+		}
+		if err := json.Unmarshal(value, &list); err != nil {
+			return nil, false, fmt.Errorf("expected an array: %w", err)
+		}
+		return json.RawMessage("[]"), true, nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to split dashboard: %w", err)
+	}
+
+	width := len(strconv.Itoa(len(list)))
+	if width < 3 {
+		width = 3
+	}
+	for i, widget := range list {
+		var meta struct {
+			Definition struct {
+				Type string `json:"type"`
+			} `json:"definition"`
+		}
+		_ = json.Unmarshal(widget, &meta) // the type only names the file
+		name := fmt.Sprintf("%0*d", width, i+1)
+		if t := storage.SanitizeFilename(meta.Definition.Type); t != "" {
+			name += "-" + t
+		}
+		widgets = append(widgets, WidgetFile{Name: name + ".json", Data: widget})
+	}
+	return skeleton, widgets, nil
+}
+
+// JoinWidgets reassembles a dashboard split by SplitWidgets, ordering the
+// widgets by their numeric prefix. A skeleton without a widgets key gets one
+// at the end.
+func JoinWidgets(skeleton json.RawMessage, widgets []WidgetFile) (json.RawMessage, error) {
+	type numbered struct {
+		n    int
+		file WidgetFile
+	}
+	ordered := make([]numbered, 0, len(widgets))
+	seen := map[int]string{}
+	for _, w := range widgets {
+		m := widgetFileRegex.FindStringSubmatch(w.Name)
+		if m == nil {
+			return nil, fmt.Errorf("widget file %s has no numeric prefix", w.Name)
+		}
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return nil, fmt.Errorf("widget file %s: %w", w.Name, err)
+		}
+		if other, ok := seen[n]; ok {
+			return nil, fmt.Errorf("widget files %s and %s have the same position", other, w.Name)
+		}
+		seen[n] = w.Name
+		ordered = append(ordered, numbered{n, w})
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].n < ordered[j].n })
+
+	var list bytes.Buffer
+	list.WriteByte('[')
+	for i, w := range ordered {
+		if i > 0 {
+			list.WriteByte(',')
+		}
+		data := bytes.TrimSpace(w.file.Data)
+		if !json.Valid(data) {
+			return nil, fmt.Errorf("widget file %s is not valid JSON", w.file.Name)
+		}
+		list.Write(data)
+	}
+	list.WriteByte(']')
+
+	found := false
+	raw, err := resource.RewriteObject(skeleton, func(key string, value json.RawMessage) (json.RawMessage, bool, error) {
+		if key != "widgets" {
+			return value, true, nil
+		}
+		found = true
+		return list.Bytes(), true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to join dashboard: %w", err)
+	}
+	if found {
+		return raw, nil
+	}
+	out := append([]byte{}, raw[:len(raw)-1]...)
+	if len(out) > 1 {
+		out = append(out, ',')
+	}
+	out = append(out, `"widgets":`...)
+	out = append(out, list.Bytes()...)
+	return append(out, '}'), nil
+}
+
+// WriteSplit splits a dashboard into dir, replacing any widget files from an
+// earlier split so removed widgets don't come back on join.
+func WriteSplit(dir string, raw json.RawMessage) error {
+	skeleton, widgets, err := SplitWidgets(raw)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() && widgetFileRegex.MatchString(e.Name()) {
+			if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+				return fmt.Errorf("failed to remove old widget file: %w", err)
+			}
+		}
+	}
+
+	if err := storage.WriteRawJSONFile(filepath.Join(dir, SkeletonName), skeleton); err != nil {
+		return err
+	}
+	for _, w := range widgets {
+		if err := storage.WriteRawJSONFile(filepath.Join(dir, w.Name), w.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadSplit joins the split dashboard in dir.
+func ReadSplit(backend storage.Backend, dir string) (json.RawMessage, error) {
+	paths, err := backend.List(dir)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		skeleton json.RawMessage
+		widgets  []WidgetFile
+	)
+	for _, p := range paths {
+		name := filepath.Base(p)
+		if filepath.Dir(p) != filepath.Clean(dir) {
+			continue // nested directories aren't part of the split
+		}
+		if name != SkeletonName && !widgetFileRegex.MatchString(name) {
+			continue
+		}
+		data, err := backend.Read(p)
+		if err != nil {
+			return nil, err
+		}
+		if name == SkeletonName {
+			skeleton = data
+		} else {
+			widgets = append(widgets, WidgetFile{Name: name, Data: data})
+		}
+	}
+	if skeleton == nil {
+		return nil, fmt.Errorf("%s not found in %s", SkeletonName, dir)
+	}
+	return JoinWidgets(skeleton, widgets)
+}
@@ -0,0 +1,154 @@
+package dashboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+func TestWriteSplit_RoundTrip(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "nested-widgets.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := WriteSplit(dir, raw); err != nil {
+		t.Fatalf("WriteSplit() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := "001-group.json 002-timeseries.json dashboard.json"; strings.Join(names, " ") != want {
+		t.Errorf("split files = %v, want %s", names, want)
+	}
+	skeleton, _ := os.ReadFile(filepath.Join(dir, SkeletonName))
+	if strings.Contains(string(skeleton), "1001") || !strings.Contains(string(skeleton), `"widgets": []`) {
+		t.Errorf("skeleton = %s, want widgets replaced by []", skeleton)
+	}
+	group, _ := os.ReadFile(filepath.Join(dir, "001-group.json"))
+	if !strings.Contains(string(group), `"content": "deep"`) {
+		t.Errorf("group widget file lost its nested widgets: %s", group)
+	}
+
+	joined, err := ReadSplit(storage.FileBackend{}, dir)
+	if err != nil {
+		t.Fatalf("ReadSplit() error = %v", err)
+	}
+	got, err := storage.FormatJSON(joined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := storage.FormatJSON(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("join of split =\n%s\nwant\n%s", got, want)
+	}
+
+	// ReadDashboard joins a directory too
+	if viaRead, err := ReadDashboard(storage.FileBackend{}, dir); err != nil || string(viaRead) != string(joined) {
+		t.Errorf("ReadDashboard(dir) = %s, %v, want the joined dashboard", viaRead, err)
+	}
+}
+
+func TestWriteSplit_ReplacesOldWidgets(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteSplit(dir, json.RawMessage(`{"widgets":[{"id":1},{"id":2}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteSplit(dir, json.RawMessage(`{"widgets":[{"id":3}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	joined, err := ReadSplit(storage.FileBackend{}, dir)
+	if err != nil {
+		t.Fatalf("ReadSplit() error = %v", err)
+	}
+	if string(compactJSON(t, joined)) != `{"widgets":[{"id":3}]}` {
+		t.Errorf("ReadSplit() = %s, want only the widgets of the last split", joined)
+	}
+}
+
+func TestSplitWidgets_Padding(t *testing.T) {
+	var widgets []string
+	for i := 0; i < 1200; i++ {
+		widgets = append(widgets, fmt.Sprintf(`{"id":%d}`, i))
+	}
+	raw := json.RawMessage(`{"widgets":[` + strings.Join(widgets, ",") + `]}`)
+	_, files, err := SplitWidgets(raw)
+	if err != nil {
+		t.Fatalf("SplitWidgets() error = %v", err)
+	}
+	if files[0].Name != "0001.json" || files[1199].Name != "1200.json" {
+		t.Errorf("SplitWidgets() names = %s ... %s, want 0001.json ... 1200.json", files[0].Name, files[1199].Name)
+	}
+}
+
+func TestJoinWidgets(t *testing.T) {
+	tests := []struct {
+		name     string
+		skeleton string
+		widgets  []WidgetFile
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "orders by prefix, not input order",
+			skeleton: `{"title":"t","widgets":[],"layout_type":"ordered"}`,
+			widgets:  []WidgetFile{{Name: "010-note.json", Data: json.RawMessage(`{"id":10}`)}, {Name: "002.json", Data: json.RawMessage(`{"id":2}`)}},
+			want:     `{"title":"t","widgets":[{"id":2},{"id":10}],"layout_type":"ordered"}`,
+		},
+		{
+			name:     "adds missing widgets key",
+			skeleton: `{"title":"t"}`,
+			widgets:  []WidgetFile{{Name: "001.json", Data: json.RawMessage(`{"id":1}`)}},
+			want:     `{"title":"t","widgets":[{"id":1}]}`,
+		},
+		{
+			name:     "duplicate position",
+			skeleton: `{"widgets":[]}`,
+			widgets:  []WidgetFile{{Name: "001-a.json", Data: json.RawMessage(`{}`)}, {Name: "1-b.json", Data: json.RawMessage(`{}`)}},
+			wantErr:  true,
+		},
+		{
+			name:     "no numeric prefix",
+			skeleton: `{"widgets":[]}`,
+			widgets:  []WidgetFile{{Name: "widget.json", Data: json.RawMessage(`{}`)}},
+			wantErr:  true,
+		},
+		{
+			name:     "invalid widget",
+			skeleton: `{"widgets":[]}`,
+			widgets:  []WidgetFile{{Name: "001.json", Data: json.RawMessage(`{`)}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JoinWidgets(json.RawMessage(tt.skeleton), tt.widgets)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("JoinWidgets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("JoinWidgets() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSplit_MissingSkeleton(t *testing.T) {
+	if _, err := ReadSplit(storage.FileBackend{}, t.TempDir()); err == nil {
+		t.Error("ReadSplit() expected error without dashboard.json")
+	}
+}
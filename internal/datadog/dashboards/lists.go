@@ -0,0 +1,111 @@
+package dashboards
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/templating"
+	"github.com/AD7six/dd-tf/internal/logging"
+)
+
+// listMembership maps dashboard IDs to the name of the manual dashboard list
+// they belong to, for the {list} path placeholder. It is fetched on first use.
+type listMembership struct {
+	once  sync.Once
+	names map[string]string
+	err   error
+}
+
+type listsKey struct {
+	client  resource.HTTPClient
+	apiBase string
+}
+
+var (
+	listsMu sync.Mutex
+	// lists holds the membership per client and API, so a run fetches the
+	// dashboard lists once however many dashboards it downloads.
+	lists = map[listsKey]*listMembership{}
+)
+
+// referencesList reports whether a path pattern uses the {list} placeholder.
+func referencesList(pattern string) bool {
+	return strings.Contains(templating.TranslatePlaceholders(pattern, templating.BuildDashboardBuiltins()), "{{.List}}")
+}
+
+// listName returns the name of the manual dashboard list dashboard id belongs
+// to, or "" if it isn't in any. A dashboard in several lists gets the
+// alphabetically-first name.
+func listName(ctx context.Context, client resource.HTTPClient, settings *config.Settings, id string) (string, error) {
+	key := listsKey{client: client, apiBase: settings.APIBaseURL()}
+	listsMu.Lock()
+	membership, ok := lists[key]
+	if !ok {
+		membership = &listMembership{}
+		lists[key] = membership
+	}
+	listsMu.Unlock()
+
+	membership.once.Do(func() {
+		membership.names, membership.err = fetchListMembership(ctx, client, settings)
+	})
+	if membership.err != nil {
+		return "", fmt.Errorf("failed to fetch dashboard lists: %w", membership.err)
+	}
+	return membership.names[id], nil
+}
+
+// fetchListMembership fetches every manual dashboard list and its dashboards:
+// one request for the lists, then one per list.
+func fetchListMembership(ctx context.Context, client resource.HTTPClient, settings *config.Settings) (map[string]string, error) {
+	raw, err := resource.FetchRawFromAPI(ctx, client, settings.APIBaseURL()+"/api/v1/dashboard/lists/manual", settings)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		DashboardLists []struct {
+			ID   int64  `json:"id"`
+			Name string `json:"name"`
+		} `json:"dashboard_lists"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode dashboard lists: %w", err)
+	}
+
+	names := map[string]string{}
+	for _, list := range resp.DashboardLists {
+		url := fmt.Sprintf("%s/api/v2/dashboard/lists/manual/%d/dashboards", settings.APIBaseURL(), list.ID)
+		raw, err := resource.FetchRawFromAPI(ctx, client, url, settings)
+		if err != nil {
+			return nil, fmt.Errorf("list %q: %w", list.Name, err)
+		}
+		var items struct {
+			Dashboards []struct {
+				ID string `json:"id"`
+			} `json:"dashboards"`
+		}
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, fmt.Errorf("failed to decode dashboard list %q: %w", list.Name, err)
+		}
+		for _, dashboard := range items.Dashboards {
+			if existing, ok := names[dashboard.ID]; !ok || lessName(list.Name, existing) {
+				names[dashboard.ID] = list.Name
+			}
+		}
+	}
+	logging.Logger.Debug("dashboard lists fetched", "lists", len(resp.DashboardLists), "dashboards", len(names))
+	return names, nil
+}
+
+// lessName orders list names alphabetically, ignoring case first.
+func lessName(a, b string) bool {
+	if la, lb := strings.ToLower(a), strings.ToLower(b); la != lb {
+		return la < lb
+	}
+	return a < b
+}
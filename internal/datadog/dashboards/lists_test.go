@@ -0,0 +1,89 @@
+package dashboards
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+)
+
+// newListsServer serves two manual dashboard lists sharing a dashboard, and
+// dashboards without tags. It counts requests to the list endpoints.
+func newListsServer(t *testing.T, listRequests *int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/dashboard/lists/manual":
+			atomic.AddInt32(listRequests, 1)
+			w.Write([]byte(`{"dashboard_lists":[{"id":1,"name":"Payments / Core"},{"id":2,"name":"api"}]}`))
+		case "/api/v2/dashboard/lists/manual/1/dashboards":
+			atomic.AddInt32(listRequests, 1)
+			w.Write([]byte(`{"dashboards":[{"id":"aaa-aaa-aaa"},{"id":"bbb-bbb-bbb"}],"total":2}`))
+		case "/api/v2/dashboard/lists/manual/2/dashboards":
+			atomic.AddInt32(listRequests, 1)
+			w.Write([]byte(`{"dashboards":[{"id":"bbb-bbb-bbb"}],"total":1}`))
+		default:
+			id := filepath.Base(r.URL.Path)
+			w.Write([]byte(`{"id":"` + id + `","title":"Dashboard ` + id + `"}`))
+		}
+	}))
+}
+
+func TestDownloadDashboardWithOptions_ListPlaceholder(t *testing.T) {
+	var listRequests int32
+	server := newListsServer(t, &listRequests)
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                   server.URL,
+		DashboardsPathTemplate: filepath.Join(dir, "{list}", "{id}.json"),
+		HTTPMaxBodySize:        1024,
+	}
+	client := newTestClient()
+	for id, want := range map[string]string{
+		"aaa-aaa-aaa": filepath.Join(dir, "Payments-Core", "aaa-aaa-aaa.json"),
+		"bbb-bbb-bbb": filepath.Join(dir, "api", "bbb-bbb-bbb.json"), // alphabetically first
+		"ccc-ccc-ccc": filepath.Join(dir, "none", "ccc-ccc-ccc.json"),
+	} {
+		path, err := DownloadDashboardWithOptions(context.Background(), client, settings, DashboardTarget{ID: id}, "")
+		if err != nil {
+			t.Fatalf("DownloadDashboardWithOptions(%s) error = %v", id, err)
+		}
+		if path != want {
+			t.Errorf("DownloadDashboardWithOptions(%s) path = %q, want %q", id, path, want)
+		}
+	}
+	if listRequests != 3 {
+		t.Errorf("list endpoints requested %d times, want 3 (once per run)", listRequests)
+	}
+}
+
+func TestDownloadDashboardWithOptions_ListNotReferenced(t *testing.T) {
+	var listRequests int32
+	server := newListsServer(t, &listRequests)
+	defer server.Close()
+
+	settings := &config.Settings{
+		Site:                   server.URL,
+		DashboardsPathTemplate: filepath.Join(t.TempDir(), "{id}.json"),
+		HTTPMaxBodySize:        1024,
+	}
+	if _, err := DownloadDashboardWithOptions(context.Background(), newTestClient(), settings, DashboardTarget{ID: "aaa-aaa-aaa"}, ""); err != nil {
+		t.Fatalf("DownloadDashboardWithOptions() error = %v", err)
+	}
+	if listRequests != 0 {
+		t.Errorf("list endpoints requested %d times without {list}, want 0", listRequests)
+	}
+}
+
+func TestKindComputePath_ListPlaceholder(t *testing.T) {
+	settings := &config.Settings{DashboardsPathTemplate: "data/dashboards/{list}/{id}.json"}
+	if _, _, err := (Kind{}).ComputePath(settings, []byte(`{"id":"aaa-aaa-aaa"}`)); err == nil {
+		t.Error("ComputePath() expected an error for {list}, which needs the API")
+	}
+}
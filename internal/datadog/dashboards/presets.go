@@ -0,0 +1,123 @@
+package dashboards
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+// presetsKey is the dashboard field moved to the presets file.
+const presetsKey = "template_variable_presets"
+
+// SplitPresets removes template_variable_presets from a dashboard, returning
+// the dashboard without them and the presets array. presets is nil if the
+// dashboard has none.
+func SplitPresets(raw json.RawMessage) (dashboard, presets json.RawMessage, err error) {
+	dashboard, err = resource.RewriteObject(raw, func(key string, value json.RawMessage) (json.RawMessage, bool, error) {
+		if key != presetsKey {
+			return value, true, nil
+		}
+		if string(value) != "null" {
+			presets = value
+		}
+		return nil, false, nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to split presets: %w", err)
+	}
+	return dashboard, presets, nil
+}
+
+// MergePresets puts presets back into a dashboard written by SplitPresets,
+// after template_variables (where the API returns them) or at the end if
+// there are no template variables. Empty presets leave dashboard unchanged.
+func MergePresets(dashboard, presets json.RawMessage) (json.RawMessage, error) {
+	if len(presets) == 0 {
+		return dashboard, nil
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(presets, &list); err != nil {
+		return nil, fmt.Errorf("presets must be a JSON array: %w", err)
+	}
+
+	merged := false
+	raw, err := resource.RewriteObject(dashboard, func(key string, value json.RawMessage) (json.RawMessage, bool, error) {
+		if key == presetsKey {
+			return nil, false, fmt.Errorf("dashboard already has %s", presetsKey)
+		}
+		if key == "template_variables" {
+			merged = true
+			return append(append(append([]byte{}, value...), `,"`+presetsKey+`":`...), presets...), true, nil
+		}
+		return value, true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge presets: %w", err)
+	}
+	if merged {
+		return raw, nil
+	}
+	// No template_variables: append before the closing brace
+	out := append([]byte{}, raw[:len(raw)-1]...)
+	if len(out) > 1 {
+		out = append(out, ',')
+	}
+	out = append(out, `"`+presetsKey+`":`...)
+	out = append(out, presets...)
+	return append(out, '}'), nil
+}
+
+// writeDashboard writes a dashboard to path. With DASHBOARDS_SPLIT_PRESETS
+// its presets go to the sibling presets file instead; a stale presets file
+// is emptied when the dashboard no longer has any.
+func writeDashboard(backend storage.Backend, path string, raw json.RawMessage, split bool) error {
+	if !split {
+		return storage.WriteRawJSON(backend, path, raw)
+	}
+	dashboard, presets, err := SplitPresets(raw)
+	if err != nil {
+		return err
+	}
+	if err := storage.WriteRawJSON(backend, path, dashboard); err != nil {
+		return err
+	}
+	presetsPath := storage.PresetsPath(path)
+	if presets == nil {
+		_, err := backend.Read(presetsPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		presets = json.RawMessage("[]")
+	}
+	return storage.WriteRawJSON(backend, presetsPath, presets)
+}
+
+// ReadDashboard reads a downloaded dashboard, merging its presets file back
+// in if there is one. A missing presets file means the dashboard has no
+// presets. A path not ending in .json is read as a split dashboard
+// directory (see WriteSplit).
+func ReadDashboard(backend storage.Backend, path string) (json.RawMessage, error) {
+	if !strings.HasSuffix(path, ".json") {
+		return ReadSplit(backend, path)
+	}
+	raw, err := backend.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	presets, err := backend.Read(storage.PresetsPath(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return raw, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return MergePresets(raw, presets)
+}
@@ -0,0 +1,154 @@
+package dashboards
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+func TestSplitPresets_RoundTrip(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "presets.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	settings := &config.Settings{
+		DashboardsPathTemplate: filepath.Join(dir, "{id}.json"),
+		DashboardsSplitPresets: true,
+	}
+	path, err := DownloadDashboardWithOptions(context.Background(), newTestClient(), settings, DashboardTarget{ID: "abc-def-ghi", Data: raw}, "")
+	if err != nil {
+		t.Fatalf("DownloadDashboardWithOptions() error = %v", err)
+	}
+
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(written), "template_variable_presets") {
+		t.Errorf("dashboard file still has presets: %s", written)
+	}
+	presets, err := os.ReadFile(filepath.Join(dir, "abc-def-ghi.presets.json"))
+	if err != nil {
+		t.Fatalf("presets file not written: %v", err)
+	}
+	if !strings.HasPrefix(string(presets), "[") || !strings.Contains(string(presets), "Production") {
+		t.Errorf("presets file = %s, want the presets array", presets)
+	}
+
+	merged, err := ReadDashboard(storage.FileBackend{}, path)
+	if err != nil {
+		t.Fatalf("ReadDashboard() error = %v", err)
+	}
+	got, err := storage.FormatJSON(merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := storage.FormatJSON(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("ReadDashboard() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestReadDashboard_NoPresetsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "abc-def-ghi.json")
+	if err := os.WriteFile(path, []byte(`{"id":"abc-def-ghi"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadDashboard(storage.FileBackend{}, path)
+	if err != nil {
+		t.Fatalf("ReadDashboard() error = %v", err)
+	}
+	if string(got) != `{"id":"abc-def-ghi"}` {
+		t.Errorf("ReadDashboard() = %s, want the dashboard unchanged", got)
+	}
+}
+
+func TestWriteDashboard_SplitPresets(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "abc-def-ghi.json")
+	presetsPath := storage.PresetsPath(path)
+
+	// No presets and no presets file: nothing extra is written
+	if err := writeDashboard(storage.FileBackend{}, path, json.RawMessage(`{"id":"abc-def-ghi"}`), true); err != nil {
+		t.Fatalf("writeDashboard() error = %v", err)
+	}
+	if _, err := os.Stat(presetsPath); !os.IsNotExist(err) {
+		t.Errorf("presets file written for a dashboard without presets: %v", err)
+	}
+
+	// A stale presets file is emptied when the presets are removed
+	if err := os.WriteFile(presetsPath, []byte(`[{"name":"old"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeDashboard(storage.FileBackend{}, path, json.RawMessage(`{"id":"abc-def-ghi"}`), true); err != nil {
+		t.Fatalf("writeDashboard() error = %v", err)
+	}
+	if data, _ := os.ReadFile(presetsPath); string(data) != "[]\n" {
+		t.Errorf("stale presets file = %q, want emptied", data)
+	}
+}
+
+func TestMergePresets(t *testing.T) {
+	tests := []struct {
+		name      string
+		dashboard string
+		presets   string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "after template_variables",
+			dashboard: `{"id":"a","template_variables":[],"layout_type":"ordered"}`,
+			presets:   `[{"name":"p"}]`,
+			want:      `{"id":"a","template_variables":[],"template_variable_presets":[{"name":"p"}],"layout_type":"ordered"}`,
+		},
+		{
+			name:      "appended without template_variables",
+			dashboard: `{"id":"a"}`,
+			presets:   `[]`,
+			want:      `{"id":"a","template_variable_presets":[]}`,
+		},
+		{
+			name:      "no presets",
+			dashboard: `{"id":"a"}`,
+			want:      `{"id":"a"}`,
+		},
+		{
+			name:      "presets must be an array",
+			dashboard: `{"id":"a"}`,
+			presets:   `{"name":"p"}`,
+			wantErr:   true,
+		},
+		{
+			name:      "dashboard already has presets",
+			dashboard: `{"id":"a","template_variable_presets":[]}`,
+			presets:   `[]`,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var presets json.RawMessage
+			if tt.presets != "" {
+				presets = json.RawMessage(tt.presets)
+			}
+			got, err := MergePresets(json.RawMessage(tt.dashboard), presets)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MergePresets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("MergePresets() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
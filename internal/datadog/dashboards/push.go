@@ -0,0 +1,85 @@
+package dashboards
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+// ServerFields are dashboard fields the API sets itself. They are ignored
+// when comparing a local dashboard with the remote one.
+var ServerFields = []string{"id", "author_handle", "author_name", "created_at", "modified_at", "url"}
+
+// PushDashboard replaces the dashboard with the ID in the file at
+// target.Path. Presets split into a sibling file are merged back first, so
+// the dashboard is sent whole, as is a split dashboard directory; its
+// skeleton alone is refused. A dashboard that doesn't exist remotely is
+// created, and the file's id changed to the new dashboard's. The file's
+// modified_at detects dashboards edited remotely since they were downloaded.
+func PushDashboard(ctx context.Context, client resource.WriteClient, settings *config.Settings, target resource.Target[string], flags resource.PushFlags) (resource.PushResult, error) {
+	backend, err := storage.NewBackend(settings)
+	if err != nil {
+		return resource.PushResult{}, err
+	}
+	path := target.Path
+	if !strings.HasSuffix(path, ".json") {
+		// A split dashboard, whose id and modified_at are in the skeleton
+		path = filepath.Join(path, SkeletonName)
+	} else if filepath.Base(path) == SkeletonName && storage.IsSplitDir(backend, filepath.Dir(path)) {
+		return resource.PushResult{}, fmt.Errorf("%s is a split dashboard's skeleton, without its widgets; push %s instead", path, filepath.Dir(path))
+	}
+	raw, err := ReadDashboard(backend, target.Path)
+	if err != nil {
+		return resource.PushResult{}, err
+	}
+	id, err := localDashboardID(raw)
+	if err != nil {
+		return resource.PushResult{}, err
+	}
+
+	result, err := resource.PushResource(ctx, client, settings, resource.PushRequest{
+		Path:           path,
+		Backend:        backend,
+		Endpoint:       settings.APIBaseURL() + "/api/v1/dashboard/" + id,
+		CreateEndpoint: settings.APIBaseURL() + "/api/v1/dashboard",
+		Body:           raw,
+		Ignore:         ServerFields,
+		URL:            settings.AppBaseURL() + "/dashboard/" + id,
+		ModifiedField:  "modified_at",
+		Modified:       resource.StringField(raw, "modified_at"),
+		Normalize: func(raw json.RawMessage) (json.RawMessage, error) {
+			return NormalizeDashboard(raw, settings)
+		},
+	}, flags)
+	if err != nil {
+		return resource.PushResult{}, err
+	}
+	if result.NewID != nil {
+		result.URL = settings.AppBaseURL() + "/dashboard/" + resource.FormatRawID(result.NewID)
+	}
+	var resp struct {
+		URL string `json:"url"`
+	}
+	if json.Unmarshal(result.Response, &resp) == nil && resp.URL != "" {
+		result.URL = settings.AppBaseURL() + resp.URL
+	}
+	return result, nil
+}
+
+// localDashboardID checks that a local dashboard is valid JSON with a valid
+// id, and returns the id.
+func localDashboardID(raw []byte) (string, error) {
+	var meta struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return "", fmt.Errorf("failed to decode dashboard: %w", err)
+	}
+	return normalizezDashboardID(meta.ID)
+}
@@ -0,0 +1,158 @@
+package dashboards
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+func TestPushDashboard(t *testing.T) {
+	var put string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/api/v1/dashboard/abc-def-ghi" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		put = string(body)
+		w.Write([]byte(`{"id":"abc-def-ghi","url":"/dashboard/abc-def-ghi/web"}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "abc-def-ghi.json")
+	os.WriteFile(path, []byte(`{"id":"abc-def-ghi","title":"Web"}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "abc-def-ghi.presets.json"), []byte(`[{"name":"prod"}]`), 0o644)
+
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 1024}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+	result, err := PushDashboard(context.Background(), client, settings, resource.Target[string]{ID: "abc-def-ghi", Path: path}, resource.PushFlags{Force: true})
+	if err != nil {
+		t.Fatalf("PushDashboard() error = %v", err)
+	}
+	if url := result.URL; url != server.URL+"/dashboard/abc-def-ghi/web" {
+		t.Errorf("PushDashboard() = %q", url)
+	}
+	if put != `{"id":"abc-def-ghi","title":"Web","template_variable_presets":[{"name":"prod"}]}` {
+		t.Errorf("PUT body = %s, want the dashboard with its presets", put)
+	}
+}
+
+func TestPushDashboard_Normalized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"id":"abc-def-ghi","title":"Web","author_handle":"a@example.com","widgets":[{"id":1001,"definition":{"type":"note"}}]}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "abc-def-ghi.json")
+	os.WriteFile(path, []byte(`{"id":"abc-def-ghi","title":"Web","widgets":[{"definition":{"type":"note"}}]}`), 0o644)
+
+	settings := &config.Settings{
+		Site:                     server.URL,
+		HTTPMaxBodySize:          1024,
+		DashboardsStripFields:    []string{"author_handle"},
+		DashboardsStripWidgetIDs: true,
+	}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+	result, err := PushDashboard(context.Background(), client, settings, resource.Target[string]{ID: "abc-def-ghi", Path: path}, resource.PushFlags{})
+	if err != nil {
+		t.Fatalf("PushDashboard() error = %v", err)
+	}
+	if result.Action != resource.PushUnchanged {
+		t.Errorf("PushDashboard() = %s, want unchanged once the remote dashboard is normalized:\n%s", result.Action, result.Diff)
+	}
+}
+
+func TestPushDashboard_InvalidFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 1024}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+	for name, content := range map[string]string{
+		"broken.json":     `{"id":`,
+		"bad-id.json":     `{"id":"not a dashboard id"}`,
+		"missing-id.json": `{"title":"Web"}`,
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0o644)
+		if _, err := PushDashboard(context.Background(), client, settings, resource.Target[string]{Path: path}, resource.PushFlags{}); err == nil {
+			t.Errorf("PushDashboard(%s) should fail", name)
+		}
+	}
+}
+
+func TestPushDashboard_SplitDir(t *testing.T) {
+	var puts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/api/v1/dashboard/abc-def-ghi" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		puts = append(puts, string(body))
+		w.Write([]byte(`{"id":"abc-def-ghi"}`))
+	}))
+	defer server.Close()
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "abc-def-ghi")
+	if err := WriteSplit(dir, json.RawMessage(`{"id":"abc-def-ghi","title":"Web","widgets":[{"definition":{"type":"note"}},{"definition":{"type":"timeseries"}}]}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 1024}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+	for name, opts := range map[string]resource.PushOptions{
+		"all":  {All: true},
+		"path": {Paths: []string{dir}},
+	} {
+		targets, err := resource.PushTargets(context.Background(), storage.FileBackend{}, root, resource.IDString, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for tr := range targets {
+			if tr.Err != nil {
+				t.Fatalf("%s: %v", name, tr.Err)
+			}
+			paths = append(paths, tr.Target.Path)
+			if _, err := PushDashboard(context.Background(), client, settings, tr.Target, resource.PushFlags{Force: true}); err != nil {
+				t.Fatalf("%s: PushDashboard() error = %v", name, err)
+			}
+		}
+		if len(paths) != 1 || paths[0] != dir {
+			t.Errorf("%s: PushTargets() = %v, want the split directory", name, paths)
+		}
+	}
+	want := `{"id":"abc-def-ghi","title":"Web","widgets":[{"definition":{"type":"note"}},{"definition":{"type":"timeseries"}}]}`
+	for _, put := range puts {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, []byte(put)); err != nil || compact.String() != want {
+			t.Errorf("PUT body = %s, want the dashboard with its widgets", put)
+		}
+	}
+
+	skeleton := filepath.Join(dir, SkeletonName)
+	if _, err := PushDashboard(context.Background(), client, settings, resource.Target[string]{ID: "abc-def-ghi", Path: skeleton}, resource.PushFlags{Force: true}); err == nil {
+		t.Error("PushDashboard() should refuse a split dashboard's skeleton")
+	}
+	if len(puts) != 2 {
+		t.Errorf("got %d PUTs, want 2", len(puts))
+	}
+}
@@ -0,0 +1,125 @@
+package dashboards
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+// summaryDashboard is the subset of a dashboard described by its summary.
+type summaryDashboard struct {
+	ID                string          `json:"id"`
+	Title             string          `json:"title"`
+	Description       string          `json:"description"`
+	LayoutType        string          `json:"layout_type"`
+	Tags              []string        `json:"tags"`
+	TemplateVariables []summaryVar    `json:"template_variables"`
+	Widgets           []summaryWidget `json:"widgets"`
+}
+
+type summaryVar struct {
+	Name     string   `json:"name"`
+	Prefix   string   `json:"prefix"`
+	Default  string   `json:"default"`
+	Defaults []string `json:"defaults"`
+}
+
+type summaryWidget struct {
+	Definition struct {
+		Type    string          `json:"type"`
+		Title   string          `json:"title"`
+		Widgets []summaryWidget `json:"widgets"`
+	} `json:"definition"`
+}
+
+// Summary renders a Markdown overview of a dashboard for reviewers: title,
+// description, tags, template variables and the tree of widget titles and
+// types, group widgets nested. The output only depends on the payload, in
+// its own order, so it diffs cleanly between downloads.
+func Summary(raw json.RawMessage) ([]byte, error) {
+	var d summaryDashboard
+	if err := json.Unmarshal(raw, &d); err != nil {
+		return nil, fmt.Errorf("failed to decode dashboard: %w", err)
+	}
+
+	var b bytes.Buffer
+	title := oneLine(d.Title)
+	if title == "" {
+		title = "untitled"
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	if description := strings.TrimSpace(d.Description); description != "" {
+		fmt.Fprintf(&b, "%s\n\n", description)
+	}
+	fmt.Fprintf(&b, "- ID: `%s`\n", d.ID)
+	if d.LayoutType != "" {
+		fmt.Fprintf(&b, "- Layout: %s\n", d.LayoutType)
+	}
+	if len(d.Tags) > 0 {
+		fmt.Fprintf(&b, "- Tags: `%s`\n", strings.Join(d.Tags, "`, `"))
+	}
+
+	if len(d.TemplateVariables) > 0 {
+		b.WriteString("\n## Template variables\n\n")
+		for _, v := range d.TemplateVariables {
+			fmt.Fprintf(&b, "- `$%s`", v.Name)
+			var details []string
+			if v.Prefix != "" {
+				details = append(details, fmt.Sprintf("tag `%s`", v.Prefix))
+			}
+			defaults := v.Defaults
+			if len(defaults) == 0 && v.Default != "" {
+				defaults = []string{v.Default}
+			}
+			if len(defaults) > 0 {
+				details = append(details, fmt.Sprintf("default `%s`", strings.Join(defaults, "`, `")))
+			}
+			if len(details) > 0 {
+				fmt.Fprintf(&b, ": %s", strings.Join(details, ", "))
+			}
+			b.WriteByte('\n')
+		}
+	}
+
+	b.WriteString("\n## Widgets\n\n")
+	if len(d.Widgets) == 0 {
+		b.WriteString("None.\n")
+	}
+	writeWidgets(&b, d.Widgets, 0)
+	return b.Bytes(), nil
+}
+
+// writeWidgets writes a bullet per widget, indented by depth, recursing into
+// group widgets.
+func writeWidgets(b *bytes.Buffer, widgets []summaryWidget, depth int) {
+	for _, w := range widgets {
+		b.WriteString(strings.Repeat("  ", depth) + "- ")
+		if title := oneLine(w.Definition.Title); title != "" {
+			b.WriteString(title + " ")
+		}
+		fmt.Fprintf(b, "(`%s`)\n", w.Definition.Type)
+		writeWidgets(b, w.Definition.Widgets, depth+1)
+	}
+}
+
+// oneLine joins a multi-line string into one line, so it can't break the
+// Markdown structure around it.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// writeSummary writes the summary of a dashboard next to the dashboard file
+// at path (see storage.SummaryPath).
+func writeSummary(backend storage.Backend, path string, raw json.RawMessage) error {
+	summary, err := Summary(raw)
+	if err != nil {
+		return err
+	}
+	if err := backend.Write(storage.SummaryPath(path), summary); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package dashboards
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func TestSummary(t *testing.T) {
+	for _, name := range []string{"summary", "nested-widgets"} {
+		t.Run(name, func(t *testing.T) {
+			raw, err := os.ReadFile(filepath.Join("testdata", name+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Summary(raw)
+			if err != nil {
+				t.Fatalf("Summary() error = %v", err)
+			}
+			golden := filepath.Join("testdata", name+".md.golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("missing golden file (run with -update): %v", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("Summary() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestDownloadDashboardWithOptions_WriteSummary(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "summary.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	settings := &config.Settings{
+		DashboardsPathTemplate: filepath.Join(dir, "{id}.json"),
+		DashboardsWriteSummary: true,
+		DashboardsSplitPresets: true,
+	}
+	path, err := DownloadDashboardWithOptions(context.Background(), newTestClient(), settings, DashboardTarget{ID: "abc-def-ghi", Data: raw}, "")
+	if err != nil {
+		t.Fatalf("DownloadDashboardWithOptions() error = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "abc-def-ghi.md"))
+	if err != nil {
+		t.Fatalf("summary not written next to %s: %v", path, err)
+	}
+	want, err := os.ReadFile(filepath.Join("testdata", "summary.md.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("summary =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,256 @@
+// Package diff compares JSON resources: both sides are normalized, so only
+// real changes show, and the result is a unified diff of their indented
+// lines, a one-line summary of the fields changed or a JSON Patch.
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// maxDiffCells bounds the table used to diff the changed middle of two
+// files. Files differing in more lines than this allows are shown as
+// entirely replaced.
+const maxDiffCells = 4 << 20
+
+// Normalize prepares a resource for comparison: the named top-level
+// fields are dropped, "tags" lists are sorted, and the result is indented
+// with sorted keys, so equal resources give equal bytes whatever their field
+// order or formatting.
+func Normalize(raw []byte, ignore ...string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if obj, ok := doc.(map[string]any); ok {
+		for _, field := range ignore {
+			delete(obj, field)
+		}
+	}
+	sortTags(doc)
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// sortTags sorts every "tags" list of strings in doc, in place.
+func sortTags(doc any) {
+	switch v := doc.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if tags, ok := value.([]any); ok && key == "tags" {
+				sort.SliceStable(tags, func(i, j int) bool {
+					a, aok := tags[i].(string)
+					b, bok := tags[j].(string)
+					return aok && bok && a < b
+				})
+			}
+			sortTags(value)
+		}
+	case []any:
+		for _, value := range v {
+			sortTags(value)
+		}
+	}
+}
+
+// JSON normalizes a and b with Normalize and returns a unified diff
+// between them, or "" if they are equal. A nil a is a resource that doesn't
+// exist yet, diffed from nothing.
+func JSON(aName, bName string, a, b []byte, ignore ...string) (string, error) {
+	var err error
+	if a != nil {
+		if a, err = Normalize(a, ignore...); err != nil {
+			return "", fmt.Errorf("%s: %w", aName, err)
+		}
+	}
+	if b, err = Normalize(b, ignore...); err != nil {
+		return "", fmt.Errorf("%s: %w", bName, err)
+	}
+	return Unified(aName, bName, string(a), string(b)), nil
+}
+
+// diffOp is one line of an edit script: ' ' kept, '-' removed from a, '+'
+// added from b.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// Unified returns the unified diff between the lines of a and b, with
+// three lines of context, or "" if they are equal.
+func Unified(aName, bName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", aName, bName)
+	// aLine and bLine are the 1-based line numbers of ops[i] in a and b
+	aLine, bLine := 1, 1
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			aLine++
+			bLine++
+			i++
+			continue
+		}
+		// A hunk starts up to diffContext lines before the change and runs
+		// until more than 2*diffContext unchanged lines separate changes.
+		start := i
+		for start > 0 && i-start < diffContext && ops[start-1].kind == ' ' {
+			start--
+		}
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				end = min(end+diffContext, run)
+				break
+			}
+			end = run
+		}
+
+		aStart, bStart := aLine-(i-start), bLine-(i-start)
+		var aCount, bCount int
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		for _, op := range ops[start:end] {
+			buf.WriteByte(op.kind)
+			buf.WriteString(op.line)
+			buf.WriteByte('\n')
+		}
+		for _, op := range ops[i:end] {
+			if op.kind != '+' {
+				aLine++
+			}
+			if op.kind != '-' {
+				bLine++
+			}
+		}
+		i = end
+	}
+	return buf.String()
+}
+
+// hunkRange formats a hunk's start line and length; an empty range starts
+// at the line before it.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines returns an edit script turning a into b. Common leading and
+// trailing lines are matched directly and the rest with a longest common
+// subsequence, which is cheap for the small edits made to resource files.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+func diffMiddle(a, b []string) []diffOp {
+	var ops []diffOp
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
@@ -0,0 +1,196 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	a, err := Normalize([]byte(`{"title":"Web","id":"abc","tags":["team:b","env:prod"],"widgets":[{"tags":["z","a"]}],"n":1.50}`), "id")
+	if err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	b, err := Normalize([]byte(`{"n":1.50,"widgets":[{"tags":["a","z"]}],"tags":["env:prod","team:b"],"title":"Web"}`))
+	if err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	if string(a) != string(b) {
+		t.Errorf("Normalize() differs:\n%s\n%s", a, b)
+	}
+	if !strings.Contains(string(a), "1.50") {
+		t.Errorf("Normalize() = %s, want numbers kept as written", a)
+	}
+	if _, err := Normalize([]byte(`{"id":`)); err == nil {
+		t.Error("Normalize() should fail on invalid JSON")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		a, b   string
+		ignore []string
+		want   string
+	}{
+		{
+			name:   "ignored fields and tag order",
+			a:      `{"id":1,"name":"a","tags":["x","y"]}`,
+			b:      `{"name":"a","tags":["y","x"]}`,
+			ignore: []string{"id"},
+			want:   "",
+		},
+		{
+			name: "key order and formatting",
+			a:    `{"name":"a","options":{"b":1,"a":2}}`,
+			b:    "{\n  \"options\": {\"a\": 2, \"b\": 1},\n  \"name\": \"a\"\n}",
+			want: "",
+		},
+		{
+			name: "changed field",
+			a:    `{"name":"a","query":"q1"}`,
+			b:    `{"name":"a","query":"q2"}`,
+			want: `--- remote
++++ local
+@@ -1,4 +1,4 @@
+ {
+   "name": "a",
+-  "query": "q1"
++  "query": "q2"
+ }
+`,
+		},
+		{
+			name: "nested change",
+			a:    `{"options":{"thresholds":{"critical":90}}}`,
+			b:    `{"options":{"thresholds":{"critical":95}}}`,
+			want: `--- remote
++++ local
+@@ -1,7 +1,7 @@
+ {
+   "options": {
+     "thresholds": {
+-      "critical": 90
++      "critical": 95
+     }
+   }
+ }
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSON("remote", "local", []byte(tt.a), []byte(tt.b), tt.ignore...)
+			if err != nil {
+				t.Fatalf("JSON() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("JSON() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSON_FromNothing(t *testing.T) {
+	got, err := JSON("/dev/null", "local", nil, []byte(`{"name":"a"}`))
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	if !strings.HasPrefix(got, "--- /dev/null\n+++ local\n@@ -0,0 +1,3 @@\n+{\n") {
+		t.Errorf("JSON() from nothing =\n%s", got)
+	}
+	if _, err := JSON("remote", "local", []byte(`{`), []byte(`{}`)); err == nil || !strings.HasPrefix(err.Error(), "remote: ") {
+		t.Errorf("JSON() of invalid JSON: error = %v, want one naming the side", err)
+	}
+}
+
+func TestUnified_Hunks(t *testing.T) {
+	var a, b []string
+	for i := 1; i <= 20; i++ {
+		a = append(a, fmt.Sprint(i))
+		b = append(b, fmt.Sprint(i))
+	}
+	b[1] = "two"  // line 2
+	b[17] = "18b" // line 18, far enough away for its own hunk
+	b = append(b[:5], append([]string{"5.5"}, b[5:]...)...)
+
+	got := Unified("a", "b", strings.Join(a, "\n")+"\n", strings.Join(b, "\n")+"\n")
+	want := `--- a
++++ b
+@@ -1,8 +1,9 @@
+ 1
+-2
++two
+ 3
+ 4
+ 5
++5.5
+ 6
+ 7
+ 8
+@@ -15,6 +16,6 @@
+ 15
+ 16
+ 17
+-18
++18b
+ 19
+ 20
+`
+	if got != want {
+		t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+	if Unified("a", "b", "x\n", "x\n") != "" {
+		t.Error("Unified() of equal input should be empty")
+	}
+}
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		name   string
+		a, b   string
+		ignore []string
+		want   string
+	}{
+		{
+			name:   "equal once normalized",
+			a:      `{"id":"x","tags":["b","a"],"title":"T"}`,
+			b:      `{"title":"T","tags":["a","b"]}`,
+			ignore: []string{"id"},
+			want:   "",
+		},
+		{
+			name: "changed fields",
+			a:    `{"title":"T","options":{"a":1},"query":"q"}`,
+			b:    `{"title":"U","options":{"a":2},"query":"q"}`,
+			want: "options; title",
+		},
+		{
+			name: "fields added and removed",
+			a:    `{"title":"T","description":"d"}`,
+			b:    `{"title":"T","notify_list":[]}`,
+			want: "description removed; notify_list added",
+		},
+		{
+			name: "list items",
+			a:    `{"widgets":[{"id":1},{"id":2}],"tags":["a","b","c"]}`,
+			b:    `{"widgets":[{"id":1},{"id":3},{"id":4},{"id":5}],"tags":["a","b"]}`,
+			want: "tags: 1 removed; widgets: 1 changed, 2 added",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Summary([]byte(tt.a), []byte(tt.b), tt.ignore...)
+			if err != nil {
+				t.Fatalf("Summary() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Summary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Summary([]byte(`[]`), []byte(`{}`)); err == nil {
+		t.Error("Summary() of an array: expected an error")
+	}
+}
@@ -0,0 +1,151 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Direction is which side of a comparison a patch is applied to.
+type Direction string
+
+const (
+	RemoteToLocal Direction = "remote-to-local" // Applied to the remote resource, the patch gives the local one
+	LocalToRemote Direction = "local-to-remote" // Applied to the local resource, the patch gives the remote one
+)
+
+// ParseDirection validates a --direction value.
+func ParseDirection(s string) (Direction, error) {
+	switch d := Direction(s); d {
+	case RemoteToLocal, LocalToRemote:
+		return d, nil
+	}
+	return "", fmt.Errorf("invalid direction %q (expected %s or %s)", s, RemoteToLocal, LocalToRemote)
+}
+
+// Operation is an RFC 6902 JSON Patch operation.
+type Operation struct {
+	Op    string          `json:"op"`              // "add", "remove" or "replace"
+	Path  string          `json:"path"`            // RFC 6901 JSON Pointer
+	Value json.RawMessage `json:"value,omitempty"` // Unset for "remove"
+}
+
+// Patch normalizes a and b with Normalize and returns the JSON Patch
+// operations that turn a into b, or none if they are equal. Objects are
+// compared key by key, in key order; lists item by item, by index, with
+// items added at the end of b appended and those past its end removed last
+// first, so each operation's path is valid once the previous ones are
+// applied. A value of a different type is replaced.
+func Patch(a, b []byte, ignore ...string) ([]Operation, error) {
+	aDoc, err := decode(a, ignore)
+	if err != nil {
+		return nil, err
+	}
+	bDoc, err := decode(b, ignore)
+	if err != nil {
+		return nil, err
+	}
+	ops := []Operation{}
+	return patchValue(ops, "", aDoc, bDoc)
+}
+
+// decode normalizes raw and decodes it, keeping numbers as written.
+func decode(raw []byte, ignore []string) (any, error) {
+	normalized, err := Normalize(raw, ignore...)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(normalized))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func patchValue(ops []Operation, path string, a, b any) ([]Operation, error) {
+	if reflect.DeepEqual(a, b) {
+		return ops, nil
+	}
+	switch av := a.(type) {
+	case map[string]any:
+		if bv, ok := b.(map[string]any); ok {
+			return patchObject(ops, path, av, bv)
+		}
+	case []any:
+		if bv, ok := b.([]any); ok {
+			return patchList(ops, path, av, bv)
+		}
+	}
+	return appendOp(ops, "replace", path, b)
+}
+
+func patchObject(ops []Operation, path string, a, b map[string]any) ([]Operation, error) {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var err error
+	for _, key := range keys {
+		av, inA := a[key]
+		bv, inB := b[key]
+		keyPath := path + "/" + escapePointer(key)
+		switch {
+		case !inA:
+			ops, err = appendOp(ops, "add", keyPath, bv)
+		case !inB:
+			ops = append(ops, Operation{Op: "remove", Path: keyPath})
+		default:
+			ops, err = patchValue(ops, keyPath, av, bv)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ops, nil
+}
+
+func patchList(ops []Operation, path string, a, b []any) ([]Operation, error) {
+	var err error
+	for i := 0; i < min(len(a), len(b)); i++ {
+		if ops, err = patchValue(ops, path+"/"+strconv.Itoa(i), a[i], b[i]); err != nil {
+			return nil, err
+		}
+	}
+	for i := len(a); i < len(b); i++ {
+		if ops, err = appendOp(ops, "add", path+"/"+strconv.Itoa(i), b[i]); err != nil {
+			return nil, err
+		}
+	}
+	for i := len(a) - 1; i >= len(b); i-- {
+		ops = append(ops, Operation{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+	}
+	return ops, nil
+}
+
+func appendOp(ops []Operation, op, path string, value any) ([]Operation, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return nil, err
+	}
+	return append(ops, Operation{Op: op, Path: path, Value: bytes.TrimSpace(buf.Bytes())}), nil
+}
+
+// escapePointer escapes a key for a JSON Pointer.
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
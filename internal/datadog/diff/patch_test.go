@@ -0,0 +1,191 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func TestPatch_Golden(t *testing.T) {
+	remote, err := os.ReadFile(filepath.Join("testdata", "dashboard.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"added-widget", "changed-query", "removed-tag"} {
+		t.Run(name, func(t *testing.T) {
+			local, err := os.ReadFile(filepath.Join("testdata", name+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ops, err := Patch(remote, local)
+			if err != nil {
+				t.Fatalf("Patch() error = %v", err)
+			}
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(ops); err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", name+".patch.golden")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("missing golden file (run with -update): %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("Patch() =\n%s\nwant\n%s", buf.Bytes(), want)
+			}
+
+			// Both directions apply cleanly
+			checkApplies(t, remote, local)
+			checkApplies(t, local, remote)
+		})
+	}
+}
+
+func TestPatch(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", `{"a":1,"tags":["b","a"]}`, `{"tags":["a","b"],"a":1}`, `[]`},
+		{"escaped keys", `{"a/b":1,"c~d":1}`, `{"a/b":2}`, `[{"op":"replace","path":"/a~1b","value":2},{"op":"remove","path":"/c~0d"}]`},
+		{"null and false values", `{"a":1,"b":true}`, `{"a":null,"b":false}`, `[{"op":"replace","path":"/a","value":null},{"op":"replace","path":"/b","value":false}]`},
+		{"type change", `{"a":[1]}`, `{"a":{"b":1}}`, `[{"op":"replace","path":"/a","value":{"b":1}}]`},
+		{"removed items last first", `{"a":[1,2,3]}`, `{"a":[0]}`, `[{"op":"replace","path":"/a/0","value":0},{"op":"remove","path":"/a/2"},{"op":"remove","path":"/a/1"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := Patch([]byte(tt.a), []byte(tt.b))
+			if err != nil {
+				t.Fatalf("Patch() error = %v", err)
+			}
+			got, _ := json.Marshal(ops)
+			if string(got) != tt.want {
+				t.Errorf("Patch() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Patch([]byte(`{`), []byte(`{}`)); err == nil {
+		t.Error("Patch() of invalid JSON expected an error")
+	}
+}
+
+func TestParseDirection(t *testing.T) {
+	if d, err := ParseDirection("local-to-remote"); err != nil || d != LocalToRemote {
+		t.Errorf("ParseDirection(local-to-remote) = %q, %v", d, err)
+	}
+	if _, err := ParseDirection("up"); err == nil {
+		t.Error("ParseDirection(up) expected an error")
+	}
+}
+
+// checkApplies checks that applying Patch(a, b) to a gives b.
+func checkApplies(t *testing.T, a, b []byte) {
+	t.Helper()
+	ops, err := Patch(a, b)
+	if err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	doc, _ := decode(a, nil)
+	want, _ := decode(b, nil)
+	for _, op := range ops {
+		doc = applyOp(t, doc, op)
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("applying %+v gave %v, want %v", ops, doc, want)
+	}
+}
+
+// applyOp applies an add, remove or replace operation to doc.
+func applyOp(t *testing.T, doc any, op Operation) any {
+	t.Helper()
+	var value any
+	if op.Value != nil {
+		dec := json.NewDecoder(bytes.NewReader(op.Value))
+		dec.UseNumber()
+		if err := dec.Decode(&value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if op.Path == "" {
+		return value
+	}
+	keys := strings.Split(op.Path, "/")[1:]
+	parent := doc
+	for _, key := range keys[:len(keys)-1] {
+		parent = child(t, parent, key)
+	}
+	last := strings.NewReplacer("~1", "/", "~0", "~").Replace(keys[len(keys)-1])
+	switch p := parent.(type) {
+	case map[string]any:
+		if op.Op == "remove" {
+			delete(p, last)
+		} else {
+			p[last] = value
+		}
+	case []any:
+		i, _ := strconv.Atoi(last)
+		var list []any
+		switch op.Op {
+		case "add":
+			list = append(append(append([]any{}, p[:i]...), value), p[i:]...)
+		case "remove":
+			list = append(append([]any{}, p[:i]...), p[i+1:]...)
+		default:
+			p[i] = value
+			return doc
+		}
+		return setChild(t, doc, keys[:len(keys)-1], list)
+	}
+	return doc
+}
+
+func child(t *testing.T, doc any, key string) any {
+	switch v := doc.(type) {
+	case map[string]any:
+		return v[strings.NewReplacer("~1", "/", "~0", "~").Replace(key)]
+	case []any:
+		i, _ := strconv.Atoi(key)
+		return v[i]
+	}
+	t.Fatalf("can't index %v with %q", doc, key)
+	return nil
+}
+
+// setChild replaces the value at keys in doc.
+func setChild(t *testing.T, doc any, keys []string, value any) any {
+	if len(keys) == 0 {
+		return value
+	}
+	parent := doc
+	for _, key := range keys[:len(keys)-1] {
+		parent = child(t, parent, key)
+	}
+	last := keys[len(keys)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		p[strings.NewReplacer("~1", "/", "~0", "~").Replace(last)] = value
+	case []any:
+		i, _ := strconv.Atoi(last)
+		p[i] = value
+	}
+	return doc
+}
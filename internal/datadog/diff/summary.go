@@ -0,0 +1,94 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Summary normalizes a and b with Normalize and describes on one line which
+// top-level fields of b differ from a, or returns "" if they are equal, e.g.
+// "title; widgets: 1 changed, 2 added". Fields only in b are "added", those
+// only in a "removed", and lists count their changed, added and removed
+// items by position.
+func Summary(a, b []byte, ignore ...string) (string, error) {
+	aDoc, err := decodeObject(a, ignore)
+	if err != nil {
+		return "", err
+	}
+	bDoc, err := decodeObject(b, ignore)
+	if err != nil {
+		return "", err
+	}
+
+	keys := make([]string, 0, len(aDoc)+len(bDoc))
+	for key := range aDoc {
+		keys = append(keys, key)
+	}
+	for key := range bDoc {
+		if _, ok := aDoc[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		av, inA := aDoc[key]
+		bv, inB := bDoc[key]
+		switch {
+		case !inA:
+			parts = append(parts, key+" added")
+		case !inB:
+			parts = append(parts, key+" removed")
+		case reflect.DeepEqual(av, bv):
+		default:
+			parts = append(parts, describeChange(key, av, bv))
+		}
+	}
+	return strings.Join(parts, "; "), nil
+}
+
+// decodeObject normalizes raw and decodes it as an object.
+func decodeObject(raw []byte, ignore []string) (map[string]any, error) {
+	normalized, err := Normalize(raw, ignore...)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(normalized))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("expected a JSON object: %w", err)
+	}
+	return doc, nil
+}
+
+// describeChange describes how the field key changed from a to b: lists by
+// the number of items changed, added and removed, anything else by name.
+func describeChange(key string, a, b any) string {
+	aList, aOK := a.([]any)
+	bList, bOK := b.([]any)
+	if !aOK || !bOK {
+		return key
+	}
+	changed := 0
+	for i := 0; i < min(len(aList), len(bList)); i++ {
+		if !reflect.DeepEqual(aList[i], bList[i]) {
+			changed++
+		}
+	}
+	var counts []string
+	if changed > 0 {
+		counts = append(counts, fmt.Sprintf("%d changed", changed))
+	}
+	if n := len(bList) - len(aList); n > 0 {
+		counts = append(counts, fmt.Sprintf("%d added", n))
+	} else if n < 0 {
+		counts = append(counts, fmt.Sprintf("%d removed", -n))
+	}
+	return key + ": " + strings.Join(counts, ", ")
+}
@@ -0,0 +1,188 @@
+// Package downtimes downloads Datadog downtime schedules from the v2
+// downtime API.
+package downtimes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	resource.Register(Kind)
+}
+
+// Kind registers downtimes with the generic resource commands. Their {name}
+// is the downtime's scope, and --tags matches the tags of the monitors they
+// silence as well as the tags in their scope.
+var Kind = &resource.ListKind{
+	KindName:   "downtime",
+	PluralName: "downtimes",
+	IDType:     resource.IDString,
+	Template: func(settings *config.Settings) string {
+		return settings.DowntimesPathTemplate
+	},
+	List:      listDowntimes,
+	Get:       fetchDowntime,
+	Decode:    decodeDowntime,
+	Normalize: NormalizeDowntime,
+	Flags: func(flags *pflag.FlagSet) {
+		flags.Bool("include-expired", false, "Also select ended and cancelled downtimes")
+	},
+	Filter: func(flags *pflag.FlagSet) (func(json.RawMessage) bool, error) {
+		includeExpired, err := flags.GetBool("include-expired")
+		if err != nil {
+			return nil, err
+		}
+		return func(raw json.RawMessage) bool {
+			return includeExpired || !isExpired(raw)
+		}, nil
+	},
+}
+
+// downtime is the part of a v2 downtime dd-tf reads.
+type downtime struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		Scope             string `json:"scope"`
+		Status            string `json:"status"`
+		Canceled          string `json:"canceled"`
+		MonitorIdentifier struct {
+			MonitorTags []string `json:"monitor_tags"`
+		} `json:"monitor_identifier"`
+	} `json:"attributes"`
+}
+
+// listDowntimes pages through /api/v2/downtime.
+func listDowntimes(ctx context.Context, client resource.HTTPClient, settings *config.Settings) ([]json.RawMessage, error) {
+	var all []json.RawMessage
+	pagination := resource.NewOffsetPagination(settings.PageSize)
+	for {
+		query := url.Values{}
+		query.Set("page[offset]", strconv.Itoa(pagination.Start))
+		query.Set("page[limit]", strconv.Itoa(pagination.Count))
+		page, err := resource.FetchDataList(ctx, client, settings.APIBaseURL()+"/api/v2/downtime?"+query.Encode(), settings)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if !pagination.NextOffsetPage(len(page)) {
+			break
+		}
+	}
+	logging.Logger.Debug("downtimes listed", "count", len(all))
+	return all, nil
+}
+
+func fetchDowntime(ctx context.Context, client resource.HTTPClient, settings *config.Settings, id string) (json.RawMessage, error) {
+	return resource.FetchData(ctx, client, settings.APIBaseURL()+"/api/v2/downtime/"+url.PathEscape(id), settings)
+}
+
+func decodeDowntime(raw json.RawMessage) (resource.Meta, error) {
+	var d downtime
+	if err := json.Unmarshal(raw, &d); err != nil {
+		return resource.Meta{}, err
+	}
+	tags := append([]string(nil), d.Attributes.MonitorIdentifier.MonitorTags...)
+	tags = append(tags, ScopeTags(d.Attributes.Scope)...)
+	return resource.Meta{ID: d.ID, Name: d.Attributes.Scope, Tags: tags}, nil
+}
+
+// isExpired reports whether a downtime has ended or was cancelled.
+func isExpired(raw json.RawMessage) bool {
+	var d downtime
+	if err := json.Unmarshal(raw, &d); err != nil {
+		return false
+	}
+	return d.Attributes.Status == "ended" || d.Attributes.Status == "canceled" || d.Attributes.Canceled != ""
+}
+
+// NormalizeDowntime removes the fields Datadog updates as a downtime runs,
+// so the files only change when someone edits the downtime: the status, the
+// modification time and a recurring schedule's current occurrence.
+func NormalizeDowntime(raw json.RawMessage, _ *config.Settings) (json.RawMessage, error) {
+	raw, err := resource.RewriteObject(raw, func(key string, value json.RawMessage) (json.RawMessage, bool, error) {
+		if key != "attributes" {
+			return value, true, nil
+		}
+		value, err := resource.RewriteObject(value, func(key string, value json.RawMessage) (json.RawMessage, bool, error) {
+			switch key {
+			case "status", "modified":
+				return nil, false, nil
+			case "schedule":
+				if string(value) == "null" {
+					return value, true, nil
+				}
+				value, err := resource.StripFields(value, "current_downtime")
+				return value, true, err
+			}
+			return value, true, nil
+		})
+		return value, true, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to normalize downtime: %w", err)
+	}
+	return raw, nil
+}
+
+// ScopeTags returns the tags a downtime scope requires, e.g.
+// "env:(prod OR staging) AND -service:web" gives env:prod and env:staging.
+// Negated terms are left out.
+func ScopeTags(scope string) []string {
+	var tags []string
+	fields := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(scope))
+	key := ""       // key of a "key:(a OR b)" group being read, "-" if it is negated
+	depth := 0      // parentheses open since that group started
+	negate := false // a pending NOT applies to the next term or group
+	for _, field := range fields {
+		switch strings.ToUpper(field) {
+		case "AND", "OR":
+			continue
+		case "NOT":
+			negate = true
+			continue
+		case "(":
+			if key == "" && negate {
+				key = "-"
+			}
+			if key != "" {
+				depth++
+			}
+			negate = false
+			continue
+		case ")":
+			if key != "" {
+				if depth--; depth == 0 {
+					key = ""
+				}
+			}
+			continue
+		}
+		negated := negate || strings.HasPrefix(field, "-") || strings.HasPrefix(field, "!")
+		negate = false
+		field = strings.TrimLeft(field, "-!")
+		switch {
+		case key != "":
+			if key != "-" && !negated && !strings.Contains(field, ":") {
+				tags = append(tags, key+":"+field)
+			}
+		case strings.HasSuffix(field, ":"):
+			key = strings.TrimSuffix(field, ":")
+			if negated {
+				key = "-"
+			}
+		case field != "*" && strings.Contains(field, ":") && !negated:
+			tags = append(tags, field)
+		}
+	}
+	return tags
+}
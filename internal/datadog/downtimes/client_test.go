@@ -0,0 +1,103 @@
+package downtimes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/spf13/pflag"
+)
+
+func TestScopeTags(t *testing.T) {
+	tests := []struct {
+		scope string
+		want  []string
+	}{
+		{"env:prod", []string{"env:prod"}},
+		{"env:prod AND service:web", []string{"env:prod", "service:web"}},
+		{"env:(prod OR staging) AND datacenter:us-east-1", []string{"env:prod", "env:staging", "datacenter:us-east-1"}},
+		{"env:prod AND -service:web AND NOT team:a", []string{"env:prod"}},
+		{"NOT env:(dev OR test) AND service:web", []string{"service:web"}},
+		{"NOT (team:a OR team:b) AND env:prod", []string{"env:prod"}},
+		{"*", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := ScopeTags(tt.scope); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ScopeTags(%q) = %q, want %q", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeDowntime(t *testing.T) {
+	raw := `{"id":"d1","type":"downtime","attributes":{"scope":"env:prod","status":"active","modified":"2024-01-02T00:00:00Z","schedule":{"recurrences":[{"rrule":"FREQ=WEEKLY"}],"current_downtime":{"start":"2024-01-01T00:00:00Z"}}}}`
+	want := `{"id":"d1","type":"downtime","attributes":{"scope":"env:prod","schedule":{"recurrences":[{"rrule":"FREQ=WEEKLY"}]}}}`
+	got, err := NormalizeDowntime([]byte(raw), &config.Settings{})
+	if err != nil {
+		t.Fatalf("NormalizeDowntime() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("NormalizeDowntime() = %s, want %s", got, want)
+	}
+}
+
+func TestKind_Targets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/downtime" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"data":[` +
+			`{"id":"active","attributes":{"scope":"env:prod","status":"active","monitor_identifier":{"monitor_tags":["service:web"]}}},` +
+			`{"id":"scheduled","attributes":{"scope":"env:(prod OR staging)","status":"scheduled","monitor_identifier":{"monitor_id":123}}},` +
+			`{"id":"ended","attributes":{"scope":"env:prod","status":"ended","monitor_identifier":{"monitor_tags":["service:web"]}}},` +
+			`{"id":"cancelled","attributes":{"scope":"env:dev","status":"canceled","canceled":"2024-01-01T00:00:00Z","monitor_identifier":{"monitor_tags":["*"]}}}` +
+			`]}`))
+	}))
+	defer server.Close()
+
+	settings := &config.Settings{Site: server.URL, PageSize: 100, HTTPMaxBodySize: 4096}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+
+	tests := []struct {
+		name           string
+		opts           resource.BaseDownloadOptions
+		includeExpired bool
+		want           []string
+	}{
+		{name: "all excludes expired", opts: resource.BaseDownloadOptions{All: true}, want: []string{"active", "scheduled"}},
+		{name: "include expired", opts: resource.BaseDownloadOptions{All: true}, includeExpired: true, want: []string{"active", "cancelled", "ended", "scheduled"}},
+		{name: "monitor tags", opts: resource.BaseDownloadOptions{Tags: "service:web"}, want: []string{"active"}},
+		{name: "scope tags", opts: resource.BaseDownloadOptions{Tags: "env:staging"}, want: []string{"scheduled"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := pflag.NewFlagSet("downtime", pflag.ContinueOnError)
+			Kind.AddFlags(flags)
+			if tt.includeExpired {
+				flags.Set("include-expired", "true")
+			}
+			targets, err := Kind.Targets(context.Background(), client, settings, tt.opts, flags)
+			if err != nil {
+				t.Fatalf("Targets() error = %v", err)
+			}
+			var got []string
+			for result := range targets {
+				if result.Err != nil {
+					t.Fatalf("Targets() error = %v", result.Err)
+				}
+				got = append(got, result.Target.ID)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Targets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
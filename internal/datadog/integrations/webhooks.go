@@ -0,0 +1,118 @@
+// Package integrations downloads the configuration of Datadog integrations.
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+)
+
+func init() {
+	resource.Register(Webhooks)
+}
+
+// Webhooks registers webhooks as "dd-tf integrations webhooks". Webhooks are
+// identified by their name. Their payload and custom headers are kept as
+// they are, except for the value of an Authorization header.
+var Webhooks = &resource.ListKind{
+	KindName:   "webhook",
+	PluralName: "webhooks",
+	GroupName:  "integrations",
+	IDType:     resource.IDString,
+	IDField:    "name",
+	Template: func(settings *config.Settings) string {
+		return settings.WebhooksPathTemplate
+	},
+	List:      listWebhooks,
+	Get:       fetchWebhook,
+	Summaries: true,
+	Decode:    decodeName,
+	Normalize: NormalizeWebhook,
+}
+
+// RedactedValue replaces the value of Authorization headers.
+const RedactedValue = "REDACTED"
+
+// listWebhooks returns the webhooks of the integration configuration. Their
+// details are fetched on their own, by name.
+func listWebhooks(ctx context.Context, client resource.HTTPClient, settings *config.Settings) ([]json.RawMessage, error) {
+	raw, err := resource.FetchRawFromAPI(ctx, client, settings.APIBaseURL()+"/api/v1/integration/webhooks", settings)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Hooks []json.RawMessage `json:"hooks"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode webhooks integration: %w", err)
+	}
+	return resp.Hooks, nil
+}
+
+func fetchWebhook(ctx context.Context, client resource.HTTPClient, settings *config.Settings, name string) (json.RawMessage, error) {
+	return resource.FetchRawFromAPI(ctx, client, settings.APIBaseURL()+"/api/v1/integration/webhooks/configuration/webhooks/"+url.PathEscape(name), settings)
+}
+
+// decodeName reads resources identified by their "name".
+func decodeName(raw json.RawMessage) (resource.Meta, error) {
+	var fields struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return resource.Meta{}, err
+	}
+	return resource.Meta{ID: fields.Name, Name: fields.Name}, nil
+}
+
+// NormalizeWebhook replaces the value of an Authorization header in
+// custom_headers with RedactedValue, unless WEBHOOKS_REDACT_AUTHORIZATION is
+// off. custom_headers is a string holding a JSON object; it is only
+// rewritten if it has such a header.
+func NormalizeWebhook(raw json.RawMessage, settings *config.Settings) (json.RawMessage, error) {
+	if !settings.WebhooksRedactAuthorization {
+		return raw, nil
+	}
+	raw, err := resource.RewriteObject(raw, func(key string, value json.RawMessage) (json.RawMessage, bool, error) {
+		if key != "custom_headers" {
+			return value, true, nil
+		}
+		var headers string
+		if err := json.Unmarshal(value, &headers); err != nil || headers == "" {
+			// null, or not a string: nothing to redact
+			return value, true, nil
+		}
+		redacted, changed, err := redactAuthorization(headers)
+		if err != nil || !changed {
+			return value, true, err
+		}
+		value, err = json.Marshal(redacted)
+		return value, true, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to normalize webhook: %w", err)
+	}
+	return raw, nil
+}
+
+// redactAuthorization redacts the Authorization header, whatever its case,
+// in a JSON object of headers.
+func redactAuthorization(headers string) (string, bool, error) {
+	redacted, _ := json.Marshal(RedactedValue)
+	changed := false
+	out, err := resource.RewriteObject([]byte(headers), func(key string, value json.RawMessage) (json.RawMessage, bool, error) {
+		if !strings.EqualFold(key, "Authorization") {
+			return value, true, nil
+		}
+		changed = true
+		return redacted, true, nil
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("custom_headers: %w", err)
+	}
+	return string(out), changed, nil
+}
@@ -0,0 +1,116 @@
+package integrations
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+)
+
+func newTestClient() resource.HTTPClient {
+	return internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+}
+
+func TestNormalizeWebhook(t *testing.T) {
+	tests := []struct {
+		name   string
+		redact bool
+		raw    string
+		want   string
+	}{
+		{
+			name:   "redacts authorization",
+			redact: true,
+			raw:    `{"name":"ops","payload":"{\n  \"text\": \"$EVENT_TITLE\"\n}","custom_headers":"{\"authorization\": \"Bearer s3cret\", \"X-Team\": \"ops\"}"}`,
+			want:   `{"name":"ops","payload":"{\n  \"text\": \"$EVENT_TITLE\"\n}","custom_headers":"{\"authorization\":\"REDACTED\",\"X-Team\":\"ops\"}"}`,
+		},
+		{
+			name:   "keeps other headers exactly",
+			redact: true,
+			raw:    `{"name":"ops","custom_headers":"{\"X-Team\" : \"ops\"}"}`,
+			want:   `{"name":"ops","custom_headers":"{\"X-Team\" : \"ops\"}"}`,
+		},
+		{
+			name:   "no custom headers",
+			redact: true,
+			raw:    `{"name":"ops","custom_headers":null}`,
+			want:   `{"name":"ops","custom_headers":null}`,
+		},
+		{
+			name:   "redaction off",
+			redact: false,
+			raw:    `{"name":"ops","custom_headers":"{\"Authorization\": \"Bearer s3cret\"}"}`,
+			want:   `{"name":"ops","custom_headers":"{\"Authorization\": \"Bearer s3cret\"}"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeWebhook([]byte(tt.raw), &config.Settings{WebhooksRedactAuthorization: tt.redact})
+			if err != nil {
+				t.Fatalf("NormalizeWebhook() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("NormalizeWebhook() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhooks_UpdateByName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/integration/webhooks/configuration/webhooks/ops-alerts":
+			w.Write([]byte(`{"name":"ops-alerts","url":"https://example.com/hook","encode_as":"json","payload":null,"custom_headers":"{\"Authorization\": \"Bearer s3cret\"}"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                        server.URL,
+		WebhooksPathTemplate:        filepath.Join(dir, "{name}.json"),
+		WebhooksRedactAuthorization: true,
+		HTTPMaxBodySize:             4096,
+	}
+	existing := filepath.Join(dir, "ops.json")
+	if err := os.WriteFile(existing, []byte(`{"name":"ops-alerts"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	targets, err := Webhooks.Targets(context.Background(), newTestClient(), settings, resource.BaseDownloadOptions{Update: true}, nil)
+	if err != nil {
+		t.Fatalf("Targets() error = %v", err)
+	}
+	for result := range targets {
+		if result.Err != nil {
+			t.Fatalf("Targets() error = %v", result.Err)
+		}
+		path, err := Webhooks.Download(context.Background(), newTestClient(), settings, result.Target, "")
+		if err != nil {
+			t.Fatalf("Download() error = %v", err)
+		}
+		if path != existing {
+			t.Errorf("download --update wrote %s, want %s", path, existing)
+		}
+	}
+	written, _ := os.ReadFile(existing)
+	want := `{
+  "name": "ops-alerts",
+  "url": "https://example.com/hook",
+  "encode_as": "json",
+  "payload": null,
+  "custom_headers": "{\"Authorization\":\"REDACTED\"}"
+}
+`
+	if string(written) != want {
+		t.Errorf("webhook written as\n%s\nwant\n%s", written, want)
+	}
+}
@@ -0,0 +1,62 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+func init() {
+	resource.Register(Archives)
+}
+
+// Archives registers log archives as "dd-tf logs archives". Archives are
+// written verbatim as the v2 "data" item: their destination holds the
+// integration's role or client details but no secrets. Every download also
+// writes the archive order, which no single archive holds.
+var Archives = &archivesKind{&resource.ListKind{
+	KindName:   "archive",
+	PluralName: "archives",
+	GroupName:  "logs",
+	IDType:     resource.IDString,
+	Template: func(settings *config.Settings) string {
+		return settings.LogsArchivesPathTemplate
+	},
+	List:   listArchives,
+	Get:    fetchArchive,
+	Decode: resource.DecodeDataMeta,
+}}
+
+type archivesKind struct {
+	*resource.ListKind
+}
+
+func listArchives(ctx context.Context, client resource.HTTPClient, settings *config.Settings) ([]json.RawMessage, error) {
+	return resource.FetchDataList(ctx, client, settings.APIBaseURL()+"/api/v2/logs/config/archives", settings)
+}
+
+func fetchArchive(ctx context.Context, client resource.HTTPClient, settings *config.Settings, id string) (json.RawMessage, error) {
+	return resource.FetchData(ctx, client, settings.APIBaseURL()+"/api/v2/logs/config/archives/"+url.PathEscape(id), settings)
+}
+
+// DownloadCompanion writes the archive order, the "data" item of
+// /api/v2/logs/config/archive-order, to LOGS_ARCHIVE_ORDER_PATH.
+func (k *archivesKind) DownloadCompanion(ctx context.Context, client resource.HTTPClient, settings *config.Settings) (string, error) {
+	order, err := resource.FetchData(ctx, client, settings.APIBaseURL()+"/api/v2/logs/config/archive-order", settings)
+	if err != nil {
+		return "", err
+	}
+	backend, err := storage.NewBackend(settings)
+	if err != nil {
+		return "", err
+	}
+	path := settings.LogsArchiveOrderPath
+	if err := storage.WriteRawJSON(backend, path, order); err != nil {
+		return "", err
+	}
+	return path, nil
+}
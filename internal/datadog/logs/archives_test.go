@@ -0,0 +1,82 @@
+package logs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+)
+
+func TestArchives_DownloadWithOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/logs/config/archives":
+			w.Write([]byte(`{"data":[{"type":"archives","id":"a1","attributes":{"name":"S3 prod","query":"env:prod","destination":{"type":"s3","bucket":"logs","integration":{"account_id":"123","role_name":"DatadogLogs"}}}}]}`))
+		case "/api/v2/logs/config/archive-order":
+			w.Write([]byte(`{"data":{"type":"archive_order","attributes":{"archive_ids":["a2","a1"]}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                     server.URL,
+		LogsArchivesPathTemplate: filepath.Join(dir, "archives", "{id}.json"),
+		LogsArchiveOrderPath:     filepath.Join(dir, "archive-order.json"),
+		HTTPMaxBodySize:          4096,
+	}
+
+	targets, err := Archives.Targets(context.Background(), newTestClient(), settings, resource.BaseDownloadOptions{All: true}, nil)
+	if err != nil {
+		t.Fatalf("Targets() error = %v", err)
+	}
+	for result := range targets {
+		if result.Err != nil {
+			t.Fatalf("Targets() error = %v", result.Err)
+		}
+		if _, err := Archives.Download(context.Background(), newTestClient(), settings, result.Target, ""); err != nil {
+			t.Fatalf("Download() error = %v", err)
+		}
+	}
+	archive, err := os.ReadFile(filepath.Join(dir, "archives", "a1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"role_name": "DatadogLogs"`; !strings.Contains(string(archive), want) {
+		t.Errorf("archive written as %s, want its destination kept", archive)
+	}
+
+	var companion resource.Kind = Archives
+	path, err := companion.(resource.CompanionDownloader).DownloadCompanion(context.Background(), newTestClient(), settings)
+	if err != nil {
+		t.Fatalf("DownloadCompanion() error = %v", err)
+	}
+	if path != settings.LogsArchiveOrderPath {
+		t.Errorf("DownloadCompanion() wrote %s, want %s", path, settings.LogsArchiveOrderPath)
+	}
+	order, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "type": "archive_order",
+  "attributes": {
+    "archive_ids": [
+      "a2",
+      "a1"
+    ]
+  }
+}
+`
+	if string(order) != want {
+		t.Errorf("archive order written as\n%s\nwant\n%s", order, want)
+	}
+}
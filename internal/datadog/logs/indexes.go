@@ -0,0 +1,61 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+)
+
+func init() {
+	resource.Register(Indexes)
+}
+
+// Indexes registers log indexes as "dd-tf logs indexes". Indexes have no ID:
+// their name identifies them, in the API and for --update. Indexes are
+// written as returned, keeping the order of their exclusion filters, which
+// Datadog applies in order.
+var Indexes = &resource.ListKind{
+	KindName:   "index",
+	PluralName: "indexes",
+	GroupName:  "logs",
+	IDType:     resource.IDString,
+	IDField:    "name",
+	Template: func(settings *config.Settings) string {
+		return settings.LogsIndexesPathTemplate
+	},
+	List:   listIndexes,
+	Get:    fetchIndex,
+	Decode: decodeIndex,
+}
+
+func listIndexes(ctx context.Context, client resource.HTTPClient, settings *config.Settings) ([]json.RawMessage, error) {
+	raw, err := resource.FetchRawFromAPI(ctx, client, settings.APIBaseURL()+"/api/v1/logs/config/indexes", settings)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Indexes []json.RawMessage `json:"indexes"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode indexes: %w", err)
+	}
+	return resp.Indexes, nil
+}
+
+func fetchIndex(ctx context.Context, client resource.HTTPClient, settings *config.Settings, name string) (json.RawMessage, error) {
+	return resource.FetchRawFromAPI(ctx, client, settings.APIBaseURL()+"/api/v1/logs/config/indexes/"+url.PathEscape(name), settings)
+}
+
+func decodeIndex(raw json.RawMessage) (resource.Meta, error) {
+	var index struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(raw, &index); err != nil {
+		return resource.Meta{}, err
+	}
+	return resource.Meta{ID: index.Name, Name: index.Name}, nil
+}
@@ -0,0 +1,93 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+)
+
+const mainIndexJSON = `{"name":"main","filter":{"query":"service:(web OR api)"},"num_retention_days":15,"daily_limit":100000000,"exclusion_filters":[{"name":"drop-debug","is_enabled":true,"filter":{"query":"status:debug","sample_rate":1}},{"name":"sample-info","is_enabled":true,"filter":{"query":"status:info","sample_rate":0.5}}]}`
+
+func TestIndexes_UpdateKeepsIndexVerbatim(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/logs/config/indexes/main":
+			w.Write([]byte(mainIndexJSON))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                    server.URL,
+		LogsIndexesPathTemplate: filepath.Join(dir, "{name}.json"),
+		HTTPMaxBodySize:         4096,
+	}
+	existing := filepath.Join(dir, "renamed-main.json")
+	if err := os.WriteFile(existing, []byte(`{"name":"main"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	targets, err := Indexes.Targets(context.Background(), newTestClient(), settings, resource.BaseDownloadOptions{Update: true}, nil)
+	if err != nil {
+		t.Fatalf("Targets() error = %v", err)
+	}
+	var paths []string
+	for result := range targets {
+		if result.Err != nil {
+			t.Fatalf("Targets() error = %v", result.Err)
+		}
+		path, err := Indexes.Download(context.Background(), newTestClient(), settings, result.Target, "")
+		if err != nil {
+			t.Fatalf("Download() error = %v", err)
+		}
+		paths = append(paths, path)
+	}
+	if len(paths) != 1 || paths[0] != existing {
+		t.Fatalf("download --update wrote %v, want [%s]", paths, existing)
+	}
+
+	written, err := os.ReadFile(existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want any
+	if err := json.Unmarshal(written, &got); err != nil {
+		t.Fatal(err)
+	}
+	json.Unmarshal([]byte(mainIndexJSON), &want)
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("index written as %s, want it unchanged", written)
+	}
+	var index struct {
+		ExclusionFilters []struct {
+			Name string `json:"name"`
+		} `json:"exclusion_filters"`
+	}
+	json.Unmarshal(written, &index)
+	if len(index.ExclusionFilters) != 2 || index.ExclusionFilters[0].Name != "drop-debug" || index.ExclusionFilters[1].Name != "sample-info" {
+		t.Errorf("exclusion filters written as %+v, want their original order", index.ExclusionFilters)
+	}
+}
+
+func TestIndexes_ComputePath(t *testing.T) {
+	settings := &config.Settings{LogsIndexesPathTemplate: "data/logs/indexes/{name}.json"}
+	path, _, err := Indexes.ComputePath(settings, []byte(mainIndexJSON))
+	if err != nil {
+		t.Fatalf("ComputePath() error = %v", err)
+	}
+	if path != filepath.Join("data", "logs", "indexes", "main.json") {
+		t.Errorf("ComputePath() = %q", path)
+	}
+}
@@ -0,0 +1,38 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+)
+
+func init() {
+	resource.Register(Metrics)
+}
+
+// Metrics registers log-based metrics as "dd-tf logs metrics". Their ID is
+// the metric name, e.g. "logs.errors.count". Metrics are written as the v2
+// "data" item, keeping id, type and attributes.
+var Metrics = &resource.ListKind{
+	KindName:   "log metric",
+	PluralName: "metrics",
+	GroupName:  "logs",
+	IDType:     resource.IDString,
+	Template: func(settings *config.Settings) string {
+		return settings.LogsMetricsPathTemplate
+	},
+	List:   listMetrics,
+	Get:    fetchMetric,
+	Decode: resource.DecodeDataMeta,
+}
+
+func listMetrics(ctx context.Context, client resource.HTTPClient, settings *config.Settings) ([]json.RawMessage, error) {
+	return resource.FetchDataList(ctx, client, settings.APIBaseURL()+"/api/v2/logs/config/metrics", settings)
+}
+
+func fetchMetric(ctx context.Context, client resource.HTTPClient, settings *config.Settings, name string) (json.RawMessage, error) {
+	return resource.FetchData(ctx, client, settings.APIBaseURL()+"/api/v2/logs/config/metrics/"+url.PathEscape(name), settings)
+}
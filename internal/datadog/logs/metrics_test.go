@@ -0,0 +1,116 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+)
+
+const errorsMetricJSON = `{"id":"logs.errors.count","type":"logs_metrics","attributes":{"compute":{"aggregation_type":"count"},"filter":{"query":"status:error"}}}`
+
+func newMetricsServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/logs/config/metrics/logs.errors.count":
+			w.Write([]byte(`{"data":` + errorsMetricJSON + `,"links":{"self":"x"}}`))
+		case "/api/v2/logs/config/metrics/logs.requests.duration":
+			w.Write([]byte(`{"data":{"id":"logs.requests.duration","type":"logs_metrics","attributes":{"compute":{"aggregation_type":"distribution","path":"@duration"}}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func downloadMetrics(t *testing.T, settings *config.Settings, opts resource.BaseDownloadOptions) []string {
+	t.Helper()
+	targets, err := Metrics.Targets(context.Background(), newTestClient(), settings, opts, nil)
+	if err != nil {
+		t.Fatalf("Targets() error = %v", err)
+	}
+	var paths []string
+	for result := range targets {
+		if result.Err != nil {
+			t.Fatalf("Targets() error = %v", result.Err)
+		}
+		path, err := Metrics.Download(context.Background(), newTestClient(), settings, result.Target, "")
+		if err != nil {
+			t.Fatalf("Download() error = %v", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestMetrics_DownloadWritesDataItem(t *testing.T) {
+	server := newMetricsServer(t)
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                    server.URL,
+		LogsMetricsPathTemplate: filepath.Join(dir, "{id}.json"),
+		HTTPMaxBodySize:         4096,
+	}
+
+	paths := downloadMetrics(t, settings, resource.BaseDownloadOptions{IDs: "logs.errors.count, logs.requests.duration"})
+	if len(paths) != 2 {
+		t.Fatalf("downloaded %v, want 2 metrics", paths)
+	}
+
+	written, err := os.ReadFile(filepath.Join(dir, "logs.errors.count.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want any
+	if err := json.Unmarshal(written, &got); err != nil {
+		t.Fatal(err)
+	}
+	json.Unmarshal([]byte(errorsMetricJSON), &want)
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("metric written as %s, want the data item %s", written, errorsMetricJSON)
+	}
+}
+
+func TestMetrics_UpdateByName(t *testing.T) {
+	server := newMetricsServer(t)
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                    server.URL,
+		LogsMetricsPathTemplate: filepath.Join(dir, "{id}.json"),
+		HTTPMaxBodySize:         4096,
+	}
+	existing := filepath.Join(dir, "errors.json")
+	if err := os.WriteFile(existing, []byte(`{"id":"logs.errors.count","type":"logs_metrics"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := downloadMetrics(t, settings, resource.BaseDownloadOptions{Update: true})
+	if len(paths) != 1 || paths[0] != existing {
+		t.Fatalf("download --update wrote %v, want [%s]", paths, existing)
+	}
+	written, err := os.ReadFile(existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var metric struct {
+		Attributes struct {
+			Filter struct {
+				Query string `json:"query"`
+			} `json:"filter"`
+		} `json:"attributes"`
+	}
+	json.Unmarshal(written, &metric)
+	if metric.Attributes.Filter.Query != "status:error" {
+		t.Errorf("metric written as %s, want it refreshed", written)
+	}
+}
@@ -0,0 +1,86 @@
+// Package logs downloads Datadog log management configuration.
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	resource.Register(Pipelines)
+}
+
+// Pipelines registers log pipelines as "dd-tf logs pipelines". The list
+// endpoint returns full pipelines, which are cached on the targets.
+var Pipelines = &resource.ListKind{
+	KindName:   "pipeline",
+	PluralName: "pipelines",
+	GroupName:  "logs",
+	IDType:     resource.IDString,
+	Template: func(settings *config.Settings) string {
+		return settings.LogsPipelinesPathTemplate
+	},
+	List: listPipelines,
+	Get:  fetchPipeline,
+	Flags: func(flags *pflag.FlagSet) {
+		flags.Bool("include-integration", false, "Also select read-only integration pipelines")
+		flags.String("name-filter", "", "Only select pipelines whose name matches this regular expression")
+	},
+	Filter: pipelineFilter,
+}
+
+func listPipelines(ctx context.Context, client resource.HTTPClient, settings *config.Settings) ([]json.RawMessage, error) {
+	raw, err := resource.FetchRawFromAPI(ctx, client, settings.APIBaseURL()+"/api/v1/logs/config/pipelines", settings)
+	if err != nil {
+		return nil, err
+	}
+	var pipelines []json.RawMessage
+	if err := json.Unmarshal(raw, &pipelines); err != nil {
+		return nil, fmt.Errorf("failed to decode pipelines: %w", err)
+	}
+	return pipelines, nil
+}
+
+func fetchPipeline(ctx context.Context, client resource.HTTPClient, settings *config.Settings, id string) (json.RawMessage, error) {
+	return resource.FetchRawFromAPI(ctx, client, settings.APIBaseURL()+"/api/v1/logs/config/pipelines/"+url.PathEscape(id), settings)
+}
+
+// pipelineFilter skips read-only integration pipelines, which can't be
+// managed with Terraform, unless --include-integration is set, and applies
+// --name-filter.
+func pipelineFilter(flags *pflag.FlagSet) (func(json.RawMessage) bool, error) {
+	includeIntegration, err := flags.GetBool("include-integration")
+	if err != nil {
+		return nil, err
+	}
+	nameFilter, err := flags.GetString("name-filter")
+	if err != nil {
+		return nil, err
+	}
+	var nameRe *regexp.Regexp
+	if nameFilter != "" {
+		if nameRe, err = regexp.Compile(nameFilter); err != nil {
+			return nil, fmt.Errorf("invalid --name-filter: %w", err)
+		}
+	}
+	return func(raw json.RawMessage) bool {
+		var pipeline struct {
+			Name       string `json:"name"`
+			IsReadOnly bool   `json:"is_read_only"`
+		}
+		if err := json.Unmarshal(raw, &pipeline); err != nil {
+			return false
+		}
+		if pipeline.IsReadOnly && !includeIntegration {
+			return false
+		}
+		return nameRe == nil || nameRe.MatchString(pipeline.Name)
+	}, nil
+}
@@ -0,0 +1,93 @@
+package logs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/spf13/pflag"
+)
+
+func newTestClient() resource.HTTPClient {
+	return internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+}
+
+func TestPipelines_Targets(t *testing.T) {
+	var getRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/logs/config/pipelines" {
+			atomic.AddInt32(&getRequests, 1)
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[` +
+			`{"id":"p1","name":"Web access logs","is_read_only":false,"processors":[]},` +
+			`{"id":"p2","name":"Nginx","is_read_only":true,"processors":[]},` +
+			`{"id":"p3","name":"Web errors","is_read_only":false,"processors":[]}` +
+			`]`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                      server.URL,
+		LogsPipelinesPathTemplate: filepath.Join(dir, "{id}.json"),
+		HTTPMaxBodySize:           4096,
+	}
+	client := newTestClient()
+
+	tests := []struct {
+		name  string
+		flags map[string]string
+		want  []string
+	}{
+		{name: "integration pipelines skipped", want: []string{"p1", "p3"}},
+		{name: "include integration", flags: map[string]string{"include-integration": "true"}, want: []string{"p1", "p2", "p3"}},
+		{name: "name filter", flags: map[string]string{"name-filter": "(?i)errors$"}, want: []string{"p3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := pflag.NewFlagSet("pipeline", pflag.ContinueOnError)
+			Pipelines.AddFlags(flags)
+			for name, value := range tt.flags {
+				flags.Set(name, value)
+			}
+			targets, err := Pipelines.Targets(context.Background(), client, settings, resource.BaseDownloadOptions{All: true}, flags)
+			if err != nil {
+				t.Fatalf("Targets() error = %v", err)
+			}
+			var got []string
+			for result := range targets {
+				if result.Err != nil {
+					t.Fatalf("Targets() error = %v", result.Err)
+				}
+				if _, err := Pipelines.Download(context.Background(), client, settings, result.Target, ""); err != nil {
+					t.Fatalf("Download() error = %v", err)
+				}
+				got = append(got, result.Target.ID)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Targets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if getRequests != 0 {
+		t.Errorf("%d per-pipeline requests made, want 0 (list data is cached)", getRequests)
+	}
+
+	flags := pflag.NewFlagSet("pipeline", pflag.ContinueOnError)
+	Pipelines.AddFlags(flags)
+	flags.Set("name-filter", "(")
+	if _, err := Pipelines.Targets(context.Background(), client, settings, resource.BaseDownloadOptions{All: true}, flags); err == nil {
+		t.Error("Targets() expected an error for an invalid --name-filter")
+	}
+}
@@ -0,0 +1,118 @@
+// Package metrics downloads Datadog metric metadata: the unit, description,
+// per_unit and type of each metric.
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	resource.Register(Kind)
+}
+
+// Kind registers metric metadata as "dd-tf metrics". The metric list only
+// has names, so each selected metric's metadata is fetched on its own. The
+// metadata has no name, so it is written with the metric name as its "id".
+var Kind = &resource.ListKind{
+	KindName:      "metric",
+	PluralName:    "metrics",
+	IDType:        resource.IDString,
+	IDPlaceholder: "{metric_name}",
+	Template: func(settings *config.Settings) string {
+		return settings.MetricsPathTemplate
+	},
+	List:      listMetrics,
+	Get:       fetchMetadata,
+	Decode:    decodeMetric,
+	Summaries: true,
+	Flags: func(flags *pflag.FlagSet) {
+		flags.String("filter", "", "Select the metrics whose name matches this regular expression, e.g. '^myapp\\.'")
+	},
+	Filter:      metricFilter,
+	SelectFlags: []string{"filter"},
+}
+
+// listMetrics returns every metric name as {"id": name}.
+func listMetrics(ctx context.Context, client resource.HTTPClient, settings *config.Settings) ([]json.RawMessage, error) {
+	raw, err := resource.FetchRawFromAPI(ctx, client, settings.APIBaseURL()+"/api/v1/search?q=metrics:", settings)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Results struct {
+			Metrics []string `json:"metrics"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode metrics: %w", err)
+	}
+	items := make([]json.RawMessage, 0, len(resp.Results.Metrics))
+	for _, name := range resp.Results.Metrics {
+		item, err := json.Marshal(map[string]string{"id": name})
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	logging.Logger.Debug("metrics listed", "count", len(items))
+	return items, nil
+}
+
+// fetchMetadata fetches a metric's metadata and adds its name as "id".
+func fetchMetadata(ctx context.Context, client resource.HTTPClient, settings *config.Settings, name string) (json.RawMessage, error) {
+	raw, err := resource.FetchRawFromAPI(ctx, client, settings.APIBaseURL()+"/api/v1/metrics/"+url.PathEscape(name), settings)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := resource.StripFields(raw, "id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode metric metadata: %w", err)
+	}
+	id, err := json.Marshal(name)
+	if err != nil {
+		return nil, err
+	}
+	withID := append([]byte(`{"id":`), id...)
+	if len(metadata) > 2 {
+		withID = append(withID, ',')
+	}
+	return append(withID, metadata[1:]...), nil
+}
+
+func decodeMetric(raw json.RawMessage) (resource.Meta, error) {
+	var metric struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &metric); err != nil {
+		return resource.Meta{}, err
+	}
+	return resource.Meta{ID: metric.ID, Name: metric.ID}, nil
+}
+
+// metricFilter selects metrics by --filter, matched against their name.
+func metricFilter(flags *pflag.FlagSet) (func(json.RawMessage) bool, error) {
+	filter, err := flags.GetString("filter")
+	if err != nil {
+		return nil, err
+	}
+	if filter == "" {
+		return func(json.RawMessage) bool { return true }, nil
+	}
+	filterRe, err := regexp.Compile(filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --filter: %w", err)
+	}
+	return func(raw json.RawMessage) bool {
+		meta, err := decodeMetric(raw)
+		return err == nil && filterRe.MatchString(meta.ID)
+	}, nil
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/spf13/pflag"
+)
+
+func newTestServer(t *testing.T, metadataRequests *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/search":
+			w.Write([]byte(`{"results":{"metrics":["myapp.requests.count","myapp.requests_count","system.cpu.user"]}}`))
+		case strings.HasPrefix(r.URL.Path, "/api/v1/metrics/"):
+			atomic.AddInt32(metadataRequests, 1)
+			w.Write([]byte(`{"description":"Requests","short_name":"reqs","type":"count","unit":"request","per_unit":"second","statsd_interval":null}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestKind_FilterSelectsAndKeepsDots(t *testing.T) {
+	var metadataRequests int32
+	server := newTestServer(t, &metadataRequests)
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                server.URL,
+		MetricsPathTemplate: filepath.Join(dir, "{metric_name}.json"),
+		HTTPMaxBodySize:     4096,
+	}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+
+	flags := pflag.NewFlagSet("metrics", pflag.ContinueOnError)
+	Kind.AddFlags(flags)
+	if err := flags.Parse([]string{"--filter", `^myapp\.`}); err != nil {
+		t.Fatal(err)
+	}
+	targets, err := Kind.Targets(context.Background(), client, settings, resource.BaseDownloadOptions{}, flags)
+	if err != nil {
+		t.Fatalf("Targets() error = %v", err)
+	}
+	var paths []string
+	for result := range targets {
+		if result.Err != nil {
+			t.Fatalf("Targets() error = %v", result.Err)
+		}
+		if result.Target.Data != nil {
+			t.Errorf("target %s has the list summary cached", result.Target.ID)
+		}
+		path, err := Kind.Download(context.Background(), client, settings, result.Target, "")
+		if err != nil {
+			t.Fatalf("Download() error = %v", err)
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	want := []string{filepath.Join(dir, "myapp.requests.count.json"), filepath.Join(dir, "myapp.requests_count.json")}
+	if len(paths) != 2 || paths[0] != want[0] || paths[1] != want[1] {
+		t.Fatalf("download --filter wrote %v, want %v", paths, want)
+	}
+	if metadataRequests != 2 {
+		t.Errorf("fetched metadata %d times, want 2", metadataRequests)
+	}
+
+	written, err := os.ReadFile(want[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(written), "{\n  \"id\": \"myapp.requests.count\",\n  \"description\": \"Requests\",") {
+		t.Errorf("metadata written as %s, want the metric name first", written)
+	}
+}
+
+func TestKind_RequiresSelection(t *testing.T) {
+	_, err := Kind.Targets(context.Background(), nil, &config.Settings{}, resource.BaseDownloadOptions{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "--filter") {
+		t.Errorf("Targets() error = %v, want one suggesting --filter", err)
+	}
+}
+
+func TestKind_InvalidFilter(t *testing.T) {
+	flags := pflag.NewFlagSet("metrics", pflag.ContinueOnError)
+	Kind.AddFlags(flags)
+	flags.Parse([]string{"--filter", "("})
+	if _, err := Kind.Targets(context.Background(), nil, &config.Settings{}, resource.BaseDownloadOptions{}, flags); err == nil {
+		t.Error("Targets() expected error for an invalid --filter")
+	}
+}
@@ -1,16 +1,16 @@
 package monitors
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/AD7six/dd-tf/internal/config"
 	"github.com/AD7six/dd-tf/internal/datadog/resource"
 	"github.com/AD7six/dd-tf/internal/datadog/templating"
-	internalhttp "github.com/AD7six/dd-tf/internal/http"
 	"github.com/AD7six/dd-tf/internal/logging"
 	"github.com/AD7six/dd-tf/internal/storage"
 )
@@ -23,8 +23,22 @@ type MonitorTargetResult = resource.TargetResult[int]
 
 // DownloadOptions contains options for downloading monitors.
 type DownloadOptions struct {
-	resource.BaseDownloadOptions     // Embedded common options
-	Priority                     int // Filter by monitor priority
+	resource.BaseDownloadOptions        // Embedded common options
+	Priority                     int    // Filter by monitor priority
+	WithDependencies             bool   // Also select monitors referenced by composite monitors
+	QueryScope                   string // Comma-separated tags the monitor query must be scoped to
+	AnyReference                 string // Comma-separated tags the monitor must have or its query be scoped to
+}
+
+// MonitorMeta is the subset of monitor fields needed for filtering and path
+// templating, decoded from the raw payload with a targeted struct.
+type MonitorMeta struct {
+	ID       int      `json:"id"`
+	Name     string   `json:"name"`
+	Tags     []string `json:"tags"`
+	Priority int      `json:"priority"`
+	Query    string   `json:"query"`
+	Modified string   `json:"modified"`
 }
 
 // monitorTemplateData holds the data available in path templates for monitors
@@ -37,13 +51,11 @@ type monitorTemplateData struct {
 
 // GenerateMonitorTargets returns a channel that yields monitor IDs and target paths.
 // If filterTags or team is set, fetches all monitors and filters by tags/team/priority.
-func GenerateMonitorTargets(opts DownloadOptions) (<-chan MonitorTargetResult, error) {
+// Targets are emitted as each list page is decoded so downloads can start while
+// later pages are still being fetched. The producer stops, and closes the
+// channel, once ctx is done.
+func GenerateMonitorTargets(ctx context.Context, client resource.HTTPClient, settings *config.Settings, opts DownloadOptions) (<-chan MonitorTargetResult, error) {
 	out := make(chan MonitorTargetResult)
-	settings, err := config.LoadSettings()
-	if err != nil {
-		close(out)
-		return nil, err
-	}
 
 	// Parse monitor IDs from comma-separated string
 	var ids []int
@@ -60,182 +72,444 @@ func GenerateMonitorTargets(opts DownloadOptions) (<-chan MonitorTargetResult, e
 		}
 	}
 
-	// Parse filter tags from comma-separated string
-	var filterTags []string
-	if opts.Tags != "" {
-		tagStrs := strings.Split(opts.Tags, ",")
-		for _, t := range tagStrs {
-			filterTags = append(filterTags, strings.TrimSpace(t))
-		}
-	}
+	filter := newMonitorFilter(ids, opts.Team, splitTags(opts.Tags), opts.Priority)
+	filter.queryScope = splitTags(opts.QueryScope)
+	filter.anyReference = splitTags(opts.AnyReference)
 
 	go func() {
 		defer close(out)
-		client := internalhttp.GetHTTPClient(settings)
 		// --update: scan existing monitor files and use their paths
 		if opts.Update {
 			monitorsDir := templating.ExtractStaticPrefix(settings.MonitorsPathTemplate)
-			idToPath, err := storage.ExtractIntIDsFromJSONFiles(monitorsDir)
+			backend, err := storage.NewBackend(settings)
 			if err != nil {
-				out <- MonitorTargetResult{Err: fmt.Errorf("failed to scan directory: %w", err)}
+				resource.Send(ctx, out, MonitorTargetResult{Err: err})
 				return
 			}
-			for id, path := range idToPath {
-				out <- MonitorTargetResult{Target: MonitorTarget{ID: id, Path: path}}
-			}
-			return
-		}
-		// Always fetch from the list endpoint - it contains all the data we need
-		// (including matching_downtimes which is not in the individual monitor endpoint)
-		// Use pagination to handle large numbers of monitors
-		var allMonitors []map[string]any
-		pagination := resource.NewPagePagination(settings.PageSize)
-		for {
-			url := pagination.FormatPageURL(fmt.Sprintf("https://api.%s/api/v1/monitor", settings.Site))
-			resp, err := client.Get(url)
+			idToPath, err := storage.ExtractIntIDs(backend, monitorsDir)
 			if err != nil {
-				out <- MonitorTargetResult{Err: fmt.Errorf("failed to fetch monitors page %d: %w", pagination.Page, err)}
+				resource.Send(ctx, out, MonitorTargetResult{Err: fmt.Errorf("failed to scan directory: %w", err)})
 				return
 			}
-			if resp.StatusCode != http.StatusOK {
-				body, _ := io.ReadAll(io.LimitReader(resp.Body, settings.HTTPMaxBodySize))
-				resp.Body.Close()
-				out <- MonitorTargetResult{Err: fmt.Errorf("API error on page %d: %s\n%s", pagination.Page, resp.Status, string(body))}
-				return
-			}
-			var monitorsList []map[string]any
-			if err := json.NewDecoder(resp.Body).Decode(&monitorsList); err != nil {
-				resp.Body.Close()
-				out <- MonitorTargetResult{Err: fmt.Errorf("failed to decode monitors page %d: %w", pagination.Page, err)}
-				return
+			for id, path := range idToPath {
+				if !resource.Send(ctx, out, MonitorTargetResult{Target: MonitorTarget{ID: id, Path: path}}) {
+					return
+				}
 			}
-			resp.Body.Close()
+			return
+		}
 
-			if len(monitorsList) == 0 {
-				break
+		// --id without any other filter: no need to page through the list
+		// endpoint, each monitor is fetched individually at download time
+		if len(ids) > 0 && !filter.needsListData() {
+			for _, id := range ids {
+				if !resource.Send(ctx, out, MonitorTargetResult{Target: MonitorTarget{ID: id}}) {
+					return
+				}
 			}
-			allMonitors = append(allMonitors, monitorsList...)
+			return
+		}
 
-			// Check if there might be more pages
-			if !pagination.NextPage(len(monitorsList)) {
-				break
-			}
+		listURL := settings.APIBaseURL() + "/api/v1/monitor"
+		emitMonitorTargets(ctx, client, listURL, settings, filter, out)
+	}()
+	if opts.WithDependencies {
+		return withDependencies(ctx, client, settings, out), nil
+	}
+	return out, nil
+}
+
+// monitorFilter holds the selection criteria applied to each monitor returned
+// by the list endpoint.
+type monitorFilter struct {
+	ids          map[int]struct{}
+	team         string
+	tags         []string
+	priority     int
+	queryScope   []string // tags parsed from the query, see ParseQuery
+	anyReference []string // each either a monitor tag or in the query
+}
+
+func newMonitorFilter(ids []int, team string, tags []string, priority int) monitorFilter {
+	f := monitorFilter{team: team, tags: tags, priority: priority}
+	if len(ids) > 0 {
+		f.ids = make(map[int]struct{}, len(ids))
+		for _, id := range ids {
+			f.ids[id] = struct{}{}
 		}
+	}
+	return f
+}
 
-		for _, mon := range allMonitors {
-			// Filter by ID if specified and not --all
-			if len(ids) > 0 {
-				idVal, ok := mon["id"].(float64)
-				if !ok {
-					continue
-				}
-				idInt := int(idVal)
-				found := false
-				for _, want := range ids {
-					if want == idInt {
-						found = true
-						break
-					}
-				}
-				if !found {
-					continue
-				}
+// splitTags parses a comma-separated list of tags.
+func splitTags(list string) []string {
+	if list == "" {
+		return nil
+	}
+	var tags []string
+	for _, t := range strings.Split(list, ",") {
+		tags = append(tags, strings.TrimSpace(t))
+	}
+	return tags
+}
+
+// needsListData reports whether the filter depends on monitor fields (tags,
+// priority, query) and therefore requires the list endpoint.
+func (f monitorFilter) needsListData() bool {
+	return f.team != "" || len(f.tags) > 0 || f.priority > 0 || len(f.queryScope) > 0 || len(f.anyReference) > 0
+}
+
+// matches reports whether a monitor from the list endpoint satisfies the filter.
+func (f monitorFilter) matches(mon MonitorMeta) bool {
+	if mon.ID == 0 {
+		return false
+	}
+	// Filter by ID if specified and not --all
+	if f.ids != nil {
+		if _, found := f.ids[mon.ID]; !found {
+			return false
+		}
+	}
+	// Filter by tags/team
+	tags := extractTags(mon)
+	if f.team != "" && tags["team"] != f.team {
+		return false
+	}
+	if len(f.tags) > 0 && !templating.HasAllTagsMap(tags, f.tags) {
+		return false
+	}
+	// Filter by priority
+	if f.priority > 0 && mon.Priority != f.priority {
+		return false
+	}
+	// Filter by the tags the query refers to
+	if len(f.queryScope) > 0 || len(f.anyReference) > 0 {
+		refs := ParseQuery(mon.Query)
+		if !refs.Matches(f.queryScope) {
+			return false
+		}
+		for _, tag := range f.anyReference {
+			if !templating.HasAllTagsMap(tags, []string{tag}) && !refs.Matches([]string{tag}) {
+				return false
 			}
-			// Filter by tags/team
-			tags := extractTags(mon)
-			if opts.Team != "" && tags["team"] != opts.Team {
+		}
+	}
+	return true
+}
+
+// emitMonitorTargets pages through the monitors list endpoint and sends a
+// target for every matching monitor as soon as its page has been decoded.
+// The list endpoint contains all the data we need (including
+// matching_downtimes which is not in the individual monitor endpoint), so the
+// monitor data is cached on the target.
+func emitMonitorTargets(ctx context.Context, client resource.HTTPClient, listURL string, settings *config.Settings, filter monitorFilter, out chan<- MonitorTargetResult) {
+	// emit sends targets for the matching monitors of a page, returning false
+	// if ctx is done
+	emit := func(page int, monitorsList []json.RawMessage) bool {
+		for _, raw := range monitorsList {
+			var mon MonitorMeta
+			if err := json.Unmarshal(raw, &mon); err != nil {
+				logging.Logger.Warn("failed to decode monitor", "page", page, "error", err)
 				continue
 			}
-			if len(filterTags) > 0 && !templating.HasAllTagsMap(tags, filterTags) {
+			if !filter.matches(mon) {
 				continue
 			}
-			// Filter by priority
-			if opts.Priority > 0 {
-				if p, ok := mon["priority"].(float64); !ok || int(p) != opts.Priority {
-					continue
-				}
+			if !resource.Send(ctx, out, MonitorTargetResult{Target: MonitorTarget{ID: mon.ID, Path: "", Data: raw, Modified: mon.Modified}}) {
+				return false
 			}
-			// Yield monitor
-			idVal, ok := mon["id"].(float64)
-			if !ok {
+		}
+		return true
+	}
+
+	pagination := resource.NewPagePagination(settings.MonitorsPageSize)
+	for {
+		monitorsList, err := fetchMonitorsPage(ctx, client, listURL, pagination, settings)
+		if err != nil {
+			if pagination.ShrinkAfterError(err) {
+				logging.Logger.Warn("page size rejected by API, retrying with a smaller page", "page_size", pagination.PageSize, "error", err)
 				continue
 			}
-			idInt := int(idVal)
-			out <- MonitorTargetResult{Target: MonitorTarget{ID: idInt, Path: "", Data: mon}}
+			resource.Send(ctx, out, MonitorTargetResult{Err: err})
+			return
 		}
-	}()
-	return out, nil
+		if !emit(pagination.Page, monitorsList) {
+			return
+		}
+
+		// Check if there might be more pages
+		if !pagination.NextPage(len(monitorsList)) {
+			return
+		}
+
+		// Once the first page confirms there is more to fetch, the remaining
+		// pages can be requested concurrently if enabled
+		if settings.ParallelListPages {
+			emitMonitorPagesParallel(ctx, client, listURL, pagination, settings, emit, out)
+			return
+		}
+	}
 }
 
-// extractTags extracts tags from a monitor JSON object as a map[string]string
-func extractTags(mon map[string]any) map[string]string {
-	if raw, ok := mon["tags"]; ok {
-		return templating.ExtractTagMap(raw, false)
+// emitMonitorPagesParallel fetches list pages concurrently, starting at
+// pagination.Page, with as many in flight as HTTP_MAX_CONCURRENCY allows, and
+// emits them in page order so the output is deterministic. Once a short or
+// failed page has been seen no later page is requested, and emitting stops
+// at the first short page. The HTTP client's 429 pause still applies to
+// every request.
+func emitMonitorPagesParallel(ctx context.Context, client resource.HTTPClient, listURL string, pagination *resource.PaginationParams, settings *config.Settings, emit func(int, []json.RawMessage) bool, out chan<- MonitorTargetResult) {
+	window := settings.HTTPMaxConcurrency
+	if window < 1 {
+		window = config.DefaultHTTPMaxConcurrency
 	}
-	return map[string]string{}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type page struct {
+		number int
+		items  []json.RawMessage
+		err    error
+	}
+	// Buffered so fetches still in flight on return don't block
+	results := make(chan page, window)
+	fetch := func(number int) {
+		p := &resource.PaginationParams{Page: number, PageSize: pagination.PageSize}
+		items, err := fetchMonitorsPage(ctx, client, listURL, p, settings)
+		results <- page{number: number, items: items, err: err}
+	}
+
+	next, scheduled, inFlight := pagination.Page, pagination.Page, 0
+	last := -1 // The first short or failed page seen, -1 until then
+	fetched := map[int]page{}
+	for {
+		for inFlight < window && last < 0 {
+			go fetch(scheduled)
+			scheduled++
+			inFlight++
+		}
+		p := <-results
+		inFlight--
+		fetched[p.number] = p
+		if (p.err != nil || len(p.items) < pagination.PageSize) && (last < 0 || p.number < last) {
+			last = p.number
+		}
+
+		for {
+			p, ok := fetched[next]
+			if !ok {
+				break
+			}
+			delete(fetched, next)
+			if p.err != nil {
+				resource.Send(ctx, out, MonitorTargetResult{Err: p.err})
+				return
+			}
+			if !emit(next, p.items) || len(p.items) < pagination.PageSize {
+				return
+			}
+			next++
+		}
+	}
+}
+
+// fetchMonitorsPage fetches and decodes a single page of the monitors list
+// endpoint, leaving each monitor as raw JSON.
+func fetchMonitorsPage(ctx context.Context, client resource.HTTPClient, listURL string, pagination *resource.PaginationParams, settings *config.Settings) ([]json.RawMessage, error) {
+	url := pagination.FormatPageURL(listURL)
+	resp, err := client.GetWithContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch monitors page %d: %w", pagination.Page, err)
+	}
+	defer resp.Body.Close()
+
+	if err := resource.CheckResponse(resp, settings); err != nil {
+		return nil, fmt.Errorf("monitors page %d: %w", pagination.Page, err)
+	}
+
+	var monitorsList []json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&monitorsList); err != nil {
+		return nil, fmt.Errorf("failed to decode monitors page %d: %w", pagination.Page, err)
+	}
+	logging.Logger.Debug("fetched monitors page", "page", pagination.Page, "page_size", pagination.PageSize, "returned", len(monitorsList))
+	return monitorsList, nil
 }
 
-// DownloadMonitorWithOptions fetches a monitor and writes it to the specified path.
-func DownloadMonitorWithOptions(target MonitorTarget, outputPath string) error {
-	settings, err := config.LoadSettings()
+// ListMonitorIDs returns the ID of every monitor, from the list endpoint.
+func ListMonitorIDs(ctx context.Context, client resource.HTTPClient, settings *config.Settings) (map[string]bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	targets, err := GenerateMonitorTargets(ctx, client, settings, DownloadOptions{
+		BaseDownloadOptions: resource.BaseDownloadOptions{All: true},
+	})
 	if err != nil {
-		return err
-	}
-	var result map[string]any
-	if target.Data != nil {
-		result = target.Data
-	} else {
-		client := internalhttp.GetHTTPClient(settings)
-		url := fmt.Sprintf("https://api.%s/api/v1/monitor/%d", settings.Site, target.ID)
-		var err error
-		result, err = resource.FetchResourceFromAPI(client, url, settings)
+		return nil, err
+	}
+	ids := map[string]bool{}
+	for result := range targets {
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		ids[strconv.Itoa(result.Target.ID)] = true
+	}
+	return ids, nil
+}
+
+// extractTags extracts tags from a monitor as a map[string]string
+func extractTags(mon MonitorMeta) map[string]string {
+	return templating.ExtractTagMap(mon.Tags, false)
+}
+
+// DownloadMonitorWithOptions fetches a monitor and writes it to the specified
+// path, returning the path written. A monitor missing one of REQUIRED_TAGS
+// returns a *resource.UntaggedError: it is not written, or with
+// ON_MISSING_REQUIRED_TAG=quarantine it is written under the quarantine
+// directory and the error carries that path.
+func DownloadMonitorWithOptions(ctx context.Context, client resource.HTTPClient, settings *config.Settings, target MonitorTarget, outputPath string) (string, error) {
+	var err error
+	raw := target.Data
+	// List data lacks group states, so they need the monitor endpoint
+	if raw == nil || settings.MonitorsGroupStates != "" {
+		raw, err = fetchMonitor(ctx, client, settings, target.ID)
 		if err != nil {
-			return err
+			return "", err
 		}
 	}
 
-	// Remove runtime state fields that cause unnecessary churn
-	delete(result, "matching_downtimes")
+	raw, err = NormalizeMonitor(raw, settings)
+	if err != nil {
+		return "", err
+	}
+
+	var meta MonitorMeta
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return "", fmt.Errorf("failed to decode monitor: %w", err)
+	}
+	meta.ID = target.ID
 
 	// Compute path if not provided
 	targetPath := target.Path
 	if targetPath == "" {
-		// Build template pattern (output override or settings default)
-		pattern := outputPath
-		if pattern == "" {
-			pattern = settings.MonitorsPathTemplate
+		targetPath, err = computeMonitorPath(settings, meta, outputPath)
+		if err != nil {
+			return "", err
 		}
-		pattern = templating.TranslatePlaceholders(pattern, templating.BuildMonitorBuiltins())
+	}
 
-		// Extract and sanitize data for templating
-		name := "untitled"
-		if v, ok := result["name"].(string); ok && v != "" {
-			name = storage.SanitizeFilename(v)
+	untagged := resource.CheckRequiredTags(settings, meta.Name, extractTags(meta))
+	if untagged != nil {
+		if settings.OnMissingRequiredTag != config.OnMissingTagQuarantine {
+			return "", untagged
 		}
+		untagged.Path = resource.QuarantinePath(monitorPathTemplate(settings, outputPath), targetPath)
+		targetPath = untagged.Path
+	}
 
-		tagMap := templating.ExtractTagMap(result["tags"], true)
-		var prio int
-		if p, ok := result["priority"].(float64); ok {
-			prio = int(p)
+	backend, err := storage.NewBackend(settings)
+	if err != nil {
+		return "", err
+	}
+	if err := storage.WriteRawJSON(backend, targetPath, raw); err != nil {
+		return "", err
+	}
+	if settings.MonitorsWithRestrictionPolicy {
+		if _, err := resource.DownloadRestrictionPolicy(ctx, client, settings, "monitor", strconv.Itoa(target.ID), targetPath); err != nil {
+			return "", err
 		}
+	}
+	if untagged != nil {
+		return targetPath, untagged
+	}
+	return targetPath, nil
+}
 
-		data := monitorTemplateData{
-			ID:       target.ID,
-			Name:     name,
-			Tags:     tagMap,
-			Priority: prio,
-		}
+// monitorPathTemplate returns the path template in effect: the --output
+// override or MONITORS_PATH_TEMPLATE.
+func monitorPathTemplate(settings *config.Settings, outputPath string) string {
+	if outputPath != "" {
+		return outputPath
+	}
+	return settings.MonitorsPathTemplate
+}
 
-		// Compute path from template
-		var err error
-		targetPath, err = templating.ComputePathFromTemplate(pattern, data)
-		if err != nil {
-			return err
+// FetchMonitorJSON fetches a single monitor and returns it formatted
+// exactly as it would be written to a file.
+func FetchMonitorJSON(ctx context.Context, client resource.HTTPClient, settings *config.Settings, id int) ([]byte, error) {
+	raw, err := fetchMonitor(ctx, client, settings, id)
+	if err != nil {
+		return nil, err
+	}
+	raw, err = NormalizeMonitor(raw, settings)
+	if err != nil {
+		return nil, err
+	}
+	return storage.FormatJSON(raw)
+}
+
+// fetchMonitor fetches the raw JSON for a single monitor. Unlike the list
+// endpoint, the monitor endpoint only includes matching_downtimes when asked
+// to, so it is requested when runtime fields are kept. Group states are
+// requested when MONITORS_GROUP_STATES is set.
+func fetchMonitor(ctx context.Context, client resource.HTTPClient, settings *config.Settings, id int) (json.RawMessage, error) {
+	query := url.Values{}
+	if settings.MonitorsIncludeRuntime {
+		query.Set("with_downtimes", "true")
+	}
+	if settings.MonitorsGroupStates != "" {
+		query.Set("group_states", settings.MonitorsGroupStates)
+	}
+	endpoint := fmt.Sprintf("%s/api/v1/monitor/%d", settings.APIBaseURL(), id)
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	return resource.FetchRawFromAPI(ctx, client, endpoint, settings)
+}
+
+// RuntimeFields are monitor fields describing current state rather than
+// configuration. They change without anyone editing the monitor, so they are
+// stripped unless MONITORS_INCLUDE_RUNTIME (--include-runtime) is set. The
+// state block is kept when MONITORS_GROUP_STATES asks for it.
+var RuntimeFields = []string{"matching_downtimes", "state"}
+
+// NormalizeMonitor removes settings.MonitorsStripFields, and the runtime
+// state fields that cause unnecessary churn unless
+// settings.MonitorsIncludeRuntime is set. Monitors are normalized with it when
+// downloaded, compared and pushed, so both sides of a comparison must use the
+// same settings.
+func NormalizeMonitor(raw json.RawMessage, settings *config.Settings) (json.RawMessage, error) {
+	paths := settings.MonitorsStripFields
+	if !settings.MonitorsIncludeRuntime {
+		paths = append([]string(nil), paths...)
+		for _, f := range RuntimeFields {
+			if f != "state" || settings.MonitorsGroupStates == "" {
+				paths = append(paths, f)
+			}
 		}
 	}
-	if err := storage.WriteJSONFile(targetPath, result); err != nil {
-		return err
+	raw, err := resource.StripPaths(raw, paths...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to strip fields: %w", err)
 	}
-	logging.Logger.Info("monitor saved", "path", targetPath)
-	return nil
+	return raw, nil
+}
+
+// computeMonitorPath computes the file path from the configured pattern or
+// outputPath override using Go templates.
+func computeMonitorPath(settings *config.Settings, monitor MonitorMeta, outputPath string) (string, error) {
+	pattern := templating.TranslatePlaceholders(monitorPathTemplate(settings, outputPath), templating.BuildMonitorBuiltins())
+
+	// Extract and sanitize data for templating
+	name := "untitled"
+	if monitor.Name != "" {
+		name = storage.SanitizeFilename(monitor.Name)
+	}
+
+	data := monitorTemplateData{
+		ID:       monitor.ID,
+		Name:     name,
+		Tags:     templating.ExtractTagMap(monitor.Tags, true),
+		Priority: monitor.Priority,
+	}
+
+	// Compute path from template
+	return templating.ComputePathFromTemplate(pattern, data)
 }
@@ -1,11 +1,32 @@
 package monitors
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
 	"github.com/AD7six/dd-tf/internal/datadog/templating"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/storage"
 )
 
+// newTestClient returns an unshared Datadog client; tests point it at an
+// httptest server through the request URL.
+func newTestClient() resource.HTTPClient {
+	return internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+}
+
 func TestTranslateToTemplate(t *testing.T) {
 	cases := []struct {
 		name, input, expected string
@@ -30,12 +51,12 @@ func TestTranslateToTemplate(t *testing.T) {
 func TestExtractTags(t *testing.T) {
 	cases := []struct {
 		name     string
-		monitor  map[string]any
+		monitor  MonitorMeta
 		expected map[string]string
 	}{
-		{"extracts tags", map[string]any{"id": float64(1), "tags": []interface{}{"team:platform", "env:prod"}}, map[string]string{"team": "platform", "env": "prod"}},
-		{"filters invalid tag entries", map[string]any{"id": float64(1), "tags": []interface{}{"team:platform", "invalid", "service:api"}}, map[string]string{"team": "platform", "service": "api"}},
-		{"missing tags field", map[string]any{"id": float64(1)}, map[string]string{}},
+		{"extracts tags", MonitorMeta{ID: 1, Tags: []string{"team:platform", "env:prod"}}, map[string]string{"team": "platform", "env": "prod"}},
+		{"filters invalid tag entries", MonitorMeta{ID: 1, Tags: []string{"team:platform", "invalid", "service:api"}}, map[string]string{"team": "platform", "service": "api"}},
+		{"missing tags field", MonitorMeta{ID: 1}, map[string]string{}},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -52,6 +73,575 @@ func TestExtractTags(t *testing.T) {
 	}
 }
 
+func TestEmitMonitorTargets_StreamsPages(t *testing.T) {
+	const pageSize = 2
+	const pages = 3
+	firstWritten := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == pages-1 {
+			// Don't serve the last page until the first monitor is on disk
+			select {
+			case <-firstWritten:
+			case <-time.After(5 * time.Second):
+				t.Errorf("last page requested before the first monitor was written")
+			}
+		}
+		count := pageSize
+		if page == pages-1 {
+			count = 1 // short page ends pagination
+		}
+		w.Write([]byte("["))
+		for i := 0; i < count; i++ {
+			if i > 0 {
+				w.Write([]byte(","))
+			}
+			fmt.Fprintf(w, `{"id":%d,"name":"m%d"}`, page*pageSize+i+1, page*pageSize+i+1)
+		}
+		w.Write([]byte("]"))
+	}))
+	defer server.Close()
+
+	settings := &config.Settings{MonitorsPageSize: pageSize, HTTPMaxBodySize: 1024}
+	out := make(chan MonitorTargetResult)
+	go func() {
+		defer close(out)
+		emitMonitorTargets(context.Background(), newTestClient(), server.URL, settings, newMonitorFilter(nil, "", nil, 0), out)
+	}()
+
+	dir := t.TempDir()
+	var got []int
+	for result := range out {
+		if result.Err != nil {
+			t.Fatalf("unexpected error: %v", result.Err)
+		}
+		path := filepath.Join(dir, strconv.Itoa(result.Target.ID)+".json")
+		if err := storage.WriteRawJSONFile(path, result.Target.Data); err != nil {
+			t.Fatalf("WriteRawJSONFile() error = %v", err)
+		}
+		if len(got) == 0 {
+			close(firstWritten)
+		}
+		got = append(got, result.Target.ID)
+	}
+
+	if len(got) != 5 {
+		t.Fatalf("got %d monitors, want 5: %v", len(got), got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "1.json")); err != nil {
+		t.Errorf("expected first monitor file to exist: %v", err)
+	}
+}
+
+func TestEmitMonitorTargets_ParallelPages(t *testing.T) {
+	const pageSize = 3
+	const pages = 10
+	const total = (pages-1)*pageSize + 2 // last page is short
+
+	newServer := func() *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			w.Write([]byte("["))
+			for i := 0; i < pageSize; i++ {
+				id := page*pageSize + i + 1
+				if id > total {
+					break
+				}
+				if i > 0 {
+					w.Write([]byte(","))
+				}
+				fmt.Fprintf(w, `{"id":%d}`, id)
+			}
+			w.Write([]byte("]"))
+		}))
+	}
+
+	for _, parallel := range []bool{false, true} {
+		t.Run(fmt.Sprintf("parallel=%v", parallel), func(t *testing.T) {
+			server := newServer()
+			defer server.Close()
+
+			settings := &config.Settings{MonitorsPageSize: pageSize, HTTPMaxBodySize: 1024, ParallelListPages: parallel}
+			out := make(chan MonitorTargetResult)
+			go func() {
+				defer close(out)
+				emitMonitorTargets(context.Background(), newTestClient(), server.URL, settings, newMonitorFilter(nil, "", nil, 0), out)
+			}()
+
+			var got []int
+			for result := range out {
+				if result.Err != nil {
+					t.Fatalf("unexpected error: %v", result.Err)
+				}
+				got = append(got, result.Target.ID)
+			}
+
+			if len(got) != total {
+				t.Fatalf("got %d monitors, want %d", len(got), total)
+			}
+			for i, id := range got {
+				if id != i+1 {
+					t.Fatalf("monitor %d has id %d, want %d (duplicate or out of order)", i, id, i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestEmitMonitorTargets_ParallelWindow(t *testing.T) {
+	const pageSize = 2
+	const short = 6 // The last page, with one monitor
+	const window = 3
+
+	var inFlight, maxInFlight, maxPage atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for m := maxInFlight.Load(); n > m && !maxInFlight.CompareAndSwap(m, n); m = maxInFlight.Load() {
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		for m := maxPage.Load(); int64(page) > m && !maxPage.CompareAndSwap(m, int64(page)); m = maxPage.Load() {
+		}
+		time.Sleep(5 * time.Millisecond)
+		switch {
+		case page < short:
+			fmt.Fprintf(w, `[{"id":%d},{"id":%d}]`, page*pageSize+1, page*pageSize+2)
+		case page == short:
+			fmt.Fprintf(w, `[{"id":%d}]`, page*pageSize+1)
+		default:
+			w.Write([]byte("[]"))
+		}
+	}))
+	defer server.Close()
+
+	settings := &config.Settings{MonitorsPageSize: pageSize, HTTPMaxBodySize: 1024, HTTPMaxConcurrency: window, ParallelListPages: true}
+	out := make(chan MonitorTargetResult)
+	go func() {
+		defer close(out)
+		emitMonitorTargets(context.Background(), newTestClient(), server.URL, settings, newMonitorFilter(nil, "", nil, 0), out)
+	}()
+	var got int
+	for result := range out {
+		if result.Err != nil {
+			t.Fatalf("unexpected error: %v", result.Err)
+		}
+		got++
+	}
+
+	if got != short*pageSize+1 {
+		t.Errorf("got %d monitors, want %d", got, short*pageSize+1)
+	}
+	if n := maxInFlight.Load(); n > window {
+		t.Errorf("%d pages requested at once, want at most HTTP_MAX_CONCURRENCY (%d)", n, window)
+	}
+	// Pages already in flight when the short one returns may be past it
+	if n := maxPage.Load(); n > short+window-1 {
+		t.Errorf("requested page %d, want none after %d", n, short+window-1)
+	}
+}
+
+func TestEmitMonitorTargets_ShrinksPageSizeOn400(t *testing.T) {
+	var sizes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size := r.URL.Query().Get("page_size")
+		sizes = append(sizes, size)
+		if size == "4" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["page_size is too large"]}`))
+			return
+		}
+		w.Write([]byte(`[{"id":1}]`))
+	}))
+	defer server.Close()
+
+	settings := &config.Settings{MonitorsPageSize: 4, HTTPMaxBodySize: 1024}
+	out := make(chan MonitorTargetResult)
+	go func() {
+		defer close(out)
+		emitMonitorTargets(context.Background(), newTestClient(), server.URL, settings, newMonitorFilter(nil, "", nil, 0), out)
+	}()
+
+	var got []int
+	for result := range out {
+		if result.Err != nil {
+			t.Fatalf("unexpected error: %v", result.Err)
+		}
+		got = append(got, result.Target.ID)
+	}
+
+	if len(got) != 1 || got[0] != 1 {
+		t.Errorf("got monitors %v, want [1]", got)
+	}
+	if len(sizes) != 2 || sizes[0] != "4" || sizes[1] != "2" {
+		t.Errorf("requested page sizes %v, want [4 2]", sizes)
+	}
+}
+
+func TestMonitorFilter_Matches(t *testing.T) {
+	mon := MonitorMeta{
+		ID:       42,
+		Priority: 2,
+		Tags:     []string{"team:platform", "env:prod"},
+		Query:    "avg(last_5m):avg:system.cpu.user{service:web,!env:staging} by {host} > 90",
+	}
+	withQuery := func(queryScope, anyReference []string) monitorFilter {
+		f := newMonitorFilter(nil, "", nil, 0)
+		f.queryScope, f.anyReference = queryScope, anyReference
+		return f
+	}
+	cases := []struct {
+		name   string
+		filter monitorFilter
+		want   bool
+	}{
+		{"no filter", newMonitorFilter(nil, "", nil, 0), true},
+		{"matching id", newMonitorFilter([]int{1, 42}, "", nil, 0), true},
+		{"other id", newMonitorFilter([]int{1}, "", nil, 0), false},
+		{"matching team", newMonitorFilter(nil, "platform", nil, 0), true},
+		{"other team", newMonitorFilter(nil, "web", nil, 0), false},
+		{"matching tags", newMonitorFilter(nil, "", []string{"env:prod"}, 0), true},
+		{"missing tag", newMonitorFilter(nil, "", []string{"env:dev"}, 0), false},
+		{"matching priority", newMonitorFilter(nil, "", nil, 2), true},
+		{"other priority", newMonitorFilter(nil, "", nil, 1), false},
+		{"matching query scope", withQuery([]string{"service:web"}, nil), true},
+		{"matching query group by", withQuery([]string{"host"}, nil), true},
+		{"negated query scope", withQuery([]string{"env:staging"}, nil), false},
+		{"query scope ignores monitor tags", withQuery([]string{"env:prod"}, nil), false},
+		{"any reference to a monitor tag", withQuery(nil, []string{"env:prod"}), true},
+		{"any reference to the query", withQuery(nil, []string{"service:web"}), true},
+		{"any reference to both", withQuery(nil, []string{"env:prod", "service:web"}), true},
+		{"any reference missing", withQuery(nil, []string{"service:api"}), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.filter.matches(mon); got != c.want {
+				t.Errorf("matches() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+// newMonitorServer serves single monitors by id, 404 for anything else.
+func newMonitorServer(t *testing.T, monitors map[string]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := monitors[strings.TrimPrefix(r.URL.Path, "/api/v1/monitor/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+}
+
+func TestGenerateMonitorTargets_IDsSkipList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL)
+	}))
+	defer server.Close()
+
+	settings := &config.Settings{Site: server.URL, MonitorsPageSize: 100}
+	targets, err := GenerateMonitorTargets(context.Background(), newTestClient(), settings, DownloadOptions{
+		BaseDownloadOptions: resource.BaseDownloadOptions{IDs: "1, 2"},
+	})
+	if err != nil {
+		t.Fatalf("GenerateMonitorTargets() error = %v", err)
+	}
+	var got []int
+	for result := range targets {
+		got = append(got, result.Target.ID)
+	}
+	if fmt.Sprint(got) != "[1 2]" {
+		t.Errorf("got ids %v, want [1 2]", got)
+	}
+}
+
+// waitClosed drains ch and fails the test if it isn't closed promptly.
+func waitClosed(t *testing.T, ch <-chan MonitorTargetResult) {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("producer did not stop after cancel")
+		}
+	}
+}
+
+func TestGenerateMonitorTargets_StopsOnCancel(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		// Every page is full, so pagination never ends by itself
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		fmt.Fprintf(w, `[{"id":%d},{"id":%d}]`, page*2+1, page*2+2)
+	}))
+	defer server.Close()
+
+	for _, parallel := range []bool{false, true} {
+		t.Run(fmt.Sprintf("parallel=%v", parallel), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			settings := &config.Settings{Site: server.URL, MonitorsPageSize: 2, HTTPMaxBodySize: 1024, ParallelListPages: parallel}
+			targets, err := GenerateMonitorTargets(ctx, newTestClient(), settings, DownloadOptions{
+				BaseDownloadOptions: resource.BaseDownloadOptions{All: true},
+			})
+			if err != nil {
+				t.Fatalf("GenerateMonitorTargets() error = %v", err)
+			}
+
+			if first := <-targets; first.Err != nil || first.Target.ID != 1 {
+				t.Fatalf("first target = %+v, want monitor 1", first)
+			}
+			cancel()
+			waitClosed(t, targets)
+
+			// Stop reading: no more pages may be requested once the
+			// producer has returned
+			before := atomic.LoadInt32(&requests)
+			time.Sleep(50 * time.Millisecond)
+			if after := atomic.LoadInt32(&requests); after != before {
+				t.Errorf("%d requests made after the producer stopped", after-before)
+			}
+		})
+	}
+}
+
+func TestGenerateMonitorTargets_InvalidID(t *testing.T) {
+	_, err := GenerateMonitorTargets(context.Background(), newTestClient(), &config.Settings{}, DownloadOptions{
+		BaseDownloadOptions: resource.BaseDownloadOptions{IDs: "abc"},
+	})
+	if err == nil {
+		t.Error("GenerateMonitorTargets() expected error for a non-numeric id")
+	}
+}
+
+func TestDownloadMonitorWithOptions(t *testing.T) {
+	server := newMonitorServer(t, map[string]string{
+		"42": `{"id":42,"name":"CPU high","priority":2,"matching_downtimes":[]}`,
+	})
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                 server.URL,
+		MonitorsPathTemplate: filepath.Join(dir, "{priority}", "{id}.json"),
+		HTTPMaxBodySize:      1024,
+	}
+	path, err := DownloadMonitorWithOptions(context.Background(), newTestClient(), settings, MonitorTarget{ID: 42}, "")
+	if err != nil {
+		t.Fatalf("DownloadMonitorWithOptions() error = %v", err)
+	}
+	if want := filepath.Join(dir, "2", "42.json"); path != want {
+		t.Errorf("DownloadMonitorWithOptions() path = %q, want %q", path, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("monitor file not written: %v", err)
+	}
+	if strings.Contains(string(data), "matching_downtimes") {
+		t.Errorf("runtime fields should be stripped: %s", data)
+	}
+
+	if _, err := DownloadMonitorWithOptions(context.Background(), newTestClient(), settings, MonitorTarget{ID: 7}, ""); err == nil {
+		t.Error("DownloadMonitorWithOptions() expected error for a missing monitor")
+	}
+}
+
+func TestDownloadMonitorWithOptions_RequiredTags(t *testing.T) {
+	server := newMonitorServer(t, map[string]string{
+		"42": `{"id":42,"name":"CPU high","tags":["env:prod"]}`,
+		"43": `{"id":43,"name":"Disk","tags":["team:a"]}`,
+	})
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                 server.URL,
+		MonitorsPathTemplate: filepath.Join(dir, "{team}", "{id}.json"),
+		HTTPMaxBodySize:      1024,
+		RequiredTags:         []string{"team"},
+		OnMissingRequiredTag: config.OnMissingTagSkip,
+	}
+	var untagged *resource.UntaggedError
+	_, err := DownloadMonitorWithOptions(context.Background(), newTestClient(), settings, MonitorTarget{ID: 42}, "")
+	if !errors.As(err, &untagged) || untagged.Title != "CPU high" || untagged.Path != "" {
+		t.Fatalf("DownloadMonitorWithOptions() error = %v, want an untagged error", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "none", "42.json")); err == nil {
+		t.Error("skipped monitor was written")
+	}
+
+	settings.OnMissingRequiredTag = config.OnMissingTagQuarantine
+	path, err := DownloadMonitorWithOptions(context.Background(), newTestClient(), settings, MonitorTarget{ID: 42}, "")
+	want := filepath.Join(dir, resource.QuarantineDir, "42.json")
+	if !errors.As(err, &untagged) || path != want || untagged.Path != want {
+		t.Fatalf("DownloadMonitorWithOptions() = %q, %v, want %q and an untagged error", path, err, want)
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("quarantined monitor not written: %v", err)
+	}
+
+	if path, err := DownloadMonitorWithOptions(context.Background(), newTestClient(), settings, MonitorTarget{ID: 43}, ""); err != nil || path != filepath.Join(dir, "a", "43.json") {
+		t.Errorf("DownloadMonitorWithOptions() tagged = %q, %v", path, err)
+	}
+}
+
+func TestNormalizeMonitor(t *testing.T) {
+	raw := []byte(`{"id":1,"matching_downtimes":[{"id":9}],"name":"x"}`)
+
+	stripped, err := NormalizeMonitor(raw, &config.Settings{})
+	if err != nil {
+		t.Fatalf("NormalizeMonitor() error = %v", err)
+	}
+	if string(stripped) != `{"id":1,"name":"x"}` {
+		t.Errorf("NormalizeMonitor() = %s, want runtime fields stripped", stripped)
+	}
+
+	kept, err := NormalizeMonitor(raw, &config.Settings{MonitorsIncludeRuntime: true})
+	if err != nil {
+		t.Fatalf("NormalizeMonitor() error = %v", err)
+	}
+	if string(kept) != string(raw) {
+		t.Errorf("NormalizeMonitor() with MonitorsIncludeRuntime = %s, want unchanged", kept)
+	}
+}
+
+func TestNormalizeMonitor_StripFields(t *testing.T) {
+	raw := []byte(`{"id":1,"creator":{"handle":"a"},"matching_downtimes":[],"options":{"silenced":{},"thresholds":{"critical":1}}}`)
+	settings := &config.Settings{MonitorsStripFields: []string{"creator", "options.silenced"}, MonitorsIncludeRuntime: true}
+
+	got, err := NormalizeMonitor(raw, settings)
+	if err != nil {
+		t.Fatalf("NormalizeMonitor() error = %v", err)
+	}
+	if want := `{"id":1,"matching_downtimes":[],"options":{"thresholds":{"critical":1}}}`; string(got) != want {
+		t.Errorf("NormalizeMonitor() = %s, want %s", got, want)
+	}
+}
+
+func TestDownloadMonitorWithOptions_IncludeRuntime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("with_downtimes") != "true" {
+			t.Errorf("request %s should ask for downtimes", r.URL)
+		}
+		w.Write([]byte(`{"id":42,"name":"CPU high","matching_downtimes":[{"id":9}]}`))
+	}))
+	defer server.Close()
+
+	settings := &config.Settings{
+		Site:                   server.URL,
+		MonitorsPathTemplate:   filepath.Join(t.TempDir(), "{id}.json"),
+		HTTPMaxBodySize:        1024,
+		MonitorsIncludeRuntime: true,
+	}
+	path, err := DownloadMonitorWithOptions(context.Background(), newTestClient(), settings, MonitorTarget{ID: 42}, "")
+	if err != nil {
+		t.Fatalf("DownloadMonitorWithOptions() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "matching_downtimes") {
+		t.Errorf("runtime fields should be kept: %s", data)
+	}
+}
+
+func TestNormalizeMonitor_GroupStates(t *testing.T) {
+	raw := []byte(`{"id":1,"matching_downtimes":[],"state":{"groups":{}},"name":"x"}`)
+
+	stripped, err := NormalizeMonitor(raw, &config.Settings{})
+	if err != nil {
+		t.Fatalf("NormalizeMonitor() error = %v", err)
+	}
+	if string(stripped) != `{"id":1,"name":"x"}` {
+		t.Errorf("NormalizeMonitor() = %s, want state stripped", stripped)
+	}
+
+	kept, err := NormalizeMonitor(raw, &config.Settings{MonitorsGroupStates: "alert"})
+	if err != nil {
+		t.Fatalf("NormalizeMonitor() error = %v", err)
+	}
+	if string(kept) != `{"id":1,"state":{"groups":{}},"name":"x"}` {
+		t.Errorf("NormalizeMonitor() with MonitorsGroupStates = %s, want only state kept", kept)
+	}
+}
+
+func TestDownloadMonitorWithOptions_GroupStates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("group_states") != "warn" {
+			t.Errorf("request %s should ask for warn group states", r.URL)
+		}
+		w.Write([]byte(`{"id":42,"name":"CPU high","state":{"groups":{"host:a":{"status":"Warn"}}}}`))
+	}))
+	defer server.Close()
+
+	settings := &config.Settings{
+		Site:                 server.URL,
+		MonitorsPathTemplate: filepath.Join(t.TempDir(), "{id}.json"),
+		HTTPMaxBodySize:      1024,
+		MonitorsGroupStates:  "warn",
+	}
+	// Cached list data has no state, so the monitor is fetched regardless
+	target := MonitorTarget{ID: 42, Data: []byte(`{"id":42,"name":"CPU high"}`)}
+	path, err := DownloadMonitorWithOptions(context.Background(), newTestClient(), settings, target, "")
+	if err != nil {
+		t.Fatalf("DownloadMonitorWithOptions() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"host:a"`) {
+		t.Errorf("group states should be stored: %s", data)
+	}
+}
+
+func TestDownloadMonitorWithOptions_RestrictionPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/restriction_policy/monitor:42":
+			w.Write([]byte(`{"data":{"id":"monitor:42","type":"restriction_policy","attributes":{"bindings":[{"relation":"editor","principals":["role:abc"]}]}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                          server.URL,
+		MonitorsPathTemplate:          filepath.Join(dir, "{team}", "{id}.json"),
+		HTTPMaxBodySize:               1024,
+		MonitorsWithRestrictionPolicy: true,
+	}
+	for _, id := range []int{42, 43} {
+		target := MonitorTarget{ID: id, Data: []byte(fmt.Sprintf(`{"id":%d,"name":"CPU high","tags":["team:ops"]}`, id))}
+		if _, err := DownloadMonitorWithOptions(context.Background(), newTestClient(), settings, target, ""); err != nil {
+			t.Fatalf("DownloadMonitorWithOptions(%d) error = %v", id, err)
+		}
+	}
+
+	policy, err := os.ReadFile(filepath.Join(dir, "ops", "42.policy.json"))
+	if err != nil {
+		t.Fatalf("policy not written next to the monitor: %v", err)
+	}
+	if !strings.HasPrefix(string(policy), `{
+  "id": "monitor:42",`) {
+		t.Errorf("policy written as %s, want the data item", policy)
+	}
+	// 43 has no policy (404)
+	if _, err := os.Stat(filepath.Join(dir, "ops", "43.policy.json")); !os.IsNotExist(err) {
+		t.Errorf("no policy file should be written without a policy, got %v", err)
+	}
+}
+
 // Removed broad DownloadMonitorWithOptions panic-guard tests; they were
 // checking side-effects instead of path construction logic.
 
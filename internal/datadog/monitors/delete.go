@@ -0,0 +1,72 @@
+package monitors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+)
+
+// compositeRefRegex finds the composite monitor IDs in the API's error for
+// a monitor that composites still reference, e.g. "monitor [123,] is
+// referenced in composite monitors: [456,789]".
+var (
+	compositeRefRegex = regexp.MustCompile(`(?i)composite monitors?\D*((?:\d+[,\s\]]*)+)`)
+	digitsRegex       = regexp.MustCompile(`\d+`)
+)
+
+// ReferencedError is returned when a monitor can't be deleted because
+// composite monitors reference it.
+type ReferencedError struct {
+	ID         int
+	Composites []int // Referencing composites, when the API named them
+	Err        error
+}
+
+func (e *ReferencedError) Error() string {
+	ids := make([]string, len(e.Composites))
+	for i, id := range e.Composites {
+		ids[i] = strconv.Itoa(id)
+	}
+	msg := fmt.Sprintf("monitor %d is referenced by composite monitor(s)", e.ID)
+	if len(ids) > 0 {
+		msg += " " + strings.Join(ids, ", ")
+	}
+	return msg + "; delete or edit them first, or use --force"
+}
+
+func (e *ReferencedError) Unwrap() error { return e.Err }
+
+// DeleteMonitor deletes the monitor with id. With force the API deletes it
+// even if composite monitors or SLOs reference it; without, a reference by
+// a composite returns a *ReferencedError.
+func DeleteMonitor(ctx context.Context, client resource.WriteClient, settings *config.Settings, id int, force bool) error {
+	endpoint := fmt.Sprintf("%s/api/v1/monitor/%d", settings.APIBaseURL(), id)
+	if force {
+		endpoint += "?force=true"
+	}
+	_, err := resource.DeleteFromAPI(ctx, client, endpoint, settings)
+	var apiErr *resource.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest {
+		for _, msg := range apiErr.Messages() {
+			match := compositeRefRegex.FindStringSubmatch(msg)
+			if match == nil {
+				continue
+			}
+			refErr := &ReferencedError{ID: id, Err: err}
+			for _, s := range digitsRegex.FindAllString(match[1], -1) {
+				if ref, convErr := strconv.Atoi(s); convErr == nil && ref != id {
+					refErr.Composites = append(refErr.Composites, ref)
+				}
+			}
+			return refErr
+		}
+	}
+	return err
+}
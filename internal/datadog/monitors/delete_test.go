@@ -0,0 +1,69 @@
+package monitors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+)
+
+func TestDeleteMonitor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		switch {
+		case r.URL.Path == "/api/v1/monitor/1":
+			w.Write([]byte(`{"deleted_monitor_id":1}`))
+		case r.URL.Path == "/api/v1/monitor/2" && r.URL.Query().Get("force") == "true":
+			w.Write([]byte(`{"deleted_monitor_id":2}`))
+		case r.URL.Path == "/api/v1/monitor/2":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["monitor [2,] is referenced in composite monitors: [10,11]"]}`))
+		case r.URL.Path == "/api/v1/monitor/3":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["Something else"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 1024}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+	ctx := context.Background()
+
+	if err := DeleteMonitor(ctx, client, settings, 1, false); err != nil {
+		t.Errorf("DeleteMonitor(1) error = %v", err)
+	}
+
+	err := DeleteMonitor(ctx, client, settings, 2, false)
+	var refErr *ReferencedError
+	if !errors.As(err, &refErr) {
+		t.Fatalf("DeleteMonitor(2) error = %v, want a ReferencedError", err)
+	}
+	if !reflect.DeepEqual(refErr.Composites, []int{10, 11}) {
+		t.Errorf("ReferencedError.Composites = %v, want [10 11]", refErr.Composites)
+	}
+	if !strings.Contains(err.Error(), "composite monitor(s) 10, 11") {
+		t.Errorf("DeleteMonitor(2) error = %q, want the composites named", err)
+	}
+	if err := DeleteMonitor(ctx, client, settings, 2, true); err != nil {
+		t.Errorf("DeleteMonitor(2, force) error = %v", err)
+	}
+
+	err = DeleteMonitor(ctx, client, settings, 3, false)
+	if errors.As(err, &refErr) || err == nil {
+		t.Errorf("DeleteMonitor(3) error = %v, want the API error as is", err)
+	}
+	if err := DeleteMonitor(ctx, client, settings, 4, false); !errors.Is(err, resource.ErrNotFound) {
+		t.Errorf("DeleteMonitor(4) error = %v, want not found", err)
+	}
+}
@@ -0,0 +1,105 @@
+package monitors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/logging"
+)
+
+// compositeIDRegex matches the monitor IDs in a composite monitor query,
+// e.g. "12345 && !(67890 || 111)".
+var compositeIDRegex = regexp.MustCompile(`\b\d+\b`)
+
+// CompositeDependencies returns the IDs of the monitors a composite monitor
+// references in its query, in order of first appearance. Other monitor types
+// have none.
+func CompositeDependencies(raw json.RawMessage) ([]int, error) {
+	var monitor struct {
+		Type  string `json:"type"`
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(raw, &monitor); err != nil {
+		return nil, fmt.Errorf("failed to decode monitor: %w", err)
+	}
+	if monitor.Type != "composite" {
+		return nil, nil
+	}
+	var ids []int
+	seen := map[int]bool{}
+	for _, s := range compositeIDRegex.FindAllString(monitor.Query, -1) {
+		id, err := strconv.Atoi(s)
+		if err != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// withDependencies passes targets through, followed by each one's composite
+// dependencies (recursively) marked as Dependency. Every monitor is emitted
+// at most once, which also stops reference cycles. Targets without cached
+// data are fetched here to read their query, and keep the data so download
+// doesn't fetch them again.
+func withDependencies(ctx context.Context, client resource.HTTPClient, settings *config.Settings, targets <-chan MonitorTargetResult) <-chan MonitorTargetResult {
+	out := make(chan MonitorTargetResult)
+	go func() {
+		defer close(out)
+		seen := map[int]bool{}
+		// emit sends target then, depth first, its unseen dependencies
+		var emit func(target MonitorTarget) bool
+		emit = func(target MonitorTarget) bool {
+			if target.Data == nil {
+				data, err := fetchMonitor(ctx, client, settings, target.ID)
+				if err != nil {
+					// Leave the failure to download, which reports it with the ID
+					return resource.Send(ctx, out, MonitorTargetResult{Target: target})
+				}
+				target.Data = data
+			}
+			if !resource.Send(ctx, out, MonitorTargetResult{Target: target}) {
+				return false
+			}
+			deps, err := CompositeDependencies(target.Data)
+			if err != nil {
+				logging.Logger.Warn("failed to read composite dependencies", "id", target.ID, "error", err)
+				return true
+			}
+			for _, dep := range deps {
+				if seen[dep] {
+					continue
+				}
+				seen[dep] = true
+				logging.Logger.Debug("selecting composite dependency", "id", dep, "composite", target.ID)
+				if !emit(MonitorTarget{ID: dep, Dependency: true}) {
+					return false
+				}
+			}
+			return true
+		}
+
+		for result := range targets {
+			if result.Err != nil {
+				if !resource.Send(ctx, out, result) {
+					return
+				}
+				continue
+			}
+			if seen[result.Target.ID] {
+				continue
+			}
+			seen[result.Target.ID] = true
+			if !emit(result.Target) {
+				return
+			}
+		}
+	}()
+	return out
+}
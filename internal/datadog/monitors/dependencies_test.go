@@ -0,0 +1,92 @@
+package monitors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+)
+
+func TestCompositeDependencies(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want []int
+	}{
+		{"composite", `{"type":"composite","query":"12345 && !(67890 || 12345)"}`, []int{12345, 67890}},
+		{"not composite", `{"type":"metric alert","query":"avg(last_5m):avg:system.cpu.user{host:42} > 90"}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CompositeDependencies(json.RawMessage(tt.raw))
+			if err != nil {
+				t.Fatalf("CompositeDependencies() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompositeDependencies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateMonitorTargets_WithDependencies(t *testing.T) {
+	// 1 -> 2, 3; 3 -> 1 (cycle), 4
+	monitors := map[string]string{
+		"1": `{"id":1,"type":"composite","query":"2 && 3"}`,
+		"2": `{"id":2,"type":"metric alert","query":"avg:cpu{*} > 1"}`,
+		"3": `{"id":3,"type":"composite","query":"1 || 4"}`,
+		"4": `{"id":4,"type":"metric alert","query":"avg:mem{*} > 1"}`,
+	}
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		body, ok := monitors[strings.TrimPrefix(r.URL.Path, "/api/v1/monitor/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 1024}
+
+	targets, err := GenerateMonitorTargets(context.Background(), newTestClient(), settings, DownloadOptions{
+		BaseDownloadOptions: resource.BaseDownloadOptions{IDs: "1,4"},
+		WithDependencies:    true,
+	})
+	if err != nil {
+		t.Fatalf("GenerateMonitorTargets() error = %v", err)
+	}
+
+	var ids []int
+	dependencies := 0
+	for result := range targets {
+		if result.Err != nil {
+			t.Fatalf("target error = %v", result.Err)
+		}
+		if result.Target.Data == nil {
+			t.Errorf("monitor %d has no cached data", result.Target.ID)
+		}
+		if result.Target.Dependency {
+			dependencies++
+		}
+		ids = append(ids, result.Target.ID)
+	}
+	if want := []int{1, 2, 3, 4}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("targets = %v, want %v", ids, want)
+	}
+	if dependencies != 3 {
+		t.Errorf("dependencies = %d, want 3 (4 was reached through 3 before it was selected)", dependencies)
+	}
+	if got := requests.Load(); got != 4 {
+		t.Errorf("requests = %d, want one per monitor", got)
+	}
+}
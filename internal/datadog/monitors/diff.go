@@ -0,0 +1,46 @@
+package monitors
+
+import (
+	"context"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/diff"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+// CompareMonitor returns the monitor in the file at path and the remote
+// monitor with the same id, or with remoteID if it isn't 0, both normalized
+// as downloads are. Compare them ignoring the ReadOnlyFields. A monitor that
+// doesn't exist remotely returns an error wrapping resource.ErrNotFound.
+func CompareMonitor(ctx context.Context, client resource.HTTPClient, settings *config.Settings, backend storage.Backend, path string, remoteID int) (local, remote []byte, err error) {
+	raw, err := backend.Read(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	id, _, err := PrepareMonitorPush(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	if local, err = NormalizeMonitor(raw, settings); err != nil {
+		return nil, nil, err
+	}
+	if remoteID == 0 {
+		remoteID = id
+	}
+	if remote, err = FetchMonitorJSON(ctx, client, settings, remoteID); err != nil {
+		return nil, nil, err
+	}
+	return local, remote, nil
+}
+
+// DiffMonitor compares the monitor in the file at path with the remote
+// monitor with the same id, as CompareMonitor does, and returns the unified
+// diff from the remote one to the file, or "" if they match.
+func DiffMonitor(ctx context.Context, client resource.HTTPClient, settings *config.Settings, backend storage.Backend, path string) (string, error) {
+	local, remote, err := CompareMonitor(ctx, client, settings, backend, path, 0)
+	if err != nil {
+		return "", err
+	}
+	return diff.JSON("remote "+path, path, remote, local, ReadOnlyFields...)
+}
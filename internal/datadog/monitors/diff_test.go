@@ -0,0 +1,72 @@
+package monitors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+func TestDiffMonitor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/monitor/42":
+			w.Write([]byte(`{"id":42,"name":"CPU high","query":"q","overall_state":"Alert","modified":"2024-03-01","matching_downtimes":[{"id":9}],"tags":["team:web","env:prod"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 4096, MonitorsStripFields: []string{"overall_state"}}
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// Stripped, runtime and read-only fields, and the tag order, are ignored
+	path := write("42.json", `{"id":42,"name":"CPU high","query":"q","modified":"2024-01-01","tags":["env:prod","team:web"]}`)
+	got, err := DiffMonitor(context.Background(), newTestClient(), settings, storage.FileBackend{}, path)
+	if err != nil || got != "" {
+		t.Errorf("DiffMonitor() = %q, %v, want in sync", got, err)
+	}
+
+	write("42.json", `{"id":42,"name":"CPU high","query":"q2","tags":["env:prod","team:web"]}`)
+	got, err = DiffMonitor(context.Background(), newTestClient(), settings, storage.FileBackend{}, path)
+	if err != nil {
+		t.Fatalf("DiffMonitor() error = %v", err)
+	}
+	if !strings.Contains(got, "-  \"query\": \"q\",\n+  \"query\": \"q2\",\n") {
+		t.Errorf("DiffMonitor() =\n%s\nwant the query change", got)
+	}
+
+	missing := write("7.json", `{"id":7,"name":"Gone"}`)
+	if _, err := DiffMonitor(context.Background(), newTestClient(), settings, storage.FileBackend{}, missing); !errors.Is(err, resource.ErrNotFound) {
+		t.Errorf("DiffMonitor() of a monitor missing remotely: error = %v, want ErrNotFound", err)
+	}
+
+	// --against compares a clone with the original
+	clone := write("43.json", `{"id":43,"name":"CPU high","query":"q2","tags":["env:prod","team:web"]}`)
+	local, remote, err := (Kind{}).Compare(context.Background(), newTestClient(), settings, storage.FileBackend{}, clone, "42")
+	if err != nil {
+		t.Fatalf("Compare(against 42) error = %v", err)
+	}
+	if !strings.Contains(string(local), `"id":43`) || !strings.Contains(string(remote), `"id": 42`) {
+		t.Errorf("Compare(against 42) = %s, %s, want the clone and monitor 42", local, remote)
+	}
+	if _, _, err := (Kind{}).Compare(context.Background(), newTestClient(), settings, storage.FileBackend{}, clone, "abc"); err == nil {
+		t.Error("Compare(against abc) expected an error")
+	}
+}
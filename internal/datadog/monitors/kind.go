@@ -0,0 +1,158 @@
+package monitors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/templating"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	resource.Register(Kind{})
+}
+
+// Kind registers monitors with the generic resource commands.
+type Kind struct{}
+
+func (Kind) Name() string            { return resource.KindMonitor }
+func (Kind) Plural() string          { return "monitors" }
+func (Kind) IDKind() resource.IDKind { return resource.IDNumeric }
+
+func (Kind) PathTemplate(settings *config.Settings) string {
+	return settings.MonitorsPathTemplate
+}
+
+func (Kind) Builtins() map[string]string {
+	return templating.BuildMonitorBuiltins()
+}
+
+// AddFlags adds --priority, --with-dependencies, --query-scope and
+// --any-reference.
+func (Kind) AddFlags(flags *pflag.FlagSet) {
+	flags.Int("priority", 0, "Filter by monitor priority (integer)")
+	flags.Bool("with-dependencies", false, "Also select the monitors referenced by selected composite monitors, recursively")
+	flags.String("query-scope", "", "Filter by tags the monitor query is scoped to or grouped by (comma-separated, e.g. service:web)")
+	flags.String("any-reference", "", "Filter by tags the monitor has or its query is scoped to (comma-separated)")
+}
+
+// AddDownloadFlags adds --include-runtime, --with-group-states and
+// --with-restriction-policy.
+func (Kind) AddDownloadFlags(flags *pflag.FlagSet) {
+	flags.Bool("include-runtime", false, "Keep runtime fields such as matching_downtimes (default: MONITORS_INCLUDE_RUNTIME)")
+	flags.String("with-group-states", "", "Store the monitor's group states: all, alert or warn (default: MONITORS_GROUP_STATES)")
+	flags.Bool("with-restriction-policy", false, "Also write each monitor's restriction policy next to it, as <name>.policy.json (default: MONITORS_WITH_RESTRICTION_POLICY)")
+}
+
+func (Kind) ApplyDownloadFlags(settings *config.Settings, flags *pflag.FlagSet) error {
+	if flags.Changed("include-runtime") {
+		include, err := flags.GetBool("include-runtime")
+		if err != nil {
+			return err
+		}
+		settings.MonitorsIncludeRuntime = include
+	}
+	if flags.Changed("with-group-states") {
+		states, err := flags.GetString("with-group-states")
+		if err != nil {
+			return err
+		}
+		if err := config.ValidateGroupStates(states); err != nil {
+			return fmt.Errorf("--with-group-states: %w", err)
+		}
+		settings.MonitorsGroupStates = states
+	}
+	if flags.Changed("with-restriction-policy") {
+		with, err := flags.GetBool("with-restriction-policy")
+		if err != nil {
+			return err
+		}
+		settings.MonitorsWithRestrictionPolicy = with
+	}
+	return nil
+}
+
+func (Kind) Targets(ctx context.Context, client resource.HTTPClient, settings *config.Settings, opts resource.BaseDownloadOptions, flags *pflag.FlagSet) (<-chan resource.TargetResult[string], error) {
+	priority, err := flags.GetInt("priority")
+	if err != nil {
+		return nil, err
+	}
+	withDependencies, err := flags.GetBool("with-dependencies")
+	if err != nil {
+		return nil, err
+	}
+	queryScope, err := flags.GetString("query-scope")
+	if err != nil {
+		return nil, err
+	}
+	anyReference, err := flags.GetString("any-reference")
+	if err != nil {
+		return nil, err
+	}
+	targets, err := GenerateMonitorTargets(ctx, client, settings, DownloadOptions{
+		BaseDownloadOptions: opts,
+		Priority:            priority,
+		WithDependencies:    withDependencies,
+		QueryScope:          queryScope,
+		AnyReference:        anyReference,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resource.StringTargets(ctx, targets, strconv.Itoa), nil
+}
+
+func (Kind) ComputePath(settings *config.Settings, raw []byte) (string, []string, error) {
+	var meta MonitorMeta
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return "", nil, fmt.Errorf("failed to decode monitor: %w", err)
+	}
+	if meta.ID == 0 {
+		return "", nil, fmt.Errorf("monitor missing valid 'id' field")
+	}
+	path, err := computeMonitorPath(settings, meta, "")
+	if err != nil {
+		return "", nil, err
+	}
+	missing := templating.MissingTags(settings.MonitorsPathTemplate, templating.BuildMonitorBuiltins(), extractTags(meta))
+	return path, missing, nil
+}
+
+func (Kind) Fetch(ctx context.Context, client resource.HTTPClient, settings *config.Settings, id string) ([]byte, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid monitor ID: %s", id)
+	}
+	return FetchMonitorJSON(ctx, client, settings, n)
+}
+
+func (Kind) Download(ctx context.Context, client resource.HTTPClient, settings *config.Settings, target resource.Target[string], outputPath string) (string, error) {
+	id, err := strconv.Atoi(target.ID)
+	if err != nil {
+		return "", fmt.Errorf("invalid monitor ID: %s", target.ID)
+	}
+	return DownloadMonitorWithOptions(ctx, client, settings, MonitorTarget{ID: id, Path: target.Path, Data: target.Data}, outputPath)
+}
+
+func (Kind) Push(ctx context.Context, client resource.WriteClient, settings *config.Settings, target resource.Target[string], flags resource.PushFlags) (resource.PushResult, error) {
+	return PushMonitor(ctx, client, settings, target, flags)
+}
+
+func (Kind) Compare(ctx context.Context, client resource.HTTPClient, settings *config.Settings, backend storage.Backend, path, against string) ([]byte, []byte, error) {
+	remoteID := 0
+	if against != "" {
+		n, err := strconv.Atoi(against)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid monitor ID: %s", against)
+		}
+		remoteID = n
+	}
+	return CompareMonitor(ctx, client, settings, backend, path, remoteID)
+}
+
+func (Kind) IgnoredFields() []string { return ReadOnlyFields }
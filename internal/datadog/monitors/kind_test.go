@@ -0,0 +1,97 @@
+package monitors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/spf13/pflag"
+)
+
+func TestKind(t *testing.T) {
+	k, ok := resource.LookupKind("monitors")
+	if !ok {
+		t.Fatal("monitors kind not registered")
+	}
+	if k.Name() != "monitor" || k.IDKind() != resource.IDNumeric {
+		t.Errorf("Name() = %q, IDKind() = %v", k.Name(), k.IDKind())
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/monitor":
+			fmt.Fprint(w, `[{"id":42,"name":"CPU high","priority":2},{"id":43,"name":"Disk","priority":1}]`)
+		case "/api/v1/monitor/42":
+			fmt.Fprint(w, `{"id":42,"name":"CPU high","priority":2}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                 server.URL,
+		MonitorsPathTemplate: filepath.Join(dir, "{id}.json"),
+		HTTPMaxBodySize:      1024,
+		MonitorsPageSize:     100,
+	}
+
+	flags := pflag.NewFlagSet("monitor", pflag.ContinueOnError)
+	k.AddFlags(flags)
+	if err := flags.Parse([]string{"--priority=2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	targets, err := k.Targets(context.Background(), newTestClient(), settings, resource.BaseDownloadOptions{IDs: "42,43"}, flags)
+	if err != nil {
+		t.Fatalf("Targets() error = %v", err)
+	}
+	var got []resource.Target[string]
+	for result := range targets {
+		if result.Err != nil {
+			t.Fatalf("Targets() result error = %v", result.Err)
+		}
+		got = append(got, result.Target)
+	}
+	if len(got) != 1 || got[0].ID != "42" {
+		t.Fatalf("Targets() = %+v, want only monitor 42 (priority 2)", got)
+	}
+
+	path, err := k.Download(context.Background(), newTestClient(), settings, got[0], "")
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("monitor file not written: %v", err)
+	}
+
+	if _, err := k.Fetch(context.Background(), newTestClient(), settings, "abc"); err == nil {
+		t.Error("Fetch() expected error for a non-numeric ID")
+	}
+}
+
+func TestKind_ApplyDownloadFlags(t *testing.T) {
+	k := Kind{}
+	flags := pflag.NewFlagSet("monitor download", pflag.ContinueOnError)
+	k.AddDownloadFlags(flags)
+
+	// Unset flags leave the configured value alone
+	settings := &config.Settings{MonitorsIncludeRuntime: true}
+	if err := k.ApplyDownloadFlags(settings, flags); err != nil || !settings.MonitorsIncludeRuntime {
+		t.Errorf("ApplyDownloadFlags() = %v, MonitorsIncludeRuntime = %v, want unchanged", err, settings.MonitorsIncludeRuntime)
+	}
+
+	if err := flags.Parse([]string{"--include-runtime"}); err != nil {
+		t.Fatal(err)
+	}
+	settings = &config.Settings{}
+	if err := k.ApplyDownloadFlags(settings, flags); err != nil || !settings.MonitorsIncludeRuntime {
+		t.Errorf("ApplyDownloadFlags() = %v, MonitorsIncludeRuntime = %v, want true", err, settings.MonitorsIncludeRuntime)
+	}
+}
@@ -0,0 +1,126 @@
+package monitors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+// ReadOnlyFields are monitor fields the API sets itself and rejects in
+// updates. They are stripped from local monitors before they are sent.
+var ReadOnlyFields = []string{
+	"id",
+	"created",
+	"creator",
+	"deleted",
+	"matching_downtimes",
+	"modified",
+	"multi",
+	"overall_state",
+	"overall_state_modified",
+	"state",
+}
+
+// PushMonitor replaces the monitor with the ID in the file at target.Path.
+// A monitor that doesn't exist remotely is created, and the file's id
+// changed to the new monitor's. The file's modified time detects monitors
+// edited remotely since they were downloaded.
+func PushMonitor(ctx context.Context, client resource.WriteClient, settings *config.Settings, target resource.Target[string], flags resource.PushFlags) (resource.PushResult, error) {
+	backend, err := storage.NewBackend(settings)
+	if err != nil {
+		return resource.PushResult{}, err
+	}
+	raw, err := backend.Read(target.Path)
+	if err != nil {
+		return resource.PushResult{}, err
+	}
+	id, body, err := PrepareMonitorPush(raw)
+	if err != nil {
+		return resource.PushResult{}, err
+	}
+	result, err := resource.PushResource(ctx, client, settings, resource.PushRequest{
+		Path:           target.Path,
+		Backend:        backend,
+		Endpoint:       fmt.Sprintf("%s/api/v1/monitor/%d", settings.APIBaseURL(), id),
+		CreateEndpoint: settings.APIBaseURL() + "/api/v1/monitor",
+		Body:           body,
+		Ignore:         ReadOnlyFields,
+		URL:            fmt.Sprintf("%s/monitors/%d", settings.AppBaseURL(), id),
+		ModifiedField:  "modified",
+		Modified:       resource.StringField(raw, "modified"),
+		Normalize: func(raw json.RawMessage) (json.RawMessage, error) {
+			return NormalizeMonitor(raw, settings)
+		},
+	}, flags)
+	if err != nil || result.NewID == nil {
+		return result, err
+	}
+	result.URL = settings.AppBaseURL() + "/monitors/" + resource.FormatRawID(result.NewID)
+	return result, nil
+}
+
+// ValidateID checks a monitor id decoded from a local file, as
+// "dd-tf validate" does: it must be a positive integer. Values that aren't
+// numbers are left to the schema.
+func ValidateID(id any) error {
+	n, ok := id.(float64)
+	if ok && (n <= 0 || n != float64(int64(n))) {
+		return fmt.Errorf("monitor ID must be a positive integer, got %v", id)
+	}
+	return nil
+}
+
+// PrepareMonitorPush checks that a local monitor is valid JSON with a
+// numeric id, and returns the id and the monitor without ReadOnlyFields,
+// keeping the order of the other fields.
+func PrepareMonitorPush(raw []byte) (int, []byte, error) {
+	var meta struct {
+		ID json.Number `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return 0, nil, fmt.Errorf("failed to decode monitor: %w", err)
+	}
+	id, err := strconv.Atoi(meta.ID.String())
+	if err != nil || id <= 0 {
+		return 0, nil, fmt.Errorf("monitor missing valid 'id' field")
+	}
+	body, err := resource.StripFields(raw, ReadOnlyFields...)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to strip read-only fields: %w", err)
+	}
+	return id, body, nil
+}
+
+// TerraformMonitor returns a local monitor as the datadog_monitor_json
+// Terraform resource takes it: normalized as downloads are, without
+// ReadOnlyFields, which the provider rejects, and formatted as resource files
+// are.
+func TerraformMonitor(raw []byte, settings *config.Settings) ([]byte, error) {
+	normalized, err := NormalizeMonitor(raw, settings)
+	if err != nil {
+		return nil, err
+	}
+	body, err := resource.StripFields(normalized, ReadOnlyFields...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to strip read-only fields: %w", err)
+	}
+	return storage.FormatJSON(body)
+}
+
+// ValidateMonitor checks a local monitor with the API's validation endpoint,
+// without creating or changing anything. The monitor is sent as push would
+// send it. A monitor the API rejects returns a *resource.APIError, whose
+// Messages say why.
+func ValidateMonitor(ctx context.Context, client resource.WriteClient, settings *config.Settings, raw []byte) error {
+	_, body, err := PrepareMonitorPush(raw)
+	if err != nil {
+		return err
+	}
+	_, err = resource.PostToAPI(ctx, client, settings.APIBaseURL()+"/api/v1/monitor/validate", body, settings)
+	return err
+}
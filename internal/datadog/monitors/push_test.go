@@ -0,0 +1,507 @@
+package monitors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/AD7six/dd-tf/internal/storage/storagetest"
+)
+
+// downloadedMonitor is a monitor as the API returns it, runtime fields
+// included.
+const downloadedMonitor = `{
+  "id": 42,
+  "name": "CPU high on {{host.name}}",
+  "type": "metric alert",
+  "query": "avg(last_5m):avg:system.cpu.user{env:prod} by {host} > 90",
+  "message": "@slack-ops",
+  "tags": ["team:ops"],
+  "options": {"thresholds": {"critical": 90}, "notify_no_data": false},
+  "priority": 2,
+  "restricted_roles": null,
+  "created": "2024-01-02T03:04:05.000000+00:00",
+  "creator": {"email": "jane@example.com", "handle": "jane@example.com", "id": 1, "name": "Jane"},
+  "deleted": null,
+  "matching_downtimes": [],
+  "modified": "2024-02-03T04:05:06.000000+00:00",
+  "multi": true,
+  "overall_state": "OK",
+  "overall_state_modified": "2024-02-03T04:05:06+00:00",
+  "org_id": 1234
+}`
+
+func TestPrepareMonitorPush_RoundTrip(t *testing.T) {
+	id, body, err := PrepareMonitorPush([]byte(downloadedMonitor))
+	if err != nil {
+		t.Fatalf("PrepareMonitorPush() error = %v", err)
+	}
+	if id != 42 {
+		t.Errorf("PrepareMonitorPush() id = %d, want 42", id)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("body is not valid JSON: %v", err)
+	}
+	for _, field := range ReadOnlyFields {
+		if _, ok := got[field]; ok {
+			t.Errorf("body keeps read-only field %q", field)
+		}
+	}
+	for _, field := range []string{"name", "type", "query", "message", "tags", "options", "priority", "restricted_roles"} {
+		if _, ok := got[field]; !ok {
+			t.Errorf("body lost field %q", field)
+		}
+	}
+
+	// Pushing what was stripped changes nothing more
+	_, again, err := PrepareMonitorPush([]byte(`{"id":42,` + string(body[1:])))
+	if err != nil {
+		t.Fatalf("PrepareMonitorPush() error = %v", err)
+	}
+	if string(again) != string(body) {
+		t.Errorf("PrepareMonitorPush() is not stable:\n%s\n%s", body, again)
+	}
+}
+
+func TestTerraformMonitor(t *testing.T) {
+	settings := &config.Settings{MonitorsStripFields: []string{"org_id"}}
+	got, err := TerraformMonitor([]byte(downloadedMonitor), settings)
+	if err != nil {
+		t.Fatalf("TerraformMonitor() error = %v", err)
+	}
+	for _, field := range append([]string{"org_id"}, ReadOnlyFields...) {
+		if strings.Contains(string(got), `"`+field+`"`) {
+			t.Errorf("TerraformMonitor() kept %q:\n%s", field, got)
+		}
+	}
+	if !strings.HasPrefix(string(got), "{\n  \"name\": ") || !strings.HasSuffix(string(got), "}\n") {
+		t.Errorf("TerraformMonitor() isn't formatted as resource files:\n%s", got)
+	}
+	again, err := TerraformMonitor(got, settings)
+	if err != nil || string(again) != string(got) {
+		t.Errorf("TerraformMonitor() of a clean monitor changed it:\n%s", again)
+	}
+}
+
+func TestPrepareMonitorPush_Invalid(t *testing.T) {
+	for _, raw := range []string{`{"id":`, `{"name":"x"}`, `{"id":"abc"}`, `{"id":0}`} {
+		if _, _, err := PrepareMonitorPush([]byte(raw)); err == nil {
+			t.Errorf("PrepareMonitorPush(%s) should fail", raw)
+		}
+	}
+}
+
+func TestValidateID(t *testing.T) {
+	for _, id := range []any{float64(1), float64(123456), "abc"} {
+		if err := ValidateID(id); err != nil {
+			t.Errorf("ValidateID(%v) error = %v", id, err)
+		}
+	}
+	for _, id := range []any{float64(0), float64(-4), 1.5} {
+		if err := ValidateID(id); err == nil {
+			t.Errorf("ValidateID(%v) expected an error", id)
+		}
+	}
+}
+
+func TestPushMonitor(t *testing.T) {
+	var put map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/api/v1/monitor/42" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &put); err != nil {
+			t.Errorf("PUT body is not valid JSON: %v", err)
+		}
+		w.Write([]byte(`{"id":42}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "42.json")
+	os.WriteFile(path, []byte(downloadedMonitor), 0o644)
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 1024}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+
+	result, err := PushMonitor(context.Background(), client, settings, resource.Target[string]{ID: "42", Path: path}, resource.PushFlags{Force: true, Overwrite: true})
+	if err != nil {
+		t.Fatalf("PushMonitor() error = %v", err)
+	}
+	if url := result.URL; url != server.URL+"/monitors/42" {
+		t.Errorf("PushMonitor() = %q", url)
+	}
+	if put["name"] != "CPU high on {{host.name}}" || put["overall_state"] != nil {
+		t.Errorf("PUT body = %v", put)
+	}
+}
+
+func TestPushMonitor_CreatesMissing(t *testing.T) {
+	var posted map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/monitor/42":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":["Monitor not found"]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/monitor":
+			body, _ := io.ReadAll(r.Body)
+			json.Unmarshal(body, &posted)
+			w.Write([]byte(`{"id":987,"name":"CPU high on {{host.name}}"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "42.json")
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 1024}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+	target := resource.Target[string]{ID: "42", Path: path}
+
+	os.WriteFile(path, []byte(downloadedMonitor), 0o644)
+	if _, err := PushMonitor(context.Background(), client, settings, target, resource.PushFlags{NoCreate: true}); !errors.Is(err, resource.ErrNotFound) {
+		t.Fatalf("PushMonitor() with NoCreate error = %v, want not found", err)
+	}
+	if posted != nil {
+		t.Fatal("PushMonitor() with NoCreate created the monitor")
+	}
+
+	result, err := PushMonitor(context.Background(), client, settings, target, resource.PushFlags{})
+	if err != nil {
+		t.Fatalf("PushMonitor() error = %v", err)
+	}
+	if result.Action != resource.PushCreated || result.URL != server.URL+"/monitors/987" {
+		t.Errorf("PushMonitor() = %+v, want monitor 987 created", result)
+	}
+	if _, ok := posted["id"]; ok || posted["name"] != "CPU high on {{host.name}}" {
+		t.Errorf("POST body = %v", posted)
+	}
+
+	written, _ := os.ReadFile(path)
+	want := strings.Replace(downloadedMonitor, `"id": 42,`, `"id": 987,`, 1)
+	var got, wantDoc any
+	json.Unmarshal(written, &got)
+	json.Unmarshal([]byte(want), &wantDoc)
+	if !reflect.DeepEqual(got, wantDoc) {
+		t.Errorf("local file = %s, want only the id changed", written)
+	}
+	if !strings.Contains(string(written), "by {host} > 90") || !strings.HasPrefix(string(written), "{\n  \"id\": 987,\n  \"name\"") {
+		t.Errorf("local file = %s, want the query and key order kept", written)
+	}
+}
+
+func TestPushMonitor_CreatesOnBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/monitor/42":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/monitor":
+			w.Write([]byte(`{"id":987,"modified":"2024-03-02T00:00:00.000000+00:00"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	// Not on the file system, as with STORAGE_BACKEND=s3
+	backend := storagetest.NewMemory(map[string]string{"data/monitors/42.json": downloadedMonitor})
+	storage.Override(backend)
+	defer storage.Override(nil)
+
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 1024}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+	target := resource.Target[string]{ID: "42", Path: "data/monitors/42.json"}
+	result, err := PushMonitor(context.Background(), client, settings, target, resource.PushFlags{})
+	if err != nil {
+		t.Fatalf("PushMonitor() error = %v", err)
+	}
+	if result.Action != resource.PushCreated {
+		t.Errorf("PushMonitor() = %s, want created", result.Action)
+	}
+
+	written, _ := backend.Read(target.Path)
+	want := strings.Replace(downloadedMonitor, `"id": 42,`, `"id": 987,`, 1)
+	want = strings.Replace(want, "2024-02-03T04:05:06.000000+00:00", "2024-03-02T00:00:00.000000+00:00", 1)
+	var got, wantDoc any
+	json.Unmarshal(written, &got)
+	json.Unmarshal([]byte(want), &wantDoc)
+	if !reflect.DeepEqual(got, wantDoc) {
+		t.Errorf("stored monitor = %s, want the new id and modified time", written)
+	}
+}
+
+func TestPushMonitor_RefreshesModifiedOnBackend(t *testing.T) {
+	remote := downloadedMonitor
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(remote))
+		case http.MethodPut:
+			remote = strings.Replace(downloadedMonitor, "> 90", "> 95", 1)
+			remote = strings.Replace(remote, "2024-02-03T04:05:06.000000+00:00", "2024-03-02T00:00:00.000000+00:00", 1)
+			w.Write([]byte(remote))
+		}
+	}))
+	defer server.Close()
+
+	path := "data/monitors/42.json"
+	backend := storagetest.NewMemory(map[string]string{path: strings.Replace(downloadedMonitor, "> 90", "> 95", 1)})
+	storage.Override(backend)
+	defer storage.Override(nil)
+
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 4096}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+	target := resource.Target[string]{ID: "42", Path: path}
+	if result, err := PushMonitor(context.Background(), client, settings, target, resource.PushFlags{}); err != nil || result.Action != resource.PushUpdated {
+		t.Fatalf("PushMonitor() = %v, %v, want updated", result.Action, err)
+	}
+	written, _ := backend.Read(path)
+	if resource.StringField(written, "modified") != "2024-03-02T00:00:00.000000+00:00" {
+		t.Errorf("stored monitor = %s, want modified updated to the pushed monitor's", written)
+	}
+
+	// The refreshed modified time matches the remote one: no conflict
+	if result, err := PushMonitor(context.Background(), client, settings, target, resource.PushFlags{}); err != nil || result.Action != resource.PushUnchanged {
+		t.Errorf("second PushMonitor() = %v, %v, want unchanged", result.Action, err)
+	}
+}
+
+func TestPushMonitor_SkipsUnchanged(t *testing.T) {
+	remote := strings.Replace(downloadedMonitor, `"overall_state": "OK"`, `"overall_state": "Alert"`, 1)
+	remote = strings.Replace(remote, `"tags": ["team:ops"]`, `"tags": ["env:prod", "team:ops"]`, 1)
+	var gets, puts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			gets++
+			w.Write([]byte(remote))
+		case http.MethodPut:
+			puts++
+			w.Write([]byte(`{"id":42}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "42.json")
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 4096}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+	target := resource.Target[string]{ID: "42", Path: path}
+
+	// Tags in another order are the same monitor
+	local := strings.Replace(downloadedMonitor, `"tags": ["team:ops"]`, `"tags": ["team:ops", "env:prod"]`, 1)
+	os.WriteFile(path, []byte(local), 0o644)
+	result, err := PushMonitor(context.Background(), client, settings, target, resource.PushFlags{})
+	if err != nil {
+		t.Fatalf("PushMonitor() error = %v", err)
+	}
+	if result.Action != resource.PushUnchanged || gets != 1 || puts != 0 {
+		t.Errorf("PushMonitor() = %s with %d GET and %d PUT, want unchanged with no PUT", result.Action, gets, puts)
+	}
+
+	result, err = PushMonitor(context.Background(), client, settings, target, resource.PushFlags{Force: true, Overwrite: true})
+	if err != nil {
+		t.Fatalf("PushMonitor() error = %v", err)
+	}
+	if result.Action != resource.PushUpdated || gets != 1 || puts != 1 {
+		t.Errorf("PushMonitor() with Force and Overwrite = %s with %d GET and %d PUT, want updated without a GET", result.Action, gets, puts)
+	}
+
+	os.WriteFile(path, []byte(strings.Replace(local, "> 90", "> 95", 1)), 0o644)
+	result, err = PushMonitor(context.Background(), client, settings, target, resource.PushFlags{})
+	if err != nil {
+		t.Fatalf("PushMonitor() error = %v", err)
+	}
+	if result.Action != resource.PushUpdated || gets != 2 || puts != 2 {
+		t.Errorf("PushMonitor() = %s with %d GET and %d PUT, want an update", result.Action, gets, puts)
+	}
+}
+
+func TestPushMonitor_ManagedTag(t *testing.T) {
+	remote := downloadedMonitor
+	var sent []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(remote))
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			var monitor struct {
+				Tags []string `json:"tags"`
+			}
+			json.Unmarshal(body, &monitor)
+			sent = monitor.Tags
+			remote = strings.Replace(downloadedMonitor, `"tags": ["team:ops"]`, `"tags": ["team:ops", "managed-by:dd-tf"]`, 1)
+			w.Write([]byte(remote))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "42.json")
+	os.WriteFile(path, []byte(downloadedMonitor), 0o644)
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 4096}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+	target := resource.Target[string]{ID: "42", Path: path}
+	flags := resource.PushFlags{ManagedTag: "managed-by:dd-tf"}
+
+	result, err := PushMonitor(context.Background(), client, settings, target, flags)
+	if err != nil {
+		t.Fatalf("PushMonitor() error = %v", err)
+	}
+	if result.Action != resource.PushUpdated || !reflect.DeepEqual(sent, []string{"team:ops", "managed-by:dd-tf"}) {
+		t.Errorf("PushMonitor() = %s sending tags %v, want the managed tag added", result.Action, sent)
+	}
+
+	// The remote monitor now has the tag, whether or not the local file does
+	sent = nil
+	result, err = PushMonitor(context.Background(), client, settings, target, flags)
+	if err != nil {
+		t.Fatalf("PushMonitor() error = %v", err)
+	}
+	if result.Action != resource.PushUnchanged || sent != nil {
+		t.Errorf("second PushMonitor() = %s, want unchanged", result.Action)
+	}
+	os.WriteFile(path, []byte(remote), 0o644)
+	result, err = PushMonitor(context.Background(), client, settings, target, flags)
+	if err != nil {
+		t.Fatalf("PushMonitor() error = %v", err)
+	}
+	if result.Action != resource.PushUnchanged || sent != nil {
+		t.Errorf("PushMonitor() of a tagged file = %s, want unchanged", result.Action)
+	}
+}
+
+func TestPushMonitor_Conflict(t *testing.T) {
+	remote := strings.Replace(downloadedMonitor, `"modified": "2024-02-03T04:05:06.000000+00:00"`, `"modified": "2024-03-01T00:00:00.000000+00:00"`, 1)
+	remote = strings.Replace(remote, `"message": "@slack-ops"`, `"message": "@slack-ops @pagerduty"`, 1)
+	var puts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(remote))
+		case http.MethodPut:
+			puts++
+			w.Write([]byte(`{"id":42,"modified":"2024-03-02T00:00:00.000000+00:00"}`))
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "42.json")
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 4096}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+	target := resource.Target[string]{ID: "42", Path: path}
+	local := strings.Replace(downloadedMonitor, "> 90", "> 95", 1)
+	os.WriteFile(path, []byte(local), 0o644)
+
+	for _, flags := range []resource.PushFlags{{}, {Force: true}, {DryRun: true}} {
+		_, err := PushMonitor(context.Background(), client, settings, target, flags)
+		var conflict *resource.ConflictError
+		if !errors.As(err, &conflict) || conflict.Remote != "2024-03-01T00:00:00.000000+00:00" {
+			t.Errorf("PushMonitor(%+v) error = %v, want a conflict", flags, err)
+		}
+	}
+	if puts != 0 {
+		t.Fatalf("a conflicting monitor was pushed %d time(s)", puts)
+	}
+
+	if _, err := PushMonitor(context.Background(), client, settings, target, resource.PushFlags{Overwrite: true}); err != nil {
+		t.Fatalf("PushMonitor() with Overwrite error = %v", err)
+	}
+	if puts != 1 {
+		t.Errorf("PushMonitor() with Overwrite sent %d PUT, want 1", puts)
+	}
+	written, _ := os.ReadFile(path)
+	want := strings.Replace(local, "2024-02-03T04:05:06.000000+00:00", "2024-03-02T00:00:00.000000+00:00", 1)
+	var got, wantDoc any
+	json.Unmarshal(written, &got)
+	json.Unmarshal([]byte(want), &wantDoc)
+	if !reflect.DeepEqual(got, wantDoc) {
+		t.Errorf("local file = %s, want only modified updated to the pushed monitor's", written)
+	}
+}
+
+func TestPushMonitor_DryRun(t *testing.T) {
+	remote := strings.Replace(downloadedMonitor, `"overall_state": "OK"`, `"overall_state": "Alert"`, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/v1/monitor/42" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(remote))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "42.json")
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 4096}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+	target := resource.Target[string]{ID: "42", Path: path}
+
+	os.WriteFile(path, []byte(downloadedMonitor), 0o644)
+	result, err := PushMonitor(context.Background(), client, settings, target, resource.PushFlags{DryRun: true})
+	if err != nil {
+		t.Fatalf("PushMonitor() error = %v", err)
+	}
+	if result.Action != resource.PushUnchanged {
+		t.Errorf("PushMonitor() = %+v, want unchanged when only runtime fields differ", result)
+	}
+
+	os.WriteFile(path, []byte(strings.Replace(downloadedMonitor, "> 90", "> 95", 1)), 0o644)
+	result, err = PushMonitor(context.Background(), client, settings, target, resource.PushFlags{DryRun: true})
+	if err != nil {
+		t.Fatalf("PushMonitor() error = %v", err)
+	}
+	if result.Action != resource.PushUpdated || !strings.Contains(result.Diff, "+  \"query\": \"avg(last_5m):avg:system.cpu.user{env:prod} by {host} > 95\"") {
+		t.Errorf("PushMonitor() = %+v, want a diff of the query", result)
+	}
+}
+
+func TestValidateMonitor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/monitor/validate" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var monitor map[string]any
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &monitor)
+		if _, ok := monitor["id"]; ok {
+			t.Errorf("validated monitor keeps its id")
+		}
+		if monitor["query"] == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["The value provided for parameter 'query' is invalid"]}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 1024}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+
+	if err := ValidateMonitor(context.Background(), client, settings, []byte(downloadedMonitor)); err != nil {
+		t.Errorf("ValidateMonitor() error = %v", err)
+	}
+
+	err := ValidateMonitor(context.Background(), client, settings, []byte(`{"id":42,"type":"metric alert","query":"bad"}`))
+	var apiErr *resource.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("ValidateMonitor() error = %v, want an APIError", err)
+	}
+	if msgs := apiErr.Messages(); len(msgs) != 1 || msgs[0] != "The value provided for parameter 'query' is invalid" {
+		t.Errorf("Messages() = %v", msgs)
+	}
+}
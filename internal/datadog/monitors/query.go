@@ -0,0 +1,336 @@
+package monitors
+
+import (
+	"strings"
+)
+
+// QueryReferences are the tags a monitor query refers to, as opposed to the
+// monitor's own tags.
+type QueryReferences struct {
+	Scope   []string // tags the query is scoped to, e.g. "service:web"; negated terms are left out
+	GroupBy []string // tag keys the query groups by, e.g. "host"
+}
+
+// ParseQuery extracts the tag references from a monitor query. It
+// understands metric scopes ({service:web AND env:prod}, several per query,
+// with AND/OR/NOT, !/- negation and IN lists), "by {...}" clauses, service
+// check .over()/.by()/.exclude() calls and the quoted search queries of log,
+// event and trace monitors (logs("service:web status:error")). Template
+// variables ({{var}}, $var) and attributes (@http.status_code) aren't tags
+// and are skipped. Anything unrecognised is ignored rather than guessed at.
+func ParseQuery(query string) QueryReferences {
+	var refs QueryReferences
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; c {
+		case '"', '\'':
+			i = closingQuote(query, i)
+		case '{':
+			if strings.HasPrefix(query[i:], "{{") {
+				end := strings.Index(query[i:], "}}")
+				if end < 0 {
+					return refs.dedupe()
+				}
+				i += end + 1
+				continue
+			}
+			end := closingBrace(query[i:])
+			if end < 0 {
+				return refs.dedupe()
+			}
+			block := query[i+1 : i+end]
+			if lastWord(query[:i]) == "by" {
+				refs.GroupBy = append(refs.GroupBy, groupKeys(block)...)
+			} else {
+				refs.Scope = append(refs.Scope, parseScope(block, false)...)
+			}
+			i += end
+		case '(':
+			name, dotted := callName(query[:i])
+			args, end := quotedArgs(query, i)
+			if name == "" || end < 0 {
+				// Not a call with string arguments, e.g. avg(last_5m) or
+				// anomalies(avg:x{...}, 'basic', 2): keep scanning inside
+				continue
+			}
+			switch {
+			case dotted && name == "over":
+				for _, arg := range args {
+					refs.Scope = append(refs.Scope, parseScope(arg, false)...)
+				}
+			case dotted && name == "by":
+				for _, arg := range args {
+					refs.GroupBy = append(refs.GroupBy, groupKeys(arg)...)
+				}
+			case dotted:
+				// .exclude(), .last(), .rollup(), .index(): no scope
+			case len(args) > 0:
+				// A search source: logs(), events(), spans(), rum(), …
+				refs.Scope = append(refs.Scope, parseScope(args[0], true)...)
+			}
+			i = end
+		}
+	}
+	return refs.dedupe()
+}
+
+// Matches reports whether the references include every filter, compared
+// case-insensitively. A "key:value" filter matches a scope tag; a bare "key"
+// also matches any scope tag with that key or a group-by key.
+func (r QueryReferences) Matches(filters []string) bool {
+	for _, filter := range filters {
+		if !r.matches(strings.ToLower(filter)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r QueryReferences) matches(filter string) bool {
+	for _, tag := range r.Scope {
+		tag = strings.ToLower(tag)
+		if tag == filter || (!strings.Contains(filter, ":") && strings.HasPrefix(tag, filter+":")) {
+			return true
+		}
+	}
+	if strings.Contains(filter, ":") {
+		return false
+	}
+	for _, key := range r.GroupBy {
+		if strings.ToLower(key) == filter {
+			return true
+		}
+	}
+	return false
+}
+
+func (r QueryReferences) dedupe() QueryReferences {
+	return QueryReferences{Scope: unique(r.Scope), GroupBy: unique(r.GroupBy)}
+}
+
+func unique(values []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// parseScope returns the tags a scope or search expression requires. In
+// search syntax, words without a key are free text rather than tags.
+func parseScope(expr string, search bool) []string {
+	tokens := scopeTokens(expr)
+	var tags []string
+	negations := []bool{false} // whether each open group is negated
+	negateNext := false
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		negated := negations[len(negations)-1]
+		switch strings.ToUpper(token) {
+		case "AND", "OR":
+			continue
+		case "NOT":
+			negateNext = !negateNext
+			continue
+		case "(":
+			negations = append(negations, negated != negateNext)
+			negateNext = false
+			continue
+		case ")":
+			if len(negations) > 1 {
+				negations = negations[:len(negations)-1]
+			}
+			continue
+		}
+		negated = negated != negateNext
+		negateNext = false
+		if strings.HasPrefix(token, "!") || strings.HasPrefix(token, "-") {
+			negated = !negated
+			token = token[1:]
+		}
+
+		// key IN (a, b) and key NOT IN (a, b)
+		in := 0
+		if i+2 < len(tokens) && strings.EqualFold(tokens[i+1], "IN") && tokens[i+2] == "(" {
+			in = 2
+		} else if i+3 < len(tokens) && strings.EqualFold(tokens[i+1], "NOT") && strings.EqualFold(tokens[i+2], "IN") && tokens[i+3] == "(" {
+			in, negated = 3, !negated
+		}
+		if in > 0 {
+			j := i + in + 1
+			for ; j < len(tokens) && tokens[j] != ")"; j++ {
+				if !negated {
+					tags = appendTag(tags, token+":"+tokens[j], search)
+				}
+			}
+			i = j
+			continue
+		}
+
+		if !negated {
+			tags = appendTag(tags, token, search)
+		}
+	}
+	return tags
+}
+
+// appendTag adds tag unless it is a wildcard, an attribute or a template
+// variable.
+func appendTag(tags []string, tag string, search bool) []string {
+	key, value, hasValue := strings.Cut(tag, ":")
+	switch {
+	case tag == "" || tag == "*":
+	case strings.HasPrefix(key, "@") || strings.HasPrefix(key, "$") || strings.HasPrefix(value, "$") || strings.Contains(tag, "{{"):
+	case !hasValue && search:
+	case hasValue && (key == "" || value == ""):
+	default:
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// scopeTokens splits an expression into words and parentheses. Commas and
+// whitespace separate words; quotes are removed, keeping their content in
+// one word.
+func scopeTokens(expr string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; c {
+		case ' ', '\t', '\n', ',':
+			flush()
+		case '(', ')':
+			flush()
+			tokens = append(tokens, string(c))
+		case '"', '\'':
+			end := closingQuote(expr, i)
+			word.WriteString(expr[i+1 : min(end, len(expr))])
+			i = end
+		default:
+			word.WriteByte(c)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// groupKeys splits a group-by list, "host, service" or "host,service".
+func groupKeys(list string) []string {
+	var keys []string
+	for _, key := range strings.Split(list, ",") {
+		key = strings.Trim(strings.TrimSpace(key), `"'`)
+		if key != "" && !strings.HasPrefix(key, "@") {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// closingQuote returns the index of the quote closing the string starting
+// at s[start], or len(s) if it is unterminated. Backslash escapes are
+// skipped.
+func closingQuote(s string, start int) int {
+	q := s[start]
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case q:
+			return i
+		}
+	}
+	return len(s)
+}
+
+// closingBrace returns the index of the brace closing the scope block that
+// s starts with, skipping any {{template}} inside it, or -1 if there is none.
+func closingBrace(s string) int {
+	for i := 1; i < len(s); i++ {
+		if strings.HasPrefix(s[i:], "{{") {
+			end := strings.Index(s[i:], "}}")
+			if end < 0 {
+				return -1
+			}
+			i += end + 1
+			continue
+		}
+		if s[i] == '}' {
+			return i
+		}
+	}
+	return -1
+}
+
+// callName returns the function name directly before an opening
+// parenthesis, and whether it is a method (".over(").
+func callName(before string) (string, bool) {
+	end := len(before)
+	start := end
+	for start > 0 && isNameChar(before[start-1]) {
+		start--
+	}
+	if start == end {
+		return "", false
+	}
+	return before[start:end], start > 0 && before[start-1] == '.'
+}
+
+func isNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// quotedArgs parses a call's arguments starting at the opening parenthesis
+// s[open] if they are all quoted strings, returning them and the index of
+// the closing parenthesis. end is -1 if the arguments aren't all strings.
+func quotedArgs(s string, open int) (args []string, end int) {
+	for i := open + 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == ' ' || c == ',':
+		case c == ')':
+			return args, i
+		case c == '"' || c == '\'':
+			close := closingQuote(s, i)
+			if close >= len(s) {
+				return nil, -1
+			}
+			args = append(args, unescape(s[i+1:close]))
+			i = close
+		default:
+			return nil, -1
+		}
+	}
+	return nil, -1
+}
+
+// unescape removes the backslashes escaping characters in a quoted string.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// lastWord returns the last whitespace-separated word of s, lower-cased.
+func lastWord(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToLower(fields[len(fields)-1])
+}
@@ -0,0 +1,150 @@
+package monitors
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		scope   []string
+		groupBy []string
+	}{
+		{
+			name:  "simple metric scope",
+			query: "avg(last_5m):avg:system.cpu.user{service:web} > 90",
+			scope: []string{"service:web"},
+		},
+		{
+			name:    "comma-separated scope with group by",
+			query:   "avg(last_5m):avg:system.cpu.user{service:web,env:prod} by {host} > 90",
+			scope:   []string{"service:web", "env:prod"},
+			groupBy: []string{"host"},
+		},
+		{
+			name:    "multi-key group by",
+			query:   "sum(last_1h):sum:requests.count{env:prod} by {service, availability-zone}.as_count() > 1000",
+			scope:   []string{"env:prod"},
+			groupBy: []string{"service", "availability-zone"},
+		},
+		{
+			name:  "wildcard scope",
+			query: "avg(last_5m):avg:system.load.1{*} > 4",
+		},
+		{
+			name:  "boolean operators",
+			query: "avg(last_5m):avg:system.cpu.user{service:web AND (env:prod OR env:staging)} > 90",
+			scope: []string{"service:web", "env:prod", "env:staging"},
+		},
+		{
+			name:  "negation is not a reference",
+			query: "avg(last_5m):avg:system.cpu.user{service:web,!env:staging} > 90",
+			scope: []string{"service:web"},
+		},
+		{
+			name:  "NOT keyword and negated group",
+			query: "avg(last_5m):avg:system.cpu.user{service:web AND NOT env:staging AND NOT (team:a OR team:b)} > 90",
+			scope: []string{"service:web"},
+		},
+		{
+			name:  "double negation",
+			query: "avg(last_5m):avg:x{NOT (service:web AND NOT env:prod)} > 1",
+			scope: []string{"env:prod"},
+		},
+		{
+			name:  "IN and NOT IN lists",
+			query: "avg(last_5m):avg:x{service IN (web, api) AND env NOT IN (staging)} > 1",
+			scope: []string{"service:web", "service:api"},
+		},
+		{
+			name:  "multiple scope groups in a formula",
+			query: "avg(last_5m):avg:errors{service:web} / avg:requests{service:api} > 0.05",
+			scope: []string{"service:web", "service:api"},
+		},
+		{
+			name:  "repeated scope is reported once",
+			query: "avg(last_5m):avg:errors{service:web} / avg:requests{service:web} > 0.05",
+			scope: []string{"service:web"},
+		},
+		{
+			name:  "function wrapping a scope",
+			query: "avg(last_4h):anomalies(avg:system.cpu.user{service:web}, 'basic', 2) >= 1",
+			scope: []string{"service:web"},
+		},
+		{
+			name:  "template variables are skipped",
+			query: "avg(last_5m):avg:x{service:$service,env:prod,host:{{host.name}}} > 1",
+			scope: []string{"env:prod"},
+		},
+		{
+			name:  "bare tags in a metric scope",
+			query: "avg(last_5m):avg:x{production,service:web} > 1",
+			scope: []string{"production", "service:web"},
+		},
+		{
+			name:    "service check",
+			query:   `"http.can_connect".over("service:web","env:prod").exclude("env:staging").by("host","url").last(2).count_by_status()`,
+			scope:   []string{"service:web", "env:prod"},
+			groupBy: []string{"host", "url"},
+		},
+		{
+			name:    "log search",
+			query:   `logs("service:web status:error -env:staging @http.status_code:500 timeout").index("*").rollup("count").by("host").last("5m") > 10`,
+			scope:   []string{"service:web", "status:error"},
+			groupBy: []string{"host"},
+		},
+		{
+			name:  "event search with boolean operators",
+			query: `events("source:nagios AND (env:prod OR env:dr) NOT priority:low").rollup("count").last("1h") > 0`,
+			scope: []string{"source:nagios", "env:prod", "env:dr"},
+		},
+		{
+			name:  "trace analytics with quoted value",
+			query: `trace-analytics("env:prod service:\"web app\"").rollup("count").last("5m") > 100`,
+			scope: []string{"env:prod", "service:web app"},
+		},
+		{
+			name:  "composite",
+			query: "12345 && !(67890 || 111)",
+		},
+		{
+			name:  "unbalanced scope",
+			query: "avg(last_5m):avg:x{service:web > 1",
+		},
+		{
+			name: "empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseQuery(tt.query)
+			if !reflect.DeepEqual(got.Scope, tt.scope) || !reflect.DeepEqual(got.GroupBy, tt.groupBy) {
+				t.Errorf("ParseQuery(%q) = %+v, want scope %q, group by %q", tt.query, got, tt.scope, tt.groupBy)
+			}
+		})
+	}
+}
+
+func TestQueryReferences_Matches(t *testing.T) {
+	refs := ParseQuery("avg(last_5m):avg:x{service:web,env:prod} by {host} > 1")
+	tests := []struct {
+		filters []string
+		want    bool
+	}{
+		{[]string{"service:web"}, true},
+		{[]string{"Service:Web", "env:prod"}, true},
+		{[]string{"service"}, true},
+		{[]string{"host"}, true},
+		{[]string{"host:a"}, false},
+		{[]string{"service:api"}, false},
+		{[]string{"service:web", "team:a"}, false},
+		{nil, true},
+	}
+	for _, tt := range tests {
+		if got := refs.Matches(tt.filters); got != tt.want {
+			t.Errorf("Matches(%q) = %v, want %v", tt.filters, got, tt.want)
+		}
+	}
+}
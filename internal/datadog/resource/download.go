@@ -1,7 +1,9 @@
 package resource
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,34 +11,125 @@ import (
 	"github.com/AD7six/dd-tf/internal/config"
 )
 
-// HTTPClient is an interface for HTTP clients that can perform GET requests.
-// This allows using both *http.Client and *internalhttp.DatadogHTTPClient.
+// HTTPClient is the interface the Datadog clients use to make requests. It is
+// implemented by *internalhttp.DatadogHTTPClient; tests can point one of those
+// at an httptest server or supply their own fake.
 type HTTPClient interface {
 	Get(url string) (*http.Response, error)
+	GetWithContext(ctx context.Context, url string) (*http.Response, error)
+}
+
+// Sentinel errors matched by an *APIError with the corresponding status code,
+// e.g. errors.Is(err, ErrNotFound).
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrForbidden = errors.New("forbidden")
+	// ErrNotModified is the 304 response to a conditional GET, see
+	// http.Conditional: the file already written is up to date
+	ErrNotModified = errors.New("not modified")
+)
+
+// APIError describes a non-200 response from the Datadog API.
+type APIError struct {
+	StatusCode int    // HTTP status code
+	Status     string // HTTP status line, e.g. "404 Not Found"
+	Body       string // Response body (truncated to the max body size)
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error: %s\n%s", e.Status, e.Body)
+}
+
+// Messages returns the messages in the API's {"errors": [...]} response
+// body, or nil if the body has none.
+func (e *APIError) Messages() []string {
+	var body struct {
+		Errors []string `json:"errors"`
+	}
+	if json.Unmarshal([]byte(e.Body), &body) != nil {
+		return nil
+	}
+	return body.Errors
+}
+
+// Is reports whether target is the sentinel error for e's status code.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotModified:
+		return e.StatusCode == http.StatusNotModified
+	}
+	return false
+}
+
+// newAPIError builds an APIError from a non-200 response, reading at most
+// maxSize bytes of the body.
+func newAPIError(resp *http.Response, maxSize int64) error {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize))
+	if err != nil {
+		return fmt.Errorf("API error %s (failed to read response body: %w)", resp.Status, err)
+	}
+	return &APIError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
+}
+
+// CheckResponse returns nil for a 200 response, otherwise an *APIError
+// describing the failure. The caller remains responsible for closing the body.
+func CheckResponse(resp *http.Response, settings *config.Settings) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	return newAPIError(resp, settings.HTTPMaxBodySize)
 }
 
 // FetchResourceFromAPI fetches a resource from the Datadog API.
 // Returns the decoded JSON data or an error.
 // This consolidates the common pattern of: HTTP GET, check status, decode JSON.
-func FetchResourceFromAPI(client HTTPClient, url string, settings *config.Settings) (map[string]any, error) {
-	resp, err := client.Get(url)
+func FetchResourceFromAPI(ctx context.Context, client HTTPClient, url string, settings *config.Settings) (map[string]any, error) {
+	raw, err := FetchRawFromAPI(ctx, client, url, settings)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(io.LimitReader(resp.Body, settings.HTTPMaxBodySize))
-		if err != nil {
-			return nil, fmt.Errorf("API error %s (failed to read response body: %w)", resp.Status, err)
-		}
-		return nil, fmt.Errorf("API error: %s\n%s", resp.Status, string(body))
-	}
 
 	var result map[string]any
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.Unmarshal(raw, &result); err != nil {
 		return nil, err
 	}
 
 	return result, nil
 }
+
+// FetchRawFromAPI fetches a resource from the Datadog API and returns the raw
+// response body. Keeping the bytes as received avoids a decode/re-encode round
+// trip (and the key reordering that comes with it) when the payload is only
+// going to be written to disk.
+func FetchRawFromAPI(ctx context.Context, client HTTPClient, url string, settings *config.Settings) ([]byte, error) {
+	resp, err := client.GetWithContext(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := CheckResponse(resp, settings); err != nil {
+		return nil, err
+	}
+
+	return readBody(resp.Body, settings.HTTPMaxBodySize)
+}
+
+// readBody reads a response body, enforcing maxSize when it is positive.
+func readBody(r io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		return io.ReadAll(r)
+	}
+	body, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxSize {
+		return nil, fmt.Errorf("response body exceeds maximum size of %d bytes", maxSize)
+	}
+	return body, nil
+}
@@ -2,6 +2,8 @@ package resource
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
 	"net/http"
 	"testing"
@@ -18,6 +20,10 @@ func (f *fakeHTTPClient) Get(url string) (*http.Response, error) {
 	return f.resp, f.err
 }
 
+func (f *fakeHTTPClient) GetWithContext(_ context.Context, url string) (*http.Response, error) {
+	return f.Get(url)
+}
+
 func TestFetchResourceFromAPI_HappyPath(t *testing.T) {
 	body := `{"foo":"bar","n":123}`
 	resp := &http.Response{
@@ -28,7 +34,7 @@ func TestFetchResourceFromAPI_HappyPath(t *testing.T) {
 	settings := &config.Settings{HTTPMaxBodySize: 1024}
 	client := &fakeHTTPClient{resp: resp}
 
-	got, err := FetchResourceFromAPI(client, "https://api.example.com/v1/x", settings)
+	got, err := FetchResourceFromAPI(context.Background(), client, "https://api.example.com/v1/x", settings)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -46,8 +52,46 @@ func TestFetchResourceFromAPI_Non200(t *testing.T) {
 	settings := &config.Settings{HTTPMaxBodySize: 1024}
 	client := &fakeHTTPClient{resp: resp}
 
-	_, err := FetchResourceFromAPI(client, "https://api.example.com/v1/x", settings)
+	_, err := FetchResourceFromAPI(context.Background(), client, "https://api.example.com/v1/x", settings)
 	if err == nil {
 		t.Fatalf("expected error for non-200 response")
 	}
 }
+
+func TestFetchRawFromAPI_StatusSentinels(t *testing.T) {
+	tests := []struct {
+		status                           int
+		notFound, forbidden, notModified bool
+	}{
+		{http.StatusNotFound, true, false, false},
+		{http.StatusForbidden, false, true, false},
+		{http.StatusNotModified, false, false, true},
+		{http.StatusInternalServerError, false, false, false},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{
+			StatusCode: tt.status,
+			Status:     http.StatusText(tt.status),
+			Body:       io.NopCloser(bytes.NewBufferString("{}")),
+		}
+		_, err := FetchRawFromAPI(context.Background(), &fakeHTTPClient{resp: resp}, "https://api.example.com/v1/x", &config.Settings{HTTPMaxBodySize: 1024})
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+			t.Errorf("%d: error = %v, want *APIError", tt.status, err)
+		}
+		if errors.Is(err, ErrNotFound) != tt.notFound || errors.Is(err, ErrForbidden) != tt.forbidden || errors.Is(err, ErrNotModified) != tt.notModified {
+			t.Errorf("%d: Is(ErrNotFound) = %v, Is(ErrForbidden) = %v, Is(ErrNotModified) = %v", tt.status, errors.Is(err, ErrNotFound), errors.Is(err, ErrForbidden), errors.Is(err, ErrNotModified))
+		}
+	}
+}
+
+func TestAPIError_Messages(t *testing.T) {
+	err := &APIError{StatusCode: 400, Body: `{"errors":["The value provided for parameter 'query' is invalid"]}`}
+	if got := err.Messages(); len(got) != 1 || got[0] != "The value provided for parameter 'query' is invalid" {
+		t.Errorf("Messages() = %v", got)
+	}
+	if got := (&APIError{Body: "Bad Gateway"}).Messages(); got != nil {
+		t.Errorf("Messages() for a non-JSON body = %v, want nil", got)
+	}
+}
@@ -0,0 +1,239 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/AD7six/dd-tf/internal/logging"
+)
+
+// defaultWorkers matches the default HTTP client concurrency limit; more
+// workers would only queue on the client.
+const defaultWorkers = 8
+
+// Downloader consumes a stream of targets and downloads each one with a
+// bounded pool of workers, collecting the outcome into a Summary. It holds
+// everything the download commands have in common, so a new resource type
+// only supplies Download and FormatID.
+type Downloader[T comparable] struct {
+	Kind         string                                                      // Resource name for log messages, e.g. "dashboard"
+	Download     func(ctx context.Context, target Target[T]) (string, error) // Downloads one target, returning the path written
+	FormatID     func(T) string                                              // Formats an ID for logs and the summary
+	Workers      int                                                         // Concurrent downloads, defaults to 8
+	DryRun       bool                                                        // Log what would be downloaded without downloading
+	Quiet        bool                                                        // Don't log each target, only failures
+	Strict       bool                                                        // Count targets the API refuses (403) as failures, not only as restricted
+	FailUntagged bool                                                        // Count targets missing a required tag as failures, not only as untagged
+	Hooks        Hooks[T]                                                    // Optional callbacks for download events
+}
+
+// Hooks are optional callbacks for download events. They may be called
+// concurrently from worker goroutines, so they must be safe for concurrent
+// use. A panicking hook is recovered, logged and counted as an error for
+// that target.
+type Hooks[T comparable] struct {
+	OnTargetDiscovered func(target Target[T])              // A target was received, before it is downloaded
+	OnDownloaded       func(target Target[T], path string) // A target was written to path
+	OnError            func(target Target[T], err error)   // A target failed; target is zero for target generation errors
+}
+
+// Downloaded is a target that was written.
+type Downloaded struct {
+	ID   string
+	Path string
+}
+
+// Untagged is a target missing some of the REQUIRED_TAGS.
+type Untagged struct {
+	ID      string
+	Title   string
+	Missing []string // Required tag keys it doesn't have
+	Path    string   // Quarantine path written, empty if skipped
+}
+
+// Summary is the outcome of Downloader.Run.
+type Summary struct {
+	Total        int          // Targets received (excluding target generation errors)
+	Dependencies int          // Targets received as dependencies of other targets, included in Total
+	Downloaded   []Downloaded // Targets written, in completion order
+	NotModified  []Downloaded // Targets whose file was already up to date (ErrNotModified), not written
+	Errors       []error      // *TargetError for failed targets, or target generation errors
+	FailedIDs    []string     // IDs of failed targets
+	Restricted   []string     // IDs of targets the API refused (403); failures too only with Strict
+	Untagged     []Untagged   // Targets missing a required tag; failures too only with FailUntagged
+}
+
+// Failed returns the number of errors, including target generation errors.
+func (s Summary) Failed() int {
+	return len(s.Errors)
+}
+
+// FailedError reports that a run had failures. It unwraps to the individual
+// errors, so errors.Is(err, ErrNotFound) and friends work on the command's
+// returned error.
+type FailedError struct {
+	Kind string  // Resource name, e.g. "dashboard"
+	Verb string  // What failed, e.g. "push"; defaults to "download"
+	Errs []error // The underlying failures
+}
+
+func (e *FailedError) Error() string {
+	verb := e.Verb
+	if verb == "" {
+		verb = "download"
+	}
+	return fmt.Sprintf("one or more %ss failed to %s", e.Kind, verb)
+}
+
+func (e *FailedError) Unwrap() []error {
+	return e.Errs
+}
+
+// Run downloads every target from targets until the channel closes or ctx is
+// done, then waits for in-flight downloads. Failures are logged as they
+// happen and returned in the Summary. A target whose OnTargetDiscovered hook
+// panics is not downloaded.
+func (d *Downloader[T]) Run(ctx context.Context, targets <-chan TargetResult[T]) Summary {
+	workers := d.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+
+	var (
+		summary Summary
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+	)
+	record := func(err error) {
+		attrs := []any{"error", err}
+		var targetErr *TargetError
+		if errors.As(err, &targetErr) && targetErr.Path != "" {
+			attrs = append(attrs, "path", targetErr.Path)
+		}
+		logging.Logger.Error("download failed", attrs...)
+
+		mu.Lock()
+		defer mu.Unlock()
+		summary.Errors = append(summary.Errors, err)
+		if targetErr != nil {
+			summary.FailedIDs = append(summary.FailedIDs, targetErr.ID)
+		}
+	}
+	// hook runs fn, turning a panic into an error for target
+	hook := func(name string, target Target[T], fn func()) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = &TargetError{ID: d.FormatID(target.ID), Path: target.Path, Err: fmt.Errorf("%s hook panicked: %v", name, r)}
+				record(err)
+			}
+		}()
+		fn()
+		return nil
+	}
+	fail := func(target Target[T], err error) {
+		record(err)
+		if d.Hooks.OnError != nil {
+			hook("OnError", target, func() { d.Hooks.OnError(target, err) })
+		}
+	}
+
+	work := make(chan Target[T])
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for target := range work {
+				id := d.FormatID(target.ID)
+				path, err := d.Download(ctx, target)
+				if errors.Is(err, ErrNotModified) {
+					if !d.Quiet {
+						logging.Logger.Info(d.Kind+" not modified", "path", path)
+					}
+					mu.Lock()
+					summary.NotModified = append(summary.NotModified, Downloaded{ID: id, Path: path})
+					mu.Unlock()
+					continue
+				}
+				if errors.Is(err, ErrForbidden) {
+					mu.Lock()
+					summary.Restricted = append(summary.Restricted, id)
+					mu.Unlock()
+					if !d.Strict {
+						// Listed once by the caller rather than as an error each
+						logging.Logger.Debug(d.Kind+" restricted", "id", id, "error", err)
+						continue
+					}
+				}
+				var untagged *UntaggedError
+				if errors.As(err, &untagged) {
+					mu.Lock()
+					summary.Untagged = append(summary.Untagged, Untagged{ID: id, Title: untagged.Title, Missing: untagged.Missing, Path: untagged.Path})
+					mu.Unlock()
+					switch {
+					case untagged.Path != "":
+						// Quarantined: written, so carry on as downloaded
+						path, err = untagged.Path, nil
+					case !d.FailUntagged:
+						// Listed once by the caller rather than as an error each
+						logging.Logger.Debug(d.Kind+" skipped", "id", id, "error", err)
+						continue
+					}
+				}
+				if err != nil {
+					fail(target, &TargetError{ID: id, Path: target.Path, Err: err})
+					continue
+				}
+				if !d.Quiet {
+					logging.Logger.Info(d.Kind+" saved", "path", path)
+				}
+				mu.Lock()
+				summary.Downloaded = append(summary.Downloaded, Downloaded{ID: id, Path: path})
+				mu.Unlock()
+				if d.Hooks.OnDownloaded != nil {
+					target.Path = path
+					hook("OnDownloaded", target, func() { d.Hooks.OnDownloaded(target, path) })
+				}
+			}
+		}()
+	}
+
+	for result := range targets {
+		if ctx.Err() != nil {
+			// Producers stop on their own once ctx is done
+			break
+		}
+		// Check if target generation failed
+		if result.Err != nil {
+			fail(Target[T]{}, result.Err)
+			continue
+		}
+
+		summary.Total++
+		target := result.Target
+		if target.Dependency {
+			summary.Dependencies++
+		}
+		if d.Hooks.OnTargetDiscovered != nil {
+			if hook("OnTargetDiscovered", target, func() { d.Hooks.OnTargetDiscovered(target) }) != nil {
+				continue
+			}
+		}
+		if d.DryRun {
+			logging.Logger.Info("would download "+d.Kind, "id", d.FormatID(target.ID), "path", target.Path)
+			continue
+		}
+		if !d.Quiet {
+			logging.Logger.Info("downloading "+d.Kind, "id", d.FormatID(target.ID))
+		}
+		select {
+		case work <- target:
+		case <-ctx.Done():
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	return summary
+}
@@ -0,0 +1,384 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+)
+
+// targetsOf returns a closed channel holding results.
+func targetsOf(results ...TargetResult[int]) <-chan TargetResult[int] {
+	ch := make(chan TargetResult[int], len(results))
+	for _, r := range results {
+		ch <- r
+	}
+	close(ch)
+	return ch
+}
+
+func TestDownloader_Run(t *testing.T) {
+	listErr := errors.New("list failed")
+	d := Downloader[int]{
+		Kind:     "monitor",
+		FormatID: strconv.Itoa,
+		Download: func(_ context.Context, target Target[int]) (string, error) {
+			if target.ID == 2 {
+				return "", errors.New("API error: 404")
+			}
+			return fmt.Sprintf("data/%d.json", target.ID), nil
+		},
+	}
+
+	summary := d.Run(context.Background(), targetsOf(
+		TargetResult[int]{Target: Target[int]{ID: 1}},
+		TargetResult[int]{Target: Target[int]{ID: 2, Path: "data/2.json"}},
+		TargetResult[int]{Err: listErr},
+		TargetResult[int]{Target: Target[int]{ID: 3, Dependency: true}},
+	))
+
+	if summary.Total != 3 || summary.Dependencies != 1 {
+		t.Errorf("Total = %d, Dependencies = %d, want 3 and 1", summary.Total, summary.Dependencies)
+	}
+	var paths []string
+	for _, dl := range summary.Downloaded {
+		paths = append(paths, dl.Path)
+	}
+	sort.Strings(paths)
+	if fmt.Sprint(paths) != "[data/1.json data/3.json]" {
+		t.Errorf("Downloaded paths = %v", paths)
+	}
+	if summary.Failed() != 2 || fmt.Sprint(summary.FailedIDs) != "[2]" {
+		t.Errorf("Failed() = %d, FailedIDs = %v, want 2 and [2]", summary.Failed(), summary.FailedIDs)
+	}
+
+	var targetErr *TargetError
+	var sawList bool
+	for _, err := range summary.Errors {
+		if errors.Is(err, listErr) {
+			sawList = true
+		}
+		if errors.As(err, &targetErr) && (targetErr.ID != "2" || targetErr.Path != "data/2.json") {
+			t.Errorf("TargetError = %+v, want id 2 with its path", targetErr)
+		}
+	}
+	if !sawList || targetErr == nil {
+		t.Errorf("Errors = %v, want the list error and a TargetError", summary.Errors)
+	}
+}
+
+func TestDownloader_DryRun(t *testing.T) {
+	d := Downloader[int]{
+		Kind:     "monitor",
+		FormatID: strconv.Itoa,
+		DryRun:   true,
+		Download: func(context.Context, Target[int]) (string, error) {
+			t.Error("Download called in dry-run mode")
+			return "", nil
+		},
+	}
+	summary := d.Run(context.Background(), targetsOf(TargetResult[int]{Target: Target[int]{ID: 1}}))
+	if summary.Total != 1 || len(summary.Downloaded) != 0 || summary.Failed() != 0 {
+		t.Errorf("summary = %+v, want one target and nothing downloaded", summary)
+	}
+}
+
+func TestDownloader_Workers(t *testing.T) {
+	var running, peak int32
+	d := Downloader[int]{
+		Kind:     "monitor",
+		FormatID: strconv.Itoa,
+		Workers:  2,
+		Download: func(context.Context, Target[int]) (string, error) {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return "x.json", nil
+		},
+	}
+
+	var results []TargetResult[int]
+	for i := 0; i < 6; i++ {
+		results = append(results, TargetResult[int]{Target: Target[int]{ID: i}})
+	}
+	summary := d.Run(context.Background(), targetsOf(results...))
+
+	if len(summary.Downloaded) != 6 {
+		t.Errorf("downloaded %d, want 6", len(summary.Downloaded))
+	}
+	if peak > 2 {
+		t.Errorf("peak concurrency = %d, want at most 2", peak)
+	}
+}
+
+func TestDownloader_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	targets := make(chan TargetResult[int]) // never closed by the producer
+	var calls int32
+	d := Downloader[int]{
+		Kind:     "monitor",
+		FormatID: strconv.Itoa,
+		Workers:  1,
+		Download: func(ctx context.Context, target Target[int]) (string, error) {
+			atomic.AddInt32(&calls, 1)
+			cancel()
+			return "", ctx.Err()
+		},
+	}
+
+	done := make(chan Summary)
+	go func() { done <- d.Run(ctx, targets) }()
+	targets <- TargetResult[int]{Target: Target[int]{ID: 1}}
+
+	// A producer honouring ctx closes its channel once cancelled
+	<-ctx.Done()
+	close(targets)
+
+	select {
+	case summary := <-done:
+		if calls != 1 || summary.Failed() != 1 {
+			t.Errorf("calls = %d, Failed() = %d, want 1 and 1", calls, summary.Failed())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+}
+
+func TestDownloader_Hooks(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		discovered []int
+		downloaded []string
+		failed     []int
+	)
+	d := Downloader[int]{
+		Kind:     "monitor",
+		FormatID: strconv.Itoa,
+		Download: func(_ context.Context, target Target[int]) (string, error) {
+			if target.ID == 2 {
+				return "", errors.New("API error: 404")
+			}
+			return fmt.Sprintf("data/%d.json", target.ID), nil
+		},
+		Hooks: Hooks[int]{
+			OnTargetDiscovered: func(target Target[int]) {
+				mu.Lock()
+				defer mu.Unlock()
+				discovered = append(discovered, target.ID)
+			},
+			OnDownloaded: func(target Target[int], path string) {
+				mu.Lock()
+				defer mu.Unlock()
+				downloaded = append(downloaded, path)
+			},
+			OnError: func(target Target[int], err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				failed = append(failed, target.ID)
+			},
+		},
+	}
+
+	d.Run(context.Background(), targetsOf(
+		TargetResult[int]{Target: Target[int]{ID: 1}},
+		TargetResult[int]{Target: Target[int]{ID: 2}},
+	))
+
+	sort.Ints(discovered)
+	if fmt.Sprint(discovered) != "[1 2]" {
+		t.Errorf("discovered = %v, want [1 2]", discovered)
+	}
+	if fmt.Sprint(downloaded) != "[data/1.json]" {
+		t.Errorf("downloaded = %v, want [data/1.json]", downloaded)
+	}
+	if fmt.Sprint(failed) != "[2]" {
+		t.Errorf("failed = %v, want [2]", failed)
+	}
+}
+
+func TestDownloader_HookPanic(t *testing.T) {
+	var calls int32
+	d := Downloader[int]{
+		Kind:     "monitor",
+		FormatID: strconv.Itoa,
+		Download: func(_ context.Context, target Target[int]) (string, error) {
+			atomic.AddInt32(&calls, 1)
+			return "x.json", nil
+		},
+		Hooks: Hooks[int]{
+			OnTargetDiscovered: func(target Target[int]) {
+				if target.ID == 1 {
+					panic("boom")
+				}
+			},
+			OnDownloaded: func(Target[int], string) { panic("boom") },
+		},
+	}
+
+	summary := d.Run(context.Background(), targetsOf(
+		TargetResult[int]{Target: Target[int]{ID: 1}},
+		TargetResult[int]{Target: Target[int]{ID: 2}},
+	))
+
+	if calls != 1 {
+		t.Errorf("Download called %d times, want 1 (target 1 skipped)", calls)
+	}
+	if summary.Failed() != 2 {
+		t.Fatalf("Failed() = %d, want 2", summary.Failed())
+	}
+	sort.Strings(summary.FailedIDs)
+	if fmt.Sprint(summary.FailedIDs) != "[1 2]" {
+		t.Errorf("FailedIDs = %v, want [1 2]", summary.FailedIDs)
+	}
+}
+
+func TestDownloader_FailedErrorChain(t *testing.T) {
+	d := Downloader[int]{
+		Kind:     "monitor",
+		FormatID: strconv.Itoa,
+		Download: func(_ context.Context, target Target[int]) (string, error) {
+			if target.ID == 1 {
+				_, err := FetchRawFromAPI(context.Background(), &fakeHTTPClient{resp: &http.Response{
+					StatusCode: http.StatusNotFound,
+					Status:     "404 Not Found",
+					Body:       io.NopCloser(strings.NewReader("{}")),
+				}}, "https://api.example.com/v1/monitor/1", &config.Settings{})
+				return "", fmt.Errorf("failed to fetch monitor: %w", err)
+			}
+			return "", fmt.Errorf("failed to fetch monitor: %w", &internalhttp.RateLimitedError{RetryAfter: 5 * time.Second})
+		},
+	}
+	summary := d.Run(context.Background(), targetsOf(
+		TargetResult[int]{Target: Target[int]{ID: 1}},
+		TargetResult[int]{Target: Target[int]{ID: 2}},
+	))
+
+	// As returned by the download commands
+	err := fmt.Errorf("monitors download: %w", &FailedError{Kind: "monitor", Errs: summary.Errors})
+
+	if err.Error() != "monitors download: one or more monitors failed to download" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("errors.Is(err, ErrNotFound) = false for %v", summary.Errors)
+	}
+	var rateLimited *internalhttp.RateLimitedError
+	if !errors.As(err, &rateLimited) || rateLimited.RetryAfter != 5*time.Second {
+		t.Errorf("errors.As(err, *RateLimitedError) failed for %v", summary.Errors)
+	}
+	var targetErr *TargetError
+	if !errors.As(err, &targetErr) {
+		t.Errorf("errors.As(err, *TargetError) failed for %v", summary.Errors)
+	}
+}
+
+func TestDownloader_Restricted(t *testing.T) {
+	forbidden := &APIError{StatusCode: http.StatusForbidden, Status: "403 Forbidden"}
+	for _, strict := range []bool{false, true} {
+		d := Downloader[int]{
+			Kind:     "monitor",
+			FormatID: strconv.Itoa,
+			Strict:   strict,
+			Download: func(_ context.Context, target Target[int]) (string, error) {
+				if target.ID == 2 {
+					return "", fmt.Errorf("failed to fetch monitor: %w", forbidden)
+				}
+				return fmt.Sprintf("data/%d.json", target.ID), nil
+			},
+		}
+		summary := d.Run(context.Background(), targetsOf(
+			TargetResult[int]{Target: Target[int]{ID: 1}},
+			TargetResult[int]{Target: Target[int]{ID: 2}},
+		))
+
+		if fmt.Sprint(summary.Restricted) != "[2]" || len(summary.Downloaded) != 1 {
+			t.Errorf("strict=%v: Restricted = %v, Downloaded = %v, want [2] and one download", strict, summary.Restricted, summary.Downloaded)
+		}
+		wantFailed := 0
+		if strict {
+			wantFailed = 1
+		}
+		if summary.Failed() != wantFailed {
+			t.Errorf("strict=%v: Failed() = %d, want %d", strict, summary.Failed(), wantFailed)
+		}
+		if strict && !errors.Is(&FailedError{Kind: "monitor", Errs: summary.Errors}, ErrForbidden) {
+			t.Errorf("strict: errors.Is(err, ErrForbidden) = false for %v", summary.Errors)
+		}
+	}
+}
+
+func TestDownloader_NotModified(t *testing.T) {
+	notModified := &APIError{StatusCode: http.StatusNotModified, Status: "304 Not Modified"}
+	d := Downloader[int]{
+		Kind:     "dashboard",
+		FormatID: strconv.Itoa,
+		Download: func(_ context.Context, target Target[int]) (string, error) {
+			path := fmt.Sprintf("data/%d.json", target.ID)
+			if target.ID == 2 {
+				return path, fmt.Errorf("failed to fetch dashboard: %w", notModified)
+			}
+			return path, nil
+		},
+	}
+	summary := d.Run(context.Background(), targetsOf(
+		TargetResult[int]{Target: Target[int]{ID: 1}},
+		TargetResult[int]{Target: Target[int]{ID: 2}},
+	))
+
+	want := []Downloaded{{ID: "2", Path: "data/2.json"}}
+	if fmt.Sprint(summary.NotModified) != fmt.Sprint(want) || len(summary.Downloaded) != 1 || summary.Failed() != 0 {
+		t.Errorf("NotModified = %v, Downloaded = %v, Failed() = %d, want %v, one download and no failures", summary.NotModified, summary.Downloaded, summary.Failed(), want)
+	}
+}
+
+func TestDownloader_Untagged(t *testing.T) {
+	for _, failUntagged := range []bool{false, true} {
+		d := Downloader[int]{
+			Kind:         "monitor",
+			FormatID:     strconv.Itoa,
+			FailUntagged: failUntagged,
+			Download: func(_ context.Context, target Target[int]) (string, error) {
+				switch target.ID {
+				case 2:
+					return "", &UntaggedError{Title: "Skipped", Missing: []string{"team"}}
+				case 3:
+					return "data/_untagged/3.json", &UntaggedError{Title: "Quarantined", Missing: []string{"team"}, Path: "data/_untagged/3.json"}
+				}
+				return fmt.Sprintf("data/%d.json", target.ID), nil
+			},
+		}
+		summary := d.Run(context.Background(), targetsOf(
+			TargetResult[int]{Target: Target[int]{ID: 1}},
+			TargetResult[int]{Target: Target[int]{ID: 2}},
+			TargetResult[int]{Target: Target[int]{ID: 3}},
+		))
+
+		if len(summary.Untagged) != 2 || len(summary.Downloaded) != 2 {
+			t.Errorf("fail=%v: Untagged = %+v, Downloaded = %v, want two of each", failUntagged, summary.Untagged, summary.Downloaded)
+		}
+		wantFailed := 0
+		if failUntagged {
+			wantFailed = 1
+		}
+		if summary.Failed() != wantFailed {
+			t.Errorf("fail=%v: Failed() = %d, want %d", failUntagged, summary.Failed(), wantFailed)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Exit codes of the diff and drift commands run with --exit-code, as for
+// git diff --exit-code. Other commands exit ExitDifferences on any error,
+// and every command ExitInterrupted once stopped by SIGINT or SIGTERM.
+const (
+	ExitOK          = 0   // Everything is in sync
+	ExitDifferences = 1   // Something differs
+	ExitError       = 2   // Something couldn't be compared
+	ExitInterrupted = 130 // Interrupted, as shells report a SIGINT
+)
+
+// AnnotationRunsUntilInterrupted marks, in a command's annotations, a run
+// whose normal way to stop is an interrupt, such as download --watch, so it
+// exits with its own result rather than ExitInterrupted.
+const AnnotationRunsUntilInterrupted = "dd-tf/runs-until-interrupted"
+
+// DifferencesError reports that a diff or drift run with --exit-code found
+// differences. It is not a failure: the comparison itself succeeded.
+type DifferencesError struct {
+	Kind  string // Resource name, e.g. "dashboard"; "resource" for several kinds
+	Count int    // How many resources differ
+}
+
+func (e *DifferencesError) Error() string {
+	return fmt.Sprintf("%d %s(s) differ", e.Count, e.Kind)
+}
+
+// OperationalError reports that a command run with --exit-code failed for
+// another reason than differences: configuration, authentication, network
+// or file errors.
+type OperationalError struct {
+	Err error
+}
+
+func (e *OperationalError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *OperationalError) Unwrap() error {
+	return e.Err
+}
+
+// InterruptedError reports that a command was stopped by a signal. Err is
+// whatever the command returned as it stopped, if anything.
+type InterruptedError struct {
+	Err error
+}
+
+func (e *InterruptedError) Error() string {
+	if e.Err == nil {
+		return "interrupted"
+	}
+	return "interrupted: " + e.Err.Error()
+}
+
+func (e *InterruptedError) Unwrap() error {
+	return e.Err
+}
+
+// WithExitCode returns err as a command run with --exit-code should: nil
+// and a DifferencesError unchanged, anything else as an OperationalError.
+func WithExitCode(err error) error {
+	var diffErr *DifferencesError
+	if err == nil || errors.As(err, &diffErr) {
+		return err
+	}
+	return &OperationalError{Err: err}
+}
+
+// ExitCode returns the process exit code for a command's error.
+func ExitCode(err error) int {
+	var (
+		opErr          *OperationalError
+		interruptedErr *InterruptedError
+	)
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &interruptedErr):
+		return ExitInterrupted
+	case errors.As(err, &opErr):
+		return ExitError
+	}
+	return ExitDifferences
+}
@@ -0,0 +1,40 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	differ := &DifferencesError{Kind: "dashboard", Count: 2}
+	failed := &FailedError{Kind: "dashboard", Verb: "diff", Errs: []error{ErrForbidden}}
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"plain error", errors.New("boom"), ExitDifferences},
+		{"differences", WithExitCode(differ), ExitDifferences},
+		{"wrapped differences", WithExitCode(fmt.Errorf("diff: %w", differ)), ExitDifferences},
+		{"failure", WithExitCode(failed), ExitError},
+		{"operational", &OperationalError{Err: errors.New("boom")}, ExitError},
+		{"interrupted", &InterruptedError{}, ExitInterrupted},
+		{"interrupted with --exit-code", WithExitCode(&InterruptedError{Err: differ}), ExitInterrupted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	if WithExitCode(nil) != nil {
+		t.Error("WithExitCode(nil) != nil")
+	}
+	if err := WithExitCode(failed); !errors.Is(err, ErrForbidden) {
+		t.Errorf("WithExitCode(%v) = %v, want it to unwrap to the failures", failed, err)
+	}
+}
@@ -0,0 +1,84 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/AD7six/dd-tf/internal/config"
+)
+
+// The v2 API wraps resources in a JSON:API envelope: {"data": {"id": ...,
+// "type": ..., "attributes": {...}}}, with "data" an array for lists. dd-tf
+// writes v2 resources as their "data" member, keeping id, type and
+// attributes, and dropping the envelope's "included", "links" and "meta".
+
+// FetchData fetches a v2 endpoint and returns its "data" member.
+func FetchData(ctx context.Context, client HTTPClient, url string, settings *config.Settings) (json.RawMessage, error) {
+	raw, err := FetchRawFromAPI(ctx, client, url, settings)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if resp.Data == nil {
+		return nil, fmt.Errorf("response has no data")
+	}
+	return resp.Data, nil
+}
+
+// FetchDataList fetches a v2 list endpoint and returns the resources in its
+// "data" array.
+func FetchDataList(ctx context.Context, client HTTPClient, url string, settings *config.Settings) ([]json.RawMessage, error) {
+	data, err := FetchData(ctx, client, url, settings)
+	if err != nil {
+		return nil, err
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to decode data: %w", err)
+	}
+	return items, nil
+}
+
+// FetchDataPages fetches every page of a v2 list endpoint paginated with
+// page[number]/page[size] and returns the resources in their "data" arrays.
+func FetchDataPages(ctx context.Context, client HTTPClient, url string, pageSize int, settings *config.Settings) ([]json.RawMessage, error) {
+	var all []json.RawMessage
+	pagination := NewPagePagination(pageSize)
+	for {
+		page, err := FetchDataList(ctx, client, pagination.FormatJSONAPIPageURL(url), settings)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if !pagination.NextPage(len(page)) {
+			return all, nil
+		}
+	}
+}
+
+// DecodeDataMeta is DecodeMeta for v2 resources: the ID is the top-level
+// "id", and the name and tags are read from "attributes". Resources without
+// a name are named after their ID.
+func DecodeDataMeta(raw json.RawMessage) (Meta, error) {
+	var item struct {
+		ID         json.RawMessage `json:"id"`
+		Attributes struct {
+			Name string   `json:"name"`
+			Tags []string `json:"tags"`
+		} `json:"attributes"`
+	}
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return Meta{}, err
+	}
+	meta := Meta{ID: FormatRawID(item.ID), Name: item.Attributes.Name, Tags: item.Attributes.Tags}
+	if meta.Name == "" {
+		meta.Name = meta.ID
+	}
+	return meta, nil
+}
@@ -0,0 +1,29 @@
+package resource
+
+// Resource kinds recognised by GuessKind.
+const (
+	KindDashboard = "dashboard"
+	KindMonitor   = "monitor"
+)
+
+// GuessKind infers the resource type of a decoded JSON document from its
+// shape: dashboards have a string id plus widgets and a layout type,
+// monitors have a numeric id, a type and a query. Returns "" if neither
+// matches.
+func GuessKind(content map[string]any) string {
+	switch content["id"].(type) {
+	case string:
+		_, widgets := content["widgets"]
+		_, layout := content["layout_type"]
+		if widgets && layout {
+			return KindDashboard
+		}
+	case float64:
+		_, typ := content["type"]
+		_, query := content["query"]
+		if typ && query {
+			return KindMonitor
+		}
+	}
+	return ""
+}
@@ -0,0 +1,24 @@
+package resource
+
+import "testing"
+
+func TestGuessKind(t *testing.T) {
+	tests := []struct {
+		name    string
+		content map[string]any
+		want    string
+	}{
+		{"dashboard", map[string]any{"id": "abc-def-ghi", "widgets": []any{}, "layout_type": "ordered"}, KindDashboard},
+		{"monitor", map[string]any{"id": float64(123), "type": "metric alert", "query": "avg(last_5m):avg:system.load.1{*} > 1"}, KindMonitor},
+		{"dashboard without widgets", map[string]any{"id": "abc-def-ghi", "layout_type": "ordered"}, ""},
+		{"monitor with string id", map[string]any{"id": "123", "type": "metric alert", "query": "q"}, ""},
+		{"no id", map[string]any{"widgets": []any{}, "layout_type": "ordered"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GuessKind(tt.content); got != tt.want {
+				t.Errorf("GuessKind() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
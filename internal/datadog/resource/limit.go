@@ -0,0 +1,65 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+)
+
+// TooManyError reports a selection larger than the MAX_RESOURCES cap.
+type TooManyError struct {
+	Plural string // e.g. "dashboards"
+	Max    int
+	Verb   string // What wasn't done, e.g. "deleted"; "downloaded" if empty
+}
+
+func (e *TooManyError) Error() string {
+	verb := e.Verb
+	if verb == "" {
+		verb = "downloaded"
+	}
+	return fmt.Sprintf("more than %d %s selected, nothing was %s; raise --max-resources (MAX_RESOURCES) or tighten the filters", e.Max, e.Plural, verb)
+}
+
+// CheckLimit returns a *TooManyError if count is more than max, for a
+// command that has counted its selection before doing anything with it.
+// With max <= 0 there is no limit.
+func CheckLimit(plural, verb string, count, max int) error {
+	if max > 0 && count > max {
+		return &TooManyError{Plural: plural, Max: max, Verb: verb}
+	}
+	return nil
+}
+
+// LimitTargets holds targets back until the producer has finished, so that
+// nothing is downloaded (or verb, e.g. "pushed") before the selection is
+// known to be within max. As soon as more than max targets arrive it calls
+// cancel, which stops the producer, and returns a *TooManyError. Target
+// generation errors don't count. With max <= 0 there is no limit and targets
+// is returned as is.
+func LimitTargets[T comparable](ctx context.Context, cancel context.CancelFunc, targets <-chan TargetResult[T], plural, verb string, max int) (<-chan TargetResult[T], error) {
+	if max <= 0 {
+		return targets, nil
+	}
+	var held []TargetResult[T]
+	count := 0
+	for result := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		held = append(held, result)
+		if result.Err != nil {
+			continue
+		}
+		if count++; count > max {
+			cancel()
+			return nil, &TooManyError{Plural: plural, Max: max, Verb: verb}
+		}
+	}
+
+	out := make(chan TargetResult[T], len(held))
+	for _, result := range held {
+		out <- result
+	}
+	close(out)
+	return out, nil
+}
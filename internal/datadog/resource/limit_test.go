@@ -0,0 +1,77 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLimitTargets(t *testing.T) {
+	t.Run("within the limit", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		in := targetsOf(TargetResult[int]{Target: Target[int]{ID: 1}}, TargetResult[int]{Err: errors.New("page failed")}, TargetResult[int]{Target: Target[int]{ID: 2}})
+		out, err := LimitTargets(ctx, cancel, in, "monitors", "", 2)
+		if err != nil {
+			t.Fatalf("LimitTargets() error = %v", err)
+		}
+		var got int
+		for range out {
+			got++
+		}
+		if got != 3 {
+			t.Errorf("LimitTargets() passed %d results, want all 3", got)
+		}
+		if ctx.Err() != nil {
+			t.Error("LimitTargets() cancelled the producer within the limit")
+		}
+	})
+
+	t.Run("over the limit stops the producer", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		in := make(chan TargetResult[int])
+		done := make(chan struct{})
+		sent := 0
+		go func() {
+			defer close(done)
+			for id := 1; ; id++ {
+				if !Send(ctx, in, TargetResult[int]{Target: Target[int]{ID: id}}) {
+					return
+				}
+				sent++
+			}
+		}()
+		_, err := LimitTargets(ctx, cancel, in, "monitors", "", 3)
+		var tooMany *TooManyError
+		if !errors.As(err, &tooMany) || tooMany.Max != 3 {
+			t.Fatalf("LimitTargets() error = %v, want a TooManyError for 3", err)
+		}
+		<-done
+		if sent != 4 {
+			t.Errorf("producer sent %d targets, want it stopped after 4", sent)
+		}
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		in := targetsOf(TargetResult[int]{Target: Target[int]{ID: 1}})
+		out, err := LimitTargets(context.Background(), func() {}, in, "monitors", "", 0)
+		if err != nil || out != in {
+			t.Errorf("LimitTargets() = %v, %v, want the targets unchanged", out, err)
+		}
+	})
+}
+
+func TestCheckLimit(t *testing.T) {
+	if err := CheckLimit("monitors", "deleted", 3, 3); err != nil {
+		t.Errorf("CheckLimit(3, 3) = %v, want nil", err)
+	}
+	if err := CheckLimit("monitors", "deleted", 30, 0); err != nil {
+		t.Errorf("CheckLimit(30, no limit) = %v, want nil", err)
+	}
+	err := CheckLimit("monitors", "deleted", 4, 3)
+	want := "more than 3 monitors selected, nothing was deleted; raise --max-resources (MAX_RESOURCES) or tighten the filters"
+	if err == nil || err.Error() != want {
+		t.Errorf("CheckLimit(4, 3) = %v, want %q", err, want)
+	}
+}
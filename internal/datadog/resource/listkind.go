@@ -0,0 +1,458 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/templating"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/AD7six/dd-tf/internal/utils"
+	"github.com/spf13/pflag"
+)
+
+// Meta is what a ListKind needs to know about one resource.
+type Meta struct {
+	ID   string
+	Name string
+	Tags []string
+}
+
+// ListKind implements Kind for resources that an endpoint lists in full, so
+// that selecting by tags or --all needs no per-resource requests. Kinds with
+// small, flat payloads (Synthetics private locations, log pipelines, ...)
+// only need to describe their endpoints and fields.
+type ListKind struct {
+	KindName   string // singular name for logs, e.g. "private location"
+	PluralName string // command name, e.g. "private-locations"
+	GroupName  string // parent command, e.g. "synthetics"; empty for a top-level command
+	IDType     IDKind
+	// IDField is the field --update reads IDs from, "id" if empty; e.g.
+	// "name" for log indexes, which have no ID, or "attributes.handle".
+	IDField string
+	// IDPlaceholder is another path placeholder for the ID, e.g.
+	// "{dd-service}" for service definitions.
+	IDPlaceholder string
+	// NamePlaceholder is another path placeholder for the name, e.g.
+	// "{handle}" for users.
+	NamePlaceholder string
+	// SanitizeName makes a name safe for paths. If nil,
+	// storage.SanitizeFilename is used.
+	SanitizeName func(name string) string
+	// Template returns the configured path template.
+	Template func(settings *config.Settings) string
+	// List returns every resource, each as it would be fetched on its own.
+	List func(ctx context.Context, client HTTPClient, settings *config.Settings) ([]json.RawMessage, error)
+	// Get fetches one resource. If nil, resources are picked out of List.
+	Get func(ctx context.Context, client HTTPClient, settings *config.Settings, id string) (json.RawMessage, error)
+	// Summaries marks List as returning only enough of each resource to
+	// select it, e.g. metric names; resources are then fetched with Get.
+	Summaries bool
+	// Decode extracts a resource's ID, name and tags. If nil, DecodeMeta is
+	// used.
+	Decode func(raw json.RawMessage) (Meta, error)
+	// Normalize rewrites a resource before it is written, e.g. to strip
+	// secrets. If nil, resources are written as fetched.
+	Normalize func(raw json.RawMessage, settings *config.Settings) (json.RawMessage, error)
+	// Tagged kinds are checked against REQUIRED_TAGS.
+	Tagged bool
+	// Flags adds kind-specific selection flags, e.g. --include-expired.
+	Flags func(flags *pflag.FlagSet)
+	// Filter returns, given the flags, a predicate for which listed
+	// resources --all, --team and --tags select; --id and --update are not
+	// filtered. If nil, every listed resource is selectable.
+	Filter func(flags *pflag.FlagSet) (func(raw json.RawMessage) bool, error)
+	// SelectFlags are kind flags that select resources on their own, as
+	// --all would with their Filter, e.g. --filter for metrics.
+	SelectFlags []string
+}
+
+func (k *ListKind) Name() string   { return k.KindName }
+func (k *ListKind) Plural() string { return k.PluralName }
+func (k *ListKind) Group() string  { return k.GroupName }
+func (k *ListKind) IDKind() IDKind { return k.IDType }
+
+func (k *ListKind) PathTemplate(settings *config.Settings) string {
+	return k.Template(settings)
+}
+
+func (k *ListKind) Builtins() map[string]string {
+	builtins := map[string]string{
+		"{id}":    "{{.ID}}",
+		"{name}":  "{{.Name}}",
+		"{title}": "{{.Name}}", // Alias for consistency with dashboards
+	}
+	if k.IDPlaceholder != "" {
+		builtins[k.IDPlaceholder] = "{{.ID}}"
+	}
+	if k.NamePlaceholder != "" {
+		builtins[k.NamePlaceholder] = "{{.Name}}"
+	}
+	return builtins
+}
+
+func (k *ListKind) AddFlags(flags *pflag.FlagSet) {
+	if k.Flags != nil {
+		k.Flags(flags)
+	}
+}
+
+// Targets selects resources like dashboards do: --update scans existing
+// files, --all, --id, --team and --tags pick from the list endpoint. Listed
+// resources are cached on their targets, unless List only returns summaries.
+func (k *ListKind) Targets(ctx context.Context, client HTTPClient, settings *config.Settings, opts BaseDownloadOptions, flags *pflag.FlagSet) (<-chan TargetResult[string], error) {
+	if opts.Update {
+		out := make(chan TargetResult[string])
+		go func() {
+			defer close(out)
+			k.updateTargets(ctx, client, settings, out)
+		}()
+		return out, nil
+	}
+
+	var filterTags []string
+	if opts.Team != "" {
+		filterTags = append(filterTags, "team:"+opts.Team)
+	}
+	if opts.Tags != "" {
+		filterTags = append(filterTags, utils.ParseCommaSeparatedIDs(opts.Tags)...)
+	}
+	ids := utils.ParseCommaSeparatedIDs(opts.IDs)
+	if flags == nil {
+		flags = pflag.NewFlagSet(k.KindName, pflag.ContinueOnError)
+		k.AddFlags(flags)
+	}
+	all := opts.All
+	for _, name := range k.SelectFlags {
+		if flags.Changed(name) {
+			all = true
+		}
+	}
+	if !all && len(ids) == 0 && len(filterTags) == 0 {
+		options := "--id, --all, --team, --tags"
+		for _, name := range k.SelectFlags {
+			options += ", --" + name
+		}
+		return nil, fmt.Errorf("please specify %s, or --update", options)
+	}
+	selectable := func(json.RawMessage) bool { return true }
+	if k.Filter != nil {
+		var err error
+		if selectable, err = k.Filter(flags); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make(chan TargetResult[string])
+
+	// --id on its own: fetch each resource at download time
+	if !all && len(filterTags) == 0 && k.Get != nil {
+		go func() {
+			defer close(out)
+			for _, id := range ids {
+				if !Send(ctx, out, TargetResult[string]{Target: Target[string]{ID: id}}) {
+					return
+				}
+			}
+		}()
+		return out, nil
+	}
+
+	go func() {
+		defer close(out)
+		items, err := k.List(ctx, client, settings)
+		if err != nil {
+			Send(ctx, out, TargetResult[string]{Err: fmt.Errorf("failed to list %s: %w", k.PluralName, err)})
+			return
+		}
+		wanted := map[string]bool{}
+		for _, id := range ids {
+			wanted[id] = true
+		}
+		found := 0
+		for _, raw := range items {
+			meta, err := k.decode(raw)
+			if err != nil {
+				logging.Logger.Warn("failed to decode "+k.KindName, "error", err)
+				continue
+			}
+			if !all && len(ids) > 0 && !wanted[meta.ID] {
+				continue
+			}
+			if !wanted[meta.ID] && !selectable(raw) {
+				continue
+			}
+			if !templating.HasAllTagsMap(templating.ExtractTagMap(meta.Tags, false), filterTags) {
+				continue
+			}
+			found++
+			delete(wanted, meta.ID)
+			target := Target[string]{ID: meta.ID}
+			if !k.Summaries {
+				target.Data = raw
+			}
+			if !Send(ctx, out, TargetResult[string]{Target: target}) {
+				return
+			}
+		}
+		if !all {
+			for _, id := range ids {
+				if wanted[id] {
+					if !Send(ctx, out, TargetResult[string]{Err: fmt.Errorf("%s %s: %w", k.KindName, id, ErrNotFound)}) {
+						return
+					}
+				}
+			}
+		}
+		if found == 0 && len(filterTags) > 0 {
+			logging.Logger.Warn("no "+k.PluralName+" found with tags", "tags", filterTags)
+		}
+	}()
+	return out, nil
+}
+
+// updateTargets sends a target for every downloaded resource, keeping its
+// path. Without a Get endpoint, the resources are listed once up front.
+func (k *ListKind) updateTargets(ctx context.Context, client HTTPClient, settings *config.Settings, out chan<- TargetResult[string]) {
+	backend, err := storage.NewBackend(settings)
+	if err != nil {
+		Send(ctx, out, TargetResult[string]{Err: err})
+		return
+	}
+	idToPath, err := k.extractIDs(backend, templating.ExtractStaticPrefix(k.Template(settings)))
+	if err != nil {
+		Send(ctx, out, TargetResult[string]{Err: fmt.Errorf("failed to scan directory: %w", err)})
+		return
+	}
+
+	var listed map[string]json.RawMessage
+	if k.Get == nil && len(idToPath) > 0 {
+		items, err := k.List(ctx, client, settings)
+		if err != nil {
+			Send(ctx, out, TargetResult[string]{Err: fmt.Errorf("failed to list %s: %w", k.PluralName, err)})
+			return
+		}
+		listed = make(map[string]json.RawMessage, len(items))
+		for _, raw := range items {
+			if meta, err := k.decode(raw); err == nil {
+				listed[meta.ID] = raw
+			}
+		}
+	}
+
+	for id, path := range idToPath {
+		result := TargetResult[string]{Target: Target[string]{ID: id, Path: path}}
+		if listed != nil {
+			result.Target.Data = listed[id]
+			if result.Target.Data == nil {
+				result = TargetResult[string]{Err: &TargetError{ID: id, Path: path, Err: fmt.Errorf("%s %w", k.KindName, ErrNotFound)}}
+			}
+		}
+		if !Send(ctx, out, result) {
+			return
+		}
+	}
+}
+
+// extractIDs maps the IDs of the downloaded files under dir to their paths.
+func (k *ListKind) extractIDs(backend storage.Backend, dir string) (map[string]string, error) {
+	field := k.IDField
+	if field == "" {
+		field = "id"
+	}
+	return ExtractLocalIDs(backend, dir, field, k.IDType)
+}
+
+// ExtractLocalIDs maps the IDs read from field in the downloaded files under
+// dir to their paths. Numeric IDs are read from numbers and formatted.
+func ExtractLocalIDs(backend storage.Backend, dir, field string, kind IDKind) (map[string]string, error) {
+	if kind != IDNumeric {
+		return storage.ExtractField(backend, dir, field)
+	}
+	intIDs, err := storage.ExtractIntIDs(backend, dir)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]string, len(intIDs))
+	for id, path := range intIDs {
+		ids[strconv.Itoa(id)] = path
+	}
+	return ids, nil
+}
+
+func (k *ListKind) Fetch(ctx context.Context, client HTTPClient, settings *config.Settings, id string) ([]byte, error) {
+	raw, err := k.fetch(ctx, client, settings, id)
+	if err != nil {
+		return nil, err
+	}
+	raw, err = k.normalize(raw, settings)
+	if err != nil {
+		return nil, err
+	}
+	return storage.FormatJSON(raw)
+}
+
+// Download writes target, using its cached data if any. A tagged resource
+// missing one of REQUIRED_TAGS returns a *UntaggedError, as for dashboards.
+func (k *ListKind) Download(ctx context.Context, client HTTPClient, settings *config.Settings, target Target[string], outputPath string) (string, error) {
+	raw := target.Data
+	if raw == nil {
+		var err error
+		if raw, err = k.fetch(ctx, client, settings, target.ID); err != nil {
+			return "", err
+		}
+	}
+	raw, err := k.normalize(raw, settings)
+	if err != nil {
+		return "", err
+	}
+	meta, err := k.decode(raw)
+	if err != nil {
+		return "", err
+	}
+
+	template := outputPath
+	if template == "" {
+		template = k.Template(settings)
+	}
+	targetPath := target.Path
+	if targetPath == "" {
+		if targetPath, err = k.computePath(template, meta); err != nil {
+			return "", err
+		}
+	}
+
+	var untagged *UntaggedError
+	if k.Tagged {
+		untagged = CheckRequiredTags(settings, meta.Name, templating.ExtractTagMap(meta.Tags, false))
+	}
+	if untagged != nil {
+		if settings.OnMissingRequiredTag != config.OnMissingTagQuarantine {
+			return "", untagged
+		}
+		untagged.Path = QuarantinePath(template, targetPath)
+		targetPath = untagged.Path
+	}
+
+	backend, err := storage.NewBackend(settings)
+	if err != nil {
+		return "", err
+	}
+	if err := storage.WriteRawJSON(backend, targetPath, raw); err != nil {
+		return "", err
+	}
+	if untagged != nil {
+		return targetPath, untagged
+	}
+	return targetPath, nil
+}
+
+func (k *ListKind) ComputePath(settings *config.Settings, raw []byte) (string, []string, error) {
+	meta, err := k.decode(raw)
+	if err != nil {
+		return "", nil, err
+	}
+	template := k.Template(settings)
+	path, err := k.computePath(template, meta)
+	if err != nil {
+		return "", nil, err
+	}
+	missing := templating.MissingTags(template, k.Builtins(), templating.ExtractTagMap(meta.Tags, true))
+	return path, missing, nil
+}
+
+// computePath renders a path template for a resource. {name} falls back to
+// "untitled".
+func (k *ListKind) computePath(template string, meta Meta) (string, error) {
+	name := meta.Name
+	if name == "" {
+		logging.Logger.Warn(k.KindName+" missing name; using placeholder", "id", meta.ID)
+		name = "untitled"
+	}
+	sanitize := k.SanitizeName
+	if sanitize == nil {
+		sanitize = storage.SanitizeFilename
+	}
+	data := struct {
+		ID   string
+		Name string
+		Tags map[string]string
+	}{
+		ID:   meta.ID,
+		Name: sanitize(name),
+		Tags: templating.ExtractTagMap(meta.Tags, true),
+	}
+	return templating.ComputePathFromTemplate(templating.TranslatePlaceholders(template, k.Builtins()), data)
+}
+
+// fetch returns one resource as fetched, from Get or else from List.
+func (k *ListKind) fetch(ctx context.Context, client HTTPClient, settings *config.Settings, id string) (json.RawMessage, error) {
+	if k.Get != nil {
+		return k.Get(ctx, client, settings, id)
+	}
+	items, err := k.List(ctx, client, settings)
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range items {
+		if meta, err := k.decode(raw); err == nil && meta.ID == id {
+			return raw, nil
+		}
+	}
+	return nil, fmt.Errorf("%s %s: %w", k.KindName, id, ErrNotFound)
+}
+
+func (k *ListKind) normalize(raw json.RawMessage, settings *config.Settings) (json.RawMessage, error) {
+	if k.Normalize == nil {
+		return raw, nil
+	}
+	return k.Normalize(raw, settings)
+}
+
+func (k *ListKind) decode(raw json.RawMessage) (Meta, error) {
+	decode := k.Decode
+	if decode == nil {
+		decode = DecodeMeta
+	}
+	meta, err := decode(raw)
+	if err != nil {
+		return Meta{}, fmt.Errorf("failed to decode %s: %w", k.KindName, err)
+	}
+	if meta.ID == "" {
+		return Meta{}, fmt.Errorf("%s missing valid 'id' field", k.KindName)
+	}
+	return meta, nil
+}
+
+// DecodeMeta reads the top-level "id" (a string or a number), "name" and
+// "tags" fields of a resource.
+func DecodeMeta(raw json.RawMessage) (Meta, error) {
+	var fields struct {
+		ID   json.RawMessage `json:"id"`
+		Name string          `json:"name"`
+		Tags []string        `json:"tags"`
+	}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return Meta{}, err
+	}
+	return Meta{ID: FormatRawID(fields.ID), Name: fields.Name, Tags: fields.Tags}, nil
+}
+
+// FormatRawID formats a raw JSON string or number ID, returning "" for
+// anything else.
+func FormatRawID(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return id
+	}
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n.String()
+	}
+	return ""
+}
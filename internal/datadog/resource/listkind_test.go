@@ -0,0 +1,148 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+)
+
+// newTestListKind returns a ListKind over a fixed list, without a Get
+// endpoint, writing to dir/{id}.json.
+func newTestListKind(dir string) *ListKind {
+	return &ListKind{
+		KindName:   "widget",
+		PluralName: "widgets",
+		Template:   func(*config.Settings) string { return filepath.Join(dir, "{id}.json") },
+		List: func(context.Context, HTTPClient, *config.Settings) ([]json.RawMessage, error) {
+			return []json.RawMessage{
+				json.RawMessage(`{"id":"a","name":"A","tags":["team:x"],"secret":"s"}`),
+				json.RawMessage(`{"id":"b","name":"B","tags":["team:y"],"secret":"s"}`),
+			}, nil
+		},
+		Normalize: func(raw json.RawMessage, _ *config.Settings) (json.RawMessage, error) {
+			return StripFields(raw, "secret")
+		},
+	}
+}
+
+// collectTargets drains targets into their IDs and errors.
+func collectTargets(t *testing.T, targets <-chan TargetResult[string]) (ids []string, errs []error) {
+	t.Helper()
+	for result := range targets {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+			continue
+		}
+		if result.Target.Data == nil {
+			t.Errorf("target %s has no cached data", result.Target.ID)
+		}
+		ids = append(ids, result.Target.ID)
+	}
+	sort.Strings(ids)
+	return ids, errs
+}
+
+func TestListKind_Targets(t *testing.T) {
+	k := newTestListKind(t.TempDir())
+	settings := &config.Settings{}
+	tests := []struct {
+		name    string
+		opts    BaseDownloadOptions
+		want    []string
+		wantErr bool
+	}{
+		{name: "all", opts: BaseDownloadOptions{All: true}, want: []string{"a", "b"}},
+		{name: "team", opts: BaseDownloadOptions{Team: "y"}, want: []string{"b"}},
+		{name: "ids", opts: BaseDownloadOptions{IDs: "a"}, want: []string{"a"}},
+		{name: "missing id", opts: BaseDownloadOptions{IDs: "a,c"}, want: []string{"a"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, err := k.Targets(context.Background(), nil, settings, tt.opts, nil)
+			if err != nil {
+				t.Fatalf("Targets() error = %v", err)
+			}
+			ids, errs := collectTargets(t, targets)
+			if len(ids) != len(tt.want) || (len(ids) > 0 && ids[0] != tt.want[0]) {
+				t.Errorf("Targets() = %v, want %v", ids, tt.want)
+			}
+			if (len(errs) > 0) != tt.wantErr {
+				t.Errorf("Targets() errors = %v, want errors: %v", errs, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := k.Targets(context.Background(), nil, settings, BaseDownloadOptions{}, nil); err == nil {
+		t.Error("Targets() without a selection expected an error")
+	}
+}
+
+func TestListKind_UpdateAndDownload(t *testing.T) {
+	dir := t.TempDir()
+	k := newTestListKind(dir)
+	settings := &config.Settings{}
+	if err := os.WriteFile(filepath.Join(dir, "renamed.json"), []byte(`{"id":"a"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "gone.json"), []byte(`{"id":"c"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	targets, err := k.Targets(context.Background(), nil, settings, BaseDownloadOptions{Update: true}, nil)
+	if err != nil {
+		t.Fatalf("Targets() error = %v", err)
+	}
+	var got []Target[string]
+	for result := range targets {
+		if result.Err != nil {
+			if !errors.Is(result.Err, ErrNotFound) {
+				t.Errorf("Targets() error = %v, want not found for the deleted resource", result.Err)
+			}
+			continue
+		}
+		got = append(got, result.Target)
+	}
+	if len(got) != 1 || got[0].ID != "a" {
+		t.Fatalf("Targets(--update) = %+v, want only a", got)
+	}
+
+	path, err := k.Download(context.Background(), nil, settings, got[0], "")
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if path != filepath.Join(dir, "renamed.json") {
+		t.Errorf("Download() path = %q, want the existing file", path)
+	}
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content map[string]any
+	if err := json.Unmarshal(written, &content); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := content["secret"]; ok || content["name"] != "A" {
+		t.Errorf("Download() wrote %s, want the normalized resource", written)
+	}
+}
+
+func TestListKind_ComputePath(t *testing.T) {
+	k := newTestListKind("")
+	k.Template = func(*config.Settings) string { return "data/widgets/{team}/{name}.json" }
+	path, missing, err := k.ComputePath(&config.Settings{}, []byte(`{"id":7,"name":"My widget"}`))
+	if err != nil {
+		t.Fatalf("ComputePath() error = %v", err)
+	}
+	if path != filepath.Join("data", "widgets", "none", "My-widget.json") {
+		t.Errorf("ComputePath() = %q", path)
+	}
+	if len(missing) != 1 || missing[0] != "team" {
+		t.Errorf("ComputePath() missing = %v, want [team]", missing)
+	}
+}
@@ -0,0 +1,76 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// stripTree is a set of dot-paths, split into a tree of keys.
+type stripTree map[string]*stripNode
+
+type stripNode struct {
+	strip    bool      // Remove the key itself
+	children stripTree // Keys to remove inside its value
+}
+
+// StripPaths removes the fields at dot-separated paths from a raw JSON
+// object: "url" is a top-level key and "options.silenced" the silenced key
+// of the options object. A path through an array applies to each element,
+// so "widgets.id" removes the id of every widget. Missing paths are ignored.
+// Like StripFields, the remaining keys keep their order and formatting.
+//
+// Resources are normalized with it when downloaded, compared and pushed, so
+// the same fields are missing from what is stored, compared and sent.
+func StripPaths(raw []byte, paths ...string) ([]byte, error) {
+	tree := stripTree{}
+	for _, path := range paths {
+		tree.add(strings.Split(path, "."))
+	}
+	if len(tree) == 0 {
+		return raw, nil
+	}
+	return tree.rewriteObject(raw)
+}
+
+func (t stripTree) add(keys []string) {
+	node := t[keys[0]]
+	if node == nil {
+		node = &stripNode{}
+		t[keys[0]] = node
+	}
+	if len(keys) == 1 {
+		node.strip = true
+		return
+	}
+	if node.children == nil {
+		node.children = stripTree{}
+	}
+	node.children.add(keys[1:])
+}
+
+// rewrite removes t's paths from raw if it is an object or an array of them.
+// Other values are returned unchanged.
+func (t stripTree) rewrite(raw json.RawMessage) (json.RawMessage, error) {
+	switch trimmed := bytes.TrimSpace(raw); {
+	case len(trimmed) > 0 && trimmed[0] == '[':
+		return RewriteArray(raw, t.rewrite)
+	case len(trimmed) > 0 && trimmed[0] == '{':
+		return t.rewriteObject(raw)
+	}
+	return raw, nil
+}
+
+func (t stripTree) rewriteObject(raw []byte) ([]byte, error) {
+	return RewriteObject(raw, func(key string, value json.RawMessage) (json.RawMessage, bool, error) {
+		node := t[key]
+		switch {
+		case node == nil:
+			return value, true, nil
+		case node.strip:
+			return nil, false, nil
+		}
+		value, err := node.children.rewrite(value)
+		return value, true, err
+	})
+}
@@ -0,0 +1,63 @@
+package resource
+
+import "testing"
+
+func TestStripPaths(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		paths []string
+		want  string
+	}{
+		{
+			name: "no paths returns input",
+			raw:  `{"b":1, "a":2}`,
+			want: `{"b":1, "a":2}`,
+		},
+		{
+			name:  "top-level keys",
+			raw:   `{"id":1,"url":"/x","created_at":"t","title":"a"}`,
+			paths: []string{"url", "created_at"},
+			want:  `{"id":1,"title":"a"}`,
+		},
+		{
+			name:  "nested key",
+			raw:   `{"options":{"silenced":{},"thresholds":{"critical":1}},"silenced":true}`,
+			paths: []string{"options.silenced"},
+			want:  `{"options":{"thresholds":{"critical":1}},"silenced":true}`,
+		},
+		{
+			name:  "through arrays",
+			raw:   `{"widgets":[{"id":1,"definition":{"type":"note"}},{"id":2}]}`,
+			paths: []string{"widgets.id"},
+			want:  `{"widgets":[{"definition":{"type":"note"}},{}]}`,
+		},
+		{
+			name:  "key and a path under it",
+			raw:   `{"options":{"a":1},"b":2}`,
+			paths: []string{"options.a", "options"},
+			want:  `{"b":2}`,
+		},
+		{
+			name:  "missing paths and scalars are left alone",
+			raw:   `{"options":5,"tags":["a"]}`,
+			paths: []string{"options.a", "tags.x", "nope"},
+			want:  `{"options":5,"tags":["a"]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StripPaths([]byte(tt.raw), tt.paths...)
+			if err != nil {
+				t.Fatalf("StripPaths() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("StripPaths() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := StripPaths([]byte(`[1]`), "a"); err == nil {
+		t.Error("StripPaths() of an array: expected an error")
+	}
+}
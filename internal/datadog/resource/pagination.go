@@ -1,6 +1,19 @@
 package resource
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// pageSizeErrorRegex matches API error bodies complaining about the page size
+	pageSizeErrorRegex = regexp.MustCompile(`(?i)page[ _\[]?size|\bcount\b`)
+)
 
 // PaginationParams holds pagination state for API requests.
 type PaginationParams struct {
@@ -8,9 +21,13 @@ type PaginationParams struct {
 	Start int
 	Count int
 
-	// For page-based pagination (monitors)
+	// For page-based pagination (monitors, service definitions)
 	Page     int
 	PageSize int
+
+	// shrunk records that the page size was already halved after a 400, so
+	// it is only retried once
+	shrunk bool
 }
 
 // NewOffsetPagination creates pagination params for offset-based APIs (start/count).
@@ -58,3 +75,56 @@ func (p *PaginationParams) FormatOffsetURL(baseURL string) string {
 func (p *PaginationParams) FormatPageURL(baseURL string) string {
 	return fmt.Sprintf("%s?page=%d&page_size=%d", baseURL, p.Page, p.PageSize)
 }
+
+// FormatJSONAPIPageURL formats a URL with the page[number]/page[size]
+// pagination parameters of JSON:API endpoints, e.g. service definitions.
+func (p *PaginationParams) FormatJSONAPIPageURL(baseURL string) string {
+	query := url.Values{}
+	query.Set("page[number]", strconv.Itoa(p.Page))
+	query.Set("page[size]", strconv.Itoa(p.PageSize))
+	sep := "?"
+	if strings.Contains(baseURL, "?") {
+		sep = "&"
+	}
+	return baseURL + sep + query.Encode()
+}
+
+// Size returns the current page size, whichever pagination style is in use.
+func (p *PaginationParams) Size() int {
+	if p.Count > 0 {
+		return p.Count
+	}
+	return p.PageSize
+}
+
+// ShrinkAfterError halves the page size when err is a 400 API error that
+// mentions the page size, returning true if the request should be retried.
+// The page size is only ever halved once. For page-based pagination the page
+// number is recomputed so the next page still starts at the first item not
+// fetched yet; when half the size doesn't divide that offset, as with odd
+// sizes, the largest smaller size that does is used.
+func (p *PaginationParams) ShrinkAfterError(err error) bool {
+	if p.shrunk || p.Size() <= 1 {
+		return false
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	if !pageSizeErrorRegex.MatchString(apiErr.Body) {
+		return false
+	}
+
+	p.shrunk = true
+	if p.Count > 0 {
+		p.Count /= 2
+	} else {
+		offset := p.Page * p.PageSize
+		size := p.PageSize / 2
+		for offset%size != 0 {
+			size--
+		}
+		p.PageSize, p.Page = size, offset/size
+	}
+	return true
+}
@@ -1,6 +1,10 @@
 package resource
 
-import "testing"
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
 
 func TestOffsetPagination(t *testing.T) {
 	p := NewOffsetPagination(100)
@@ -41,3 +45,80 @@ func TestPagePagination(t *testing.T) {
 		t.Fatalf("expected no more pages when itemsReceived < page size")
 	}
 }
+
+func TestJSONAPIPagePagination(t *testing.T) {
+	p := NewPagePagination(100)
+	if got, want := p.FormatJSONAPIPageURL("https://api.example.com/api/v2/services/definitions"), "https://api.example.com/api/v2/services/definitions?page%5Bnumber%5D=0&page%5Bsize%5D=100"; got != want {
+		t.Fatalf("unexpected url: %s, want %s", got, want)
+	}
+	if !p.NextPage(100) {
+		t.Fatalf("expected more pages when itemsReceived equals page size")
+	}
+	if got, want := p.FormatJSONAPIPageURL("https://api.example.com/items?schema_version=v2.2"), "https://api.example.com/items?schema_version=v2.2&page%5Bnumber%5D=1&page%5Bsize%5D=100"; got != want {
+		t.Fatalf("unexpected url: %s, want %s", got, want)
+	}
+}
+
+func TestShrinkAfterError(t *testing.T) {
+	pageSizeErr := &APIError{StatusCode: http.StatusBadRequest, Status: "400 Bad Request", Body: `{"errors":["page_size must be <= 500"]}`}
+
+	t.Run("halves page size once for page-based pagination", func(t *testing.T) {
+		p := NewPagePagination(1000)
+		p.Page = 3
+		if !p.ShrinkAfterError(fmt.Errorf("monitors page 3: %w", pageSizeErr)) {
+			t.Fatalf("expected retry after page size error")
+		}
+		if p.PageSize != 500 || p.Page != 6 {
+			t.Fatalf("unexpected state after shrink: page=%d pageSize=%d", p.Page, p.PageSize)
+		}
+		if p.ShrinkAfterError(pageSizeErr) {
+			t.Fatalf("expected page size to be halved only once")
+		}
+	})
+
+	t.Run("keeps the item offset for odd page sizes", func(t *testing.T) {
+		for _, tt := range []struct{ size, page, wantSize, wantPage int }{
+			{5, 0, 2, 0},
+			{5, 2, 2, 5},
+			{5, 3, 1, 15},
+			{9, 2, 3, 6},
+		} {
+			p := NewPagePagination(tt.size)
+			p.Page = tt.page
+			if !p.ShrinkAfterError(pageSizeErr) {
+				t.Fatalf("expected retry after page size error")
+			}
+			if p.PageSize != tt.wantSize || p.Page != tt.wantPage || p.Page*p.PageSize != tt.page*tt.size {
+				t.Errorf("shrinking page %d of %d = page %d of %d, want page %d of %d", tt.page, tt.size, p.Page, p.PageSize, tt.wantPage, tt.wantSize)
+			}
+		}
+	})
+
+	t.Run("halves count for offset pagination", func(t *testing.T) {
+		p := NewOffsetPagination(100)
+		err := &APIError{StatusCode: http.StatusBadRequest, Status: "400 Bad Request", Body: "invalid count"}
+		if !p.ShrinkAfterError(err) {
+			t.Fatalf("expected retry after count error")
+		}
+		if p.Count != 50 || p.Size() != 50 {
+			t.Fatalf("expected count to be halved, got %d", p.Count)
+		}
+	})
+
+	t.Run("ignores unrelated errors", func(t *testing.T) {
+		cases := []error{
+			fmt.Errorf("connection refused"),
+			&APIError{StatusCode: http.StatusBadRequest, Status: "400 Bad Request", Body: "invalid query"},
+			&APIError{StatusCode: http.StatusForbidden, Status: "403 Forbidden", Body: "page_size"},
+		}
+		for _, err := range cases {
+			p := NewPagePagination(1000)
+			if p.ShrinkAfterError(err) {
+				t.Errorf("ShrinkAfterError(%v) = true, want false", err)
+			}
+			if p.PageSize != 1000 {
+				t.Errorf("page size changed for %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,521 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/diff"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/AD7six/dd-tf/internal/utils"
+)
+
+// DefaultPushWorkers is how many resources are pushed at once. It is lower
+// than defaultWorkers: writes are rate limited more tightly than reads.
+const DefaultPushWorkers = 4
+
+// WriteClient is an HTTPClient that can also send resources to the API, and
+// delete them.
+type WriteClient interface {
+	HTTPClient
+	Put(url string, body io.Reader) (*http.Response, error)
+	PutWithContext(ctx context.Context, url string, body io.Reader) (*http.Response, error)
+	Post(url string, body io.Reader) (*http.Response, error)
+	PostWithContext(ctx context.Context, url string, body io.Reader) (*http.Response, error)
+	Delete(url string) (*http.Response, error)
+	DeleteWithContext(ctx context.Context, url string) (*http.Response, error)
+}
+
+// PutToAPI sends body to url with a PUT and returns the response body.
+// Errors are reported as for FetchRawFromAPI.
+func PutToAPI(ctx context.Context, client WriteClient, url string, body []byte, settings *config.Settings) ([]byte, error) {
+	return sendToAPI(settings, func() (*http.Response, error) {
+		return client.PutWithContext(ctx, url, bytes.NewReader(body))
+	})
+}
+
+// PostToAPI sends body to url with a POST and returns the response body.
+// Errors are reported as for FetchRawFromAPI.
+func PostToAPI(ctx context.Context, client WriteClient, url string, body []byte, settings *config.Settings) ([]byte, error) {
+	return sendToAPI(settings, func() (*http.Response, error) {
+		return client.PostWithContext(ctx, url, bytes.NewReader(body))
+	})
+}
+
+// DeleteFromAPI sends a DELETE to url and returns the response body.
+// Errors are reported as for FetchRawFromAPI.
+func DeleteFromAPI(ctx context.Context, client WriteClient, url string, settings *config.Settings) ([]byte, error) {
+	return sendToAPI(settings, func() (*http.Response, error) {
+		return client.DeleteWithContext(ctx, url)
+	})
+}
+
+// sendToAPI checks the response of send and returns its body.
+func sendToAPI(settings *config.Settings, send func() (*http.Response, error)) ([]byte, error) {
+	resp, err := send()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := CheckResponse(resp, settings); err != nil {
+		return nil, err
+	}
+	return readBody(resp.Body, settings.HTTPMaxBodySize)
+}
+
+// PushAction is what pushing a resource did, or would do with --dry-run.
+type PushAction string
+
+const (
+	PushCreated   PushAction = "created"
+	PushUpdated   PushAction = "updated"
+	PushUnchanged PushAction = "unchanged"
+)
+
+// PushFlags change how each resource is pushed.
+type PushFlags struct {
+	DryRun    bool // Compare with the remote resource instead of sending anything
+	NoCreate  bool // Fail instead of creating resources that don't exist remotely
+	Force     bool // Send resources without comparing them with the remote ones first
+	Overwrite bool // Push resources even if they changed remotely since they were downloaded
+
+	ManagedTag string // Tag added to every resource pushed, if not empty
+}
+
+// PushRequest describes how to push one local resource.
+type PushRequest struct {
+	Path           string          // The local file
+	Backend        storage.Backend // Where Path is stored, to write back the id and modified time
+	Endpoint       string          // The remote resource, to GET and PUT
+	CreateEndpoint string          // Where to POST the resource if Endpoint doesn't exist; empty if it can't be created
+	Body           []byte          // What is sent
+	Ignore         []string        // Top-level fields the API sets itself, ignored when comparing and not sent when creating
+	URL            string          // The resource in the Datadog app
+	ModifiedField  string          // Top-level field holding the resource's last modification time, e.g. "modified"
+	Modified       string          // The ModifiedField value in the local file, as downloaded; empty to skip conflict checks
+
+	// Normalize, if set, is applied to Body before it is sent and to the
+	// remote resource before they are compared, as it is on download
+	Normalize func(json.RawMessage) (json.RawMessage, error)
+}
+
+// PushResult is the outcome of pushing one resource.
+type PushResult struct {
+	Action   PushAction
+	URL      string          // The resource in the Datadog app
+	Diff     string          // The unified diff from the remote resource to the local one, unless Force
+	Response []byte          // The API's response, unless DryRun
+	NewID    json.RawMessage // The ID the API gave a created resource, unless DryRun
+}
+
+// ConflictError is returned when a resource changed remotely since it was
+// downloaded, so pushing it would overwrite someone else's change.
+type ConflictError struct {
+	Downloaded string // The modification time in the local file
+	Remote     string // The remote resource's modification time
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("remote modified since download (downloaded at %s, modified at %s); use --overwrite to push anyway", e.Downloaded, e.Remote)
+}
+
+// PushResource sends req.Body to req.Endpoint with a PUT, or creates the
+// resource with a POST to req.CreateEndpoint if it doesn't exist. The remote
+// resource is fetched first and diffed with req.Body, both normalized with
+// req.Normalize and diff.Normalize, and nothing is sent if they are equal;
+// Force skips the comparison. A resource modified remotely since
+// req.Modified returns a *ConflictError, unless Overwrite is set. With
+// DryRun only the comparison is made. A ManagedTag is added to req.Body's
+// tags once it is normalized, before anything else.
+//
+// After a push, the id and ModifiedField of the local file are updated from
+// the API's response, so the next push updates the same resource and doesn't
+// see its own change as a conflict.
+func PushResource(ctx context.Context, client WriteClient, settings *config.Settings, req PushRequest, flags PushFlags) (PushResult, error) {
+	normalize := req.Normalize
+	if normalize == nil {
+		normalize = func(raw json.RawMessage) (json.RawMessage, error) { return raw, nil }
+	}
+	body, err := normalize(req.Body)
+	if err != nil {
+		return PushResult{}, err
+	}
+	req.Body = body
+	if flags.ManagedTag != "" {
+		body, err := EnsureTag(req.Body, flags.ManagedTag)
+		if err != nil {
+			return PushResult{}, err
+		}
+		req.Body = body
+	}
+	// Rejected before any API call
+	if err := CheckBodyTags(settings, req.Body); err != nil {
+		return PushResult{}, err
+	}
+	checkConflict := req.Modified != "" && !flags.Overwrite
+	if flags.Force && !flags.DryRun && !checkConflict {
+		resp, err := PutToAPI(ctx, client, req.Endpoint, req.Body, settings)
+		if errors.Is(err, ErrNotFound) {
+			return createResource(ctx, client, settings, req, flags, err)
+		}
+		if err != nil {
+			return PushResult{}, err
+		}
+		return PushResult{Action: PushUpdated, URL: req.URL, Response: resp}, updateLocal(req, resp)
+	}
+
+	remote, err := FetchRawFromAPI(ctx, client, req.Endpoint, settings)
+	notFound := errors.Is(err, ErrNotFound)
+	from := "remote " + req.Path
+	if notFound {
+		if err := checkCreate(req, flags, err); err != nil {
+			return PushResult{}, err
+		}
+		remote, err, from = nil, nil, "/dev/null"
+	}
+	if err != nil {
+		return PushResult{}, fmt.Errorf("failed to fetch remote: %w", err)
+	}
+	compared := remote
+	if !notFound {
+		if compared, err = normalize(remote); err != nil {
+			return PushResult{}, fmt.Errorf("failed to normalize remote: %w", err)
+		}
+	}
+	changes, err := diff.JSON(from, req.Path, compared, req.Body, req.Ignore...)
+	if err != nil {
+		return PushResult{}, err
+	}
+	if current := StringField(remote, req.ModifiedField); checkConflict && !notFound && changes != "" && current != req.Modified {
+		return PushResult{}, &ConflictError{Downloaded: req.Modified, Remote: current}
+	}
+
+	switch {
+	case flags.DryRun && changes == "":
+		return PushResult{Action: PushUnchanged, URL: req.URL}, nil
+	case changes == "" && !flags.Force:
+		return PushResult{Action: PushUnchanged, URL: req.URL}, updateLocal(req, remote)
+	case flags.DryRun && notFound:
+		return PushResult{Action: PushCreated, URL: req.URL, Diff: changes}, nil
+	case flags.DryRun:
+		return PushResult{Action: PushUpdated, URL: req.URL, Diff: changes}, nil
+	case notFound:
+		return createResource(ctx, client, settings, req, flags, nil)
+	}
+	resp, err := PutToAPI(ctx, client, req.Endpoint, req.Body, settings)
+	if err != nil {
+		return PushResult{}, err
+	}
+	return PushResult{Action: PushUpdated, URL: req.URL, Diff: changes, Response: resp}, updateLocal(req, resp)
+}
+
+// checkCreate returns notFound, explained, if req can't be created.
+func checkCreate(req PushRequest, flags PushFlags, notFound error) error {
+	if req.CreateEndpoint == "" {
+		return notFound
+	}
+	if flags.NoCreate {
+		return fmt.Errorf("not creating it with --no-create: %w", notFound)
+	}
+	return nil
+}
+
+// createResource POSTs req.Body, without req.Ignore fields, to
+// req.CreateEndpoint, after the resource was found missing with notFound,
+// or nil if that was already checked. The local file gets the new id.
+func createResource(ctx context.Context, client WriteClient, settings *config.Settings, req PushRequest, flags PushFlags, notFound error) (PushResult, error) {
+	if notFound != nil {
+		if err := checkCreate(req, flags, notFound); err != nil {
+			return PushResult{}, err
+		}
+	}
+	body, err := StripFields(req.Body, req.Ignore...)
+	if err != nil {
+		return PushResult{}, err
+	}
+	resp, err := PostToAPI(ctx, client, req.CreateEndpoint, body, settings)
+	if err != nil {
+		return PushResult{}, fmt.Errorf("failed to create: %w", err)
+	}
+	var created struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(resp, &created); err != nil || FormatRawID(created.ID) == "" {
+		return PushResult{}, fmt.Errorf("created, but the response has no id")
+	}
+	result := PushResult{Action: PushCreated, Response: resp, NewID: created.ID}
+	if err := updateLocal(req, resp, "id"); err != nil {
+		return PushResult{}, fmt.Errorf("created %s, but %w", FormatRawID(created.ID), err)
+	}
+	return result, nil
+}
+
+// updateLocal copies fields, and req.ModifiedField if the local file has
+// it, from the remote resource into the local file, leaving the rest of the
+// document as it was. Nothing is written if they already match.
+func updateLocal(req PushRequest, remote []byte, fields ...string) error {
+	if req.Modified != "" {
+		fields = append(fields, req.ModifiedField)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(remote, &values); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	raw, err := req.Backend.Read(req.Path)
+	if err != nil {
+		return fmt.Errorf("failed to update the local file: %w", err)
+	}
+	updated := raw
+	for _, field := range fields {
+		if value, ok := values[field]; ok {
+			if updated, err = SetField(updated, field, value); err != nil {
+				return fmt.Errorf("failed to update the local file: %w", err)
+			}
+		}
+	}
+	if bytes.Equal(updated, raw) {
+		return nil
+	}
+	if err := storage.WriteRawJSON(req.Backend, req.Path, updated); err != nil {
+		return fmt.Errorf("failed to update the local file: %w", err)
+	}
+	return nil
+}
+
+// PushOptions select the downloaded files to push.
+type PushOptions struct {
+	Paths []string // Files, or directories to push every file under
+	IDs   string   // Comma-separated IDs, looked up under the kind's directory
+	All   bool     // Every file under the kind's directory
+}
+
+// PushTargets sends a target for each downloaded file selected by opts,
+// with the ID read from the file. dir is where --id and --all look, the
+// static prefix of the kind's path template.
+func PushTargets(ctx context.Context, backend storage.Backend, dir string, kind IDKind, opts PushOptions) (<-chan TargetResult[string], error) {
+	ids := utils.ParseCommaSeparatedIDs(opts.IDs)
+	if !opts.All && len(ids) == 0 && len(opts.Paths) == 0 {
+		return nil, fmt.Errorf("please specify --path, --id or --all")
+	}
+
+	out := make(chan TargetResult[string])
+	go func() {
+		defer close(out)
+		selected := map[string]string{} // path -> id
+		if opts.All || len(ids) > 0 {
+			local, err := ExtractLocalIDs(backend, dir, "id", kind)
+			if err != nil {
+				Send(ctx, out, TargetResult[string]{Err: fmt.Errorf("failed to scan directory: %w", err)})
+				return
+			}
+			for id, path := range local {
+				if opts.All {
+					selected[path] = id
+				}
+			}
+			for _, id := range ids {
+				path, ok := local[id]
+				if !ok {
+					if !Send(ctx, out, TargetResult[string]{Err: &TargetError{ID: id, Err: fmt.Errorf("no downloaded file under %s: %w", dir, ErrNotFound)}}) {
+						return
+					}
+					continue
+				}
+				selected[path] = id
+			}
+		}
+		for _, path := range opts.Paths {
+			local, err := ExtractLocalIDs(backend, path, "id", kind)
+			if err != nil {
+				if !Send(ctx, out, TargetResult[string]{Err: fmt.Errorf("%s: %w", path, err)}) {
+					return
+				}
+				continue
+			}
+			if len(local) == 0 {
+				if !Send(ctx, out, TargetResult[string]{Err: fmt.Errorf("%s: no file with a valid id", path)}) {
+					return
+				}
+				continue
+			}
+			for id, p := range local {
+				selected[p] = id
+			}
+		}
+
+		targets := make(map[string]string, len(selected))
+		paths := make([]string, 0, len(selected))
+		for path, id := range selected {
+			// A split dashboard's skeleton has no widgets: push the whole
+			// directory, or its widgets are deleted remotely.
+			if filepath.Base(path) == storage.SplitSkeletonName && storage.IsSplitDir(backend, filepath.Dir(path)) {
+				path = filepath.Dir(path)
+			}
+			targets[path] = id
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			if !Send(ctx, out, TargetResult[string]{Target: Target[string]{ID: targets[path], Path: path}}) {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Pusher consumes a stream of downloaded files and pushes each one with a
+// bounded pool of workers, as Downloader does for downloads.
+type Pusher struct {
+	Kind    string                                                               // Resource name for log messages, e.g. "dashboard"
+	Push    func(ctx context.Context, target Target[string]) (PushResult, error) // Pushes one file
+	Workers int                                                                  // Concurrent pushes, defaults to DefaultPushWorkers
+	Quiet   bool                                                                 // Don't log each target, only failures
+	DryRun  bool                                                                 // Log what would be done, as Push only compares
+}
+
+// Pushed is a file that was pushed, or compared with --dry-run.
+type Pushed struct {
+	ID   string
+	Path string
+	PushResult
+}
+
+// PushSummary is the outcome of Pusher.Run.
+type PushSummary struct {
+	Total     int      // Targets received (excluding target generation errors)
+	Pushed    []Pushed // Files pushed, unchanged ones included, in completion order
+	Errors    []error  // *TargetError for failed targets, or target generation errors
+	FailedIDs []string // IDs of failed targets
+}
+
+// Count returns the number of files pushed with action.
+func (s PushSummary) Count(action PushAction) int {
+	n := 0
+	for _, p := range s.Pushed {
+		if p.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Conflicts returns the errors for resources modified remotely since they
+// were downloaded.
+func (s PushSummary) Conflicts() []error {
+	var conflicts []error
+	for _, err := range s.Errors {
+		if IsConflict(err) {
+			conflicts = append(conflicts, err)
+		}
+	}
+	return conflicts
+}
+
+// IsConflict reports whether err is, or wraps, a *ConflictError.
+func IsConflict(err error) bool {
+	var conflict *ConflictError
+	return errors.As(err, &conflict)
+}
+
+// Failed returns the number of errors, conflicts and target generation
+// errors included.
+func (s PushSummary) Failed() int {
+	return len(s.Errors)
+}
+
+// Run pushes every target from targets until the channel closes or ctx is
+// done, then waits for in-flight pushes. Failures are logged as they happen
+// and returned in the PushSummary.
+func (p *Pusher) Run(ctx context.Context, targets <-chan TargetResult[string]) PushSummary {
+	workers := p.Workers
+	if workers <= 0 {
+		workers = DefaultPushWorkers
+	}
+
+	var (
+		summary PushSummary
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+	)
+	fail := func(err error) {
+		attrs := []any{"error", err}
+		var targetErr *TargetError
+		ok := errors.As(err, &targetErr)
+		if ok && targetErr.Path != "" {
+			attrs = append(attrs, "path", targetErr.Path)
+		}
+		msg := "push failed"
+		if IsConflict(err) {
+			msg = "push conflict"
+		}
+		logging.Logger.Error(msg, attrs...)
+
+		mu.Lock()
+		defer mu.Unlock()
+		summary.Errors = append(summary.Errors, err)
+		if ok {
+			summary.FailedIDs = append(summary.FailedIDs, targetErr.ID)
+		}
+	}
+
+	work := make(chan Target[string])
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for target := range work {
+				result, err := p.Push(ctx, target)
+				if err != nil {
+					fail(&TargetError{ID: target.ID, Path: target.Path, Err: err})
+					continue
+				}
+				if !p.Quiet {
+					msg := p.Kind + " " + string(result.Action)
+					if p.DryRun && result.Action != PushUnchanged {
+						msg = p.Kind + " would be " + string(result.Action)
+					}
+					logging.Logger.Info(msg, "path", target.Path, "url", result.URL)
+				}
+				mu.Lock()
+				summary.Pushed = append(summary.Pushed, Pushed{ID: target.ID, Path: target.Path, PushResult: result})
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for result := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		if result.Err != nil {
+			fail(result.Err)
+			continue
+		}
+		summary.Total++
+		if !p.Quiet {
+			logging.Logger.Info("pushing "+p.Kind, "id", result.Target.ID, "path", result.Target.Path)
+		}
+		select {
+		case work <- result.Target:
+		case <-ctx.Done():
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	return summary
+}
@@ -0,0 +1,195 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+// writeLocalFiles writes name -> content files under dir.
+func writeLocalFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPushTargets(t *testing.T) {
+	dir := t.TempDir()
+	writeLocalFiles(t, dir, map[string]string{
+		"1.json":             `{"id":1}`,
+		"team/2.json":        `{"id":2}`,
+		"team/2.policy.json": `{"id":"monitor:2"}`,
+	})
+	other := filepath.Join(t.TempDir(), "3.json")
+	writeLocalFiles(t, filepath.Dir(other), map[string]string{"3.json": `{"id":3}`})
+
+	tests := []struct {
+		name    string
+		opts    PushOptions
+		want    []string
+		wantErr error
+	}{
+		{name: "all", opts: PushOptions{All: true}, want: []string{"1", "2"}},
+		{name: "id", opts: PushOptions{IDs: "2"}, want: []string{"2"}},
+		{name: "missing id", opts: PushOptions{IDs: "2,9"}, want: []string{"2"}, wantErr: ErrNotFound},
+		{name: "path outside dir", opts: PushOptions{Paths: []string{other}}, want: []string{"3"}},
+		{name: "directory", opts: PushOptions{Paths: []string{filepath.Join(dir, "team")}}, want: []string{"2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, err := PushTargets(context.Background(), storage.FileBackend{}, dir, IDNumeric, tt.opts)
+			if err != nil {
+				t.Fatalf("PushTargets() error = %v", err)
+			}
+			var ids []string
+			var errs []error
+			for result := range targets {
+				if result.Err != nil {
+					errs = append(errs, result.Err)
+					continue
+				}
+				ids = append(ids, result.Target.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("PushTargets() IDs = %v, want %v", ids, tt.want)
+			}
+			if tt.wantErr == nil && len(errs) > 0 {
+				t.Errorf("PushTargets() errors = %v", errs)
+			}
+			if tt.wantErr != nil && (len(errs) != 1 || !errors.Is(errs[0], tt.wantErr)) {
+				t.Errorf("PushTargets() errors = %v, want %v", errs, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := PushTargets(context.Background(), storage.FileBackend{}, dir, IDNumeric, PushOptions{}); err == nil {
+		t.Error("PushTargets() without a selection should fail")
+	}
+}
+
+func TestPusher_Run(t *testing.T) {
+	targets := make(chan TargetResult[string], 3)
+	targets <- TargetResult[string]{Target: Target[string]{ID: "1", Path: "1.json"}}
+	targets <- TargetResult[string]{Target: Target[string]{ID: "2", Path: "2.json"}}
+	targets <- TargetResult[string]{Err: errors.New("scan failed")}
+	close(targets)
+
+	pusher := Pusher{Kind: "monitor", Quiet: true, Push: func(_ context.Context, target Target[string]) (PushResult, error) {
+		if target.ID == "2" {
+			return PushResult{}, errors.New("bad request")
+		}
+		return PushResult{Action: PushUpdated, URL: "https://app.example.com/monitors/" + target.ID}, nil
+	}}
+	summary := pusher.Run(context.Background(), targets)
+
+	if summary.Total != 2 || summary.Failed() != 2 {
+		t.Errorf("Run() total = %d, failed = %d, want 2 and 2", summary.Total, summary.Failed())
+	}
+	if len(summary.Pushed) != 1 || summary.Pushed[0].URL != "https://app.example.com/monitors/1" {
+		t.Errorf("Run() pushed = %+v", summary.Pushed)
+	}
+	if summary.Count(PushUpdated) != 1 || summary.Count(PushCreated) != 0 {
+		t.Errorf("Run() updated = %d, created = %d, want 1 and 0", summary.Count(PushUpdated), summary.Count(PushCreated))
+	}
+	if !reflect.DeepEqual(summary.FailedIDs, []string{"2"}) {
+		t.Errorf("Run() failed IDs = %v, want [2]", summary.FailedIDs)
+	}
+}
+
+func TestPusher_RunConflicts(t *testing.T) {
+	targets := make(chan TargetResult[string], 2)
+	targets <- TargetResult[string]{Target: Target[string]{ID: "1", Path: "1.json"}}
+	targets <- TargetResult[string]{Target: Target[string]{ID: "2", Path: "2.json"}}
+	close(targets)
+
+	pusher := Pusher{Kind: "monitor", Quiet: true, Push: func(_ context.Context, target Target[string]) (PushResult, error) {
+		if target.ID == "2" {
+			return PushResult{}, &ConflictError{Downloaded: "t1", Remote: "t2"}
+		}
+		return PushResult{}, errors.New("bad request")
+	}}
+	summary := pusher.Run(context.Background(), targets)
+
+	if summary.Failed() != 2 || len(summary.Conflicts()) != 1 {
+		t.Fatalf("Run() failed = %d, conflicts = %d, want 2 and 1", summary.Failed(), len(summary.Conflicts()))
+	}
+	var targetErr *TargetError
+	if !errors.As(summary.Conflicts()[0], &targetErr) || targetErr.ID != "2" {
+		t.Errorf("Run() conflict = %v, want monitor 2", summary.Conflicts()[0])
+	}
+}
+
+func TestPushResource_DryRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("dry run sent %s %s", r.Method, r.URL.Path)
+		}
+		switch r.URL.Path {
+		case "/api/v1/monitor/1":
+			w.Write([]byte(`{"id":1,"modified":"2024-01-01","name":"cpu","tags":["b","a"]}`))
+		case "/api/v1/monitor/2":
+			w.Write([]byte(`{"id":2,"name":"disk"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 1024}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+	request := func(id, body string) PushRequest {
+		return PushRequest{
+			Path:           id + ".json",
+			Endpoint:       server.URL + "/api/v1/monitor/" + id,
+			CreateEndpoint: server.URL + "/api/v1/monitor",
+			Body:           []byte(body),
+			Ignore:         []string{"id", "modified"},
+		}
+	}
+	push := func(id, body string) PushResult {
+		t.Helper()
+		result, err := PushResource(context.Background(), client, settings, request(id, body), PushFlags{DryRun: true})
+		if err != nil {
+			t.Fatalf("PushResource(%s) error = %v", id, err)
+		}
+		return result
+	}
+
+	if got := push("1", `{"name":"cpu","tags":["a","b"]}`); got.Action != PushUnchanged || got.Diff != "" {
+		t.Errorf("PushResource() = %+v, want unchanged", got)
+	}
+	got := push("2", `{"name":"disk full"}`)
+	if got.Action != PushUpdated || !strings.Contains(got.Diff, `-  "name": "disk"`+"\n"+`+  "name": "disk full"`) {
+		t.Errorf("PushResource() = %+v, want an update diff", got)
+	}
+	if got := push("3", `{"name":"new"}`); got.Action != PushCreated || !strings.HasPrefix(got.Diff, "--- /dev/null\n+++ 3.json\n") {
+		t.Errorf("PushResource() = %+v, want a create diff", got)
+	}
+	if _, err := PushResource(context.Background(), client, settings, request("3", `{}`), PushFlags{DryRun: true, NoCreate: true}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("PushResource() with NoCreate error = %v, want not found", err)
+	}
+
+	// A file missing a required tag is rejected before any request
+	server.Close()
+	settings.RequiredTags = []string{"team"}
+	var untagged *UntaggedError
+	if _, err := PushResource(context.Background(), client, settings, request("1", `{"name":"cpu"}`), PushFlags{}); !errors.As(err, &untagged) {
+		t.Errorf("PushResource() without a required tag error = %v, want untagged", err)
+	}
+}
@@ -0,0 +1,186 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// StripFields removes the named top-level keys from a raw JSON object.
+// The remaining keys and their values are copied through byte-for-byte so the
+// original key order and formatting of nested values is preserved.
+func StripFields(raw []byte, fields ...string) ([]byte, error) {
+	if len(fields) == 0 {
+		return raw, nil
+	}
+	strip := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		strip[f] = struct{}{}
+	}
+	return RewriteObject(raw, func(key string, value json.RawMessage) (json.RawMessage, bool, error) {
+		_, skip := strip[key]
+		return value, !skip, nil
+	})
+}
+
+// RewriteObject rebuilds a raw JSON object key by key, in the original order.
+// fn receives each key and raw value and returns the value to write, or
+// keep=false to drop the key. Values fn returns unchanged are copied through
+// byte-for-byte.
+func RewriteObject(raw []byte, fn func(key string, value json.RawMessage) (json.RawMessage, bool, error)) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected JSON object")
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(raw))
+	buf.WriteByte('{')
+	first := true
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		key, ok := keyTok.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected object key, got %v", keyTok)
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, fmt.Errorf("failed to parse value for %q: %w", key, err)
+		}
+		value, keep, err := fn(key, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		if !keep {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		keyJSON, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(keyJSON)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// RewriteArray rebuilds a raw JSON array, replacing each element with the
+// value fn returns for it.
+func RewriteArray(raw []byte, fn func(elem json.RawMessage) (json.RawMessage, error)) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("expected JSON array")
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(raw))
+	buf.WriteByte('[')
+	for i := 0; dec.More(); i++ {
+		var elem json.RawMessage
+		if err := dec.Decode(&elem); err != nil {
+			return nil, fmt.Errorf("failed to parse element %d: %w", i, err)
+		}
+		elem, err := fn(elem)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(elem)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	buf.WriteByte(']')
+
+	return buf.Bytes(), nil
+}
+
+// StringField returns the top-level string field of a raw JSON object, or
+// "" if it is missing or not a string.
+func StringField(raw []byte, field string) string {
+	if field == "" {
+		return ""
+	}
+	var values map[string]json.RawMessage
+	if json.Unmarshal(raw, &values) != nil {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(values[field], &s) != nil {
+		return ""
+	}
+	return s
+}
+
+// SetField sets the top-level key of a raw JSON object to value, in place if
+// the key exists and appended otherwise.
+func SetField(raw []byte, key string, value json.RawMessage) ([]byte, error) {
+	found := false
+	out, err := RewriteObject(raw, func(k string, v json.RawMessage) (json.RawMessage, bool, error) {
+		if k != key {
+			return v, true, nil
+		}
+		found = true
+		return value, true, nil
+	})
+	if err != nil || found {
+		return out, err
+	}
+	keyJSON, err := json.Marshal(key)
+	if err != nil {
+		return nil, err
+	}
+	out = out[:len(out)-1]
+	if len(out) > 1 {
+		out = append(out, ',')
+	}
+	out = append(out, keyJSON...)
+	out = append(out, ':')
+	out = append(out, value...)
+	return append(out, '}'), nil
+}
+
+// EnsureTag adds tag to the "tags" list of a raw JSON object, creating the
+// list if it is missing or null. The object is returned unchanged if it
+// already has the tag.
+func EnsureTag(raw []byte, tag string) ([]byte, error) {
+	var obj struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, fmt.Errorf("failed to parse tags: %w", err)
+	}
+	for _, t := range obj.Tags {
+		if t == tag {
+			return raw, nil
+		}
+	}
+	tags, err := json.Marshal(append(obj.Tags, tag))
+	if err != nil {
+		return nil, err
+	}
+	return SetField(raw, "tags", tags)
+}
@@ -0,0 +1,131 @@
+package resource
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStripFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		fields  []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "no fields returns input",
+			raw:    `{"b":1,"a":2}`,
+			fields: nil,
+			want:   `{"b":1,"a":2}`,
+		},
+		{
+			name:   "removes top-level key and preserves order",
+			raw:    `{"z":1,"matching_downtimes":[{"id":1}],"a":{"nested":true}}`,
+			fields: []string{"matching_downtimes"},
+			want:   `{"z":1,"a":{"nested":true}}`,
+		},
+		{
+			name:   "does not touch nested keys",
+			raw:    `{"options":{"id":5},"id":7}`,
+			fields: []string{"id"},
+			want:   `{"options":{"id":5}}`,
+		},
+		{
+			name:   "missing field is a no-op",
+			raw:    `{"a":1}`,
+			fields: []string{"b"},
+			want:   `{"a":1}`,
+		},
+		{
+			name:    "rejects non-object",
+			raw:     `[1,2]`,
+			fields:  []string{"a"},
+			wantErr: true,
+		},
+		{
+			name:    "rejects invalid JSON",
+			raw:     `{"a":`,
+			fields:  []string{"a"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StripFields([]byte(tt.raw), tt.fields...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StripFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("StripFields() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteArray(t *testing.T) {
+	got, err := RewriteArray([]byte(`[ {"id":1,"b":2}, 3 ]`), func(elem json.RawMessage) (json.RawMessage, error) {
+		if elem[0] != '{' {
+			return elem, nil
+		}
+		return StripFields(elem, "id")
+	})
+	if err != nil {
+		t.Fatalf("RewriteArray() error = %v", err)
+	}
+	if want := `[{"b":2},3]`; string(got) != want {
+		t.Errorf("RewriteArray() = %s, want %s", got, want)
+	}
+
+	if _, err := RewriteArray([]byte(`{"a":1}`), nil); err == nil {
+		t.Error("RewriteArray() expected error for non-array")
+	}
+	if _, err := RewriteArray([]byte(`[{"id":1}]`), func(elem json.RawMessage) (json.RawMessage, error) {
+		return StripFields([]byte(`[`), "id")
+	}); err == nil {
+		t.Error("RewriteArray() expected error from fn")
+	}
+}
+
+func TestSetField(t *testing.T) {
+	tests := []struct {
+		raw, want string
+	}{
+		{`{"a":1,"b":null,"c":3}`, `{"a":1,"b":[2],"c":3}`},
+		{`{"a":1}`, `{"a":1,"b":[2]}`},
+		{`{}`, `{"b":[2]}`},
+	}
+	for _, tt := range tests {
+		got, err := SetField([]byte(tt.raw), "b", json.RawMessage(`[2]`))
+		if err != nil {
+			t.Fatalf("SetField(%s) error = %v", tt.raw, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("SetField(%s) = %s, want %s", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestEnsureTag(t *testing.T) {
+	tests := []struct {
+		raw, want string
+	}{
+		{`{"id":1,"tags":["team:web"],"name":"x"}`, `{"id":1,"tags":["team:web","managed-by:dd-tf"],"name":"x"}`},
+		{`{"id":1,"tags":["managed-by:dd-tf", "team:web"]}`, `{"id":1,"tags":["managed-by:dd-tf", "team:web"]}`},
+		{`{"id":1,"tags":null}`, `{"id":1,"tags":["managed-by:dd-tf"]}`},
+		{`{"id":1}`, `{"id":1,"tags":["managed-by:dd-tf"]}`},
+	}
+	for _, tt := range tests {
+		got, err := EnsureTag([]byte(tt.raw), "managed-by:dd-tf")
+		if err != nil {
+			t.Fatalf("EnsureTag(%s) error = %v", tt.raw, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("EnsureTag(%s) = %s, want %s", tt.raw, got, tt.want)
+		}
+	}
+	if _, err := EnsureTag([]byte(`{"tags":"team:web"}`), "managed-by:dd-tf"); err == nil {
+		t.Error("EnsureTag() with non-list tags: want an error")
+	}
+}
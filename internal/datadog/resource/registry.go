@@ -0,0 +1,148 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/spf13/pflag"
+)
+
+// IDKind is the type of a resource's IDs.
+type IDKind int
+
+const (
+	IDString  IDKind = iota // e.g. dashboard IDs "abc-def-ghi"
+	IDNumeric               // e.g. monitor IDs 12345
+)
+
+// Kind describes a Datadog resource type, so the generic commands can select,
+// list and download it without knowing its details. IDs cross this interface
+// as strings; numeric IDs are formatted in base 10.
+type Kind interface {
+	// Name is the singular name used in logs, e.g. "dashboard".
+	Name() string
+	// Plural is the command name, e.g. "dashboards".
+	Plural() string
+	// IDKind is the type of the resource's IDs.
+	IDKind() IDKind
+	// PathTemplate returns the configured output path template.
+	PathTemplate(settings *config.Settings) string
+	// Builtins maps path template placeholders to template expressions.
+	Builtins() map[string]string
+	// AddFlags registers selection flags specific to this kind, which
+	// Targets reads back from the same flag set.
+	AddFlags(flags *pflag.FlagSet)
+	// Targets lists the resources selected by opts and the kind's flags.
+	Targets(ctx context.Context, client HTTPClient, settings *config.Settings, opts BaseDownloadOptions, flags *pflag.FlagSet) (<-chan TargetResult[string], error)
+	// Fetch returns a single resource's JSON, as it would be written.
+	Fetch(ctx context.Context, client HTTPClient, settings *config.Settings, id string) ([]byte, error)
+	// Download writes target, returning the path written.
+	Download(ctx context.Context, client HTTPClient, settings *config.Settings, target Target[string], outputPath string) (string, error)
+}
+
+// DownloadFlagger is implemented by kinds with flags that only apply to
+// download, e.g. monitors' --include-runtime. ApplyDownloadFlags copies the
+// flags that were set onto the run's settings before anything is fetched.
+type DownloadFlagger interface {
+	AddDownloadFlags(flags *pflag.FlagSet)
+	ApplyDownloadFlags(settings *config.Settings, flags *pflag.FlagSet) error
+}
+
+// PathComputer is implemented by kinds that can compute a downloaded file's
+// path from its content alone, e.g. to move files after a template change.
+// missingTags lists the template's tag placeholders the content has no value
+// for, which render as "none".
+type PathComputer interface {
+	ComputePath(settings *config.Settings, raw []byte) (path string, missingTags []string, err error)
+}
+
+// Grouper is implemented by kinds whose commands sit under a shared parent
+// command, e.g. "synthetics private-locations download". Group returns the
+// parent command's name.
+type Grouper interface {
+	Group() string
+}
+
+// CompanionDownloader is implemented by kinds with a document that belongs
+// to none of their resources, e.g. the order of log archives. Downloads write
+// it alongside the resources, returning its path.
+type CompanionDownloader interface {
+	DownloadCompanion(ctx context.Context, client HTTPClient, settings *config.Settings) (string, error)
+}
+
+// Pushable is implemented by kinds whose downloaded files can be sent back
+// to Datadog, e.g. "dashboards push". Push sends the file at target.Path,
+// holding the resource target.ID, usually with PushResource.
+type Pushable interface {
+	Push(ctx context.Context, client WriteClient, settings *config.Settings, target Target[string], flags PushFlags) (PushResult, error)
+}
+
+// Comparable is implemented by kinds whose downloaded files can be compared
+// with Datadog, e.g. "dashboards diff". Compare returns the resource in the
+// file at path and the remote one with the same id, or with the id against
+// if it isn't empty, both normalized as downloads are; one that doesn't exist
+// remotely returns an error wrapping ErrNotFound. IgnoredFields are the
+// top-level fields the API sets itself, ignored when comparing.
+type Comparable interface {
+	Compare(ctx context.Context, client HTTPClient, settings *config.Settings, backend storage.Backend, path, against string) (local, remote []byte, err error)
+	IgnoredFields() []string
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []Kind
+)
+
+// Register adds k to the registry; each kind's package registers itself from
+// init. It panics if a kind with the same name is already registered.
+func Register(k Kind) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, existing := range registry {
+		if existing.Name() == k.Name() {
+			panic(fmt.Sprintf("resource: kind %q registered twice", k.Name()))
+		}
+	}
+	registry = append(registry, k)
+}
+
+// Kinds returns the registered kinds in registration order.
+func Kinds() []Kind {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return append([]Kind(nil), registry...)
+}
+
+// LookupKind returns the registered kind with the given singular or plural
+// name.
+func LookupKind(name string) (Kind, bool) {
+	for _, k := range Kinds() {
+		if k.Name() == name || k.Plural() == name {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+// StringTargets converts a stream of targets with IDs of type T into one with
+// string IDs, for kinds whose IDs aren't strings. The returned channel is
+// closed once targets is closed or ctx is done.
+func StringTargets[T comparable](ctx context.Context, targets <-chan TargetResult[T], formatID func(T) string) <-chan TargetResult[string] {
+	out := make(chan TargetResult[string])
+	go func() {
+		defer close(out)
+		for result := range targets {
+			converted := TargetResult[string]{Err: result.Err}
+			if result.Err == nil {
+				converted.Target = Target[string]{ID: formatID(result.Target.ID), Path: result.Target.Path, Data: result.Target.Data, Modified: result.Target.Modified, Dependency: result.Target.Dependency}
+			}
+			if !Send(ctx, out, converted) {
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,71 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/spf13/pflag"
+)
+
+type fakeKind struct{ name string }
+
+func (k fakeKind) Name() string                       { return k.name }
+func (k fakeKind) Plural() string                     { return k.name + "s" }
+func (fakeKind) IDKind() IDKind                       { return IDString }
+func (fakeKind) PathTemplate(*config.Settings) string { return "data/{id}.json" }
+func (fakeKind) Builtins() map[string]string          { return map[string]string{"{id}": "{{.ID}}"} }
+func (fakeKind) AddFlags(*pflag.FlagSet)              {}
+func (fakeKind) Fetch(context.Context, HTTPClient, *config.Settings, string) ([]byte, error) {
+	return nil, nil
+}
+func (fakeKind) Targets(context.Context, HTTPClient, *config.Settings, BaseDownloadOptions, *pflag.FlagSet) (<-chan TargetResult[string], error) {
+	return nil, nil
+}
+func (fakeKind) Download(context.Context, HTTPClient, *config.Settings, Target[string], string) (string, error) {
+	return "", nil
+}
+
+func TestRegister(t *testing.T) {
+	Register(fakeKind{name: "widget"})
+
+	for _, name := range []string{"widget", "widgets"} {
+		if k, ok := LookupKind(name); !ok || k.Name() != "widget" {
+			t.Errorf("LookupKind(%q) = %v, %v", name, k, ok)
+		}
+	}
+	if _, ok := LookupKind("gadget"); ok {
+		t.Error("LookupKind() found an unregistered kind")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() should panic for a duplicate name")
+		}
+	}()
+	Register(fakeKind{name: "widget"})
+}
+
+func TestStringTargets(t *testing.T) {
+	listErr := fmt.Errorf("list failed")
+	out := StringTargets(context.Background(), targetsOf(
+		TargetResult[int]{Target: Target[int]{ID: 7, Path: "data/7.json"}},
+		TargetResult[int]{Err: listErr},
+	), strconv.Itoa)
+
+	var got []TargetResult[string]
+	for r := range out {
+		got = append(got, r)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d results, want 2", len(got))
+	}
+	if got[0].Target.ID != "7" || got[0].Target.Path != "data/7.json" {
+		t.Errorf("target = %+v, want id 7 with its path", got[0].Target)
+	}
+	if got[1].Err != listErr || got[1].Target.ID != "" {
+		t.Errorf("error result = %+v, want the list error and no ID", got[1])
+	}
+}
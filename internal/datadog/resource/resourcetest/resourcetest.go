@@ -0,0 +1,54 @@
+// Package resourcetest has helpers for testing resource kinds.
+package resourcetest
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/spf13/pflag"
+)
+
+// DownloadAll downloads every target kind selects with opts and its flags,
+// parsed from args, and returns the paths written, sorted. Any error fails
+// the test.
+func DownloadAll(t testing.TB, kind resource.Kind, settings *config.Settings, opts resource.BaseDownloadOptions, args ...string) []string {
+	t.Helper()
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+	flags := pflag.NewFlagSet(kind.Plural(), pflag.ContinueOnError)
+	kind.AddFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	targets, err := kind.Targets(context.Background(), client, settings, opts, flags)
+	if err != nil {
+		t.Fatalf("Targets() error = %v", err)
+	}
+	var paths []string
+	for result := range targets {
+		if result.Err != nil {
+			t.Fatalf("Targets() error = %v", result.Err)
+		}
+		path, err := kind.Download(context.Background(), client, settings, result.Target, "")
+		if err != nil {
+			t.Fatalf("Download() error = %v", err)
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Bases returns the file name of each path, sorted.
+func Bases(paths []string) []string {
+	bases := make([]string, len(paths))
+	for i, path := range paths {
+		bases[i] = filepath.Base(path)
+	}
+	sort.Strings(bases)
+	return bases
+}
@@ -0,0 +1,41 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+// DownloadRestrictionPolicy writes the restriction policy of a resource,
+// e.g. "dashboard" and its ID, next to the resource written at path, as
+// storage.PolicyPath(path). The policy is written as its v2 "data" item.
+// Resources without a policy (404) are skipped: no file is written and the
+// returned path is empty.
+func DownloadRestrictionPolicy(ctx context.Context, client HTTPClient, settings *config.Settings, resourceType, id, path string) (string, error) {
+	policyURL := settings.APIBaseURL() + "/api/v2/restriction_policy/" + url.PathEscape(resourceType+":"+id)
+	data, err := FetchData(ctx, client, policyURL, settings)
+	if errors.Is(err, ErrNotFound) {
+		return "", nil
+	}
+	if errors.Is(err, ErrForbidden) {
+		// Not wrapped: the resource itself was readable, so this is a
+		// failure rather than a restricted resource
+		return "", fmt.Errorf("failed to fetch restriction policy: %v", err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch restriction policy: %w", err)
+	}
+	backend, err := storage.NewBackend(settings)
+	if err != nil {
+		return "", err
+	}
+	policyPath := storage.PolicyPath(path)
+	if err := storage.WriteRawJSON(backend, policyPath, data); err != nil {
+		return "", err
+	}
+	return policyPath, nil
+}
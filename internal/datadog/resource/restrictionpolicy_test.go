@@ -0,0 +1,39 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+)
+
+func TestDownloadRestrictionPolicy_Errors(t *testing.T) {
+	tests := []struct {
+		status  int
+		wantErr bool
+	}{
+		{status: http.StatusNotFound},
+		{status: http.StatusForbidden, wantErr: true},
+		{status: http.StatusInternalServerError, wantErr: true},
+	}
+	for _, tt := range tests {
+		client := &fakeHTTPClient{resp: &http.Response{StatusCode: tt.status, Status: http.StatusText(tt.status), Body: io.NopCloser(bytes.NewBufferString(`{"errors":["x"]}`))}}
+		settings := &config.Settings{HTTPMaxBodySize: 1024}
+		path, err := DownloadRestrictionPolicy(context.Background(), client, settings, "dashboard", "abc-def-ghi", filepath.Join(t.TempDir(), "abc-def-ghi.json"))
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%d: DownloadRestrictionPolicy() error = %v, wantErr %v", tt.status, err, tt.wantErr)
+		}
+		if path != "" {
+			t.Errorf("%d: DownloadRestrictionPolicy() = %q, want no file", tt.status, path)
+		}
+		// A 403 for the policy must not mark the resource as restricted
+		if errors.Is(err, ErrForbidden) {
+			t.Errorf("%d: DownloadRestrictionPolicy() error = %v, want it not to be ErrForbidden", tt.status, err)
+		}
+	}
+}
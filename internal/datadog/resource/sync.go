@@ -0,0 +1,48 @@
+package resource
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CollectIDs drains targets and returns the set of IDs they hold. Any error
+// means the set is incomplete, so the first one is returned instead.
+func CollectIDs(targets <-chan TargetResult[string]) (map[string]bool, error) {
+	ids := map[string]bool{}
+	var firstErr error
+	for result := range targets {
+		if result.Err != nil {
+			if firstErr == nil {
+				firstErr = result.Err
+			}
+			continue
+		}
+		ids[result.Target.ID] = true
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return ids, nil
+}
+
+// FindOrphans returns the local files, from an ID to path map such as
+// ExtractLocalIDs returns, whose IDs aren't in remote, sorted by ID. Files
+// outside dir are never returned.
+func FindOrphans(local map[string]string, remote map[string]bool, dir string) []Target[string] {
+	var orphans []Target[string]
+	for id, path := range local {
+		if remote[id] || !insideDir(path, dir) {
+			continue
+		}
+		orphans = append(orphans, Target[string]{ID: id, Path: path})
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].ID < orphans[j].ID })
+	return orphans
+}
+
+// insideDir reports whether path is dir or below it.
+func insideDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
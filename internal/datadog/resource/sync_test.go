@@ -0,0 +1,53 @@
+package resource
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCollectIDs(t *testing.T) {
+	targets := make(chan TargetResult[string], 3)
+	targets <- TargetResult[string]{Target: Target[string]{ID: "1"}}
+	targets <- TargetResult[string]{Target: Target[string]{ID: "2"}}
+	close(targets)
+	ids, err := CollectIDs(targets)
+	if err != nil {
+		t.Fatalf("CollectIDs() error = %v", err)
+	}
+	if want := map[string]bool{"1": true, "2": true}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("CollectIDs() = %v, want %v", ids, want)
+	}
+
+	listErr := errors.New("page 2 failed")
+	targets = make(chan TargetResult[string], 3)
+	targets <- TargetResult[string]{Target: Target[string]{ID: "1"}}
+	targets <- TargetResult[string]{Err: listErr}
+	targets <- TargetResult[string]{Target: Target[string]{ID: "3"}}
+	close(targets)
+	if ids, err := CollectIDs(targets); !errors.Is(err, listErr) || ids != nil {
+		t.Errorf("CollectIDs() = %v, %v, want no IDs and %v", ids, err, listErr)
+	}
+}
+
+func TestFindOrphans(t *testing.T) {
+	dir := filepath.Join("data", "monitors")
+	local := map[string]string{
+		"1": filepath.Join(dir, "1.json"),
+		"2": filepath.Join(dir, "team", "2.json"),
+		"3": filepath.Join(dir, "_untagged", "3.json"),
+		"4": filepath.Join("data", "monitors-old", "4.json"),
+		"5": filepath.Join(dir, "..", "5.json"),
+	}
+	remote := map[string]bool{"1": true}
+
+	got := FindOrphans(local, remote, dir)
+	want := []Target[string]{
+		{ID: "2", Path: filepath.Join(dir, "team", "2.json")},
+		{ID: "3", Path: filepath.Join(dir, "_untagged", "3.json")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindOrphans() = %v, want %v", got, want)
+	}
+}
@@ -1,11 +1,23 @@
 package resource
 
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
 // Target represents a Datadog resource (dashboard, monitor, etc.) with its ID and file path.
 // The generic type T allows this to work with both string IDs (dashboards) and int IDs (monitors).
 type Target[T comparable] struct {
-	ID   T              // Resource ID (string for dashboards, int for monitors)
-	Path string         // File path where the resource should be written
-	Data map[string]any // Full resource data from API (cached to avoid duplicate requests)
+	ID   T               // Resource ID (string for dashboards, int for monitors)
+	Path string          // File path where the resource should be written
+	Data json.RawMessage // Raw resource data from API (cached to avoid duplicate requests)
+	// Modified is the resource's last modification time as reported by the
+	// list endpoint, if known
+	Modified string
+	// Dependency is set for targets selected only because another target
+	// references them, e.g. monitors used by a composite monitor
+	Dependency bool
 }
 
 // TargetResult wraps a Target with a potential error from target generation.
@@ -13,3 +25,44 @@ type TargetResult[T comparable] struct {
 	Target Target[T] // The resource target containing ID, path, and optional cached data
 	Err    error     // Error encountered during target generation, if any
 }
+
+// Send delivers v on out unless ctx is done first, and reports whether it
+// was sent. Target producers use it so they stop instead of blocking forever
+// once the consumer has given up.
+func Send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// TargetError records a failure to download a single target, keeping the
+// local file path (when known) so it can be reported against that file.
+type TargetError struct {
+	ID   string // Resource ID, formatted for display
+	Path string // Local file path, empty if it wasn't computed yet
+	Err  error
+}
+
+func (e *TargetError) Error() string {
+	return e.ID + ": " + e.Err.Error()
+}
+
+func (e *TargetError) Unwrap() error {
+	return e.Err
+}
+
+// TargetErrorIDs returns the IDs of the *TargetErrors in errs, e.g. to list
+// the failed resources of a run; other errors have no ID and are skipped.
+func TargetErrorIDs(errs []error) []string {
+	var ids []string
+	for _, err := range errs {
+		var targetErr *TargetError
+		if errors.As(err, &targetErr) && targetErr.ID != "" {
+			ids = append(ids, targetErr.ID)
+		}
+	}
+	return ids
+}
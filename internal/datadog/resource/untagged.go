@@ -0,0 +1,116 @@
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/templating"
+	"github.com/spf13/pflag"
+)
+
+// QuarantineDir is the directory, under a kind's data directory, that
+// resources missing a required tag are written to with
+// ON_MISSING_REQUIRED_TAG=quarantine.
+const QuarantineDir = "_untagged"
+
+// UntaggedError reports a resource without some of the REQUIRED_TAGS. With
+// ON_MISSING_REQUIRED_TAG=quarantine the resource is still written, and Path
+// is where.
+type UntaggedError struct {
+	Title   string   // Resource title or name, for someone to find it in Datadog
+	Missing []string // Required tag keys the resource doesn't have
+	Path    string   // Quarantine path written, empty if the resource was skipped
+}
+
+func (e *UntaggedError) Error() string {
+	return "missing required tags: " + strings.Join(e.Missing, ", ")
+}
+
+// CheckRequiredTags returns an *UntaggedError if tags (a key -> value map)
+// lack any of settings.RequiredTags, otherwise nil.
+func CheckRequiredTags(settings *config.Settings, title string, tags map[string]string) *UntaggedError {
+	var missing []string
+	for _, key := range settings.RequiredTags {
+		if tags[key] == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return &UntaggedError{Title: title, Missing: missing}
+}
+
+// CheckBodyTags is CheckRequiredTags for a raw resource, such as a file
+// about to be pushed, titled by its "title" or "name" field.
+func CheckBodyTags(settings *config.Settings, raw []byte) error {
+	if len(settings.RequiredTags) == 0 {
+		return nil
+	}
+	var meta struct {
+		Title string `json:"title"`
+		Name  string `json:"name"`
+		Tags  any    `json:"tags"`
+	}
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return fmt.Errorf("failed to parse tags: %w", err)
+	}
+	title := meta.Title
+	if title == "" {
+		title = meta.Name
+	}
+	if untagged := CheckRequiredTags(settings, title, templating.ExtractTagMap(meta.Tags, false)); untagged != nil {
+		return untagged
+	}
+	return nil
+}
+
+// AddTagFlags registers --require-tags, and if strict --strict-tags, which
+// override REQUIRED_TAGS and ON_MISSING_REQUIRED_TAG=fail for one run.
+func AddTagFlags(flags *pflag.FlagSet, strict bool) {
+	flags.StringSlice("require-tags", nil, `Tag keys every resource must have, comma-separated; "none" disables the check (default: REQUIRED_TAGS)`)
+	if strict {
+		flags.Bool("strict-tags", false, "Fail the run for resources missing a required tag, as ON_MISSING_REQUIRED_TAG=fail")
+	}
+}
+
+// ApplyTagFlags applies the AddTagFlags flags that were set to settings.
+func ApplyTagFlags(settings *config.Settings, flags *pflag.FlagSet) error {
+	if flags.Changed("require-tags") {
+		keys, err := flags.GetStringSlice("require-tags")
+		if err != nil {
+			return err
+		}
+		settings.RequiredTags = nil
+		for _, key := range keys {
+			if key = strings.TrimSpace(key); key != "" && !strings.EqualFold(key, "none") {
+				settings.RequiredTags = append(settings.RequiredTags, key)
+			}
+		}
+	}
+	if f := flags.Lookup("strict-tags"); f != nil && f.Changed {
+		strict, err := flags.GetBool("strict-tags")
+		if err != nil {
+			return err
+		}
+		if strict {
+			settings.OnMissingRequiredTag = config.OnMissingTagFail
+		}
+	}
+	return nil
+}
+
+// QuarantinePath returns where a resource that would be written to path goes
+// when quarantined: the same file name under QuarantineDir in the static
+// directory of the path template, e.g. data/monitors/_untagged/123.json.
+func QuarantinePath(template, path string) string {
+	dir := templating.ExtractStaticPrefix(template)
+	if !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+		// The prefix ends part way through a name, e.g. data/monitors/mon-
+		dir = filepath.Dir(dir)
+	}
+	return filepath.Join(dir, QuarantineDir, filepath.Base(path))
+}
@@ -0,0 +1,81 @@
+package resource
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/spf13/pflag"
+)
+
+func TestCheckRequiredTags(t *testing.T) {
+	settings := &config.Settings{RequiredTags: []string{"team", "env"}}
+	if err := CheckRequiredTags(settings, "CPU", map[string]string{"team": "a", "env": "prod"}); err != nil {
+		t.Errorf("CheckRequiredTags() = %v, want nil", err)
+	}
+	err := CheckRequiredTags(settings, "CPU", map[string]string{"env": ""})
+	if err == nil || err.Title != "CPU" || !reflect.DeepEqual(err.Missing, []string{"team", "env"}) {
+		t.Errorf("CheckRequiredTags() = %+v, want team and env missing", err)
+	}
+	if err := CheckRequiredTags(&config.Settings{}, "CPU", nil); err != nil {
+		t.Errorf("CheckRequiredTags() without REQUIRED_TAGS = %v, want nil", err)
+	}
+}
+
+func TestCheckBodyTags(t *testing.T) {
+	settings := &config.Settings{RequiredTags: []string{"team", "service"}}
+	if err := CheckBodyTags(settings, []byte(`{"title":"CPU","tags":["team:a","service:b"]}`)); err != nil {
+		t.Errorf("CheckBodyTags() = %v, want nil", err)
+	}
+	var untagged *UntaggedError
+	err := CheckBodyTags(settings, []byte(`{"name":"disk","tags":["team:a"]}`))
+	if !errors.As(err, &untagged) || untagged.Title != "disk" || !reflect.DeepEqual(untagged.Missing, []string{"service"}) {
+		t.Errorf("CheckBodyTags() = %v, want service missing from disk", err)
+	}
+	if err := CheckBodyTags(settings, []byte(`{`)); err == nil {
+		t.Error("CheckBodyTags() of invalid JSON expected an error")
+	}
+}
+
+func TestApplyTagFlags(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddTagFlags(flags, true)
+	settings := &config.Settings{RequiredTags: []string{"env"}, OnMissingRequiredTag: config.OnMissingTagSkip}
+	if err := flags.Parse([]string{"--require-tags", "team, service", "--strict-tags"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyTagFlags(settings, flags); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(settings.RequiredTags, []string{"team", "service"}) || settings.OnMissingRequiredTag != config.OnMissingTagFail {
+		t.Errorf("ApplyTagFlags() settings = %v, %q", settings.RequiredTags, settings.OnMissingRequiredTag)
+	}
+
+	flags = pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddTagFlags(flags, false)
+	if err := flags.Parse([]string{"--require-tags", "none"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyTagFlags(settings, flags); err != nil || settings.RequiredTags != nil {
+		t.Errorf("ApplyTagFlags(none) = %v, %v, want no required tags", settings.RequiredTags, err)
+	}
+}
+
+func TestQuarantinePath(t *testing.T) {
+	tests := []struct {
+		template, path, want string
+	}{
+		{"data/monitors/{id}.json", "data/monitors/1.json", "data/monitors/_untagged/1.json"},
+		{"data/monitors/{team}/{id}.json", "data/monitors/none/1.json", "data/monitors/_untagged/1.json"},
+		{"data/monitors/mon-{id}.json", "data/monitors/mon-1.json", "data/monitors/_untagged/mon-1.json"},
+		{"{id}.json", "1.json", "_untagged/1.json"},
+	}
+	for _, tt := range tests {
+		got := QuarantinePath(filepath.FromSlash(tt.template), filepath.FromSlash(tt.path))
+		if got != filepath.FromSlash(tt.want) {
+			t.Errorf("QuarantinePath(%q, %q) = %q, want %q", tt.template, tt.path, got, tt.want)
+		}
+	}
+}
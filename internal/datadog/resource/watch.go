@@ -0,0 +1,79 @@
+package resource
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ChangeTracker remembers the modification time of each resource written by
+// a watch, so the next poll only downloads the resources that changed.
+type ChangeTracker struct {
+	mu   sync.Mutex
+	seen map[string]string // ID -> Modified
+}
+
+// Changed reports whether target needs downloading: it wasn't written yet,
+// its modification time changed since, or the list endpoint doesn't report
+// one.
+func (c *ChangeTracker) Changed(target Target[string]) bool {
+	if target.Modified == "" {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.seen[target.ID] != target.Modified
+}
+
+// Record notes that target was written. It is safe for concurrent use, e.g.
+// from a Downloader's OnDownloaded hook.
+func (c *ChangeTracker) Record(target Target[string]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.seen == nil {
+		c.seen = map[string]string{}
+	}
+	c.seen[target.ID] = target.Modified
+}
+
+// Filter forwards the targets that Changed, and every error, from targets
+// to the returned channel. It stops, closing the channel, once targets is
+// closed or ctx is done, so a stopped watch downloads no more targets but
+// lets those already received finish.
+func (c *ChangeTracker) Filter(ctx context.Context, targets <-chan TargetResult[string]) <-chan TargetResult[string] {
+	out := make(chan TargetResult[string])
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case result, ok := <-targets:
+				if !ok {
+					return
+				}
+				if result.Err == nil && !c.Changed(result.Target) {
+					continue
+				}
+				if !Send(ctx, out, result) {
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// maxBackoff caps how many times longer than its base a watch interval gets
+// while rate limited.
+const maxBackoff = 8
+
+// NextInterval returns the wait before the next poll of a watch: current
+// doubled, up to maxBackoff times base, after a poll that was rate limited,
+// otherwise base again.
+func NextInterval(base, current time.Duration, rateLimited bool) time.Duration {
+	if !rateLimited {
+		return base
+	}
+	return min(2*current, maxBackoff*base)
+}
@@ -0,0 +1,76 @@
+package resource
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestChangeTracker_Filter(t *testing.T) {
+	var tracker ChangeTracker
+	tracker.Record(Target[string]{ID: "1", Modified: "t1"})
+	tracker.Record(Target[string]{ID: "2", Modified: "t1"})
+
+	targets := make(chan TargetResult[string], 5)
+	targets <- TargetResult[string]{Target: Target[string]{ID: "1", Modified: "t1"}}
+	targets <- TargetResult[string]{Target: Target[string]{ID: "2", Modified: "t2"}}
+	targets <- TargetResult[string]{Target: Target[string]{ID: "3", Modified: "t1"}}
+	targets <- TargetResult[string]{Target: Target[string]{ID: "4"}}
+	targets <- TargetResult[string]{Err: context.DeadlineExceeded}
+	close(targets)
+
+	var got []string
+	for result := range tracker.Filter(context.Background(), targets) {
+		if result.Err != nil {
+			got = append(got, "error")
+			continue
+		}
+		got = append(got, result.Target.ID)
+	}
+	want := []string{"2", "3", "4", "error"}
+	if len(got) != len(want) {
+		t.Fatalf("Filter() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Filter() = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestChangeTracker_FilterStops(t *testing.T) {
+	var tracker ChangeTracker
+	ctx, cancel := context.WithCancel(context.Background())
+	targets := make(chan TargetResult[string])
+	out := tracker.Filter(ctx, targets)
+	cancel()
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Error("Filter() sent a target after ctx was done")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Filter() didn't stop once ctx was done")
+	}
+}
+
+func TestNextInterval(t *testing.T) {
+	base := 5 * time.Minute
+	tests := []struct {
+		current     time.Duration
+		rateLimited bool
+		want        time.Duration
+	}{
+		{base, false, base},
+		{base, true, 10 * time.Minute},
+		{20 * time.Minute, true, 40 * time.Minute},
+		{40 * time.Minute, true, 40 * time.Minute},
+		{40 * time.Minute, false, base},
+	}
+	for _, tt := range tests {
+		if got := NextInterval(base, tt.current, tt.rateLimited); got != tt.want {
+			t.Errorf("NextInterval(%v, %v, %v) = %v, want %v", base, tt.current, tt.rateLimited, got, tt.want)
+		}
+	}
+}
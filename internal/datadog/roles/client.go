@@ -0,0 +1,105 @@
+// Package roles downloads Datadog roles with the names of their permissions.
+package roles
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	resource.Register(Kind)
+}
+
+// Kind registers roles as "dd-tf roles". Roles are written as the v2 "data"
+// item, with the names of their permissions added to their attributes.
+var Kind = &resource.ListKind{
+	KindName:   "role",
+	PluralName: "roles",
+	IDType:     resource.IDString,
+	Template: func(settings *config.Settings) string {
+		return settings.RolesPathTemplate
+	},
+	List:      listRoles,
+	Get:       fetchRole,
+	Summaries: true,
+	Decode:    resource.DecodeDataMeta,
+	Flags: func(flags *pflag.FlagSet) {
+		flags.Bool("include-managed", false, "Also select the built-in Datadog Admin, Standard and Read Only roles")
+	},
+	Filter: roleFilter,
+}
+
+// ManagedRoles are the built-in roles, which Terraform can't manage.
+var ManagedRoles = map[string]bool{
+	"Datadog Admin Role":     true,
+	"Datadog Standard Role":  true,
+	"Datadog Read Only Role": true,
+}
+
+// maxPageSize is the largest page the roles API returns.
+const maxPageSize = 100
+
+// listRoles pages through /api/v2/roles. Their permissions are only fetched
+// for the selected roles, by fetchRole.
+func listRoles(ctx context.Context, client resource.HTTPClient, settings *config.Settings) ([]json.RawMessage, error) {
+	roles, err := resource.FetchDataPages(ctx, client, settings.APIBaseURL()+"/api/v2/roles", min(settings.PageSize, maxPageSize), settings)
+	if err != nil {
+		return nil, err
+	}
+	logging.Logger.Debug("roles listed", "count", len(roles))
+	return roles, nil
+}
+
+// fetchRole fetches a role and the names of its permissions, which it adds
+// as attributes.permissions, sorted.
+func fetchRole(ctx context.Context, client resource.HTTPClient, settings *config.Settings, id string) (json.RawMessage, error) {
+	roleURL := settings.APIBaseURL() + "/api/v2/roles/" + url.PathEscape(id)
+	role, err := resource.FetchData(ctx, client, roleURL, settings)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := resource.FetchDataList(ctx, client, roleURL+"/permissions", settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch permissions of role %s: %w", id, err)
+	}
+	names := make([]string, 0, len(permissions))
+	for _, raw := range permissions {
+		meta, err := resource.DecodeDataMeta(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode permission: %w", err)
+		}
+		names = append(names, meta.Name)
+	}
+	sort.Strings(names)
+	namesJSON, err := json.Marshal(names)
+	if err != nil {
+		return nil, err
+	}
+	return resource.RewriteObject(role, func(key string, value json.RawMessage) (json.RawMessage, bool, error) {
+		if key != "attributes" {
+			return value, true, nil
+		}
+		value, err := resource.SetField(value, "permissions", namesJSON)
+		return value, true, err
+	})
+}
+
+// roleFilter skips the built-in roles unless --include-managed is passed.
+func roleFilter(flags *pflag.FlagSet) (func(json.RawMessage) bool, error) {
+	includeManaged, err := flags.GetBool("include-managed")
+	if err != nil {
+		return nil, err
+	}
+	return func(raw json.RawMessage) bool {
+		meta, err := resource.DecodeDataMeta(raw)
+		return err == nil && (includeManaged || !ManagedRoles[meta.Name])
+	}, nil
+}
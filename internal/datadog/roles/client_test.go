@@ -0,0 +1,87 @@
+package roles
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/resource/resourcetest"
+)
+
+const sreRoleJSON = `{"type":"roles","id":"r-sre","attributes":{"name":"SRE / On-call","user_count":4},"relationships":{"permissions":{"data":[{"type":"permissions","id":"p2"},{"type":"permissions","id":"p1"}]}}}`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/roles":
+			w.Write([]byte(`{"data":[` + sreRoleJSON + `,{"type":"roles","id":"r-admin","attributes":{"name":"Datadog Admin Role"}}]}`))
+		case "/api/v2/roles/r-sre":
+			w.Write([]byte(`{"data":` + sreRoleJSON + `}`))
+		case "/api/v2/roles/r-sre/permissions":
+			w.Write([]byte(`{"data":[{"type":"permissions","id":"p2","attributes":{"name":"monitors_write"}},{"type":"permissions","id":"p1","attributes":{"name":"dashboards_read"}}]}`))
+		case "/api/v2/roles/r-admin":
+			w.Write([]byte(`{"data":{"type":"roles","id":"r-admin","attributes":{"name":"Datadog Admin Role"}}}`))
+		case "/api/v2/roles/r-admin/permissions":
+			w.Write([]byte(`{"data":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestKind_DownloadAll(t *testing.T) {
+	server := newTestServer(t)
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:              server.URL,
+		RolesPathTemplate: filepath.Join(dir, "{name}.json"),
+		PageSize:          100,
+		HTTPMaxBodySize:   4096,
+	}
+
+	if got := resourcetest.Bases(resourcetest.DownloadAll(t, Kind, settings, resource.BaseDownloadOptions{All: true})); strings.Join(got, ",") != "SRE-On-call.json" {
+		t.Errorf("download --all wrote %v, want [SRE-On-call.json]", got)
+	}
+	if got := resourcetest.Bases(resourcetest.DownloadAll(t, Kind, settings, resource.BaseDownloadOptions{All: true}, "--include-managed")); strings.Join(got, ",") != "Datadog-Admin-Role.json,SRE-On-call.json" {
+		t.Errorf("download --all --include-managed wrote %v", got)
+	}
+
+	written, err := os.ReadFile(filepath.Join(dir, "SRE-On-call.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `"permissions": [
+      "dashboards_read",
+      "monitors_write"
+    ]`
+	if !strings.Contains(string(written), want) {
+		t.Errorf("role written as %s, want its sorted permission names", written)
+	}
+	if !strings.Contains(string(written), `"relationships"`) {
+		t.Errorf("role written as %s, want its relationships kept", written)
+	}
+}
+
+func TestKind_UpdateByUUID(t *testing.T) {
+	server := newTestServer(t)
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:              server.URL,
+		RolesPathTemplate: filepath.Join(dir, "{name}.json"),
+		HTTPMaxBodySize:   4096,
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sre.json"), []byte(`{"type":"roles","id":"r-sre"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := resourcetest.Bases(resourcetest.DownloadAll(t, Kind, settings, resource.BaseDownloadOptions{Update: true})); strings.Join(got, ",") != "sre.json" {
+		t.Errorf("download --update wrote %v, want [sre.json]", got)
+	}
+}
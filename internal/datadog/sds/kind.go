@@ -0,0 +1,295 @@
+// Package sds downloads the Sensitive Data Scanner configuration: its
+// scanning groups and their rules.
+package sds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/templating"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/AD7six/dd-tf/internal/utils"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	resource.Register(Kind{})
+}
+
+// Kind registers the Sensitive Data Scanner as "dd-tf sds". One request
+// returns the whole configuration, which is split into a file per scanning
+// group and, in a rules directory next to it, a file per rule. With --flat
+// the configuration is written as it is returned, to a single file.
+type Kind struct{}
+
+const (
+	groupType = "sensitive_data_scanner_group"
+	ruleType  = "sensitive_data_scanner_rule"
+)
+
+func (Kind) Name() string            { return "scanning group" }
+func (Kind) Plural() string          { return "sds" }
+func (Kind) IDKind() resource.IDKind { return resource.IDString }
+
+func (Kind) PathTemplate(settings *config.Settings) string {
+	return settings.SDSPathTemplate
+}
+
+func (Kind) Builtins() map[string]string {
+	return map[string]string{
+		"{id}":    "{{.ID}}",
+		"{name}":  "{{.Name}}",
+		"{title}": "{{.Name}}", // Alias for consistency with dashboards
+	}
+}
+
+func (Kind) AddFlags(*pflag.FlagSet) {}
+
+// AddDownloadFlags adds --flat.
+func (Kind) AddDownloadFlags(flags *pflag.FlagSet) {
+	flags.Bool("flat", false, "Write the whole configuration to a single file, SDS_FLAT_PATH (default: SDS_FLAT)")
+}
+
+func (Kind) ApplyDownloadFlags(settings *config.Settings, flags *pflag.FlagSet) error {
+	if flags.Changed("flat") {
+		flat, err := flags.GetBool("flat")
+		if err != nil {
+			return err
+		}
+		settings.SDSFlat = flat
+	}
+	return nil
+}
+
+// item is a group or rule in the configuration's "included" list.
+type item struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Attributes struct {
+		Name string `json:"name"`
+	} `json:"attributes"`
+	Relationships struct {
+		Group struct {
+			Data struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		} `json:"group"`
+	} `json:"relationships"`
+}
+
+// group is a scanning group and its rules, as cached on targets.
+type group struct {
+	Group json.RawMessage   `json:"group"`
+	Rules []json.RawMessage `json:"rules"`
+}
+
+// fetchConfig fetches the configuration, returning it as is and split into
+// groups, in order.
+func fetchConfig(ctx context.Context, client resource.HTTPClient, settings *config.Settings) (json.RawMessage, []string, map[string]*group, error) {
+	raw, err := resource.FetchRawFromAPI(ctx, client, settings.APIBaseURL()+"/api/v2/sensitive-data-scanner/config", settings)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var resp struct {
+		Included []json.RawMessage `json:"included"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to decode sensitive data scanner config: %w", err)
+	}
+	var order []string
+	groups := map[string]*group{}
+	// Rules are grouped once every group is known
+	var rules []item
+	var rulesRaw []json.RawMessage
+	for _, raw := range resp.Included {
+		var it item
+		if err := json.Unmarshal(raw, &it); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to decode sensitive data scanner config: %w", err)
+		}
+		switch it.Type {
+		case groupType:
+			order = append(order, it.ID)
+			groups[it.ID] = &group{Group: raw}
+		case ruleType:
+			rules = append(rules, it)
+			rulesRaw = append(rulesRaw, raw)
+		}
+	}
+	for i, rule := range rules {
+		if g, ok := groups[rule.Relationships.Group.Data.ID]; ok {
+			g.Rules = append(g.Rules, rulesRaw[i])
+		} else {
+			logging.Logger.Warn("sensitive data scanner rule without a group", "id", rule.ID)
+		}
+	}
+	return raw, order, groups, nil
+}
+
+// Targets fetches the configuration once and caches each selected group,
+// with its rules, on its target. With --flat there is one target: the whole
+// configuration.
+func (k Kind) Targets(ctx context.Context, client resource.HTTPClient, settings *config.Settings, opts resource.BaseDownloadOptions, _ *pflag.FlagSet) (<-chan resource.TargetResult[string], error) {
+	ids := utils.ParseCommaSeparatedIDs(opts.IDs)
+	if !opts.All && !opts.Update && len(ids) == 0 {
+		if opts.Team != "" || opts.Tags != "" {
+			return nil, fmt.Errorf("scanning groups have no tags; use --id or --all")
+		}
+		return nil, fmt.Errorf("please specify --id, --all, or --update")
+	}
+
+	out := make(chan resource.TargetResult[string])
+	go func() {
+		defer close(out)
+		raw, order, groups, err := fetchConfig(ctx, client, settings)
+		if err != nil {
+			resource.Send(ctx, out, resource.TargetResult[string]{Err: fmt.Errorf("failed to fetch sensitive data scanner config: %w", err)})
+			return
+		}
+		if settings.SDSFlat {
+			resource.Send(ctx, out, resource.TargetResult[string]{Target: resource.Target[string]{ID: "config", Data: raw}})
+			return
+		}
+
+		paths := map[string]string{}
+		if opts.Update {
+			if paths, err = scanGroups(settings); err != nil {
+				resource.Send(ctx, out, resource.TargetResult[string]{Err: fmt.Errorf("failed to scan directory: %w", err)})
+				return
+			}
+			ids = nil
+			for id := range paths {
+				ids = append(ids, id)
+			}
+		}
+		selected := order
+		if !opts.All {
+			selected = ids
+		}
+		for _, id := range selected {
+			g, ok := groups[id]
+			if !ok {
+				err := fmt.Errorf("%s %s: %w", k.Name(), id, resource.ErrNotFound)
+				if !resource.Send(ctx, out, resource.TargetResult[string]{Err: &resource.TargetError{ID: id, Path: paths[id], Err: err}}) {
+					return
+				}
+				continue
+			}
+			data, err := json.Marshal(g)
+			if err != nil {
+				resource.Send(ctx, out, resource.TargetResult[string]{Err: err})
+				return
+			}
+			if !resource.Send(ctx, out, resource.TargetResult[string]{Target: resource.Target[string]{ID: id, Path: paths[id], Data: data}}) {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// scanGroups maps the IDs of the downloaded scanning groups to their paths,
+// skipping rule files.
+func scanGroups(settings *config.Settings) (map[string]string, error) {
+	backend, err := storage.NewBackend(settings)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := storage.ExtractIDs(backend, templating.ExtractStaticPrefix(settings.SDSPathTemplate))
+	if err != nil {
+		return nil, err
+	}
+	for id, p := range ids {
+		if filepath.Base(filepath.Dir(p)) == "rules" {
+			delete(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (k Kind) Fetch(ctx context.Context, client resource.HTTPClient, settings *config.Settings, id string) ([]byte, error) {
+	raw, _, groups, err := fetchConfig(ctx, client, settings)
+	if err != nil {
+		return nil, err
+	}
+	if settings.SDSFlat {
+		return storage.FormatJSON(raw)
+	}
+	g, ok := groups[id]
+	if !ok {
+		return nil, fmt.Errorf("%s %s: %w", k.Name(), id, resource.ErrNotFound)
+	}
+	return storage.FormatJSON(g.Group)
+}
+
+// Download writes a group to its path and its rules to a rules directory
+// next to it, named after the rules; or, with --flat, the whole
+// configuration to SDS_FLAT_PATH.
+func (k Kind) Download(ctx context.Context, client resource.HTTPClient, settings *config.Settings, target resource.Target[string], outputPath string) (string, error) {
+	backend, err := storage.NewBackend(settings)
+	if err != nil {
+		return "", err
+	}
+	if settings.SDSFlat {
+		targetPath := outputPath
+		if targetPath == "" {
+			targetPath = settings.SDSFlatPath
+		}
+		return targetPath, storage.WriteRawJSON(backend, targetPath, target.Data)
+	}
+
+	var g group
+	if err := json.Unmarshal(target.Data, &g); err != nil {
+		return "", fmt.Errorf("failed to decode %s: %w", k.Name(), err)
+	}
+	var it item
+	if err := json.Unmarshal(g.Group, &it); err != nil {
+		return "", fmt.Errorf("failed to decode %s: %w", k.Name(), err)
+	}
+	template := outputPath
+	if template == "" {
+		template = settings.SDSPathTemplate
+	}
+	targetPath := target.Path
+	if targetPath == "" {
+		if targetPath, err = k.computePath(template, it); err != nil {
+			return "", err
+		}
+	}
+	if err := storage.WriteRawJSON(backend, targetPath, g.Group); err != nil {
+		return "", err
+	}
+	rulesDir := filepath.Join(filepath.Dir(targetPath), "rules")
+	for _, raw := range g.Rules {
+		var rule item
+		if err := json.Unmarshal(raw, &rule); err != nil {
+			return "", fmt.Errorf("failed to decode rule: %w", err)
+		}
+		if err := storage.WriteRawJSON(backend, filepath.Join(rulesDir, sanitizedName(rule)+".json"), raw); err != nil {
+			return "", err
+		}
+	}
+	return targetPath, nil
+}
+
+func (k Kind) computePath(template string, it item) (string, error) {
+	data := struct {
+		ID   string
+		Name string
+		Tags map[string]string
+	}{ID: it.ID, Name: sanitizedName(it), Tags: map[string]string{}}
+	return templating.ComputePathFromTemplate(templating.TranslatePlaceholders(template, k.Builtins()), data)
+}
+
+// sanitizedName returns an item's name for paths, or its ID if it has none.
+func sanitizedName(it item) string {
+	if name := storage.SanitizeFilename(it.Attributes.Name); name != "" {
+		return name
+	}
+	return strings.ToLower(it.ID)
+}
@@ -0,0 +1,120 @@
+package sds
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/resource/resourcetest"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+)
+
+const configJSON = `{"data":{"id":"cfg","type":"sensitive_data_scanner_configuration","relationships":{"groups":{"data":[{"id":"g1","type":"sensitive_data_scanner_group"},{"id":"g2","type":"sensitive_data_scanner_group"}]}}},` +
+	`"included":[` +
+	`{"id":"g1","type":"sensitive_data_scanner_group","attributes":{"name":"PCI / Cards","filter":{"query":"*"}},"relationships":{"rules":{"data":[{"id":"r1","type":"sensitive_data_scanner_rule"}]}}},` +
+	`{"id":"r1","type":"sensitive_data_scanner_rule","attributes":{"name":"Visa card","pattern":"4[0-9]{12}"},"relationships":{"group":{"data":{"id":"g1","type":"sensitive_data_scanner_group"}}}},` +
+	`{"id":"r2","type":"sensitive_data_scanner_rule","attributes":{"name":"Emails"},"relationships":{"group":{"data":{"id":"g2","type":"sensitive_data_scanner_group"}}}},` +
+	`{"id":"g2","type":"sensitive_data_scanner_group","attributes":{"name":"PII"},"relationships":{"rules":{"data":[{"id":"r2","type":"sensitive_data_scanner_rule"}]}}}` +
+	`],"meta":{"version":7}}`
+
+func newTestSettings(t *testing.T) *config.Settings {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/sensitive-data-scanner/config" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(configJSON))
+	}))
+	t.Cleanup(server.Close)
+	dir := t.TempDir()
+	return &config.Settings{
+		Site:            server.URL,
+		SDSPathTemplate: filepath.Join(dir, "{name}", "config.json"),
+		SDSFlatPath:     filepath.Join(dir, "config.json"),
+		HTTPMaxBodySize: 8192,
+	}
+}
+
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files
+}
+
+func TestKind_SplitsGroupsAndRules(t *testing.T) {
+	settings := newTestSettings(t)
+	dir := filepath.Dir(settings.SDSFlatPath)
+
+	resourcetest.DownloadAll(t, Kind{}, settings, resource.BaseDownloadOptions{All: true})
+	want := []string{"PCI-Cards/config.json", "PCI-Cards/rules/Visa-card.json", "PII/config.json", "PII/rules/Emails.json"}
+	if got := listFiles(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("download --all wrote %v, want %v", got, want)
+	}
+	rule, _ := os.ReadFile(filepath.Join(dir, "PCI-Cards", "rules", "Visa-card.json"))
+	if !strings.Contains(string(rule), `"pattern": "4[0-9]{12}"`) {
+		t.Errorf("rule written as %s", rule)
+	}
+
+	// --update finds the groups again, wherever they are, and ignores rules
+	moved := filepath.Join(dir, "cards.json")
+	if err := os.Rename(filepath.Join(dir, "PCI-Cards", "config.json"), moved); err != nil {
+		t.Fatal(err)
+	}
+	paths := resourcetest.DownloadAll(t, Kind{}, settings, resource.BaseDownloadOptions{Update: true})
+	if len(paths) != 2 || (paths[0] != moved && paths[1] != moved) {
+		t.Errorf("download --update wrote %v, want %s among them", paths, moved)
+	}
+}
+
+func TestKind_Flat(t *testing.T) {
+	settings := newTestSettings(t)
+	settings.SDSFlat = true
+
+	paths := resourcetest.DownloadAll(t, Kind{}, settings, resource.BaseDownloadOptions{All: true})
+	if len(paths) != 1 || paths[0] != settings.SDSFlatPath {
+		t.Fatalf("download --all --flat wrote %v, want [%s]", paths, settings.SDSFlatPath)
+	}
+	written, _ := os.ReadFile(settings.SDSFlatPath)
+	if !strings.Contains(string(written), `"version": 7`) || !strings.Contains(string(written), `"Visa card"`) {
+		t.Errorf("config written as %s, want all of it", written)
+	}
+}
+
+func TestKind_Targets_IDs(t *testing.T) {
+	settings := newTestSettings(t)
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+	targets, err := Kind{}.Targets(context.Background(), client, settings, resource.BaseDownloadOptions{IDs: "g2,missing"}, nil)
+	if err != nil {
+		t.Fatalf("Targets() error = %v", err)
+	}
+	var ids, failed []string
+	for result := range targets {
+		if result.Err != nil {
+			failed = append(failed, result.Err.Error())
+			continue
+		}
+		ids = append(ids, result.Target.ID)
+	}
+	if strings.Join(ids, ",") != "g2" || len(failed) != 1 || !strings.Contains(failed[0], "missing") {
+		t.Errorf("Targets(--id=g2,missing) = %v, errors %v", ids, failed)
+	}
+	if _, err := (Kind{}).Targets(context.Background(), client, settings, resource.BaseDownloadOptions{Team: "a"}, nil); err == nil {
+		t.Error("Targets(--team) expected an error")
+	}
+}
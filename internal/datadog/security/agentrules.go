@@ -0,0 +1,58 @@
+// Package security downloads Datadog security resources.
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+)
+
+func init() {
+	resource.Register(AgentRules)
+}
+
+// AgentRules registers Cloud Workload Security agent rules as "dd-tf security
+// agent-rules".
+var AgentRules = &resource.ListKind{
+	KindName:   "agent rule",
+	PluralName: "agent-rules",
+	GroupName:  "security",
+	IDType:     resource.IDString,
+	Template: func(settings *config.Settings) string {
+		return settings.SecurityAgentRulesPathTemplate
+	},
+	List:      listAgentRules,
+	Get:       fetchAgentRule,
+	Decode:    resource.DecodeDataMeta,
+	Normalize: NormalizeAgentRule,
+}
+
+const agentRulesPath = "/api/v2/remote_config/products/cws/agent_rules"
+
+func listAgentRules(ctx context.Context, client resource.HTTPClient, settings *config.Settings) ([]json.RawMessage, error) {
+	return resource.FetchDataList(ctx, client, settings.APIBaseURL()+agentRulesPath, settings)
+}
+
+func fetchAgentRule(ctx context.Context, client resource.HTTPClient, settings *config.Settings, id string) (json.RawMessage, error) {
+	return resource.FetchData(ctx, client, settings.APIBaseURL()+agentRulesPath+"/"+url.PathEscape(id), settings)
+}
+
+// NormalizeAgentRule strips the creationAuthorUuId and updateAuthorUuId
+// audit attributes, which change with whoever last edited the rule.
+func NormalizeAgentRule(raw json.RawMessage, _ *config.Settings) (json.RawMessage, error) {
+	raw, err := resource.RewriteObject(raw, func(key string, value json.RawMessage) (json.RawMessage, bool, error) {
+		if key != "attributes" {
+			return value, true, nil
+		}
+		value, err := resource.StripFields(value, "creationAuthorUuId", "updateAuthorUuId")
+		return value, true, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to normalize agent rule: %w", err)
+	}
+	return raw, nil
+}
@@ -0,0 +1,69 @@
+package security
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/resource/resourcetest"
+)
+
+func TestNormalizeAgentRule(t *testing.T) {
+	raw := `{"id":"abc-123","type":"agent_rule","attributes":{"name":"my_rule","creationAuthorUuId":"u1","expression":"exec.file.name == \"sh\"","updateAuthorUuId":"u2","enabled":true}}`
+	want := `{"id":"abc-123","type":"agent_rule","attributes":{"name":"my_rule","expression":"exec.file.name == \"sh\"","enabled":true}}`
+	got, err := NormalizeAgentRule([]byte(raw), &config.Settings{})
+	if err != nil {
+		t.Fatalf("NormalizeAgentRule() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("NormalizeAgentRule() = %s, want %s", got, want)
+	}
+}
+
+func TestAgentRules_Update(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/remote_config/products/cws/agent_rules/abc-123" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"data":{"id":"abc-123","type":"agent_rule","attributes":{"name":"my_rule","enabled":true,"updateAuthorUuId":"u2"}}}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                           server.URL,
+		SecurityAgentRulesPathTemplate: filepath.Join(dir, "{id}.json"),
+		HTTPMaxBodySize:                4096,
+	}
+	existing := filepath.Join(dir, "abc-123.json")
+	if err := os.WriteFile(existing, []byte(`{"id":"abc-123"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := resourcetest.DownloadAll(t, AgentRules, settings, resource.BaseDownloadOptions{Update: true})
+	if len(paths) != 1 || paths[0] != existing {
+		t.Fatalf("download --update wrote %v, want [%s]", paths, existing)
+	}
+	written, err := os.ReadFile(existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rule struct {
+		Attributes map[string]any `json:"attributes"`
+	}
+	if err := json.Unmarshal(written, &rule); err != nil {
+		t.Fatal(err)
+	}
+	if rule.Attributes["name"] != "my_rule" {
+		t.Errorf("rule written as %s", written)
+	}
+	if _, ok := rule.Attributes["updateAuthorUuId"]; ok {
+		t.Errorf("rule written as %s, want updateAuthorUuId stripped", written)
+	}
+}
@@ -0,0 +1,108 @@
+// Package services downloads Datadog Service Catalog definitions.
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/logging"
+)
+
+func init() {
+	resource.Register(Kind)
+}
+
+// Kind registers service definitions as "dd-tf services". A definition is
+// written as its v2.2 schema, the document Datadog ingests, and is keyed by
+// its "dd-service" name. --team matches the "team" attribute.
+var Kind = &resource.ListKind{
+	KindName:      "service definition",
+	PluralName:    "services",
+	IDType:        resource.IDString,
+	IDField:       "dd-service",
+	IDPlaceholder: "{dd-service}",
+	Template: func(settings *config.Settings) string {
+		return settings.ServicesPathTemplate
+	},
+	List:   listServices,
+	Get:    fetchService,
+	Decode: decodeService,
+}
+
+// SchemaVersion is the service definition schema requested from the API.
+const SchemaVersion = "v2.2"
+
+// maxPageSize is the largest page the service definitions API returns.
+const maxPageSize = 100
+
+// listServices pages through /api/v2/services/definitions.
+func listServices(ctx context.Context, client resource.HTTPClient, settings *config.Settings) ([]json.RawMessage, error) {
+	items, err := resource.FetchDataPages(ctx, client, settings.APIBaseURL()+"/api/v2/services/definitions?schema_version="+SchemaVersion, min(settings.PageSize, maxPageSize), settings)
+	if err != nil {
+		return nil, err
+	}
+	schemas := make([]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		schema, err := extractSchema(item)
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, schema)
+	}
+	logging.Logger.Debug("service definitions listed", "count", len(schemas))
+	return schemas, nil
+}
+
+func fetchService(ctx context.Context, client resource.HTTPClient, settings *config.Settings, name string) (json.RawMessage, error) {
+	data, err := resource.FetchData(ctx, client, settings.APIBaseURL()+"/api/v2/services/definitions/"+url.PathEscape(name)+"?schema_version="+SchemaVersion, settings)
+	if err != nil {
+		return nil, err
+	}
+	return extractSchema(data)
+}
+
+// extractSchema returns the definition in a v2 item's attributes.schema,
+// dropping the attributes.meta Datadog adds (ingestion time, warnings, ...).
+func extractSchema(item json.RawMessage) (json.RawMessage, error) {
+	var definition struct {
+		Attributes struct {
+			Schema json.RawMessage `json:"schema"`
+		} `json:"attributes"`
+	}
+	if err := json.Unmarshal(item, &definition); err != nil {
+		return nil, fmt.Errorf("failed to decode service definition: %w", err)
+	}
+	if definition.Attributes.Schema == nil {
+		return nil, fmt.Errorf("service definition has no schema")
+	}
+	return definition.Attributes.Schema, nil
+}
+
+// decodeService keys a definition by its dd-service name. Its tags are its
+// own, except that any team tag is replaced by the team attribute, so that
+// --team and {team} use the team the definition declares.
+func decodeService(raw json.RawMessage) (resource.Meta, error) {
+	var schema struct {
+		Service string   `json:"dd-service"`
+		Team    string   `json:"team"`
+		Tags    []string `json:"tags"`
+	}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return resource.Meta{}, err
+	}
+	var tags []string
+	for _, tag := range schema.Tags {
+		if !strings.HasPrefix(tag, "team:") {
+			tags = append(tags, tag)
+		}
+	}
+	if schema.Team != "" {
+		tags = append(tags, "team:"+schema.Team)
+	}
+	return resource.Meta{ID: schema.Service, Name: schema.Service, Tags: tags}, nil
+}
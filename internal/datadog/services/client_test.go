@@ -0,0 +1,107 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/resource/resourcetest"
+)
+
+func definition(service, team string) string {
+	return `{"id":"id-` + service + `","type":"service-definition","attributes":{"meta":{"last-modified-time":"2024-01-01T00:00:00Z"},"schema":{"schema-version":"v2.2","dd-service":"` + service + `","team":"` + team + `","tags":["env:prod","team:stale"],"links":[{"name":"Runbook","type":"runbook","url":"https://example.com"}]}}}`
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/services/definitions":
+			if r.URL.Query().Get("schema_version") != "v2.2" || r.URL.Query().Get("page[size]") != "2" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			switch r.URL.Query().Get("page[number]") {
+			case "0":
+				w.Write([]byte(`{"data":[` + definition("web", "a") + `,` + definition("api", "b") + `]}`))
+			case "1":
+				w.Write([]byte(`{"data":[` + definition("worker", "a") + `]}`))
+			default:
+				t.Errorf("unexpected page %s", r.URL.RawQuery)
+			}
+		case "/api/v2/services/definitions/web":
+			w.Write([]byte(`{"data":` + definition("web", "a") + `}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestKind_TeamFiltersOnTeamAttribute(t *testing.T) {
+	server := newTestServer(t)
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                 server.URL,
+		ServicesPathTemplate: filepath.Join(dir, "{team}", "{dd-service}.json"),
+		PageSize:             2,
+		HTTPMaxBodySize:      4096,
+	}
+
+	paths := resourcetest.DownloadAll(t, Kind, settings, resource.BaseDownloadOptions{Team: "a"})
+	want := []string{filepath.Join(dir, "a", "web.json"), filepath.Join(dir, "a", "worker.json")}
+	if len(paths) != 2 || paths[0] != want[0] || paths[1] != want[1] {
+		t.Fatalf("download --team=a wrote %v, want %v", paths, want)
+	}
+	if paths := resourcetest.DownloadAll(t, Kind, settings, resource.BaseDownloadOptions{Team: "stale"}); len(paths) != 0 {
+		t.Errorf("download --team=stale wrote %v, want nothing", paths)
+	}
+
+	written, err := os.ReadFile(want[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := `{
+  "schema-version": "v2.2",
+  "dd-service": "web",
+  "team": "a",
+  "tags": [
+    "env:prod",
+    "team:stale"
+  ],
+  "links": [
+    {
+      "name": "Runbook",
+      "type": "runbook",
+      "url": "https://example.com"
+    }
+  ]
+}
+`
+	if string(written) != wantJSON {
+		t.Errorf("definition written as\n%s\nwant\n%s", written, wantJSON)
+	}
+}
+
+func TestKind_UpdateByServiceName(t *testing.T) {
+	server := newTestServer(t)
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                 server.URL,
+		ServicesPathTemplate: filepath.Join(dir, "{dd-service}.json"),
+		HTTPMaxBodySize:      4096,
+	}
+	existing := filepath.Join(dir, "frontend.json")
+	if err := os.WriteFile(existing, []byte(`{"schema-version":"v2.2","dd-service":"web"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := resourcetest.DownloadAll(t, Kind, settings, resource.BaseDownloadOptions{Update: true})
+	if len(paths) != 1 || paths[0] != existing {
+		t.Fatalf("download --update wrote %v, want [%s]", paths, existing)
+	}
+}
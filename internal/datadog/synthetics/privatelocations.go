@@ -0,0 +1,83 @@
+// Package synthetics downloads Datadog Synthetics resources.
+package synthetics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+)
+
+func init() {
+	resource.Register(PrivateLocations)
+}
+
+// PrivateLocations registers Synthetics private locations, whose IDs look
+// like "pl:name-abc123", as "dd-tf synthetics private-locations".
+var PrivateLocations = &resource.ListKind{
+	KindName:   "private location",
+	PluralName: "private-locations",
+	GroupName:  "synthetics",
+	IDType:     resource.IDString,
+	Template: func(settings *config.Settings) string {
+		return settings.SyntheticsPrivateLocationsPathTemplate
+	},
+	List:      listPrivateLocations,
+	Get:       fetchPrivateLocation,
+	Normalize: NormalizePrivateLocation,
+	Tagged:    true,
+}
+
+// privateLocationPrefix starts the ID of every private location, as opposed
+// to the managed locations such as "aws:eu-central-1".
+const privateLocationPrefix = "pl:"
+
+// listPrivateLocations fetches every private location. There is no endpoint
+// listing only private locations, so they are picked out of all Synthetics
+// locations and then fetched one by one, as the locations list only has
+// their names.
+func listPrivateLocations(ctx context.Context, client resource.HTTPClient, settings *config.Settings) ([]json.RawMessage, error) {
+	raw, err := resource.FetchRawFromAPI(ctx, client, settings.APIBaseURL()+"/api/v1/synthetics/locations", settings)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Locations []struct {
+			ID string `json:"id"`
+		} `json:"locations"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode locations: %w", err)
+	}
+
+	var locations []json.RawMessage
+	for _, location := range resp.Locations {
+		if !strings.HasPrefix(location.ID, privateLocationPrefix) {
+			continue
+		}
+		raw, err := fetchPrivateLocation(ctx, client, settings, location.ID)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, raw)
+	}
+	return locations, nil
+}
+
+func fetchPrivateLocation(ctx context.Context, client resource.HTTPClient, settings *config.Settings, id string) (json.RawMessage, error) {
+	return resource.FetchRawFromAPI(ctx, client, settings.APIBaseURL()+"/api/v1/synthetics/private-locations/"+url.PathEscape(id), settings)
+}
+
+// NormalizePrivateLocation removes the secrets block (the worker's access
+// keys and config decryption password), which must never be written.
+func NormalizePrivateLocation(raw json.RawMessage, _ *config.Settings) (json.RawMessage, error) {
+	raw, err := resource.StripFields(raw, "secrets")
+	if err != nil {
+		return nil, fmt.Errorf("failed to strip private location secrets: %w", err)
+	}
+	return raw, nil
+}
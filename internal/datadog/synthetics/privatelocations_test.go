@@ -0,0 +1,62 @@
+package synthetics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/resource/resourcetest"
+)
+
+const privateLocationJSON = `{"id":"pl:office-abc123","name":"Office","tags":["team:qa"],"secrets":{"authentication":{"id":"key-id","key":"key-secret"},"config_decryption":{"password":"hunter2"}},"metadata":{"restricted_roles":[]}}`
+
+func TestNormalizePrivateLocation(t *testing.T) {
+	got, err := NormalizePrivateLocation([]byte(privateLocationJSON), &config.Settings{})
+	if err != nil {
+		t.Fatalf("NormalizePrivateLocation() error = %v", err)
+	}
+	if strings.Contains(string(got), "secrets") || strings.Contains(string(got), "hunter2") {
+		t.Errorf("NormalizePrivateLocation() kept the secrets: %s", got)
+	}
+	if !strings.Contains(string(got), `"metadata"`) {
+		t.Errorf("NormalizePrivateLocation() dropped other fields: %s", got)
+	}
+}
+
+func TestPrivateLocations_Download(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/synthetics/locations":
+			w.Write([]byte(`{"locations":[{"id":"aws:eu-central-1","name":"Frankfurt (AWS)"},{"id":"pl:office-abc123","name":"Office"}]}`))
+		case "/api/v1/synthetics/private-locations/pl:office-abc123":
+			w.Write([]byte(privateLocationJSON))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                                   server.URL,
+		SyntheticsPrivateLocationsPathTemplate: filepath.Join(dir, "{id}.json"),
+		HTTPMaxBodySize:                        1024,
+	}
+
+	paths := resourcetest.DownloadAll(t, PrivateLocations, settings, resource.BaseDownloadOptions{All: true})
+	if len(paths) != 1 || paths[0] != filepath.Join(dir, "pl:office-abc123.json") {
+		t.Fatalf("downloaded %v, want only the private location", paths)
+	}
+	written, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(written), "secrets") || strings.Contains(string(written), "key-secret") {
+		t.Errorf("private location written with its secrets: %s", written)
+	}
+}
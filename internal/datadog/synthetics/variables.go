@@ -0,0 +1,103 @@
+package synthetics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+)
+
+func init() {
+	resource.Register(Variables)
+}
+
+// Variables registers Synthetics global variables as
+// "dd-tf synthetics variables".
+var Variables = &resource.ListKind{
+	KindName:   "global variable",
+	PluralName: "variables",
+	GroupName:  "synthetics",
+	IDType:     resource.IDString,
+	Template: func(settings *config.Settings) string {
+		return settings.SyntheticsVariablesPathTemplate
+	},
+	List:      listVariables,
+	Get:       fetchVariable,
+	Normalize: NormalizeVariable,
+	Tagged:    true,
+}
+
+// RedactedValue replaces the value of secure global variables.
+const RedactedValue = "REDACTED"
+
+func listVariables(ctx context.Context, client resource.HTTPClient, settings *config.Settings) ([]json.RawMessage, error) {
+	raw, err := resource.FetchRawFromAPI(ctx, client, settings.APIBaseURL()+"/api/v1/synthetics/variables", settings)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Variables []json.RawMessage `json:"variables"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode global variables: %w", err)
+	}
+	return resp.Variables, nil
+}
+
+func fetchVariable(ctx context.Context, client resource.HTTPClient, settings *config.Settings, id string) (json.RawMessage, error) {
+	return resource.FetchRawFromAPI(ctx, client, settings.APIBaseURL()+"/api/v1/synthetics/variables/"+url.PathEscape(id), settings)
+}
+
+// NormalizeVariable replaces the value of a secure global variable with
+// RedactedValue, unless SYNTHETICS_REDACT_SECURE is off. The API returns
+// secure values in plain text to callers allowed to read them. A variable
+// is secure when is_secure or value.secure is true.
+func NormalizeVariable(raw json.RawMessage, settings *config.Settings) (json.RawMessage, error) {
+	if !settings.SyntheticsRedactSecure {
+		return raw, nil
+	}
+	var variable struct {
+		IsSecure bool            `json:"is_secure"`
+		Value    json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &variable); err != nil {
+		return nil, fmt.Errorf("failed to decode global variable: %w", err)
+	}
+	if !variable.IsSecure && !isSecureValue(variable.Value) {
+		return raw, nil
+	}
+
+	redacted, _ := json.Marshal(RedactedValue)
+	raw, err := resource.RewriteObject(raw, func(key string, value json.RawMessage) (json.RawMessage, bool, error) {
+		if key != "value" {
+			return value, true, nil
+		}
+		if trimmed := bytes.TrimSpace(value); len(trimmed) == 0 || trimmed[0] != '{' {
+			return redacted, true, nil
+		}
+		value, err := resource.RewriteObject(value, func(key string, value json.RawMessage) (json.RawMessage, bool, error) {
+			if key == "value" {
+				return redacted, true, nil
+			}
+			return value, true, nil
+		})
+		return value, true, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to redact global variable: %w", err)
+	}
+	return raw, nil
+}
+
+// isSecureValue reports whether a global variable's value object is marked
+// secure.
+func isSecureValue(value json.RawMessage) bool {
+	var v struct {
+		Secure bool `json:"secure"`
+	}
+	return json.Unmarshal(value, &v) == nil && v.Secure
+}
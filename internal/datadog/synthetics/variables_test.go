@@ -0,0 +1,133 @@
+package synthetics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+)
+
+func TestNormalizeVariable(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		redact bool
+		want   string
+	}{
+		{
+			name:   "secure value object",
+			raw:    `{"id":"1","value":{"secure":true,"value":"hunter2","options":{}}}`,
+			redact: true,
+			want:   `{"id":"1","value":{"secure":true,"value":"REDACTED","options":{}}}`,
+		},
+		{
+			name:   "is_secure with a plain value",
+			raw:    `{"id":"1","is_secure":true,"value":"hunter2"}`,
+			redact: true,
+			want:   `{"id":"1","is_secure":true,"value":"REDACTED"}`,
+		},
+		{
+			name:   "not secure",
+			raw:    `{"id":"1","value":{"secure":false,"value":"https://example.com"}}`,
+			redact: true,
+			want:   `{"id":"1","value":{"secure":false,"value":"https://example.com"}}`,
+		},
+		{
+			name:   "redaction disabled",
+			raw:    `{"id":"1","value":{"secure":true,"value":"hunter2"}}`,
+			redact: false,
+			want:   `{"id":"1","value":{"secure":true,"value":"hunter2"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeVariable([]byte(tt.raw), &config.Settings{SyntheticsRedactSecure: tt.redact})
+			if err != nil {
+				t.Fatalf("NormalizeVariable() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("NormalizeVariable() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVariables_TagsAndUpdate(t *testing.T) {
+	const id = "6a1ff4b0-2a53-4f7c-a9bb-1d4bd5a4c9e2"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/synthetics/variables":
+			w.Write([]byte(`{"variables":[` +
+				`{"id":"` + id + `","name":"API_TOKEN","tags":["team:qa"],"value":{"secure":true,"value":"hunter2"}},` +
+				`{"id":"0c9b3d70-5a2e-4a8b-8e0e-7f3f5c1b2d11","name":"BASE_URL","tags":["team:web"],"value":{"secure":false,"value":"https://example.com"}}]}`))
+		case "/api/v1/synthetics/variables/" + id:
+			w.Write([]byte(`{"id":"` + id + `","name":"API_TOKEN","tags":["team:qa"],"value":{"secure":true,"value":"hunter2"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:                            server.URL,
+		SyntheticsVariablesPathTemplate: filepath.Join(dir, "{team}", "{name}.json"),
+		SyntheticsRedactSecure:          true,
+		HTTPMaxBodySize:                 1024,
+	}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+	ctx := context.Background()
+
+	download := func(opts resource.BaseDownloadOptions) []string {
+		t.Helper()
+		targets, err := Variables.Targets(ctx, client, settings, opts, nil)
+		if err != nil {
+			t.Fatalf("Targets() error = %v", err)
+		}
+		var paths []string
+		for result := range targets {
+			if result.Err != nil {
+				t.Fatalf("Targets() error = %v", result.Err)
+			}
+			path, err := Variables.Download(ctx, client, settings, result.Target, "")
+			if err != nil {
+				t.Fatalf("Download() error = %v", err)
+			}
+			paths = append(paths, path)
+		}
+		return paths
+	}
+
+	want := filepath.Join(dir, "qa", "API-TOKEN.json")
+	if paths := download(resource.BaseDownloadOptions{Tags: "team:qa"}); len(paths) != 1 || paths[0] != want {
+		t.Fatalf("download --tags=team:qa wrote %v, want [%s]", paths, want)
+	}
+	written, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var variable struct {
+		ID    string `json:"id"`
+		Value struct {
+			Value string `json:"value"`
+		} `json:"value"`
+	}
+	if err := json.Unmarshal(written, &variable); err != nil {
+		t.Fatal(err)
+	}
+	if variable.Value.Value != RedactedValue {
+		t.Errorf("secure value written as %q, want it redacted", variable.Value.Value)
+	}
+
+	// --update finds the file by its UUID and rewrites it in place
+	if paths := download(resource.BaseDownloadOptions{Update: true}); len(paths) != 1 || paths[0] != want {
+		t.Errorf("download --update wrote %v, want [%s]", paths, want)
+	}
+}
@@ -0,0 +1,58 @@
+// Package teams downloads Datadog team definitions.
+package teams
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/logging"
+)
+
+func init() {
+	resource.Register(Kind)
+}
+
+// Kind registers teams as "dd-tf teams". Teams are identified by their
+// handle, the x in team:x tags, for --id and --update alike. They are
+// written as the v2 "data" item.
+var Kind = &resource.ListKind{
+	KindName:      "team",
+	PluralName:    "teams",
+	IDType:        resource.IDString,
+	IDField:       "attributes.handle",
+	IDPlaceholder: "{handle}",
+	Template: func(settings *config.Settings) string {
+		return settings.TeamsPathTemplate
+	},
+	List:   listTeams,
+	Decode: decodeTeam,
+}
+
+// maxPageSize is the largest page the teams API returns.
+const maxPageSize = 100
+
+// listTeams pages through /api/v2/team. The team API looks teams up by UUID
+// rather than handle, so teams are always picked out of this list.
+func listTeams(ctx context.Context, client resource.HTTPClient, settings *config.Settings) ([]json.RawMessage, error) {
+	teams, err := resource.FetchDataPages(ctx, client, settings.APIBaseURL()+"/api/v2/team", min(settings.PageSize, maxPageSize), settings)
+	if err != nil {
+		return nil, err
+	}
+	logging.Logger.Debug("teams listed", "count", len(teams))
+	return teams, nil
+}
+
+func decodeTeam(raw json.RawMessage) (resource.Meta, error) {
+	var team struct {
+		Attributes struct {
+			Handle string `json:"handle"`
+			Name   string `json:"name"`
+		} `json:"attributes"`
+	}
+	if err := json.Unmarshal(raw, &team); err != nil {
+		return resource.Meta{}, err
+	}
+	return resource.Meta{ID: team.Attributes.Handle, Name: team.Attributes.Name}, nil
+}
@@ -0,0 +1,83 @@
+package teams
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/resource/resourcetest"
+)
+
+func newTestServer(t *testing.T, requests *[]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/team" {
+			http.NotFound(w, r)
+			return
+		}
+		*requests = append(*requests, r.URL.Query().Get("page[number]"))
+		switch r.URL.Query().Get("page[number]") {
+		case "0":
+			w.Write([]byte(`{"data":[` +
+				`{"type":"team","id":"uuid-sre","attributes":{"handle":"sre","name":"Site Reliability","description":"On-call","user_count":5}},` +
+				`{"type":"team","id":"uuid-web","attributes":{"handle":"web","name":"Web","user_count":3}}]}`))
+		default:
+			w.Write([]byte(`{"data":[{"type":"team","id":"uuid-data","attributes":{"handle":"data","name":"Data","user_count":2}}]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestKind_Targets(t *testing.T) {
+	var requests []string
+	server := newTestServer(t, &requests)
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:              server.URL,
+		TeamsPathTemplate: filepath.Join(dir, "{handle}.json"),
+		PageSize:          2,
+		HTTPMaxBodySize:   4096,
+	}
+
+	if got := resourcetest.Bases(resourcetest.DownloadAll(t, Kind, settings, resource.BaseDownloadOptions{All: true})); strings.Join(got, ",") != "data.json,sre.json,web.json" {
+		t.Errorf("download --all wrote %v", got)
+	}
+	if strings.Join(requests, ",") != "0,1" {
+		t.Errorf("requested pages %v, want [0 1]", requests)
+	}
+	if got := resourcetest.Bases(resourcetest.DownloadAll(t, Kind, settings, resource.BaseDownloadOptions{IDs: "sre,data"})); strings.Join(got, ",") != "data.json,sre.json" {
+		t.Errorf("download --id=sre,data wrote %v", got)
+	}
+
+	written, err := os.ReadFile(filepath.Join(dir, "sre.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(written), `"user_count": 5`) || !strings.Contains(string(written), `"description": "On-call"`) {
+		t.Errorf("team written as %s", written)
+	}
+}
+
+func TestKind_UpdateByHandle(t *testing.T) {
+	var requests []string
+	server := newTestServer(t, &requests)
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:              server.URL,
+		TeamsPathTemplate: filepath.Join(dir, "{handle}.json"),
+		PageSize:          100,
+		HTTPMaxBodySize:   4096,
+	}
+	if err := os.WriteFile(filepath.Join(dir, "reliability.json"), []byte(`{"type":"team","id":"uuid-sre","attributes":{"handle":"sre"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := resourcetest.Bases(resourcetest.DownloadAll(t, Kind, settings, resource.BaseDownloadOptions{Update: true})); strings.Join(got, ",") != "reliability.json" {
+		t.Errorf("download --update wrote %v, want [reliability.json]", got)
+	}
+}
@@ -6,23 +6,31 @@ import (
 	"github.com/AD7six/dd-tf/internal/storage"
 )
 
-// ExtractTagMap converts a raw tags value (typically []any or []interface{}) into a map[key]value.
+// ExtractTagMap converts a raw tags value ([]string, or []any / []interface{}
+// as produced by decoding into a map) into a map[key]value.
 // If sanitize is true, values are sanitized via storage.SanitizeFilename.
 func ExtractTagMap(raw any, sanitize bool) map[string]string {
 	tagMap := make(map[string]string)
+	add := func(s string) {
+		parts := strings.SplitN(s, ":", 2)
+		if len(parts) == 2 {
+			key := strings.TrimSpace(parts[0])
+			val := strings.TrimSpace(parts[1])
+			if sanitize {
+				val = storage.SanitizeFilename(val)
+			}
+			tagMap[key] = val
+		}
+	}
 	switch v := raw.(type) {
+	case []string:
+		for _, s := range v {
+			add(s)
+		}
 	case []interface{}:
 		for _, t := range v {
 			if s, ok := t.(string); ok {
-				parts := strings.SplitN(s, ":", 2)
-				if len(parts) == 2 {
-					key := strings.TrimSpace(parts[0])
-					val := strings.TrimSpace(parts[1])
-					if sanitize {
-						val = storage.SanitizeFilename(val)
-					}
-					tagMap[key] = val
-				}
+				add(s)
 			}
 		}
 	}
@@ -115,6 +115,19 @@ func TestExtractTagMap(t *testing.T) {
 				"env":  "prod",
 			},
 		},
+		{
+			name: "string slice input",
+			raw: []string{
+				"team:platform Team",
+				"env:prod",
+				"invalid",
+			},
+			sanitize: true,
+			want: map[string]string{
+				"team": "platform-Team",
+				"env":  "prod",
+			},
+		},
 		{
 			name:     "wrong type input",
 			raw:      "not-a-slice",
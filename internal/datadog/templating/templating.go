@@ -16,6 +16,10 @@ var (
 
 	// EnvVarRegex matches environment variable naming pattern (uppercase letters, numbers, underscores)
 	EnvVarRegex = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+	// tagPlaceholderRegex matches the tag expressions TranslatePlaceholders
+	// writes, e.g. {{.Tags.team}}
+	tagPlaceholderRegex = regexp.MustCompile(`\{\{\.Tags\.([A-Za-z0-9_\-]+)\}\}`)
 )
 
 // replaceEnvVars replaces environment variable placeholders in a string.
@@ -68,12 +72,32 @@ func TranslatePlaceholders(pattern string, builtins map[string]string) string {
 	return p
 }
 
+// ReferencesTags reports whether a path pattern needs tag values once its
+// builtins and environment variables have been resolved, i.e. whether any
+// placeholder would be rendered from {{.Tags.x}}.
+func ReferencesTags(pattern string, builtins map[string]string) bool {
+	return strings.Contains(TranslatePlaceholders(pattern, builtins), "{{.Tags.")
+}
+
+// MissingTags returns the tag placeholders in pattern that tags has no value
+// for; they render as "none".
+func MissingTags(pattern string, builtins map[string]string, tags map[string]string) []string {
+	var missing []string
+	for _, m := range tagPlaceholderRegex.FindAllStringSubmatch(TranslatePlaceholders(pattern, builtins), -1) {
+		if _, ok := tags[m[1]]; !ok {
+			missing = append(missing, m[1])
+		}
+	}
+	return missing
+}
+
 // BuildDashboardBuiltins returns the builtins map for dashboard path templates.
 func BuildDashboardBuiltins() map[string]string {
 	return map[string]string{
 		"{id}":    "{{.ID}}",
 		"{title}": "{{.Title}}",
 		"{name}":  "{{.Title}}", // Alias for consistency with monitors
+		"{list}":  "{{.List}}",
 	}
 }
 
@@ -2,6 +2,7 @@ package templating
 
 import (
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -200,3 +201,40 @@ func TestExtractStaticPrefix(t *testing.T) {
 		})
 	}
 }
+
+func TestReferencesTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    bool
+	}{
+		{"builtins only", "data/dashboards/{id}-{title}.json", false},
+		{"static path", "data/dashboards/dash.json", false},
+		{"tag placeholder", "data/dashboards/{team}/{id}.json", true},
+		{"unset env var falls back to tag", "{REFERENCES_TAGS_UNSET}/{id}.json", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReferencesTags(tt.pattern, BuildDashboardBuiltins()); got != tt.want {
+				t.Errorf("ReferencesTags(%q) = %v, want %v", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissingTags(t *testing.T) {
+	tags := map[string]string{"team": "platform"}
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"data/dashboards/{id}.json", ""},
+		{"data/dashboards/{team}/{id}.json", ""},
+		{"data/dashboards/{team}/{env}/{service}-{id}.json", "env,service"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(MissingTags(tt.pattern, BuildDashboardBuiltins(), tags), ","); got != tt.want {
+			t.Errorf("MissingTags(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Package users downloads Datadog users from the v2 users API.
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	resource.Register(Kind)
+}
+
+// Kind registers users as "dd-tf users". Users are keyed by their UUID and
+// named by their handle, usually an email address. They are written as the
+// v2 "data" item, keeping the relationships to their roles.
+var Kind = &resource.ListKind{
+	KindName:        "user",
+	PluralName:      "users",
+	IDType:          resource.IDString,
+	NamePlaceholder: "{handle}",
+	SanitizeName:    SanitizeHandle,
+	Template: func(settings *config.Settings) string {
+		return settings.UsersPathTemplate
+	},
+	List:   listUsers,
+	Get:    fetchUser,
+	Decode: decodeUser,
+	Flags: func(flags *pflag.FlagSet) {
+		flags.Bool("include-disabled", false, "Also select disabled users")
+		flags.Bool("include-pending", false, "Also select users who haven't accepted their invitation")
+	},
+	Filter: userFilter,
+}
+
+// maxPageSize is the largest page the users API returns.
+const maxPageSize = 100
+
+type user struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		Handle   string `json:"handle"`
+		Status   string `json:"status"`
+		Disabled bool   `json:"disabled"`
+	} `json:"attributes"`
+}
+
+// listUsers pages through /api/v2/users.
+func listUsers(ctx context.Context, client resource.HTTPClient, settings *config.Settings) ([]json.RawMessage, error) {
+	users, err := resource.FetchDataPages(ctx, client, settings.APIBaseURL()+"/api/v2/users", min(settings.PageSize, maxPageSize), settings)
+	if err != nil {
+		return nil, err
+	}
+	logging.Logger.Debug("users listed", "count", len(users))
+	return users, nil
+}
+
+func fetchUser(ctx context.Context, client resource.HTTPClient, settings *config.Settings, id string) (json.RawMessage, error) {
+	return resource.FetchData(ctx, client, settings.APIBaseURL()+"/api/v2/users/"+url.PathEscape(id), settings)
+}
+
+func decodeUser(raw json.RawMessage) (resource.Meta, error) {
+	var u user
+	if err := json.Unmarshal(raw, &u); err != nil {
+		return resource.Meta{}, err
+	}
+	return resource.Meta{ID: u.ID, Name: u.Attributes.Handle}, nil
+}
+
+// userFilter skips disabled and pending users unless --include-disabled or
+// --include-pending is passed.
+func userFilter(flags *pflag.FlagSet) (func(json.RawMessage) bool, error) {
+	includeDisabled, err := flags.GetBool("include-disabled")
+	if err != nil {
+		return nil, err
+	}
+	includePending, err := flags.GetBool("include-pending")
+	if err != nil {
+		return nil, err
+	}
+	return func(raw json.RawMessage) bool {
+		var u user
+		if err := json.Unmarshal(raw, &u); err != nil {
+			return false
+		}
+		if u.Attributes.Disabled || u.Attributes.Status == "Disabled" {
+			return includeDisabled
+		}
+		if u.Attributes.Status == "Pending" {
+			return includePending
+		}
+		return true
+	}, nil
+}
+
+var unsafeHandleRegex = regexp.MustCompile(`[^A-Za-z0-9@._+-]+`)
+
+// SanitizeHandle makes a handle safe for paths while keeping it readable:
+// "jane.doe@example.com" stays as it is, rather than becoming
+// "jane-doe-example-com" as with storage.SanitizeFilename.
+func SanitizeHandle(handle string) string {
+	handle = strings.Trim(unsafeHandleRegex.ReplaceAllString(handle, "-"), "-.")
+	if handle == "" {
+		return "untitled"
+	}
+	return handle
+}
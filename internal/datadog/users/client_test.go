@@ -0,0 +1,107 @@
+package users
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/resource/resourcetest"
+)
+
+func userJSON(id, handle, status string) string {
+	return fmt.Sprintf(`{"type":"users","id":%q,"attributes":{"handle":%q,"email":%q,"status":%q,"disabled":%t},"relationships":{"roles":{"data":[{"type":"roles","id":"role-1"}]}}}`,
+		id, handle, handle, status, status == "Disabled")
+}
+
+func TestSanitizeHandle(t *testing.T) {
+	tests := map[string]string{
+		"jane.doe@example.com":  "jane.doe@example.com",
+		"john+ops@example.com":  "john+ops@example.com",
+		"../etc/passwd":         "etc-passwd",
+		"weird name@example.io": "weird-name@example.io",
+		"///":                   "untitled",
+	}
+	for handle, want := range tests {
+		if got := SanitizeHandle(handle); got != want {
+			t.Errorf("SanitizeHandle(%q) = %q, want %q", handle, got, want)
+		}
+	}
+}
+
+func TestKind_Targets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/users" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"data":[` +
+			userJSON("u1", "jane.doe@example.com", "Active") + `,` +
+			userJSON("u2", "gone@example.com", "Disabled") + `,` +
+			userJSON("u3", "new@example.com", "Pending") +
+			`],"included":[{"type":"roles","id":"role-1"}]}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:              server.URL,
+		UsersPathTemplate: filepath.Join(dir, "{handle}.json"),
+		PageSize:          100,
+		HTTPMaxBodySize:   4096,
+	}
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{nil, []string{"jane.doe@example.com.json"}},
+		{[]string{"--include-disabled"}, []string{"gone@example.com.json", "jane.doe@example.com.json"}},
+		{[]string{"--include-disabled", "--include-pending"}, []string{"gone@example.com.json", "jane.doe@example.com.json", "new@example.com.json"}},
+	}
+	for _, tt := range tests {
+		got := resourcetest.Bases(resourcetest.DownloadAll(t, Kind, settings, resource.BaseDownloadOptions{All: true}, tt.args...))
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("download --all %v wrote %v, want %v", tt.args, got, tt.want)
+		}
+	}
+
+	written, err := os.ReadFile(filepath.Join(dir, "jane.doe@example.com.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(written), `"relationships"`) || !strings.Contains(string(written), `"role-1"`) {
+		t.Errorf("user written as %s, want its role relationships", written)
+	}
+}
+
+func TestKind_UpdateByUUID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/users/u1" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"data":` + userJSON("u1", "jane.doe@example.com", "Active") + `}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := &config.Settings{
+		Site:              server.URL,
+		UsersPathTemplate: filepath.Join(dir, "{handle}.json"),
+		HTTPMaxBodySize:   4096,
+	}
+	existing := filepath.Join(dir, "jane.json")
+	if err := os.WriteFile(existing, []byte(`{"type":"users","id":"u1"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if paths := resourcetest.DownloadAll(t, Kind, settings, resource.BaseDownloadOptions{Update: true}); len(paths) != 1 || paths[0] != existing {
+		t.Errorf("download --update wrote %v, want [%s]", paths, existing)
+	}
+}
@@ -0,0 +1,206 @@
+// Package drift compares downloaded dashboards and monitors with Datadog,
+// and reports which are in sync, which drifted, and which only exist on one
+// side.
+package drift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/AD7six/dd-tf/internal/datadog/diff"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+// Status is how a resource compares with Datadog.
+type Status string
+
+const (
+	InSync        Status = "in-sync"
+	Drifted       Status = "drifted"        // The local file differs from the remote resource
+	MissingRemote Status = "missing-remote" // The local file has no remote resource
+	MissingLocal  Status = "missing-local"  // The remote resource has no local file
+	Failed        Status = "failed"         // The resource couldn't be compared
+)
+
+// workers is how many resources are fetched and compared at once.
+const workers = 4
+
+// Source is one kind of resource to check.
+type Source struct {
+	Kind   string          // e.g. "dashboard"
+	Dir    string          // Where the kind's files are, scanned recursively
+	IDKind resource.IDKind // The type of the kind's IDs
+	Ignore []string        // Top-level fields the API sets itself, ignored when comparing
+
+	// Compare returns the resource in the file at path and the remote one,
+	// both normalized, or an error wrapping resource.ErrNotFound if it
+	// doesn't exist remotely
+	Compare func(ctx context.Context, path string) (local, remote []byte, err error)
+
+	// List returns the ID of every remote resource of the kind
+	List func(ctx context.Context) (map[string]bool, error)
+}
+
+// Entry is the outcome for one resource.
+type Entry struct {
+	Kind    string `json:"kind"`
+	ID      string `json:"id"`
+	Path    string `json:"path,omitempty"` // Empty for MissingLocal
+	Status  Status `json:"status"`
+	Summary string `json:"summary,omitempty"` // The fields changed, or why it failed
+}
+
+// Result is the outcome of a check, in kind then path order, with
+// MissingLocal entries last in each kind, by ID.
+type Result struct {
+	Entries []Entry
+}
+
+// Count returns the number of entries with status.
+func (r *Result) Count(status Status) int {
+	n := 0
+	for _, e := range r.Entries {
+		if e.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// With returns the entries with status.
+func (r *Result) With(status Status) []Entry {
+	entries := []Entry{}
+	for _, e := range r.Entries {
+		if e.Status == status {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Err returns an error if a resource couldn't be checked, or, when
+// exitCode is set, a *resource.DifferencesError if any resource isn't in
+// sync.
+func (r *Result) Err(exitCode bool) error {
+	if failed := r.Count(Failed); failed > 0 {
+		return fmt.Errorf("%d resource(s) couldn't be checked", failed)
+	}
+	if differ := len(r.Entries) - r.Count(InSync); exitCode && differ > 0 {
+		return &resource.DifferencesError{Kind: "resource", Count: differ}
+	}
+	return nil
+}
+
+// name is how e is named in logs and reports, e.g. "Monitor 12".
+func (e Entry) name() string {
+	return strings.ToUpper(e.Kind[:1]) + e.Kind[1:] + " " + e.ID
+}
+
+// LogEntries logs each entry that isn't in sync, with its path, so that CI
+// annotations (see logging.EnableAnnotations) land on its file: drifted and
+// failed entries as errors, those only on one side as warnings.
+func (r *Result) LogEntries() {
+	for _, e := range r.Entries {
+		name := e.name()
+		switch e.Status {
+		case Drifted:
+			logging.Logger.Error(name+" drifted from remote", "path", e.Path, "fields", e.Summary)
+		case MissingRemote:
+			logging.Logger.Warn(name+" only exists locally, deleted from remote", "path", e.Path)
+		case MissingLocal:
+			logging.Logger.Warn(name + " only exists remotely, not downloaded")
+		case Failed:
+			if e.ID == "" {
+				logging.Logger.Error("failed to check "+e.Kind+"s", "path", e.Path, "error", e.Summary)
+				continue
+			}
+			logging.Logger.Error(name+" couldn't be checked", "path", e.Path, "error", e.Summary)
+		}
+	}
+}
+
+// Check compares every file under each source's directory with its remote
+// resource, then lists the remote resources without a file. Failures to
+// compare a resource are Failed entries; a source that can't be scanned or
+// listed is also a Failed entry, with no ID, and then no MissingLocal
+// entries are reported for it.
+func Check(ctx context.Context, backend storage.Backend, sources []Source) *Result {
+	result := &Result{}
+	for _, src := range sources {
+		result.Entries = append(result.Entries, checkSource(ctx, backend, src)...)
+	}
+	return result
+}
+
+func checkSource(ctx context.Context, backend storage.Backend, src Source) []Entry {
+	local, err := resource.ExtractLocalIDs(backend, src.Dir, "id", src.IDKind)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return []Entry{{Kind: src.Kind, Path: src.Dir, Status: Failed, Summary: fmt.Sprintf("failed to scan: %v", err)}}
+	}
+
+	var (
+		mu      sync.Mutex
+		entries = make([]Entry, 0, len(local))
+		wg      sync.WaitGroup
+		work    = make(chan Entry)
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range work {
+				entry = compare(ctx, src, entry)
+				mu.Lock()
+				entries = append(entries, entry)
+				mu.Unlock()
+			}
+		}()
+	}
+	for id, path := range local {
+		work <- Entry{Kind: src.Kind, ID: id, Path: path}
+	}
+	close(work)
+	wg.Wait()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	remote, err := src.List(ctx)
+	if err != nil {
+		return append(entries, Entry{Kind: src.Kind, Status: Failed, Summary: fmt.Sprintf("failed to list remote %ss: %v", src.Kind, err)})
+	}
+	var missing []Entry
+	for id := range remote {
+		if _, ok := local[id]; !ok {
+			missing = append(missing, Entry{Kind: src.Kind, ID: id, Status: MissingLocal})
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i].ID < missing[j].ID })
+	return append(entries, missing...)
+}
+
+// compare fills in the status of a local file's entry.
+func compare(ctx context.Context, src Source, entry Entry) Entry {
+	local, remote, err := src.Compare(ctx, entry.Path)
+	if errors.Is(err, resource.ErrNotFound) {
+		entry.Status = MissingRemote
+		return entry
+	}
+	if err == nil {
+		entry.Summary, err = diff.Summary(remote, local, src.Ignore...)
+	}
+	switch {
+	case err != nil:
+		entry.Status, entry.Summary = Failed, err.Error()
+	case entry.Summary == "":
+		entry.Status = InSync
+	default:
+		entry.Status = Drifted
+	}
+	return entry
+}
@@ -0,0 +1,309 @@
+package drift
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/dashboards"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/report"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+// testSource is a source whose remote resources are in remote, by ID.
+func testSource(dir string, remote map[string]string) Source {
+	return Source{
+		Kind:   "monitor",
+		Dir:    dir,
+		IDKind: resource.IDNumeric,
+		Ignore: []string{"id"},
+		Compare: func(ctx context.Context, path string) ([]byte, []byte, error) {
+			local, err := os.ReadFile(path)
+			if err != nil {
+				return nil, nil, err
+			}
+			var meta struct{ ID json.Number }
+			json.Unmarshal(local, &meta)
+			raw, ok := remote[meta.ID.String()]
+			if !ok {
+				return nil, nil, resource.ErrNotFound
+			}
+			if raw == "" {
+				return nil, nil, errors.New("boom")
+			}
+			return local, []byte(raw), nil
+		},
+		List: func(ctx context.Context) (map[string]bool, error) {
+			ids := map[string]bool{}
+			for id := range remote {
+				ids[id] = true
+			}
+			return ids, nil
+		},
+	}
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCheck(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"1.json": `{"id":1,"name":"a","query":"q"}`,
+		"2.json": `{"id":2,"name":"b","query":"q2"}`,
+		"3.json": `{"id":3,"name":"c"}`,
+		"4.json": `{"id":4,"name":"d"}`,
+	})
+	src := testSource(dir, map[string]string{
+		"1": `{"id":1,"query":"q","name":"a"}`,
+		"2": `{"id":2,"name":"b","query":"q1"}`,
+		"4": ``,
+		"5": `{"id":5}`,
+	})
+
+	got := Check(context.Background(), storage.FileBackend{}, []Source{src}).Entries
+	want := []Entry{
+		{Kind: "monitor", ID: "1", Path: filepath.Join(dir, "1.json"), Status: InSync},
+		{Kind: "monitor", ID: "2", Path: filepath.Join(dir, "2.json"), Status: Drifted, Summary: "query"},
+		{Kind: "monitor", ID: "3", Path: filepath.Join(dir, "3.json"), Status: MissingRemote},
+		{Kind: "monitor", ID: "4", Path: filepath.Join(dir, "4.json"), Status: Failed, Summary: "boom"},
+		{Kind: "monitor", ID: "5", Status: MissingLocal},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Check() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Check()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCheck_MissingDirAndListFailure(t *testing.T) {
+	src := testSource(filepath.Join(t.TempDir(), "none"), map[string]string{"5": `{"id":5}`})
+	got := Check(context.Background(), storage.FileBackend{}, []Source{src}).Entries
+	if len(got) != 1 || got[0].Status != MissingLocal {
+		t.Errorf("Check() of a missing directory = %+v, want the remote monitor missing locally", got)
+	}
+
+	src.List = func(ctx context.Context) (map[string]bool, error) { return nil, errors.New("forbidden") }
+	got = Check(context.Background(), storage.FileBackend{}, []Source{src}).Entries
+	if len(got) != 1 || got[0].Status != Failed || got[0].Summary != "failed to list remote monitors: forbidden" {
+		t.Errorf("Check() with a failed list = %+v", got)
+	}
+}
+
+func TestLogEntries_Annotations(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"1.json": `{"id":1,"name":"a"}`,
+		"2.json": `{"id":2,"name":"b"}`,
+		"3.json": `{"id":3,"name":"c"}`,
+	})
+	src := testSource(dir, map[string]string{
+		"1": `{"id":1,"name":"a"}`,
+		"2": `{"id":2,"name":"B"}`,
+		"5": `{"id":5}`,
+	})
+	result := Check(context.Background(), storage.FileBackend{}, []Source{src})
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = orig }()
+	defer logging.InitLogger("")
+	logging.InitLogger("")
+	if err := logging.EnableAnnotations("github"); err != nil {
+		t.Fatal(err)
+	}
+
+	result.LogEntries()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"::error file=" + filepath.Join(dir, "2.json") + "::Monitor 2 drifted from remote",
+		"::warning file=" + filepath.Join(dir, "3.json") + "::Monitor 3 only exists locally",
+		"::warning::Monitor 5 only exists remotely",
+	} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Errorf("stdout = %q, want it to contain %q", out, want)
+		}
+	}
+	if bytes.Contains(out, []byte("Monitor 1 ")) {
+		t.Errorf("stdout = %q, want no annotation for the monitor in sync", out)
+	}
+}
+
+func testResult() *Result {
+	return &Result{Entries: []Entry{
+		{Kind: "dashboard", ID: "abc-def-ghi", Path: "data/dashboards/abc-def-ghi.json", Status: InSync},
+		{Kind: "dashboard", ID: "jkl-mno-pqr", Path: "data/dashboards/jkl-mno-pqr.json", Status: Drifted, Summary: "title; widgets: 1 changed"},
+		{Kind: "monitor", ID: "7", Path: "data/monitors/7.json", Status: MissingRemote},
+		{Kind: "monitor", ID: "9", Status: MissingLocal},
+	}}
+}
+
+func TestWrite_Table(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatTable, testResult()); err != nil {
+		t.Fatal(err)
+	}
+	want := `STATUS          COUNT
+in-sync         1
+drifted         1
+missing-remote  1
+missing-local   1
+failed          0
+
+KIND       ID           STATUS          PATH                              SUMMARY
+dashboard  jkl-mno-pqr  drifted         data/dashboards/jkl-mno-pqr.json  title; widgets: 1 changed
+monitor    7            missing-remote  data/monitors/7.json              -
+monitor    9            missing-local   -                                 -
+`
+	if buf.String() != want {
+		t.Errorf("Write(table) =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestWrite_Markdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatMarkdown, testResult()); err != nil {
+		t.Fatal(err)
+	}
+	want := "## Drift report\n\n" +
+		"| Status | Count |\n| --- | ---: |\n" +
+		"| In sync | 1 |\n| Drifted | 1 |\n| Missing remotely | 1 |\n| Missing locally | 1 |\n| Failed | 0 |\n\n" +
+		"### Drifted\n\n| Kind | ID | Path | Summary |\n| --- | --- | --- | --- |\n" +
+		"| dashboard | `jkl-mno-pqr` | `data/dashboards/jkl-mno-pqr.json` | title; widgets: 1 changed |\n\n" +
+		"### Missing remotely\n\n| Kind | ID | Path | Summary |\n| --- | --- | --- | --- |\n" +
+		"| monitor | `7` | `data/monitors/7.json` | - |\n\n" +
+		"### Missing locally\n\n| Kind | ID | Path | Summary |\n| --- | --- | --- | --- |\n" +
+		"| monitor | `9` | - | - |\n"
+	if buf.String() != want {
+		t.Errorf("Write(markdown) =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestWrite_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, testResult()); err != nil {
+		t.Fatal(err)
+	}
+	var got jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Write(json) = %s: %v", buf.String(), err)
+	}
+	if got.InSync != 1 || len(got.Drifted) != 1 || got.Drifted[0].Summary != "title; widgets: 1 changed" || len(got.MissingRemote) != 1 || len(got.MissingLocal) != 1 || got.Failed == nil {
+		t.Errorf("Write(json) = %s", buf.String())
+	}
+
+	if err := Write(&buf, "yaml", testResult()); err == nil {
+		t.Error("Write(yaml) expected an error")
+	}
+}
+
+func TestResult_Report(t *testing.T) {
+	var buf bytes.Buffer
+	if err := report.Write(&buf, report.FormatText, testResult().Report()); err != nil {
+		t.Fatal(err)
+	}
+	want := `data/dashboards/jkl-mno-pqr.json: [drifted] Dashboard jkl-mno-pqr drifted from remote: title; widgets: 1 changed
+data/monitors/7.json: [missing-remote] Monitor 7 only exists locally, deleted from remote
+9: [missing-local] Monitor 9 only exists remotely, not downloaded
+`
+	if buf.String() != want {
+		t.Errorf("Report() as text =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := report.Write(&buf, report.FormatJUnit, testResult().Report()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `tests="4" failures="3"`) {
+		t.Errorf("Report() as JUnit = %s, want 4 test cases with 3 failures", buf.String())
+	}
+}
+
+func TestResultErr_ExitCodes(t *testing.T) {
+	remote := `{"id":"abc-def-ghi","title":"Web","modified_at":"2024-03-01","widgets":[]}`
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case status != http.StatusOK:
+			w.WriteHeader(status)
+			w.Write([]byte(`{"errors":["Forbidden"]}`))
+		case r.URL.Path == "/api/v1/dashboard/abc-def-ghi":
+			w.Write([]byte(remote))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 4096}
+	client := internalhttp.NewClient(&config.Settings{APIKey: "test-api-key", AppKey: "test-app-key"})
+	dir := writeFiles(t, map[string]string{
+		"abc-def-ghi.json": `{"id":"abc-def-ghi","title":"Web","widgets":[]}`,
+	})
+	src := Source{
+		Kind:   resource.KindDashboard,
+		Dir:    dir,
+		IDKind: resource.IDString,
+		Ignore: dashboards.ServerFields,
+		Compare: func(ctx context.Context, path string) ([]byte, []byte, error) {
+			return dashboards.CompareDashboard(ctx, client, settings, storage.FileBackend{}, path, "")
+		},
+		List: func(ctx context.Context) (map[string]bool, error) {
+			return map[string]bool{"abc-def-ghi": true}, nil
+		},
+	}
+	check := func() error {
+		return resource.WithExitCode(Check(context.Background(), storage.FileBackend{}, []Source{src}).Err(true))
+	}
+
+	if err := check(); resource.ExitCode(err) != resource.ExitOK {
+		t.Errorf("in sync: exit code %d (%v), want %d", resource.ExitCode(err), err, resource.ExitOK)
+	}
+
+	remote = `{"id":"abc-def-ghi","title":"Web (old)","modified_at":"2024-03-01","widgets":[]}`
+	var diffErr *resource.DifferencesError
+	if err := check(); resource.ExitCode(err) != resource.ExitDifferences || !errors.As(err, &diffErr) || diffErr.Count != 1 {
+		t.Errorf("drifted: exit code %d (%v), want %d", resource.ExitCode(err), err, resource.ExitDifferences)
+	}
+
+	status = http.StatusForbidden
+	var opErr *resource.OperationalError
+	if err := check(); resource.ExitCode(err) != resource.ExitError || !errors.As(err, &opErr) {
+		t.Errorf("forbidden: exit code %d (%v), want %d", resource.ExitCode(err), err, resource.ExitError)
+	}
+
+	// Without --exit-code, differences aren't an error
+	status = http.StatusOK
+	if err := Check(context.Background(), storage.FileBackend{}, []Source{src}).Err(false); err != nil {
+		t.Errorf("drifted without --exit-code: %v, want nil", err)
+	}
+}
@@ -0,0 +1,164 @@
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/AD7six/dd-tf/internal/report"
+)
+
+// Output formats accepted by --format.
+const (
+	FormatTable    = "table"
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+)
+
+// Write renders r to w in the given format. Resources in sync are only
+// counted; the others are listed with their summaries.
+func Write(w io.Writer, format string, r *Result) error {
+	switch format {
+	case "", FormatTable:
+		return writeTable(w, r)
+	case FormatJSON:
+		return writeJSON(w, r)
+	case FormatMarkdown:
+		return writeMarkdown(w, r)
+	default:
+		return fmt.Errorf("unknown format %q (expected table, json or markdown)", format)
+	}
+}
+
+// counts are the headings of a report, with their statuses.
+var counts = []struct {
+	Status  Status
+	Heading string
+}{
+	{InSync, "In sync"},
+	{Drifted, "Drifted"},
+	{MissingRemote, "Missing remotely"},
+	{MissingLocal, "Missing locally"},
+	{Failed, "Failed"},
+}
+
+func writeTable(w io.Writer, r *Result) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tCOUNT")
+	for _, c := range counts {
+		fmt.Fprintf(tw, "%s\t%d\n", c.Status, r.Count(c.Status))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(r.Entries) == r.Count(InSync) {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tID\tSTATUS\tPATH\tSUMMARY")
+	for _, e := range r.Entries {
+		if e.Status != InSync {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Kind, orDash(e.ID), e.Status, orDash(e.Path), orDash(oneLine(e.Summary)))
+		}
+	}
+	return tw.Flush()
+}
+
+// jsonReport is the JSON output: counts, then the resources not in sync by
+// status.
+type jsonReport struct {
+	InSync        int     `json:"in_sync"`
+	Drifted       []Entry `json:"drifted"`
+	MissingRemote []Entry `json:"missing_remote"`
+	MissingLocal  []Entry `json:"missing_local"`
+	Failed        []Entry `json:"failed"`
+}
+
+func writeJSON(w io.Writer, r *Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jsonReport{
+		InSync:        r.Count(InSync),
+		Drifted:       r.With(Drifted),
+		MissingRemote: r.With(MissingRemote),
+		MissingLocal:  r.With(MissingLocal),
+		Failed:        r.With(Failed),
+	})
+}
+
+// writeMarkdown writes a count table, then a section per status listing its
+// resources, to paste into an issue or a review document.
+func writeMarkdown(w io.Writer, r *Result) error {
+	var b strings.Builder
+	b.WriteString("## Drift report\n\n| Status | Count |\n| --- | ---: |\n")
+	for _, c := range counts {
+		fmt.Fprintf(&b, "| %s | %d |\n", c.Heading, r.Count(c.Status))
+	}
+	for _, c := range counts[1:] {
+		entries := r.With(c.Status)
+		if len(entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n| Kind | ID | Path | Summary |\n| --- | --- | --- | --- |\n", c.Heading)
+		for _, e := range entries {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", e.Kind, code(e.ID), code(e.Path), markdownCell(e.Summary))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// oneLine joins the lines of s, such as an API error's body, with spaces.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// code formats s as inline code in a table cell, or "-" if it is empty.
+func code(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return "`" + strings.ReplaceAll(s, "|", `\|`) + "`"
+}
+
+// markdownCell escapes s for a table cell, or returns "-" if it is empty.
+func markdownCell(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(oneLine(s), "|", `\|`)
+}
+
+// Report converts r into the shared report shape for --report-format, one
+// item per resource with a finding unless it's in sync. A failed entry's
+// rule is compare-failed; the others use their status.
+func (r *Result) Report() *report.Result {
+	items := make([]report.Item, 0, len(r.Entries))
+	for _, e := range r.Entries {
+		item := report.Item{Path: e.Path, Kind: e.Kind, ID: e.ID}
+		switch e.Status {
+		case InSync:
+		case Drifted:
+			item.Findings = []report.Finding{{Rule: string(Drifted), Message: e.name() + " drifted from remote: " + e.Summary}}
+		case MissingRemote:
+			item.Findings = []report.Finding{{Rule: string(MissingRemote), Message: e.name() + " only exists locally, deleted from remote"}}
+		case MissingLocal:
+			item.Findings = []report.Finding{{Rule: string(MissingLocal), Message: e.name() + " only exists remotely, not downloaded"}}
+		case Failed:
+			item.Findings = []report.Finding{{Rule: "compare-failed", Message: e.Summary}}
+		}
+		items = append(items, item)
+	}
+	return &report.Result{Command: "drift", Items: items}
+}
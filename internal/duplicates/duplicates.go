@@ -0,0 +1,148 @@
+// Package duplicates finds dashboards that are likely copies of each other:
+// the same title, or the same widgets.
+package duplicates
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+// Match types.
+const (
+	MatchTitle   = "title"   // exactly the same title
+	MatchContent = "content" // the same widgets, ignoring widget IDs
+)
+
+// Dashboard is a member of a duplicate group.
+type Dashboard struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	Path       string `json:"path,omitempty"` // local file; empty in remote mode
+	ModifiedAt string `json:"modified_at,omitempty"`
+}
+
+// Group is a set of dashboards sharing a title or content.
+type Group struct {
+	Match      string      `json:"match"`
+	Key        string      `json:"key"` // the title, or the content hash
+	Dashboards []Dashboard `json:"dashboards"`
+}
+
+// Run reads the dashboards under dir and returns the groups of two or more
+// dashboards with the same title, then those with the same widgets.
+// Dashboards without widgets aren't compared by content.
+func Run(dir string) ([]Group, error) {
+	files := storage.FileBackend{}
+	paths, err := files.List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var dashboards []Dashboard
+	hashes := map[string][]Dashboard{}
+	for _, path := range paths {
+		if !strings.HasSuffix(path, ".json") || storage.IsSidecarPath(path) {
+			continue
+		}
+		data, err := files.Read(path)
+		if err != nil {
+			return nil, err
+		}
+		var content map[string]any
+		if err := json.Unmarshal(data, &content); err != nil {
+			continue // verify reports invalid files
+		}
+		if resource.GuessKind(content) != resource.KindDashboard {
+			continue
+		}
+		d := Dashboard{Path: path}
+		d.ID, _ = content["id"].(string)
+		d.Title, _ = content["title"].(string)
+		d.ModifiedAt, _ = content["modified_at"].(string)
+		dashboards = append(dashboards, d)
+
+		if widgets, _ := content["widgets"].([]any); len(widgets) > 0 {
+			hash := ContentHash(widgets)
+			hashes[hash] = append(hashes[hash], d)
+		}
+	}
+
+	groups := ByTitle(dashboards)
+	return append(groups, collectGroups(MatchContent, hashes)...), nil
+}
+
+// ByTitle groups dashboards with exactly the same title.
+func ByTitle(dashboards []Dashboard) []Group {
+	titles := map[string][]Dashboard{}
+	for _, d := range dashboards {
+		titles[d.Title] = append(titles[d.Title], d)
+	}
+	return collectGroups(MatchTitle, titles)
+}
+
+// ContentHash hashes decoded widgets with every widget id removed, so copies
+// of a dashboard hash the same. Keys are sorted by json.Marshal, so the hash
+// doesn't depend on key order.
+func ContentHash(widgets []any) string {
+	data, _ := json.Marshal(stripIDs(widgets)) // decoded JSON always marshals
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// stripIDs returns a copy of widgets without their id fields, recursing into
+// group widgets.
+func stripIDs(widgets []any) []any {
+	out := make([]any, len(widgets))
+	for i, w := range widgets {
+		widget, ok := w.(map[string]any)
+		if !ok {
+			out[i] = w
+			continue
+		}
+		copied := make(map[string]any, len(widget))
+		for k, v := range widget {
+			if k == "id" {
+				continue
+			}
+			copied[k] = v
+		}
+		if def, ok := widget["definition"].(map[string]any); ok {
+			if nested, ok := def["widgets"].([]any); ok {
+				defCopy := make(map[string]any, len(def))
+				for k, v := range def {
+					defCopy[k] = v
+				}
+				defCopy["widgets"] = stripIDs(nested)
+				copied["definition"] = defCopy
+			}
+		}
+		out[i] = copied
+	}
+	return out
+}
+
+// collectGroups returns the entries of byKey with more than one dashboard, sorted
+// by key, each sorted by path then ID.
+func collectGroups(match string, byKey map[string][]Dashboard) []Group {
+	var groups []Group
+	for key, dashboards := range byKey {
+		if len(dashboards) < 2 {
+			continue
+		}
+		sort.Slice(dashboards, func(i, j int) bool {
+			if dashboards[i].Path != dashboards[j].Path {
+				return dashboards[i].Path < dashboards[j].Path
+			}
+			return dashboards[i].ID < dashboards[j].ID
+		})
+		groups = append(groups, Group{Match: match, Key: key, Dashboards: dashboards})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	return groups
+}
@@ -0,0 +1,65 @@
+package duplicates
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var dataDir = filepath.Join("testdata", "data")
+
+func TestRun(t *testing.T) {
+	groups, err := Run(dataDir)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	dashboards := filepath.Join(dataDir, "dashboards")
+	aaa := Dashboard{ID: "aaa-aaa-aaa", Title: "API", Path: filepath.Join(dashboards, "aaa-aaa-aaa.json"), ModifiedAt: "2024-01-02T10:00:00.000000+00:00"}
+	bbb := Dashboard{ID: "bbb-bbb-bbb", Title: "Copy of API", Path: filepath.Join(dashboards, "team", "bbb-bbb-bbb.json"), ModifiedAt: "2025-03-04T10:00:00.000000+00:00"}
+	ccc := Dashboard{ID: "ccc-ccc-ccc", Title: "API", Path: filepath.Join(dashboards, "ccc-ccc-ccc.json")}
+
+	if len(groups) != 2 {
+		t.Fatalf("Run() = %+v, want a title group and a content group", groups)
+	}
+	want := Group{Match: MatchTitle, Key: "API", Dashboards: []Dashboard{aaa, ccc}}
+	if !reflect.DeepEqual(groups[0], want) {
+		t.Errorf("groups[0] = %+v, want %+v", groups[0], want)
+	}
+	// Widget IDs and key order differ; empty dashboards aren't grouped
+	if groups[1].Match != MatchContent || !reflect.DeepEqual(groups[1].Dashboards, []Dashboard{aaa, bbb}) {
+		t.Errorf("groups[1] = %+v, want aaa and bbb by content", groups[1])
+	}
+}
+
+func TestByTitle(t *testing.T) {
+	groups := ByTitle([]Dashboard{
+		{ID: "b", Title: "Service"},
+		{ID: "c", Title: "Other"},
+		{ID: "a", Title: "Service"},
+		{ID: "d", Title: "service"},
+	})
+	want := []Group{{Match: MatchTitle, Key: "Service", Dashboards: []Dashboard{{ID: "a", Title: "Service"}, {ID: "b", Title: "Service"}}}}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("ByTitle() = %+v, want %+v", groups, want)
+	}
+}
+
+func TestContentHash_IgnoresNestedIDs(t *testing.T) {
+	widget := func(id, nestedID float64) []any {
+		return []any{map[string]any{
+			"id": id,
+			"definition": map[string]any{
+				"type":    "group",
+				"widgets": []any{map[string]any{"id": nestedID, "definition": map[string]any{"type": "note"}}},
+			},
+		}}
+	}
+	if ContentHash(widget(1, 2)) != ContentHash(widget(3, 4)) {
+		t.Error("ContentHash() differs for widgets differing only by ID")
+	}
+	original := widget(1, 2)
+	ContentHash(original)
+	if _, ok := original[0].(map[string]any)["id"]; !ok {
+		t.Error("ContentHash() modified its input")
+	}
+}
@@ -0,0 +1,201 @@
+// Package git commits the files written by a run. It shells out to the git
+// binary; nothing is ever pushed.
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+// DefaultMessage is the commit message template used when -m isn't given.
+const DefaultMessage = "dd-tf: {command} — {downloaded} updated, {pruned} removed"
+
+// ErrNotRepo is returned when the data isn't inside a git work tree.
+var ErrNotRepo = errors.New("not inside a git work tree")
+
+// Options holds the per-command git flags.
+type Options struct {
+	Commit  bool
+	Message string
+}
+
+// AddFlags registers --git-commit and -m/--git-message on cmd.
+func AddFlags(cmd *cobra.Command, opts *Options) {
+	cmd.Flags().BoolVar(&opts.Commit, "git-commit", false, "Commit the files written by this run (never pushes)")
+	cmd.Flags().StringVarP(&opts.Message, "git-message", "m", DefaultMessage, "Commit message template (supports {command}, {downloaded}, {pruned})")
+}
+
+// Result describes a commit made by CommitPaths.
+type Result struct {
+	Committed bool
+	Updated   int // added or modified files
+	Removed   int // deleted files
+}
+
+// CommitPaths stages exactly paths (including deletions) in the work tree
+// containing them and commits them, leaving anything else the user has
+// staged alone. command fills the {command} placeholder of messageTemplate.
+// If none of the paths changed nothing is committed.
+func CommitPaths(paths []string, command, messageTemplate string) (Result, error) {
+	var result Result
+	if len(paths) == 0 {
+		return result, nil
+	}
+
+	abs := make([]string, 0, len(paths))
+	for _, p := range paths {
+		a, err := filepath.Abs(p)
+		if err != nil {
+			return result, fmt.Errorf("failed to resolve %s: %w", p, err)
+		}
+		abs = append(abs, a)
+	}
+	sort.Strings(abs)
+
+	root, err := topLevel(existingDir(abs[0]))
+	if err != nil {
+		return result, err
+	}
+
+	// Paths that are gone and were never tracked have nothing to commit, and
+	// git rejects pathspecs that match nothing
+	abs, err = committable(root, abs)
+	if err != nil || len(abs) == 0 {
+		return result, err
+	}
+
+	if _, err := run(root, append([]string{"add", "--all", "--"}, abs...)...); err != nil {
+		return result, err
+	}
+
+	status, err := run(root, append([]string{"diff", "--cached", "--name-status", "--no-renames", "--"}, abs...)...)
+	if err != nil {
+		return result, err
+	}
+	for _, line := range strings.Split(strings.TrimSpace(status), "\n") {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "D"):
+			result.Removed++
+		default:
+			result.Updated++
+		}
+	}
+	if result.Updated+result.Removed == 0 {
+		return result, nil
+	}
+
+	message := strings.NewReplacer(
+		"{command}", command,
+		"{downloaded}", strconv.Itoa(result.Updated),
+		"{pruned}", strconv.Itoa(result.Removed),
+	).Replace(messageTemplate)
+
+	// Committing with a pathspec only includes these paths, even if other
+	// changes are staged
+	if _, err := run(root, append([]string{"commit", "--quiet", "-m", message, "--"}, abs...)...); err != nil {
+		return result, err
+	}
+	result.Committed = true
+	return result, nil
+}
+
+// committable drops paths that neither exist nor are tracked.
+func committable(root string, paths []string) ([]string, error) {
+	kept := paths[:0]
+	for _, p := range paths {
+		if _, err := os.Lstat(p); err == nil {
+			kept = append(kept, p)
+			continue
+		}
+		tracked, err := run(root, "ls-files", "--", p)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(tracked) != "" {
+			kept = append(kept, p)
+		}
+	}
+	return kept, nil
+}
+
+// existingDir returns the nearest existing directory containing path.
+func existingDir(path string) string {
+	dir := filepath.Dir(path)
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// CommitRun commits the files a run wrote when --git-commit is set. Not
+// being in a git work tree, or having nothing to commit, is only a notice.
+func CommitRun(opts Options, command string, paths []string) error {
+	if !opts.Commit {
+		return nil
+	}
+	message := opts.Message
+	if message == "" {
+		message = DefaultMessage
+	}
+	result, err := CommitPaths(paths, command, message)
+	if errors.Is(err, ErrNotRepo) {
+		logging.Logger.Warn("not committing, data is not in a git work tree", "error", err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("git commit failed: %w", err)
+	}
+	if !result.Committed {
+		logging.Logger.Info("no changes to commit")
+		return nil
+	}
+	logging.Logger.Info("changes committed", "updated", result.Updated, "removed", result.Removed)
+	return nil
+}
+
+// topLevel returns the root of the work tree containing dir.
+func topLevel(dir string) (string, error) {
+	out, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		var execErr *exec.Error
+		if errors.As(err, &execErr) {
+			return "", fmt.Errorf("git not available: %w", err)
+		}
+		return "", fmt.Errorf("%s: %w", dir, ErrNotRepo)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// run executes git with args in dir. Arguments are passed directly, never
+// through a shell.
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
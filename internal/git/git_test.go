@@ -0,0 +1,122 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newRepo creates a temporary git repository with one committed file and
+// returns its path.
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "dd-tf test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "dd-tf test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+
+	dir := t.TempDir()
+	gitRun(t, dir, "init", "--quiet")
+	writeFile(t, filepath.Join(dir, "data", "dashboards", "old.json"), `{"id":"old"}`)
+	writeFile(t, filepath.Join(dir, "data", "dashboards", "keep.json"), `{"id":"keep"}`)
+	gitRun(t, dir, "add", ".")
+	gitRun(t, dir, "commit", "--quiet", "-m", "initial")
+	return dir
+}
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := run(dir, args...)
+	if err != nil {
+		t.Fatalf("git %v: %v", args, err)
+	}
+	return out
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommitPaths(t *testing.T) {
+	repo := newRepo(t)
+	dashboards := filepath.Join(repo, "data", "dashboards")
+
+	// Touched by the run: one new, one modified, one removed
+	writeFile(t, filepath.Join(dashboards, "new.json"), `{"id":"new"}`)
+	writeFile(t, filepath.Join(dashboards, "keep.json"), `{"id":"keep","title":"changed"}`)
+	os.Remove(filepath.Join(dashboards, "old.json"))
+	// Not touched by the run, must not be committed
+	writeFile(t, filepath.Join(repo, "notes.txt"), "unrelated")
+
+	paths := []string{
+		filepath.Join(dashboards, "new.json"),
+		filepath.Join(dashboards, "keep.json"),
+		filepath.Join(dashboards, "old.json"),
+	}
+	result, err := CommitPaths(paths, "dashboards download", DefaultMessage)
+	if err != nil {
+		t.Fatalf("CommitPaths() error = %v", err)
+	}
+	if !result.Committed || result.Updated != 2 || result.Removed != 1 {
+		t.Errorf("CommitPaths() = %+v", result)
+	}
+
+	subject := strings.TrimSpace(gitRun(t, repo, "log", "-1", "--format=%s"))
+	if subject != "dd-tf: dashboards download — 2 updated, 1 removed" {
+		t.Errorf("commit subject = %q", subject)
+	}
+	files := gitRun(t, repo, "show", "--name-only", "--format=", "HEAD")
+	if strings.Contains(files, "notes.txt") {
+		t.Errorf("untouched file was committed: %s", files)
+	}
+	if status := gitRun(t, repo, "status", "--porcelain"); !strings.Contains(status, "notes.txt") || strings.Contains(status, "data/") {
+		t.Errorf("unexpected status after commit:\n%s", status)
+	}
+}
+
+func TestCommitPaths_NoChanges(t *testing.T) {
+	repo := newRepo(t)
+	head := gitRun(t, repo, "rev-parse", "HEAD")
+
+	result, err := CommitPaths([]string{filepath.Join(repo, "data", "dashboards", "keep.json")}, "dashboards download", DefaultMessage)
+	if err != nil {
+		t.Fatalf("CommitPaths() error = %v", err)
+	}
+	if result.Committed {
+		t.Errorf("expected no commit for unchanged files")
+	}
+	if gitRun(t, repo, "rev-parse", "HEAD") != head {
+		t.Errorf("HEAD moved without changes")
+	}
+}
+
+func TestCommitPaths_NotRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CEILING_DIRECTORIES", os.TempDir())
+	dir := t.TempDir()
+	path := filepath.Join(dir, "x.json")
+	writeFile(t, path, "{}")
+
+	_, err := CommitPaths([]string{path}, "dashboards download", DefaultMessage)
+	if !errors.Is(err, ErrNotRepo) {
+		t.Errorf("CommitPaths() error = %v, want ErrNotRepo", err)
+	}
+	if err := CommitRun(Options{Commit: true}, "dashboards download", []string{path}); err != nil {
+		t.Errorf("CommitRun() outside a repo should only warn, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+// ETagCache maps the keys of conditional GETs to the ETag of their last
+// response written, so GETs for resources already written can be made
+// conditional. It is read from
+// its file on first use and written back by Save.
+type ETagCache struct {
+	path string
+
+	mu     sync.Mutex
+	loaded bool
+	dirty  bool
+	etags  map[string]string
+}
+
+// NewETagCache returns a cache stored in the file at path.
+func NewETagCache(path string) *ETagCache {
+	return &ETagCache{path: path}
+}
+
+// load reads the cache file once. The caller holds c.mu. A missing or
+// unreadable file starts an empty cache; the latter is logged by the caller.
+func (c *ETagCache) load() error {
+	if c.loaded {
+		return nil
+	}
+	c.loaded = true
+	c.etags = map[string]string{}
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.etags); err != nil {
+		c.etags = map[string]string{}
+		return fmt.Errorf("failed to decode cache %s: %w", c.path, err)
+	}
+	return nil
+}
+
+// Get returns the ETag stored for key, or "".
+func (c *ETagCache) Get(key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.load(); err != nil {
+		return "", err
+	}
+	return c.etags[key], nil
+}
+
+// Set stores the ETag of key.
+func (c *ETagCache) Set(key, etag string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.load()
+	if c.etags[key] != etag {
+		c.etags[key] = etag
+		c.dirty = true
+	}
+	return err
+}
+
+// Save writes the cache to its file, if it changed.
+func (c *ETagCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	data, err := json.MarshalIndent(c.etags, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+	if err := (storage.FileBackend{}).Write(c.path, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write cache %s: %w", c.path, err)
+	}
+	c.dirty = false
+	return nil
+}
+
+type cacheContextKey int
+
+const (
+	conditionalKey cacheContextKey = iota
+	noCacheKey
+)
+
+// ConditionalGet is a conditional GET, see Conditional. The ETag of its
+// response is only stored by Commit, once the response has been written.
+type ConditionalGet struct {
+	key string
+
+	mu    sync.Mutex
+	cache *ETagCache
+	etag  string
+}
+
+// Conditional marks the GETs made with the returned context as conditional:
+// with an ETag cache, they send If-None-Match with the ETag stored under key,
+// and the ETag of a 200 response is kept until Commit stores it under key. A
+// 304 Not Modified is returned to the caller like any response. key is the
+// URL, plus anything else the written file depends on. Only use it for
+// resources whose last response is still on disk, or with WithoutCache.
+func Conditional(ctx context.Context, key string) (context.Context, *ConditionalGet) {
+	get := &ConditionalGet{key: key}
+	return context.WithValue(ctx, conditionalKey, get), get
+}
+
+// Commit stores the ETag of the response, if any, for the next conditional
+// GET with the same key. Call it once the response has been written.
+func (g *ConditionalGet) Commit() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cache == nil || g.etag == "" {
+		return nil
+	}
+	return g.cache.Set(g.key, g.etag)
+}
+
+// received records the ETag of a 200 response to the GET.
+func (g *ConditionalGet) received(cache *ETagCache, etag string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.cache = cache
+	g.etag = etag
+}
+
+// WithoutCache makes the conditional GETs made with ctx unconditional; their
+// ETags are still kept for Commit, for later requests.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey, true)
+}
+
+// conditionalGet returns the ConditionalGet of ctx, or nil.
+func conditionalGet(ctx context.Context) *ConditionalGet {
+	v, _ := ctx.Value(conditionalKey).(*ConditionalGet)
+	return v
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	v, _ := ctx.Value(noCacheKey).(bool)
+	return v
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// etagServer serves a resource whose ETag is *etag, answering 304 when the
+// request's If-None-Match matches it, and records the If-None-Match headers.
+func etagServer(t *testing.T, etag *string, seen *[]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*seen = append(*seen, r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == *etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", *etag)
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDatadogHTTPClient_ConditionalGet(t *testing.T) {
+	etag := `"v1"`
+	var seen []string
+	server := etagServer(t, &etag, &seen)
+	url := server.URL + "/api/v1/dashboard/abc-def-ghi"
+	path := filepath.Join(t.TempDir(), ".dd-tf-cache.json")
+	client := New("key", "app", WithETagCache(NewETagCache(path)))
+	key := url + "#settings"
+
+	// get makes a conditional GET, and commits it unless told not to
+	get := func(ctx context.Context, commit bool) int {
+		t.Helper()
+		ctx, conditional := Conditional(ctx, key)
+		resp, err := client.GetWithContext(ctx, url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if commit {
+			if err := conditional.Commit(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.StatusCode
+	}
+	ctx := context.Background()
+
+	// Miss: nothing cached. The ETag is only stored once committed.
+	if status := get(ctx, false); status != http.StatusOK || seen[0] != "" {
+		t.Fatalf("miss: status %d, If-None-Match %q, want 200 and none", status, seen[0])
+	}
+	if status := get(ctx, true); status != http.StatusOK || seen[1] != "" {
+		t.Fatalf("uncommitted: status %d, If-None-Match %q, want 200 and none", status, seen[1])
+	}
+	seen = seen[1:]
+
+	// Hit: the cached ETag is sent and the 304 returned
+	if status := get(ctx, true); status != http.StatusNotModified || seen[1] != `"v1"` {
+		t.Errorf("hit: status %d, If-None-Match %q, want 304 and \"v1\"", status, seen[1])
+	}
+
+	// Changed: the old ETag is sent, the new one stored
+	etag = `"v2"`
+	if status := get(ctx, true); status != http.StatusOK || seen[2] != `"v1"` {
+		t.Errorf("changed: status %d, If-None-Match %q, want 200 and \"v1\"", status, seen[2])
+	}
+	if status := get(ctx, true); status != http.StatusNotModified || seen[3] != `"v2"` {
+		t.Errorf("after change: status %d, If-None-Match %q, want 304 and \"v2\"", status, seen[3])
+	}
+
+	// Unconditional requests and WithoutCache send no ETag
+	resp, err := client.GetWithContext(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	get(WithoutCache(ctx), true)
+	if seen[4] != "" || seen[5] != "" {
+		t.Errorf("If-None-Match = %q, %q, want none", seen[4], seen[5])
+	}
+
+	// The cache survives the client
+	if err := client.SaveCache(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := NewETagCache(path)
+	if got, err := reloaded.Get(key); err != nil || got != `"v2"` {
+		t.Errorf("reloaded Get() = %q, %v, want \"v2\"", got, err)
+	}
+}
@@ -1,16 +1,27 @@
 package http
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/AD7six/dd-tf/internal/config"
 	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/AD7six/dd-tf/internal/utils"
 )
 
 // Sleeper abstracts time.Sleep for testing.
@@ -38,57 +49,329 @@ type DatadogHTTPClient struct {
 	// max retries for errors (including 5xx) and 429s
 	retries int
 
-	// If we receive a 429 all http requests wait until pauseUntil; if requests
-	// aren't all subject to the same api rate limit (not all to the same api
-	// endpoint) this will be a little conservative - but otherwise prevents
-	// continuing to make requests we know will fail.
-	pause      sync.Mutex
-	pauseUntil time.Time
+	// Datadog rate limits each endpoint separately, so a 429 or pacing only
+	// holds back requests in the same bucket, see rateLimitBucket. The
+	// concurrency limit is still shared by all of them.
+	pause         sync.Mutex
+	buckets       map[string]*rateBucket
+	paceThreshold float64
 
 	// sleeper allows injecting a fake sleep for testing
 	sleeper Sleeper
+
+	// baseURL, when set, replaces the scheme and host of every request
+	baseURL *url.URL
+
+	// proxyURL, when set, is used for every request instead of the proxy
+	// from HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	proxyURL *url.URL
+
+	// cache, when set, holds the ETags of conditional GETs, see Conditional
+	cache *ETagCache
+
+	// logger defaults to logging.Logger when nil
+	logger *slog.Logger
+
+	// request counts and latencies, see Stats
+	requests      atomic.Int64
+	retried       atomic.Int64
+	rateLimited   atomic.Int64
+	rateLimitWait atomic.Int64 // nanoseconds
+	latency       latencyHistogram
+}
+
+// rateBucket is the rate limit state of one bucket, guarded by the client's
+// pause mutex.
+type rateBucket struct {
+	// If we receive a 429 the bucket's requests wait until pauseUntil
+	pauseUntil time.Time
+
+	// Pacing: once X-RateLimit-Remaining drops below paceThreshold of
+	// X-RateLimit-Limit, requests take turns paceInterval apart, from
+	// nextSlot, until the limit resets at paceEnd
+	paceInterval time.Duration
+	paceEnd      time.Time
+	nextSlot     time.Time
+}
+
+// globalBucket is the bucket of requests to paths that don't name an API
+// version and endpoint.
+const globalBucket = ""
+
+// rateLimitBucket returns the rate limit bucket of rawURL: the API version
+// and endpoint of its path, e.g. "v1/dashboard" for /api/v1/dashboard/abc,
+// or globalBucket.
+func rateLimitBucket(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return globalBucket
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) < 3 || segments[0] != "api" || segments[1] == "" || segments[2] == "" {
+		return globalBucket
+	}
+	return segments[1] + "/" + segments[2]
+}
+
+// bucket returns the state of bucket key, creating it if needed. The caller
+// holds c.pause.
+func (c *DatadogHTTPClient) bucket(key string) *rateBucket {
+	if c.buckets == nil {
+		c.buckets = map[string]*rateBucket{}
+	}
+	b, ok := c.buckets[key]
+	if !ok {
+		b = &rateBucket{}
+		c.buckets[key] = b
+	}
+	return b
+}
+
+// Option configures a client built by New.
+type Option func(*DatadogHTTPClient)
+
+// WithConcurrency limits the number of requests in flight (default 8).
+// Values below 1 are ignored.
+func WithConcurrency(n int) Option {
+	return func(c *DatadogHTTPClient) {
+		if n > 0 {
+			c.sem = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithRetries sets how many times a failed request (error, 5xx or 429) is
+// retried (default 3); 0 disables retries. Negative values are ignored.
+func WithRetries(n int) Option {
+	return func(c *DatadogHTTPClient) {
+		if n >= 0 {
+			c.retries = n
+		}
+	}
+}
+
+// WithRateLimitThreshold sets the share of the rate limit, in percent,
+// below which requests are spaced out so the remaining budget lasts until
+// the limit resets (default 10); 0 disables pacing. Values outside 0-100
+// are ignored.
+func WithRateLimitThreshold(percent int) Option {
+	return func(c *DatadogHTTPClient) {
+		if percent >= 0 && percent <= 100 {
+			c.paceThreshold = float64(percent) / 100
+		}
+	}
+}
+
+// WithTimeout sets the timeout of each request attempt (default 60s).
+// Non-positive values are ignored.
+func WithTimeout(d time.Duration) Option {
+	return func(c *DatadogHTTPClient) {
+		if d > 0 {
+			c.UnderlyingHTTP.Timeout = d
+		}
+	}
+}
+
+// WithTransport sets the transport that sends requests, e.g. a recording or
+// fake http.RoundTripper.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *DatadogHTTPClient) {
+		c.UnderlyingHTTP.Transport = rt
+	}
+}
+
+// WithBaseURL sends every request to u instead of the scheme and host in the
+// request URL, prefixing u's path if it has one. Useful to point the client
+// at a local server. An unparsable u is logged and ignored.
+func WithBaseURL(u string) Option {
+	return func(c *DatadogHTTPClient) {
+		parsed, err := url.Parse(u)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			c.log().Error("ignoring invalid base URL", "url", u)
+			return
+		}
+		c.baseURL = parsed
+	}
+}
+
+// WithProxy sends every request through the proxy at u, ignoring
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY. An empty u keeps those; an invalid
+// one is logged and ignored. It has no effect with WithTransport.
+func WithProxy(u string) Option {
+	return func(c *DatadogHTTPClient) {
+		if u == "" {
+			return
+		}
+		parsed, err := url.Parse(u)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			c.log().Error("ignoring invalid proxy URL", "url", u)
+			return
+		}
+		c.proxyURL = parsed
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of requests, e.g. extra trusted
+// CA certificates. It has no effect with WithTransport.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *DatadogHTTPClient) {
+		if t, ok := c.UnderlyingHTTP.Transport.(*http.Transport); ok && cfg != nil {
+			t.TLSClientConfig = cfg
+		}
+	}
+}
+
+// WithETagCache stores the ETags of conditional GETs in cache, see
+// Conditional.
+func WithETagCache(cache *ETagCache) Option {
+	return func(c *DatadogHTTPClient) {
+		c.cache = cache
+	}
+}
+
+// WithLogger sets the logger for request and retry messages (default
+// logging.Logger).
+func WithLogger(l *slog.Logger) Option {
+	return func(c *DatadogHTTPClient) {
+		c.logger = l
+	}
+}
+
+// withSleeper replaces time.Sleep for retries and pauses, for tests.
+func withSleeper(s Sleeper) Option {
+	return func(c *DatadogHTTPClient) {
+		c.sleeper = s
+	}
 }
 
 const (
-	defaultMaxConcurrency = 8
-	defaultRetries        = 3
-	defaultHTTPTimeout    = 60 * time.Second
+	defaultMaxConcurrency     = config.DefaultHTTPMaxConcurrency
+	defaultRetries            = config.DefaultHTTPRetries
+	defaultRateLimitThreshold = float64(config.DefaultHTTPRateLimitThreshold) / 100
+	defaultHTTPTimeout        = 60 * time.Second
 )
 
 var (
 	sharedOnce   sync.Once
-	sharedClient *DatadogHTTPClient
+	sharedClient atomic.Pointer[DatadogHTTPClient]
 )
 
 // GetHTTPClient returns a shared client instance to ensure concurrency limiting and 429 pauses
-// are coordinated across all requests in this process. If we want in the future
-// to have multiple clients (for different api endpoints, with separate rate
-// limits) we can add that later.
+// are coordinated across all requests in this process.
 func GetHTTPClient(settings *config.Settings) *DatadogHTTPClient {
 	sharedOnce.Do(func() {
-		sharedClient = newClient(settings.APIKey, settings.AppKey, defaultMaxConcurrency, defaultRetries, settings.HTTPTimeout)
+		sharedClient.Store(NewClient(settings))
 	})
-	return sharedClient
+	return sharedClient.Load()
 }
 
-func newClient(apiKey, appKey string, maxConcurrent, retries int, timeout time.Duration) *DatadogHTTPClient {
-	if maxConcurrent <= 0 {
-		maxConcurrent = defaultMaxConcurrency
+// SharedStats returns the Stats of the shared client, and false if
+// GetHTTPClient hasn't created it.
+func SharedStats() (Stats, bool) {
+	client := sharedClient.Load()
+	if client == nil {
+		return Stats{}, false
+	}
+	return client.Stats(), true
+}
+
+// NewClient returns a new, unshared client for settings. Most callers want
+// GetHTTPClient; this is for embedders that need their own limits.
+func NewClient(settings *config.Settings) *DatadogHTTPClient {
+	// Validated by config.LoadSettings
+	tlsConfig, err := settings.TLSConfig()
+	if err != nil {
+		logging.Logger.Error("ignoring TLS settings", "error", err)
+	}
+	if settings.HTTPTLSInsecureSkipVerify {
+		logging.Logger.Warn("HTTP_TLS_INSECURE_SKIP_VERIFY is set: TLS certificates are NOT verified, anyone on the network path can read and alter API requests, API keys included")
+	}
+	client := New(settings.APIKey, settings.AppKey,
+		WithTimeout(settings.HTTPTimeout),
+		WithConcurrency(settings.HTTPMaxConcurrency),
+		WithRetries(settings.HTTPRetries),
+		WithRateLimitThreshold(settings.HTTPRateLimitThreshold),
+		WithProxy(settings.HTTPProxy),
+		WithTLSConfig(tlsConfig))
+	if settings.HTTPCache {
+		client.cache = NewETagCache(filepath.Join(settings.DataDir, storage.CacheFile))
+	}
+	if settings.Fixtures != "" {
+		// Mode is validated by config.LoadSettings
+		transport, err := newFixtureTransport(settings.Fixtures, settings.FixturesDir, client.UnderlyingHTTP.Transport, settings.APIKey, settings.AppKey)
+		if err != nil {
+			// Fail closed: every request returns the error
+			logging.Logger.Error("fixtures unavailable", "error", err)
+			client.UnderlyingHTTP.Transport = unavailableFixtureTransport{err: fmt.Errorf("%w: %w", errFixturesUnavailable, err)}
+			return client
+		}
+		logging.Logger.Info("using fixtures", "mode", settings.Fixtures, "dir", settings.FixturesDir)
+		client.UnderlyingHTTP.Transport = transport
 	}
-	if retries <= 0 {
-		retries = defaultRetries
+	return client
+}
+
+// New returns a new, unshared client for the given credentials, with
+// defaults of 8 concurrent requests, 3 retries, pacing below 10% of the rate
+// limit and a 60s timeout.
+func New(apiKey, appKey string, opts ...Option) *DatadogHTTPClient {
+	client := &DatadogHTTPClient{
+		APIKey:        apiKey,
+		AppKey:        appKey,
+		sem:           make(chan struct{}, defaultMaxConcurrency),
+		retries:       defaultRetries,
+		paceThreshold: defaultRateLimitThreshold,
+		sleeper:       realSleeper{},
 	}
-	if timeout <= 0 {
-		timeout = defaultHTTPTimeout
+	// Our own transport, so the proxy is chosen explicitly whatever else
+	// is tuned on it
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = client.proxy
+	client.UnderlyingHTTP = &http.Client{Timeout: defaultHTTPTimeout, Transport: transport}
+	for _, opt := range opts {
+		opt(client)
 	}
-	return &DatadogHTTPClient{
-		APIKey:         apiKey,
-		AppKey:         appKey,
-		UnderlyingHTTP: &http.Client{Timeout: timeout},
-		sem:            make(chan struct{}, maxConcurrent),
-		retries:        retries,
-		sleeper:        realSleeper{},
+	return client
+}
+
+// log returns the client's logger.
+func (c *DatadogHTTPClient) log() *slog.Logger {
+	if c.logger != nil {
+		return c.logger
 	}
+	return logging.Logger
+}
+
+// proxy returns the proxy for req: the WithProxy one if set, otherwise the
+// one HTTP_PROXY, HTTPS_PROXY and NO_PROXY give, or nil for none.
+func (c *DatadogHTTPClient) proxy(req *http.Request) (*url.URL, error) {
+	proxy := c.proxyURL
+	if proxy == nil {
+		var err error
+		if proxy, err = http.ProxyFromEnvironment(req); err != nil {
+			return nil, err
+		}
+	}
+	if proxy != nil {
+		c.log().Debug("using proxy", "proxy", proxy.Redacted(), "url", req.URL.String())
+	}
+	return proxy, nil
+}
+
+// resolve applies the base URL, if any, to rawURL.
+func (c *DatadogHTTPClient) resolve(rawURL string) string {
+	if c.baseURL == nil {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.Scheme = c.baseURL.Scheme
+	u.Host = c.baseURL.Host
+	u.Path = strings.TrimSuffix(c.baseURL.Path, "/") + u.Path
+	u.RawPath = ""
+	return u.String()
 }
 
 // Get performs a GET request with retry logic and context support.
@@ -99,62 +382,169 @@ func (c *DatadogHTTPClient) Get(url string) (*http.Response, error) {
 
 // GetWithContext performs a GET request with the provided context for cancellation/timeout.
 func (c *DatadogHTTPClient) GetWithContext(ctx context.Context, url string) (*http.Response, error) {
+	return c.do(ctx, http.MethodGet, url, nil)
+}
+
+// Put performs a PUT request with a JSON body, with the same retry logic as
+// Get. Uses context.Background().
+func (c *DatadogHTTPClient) Put(url string, body io.Reader) (*http.Response, error) {
+	return c.PutWithContext(context.Background(), url, body)
+}
+
+// PutWithContext performs a PUT request with a JSON body and the provided
+// context. The body is read up front so that retries can send it again.
+func (c *DatadogHTTPClient) PutWithContext(ctx context.Context, url string, body io.Reader) (*http.Response, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	return c.do(ctx, http.MethodPut, url, data)
+}
+
+// Post performs a POST request with a JSON body, with the same retry logic
+// as Get. Uses context.Background().
+func (c *DatadogHTTPClient) Post(url string, body io.Reader) (*http.Response, error) {
+	return c.PostWithContext(context.Background(), url, body)
+}
+
+// PostWithContext performs a POST request with a JSON body and the provided
+// context. The body is read up front so that retries can send it again.
+func (c *DatadogHTTPClient) PostWithContext(ctx context.Context, url string, body io.Reader) (*http.Response, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	return c.do(ctx, http.MethodPost, url, data)
+}
+
+// Delete performs a DELETE request, with the same retry logic as Get. Uses
+// context.Background().
+func (c *DatadogHTTPClient) Delete(url string) (*http.Response, error) {
+	return c.DeleteWithContext(context.Background(), url)
+}
+
+// DeleteWithContext performs a DELETE request with the provided context.
+func (c *DatadogHTTPClient) DeleteWithContext(ctx context.Context, url string) (*http.Response, error) {
+	return c.do(ctx, http.MethodDelete, url, nil)
+}
+
+// do sends a request with retries, sharing the concurrency limit and 429
+// pauses across methods. A nil body sends no body.
+func (c *DatadogHTTPClient) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
 	// Acquire concurrency slot
-	c.sem <- struct{}{}
+	select {
+	case c.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 	defer func() { <-c.sem }()
 
+	bucket := rateLimitBucket(url)
+	var conditional *ConditionalGet
+	if c.cache != nil && method == http.MethodGet {
+		conditional = conditionalGet(ctx)
+	}
+
 	// Retry loop
 	var lastErr error
 	for attempt := 0; attempt <= c.retries; attempt++ {
-		// If globally paused due to 429, wait it out
-		c.waitIfPaused()
+		if attempt > 0 {
+			c.retried.Add(1)
+		}
+
+		// If this endpoint is paused due to 429, wait it out
+		if err := c.waitIfPaused(ctx, bucket); err != nil {
+			return nil, err
+		}
 
-		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		var reqBody io.Reader
+		if body != nil {
+			reqBody = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, c.resolve(url), reqBody)
 		if err != nil {
 			return nil, err
 		}
 		req.Header.Set("DD-API-KEY", c.APIKey)
 		req.Header.Set("DD-APPLICATION-KEY", c.AppKey)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if conditional != nil && !cacheBypassed(ctx) {
+			etag, err := c.cache.Get(conditional.key)
+			if err != nil {
+				c.log().Warn("ignoring HTTP cache", "error", err)
+			}
+			if etag != "" {
+				req.Header.Set("If-None-Match", etag)
+			}
+		}
 
 		c.logCurlCommand(req)
 
+		c.requests.Add(1)
+		start := time.Now()
 		resp, err := c.UnderlyingHTTP.Do(req)
+		c.latency.observe(time.Since(start))
+		c.logResponse(req, resp, err, start)
+		if err == nil {
+			c.observeRateLimit(bucket, resp)
+			if etag := resp.Header.Get("ETag"); conditional != nil && resp.StatusCode == http.StatusOK && etag != "" {
+				conditional.received(c.cache, etag)
+			}
+		}
 		if err != nil {
 			lastErr = err
+			// A missing fixture won't appear by retrying
+			if errors.Is(err, errNoFixture) || errors.Is(err, errFixturesUnavailable) {
+				return nil, err
+			}
+			// Nor will a cancelled or expired context
+			if ctx.Err() != nil {
+				return nil, err
+			}
 			if attempt < c.retries {
-				c.sleeper.Sleep(backoffDuration(attempt))
+				if err := c.sleep(ctx, backoffDuration(attempt)); err != nil {
+					return nil, err
+				}
 				continue
 			}
 			return nil, lastErr
 		}
 
-		// Handle 429: set global pause, then retry after waiting
+		// Handle 429: pause the endpoint, then retry after waiting
 		if resp.StatusCode == http.StatusTooManyRequests {
+			c.rateLimited.Add(1)
 			// Determine wait duration from Retry-After (seconds) or fall back to 1s
 			wait := parseRetryAfter(resp)
 			// Close body before sleeping/retrying
 			if err := resp.Body.Close(); err != nil {
-				logging.Logger.Warn("failed to close response body", "error", err)
+				c.log().Warn("failed to close response body", "error", err)
 			}
 
-			// Set global pause
-			c.setPause(wait)
+			// Pause the endpoint's requests
+			c.setPause(bucket, wait)
 
 			if attempt < c.retries {
 				// Sleep the same period locally before retrying this request
-				c.sleeper.Sleep(wait)
+				c.rateLimitWait.Add(int64(wait))
+				if err := c.sleep(ctx, wait); err != nil {
+					return nil, err
+				}
 				continue
 			}
-			return nil, &rateLimitedError{after: wait}
+			return nil, &RateLimitedError{RetryAfter: wait}
 		}
 
 		// Retry transient server errors (5xx). Do not retry other 4xx.
 		if resp.StatusCode >= 500 {
 			if attempt < c.retries {
 				if err := resp.Body.Close(); err != nil {
-					logging.Logger.Warn("failed to close response body", "error", err)
+					c.log().Warn("failed to close response body", "error", err)
+				}
+				if err := c.sleep(ctx, backoffDuration(attempt)); err != nil {
+					return nil, err
 				}
-				c.sleeper.Sleep(backoffDuration(attempt))
 				continue
 			}
 			// return last response to caller for error handling
@@ -180,31 +570,111 @@ func backoffDuration(attempt int) time.Duration {
 	return d
 }
 
-func (c *DatadogHTTPClient) waitIfPaused() {
+// waitIfPaused waits out the pause or pacing of bucket key, if any.
+func (c *DatadogHTTPClient) waitIfPaused(ctx context.Context, key string) error {
 	c.pause.Lock()
+	b := c.bucket(key)
 	now := time.Now()
-	until := c.pauseUntil
+	wait := b.pauseUntil.Sub(now)
+	if b.paceInterval > 0 && now.Before(b.paceEnd) {
+		// Take the next turn, after any pause
+		slot := now
+		for _, t := range []time.Time{b.pauseUntil, b.nextSlot} {
+			if t.After(slot) {
+				slot = t
+			}
+		}
+		b.nextSlot = slot.Add(b.paceInterval)
+		wait = slot.Sub(now)
+	}
 	c.pause.Unlock()
 
-	if until.IsZero() || now.After(until) || now.Equal(until) {
+	if wait > 0 {
+		c.rateLimitWait.Add(int64(wait))
+		return c.sleep(ctx, wait)
+	}
+	return nil
+}
+
+// observeRateLimit reads the X-RateLimit headers of resp and, once fewer
+// than paceThreshold of the requests allowed remain, spaces out the next
+// requests of bucket key so the rest last until the limit resets. Pacing
+// stops as soon as a response shows enough remaining requests, e.g. after
+// the reset.
+func (c *DatadogHTTPClient) observeRateLimit(key string, resp *http.Response) {
+	limit, errLimit := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	remaining, errRemaining := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	reset, errReset := strconv.Atoi(resp.Header.Get("X-RateLimit-Reset"))
+	if errLimit != nil || errRemaining != nil || errReset != nil || limit <= 0 || remaining < 0 || reset < 0 {
 		return
 	}
 
-	duration := until.Sub(now)
-	if duration > 0 {
-		c.sleeper.Sleep(duration)
+	c.pause.Lock()
+	defer c.pause.Unlock()
+	b := c.bucket(key)
+	if float64(remaining) >= c.paceThreshold*float64(limit) {
+		b.paceInterval = 0
+		return
 	}
+	window := time.Duration(max(reset, 1)) * time.Second
+	interval := window / time.Duration(remaining+1)
+	if b.paceInterval == 0 {
+		c.log().Debug("rate limit nearly used, pacing requests", "bucket", key, "remaining", remaining, "limit", limit, "reset", window, "interval", interval)
+	}
+	b.paceInterval = interval
+	b.paceEnd = time.Now().Add(window)
 }
 
-func (c *DatadogHTTPClient) setPause(d time.Duration) {
+// sleep waits for d, or until ctx is done, so an interrupted command doesn't
+// sit out a backoff or a 429 pause. Test sleepers are called as they are.
+func (c *DatadogHTTPClient) sleep(ctx context.Context, d time.Duration) error {
+	if _, ok := c.sleeper.(realSleeper); !ok {
+		c.sleeper.Sleep(d)
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SaveCache writes the ETag cache, if any, to its file.
+func (c *DatadogHTTPClient) SaveCache() error {
+	if c.cache == nil {
+		return nil
+	}
+	return c.cache.Save()
+}
+
+// PausedUntil returns when the last of the pauses set by 429s ends; the
+// zero time or a time in the past means no requests are paused.
+func (c *DatadogHTTPClient) PausedUntil() time.Time {
+	c.pause.Lock()
+	defer c.pause.Unlock()
+	var until time.Time
+	for _, b := range c.buckets {
+		if b.pauseUntil.After(until) {
+			until = b.pauseUntil
+		}
+	}
+	return until
+}
+
+// setPause pauses the requests of bucket key for d.
+func (c *DatadogHTTPClient) setPause(key string, d time.Duration) {
 	if d <= 0 {
 		d = time.Second
 	}
 	c.pause.Lock()
+	b := c.bucket(key)
 	// If there is already a longer pause in place, keep it
 	proposed := time.Now().Add(d)
-	if proposed.After(c.pauseUntil) {
-		c.pauseUntil = proposed
+	if proposed.After(b.pauseUntil) {
+		b.pauseUntil = proposed
 	}
 	c.pause.Unlock()
 }
@@ -223,33 +693,58 @@ func parseRetryAfter(resp *http.Response) time.Duration {
 	return time.Second
 }
 
-type rateLimitedError struct {
-	after time.Duration
+// RateLimitedError is returned when requests are still being rate limited
+// (429) after all retries. Detect it with errors.As.
+type RateLimitedError struct {
+	RetryAfter time.Duration // Wait requested by the last response's Retry-After header
 }
 
-func (e *rateLimitedError) Error() string {
-	return fmt.Sprintf("rate limited by server (retry after %v)", e.after)
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("rate limited by server (retry after %v)", e.RetryAfter)
 }
 
-// logCurlCommand logs the equivalent curl command for a request formatted for
-// copy-paste reuse and readability. Only handles GET requests, but that's all
-// we're emitting currently so that's fine.
+// logCurlCommand logs the equivalent curl command for a request, formatted
+// for copy-paste reuse, with the API and application keys masked. Request
+// bodies aren't logged; the command reads them from stdin.
 func (c *DatadogHTTPClient) logCurlCommand(req *http.Request) {
-	var parts []string
-	parts = append(parts, "curl")
-
-	for key, values := range req.Header {
-		for _, value := range values {
-			if key == "Dd-Api-Key" {
-				value = "${DD_API_KEY}"
-			} else if key == "Dd-Application-Key" {
-				value = "${DD_APP_KEY}"
-			}
-			parts = append(parts, fmt.Sprintf("-H %q", fmt.Sprintf("%s: %s", key, value)))
+	if !c.log().Enabled(req.Context(), slog.LevelDebug) {
+		return
+	}
+	parts := []string{"curl", "-X", req.Method, shellQuote(req.URL.String())}
+	if req.Body != nil {
+		parts = append(parts, "--data-binary", "@-")
+	}
+	parts = append(parts,
+		"-H", shellQuote("DD-API-KEY: "+utils.MaskSecret(req.Header.Get("DD-API-KEY"))),
+		"-H", shellQuote("DD-APPLICATION-KEY: "+utils.MaskSecret(req.Header.Get("DD-APPLICATION-KEY"))))
+
+	keys := make([]string, 0, len(req.Header))
+	for key := range req.Header {
+		if key != "Dd-Api-Key" && key != "Dd-Application-Key" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range req.Header[key] {
+			parts = append(parts, "-H", shellQuote(key+": "+value))
 		}
 	}
 
-	parts = append(parts, fmt.Sprintf("%q", req.URL.String()))
+	c.log().Debug("http request", "curl", strings.Join(parts, " "))
+}
+
+// logResponse logs the outcome of a request sent at start.
+func (c *DatadogHTTPClient) logResponse(req *http.Request, resp *http.Response, err error, start time.Time) {
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		c.log().Debug("http request failed", "method", req.Method, "url", req.URL.String(), "elapsed", elapsed, "error", err)
+		return
+	}
+	c.log().Debug("http response", "method", req.Method, "url", req.URL.String(), "status", resp.StatusCode, "elapsed", elapsed)
+}
 
-	logging.Logger.Debug("http request", "curl", strings.Join(parts, " "))
+// shellQuote single-quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
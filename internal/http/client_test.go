@@ -1,9 +1,18 @@
 package http
 
 import (
+	"bytes"
+	"context"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -43,9 +52,64 @@ func (f *fakeSleeper) getSleepCount() int {
 	return int(atomic.LoadInt32(&f.sleepCount))
 }
 
-func TestNewClient(t *testing.T) {
+// attempts returns how many requests client makes for a URL that always
+// returns 500.
+func attempts(t *testing.T, client *DatadogHTTPClient) int32 {
+	t.Helper()
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	resp.Body.Close()
+	return atomic.LoadInt32(&count)
+}
+
+// peakConcurrency returns the most requests client had in flight at once
+// while making n concurrent requests.
+func peakConcurrency(t *testing.T, client *DatadogHTTPClient, n int) int32 {
+	t.Helper()
+	var running, peak int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&peak)
+			if current <= max || atomic.CompareAndSwapInt32(&peak, max, current) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Errorf("Get() error: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+	return atomic.LoadInt32(&peak)
+}
+
+func TestNew(t *testing.T) {
 	t.Run("creates client with default values", func(t *testing.T) {
-		client := newClient("test-key", "test-app", 0, 0, 0)
+		client := New("test-key", "test-app", withSleeper(&fakeSleeper{}))
 
 		if client.APIKey != "test-key" {
 			t.Errorf("APIKey = %s, want test-key", client.APIKey)
@@ -53,11 +117,11 @@ func TestNewClient(t *testing.T) {
 		if client.AppKey != "test-app" {
 			t.Errorf("AppKey = %s, want test-app", client.AppKey)
 		}
-		if cap(client.sem) != defaultMaxConcurrency {
-			t.Errorf("sem capacity = %d, want %d", cap(client.sem), defaultMaxConcurrency)
+		if got := attempts(t, client); got != defaultRetries+1 {
+			t.Errorf("attempts = %d, want %d", got, defaultRetries+1)
 		}
-		if client.retries != defaultRetries {
-			t.Errorf("retries = %d, want %d", client.retries, defaultRetries)
+		if got := peakConcurrency(t, client, defaultMaxConcurrency+4); got > defaultMaxConcurrency {
+			t.Errorf("peak concurrency = %d, want <= %d", got, defaultMaxConcurrency)
 		}
 		if client.UnderlyingHTTP.Timeout != defaultHTTPTimeout {
 			t.Errorf("timeout = %v, want %v", client.UnderlyingHTTP.Timeout, defaultHTTPTimeout)
@@ -65,13 +129,13 @@ func TestNewClient(t *testing.T) {
 	})
 
 	t.Run("uses default values for invalid inputs", func(t *testing.T) {
-		client := newClient("key", "app", -1, -1, -1*time.Second)
+		client := New("key", "app", WithConcurrency(-1), WithRetries(-1), WithTimeout(-1*time.Second), withSleeper(&fakeSleeper{}))
 
-		if cap(client.sem) != defaultMaxConcurrency {
-			t.Errorf("sem capacity = %d, want %d", cap(client.sem), defaultMaxConcurrency)
+		if got := attempts(t, client); got != defaultRetries+1 {
+			t.Errorf("attempts = %d, want %d", got, defaultRetries+1)
 		}
-		if client.retries != defaultRetries {
-			t.Errorf("retries = %d, want %d", client.retries, defaultRetries)
+		if got := peakConcurrency(t, client, 4); got == 0 {
+			t.Error("no requests made with invalid concurrency")
 		}
 		if client.UnderlyingHTTP.Timeout != defaultHTTPTimeout {
 			t.Errorf("timeout = %v, want %v", client.UnderlyingHTTP.Timeout, defaultHTTPTimeout)
@@ -79,18 +143,259 @@ func TestNewClient(t *testing.T) {
 	})
 
 	t.Run("accepts custom concurrency and retry values", func(t *testing.T) {
-		client := newClient("key", "app", 5, 10, 30*time.Second)
+		client := New("key", "app", WithConcurrency(2), WithRetries(5), WithTimeout(30*time.Second), withSleeper(&fakeSleeper{}))
 
-		if cap(client.sem) != 5 {
-			t.Errorf("sem capacity = %d, want 5", cap(client.sem))
+		if got := attempts(t, client); got != 6 {
+			t.Errorf("attempts = %d, want 6", got)
 		}
-		if client.retries != 10 {
-			t.Errorf("retries = %d, want 10", client.retries)
+		if got := peakConcurrency(t, client, 6); got > 2 {
+			t.Errorf("peak concurrency = %d, want <= 2", got)
 		}
 		if client.UnderlyingHTTP.Timeout != 30*time.Second {
 			t.Errorf("timeout = %v, want 30s", client.UnderlyingHTTP.Timeout)
 		}
 	})
+
+	t.Run("zero retries disables retrying", func(t *testing.T) {
+		client := New("key", "app", WithRetries(0), withSleeper(&fakeSleeper{}))
+		if got := attempts(t, client); got != 1 {
+			t.Errorf("attempts = %d, want 1", got)
+		}
+	})
+
+	t.Run("timeout applies to each attempt", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}))
+		defer server.Close()
+		defer close(release)
+
+		client := New("key", "app", WithTimeout(20*time.Millisecond), WithRetries(0))
+		if _, err := client.Get(server.URL); err == nil {
+			t.Error("Get() expected timeout error")
+		}
+	})
+}
+
+type recordingTransport struct {
+	urls []string
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.urls = append(rt.urls, req.URL.String())
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}")), Request: req}, nil
+}
+
+func TestNew_WithTransport(t *testing.T) {
+	transport := &recordingTransport{}
+	client := New("key", "app", WithTransport(transport))
+
+	resp, err := client.Get("https://api.datadoghq.com/api/v1/dashboard/abc")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	resp.Body.Close()
+	if fmt.Sprint(transport.urls) != "[https://api.datadoghq.com/api/v1/dashboard/abc]" {
+		t.Errorf("transport saw %v", transport.urls)
+	}
+}
+
+func TestNew_WithBaseURL(t *testing.T) {
+	transport := &recordingTransport{}
+	client := New("key", "app", WithTransport(transport), WithBaseURL("http://127.0.0.1:8080/proxy/"))
+
+	resp, err := client.Get("https://api.datadoghq.com/api/v1/dashboard?start=0")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	resp.Body.Close()
+	if fmt.Sprint(transport.urls) != "[http://127.0.0.1:8080/proxy/api/v1/dashboard?start=0]" {
+		t.Errorf("transport saw %v", transport.urls)
+	}
+
+	// An invalid base URL is ignored
+	transport.urls = nil
+	client = New("key", "app", WithTransport(transport), WithBaseURL("not a url"))
+	resp, err = client.Get("https://api.datadoghq.com/api/v1/dashboard")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	resp.Body.Close()
+	if fmt.Sprint(transport.urls) != "[https://api.datadoghq.com/api/v1/dashboard]" {
+		t.Errorf("transport saw %v", transport.urls)
+	}
+}
+
+func TestNew_WithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := New("key", "app", WithTransport(&recordingTransport{}), WithLogger(logger))
+
+	resp, err := client.Get("https://api.datadoghq.com/api/v1/dashboard/abc")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	resp.Body.Close()
+	if !strings.Contains(buf.String(), "curl") || !strings.Contains(buf.String(), "DD-API-KEY: ****") {
+		t.Errorf("log = %q, want the redacted curl command", buf.String())
+	}
+}
+
+// capturingHandler is a slog.Handler that keeps the records it handles.
+type capturingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *capturingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *capturingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *capturingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *capturingHandler) WithGroup(string) slog.Handler      { return h }
+
+// attrs returns the attributes of the record with message msg.
+func (h *capturingHandler) attrs(msg string) map[string]slog.Value {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.records {
+		if r.Message != msg {
+			continue
+		}
+		attrs := map[string]slog.Value{}
+		r.Attrs(func(a slog.Attr) bool {
+			attrs[a.Key] = a.Value
+			return true
+		})
+		return attrs
+	}
+	return nil
+}
+
+func TestDatadogHTTPClient_LogsCurlCommand(t *testing.T) {
+	handler := &capturingHandler{}
+	client := New("api-key-secret", "app-key-secret", WithTransport(&recordingTransport{}), WithLogger(slog.New(handler)))
+
+	resp, err := client.Get("https://api.datadoghq.com/api/v1/dashboard/abc")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	resp.Body.Close()
+
+	request := handler.attrs("http request")
+	if request == nil {
+		t.Fatal("no http request record logged")
+	}
+	curl := request["curl"].String()
+	want := `curl -X GET 'https://api.datadoghq.com/api/v1/dashboard/abc' -H 'DD-API-KEY: ap**********et' -H 'DD-APPLICATION-KEY: ap**********et'`
+	if curl != want {
+		t.Errorf("curl = %s, want %s", curl, want)
+	}
+	if strings.Contains(curl, "api-key-secret") || strings.Contains(curl, "app-key-secret") {
+		t.Errorf("curl = %s, want the keys masked", curl)
+	}
+
+	response := handler.attrs("http response")
+	if response == nil {
+		t.Fatal("no http response record logged")
+	}
+	if status := response["status"].Int64(); status != http.StatusOK {
+		t.Errorf("status = %d, want 200", status)
+	}
+	if _, ok := response["elapsed"]; !ok {
+		t.Error("http response record has no elapsed time")
+	}
+
+	// A body is read from stdin
+	handler.records = nil
+	resp, err = client.Post("https://api.datadoghq.com/api/v1/monitor", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("Post() error: %v", err)
+	}
+	resp.Body.Close()
+	want = `curl -X POST 'https://api.datadoghq.com/api/v1/monitor' --data-binary @- -H 'DD-API-KEY: ap**********et' -H 'DD-APPLICATION-KEY: ap**********et' -H 'Content-Type: application/json'`
+	if curl := handler.attrs("http request")["curl"].String(); curl != want {
+		t.Errorf("curl = %s, want %s", curl, want)
+	}
+}
+
+func TestNew_WithProxy(t *testing.T) {
+	var seen []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A proxied request names the absolute URL it is for
+		seen = append(seen, r.RequestURI)
+		w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	handler := &capturingHandler{}
+	client := New("key", "app", WithProxy(proxy.URL), WithLogger(slog.New(handler)))
+	resp, err := client.Get("http://api.datadoghq.invalid/api/v1/dashboard")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	resp.Body.Close()
+	if fmt.Sprint(seen) != "[http://api.datadoghq.invalid/api/v1/dashboard]" {
+		t.Errorf("proxy saw %v, want the request", seen)
+	}
+	if attrs := handler.attrs("using proxy"); attrs == nil || attrs["proxy"].String() != proxy.URL {
+		t.Errorf("using proxy log = %v, want the proxy URL", attrs)
+	}
+
+	// An invalid proxy URL is ignored
+	client = New("key", "app", WithProxy("not a url"))
+	if client.proxyURL != nil {
+		t.Errorf("proxyURL = %v, want nil", client.proxyURL)
+	}
+}
+
+func TestNewClient_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, cert, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		settings config.Settings
+		wantErr  bool
+	}{
+		{name: "untrusted certificate", wantErr: true},
+		{name: "HTTP_CA_BUNDLE", settings: config.Settings{HTTPCABundle: bundle}},
+		{name: "HTTP_TLS_INSECURE_SKIP_VERIFY", settings: config.Settings{HTTPTLSInsecureSkipVerify: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.settings.APIKey, tt.settings.AppKey = "key", "app"
+			client := NewClient(&tt.settings) // HTTPRetries is 0
+			resp, err := client.Get(server.URL + "/api/v1/dashboard")
+			if tt.wantErr {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatal("Get() expected a certificate error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get() error: %v", err)
+			}
+			resp.Body.Close()
+		})
+	}
 }
 
 func TestGetHTTPClient(t *testing.T) {
@@ -129,7 +434,7 @@ func TestDatadogHTTPClient_Get_Success(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := newClient("test-api-key", "test-app-key", 1, 3, 60*time.Second)
+	client := New("test-api-key", "test-app-key", WithConcurrency(1), WithRetries(3))
 	resp, err := client.Get(server.URL)
 
 	if err != nil {
@@ -171,9 +476,8 @@ func TestDatadogHTTPClient_Get_Retries429(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := newClient("key", "key", 1, 3, 60*time.Second)
 	fakeSleep := &fakeSleeper{}
-	client.sleeper = fakeSleep
+	client := New("key", "key", WithConcurrency(1), WithRetries(3), withSleeper(fakeSleep))
 
 	resp, err := client.Get(server.URL)
 
@@ -201,6 +505,9 @@ func TestDatadogHTTPClient_Get_Retries429(t *testing.T) {
 	if totalSlept < 2*time.Second {
 		t.Errorf("total slept = %v, want >= 2s", totalSlept)
 	}
+	if got := client.Stats(); got.Requests != 3 || got.Retries != 2 || got.RateLimited != 2 {
+		t.Errorf("Stats() = %+v, want 3 requests, 2 retries, 2 rate limited", got)
+	}
 }
 
 func TestDatadogHTTPClient_Get_Retries5xx(t *testing.T) {
@@ -226,9 +533,8 @@ func TestDatadogHTTPClient_Get_Retries5xx(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := newClient("key", "key", 1, 3, 60*time.Second)
 	fakeSleep := &fakeSleeper{}
-	client.sleeper = fakeSleep
+	client := New("key", "key", WithConcurrency(1), WithRetries(3), withSleeper(fakeSleep))
 
 	resp, err := client.Get(server.URL)
 
@@ -251,6 +557,52 @@ func TestDatadogHTTPClient_Get_Retries5xx(t *testing.T) {
 	}
 }
 
+func TestDatadogHTTPClient_Put_RetriesWithBody(t *testing.T) {
+	var attemptCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count := atomic.AddInt32(&attemptCount, 1)
+		if r.Method != http.MethodPut {
+			t.Errorf("Method = %s, want PUT", r.Method)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", got)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"id":"abc"}` {
+			t.Errorf("attempt %d body = %q, want the full body on every attempt", count, body)
+		}
+		switch count {
+		case 1:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte(`{"id":"abc"}`))
+		}
+	}))
+	defer server.Close()
+
+	fakeSleep := &fakeSleeper{}
+	client := New("key", "key", WithConcurrency(1), WithRetries(3), withSleeper(fakeSleep))
+
+	resp, err := client.Put(server.URL, strings.NewReader(`{"id":"abc"}`))
+	if err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("StatusCode = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if attemptCount != 3 {
+		t.Errorf("attemptCount = %d, want 3", attemptCount)
+	}
+	if got := client.Stats(); got.Requests != 3 || got.Retries != 2 || got.RateLimited != 1 {
+		t.Errorf("Stats() = %+v, want 3 requests, 2 retries, 1 rate limited", got)
+	}
+}
+
 func TestDatadogHTTPClient_Get_DoesNotRetry4xx(t *testing.T) {
 	var attemptCount int32
 
@@ -264,7 +616,7 @@ func TestDatadogHTTPClient_Get_DoesNotRetry4xx(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := newClient("key", "key", 1, 3, 60*time.Second)
+	client := New("key", "key", WithConcurrency(1), WithRetries(3))
 	resp, err := client.Get(server.URL)
 
 	if err != nil {
@@ -282,6 +634,46 @@ func TestDatadogHTTPClient_Get_DoesNotRetry4xx(t *testing.T) {
 	}
 }
 
+func TestDatadogHTTPClient_GetWithContext_Cancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	fakeSleep := &fakeSleeper{}
+	client := New("key", "key", WithConcurrency(1), WithRetries(3), withSleeper(fakeSleep))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	_, err := client.GetWithContext(ctx, server.URL)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetWithContext() error = %v, want context.Canceled", err)
+	}
+	if fakeSleep.getSleepCount() != 0 {
+		t.Errorf("cancelled request was retried %d times", fakeSleep.getSleepCount())
+	}
+
+	// Waiting for a concurrency slot also gives up on cancel: hold the only
+	// slot with a request the server doesn't answer
+	busy, stop := context.WithCancel(context.Background())
+	defer stop()
+	go func() { _, _ = client.GetWithContext(busy, server.URL) }()
+	time.Sleep(20 * time.Millisecond)
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetWithContext(ctx, server.URL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetWithContext() waiting for a slot error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
 func TestDatadogHTTPClient_Get_MaxRetriesExceeded(t *testing.T) {
 	var attemptCount int32
 
@@ -292,9 +684,8 @@ func TestDatadogHTTPClient_Get_MaxRetriesExceeded(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := newClient("key", "key", 1, 2, 60*time.Second) // Only 2 retries
 	fakeSleep := &fakeSleeper{}
-	client.sleeper = fakeSleep
+	client := New("key", "key", WithConcurrency(1), WithRetries(2), withSleeper(fakeSleep)) // Only 2 retries
 
 	resp, err := client.Get(server.URL)
 
@@ -305,8 +696,11 @@ func TestDatadogHTTPClient_Get_MaxRetriesExceeded(t *testing.T) {
 		t.Fatal("Get() expected error for exhausted retries, got nil")
 	}
 
-	if _, ok := err.(*rateLimitedError); !ok {
-		t.Errorf("error type = %T, want *rateLimitedError", err)
+	var rateLimited *RateLimitedError
+	if !errors.As(fmt.Errorf("wrapped: %w", err), &rateLimited) {
+		t.Errorf("error type = %T, want *RateLimitedError", err)
+	} else if rateLimited.RetryAfter != 0 {
+		t.Errorf("RetryAfter = %v, want 0s from the header", rateLimited.RetryAfter)
 	}
 
 	// Should attempt initial + 2 retries = 3 total
@@ -339,8 +733,7 @@ func TestDatadogHTTPClient_Get_ConcurrencyLimit(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := newClient("key", "key", 3, 0, 60*time.Second) // Limit to 3 concurrent
-	client.sleeper = fakeSleep
+	client := New("key", "key", WithConcurrency(3), withSleeper(fakeSleep)) // Limit to 3 concurrent
 
 	// Launch 10 requests concurrently
 	var wg sync.WaitGroup
@@ -385,9 +778,8 @@ func TestDatadogHTTPClient_GlobalPause(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := newClient("key", "key", 5, 3, 60*time.Second)
 	fakeSleep := &fakeSleeper{shouldActual: true} // Need actual sleep for timing checks
-	client.sleeper = fakeSleep
+	client := New("key", "key", WithConcurrency(5), WithRetries(3), withSleeper(fakeSleep))
 
 	// Launch multiple requests concurrently
 	var wg sync.WaitGroup
@@ -517,7 +909,7 @@ func TestParseRetryAfter(t *testing.T) {
 }
 
 func TestRateLimitedError(t *testing.T) {
-	err := &rateLimitedError{after: 5 * time.Second}
+	err := &RateLimitedError{RetryAfter: 5 * time.Second}
 	expected := "rate limited by server (retry after 5s)"
 
 	if err.Error() != expected {
@@ -526,14 +918,14 @@ func TestRateLimitedError(t *testing.T) {
 }
 
 func TestDatadogHTTPClient_SetPause(t *testing.T) {
-	client := newClient("key", "key", 1, 0, 60*time.Second)
+	client := New("key", "key", WithConcurrency(1))
 
 	t.Run("sets pause duration", func(t *testing.T) {
-		client.setPause(2 * time.Second)
+		client.setPause(globalBucket, 2*time.Second)
 
 		// pauseUntil should be approximately 2 seconds from now
 		client.pause.Lock()
-		until := client.pauseUntil
+		until := client.bucket(globalBucket).pauseUntil
 		client.pause.Unlock()
 
 		diff := time.Until(until)
@@ -543,11 +935,11 @@ func TestDatadogHTTPClient_SetPause(t *testing.T) {
 	})
 
 	t.Run("uses default for zero duration", func(t *testing.T) {
-		client2 := newClient("key", "key", 1, 0, 60*time.Second)
-		client2.setPause(0)
+		client2 := New("key", "key", WithConcurrency(1))
+		client2.setPause(globalBucket, 0)
 
 		client2.pause.Lock()
-		until := client2.pauseUntil
+		until := client2.bucket(globalBucket).pauseUntil
 		client2.pause.Unlock()
 
 		diff := time.Until(until)
@@ -557,19 +949,19 @@ func TestDatadogHTTPClient_SetPause(t *testing.T) {
 	})
 
 	t.Run("keeps longer pause", func(t *testing.T) {
-		client3 := newClient("key", "key", 1, 0, 60*time.Second)
+		client3 := New("key", "key", WithConcurrency(1))
 
 		// Set a 3 second pause
-		client3.setPause(3 * time.Second)
+		client3.setPause(globalBucket, 3*time.Second)
 		client3.pause.Lock()
-		first := client3.pauseUntil
+		first := client3.bucket(globalBucket).pauseUntil
 		client3.pause.Unlock()
 
 		// Try to set a shorter 1 second pause
 		time.Sleep(10 * time.Millisecond) // Small actual sleep is acceptable here
-		client3.setPause(1 * time.Second)
+		client3.setPause(globalBucket, 1*time.Second)
 		client3.pause.Lock()
-		second := client3.pauseUntil
+		second := client3.bucket(globalBucket).pauseUntil
 		client3.pause.Unlock()
 
 		// Should keep the longer (first) pause
@@ -579,14 +971,26 @@ func TestDatadogHTTPClient_SetPause(t *testing.T) {
 	})
 }
 
+func TestDatadogHTTPClient_PausedUntil(t *testing.T) {
+	client := New("key", "key", WithConcurrency(1))
+	if until := client.PausedUntil(); !until.IsZero() {
+		t.Errorf("PausedUntil() = %v before any 429, want the zero time", until)
+	}
+	client.setPause(globalBucket, 2*time.Second)
+	if diff := time.Until(client.PausedUntil()); diff < 1900*time.Millisecond || diff > 2100*time.Millisecond {
+		t.Errorf("PausedUntil() is %v away, want ~2s", diff)
+	}
+}
+
 func TestDatadogHTTPClient_WaitIfPaused(t *testing.T) {
 	t.Run("returns immediately when not paused", func(t *testing.T) {
-		client := newClient("key", "key", 1, 0, 60*time.Second)
 		fakeSleep := &fakeSleeper{}
-		client.sleeper = fakeSleep
+		client := New("key", "key", WithConcurrency(1), withSleeper(fakeSleep))
 
 		start := time.Now()
-		client.waitIfPaused()
+		if err := client.waitIfPaused(context.Background(), globalBucket); err != nil {
+			t.Fatal(err)
+		}
 		elapsed := time.Since(start)
 
 		if elapsed > 50*time.Millisecond {
@@ -599,14 +1003,15 @@ func TestDatadogHTTPClient_WaitIfPaused(t *testing.T) {
 	})
 
 	t.Run("waits until pause expires", func(t *testing.T) {
-		client := newClient("key", "key", 1, 0, 60*time.Second)
 		fakeSleep := &fakeSleeper{shouldActual: true} // Need to actually sleep for time-based checks
-		client.sleeper = fakeSleep
+		client := New("key", "key", WithConcurrency(1), withSleeper(fakeSleep))
 
-		client.setPause(100 * time.Millisecond) // Use shorter duration
+		client.setPause(globalBucket, 100*time.Millisecond) // Use shorter duration
 
 		start := time.Now()
-		client.waitIfPaused()
+		if err := client.waitIfPaused(context.Background(), globalBucket); err != nil {
+			t.Fatal(err)
+		}
 		elapsed := time.Since(start)
 
 		// Should have actually slept
@@ -619,4 +1024,187 @@ func TestDatadogHTTPClient_WaitIfPaused(t *testing.T) {
 			t.Error("sleep count = 0, expected at least 1 sleep call")
 		}
 	})
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		client := New("key", "key", WithConcurrency(1))
+		client.setPause(globalBucket, time.Minute)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		if err := client.waitIfPaused(ctx, globalBucket); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("waitIfPaused() error = %v, want context.DeadlineExceeded", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("waitIfPaused() took %v after its context was done", elapsed)
+		}
+	})
+}
+
+func TestDatadogHTTPClient_Post(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Method = %s, want POST", r.Method)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"name":"x"}` {
+			t.Errorf("body = %q", body)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := New("key", "key", WithConcurrency(1), WithRetries(0))
+	resp, err := client.Post(server.URL, strings.NewReader(`{"name":"x"}`))
+	if err != nil {
+		t.Fatalf("Post() unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("StatusCode = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestDatadogHTTPClient_Delete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("Method = %s, want DELETE", r.Method)
+		}
+		if r.ContentLength > 0 {
+			t.Errorf("DELETE sent a body of %d bytes", r.ContentLength)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := New("key", "key", WithConcurrency(1), WithRetries(0))
+	resp, err := client.Delete(server.URL)
+	if err != nil {
+		t.Fatalf("Delete() unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("StatusCode = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestDatadogHTTPClient_PacesBelowRateLimitThreshold(t *testing.T) {
+	var remaining atomic.Int32
+	remaining.Store(50)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		left := remaining.Load()
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(left)))
+		w.Header().Set("X-RateLimit-Reset", "10")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	fakeSleep := &fakeSleeper{}
+	client := New("key", "key", WithConcurrency(1), withSleeper(fakeSleep))
+	get := func() {
+		t.Helper()
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	// Plenty left: no pacing
+	get()
+	get()
+	if n := fakeSleep.getSleepCount(); n != 0 {
+		t.Fatalf("slept %d times with half the limit left, want 0", n)
+	}
+
+	// Below 10%, the waits between requests grow as the budget shrinks
+	for _, left := range []int32{9, 5, 2, 0} {
+		remaining.Store(left)
+		get()
+	}
+	get()
+	fakeSleep.mu.Lock()
+	sleeps := append([]time.Duration(nil), fakeSleep.sleeps...)
+	fakeSleep.mu.Unlock()
+	if len(sleeps) < 3 {
+		t.Fatalf("sleeps = %v, want requests spaced out once below the threshold", sleeps)
+	}
+	for i := 1; i < len(sleeps); i++ {
+		if sleeps[i] <= sleeps[i-1] {
+			t.Errorf("sleeps = %v, want each wait longer than the last", sleeps)
+			break
+		}
+	}
+	if last := sleeps[len(sleeps)-1]; last > 20*time.Second {
+		t.Errorf("last wait = %v, want the budget spread over the 10s reset window", last)
+	}
+
+	// Once the limit has reset, requests go straight through again
+	remaining.Store(100)
+	get()
+	count := fakeSleep.getSleepCount()
+	get()
+	if n := fakeSleep.getSleepCount(); n != count {
+		t.Errorf("slept after the limit reset")
+	}
+}
+
+func TestRateLimitBucket(t *testing.T) {
+	tests := map[string]string{
+		"https://api.datadoghq.com/api/v1/dashboard":             "v1/dashboard",
+		"https://api.datadoghq.com/api/v1/dashboard/abc-def-ghi": "v1/dashboard",
+		"https://api.datadoghq.com/api/v1/monitor/123?a=b":       "v1/monitor",
+		"https://api.datadoghq.com/api/v2/team":                  "v2/team",
+		"https://api.datadoghq.com/api/v1":                       globalBucket,
+		"https://api.datadoghq.com/other/v1/dashboard":           globalBucket,
+		"https://api.datadoghq.com":                              globalBucket,
+		"://invalid":                                             globalBucket,
+	}
+	for rawURL, want := range tests {
+		if got := rateLimitBucket(rawURL); got != want {
+			t.Errorf("rateLimitBucket(%q) = %q, want %q", rawURL, got, want)
+		}
+	}
+}
+
+func TestDatadogHTTPClient_PausesPerEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/v1/dashboard") {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	fakeSleep := &fakeSleeper{}
+	client := New("key", "key", WithRetries(0), withSleeper(fakeSleep))
+
+	var rateLimited *RateLimitedError
+	if _, err := client.Get(server.URL + "/api/v1/dashboard/abc-def-ghi"); !errors.As(err, &rateLimited) {
+		t.Fatalf("dashboard error = %v, want a RateLimitedError", err)
+	}
+	if diff := time.Until(client.PausedUntil()); diff < 29*time.Second {
+		t.Errorf("PausedUntil() is %v away, want ~30s", diff)
+	}
+
+	// Monitor requests aren't held back by the dashboard pause
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL + "/api/v1/monitor/123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if n := fakeSleep.getSleepCount(); n != 0 {
+		t.Errorf("monitor requests slept %d times during a dashboard pause, want 0", n)
+	}
+
+	// Dashboard requests still wait for theirs
+	client.Get(server.URL + "/api/v1/dashboard")
+	if total := fakeSleep.getTotalSlept(); total < 29*time.Second {
+		t.Errorf("dashboard request waited %v, want ~30s", total)
+	}
 }
@@ -0,0 +1,196 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// FixturesRecord records every response to a fixture file.
+	FixturesRecord = "record"
+	// FixturesReplay serves responses from fixture files instead of the network.
+	FixturesReplay = "replay"
+)
+
+var (
+	// errNoFixture is returned in replay mode for requests without a fixture.
+	// It is not retried.
+	errNoFixture = errors.New("no fixture recorded for request")
+
+	// errFixturesUnavailable is returned for every request of a client whose
+	// fixture transport couldn't be created, rather than calling the API.
+	// It is not retried.
+	errFixturesUnavailable = errors.New("fixtures unavailable")
+
+	// fixtureNameRegex matches characters not allowed in fixture file names
+	fixtureNameRegex = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+)
+
+// fixture is the on-disk representation of a recorded response.
+// Request headers (which carry the API keys) are never stored.
+type fixture struct {
+	Method string          `json:"method"`
+	URL    string          `json:"url"` // path and query only, so fixtures work for any site
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+	Text   string          `json:"text,omitempty"` // body, when it isn't valid JSON
+}
+
+// fixtureTransport is an http.RoundTripper which records responses from next
+// to fixture files, or replays them without touching the network. It sits
+// underneath DatadogHTTPClient so retries and 429 pauses still apply.
+type fixtureTransport struct {
+	mode    string
+	dir     string
+	next    http.RoundTripper
+	secrets []string // values scrubbed from recorded bodies
+}
+
+// newFixtureTransport returns a transport for the given mode ("record" or
+// "replay"). Any non-empty secrets are replaced with "REDACTED" in recorded
+// bodies.
+func newFixtureTransport(mode, dir string, next http.RoundTripper, secrets ...string) (*fixtureTransport, error) {
+	if mode != FixturesRecord && mode != FixturesReplay {
+		return nil, fmt.Errorf("invalid fixtures mode %q (expected %q or %q)", mode, FixturesRecord, FixturesReplay)
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("fixtures directory must be set")
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	var nonEmpty []string
+	for _, s := range secrets {
+		if s != "" {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+	return &fixtureTransport{mode: mode, dir: dir, next: next, secrets: nonEmpty}, nil
+}
+
+// unavailableFixtureTransport fails every request with err, so a client
+// meant to record or replay fixtures never calls the API without them.
+type unavailableFixtureTransport struct {
+	err error
+}
+
+func (t unavailableFixtureTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, t.err
+}
+
+// FixturePath returns the fixture file used for a request method and URL.
+// The file name is derived from the method, path, and query so that it is
+// readable and stable across sites.
+func FixturePath(dir, method, rawURL string) string {
+	name := strings.ToLower(method) + "-" + strings.Trim(fixtureNameRegex.ReplaceAllString(requestURI(rawURL), "-"), "-")
+	return filepath.Join(dir, name+".json")
+}
+
+// requestURI strips scheme and host from a URL, leaving path and query.
+func requestURI(rawURL string) string {
+	if i := strings.Index(rawURL, "://"); i >= 0 {
+		rest := rawURL[i+3:]
+		if j := strings.Index(rest, "/"); j >= 0 {
+			return rest[j:]
+		}
+		return "/"
+	}
+	return rawURL
+}
+
+func (t *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := FixturePath(t.dir, req.Method, req.URL.String())
+	if t.mode == FixturesReplay {
+		return t.replay(req, path)
+	}
+	return t.record(req, path)
+}
+
+func (t *fixtureTransport) replay(req *http.Request, path string) (*http.Response, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s %s (expected %s)", errNoFixture, req.Method, req.URL.RequestURI(), path)
+		}
+		return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
+	}
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+
+	body := []byte(f.Body)
+	if f.Text != "" {
+		body = []byte(f.Text)
+	}
+	header := http.Header{}
+	if len(f.Body) > 0 {
+		header.Set("Content-Type", "application/json")
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+		StatusCode:    f.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func (t *fixtureTransport) record(req *http.Request, path string) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	// Hand the caller an unread copy of the body
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	scrubbed := body
+	for _, s := range t.secrets {
+		scrubbed = bytes.ReplaceAll(scrubbed, []byte(s), []byte("REDACTED"))
+	}
+	f := fixture{
+		Method: req.Method,
+		URL:    req.URL.RequestURI(),
+		Status: resp.StatusCode,
+	}
+	if json.Valid(scrubbed) {
+		f.Body = scrubbed
+	} else {
+		f.Text = string(scrubbed)
+	}
+
+	// The fixture file is the same shape as any other JSON we write
+	var buf bytes.Buffer
+	raw, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Indent(&buf, raw, "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create fixtures directory: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write fixture %s: %w", path, err)
+	}
+	return resp, nil
+}
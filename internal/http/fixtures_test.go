@@ -0,0 +1,129 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+)
+
+func TestFixturePath(t *testing.T) {
+	got := FixturePath("dir", "GET", "https://api.datadoghq.com/api/v1/dashboard?start=0&count=1000")
+	want := filepath.Join("dir", "get-api-v1-dashboard-start-0-count-1000.json")
+	if got != want {
+		t.Errorf("FixturePath() = %q, want %q", got, want)
+	}
+
+	// Fixtures don't depend on the site
+	eu := FixturePath("dir", "GET", "https://api.datadoghq.eu/api/v1/dashboard?start=0&count=1000")
+	if eu != want {
+		t.Errorf("FixturePath() for EU site = %q, want %q", eu, want)
+	}
+}
+
+func TestFixtureTransport_RecordThenReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, `{"id":"abc","note":"key secret-api-key"}`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recorder, err := newFixtureTransport(FixturesRecord, dir, http.DefaultTransport, "secret-api-key", "")
+	if err != nil {
+		t.Fatalf("newFixtureTransport() error: %v", err)
+	}
+	client := New("secret-api-key", "secret-app-key", WithConcurrency(1), WithTransport(recorder))
+
+	resp, err := client.Get(server.URL + "/api/v1/dashboard/abc")
+	if err != nil {
+		t.Fatalf("record Get() error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "secret-api-key") {
+		t.Errorf("recording should not alter the live response, got %s", body)
+	}
+
+	path := FixturePath(dir, "GET", server.URL+"/api/v1/dashboard/abc")
+	recorded, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("fixture not written: %v", err)
+	}
+	if strings.Contains(string(recorded), "secret-api-key") {
+		t.Errorf("fixture contains API key: %s", recorded)
+	}
+	if !strings.Contains(string(recorded), "REDACTED") {
+		t.Errorf("fixture should contain scrubbed key, got %s", recorded)
+	}
+
+	server.Close()
+	replayer, err := newFixtureTransport(FixturesReplay, dir, nil)
+	if err != nil {
+		t.Fatalf("newFixtureTransport() error: %v", err)
+	}
+	client = New("secret-api-key", "secret-app-key", WithConcurrency(1), WithTransport(replayer))
+
+	// Any host works in replay mode
+	resp, err = client.Get("https://api.datadoghq.com/api/v1/dashboard/abc")
+	if err != nil {
+		t.Fatalf("replay Get() error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("replay status = %d, want 200", resp.StatusCode)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `"id": "abc"`) {
+		t.Errorf("replay body = %s, want recorded dashboard", body)
+	}
+}
+
+func TestFixtureTransport_ReplayUnknownRequest(t *testing.T) {
+	replayer, err := newFixtureTransport(FixturesReplay, t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("newFixtureTransport() error: %v", err)
+	}
+	sleeper := &fakeSleeper{}
+	client := New("k", "a", WithConcurrency(1), WithRetries(3), withSleeper(sleeper), WithTransport(replayer))
+
+	_, err = client.Get("https://api.datadoghq.com/api/v1/dashboard/missing")
+	if !errors.Is(err, errNoFixture) {
+		t.Fatalf("Get() error = %v, want errNoFixture", err)
+	}
+	if sleeper.getSleepCount() != 0 {
+		t.Errorf("missing fixture was retried %d times", sleeper.getSleepCount())
+	}
+}
+
+func TestNewFixtureTransport_InvalidMode(t *testing.T) {
+	if _, err := newFixtureTransport("rewind", t.TempDir(), nil); err == nil {
+		t.Error("newFixtureTransport() expected error for invalid mode")
+	}
+	if _, err := newFixtureTransport(FixturesReplay, "", nil); err == nil {
+		t.Error("newFixtureTransport() expected error for empty directory")
+	}
+}
+
+func TestNewClient_FixturesUnavailable(t *testing.T) {
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	client := NewClient(&config.Settings{Fixtures: FixturesReplay, HTTPMaxConcurrency: 1, HTTPRetries: 3})
+	_, err := client.Get(server.URL + "/api/v1/dashboard/abc-def-ghi")
+	if !errors.Is(err, errFixturesUnavailable) {
+		t.Errorf("Get() error = %v, want errFixturesUnavailable", err)
+	}
+	if called || client.Stats().Requests != 1 {
+		t.Errorf("API called = %v, requests = %d, want no API call and no retries", called, client.Stats().Requests)
+	}
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Stats are a client's request counts and latencies since it was created.
+type Stats struct {
+	Requests      int64         // Requests sent, retries included
+	Retries       int64         // Requests sent again after an error, 5xx or 429
+	RateLimited   int64         // Responses with status 429
+	RateLimitWait time.Duration // Time requests spent waiting out 429 pauses and pacing, summed
+	LatencyP50    time.Duration // Median time to a response, see latencyHistogram
+	LatencyP95    time.Duration
+}
+
+// Stats returns the client's request counts and latencies so far.
+func (c *DatadogHTTPClient) Stats() Stats {
+	return Stats{
+		Requests:      c.requests.Load(),
+		Retries:       c.retried.Load(),
+		RateLimited:   c.rateLimited.Load(),
+		RateLimitWait: time.Duration(c.rateLimitWait.Load()),
+		LatencyP50:    c.latency.percentile(0.50),
+		LatencyP95:    c.latency.percentile(0.95),
+	}
+}
+
+// latencyBuckets is the number of bounded buckets of a latencyHistogram.
+const latencyBuckets = 54
+
+// latencyBounds are the upper bounds of the latencyHistogram buckets, 25%
+// apart from 1ms to about 2 minutes.
+var latencyBounds = func() [latencyBuckets]time.Duration {
+	var bounds [latencyBuckets]time.Duration
+	b := time.Millisecond
+	for i := range bounds {
+		bounds[i] = b
+		b = b * 5 / 4
+	}
+	return bounds
+}()
+
+// latencyHistogram counts latencies in log-spaced buckets, so percentiles
+// are within 25% without keeping every sample. Its zero value is empty.
+type latencyHistogram struct {
+	// counts[i] counts latencies up to latencyBounds[i], and the last one
+	// those above them all
+	counts [latencyBuckets + 1]atomic.Int64
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	i := sort.Search(latencyBuckets, func(i int) bool { return d <= latencyBounds[i] })
+	h.counts[i].Add(1)
+}
+
+// percentile returns the upper bound of the bucket holding the p-th
+// fraction of the latencies observed, or 0 if there are none. Latencies
+// above the last bound report it.
+func (h *latencyHistogram) percentile(p float64) time.Duration {
+	var counts [latencyBuckets + 1]int64
+	var total int64
+	for i := range h.counts {
+		counts[i] = h.counts[i].Load()
+		total += counts[i]
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(p * float64(total)))
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for i, n := range counts {
+		seen += n
+		if seen >= rank && i < latencyBuckets {
+			return latencyBounds[i]
+		}
+	}
+	return latencyBounds[latencyBuckets-1]
+}
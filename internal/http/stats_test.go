@@ -0,0 +1,86 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDatadogHTTPClient_Stats(t *testing.T) {
+	var attemptCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&attemptCount, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	client := New("key", "key", WithConcurrency(1), WithRetries(3), withSleeper(&fakeSleeper{}))
+	if got := client.Stats(); got != (Stats{}) {
+		t.Errorf("Stats() of a new client = %+v, want zero", got)
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Get() unexpected error: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	got := client.Stats()
+	if got.Requests != 4 || got.Retries != 2 || got.RateLimited != 1 {
+		t.Errorf("Stats() = %+v, want 4 requests, 2 retries, 1 rate limited", got)
+	}
+	// At least the 429's Retry-After. The fake sleeper doesn't let the pause
+	// pass, so the requests after it wait it out again.
+	if got.RateLimitWait < time.Second {
+		t.Errorf("RateLimitWait = %v, want >= 1s", got.RateLimitWait)
+	}
+	if got.LatencyP50 <= 0 || got.LatencyP95 < got.LatencyP50 {
+		t.Errorf("LatencyP50 = %v, LatencyP95 = %v, want 0 < p50 <= p95", got.LatencyP50, got.LatencyP95)
+	}
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	var h latencyHistogram
+	if got := h.percentile(0.5); got != 0 {
+		t.Errorf("percentile(0.5) of no latencies = %v, want 0", got)
+	}
+
+	for i := 0; i < 50; i++ {
+		h.observe(time.Millisecond)
+	}
+	for i := 0; i < 45; i++ {
+		h.observe(100 * time.Millisecond)
+	}
+	for i := 0; i < 5; i++ {
+		h.observe(time.Hour) // Above every bound
+	}
+
+	tests := []struct {
+		p        float64
+		min, max time.Duration
+	}{
+		{0.50, time.Millisecond, time.Millisecond},
+		{0.51, 100 * time.Millisecond, 125 * time.Millisecond},
+		{0.95, 100 * time.Millisecond, 125 * time.Millisecond},
+		{0.99, latencyBounds[latencyBuckets-1], latencyBounds[latencyBuckets-1]},
+	}
+	for _, tt := range tests {
+		if got := h.percentile(tt.p); got < tt.min || got > tt.max {
+			t.Errorf("percentile(%v) = %v, want %v to %v", tt.p, got, tt.min, tt.max)
+		}
+	}
+	if last := latencyBounds[latencyBuckets-1]; last < time.Minute {
+		t.Errorf("last bound = %v, want at least 1m", last)
+	}
+}
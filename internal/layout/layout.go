@@ -0,0 +1,211 @@
+// Package layout moves downloaded files to the paths the current path
+// template gives them, after the template has changed.
+package layout
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/datadog/dashboards"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+// ComputeFunc returns the path a file's content belongs at under the current
+// template, and the tag placeholders the content has no value for.
+type ComputeFunc func(raw []byte) (path string, missingTags []string, err error)
+
+// Move is a file to move.
+type Move struct {
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	MissingTags []string `json:"missing_tags,omitempty"` // rendered as "none" in To
+}
+
+// Result is the outcome of Migrate.
+type Result struct {
+	Moves      []Move  // moved, or to move with DryRun
+	Unchanged  int     // files already at their path
+	Collisions []Move  // not moved: the destination exists or another file maps to it
+	Errors     []error // files that couldn't be read or placed
+}
+
+// Options configures Migrate.
+type Options struct {
+	Dir     string      // directory to scan
+	Kind    string      // resource.Kind* of the files to move; others are left alone
+	Compute ComputeFunc // the kind's path computation
+	DryRun  bool        // plan the moves without touching any file
+}
+
+// Migrate moves every file of opts.Kind under opts.Dir to the path
+// opts.Compute gives it. Dashboard presets and summary files follow their
+// dashboard; split dashboards are left alone. Files whose destination already exists, or that
+// share a destination, stay where they are and are reported as collisions.
+// Directories emptied by the moves are removed, up to opts.Dir.
+func Migrate(opts Options) (*Result, error) {
+	files := storage.FileBackend{}
+	paths, err := files.List(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	var planned []Move
+	targets := map[string][]int{} // destination -> indexes in planned
+	sources := map[string]bool{}
+	for _, path := range paths {
+		if !strings.HasSuffix(path, ".json") || storage.IsSidecarPath(path) || filepath.Base(path) == dashboards.SkeletonName {
+			continue // split dashboards are moved by hand, as a directory
+		}
+		sources[filepath.Clean(path)] = true
+		data, err := files.Read(path)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		var content map[string]any
+		if err := json.Unmarshal(data, &content); err != nil || resource.GuessKind(content) != opts.Kind {
+			continue // split dashboards, other kinds; verify reports invalid files
+		}
+		to, missing, err := opts.Compute(data)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		if filepath.Clean(to) == filepath.Clean(path) {
+			result.Unchanged++
+			continue
+		}
+		targets[filepath.Clean(to)] = append(targets[filepath.Clean(to)], len(planned))
+		planned = append(planned, Move{From: path, To: to, MissingTags: missing})
+	}
+
+	for _, move := range planned {
+		dest := filepath.Clean(move.To)
+		if len(targets[dest]) > 1 || sources[dest] || exists(dest) {
+			result.Collisions = append(result.Collisions, move)
+			continue
+		}
+		if len(move.MissingTags) > 0 {
+			logging.Logger.Warn("file lacks tags used by the path template; moving to the fallback path", "path", move.From, "to", move.To, "missing", strings.Join(move.MissingTags, ","))
+		}
+		result.Moves = append(result.Moves, move)
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	var moved []Move
+	for _, move := range result.Moves {
+		if err := rename(move.From, move.To); err != nil {
+			result.Errors = append(result.Errors, err)
+			continue
+		}
+		to := storage.CompanionPaths(move.To)
+		for i, companion := range storage.CompanionPaths(move.From) {
+			if exists(companion) {
+				if err := rename(companion, to[i]); err != nil {
+					result.Errors = append(result.Errors, err)
+				}
+			}
+		}
+		moved = append(moved, move)
+	}
+	result.Moves = moved
+	removeEmptyDirs(opts.Dir, moved)
+	return result, nil
+}
+
+// Rehome moves the file at path, with its companion files, to the path
+// compute gives its content, e.g. once push has written a created
+// resource's new id into it. It returns where the file is: path if it is
+// already there or is a split dashboard's directory or skeleton. An
+// existing destination is an error, and the file stays where it is.
+func Rehome(backend storage.Backend, path string, compute ComputeFunc) (string, error) {
+	if filepath.Base(path) == dashboards.SkeletonName || !strings.HasSuffix(path, ".json") {
+		return path, nil
+	}
+	data, err := backend.Read(path)
+	if err != nil {
+		return path, err
+	}
+	to, missing, err := compute(data)
+	if err != nil {
+		return path, fmt.Errorf("%s: %w", path, err)
+	}
+	if filepath.Clean(to) == filepath.Clean(path) {
+		return path, nil
+	}
+	if stored(backend, to) {
+		return path, fmt.Errorf("not moving %s: %s already exists", path, to)
+	}
+	if len(missing) > 0 {
+		logging.Logger.Warn("file lacks tags used by the path template; moving to the fallback path", "path", path, "to", to, "missing", strings.Join(missing, ","))
+	}
+	if err := storage.Move(backend, path, to); err != nil {
+		return path, err
+	}
+	companions := storage.CompanionPaths(to)
+	for i, companion := range storage.CompanionPaths(path) {
+		if stored(backend, companion) {
+			if err := storage.Move(backend, companion, companions[i]); err != nil {
+				return to, err
+			}
+		}
+	}
+	return to, nil
+}
+
+// rename moves from to to, creating the destination directory.
+func rename(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", to, err)
+	}
+	if err := os.Rename(from, to); err != nil {
+		return fmt.Errorf("failed to move %s: %w", from, err)
+	}
+	return nil
+}
+
+// removeEmptyDirs removes the directories files were moved out of, and their
+// parents, if they are now empty. root itself is kept.
+func removeEmptyDirs(root string, moves []Move) {
+	root = filepath.Clean(root)
+	var dirs []string
+	for _, move := range moves {
+		dirs = append(dirs, filepath.Dir(move.From))
+	}
+	// Deepest first, so parents are empty by the time they're checked
+	sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) > len(dirs[j]) })
+	for _, dir := range dirs {
+		for dir = filepath.Clean(dir); dir != root && strings.HasPrefix(dir, root+string(filepath.Separator)); dir = filepath.Dir(dir) {
+			entries, err := os.ReadDir(dir)
+			if err != nil || len(entries) > 0 {
+				break
+			}
+			if err := os.Remove(dir); err != nil {
+				logging.Logger.Warn("failed to remove empty directory", "path", dir, "error", err)
+				break
+			}
+		}
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// stored reports whether backend has anything at path.
+func stored(backend storage.Backend, path string) bool {
+	_, err := backend.Read(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
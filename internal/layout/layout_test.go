@@ -0,0 +1,216 @@
+package layout
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/dashboards"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/AD7six/dd-tf/internal/storage/storagetest"
+)
+
+// writeFiles creates files under dir from a map of relative paths to content.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// listFiles returns the files under dir, relative to it.
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func dashboardOptions(dir, template string) Options {
+	settings := &config.Settings{DashboardsPathTemplate: filepath.Join(dir, template)}
+	return Options{
+		Dir:  dir,
+		Kind: resource.KindDashboard,
+		Compute: func(raw []byte) (string, []string, error) {
+			return dashboards.Kind{}.ComputePath(settings, raw)
+		},
+	}
+}
+
+const (
+	platformDashboard = `{"id":"abc-def-ghi","title":"API","layout_type":"ordered","widgets":[],"tags":["team:platform"]}`
+	untaggedDashboard = `{"id":"xyz-uvw-rst","title":"Misc","layout_type":"ordered","widgets":[],"tags":[]}`
+)
+
+func TestMigrate(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"old/abc-def-ghi.json":         platformDashboard,
+		"old/abc-def-ghi.presets.json": `[]`,
+		"old/abc-def-ghi.md":           "# API\n",
+		"old/nested/xyz-uvw-rst.json":  untaggedDashboard,
+		"keep/notes.txt":               "not a dashboard",
+	})
+
+	result, err := Migrate(dashboardOptions(dir, "{team}/{id}.json"))
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if len(result.Moves) != 2 || len(result.Collisions) != 0 || len(result.Errors) != 0 {
+		t.Fatalf("Migrate() = %+v, want 2 moves", result)
+	}
+	for _, move := range result.Moves {
+		if filepath.Base(move.From) == "xyz-uvw-rst.json" && !reflect.DeepEqual(move.MissingTags, []string{"team"}) {
+			t.Errorf("MissingTags = %v, want [team]", move.MissingTags)
+		}
+	}
+
+	want := []string{
+		"keep/notes.txt",
+		"none/xyz-uvw-rst.json",
+		"platform/abc-def-ghi.json",
+		"platform/abc-def-ghi.md",
+		"platform/abc-def-ghi.presets.json",
+	}
+	if got := listFiles(t, dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old")); !os.IsNotExist(err) {
+		t.Errorf("old directory not removed: %v", err)
+	}
+}
+
+func TestMigrate_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"old/abc-def-ghi.json": platformDashboard})
+
+	opts := dashboardOptions(dir, "{team}/{id}.json")
+	opts.DryRun = true
+	result, err := Migrate(opts)
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	want := []Move{{From: filepath.Join(dir, "old", "abc-def-ghi.json"), To: filepath.Join(dir, "platform", "abc-def-ghi.json")}}
+	if !reflect.DeepEqual(result.Moves, want) {
+		t.Errorf("Moves = %+v, want %+v", result.Moves, want)
+	}
+	if got := listFiles(t, dir); !reflect.DeepEqual(got, []string{"old/abc-def-ghi.json"}) {
+		t.Errorf("dry run moved files: %v", got)
+	}
+}
+
+func TestMigrate_Unchanged(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"platform/abc-def-ghi.json": platformDashboard})
+
+	result, err := Migrate(dashboardOptions(dir, "{team}/{id}.json"))
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if result.Unchanged != 1 || len(result.Moves) != 0 {
+		t.Errorf("Migrate() = %+v, want 1 unchanged", result)
+	}
+}
+
+func TestMigrate_Collisions(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		// Same dashboard twice: both map to the same path
+		"a/abc-def-ghi.json": platformDashboard,
+		"b/abc-def-ghi.json": platformDashboard,
+		// The destination exists, with content that belongs elsewhere
+		"c/xyz-uvw-rst.json":    untaggedDashboard,
+		"none/xyz-uvw-rst.json": `not json`,
+	})
+
+	result, err := Migrate(dashboardOptions(dir, "{team}/{id}.json"))
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if len(result.Moves) != 0 || len(result.Collisions) != 3 {
+		t.Errorf("Migrate() = %+v, want 3 collisions", result)
+	}
+	if got := listFiles(t, dir); len(got) != 4 {
+		t.Errorf("files = %v, want all left in place", got)
+	}
+}
+
+func TestMigrate_OtherKinds(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"monitors/1.json":                     `{"id":1,"type":"metric alert","query":"avg(last_5m):avg:cpu{*} > 1"}`,
+		"split/" + dashboards.SkeletonName:    `{"id":"abc-def-ghi","layout_type":"ordered","widgets":[]}`,
+		"split/001-note.json":                 `{"definition":{"type":"note"}}`,
+		"dashboards/abc-def-ghi.json":         platformDashboard,
+		"dashboards/abc-def-ghi.presets.json": `[]`,
+	})
+
+	result, err := Migrate(dashboardOptions(dir, "dashboards/{id}.json"))
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if result.Unchanged != 1 || len(result.Moves) != 0 || len(result.Collisions) != 0 || len(result.Errors) != 0 {
+		t.Errorf("Migrate() = %+v, want only the dashboard, unchanged", result)
+	}
+}
+
+func TestRehome(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old-id-000.json")
+	writeFiles(t, dir, map[string]string{
+		"old-id-000.json": platformDashboard,
+		"old-id-000.md":   "# API",
+	})
+	compute := dashboardOptions(dir, "{id}.json").Compute
+
+	got, err := Rehome(storage.FileBackend{}, old, compute)
+	if want := filepath.Join(dir, "abc-def-ghi.json"); err != nil || got != want {
+		t.Fatalf("Rehome() = %q, %v, want %q", got, err, want)
+	}
+	if files := listFiles(t, dir); !reflect.DeepEqual(files, []string{"abc-def-ghi.json", "abc-def-ghi.md"}) {
+		t.Errorf("files = %v, want the dashboard and its summary moved", files)
+	}
+	if got, err := Rehome(storage.FileBackend{}, got, compute); err != nil || got != filepath.Join(dir, "abc-def-ghi.json") {
+		t.Errorf("Rehome() of a file in place = %q, %v", got, err)
+	}
+
+	writeFiles(t, dir, map[string]string{"copy.json": platformDashboard})
+	if got, err := Rehome(storage.FileBackend{}, filepath.Join(dir, "copy.json"), compute); err == nil || got != filepath.Join(dir, "copy.json") {
+		t.Errorf("Rehome() onto an existing file = %q, %v, want an error and the file left in place", got, err)
+	}
+}
+
+func TestRehome_Backend(t *testing.T) {
+	backend := storagetest.NewMemory(map[string]string{
+		"data/dashboards/old-id-000.json": platformDashboard,
+		"data/dashboards/old-id-000.md":   "# API",
+	})
+	compute := dashboardOptions("data/dashboards", "{id}.json").Compute
+
+	got, err := Rehome(backend, "data/dashboards/old-id-000.json", compute)
+	if want := filepath.Join("data/dashboards", "abc-def-ghi.json"); err != nil || got != want {
+		t.Fatalf("Rehome() = %q, %v, want %q", got, err, want)
+	}
+	if paths := backend.Paths(); !reflect.DeepEqual(paths, []string{"data/dashboards/abc-def-ghi.json", "data/dashboards/abc-def-ghi.md"}) {
+		t.Errorf("stored = %v, want the dashboard and its summary moved", paths)
+	}
+}
@@ -0,0 +1,146 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// AnnotationsGitHub emits GitHub Actions workflow commands for warnings and errors.
+const AnnotationsGitHub = "github"
+
+// EnableAnnotations wraps the global logger so warnings and errors are also
+// written to stdout as CI annotations. format is "github", "none" or empty;
+// empty means "github" when running under GitHub Actions (GITHUB_ACTIONS=true)
+// and none otherwise. Call it after InitLogger.
+func EnableAnnotations(format string) error {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" && os.Getenv("GITHUB_ACTIONS") == "true" {
+		format = AnnotationsGitHub
+	}
+	switch format {
+	case "", "none":
+		return nil
+	case AnnotationsGitHub:
+		Logger = slog.New(newAnnotationHandler(Logger.Handler(), stdout{}))
+		return nil
+	default:
+		return fmt.Errorf("unknown annotations format %q (expected %q or \"none\")", format, AnnotationsGitHub)
+	}
+}
+
+// stdoutReserved is set when stdout carries command output (e.g. --stdout).
+var stdoutReserved atomic.Bool
+
+// ReserveStdout marks stdout as reserved for command output. Anything that
+// would otherwise be written to stdout, such as CI annotations, goes to
+// stderr instead so piped output stays clean.
+func ReserveStdout() {
+	stdoutReserved.Store(true)
+}
+
+// stdout writes to os.Stdout unless ReserveStdout was called.
+type stdout struct{}
+
+func (stdout) Write(p []byte) (int, error) {
+	if stdoutReserved.Load() {
+		return os.Stderr.Write(p)
+	}
+	return os.Stdout.Write(p)
+}
+
+// annotationHandler passes records through to next and, for warnings and
+// errors, also writes a GitHub workflow command such as
+//
+//	::error file=data/dashboards/x.json::download failed id=abc-123
+//
+// The file is taken from a "path" or "file" attribute so that annotations
+// land on the right file in the PR.
+type annotationHandler struct {
+	next  slog.Handler
+	w     io.Writer
+	mu    *sync.Mutex
+	attrs []slog.Attr
+}
+
+func newAnnotationHandler(next slog.Handler, w io.Writer) *annotationHandler {
+	return &annotationHandler{next: next, w: w, mu: &sync.Mutex{}}
+}
+
+func (h *annotationHandler) Enabled(ctx context.Context, lvl slog.Level) bool {
+	return lvl >= slog.LevelWarn || h.next.Enabled(ctx, lvl)
+}
+
+func (h *annotationHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelWarn {
+		if err := h.annotate(r); err != nil {
+			return err
+		}
+	}
+	if h.next.Enabled(ctx, r.Level) {
+		return h.next.Handle(ctx, r)
+	}
+	return nil
+}
+
+func (h *annotationHandler) annotate(r slog.Record) error {
+	command := "warning"
+	if r.Level >= slog.LevelError {
+		command = "error"
+	}
+
+	var file string
+	var b strings.Builder
+	b.WriteString(r.Message)
+	addAttr := func(a slog.Attr) {
+		if (a.Key == "path" || a.Key == "file") && file == "" {
+			file = a.Value.String()
+			return
+		}
+		b.WriteString(" ")
+		b.WriteString(a.Key)
+		b.WriteString("=")
+		b.WriteString(a.Value.String())
+	}
+	for _, a := range h.attrs {
+		addAttr(a)
+	}
+	r.Attrs(func(a slog.Attr) bool { addAttr(a); return true })
+
+	line := "::" + command
+	if file != "" {
+		line += " file=" + escapeAnnotationProperty(file)
+	}
+	line += "::" + escapeAnnotationData(b.String()) + "\n"
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, line)
+	return err
+}
+
+func (h *annotationHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	newAttrs := make([]slog.Attr, len(h.attrs)+len(attrs))
+	copy(newAttrs, h.attrs)
+	copy(newAttrs[len(h.attrs):], attrs)
+	return &annotationHandler{next: h.next.WithAttrs(attrs), w: h.w, mu: h.mu, attrs: newAttrs}
+}
+
+func (h *annotationHandler) WithGroup(name string) slog.Handler {
+	return &annotationHandler{next: h.next.WithGroup(name), w: h.w, mu: h.mu, attrs: h.attrs}
+}
+
+// escapeAnnotationData escapes a workflow command message.
+func escapeAnnotationData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeAnnotationProperty escapes a workflow command property value.
+func escapeAnnotationProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestAnnotationHandler(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	base := slog.NewTextHandler(&stderr, &slog.HandlerOptions{Level: slog.LevelError})
+	logger := slog.New(newAnnotationHandler(base, &stdout))
+
+	logger.Info("dashboard saved", "path", "data/dashboards/x.json")
+	logger.Warn("no dashboards found with tags", "tags", "team:web")
+	logger.Error("download failed", "path", "data/dashboards/x.json", "error", errors.New("abc-123: API error: 404\nnot found"))
+
+	want := "::warning::no dashboards found with tags tags=team:web\n" +
+		"::error file=data/dashboards/x.json::download failed error=abc-123: API error: 404%0Anot found\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("annotations =\n%s\nwant\n%s", got, want)
+	}
+
+	// The wrapped handler still sees records at its own level
+	if strings.Contains(stderr.String(), "no dashboards found") {
+		t.Errorf("warning should be filtered by the wrapped handler: %s", stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "download failed") {
+		t.Errorf("error should reach the wrapped handler: %s", stderr.String())
+	}
+}
+
+func TestAnnotationHandler_WithAttrs(t *testing.T) {
+	var stdout bytes.Buffer
+	base := slog.NewTextHandler(&bytes.Buffer{}, nil)
+	logger := slog.New(newAnnotationHandler(base, &stdout)).With("file", "a,b:c.json")
+
+	logger.Error("bad", "id", 1)
+
+	want := "::error file=a%2Cb%3Ac.json::bad id=1\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("annotation = %q, want %q", got, want)
+	}
+}
+
+func TestEnableAnnotations(t *testing.T) {
+	defer InitLogger("")
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	InitLogger("")
+	if err := EnableAnnotations(""); err != nil {
+		t.Fatalf("EnableAnnotations() error = %v", err)
+	}
+	if _, ok := Logger.Handler().(*annotationHandler); !ok {
+		t.Errorf("expected annotations under GitHub Actions")
+	}
+
+	InitLogger("")
+	if err := EnableAnnotations("none"); err != nil {
+		t.Fatalf("EnableAnnotations(none) error = %v", err)
+	}
+	if _, ok := Logger.Handler().(*annotationHandler); ok {
+		t.Errorf("expected no annotations with format none")
+	}
+
+	if err := EnableAnnotations("gitlab"); err == nil {
+		t.Errorf("expected error for unknown format")
+	}
+}
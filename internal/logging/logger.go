@@ -20,7 +20,8 @@ func init() {
 // If LOG_LEVEL is not set (shouldn't be possible since it's in defaults.env),
 // defaults to info level.
 // Also supports LOG_FORMAT environment variable to choose between "text",
-// "json", or "color"output.
+// "json", or "color" output; when unset, color is used only if stderr is a
+// terminal (see resolveFormat).
 func InitLogger(logLevel string) {
 	if logLevel == "" {
 		if logLevel = os.Getenv("LOG_LEVEL"); logLevel == "" {
@@ -41,18 +42,14 @@ func InitLogger(logLevel string) {
 		}
 	}
 
-	format := strings.ToLower(os.Getenv("LOG_FORMAT")) // "text"|"json"|"color"
-	if format == "" && os.Getenv("NO_COLOR") == "" {
-		// Default to color if terminal supports it (NO_COLOR not set)
-		format = "color"
-	}
+	format, noColor := resolveFormat(os.Getenv, stderrIsTerminal())
 
 	var handler slog.Handler
 	switch format {
 	case "json":
 		handler = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})
 	case "color":
-		handler = newColorHandler(os.Stderr, level, os.Getenv("NO_COLOR") != "")
+		handler = newColorHandler(os.Stderr, level, noColor)
 	default:
 		handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})
 	}
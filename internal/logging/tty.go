@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"os"
+	"strings"
+)
+
+// stderrIsTerminal reports whether stderr is attached to a terminal. It is a
+// variable so tests can inject the answer.
+var stderrIsTerminal = func() bool {
+	return isTerminal(os.Stderr)
+}
+
+// isTerminal reports whether f is a character device, which is how a TTY
+// shows up on every platform Go supports; pipes, files and the log capture
+// of Docker and CI runners are not.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// forceColor reports whether FORCE_COLOR asks for color regardless of the
+// terminal. Any non-empty value except "0" and "false" counts.
+func forceColor(getenv func(string) string) bool {
+	switch strings.ToLower(strings.TrimSpace(getenv("FORCE_COLOR"))) {
+	case "", "0", "false":
+		return false
+	}
+	return true
+}
+
+// resolveFormat decides the log handler. An explicit LOG_FORMAT always wins;
+// otherwise FORCE_COLOR forces color, NO_COLOR forces text, and the default
+// is color only when stderr is a terminal. noColor strips the ANSI codes from
+// the color handler's output.
+func resolveFormat(getenv func(string) string, tty bool) (format string, noColor bool) {
+	force := forceColor(getenv)
+	noColor = getenv("NO_COLOR") != "" && !force
+
+	format = strings.ToLower(strings.TrimSpace(getenv("LOG_FORMAT"))) // "text"|"json"|"color"
+	if format != "" {
+		return format, noColor
+	}
+	switch {
+	case force:
+		return "color", false
+	case noColor, !tty:
+		return "text", noColor
+	default:
+		return "color", false
+	}
+}
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"os"
+	"testing"
+)
+
+func envFrom(m map[string]string) func(string) string {
+	return func(k string) string { return m[k] }
+}
+
+func TestResolveFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		tty         bool
+		wantFormat  string
+		wantNoColor bool
+	}{
+		{"terminal defaults to color", nil, true, "color", false},
+		{"pipe defaults to text", nil, false, "text", false},
+		{"NO_COLOR on terminal", map[string]string{"NO_COLOR": "1"}, true, "text", true},
+		{"FORCE_COLOR on pipe", map[string]string{"FORCE_COLOR": "1"}, false, "color", false},
+		{"FORCE_COLOR beats NO_COLOR", map[string]string{"FORCE_COLOR": "1", "NO_COLOR": "1"}, false, "color", false},
+		{"FORCE_COLOR=0 is off", map[string]string{"FORCE_COLOR": "0"}, false, "text", false},
+		{"explicit color on pipe", map[string]string{"LOG_FORMAT": "color"}, false, "color", false},
+		{"explicit color with NO_COLOR", map[string]string{"LOG_FORMAT": "color", "NO_COLOR": "1"}, true, "color", true},
+		{"explicit text on terminal", map[string]string{"LOG_FORMAT": "text"}, true, "text", false},
+		{"explicit json with FORCE_COLOR", map[string]string{"LOG_FORMAT": "JSON", "FORCE_COLOR": "1"}, true, "json", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, noColor := resolveFormat(envFrom(tt.env), tt.tty)
+			if format != tt.wantFormat || noColor != tt.wantNoColor {
+				t.Errorf("resolveFormat() = (%q, %v), want (%q, %v)", format, noColor, tt.wantFormat, tt.wantNoColor)
+			}
+		})
+	}
+}
+
+func TestIsTerminal_File(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if isTerminal(f) {
+		t.Error("isTerminal() = true for a regular file")
+	}
+}
@@ -0,0 +1,172 @@
+// Package notify posts a run summary to a webhook (e.g. Slack) when a run
+// finishes.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+// Values accepted by --notify-on / NOTIFY_ON.
+const (
+	OnAlways  = "always"
+	OnFailure = "failure"
+	OnDrift   = "drift"
+)
+
+// Options holds the per-command notification flags. Empty fields fall back
+// to the NOTIFY_URL and NOTIFY_ON settings.
+type Options struct {
+	URL string
+	On  string
+}
+
+// AddFlags registers --notify-url and --notify-on on cmd.
+func AddFlags(cmd *cobra.Command, opts *Options) {
+	cmd.Flags().StringVar(&opts.URL, "notify-url", "", "POST a JSON run summary to this URL when the run finishes (default: NOTIFY_URL)")
+	cmd.Flags().StringVar(&opts.On, "notify-on", "", "When to notify: always, failure or drift (default: NOTIFY_ON)")
+}
+
+// Summary is the JSON body posted to the notification URL.
+type Summary struct {
+	Text            string   `json:"text"` // one-line summary, rendered by Slack incoming webhooks
+	Command         string   `json:"command"`
+	Status          string   `json:"status"` // "success" or "failure"
+	Total           int      `json:"total"`
+	Succeeded       int      `json:"succeeded"`
+	Failed          int      `json:"failed"`
+	FailedIDs       []string `json:"failed_ids,omitempty"`
+	DriftedIDs      []string `json:"drifted_ids,omitempty"`
+	RestrictedIDs   []string `json:"restricted_ids,omitempty"` // refused by the API (403), not counted as failures
+	DurationSeconds float64  `json:"duration_seconds"`
+}
+
+// NewSummary builds a summary for a finished run. Failures that aren't tied
+// to a resource ID (e.g. a failed listing) are counted but not listed.
+func NewSummary(command string, total int, failedIDs, driftedIDs []string, failed int, duration time.Duration) Summary {
+	s := Summary{
+		Command:         command,
+		Status:          "success",
+		Total:           total,
+		Failed:          failed,
+		FailedIDs:       failedIDs,
+		DriftedIDs:      driftedIDs,
+		DurationSeconds: duration.Round(time.Millisecond).Seconds(),
+	}
+	if failed > 0 {
+		s.Status = "failure"
+	}
+	s.Succeeded = total - len(failedIDs)
+	if s.Succeeded < 0 {
+		s.Succeeded = 0
+	}
+	s.Text = s.text(duration)
+	return s
+}
+
+// WithRestricted adds the resources the API refused (403) without them
+// counting as failures, so they aren't reported as succeeded either.
+func (s Summary) WithRestricted(ids []string, duration time.Duration) Summary {
+	if len(ids) == 0 {
+		return s
+	}
+	s.RestrictedIDs = ids
+	s.Succeeded -= len(ids)
+	if s.Succeeded < 0 {
+		s.Succeeded = 0
+	}
+	s.Text = s.text(duration)
+	return s
+}
+
+// text renders the one-line summary, followed by the failed IDs.
+func (s Summary) text(duration time.Duration) string {
+	text := fmt.Sprintf("dd-tf %s: %s (%d ok, %d failed", s.Command, s.Status, s.Succeeded, s.Failed)
+	if len(s.DriftedIDs) > 0 {
+		text += fmt.Sprintf(", %d drifted", len(s.DriftedIDs))
+	}
+	if len(s.RestrictedIDs) > 0 {
+		text += fmt.Sprintf(", %d restricted", len(s.RestrictedIDs))
+	}
+	text += fmt.Sprintf(") in %s", duration.Round(time.Second))
+	if len(s.FailedIDs) > 0 {
+		text += "\nFailed: " + strings.Join(s.FailedIDs, ", ")
+	}
+	return text
+}
+
+// ShouldSend reports whether a summary matches the --notify-on selector.
+func ShouldSend(on string, s Summary) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(on)) {
+	case "", OnAlways:
+		return true, nil
+	case OnFailure:
+		return s.Failed > 0, nil
+	case OnDrift:
+		return len(s.DriftedIDs) > 0, nil
+	default:
+		return false, fmt.Errorf("invalid notify-on value %q (expected %s, %s or %s)", on, OnAlways, OnFailure, OnDrift)
+	}
+}
+
+// Send POSTs the summary as JSON. It uses a plain HTTP client: the
+// notification URL must never receive the Datadog keys.
+func Send(url string, s Summary, timeout time.Duration) error {
+	body, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode summary: %w", err)
+	}
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("notification request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification rejected: %s", resp.Status)
+	}
+	return nil
+}
+
+// Finish sends the summary if a URL is configured and the selector matches.
+// Notification problems are logged as warnings and never fail the run.
+func Finish(opts Options, s Summary) {
+	url, on := opts.URL, opts.On
+	timeout := 10 * time.Second
+	if settings, err := config.LoadSettings(); err == nil {
+		if url == "" {
+			url = settings.NotifyURL
+		}
+		if on == "" {
+			on = settings.NotifyOn
+		}
+		if settings.NotifyTimeout > 0 {
+			timeout = settings.NotifyTimeout
+		}
+	}
+	if url == "" {
+		return
+	}
+
+	send, err := ShouldSend(on, s)
+	if err != nil {
+		logging.Logger.Warn("notification skipped", "error", err)
+		return
+	}
+	if !send {
+		return
+	}
+	if err := Send(url, s, timeout); err != nil {
+		logging.Logger.Warn("failed to send notification", "error", err)
+		return
+	}
+	logging.Logger.Debug("notification sent", "status", s.Status)
+}
@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestShouldSend(t *testing.T) {
+	ok := NewSummary("dashboards download", 3, nil, nil, 0, time.Second)
+	failed := NewSummary("dashboards download", 3, []string{"abc-def-ghi"}, nil, 1, time.Second)
+	drifted := NewSummary("dashboards diff", 3, nil, []string{"abc-def-ghi"}, 0, time.Second)
+
+	tests := []struct {
+		on      string
+		summary Summary
+		want    bool
+	}{
+		{"", ok, true},
+		{"always", ok, true},
+		{"failure", ok, false},
+		{"failure", failed, true},
+		{"drift", failed, false},
+		{"drift", drifted, true},
+	}
+	for _, tt := range tests {
+		got, err := ShouldSend(tt.on, tt.summary)
+		if err != nil {
+			t.Fatalf("ShouldSend(%q) error = %v", tt.on, err)
+		}
+		if got != tt.want {
+			t.Errorf("ShouldSend(%q, status=%s drifted=%v) = %v, want %v", tt.on, tt.summary.Status, tt.summary.DriftedIDs, got, tt.want)
+		}
+	}
+
+	if _, err := ShouldSend("sometimes", ok); err == nil {
+		t.Error("ShouldSend() expected error for invalid selector")
+	}
+}
+
+func TestNewSummary(t *testing.T) {
+	s := NewSummary("monitors download", 5, []string{"12", "34"}, nil, 3, 1500*time.Millisecond)
+	if s.Status != "failure" || s.Succeeded != 3 || s.Failed != 3 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+	want := "dd-tf monitors download: failure (3 ok, 3 failed) in 2s\nFailed: 12, 34"
+	if s.Text != want {
+		t.Errorf("Text = %q, want %q", s.Text, want)
+	}
+}
+
+func TestSummary_WithRestricted(t *testing.T) {
+	s := NewSummary("dashboards download", 5, []string{"abc-def-ghi"}, nil, 1, time.Second).WithRestricted([]string{"xyz-uvw-rst", "rst-uvw-xyz"}, time.Second)
+	if s.Succeeded != 2 || s.Failed != 1 || len(s.RestrictedIDs) != 2 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+	want := "dd-tf dashboards download: failure (2 ok, 1 failed, 2 restricted) in 1s\nFailed: abc-def-ghi"
+	if s.Text != want {
+		t.Errorf("Text = %q, want %q", s.Text, want)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var got Summary
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		headers = r.Header
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	s := NewSummary("dashboards download", 2, []string{"abc-def-ghi"}, nil, 1, time.Second)
+	if err := Send(server.URL, s, time.Second); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got.Command != "dashboards download" || len(got.FailedIDs) != 1 {
+		t.Errorf("received %+v", got)
+	}
+	if headers.Get("DD-API-KEY") != "" || headers.Get("DD-APPLICATION-KEY") != "" {
+		t.Errorf("notification must not carry Datadog keys")
+	}
+}
+
+func TestSend_Errors(t *testing.T) {
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer rejecting.Close()
+	if err := Send(rejecting.URL, Summary{}, time.Second); err == nil {
+		t.Error("Send() expected error for 500 response")
+	}
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+	if err := Send(slow.URL, Summary{}, 20*time.Millisecond); err == nil {
+		t.Error("Send() expected timeout error")
+	}
+}
+
+func TestFinish_NeverFails(t *testing.T) {
+	t.Setenv("DD_API_KEY", "k")
+	t.Setenv("DD_APP_KEY", "a")
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	// A rejected notification only logs a warning
+	Finish(Options{URL: server.URL}, NewSummary("dashboards download", 1, nil, nil, 0, time.Second))
+	if calls != 1 {
+		t.Errorf("expected one notification, got %d", calls)
+	}
+
+	// Selector doesn't match: nothing sent
+	Finish(Options{URL: server.URL, On: OnFailure}, NewSummary("dashboards download", 1, nil, nil, 0, time.Second))
+	if calls != 1 {
+		t.Errorf("expected no notification for successful run with --notify-on failure, got %d calls", calls)
+	}
+}
@@ -0,0 +1,483 @@
+// Package policy checks downloaded monitors against an organisation's
+// rules (required tags, priorities, message conventions), described in a
+// policy file.
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/templating"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+// DefaultPath is where lint looks for the policy file.
+var DefaultPath = filepath.Join(".dd-tf", "policy.yaml")
+
+// Rule identifiers, one per kind of policy.
+const (
+	RuleRequiredTag     = "required-tag"
+	RulePriority        = "priority"
+	RuleMessagePattern  = "message-pattern"
+	RuleForbiddenTarget = "forbidden-target"
+	RuleNotifyNoData    = "notify-no-data"
+	RuleEmailTarget     = "email-target"
+	RuleRenotify        = "renotify-interval"
+)
+
+// Policy is the set of rules every monitor must follow. Empty rules are not
+// checked.
+type Policy struct {
+	RequiredTags      []string  `json:"required_tags"`             // tag keys every monitor must have
+	AllowedPriorities []int     `json:"allowed_priorities"`        // a monitor without a priority fails too
+	MessagePatterns   []Pattern `json:"required_message_patterns"` // regexes the message must match
+	ForbiddenTargets  []string  `json:"forbidden_targets"`         // notification handles, e.g. "@all"; a trailing * matches a prefix
+	NotifyNoData      *Selector `json:"require_notify_no_data"`    // monitors that must set options.notify_no_data
+	ForbidEmail       bool      `json:"forbid_email_targets"`      // messages must notify handles, not @user@example.com
+	RenotifyInterval  *Bounds   `json:"renotify_interval"`         // minutes options.renotify_interval must be within, if set
+
+	disabled map[string]bool
+}
+
+// Bounds is an inclusive range; a zero Max has no upper bound.
+type Bounds struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// Pattern is a regex the monitor message must match, with an optional
+// description used in violations.
+type Pattern struct {
+	Pattern     string `json:"pattern"`
+	Description string `json:"description"`
+
+	re *regexp.Regexp
+}
+
+// UnmarshalJSON also accepts a bare regex string.
+func (p *Pattern) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		p.Pattern = s
+		return nil
+	}
+	type plain Pattern
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode((*plain)(p))
+}
+
+// Selector picks monitors by type or tag. A monitor matches if it has any of
+// the types or any of the tags.
+type Selector struct {
+	Types []string `json:"types"`
+	Tags  []string `json:"tags"`
+}
+
+func (s *Selector) matches(monitorType string, tags []string) bool {
+	for _, t := range s.Types {
+		if strings.EqualFold(t, monitorType) {
+			return true
+		}
+	}
+	for _, want := range s.Tags {
+		for _, tag := range tags {
+			if tag == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Starter is the policy written by "monitors lint --init".
+const Starter = `# dd-tf monitor policy, checked by "dd-tf monitors lint".
+# Remove a rule to stop checking it.
+
+# Tag keys every monitor must have
+required_tags:
+  - team
+
+# Priorities a monitor may use; monitors without a priority fail
+allowed_priorities: [1, 2, 3, 4, 5]
+
+# Regexes the monitor message must match
+required_message_patterns:
+  - pattern: "@[A-Za-z0-9_.-]+"
+    description: notify someone with an @ mention
+
+# Notification targets that must not be used; a trailing * matches any suffix
+forbidden_targets:
+  - "@all"
+  - "@here"
+
+# Monitors that must enable notify_no_data, selected by type or tag
+require_notify_no_data:
+  types: [service check, synthetics alert]
+  tags: ["category:availability"]
+
+# Notify handles such as @slack-team or @team-oncall, not raw email addresses
+forbid_email_targets: true
+
+# Minutes options.renotify_interval may be set to (max 0: no upper bound)
+renotify_interval:
+  min: 30
+  max: 1440
+`
+
+// Load reads and checks a policy file.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Parse decodes a policy from YAML, rejecting unknown keys so typos don't
+// silently disable a rule.
+func Parse(data []byte) (*Policy, error) {
+	value, err := parseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := value.(map[string]any); !ok {
+		return nil, fmt.Errorf("policy must be a mapping")
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var p Policy
+	dec := json.NewDecoder(bytes.NewReader(encoded))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	if b := p.RenotifyInterval; b != nil && b.Max != 0 && b.Min > b.Max {
+		return nil, fmt.Errorf("invalid renotify_interval: min %d is above max %d", b.Min, b.Max)
+	}
+	for i := range p.MessagePatterns {
+		mp := &p.MessagePatterns[i]
+		if mp.re, err = regexp.Compile(mp.Pattern); err != nil {
+			return nil, fmt.Errorf("invalid message pattern %q: %w", mp.Pattern, err)
+		}
+	}
+	return &p, nil
+}
+
+// Violation is a monitor breaking a rule.
+type Violation struct {
+	Path    string `json:"path"`
+	ID      string `json:"id"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: [%s] %s", v.Path, v.Rule, v.Message)
+}
+
+// Result is the outcome of Lint: every monitor checked and the violations.
+type Result struct {
+	Files      []File
+	Violations []Violation
+}
+
+// File is a checked monitor file.
+type File struct {
+	Path string
+	ID   string
+}
+
+// Lint checks every monitor under dir against the policy. Files that aren't
+// monitors are skipped; verify reports those. Files and violations are in
+// path order.
+func Lint(dir string, p *Policy) (*Result, error) {
+	files := storage.FileBackend{}
+	paths, err := files.List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	for _, path := range paths {
+		if !strings.HasSuffix(path, ".json") {
+			continue
+		}
+		data, err := files.Read(path)
+		if err != nil {
+			return nil, err
+		}
+		var content map[string]any
+		if err := json.Unmarshal(data, &content); err != nil || resource.GuessKind(content) != resource.KindMonitor {
+			continue
+		}
+		file := File{Path: path, ID: idString(content["id"])}
+		result.Files = append(result.Files, file)
+		for _, v := range p.Check(content) {
+			v.Path, v.ID = file.Path, file.ID
+			result.Violations = append(result.Violations, v)
+		}
+	}
+	return result, nil
+}
+
+// Rules returns the ID of every rule, in the order they are checked.
+func Rules() []string {
+	rules := make([]string, len(checkers))
+	for i, c := range checkers {
+		rules[i] = c.Rule
+	}
+	return rules
+}
+
+// Disable stops the rules with the given IDs from being checked, e.g. for
+// --disable. Unknown IDs are an error, so a typo doesn't go unnoticed.
+func (p *Policy) Disable(rules ...string) error {
+	for _, rule := range rules {
+		if !containsString(Rules(), rule) {
+			return fmt.Errorf("unknown rule %q (rules: %s)", rule, strings.Join(Rules(), ", "))
+		}
+		if p.disabled == nil {
+			p.disabled = map[string]bool{}
+		}
+		p.disabled[rule] = true
+	}
+	return nil
+}
+
+// monitor is a decoded monitor with the fields most rules look at.
+type monitor struct {
+	content map[string]any
+	tags    map[string]string
+	message string
+}
+
+// checker is one rule: check returns a message for each way the monitor
+// breaks it, or none if the policy doesn't set the rule.
+type checker struct {
+	Rule  string
+	check func(p *Policy, m monitor) []string
+}
+
+// checkers are every rule, in the order they are checked. To add a rule,
+// write its check and register it here.
+var checkers = []checker{
+	{RuleRequiredTag, checkRequiredTags},
+	{RulePriority, checkPriority},
+	{RuleMessagePattern, checkMessagePatterns},
+	{RuleForbiddenTarget, checkForbiddenTargets},
+	{RuleEmailTarget, checkEmailTargets},
+	{RuleNotifyNoData, checkNotifyNoData},
+	{RuleRenotify, checkRenotify},
+}
+
+// Check returns the rules a decoded monitor breaks, without Path or ID.
+// Disabled rules aren't checked.
+func (p *Policy) Check(content map[string]any) []Violation {
+	m := monitor{content: content, tags: templating.ExtractTagMap(content["tags"], false)}
+	m.message, _ = content["message"].(string)
+
+	var violations []Violation
+	for _, c := range checkers {
+		if p.disabled[c.Rule] {
+			continue
+		}
+		for _, message := range c.check(p, m) {
+			violations = append(violations, Violation{Rule: c.Rule, Message: message})
+		}
+	}
+	return violations
+}
+
+func checkRequiredTags(p *Policy, m monitor) []string {
+	var messages []string
+	for _, key := range p.RequiredTags {
+		if _, ok := m.tags[key]; !ok {
+			messages = append(messages, fmt.Sprintf("missing tag %q", key))
+		}
+	}
+	return messages
+}
+
+func checkPriority(p *Policy, m monitor) []string {
+	if len(p.AllowedPriorities) == 0 {
+		return nil
+	}
+	priority, ok := m.content["priority"].(float64)
+	switch {
+	case !ok:
+		return []string{"priority is not set"}
+	case !containsInt(p.AllowedPriorities, int(priority)):
+		return []string{fmt.Sprintf("priority %d is not allowed (allowed: %s)", int(priority), joinInts(p.AllowedPriorities))}
+	}
+	return nil
+}
+
+func checkMessagePatterns(p *Policy, m monitor) []string {
+	var messages []string
+	for _, mp := range p.MessagePatterns {
+		switch {
+		case mp.re.MatchString(m.message):
+		case mp.Description != "":
+			messages = append(messages, fmt.Sprintf("message must %s (%q)", mp.Description, mp.Pattern))
+		default:
+			messages = append(messages, fmt.Sprintf("message does not match %q", mp.Pattern))
+		}
+	}
+	return messages
+}
+
+func checkForbiddenTargets(p *Policy, m monitor) []string {
+	var messages []string
+	for _, target := range p.ForbiddenTargets {
+		if found := findTarget(m.message, target); found != "" {
+			messages = append(messages, "message notifies "+found)
+		}
+	}
+	return messages
+}
+
+// emailTarget matches a notification sent straight to an email address.
+var emailTarget = regexp.MustCompile(`@[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+func checkEmailTargets(p *Policy, m monitor) []string {
+	if !p.ForbidEmail {
+		return nil
+	}
+	var messages []string
+	for _, found := range emailTarget.FindAllString(m.message, -1) {
+		messages = append(messages, "message notifies the email address "+found)
+	}
+	return messages
+}
+
+func checkNotifyNoData(p *Policy, m monitor) []string {
+	if p.NotifyNoData == nil {
+		return nil
+	}
+	monitorType, _ := m.content["type"].(string)
+	if !p.NotifyNoData.matches(monitorType, stringTags(m.content["tags"])) {
+		return nil
+	}
+	options, _ := m.content["options"].(map[string]any)
+	if enabled, _ := options["notify_no_data"].(bool); !enabled {
+		return []string{"notify_no_data is not enabled"}
+	}
+	return nil
+}
+
+func checkRenotify(p *Policy, m monitor) []string {
+	if p.RenotifyInterval == nil {
+		return nil
+	}
+	options, _ := m.content["options"].(map[string]any)
+	interval, _ := options["renotify_interval"].(float64)
+	b := p.RenotifyInterval
+	if interval == 0 || (int(interval) >= b.Min && (b.Max == 0 || int(interval) <= b.Max)) {
+		return nil
+	}
+	if b.Max == 0 {
+		return []string{fmt.Sprintf("renotify_interval %d is below %d minutes", int(interval), b.Min)}
+	}
+	return []string{fmt.Sprintf("renotify_interval %d is not within %d-%d minutes", int(interval), b.Min, b.Max)}
+}
+
+// findTarget returns the first handle in message matching target, or "".
+// Handles match whole: "@ops" doesn't match "@ops-oncall". A trailing "*" in
+// target matches any handle with that prefix.
+func findTarget(message, target string) string {
+	prefix := strings.HasSuffix(target, "*")
+	target = strings.ToLower(strings.TrimSuffix(target, "*"))
+	if target == "" {
+		return ""
+	}
+	lower := strings.ToLower(message)
+	for i := 0; i < len(lower); {
+		j := strings.Index(lower[i:], target)
+		if j < 0 {
+			return ""
+		}
+		start := i + j
+		end := start + len(target)
+		if start > 0 && isHandleChar(lower[start-1]) {
+			i = start + 1
+			continue
+		}
+		for prefix && end < len(lower) && isHandleChar(lower[end]) {
+			end++
+		}
+		if end == len(lower) || !isHandleChar(lower[end]) {
+			return message[start:end]
+		}
+		i = start + 1
+	}
+	return ""
+}
+
+func isHandleChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '@'
+}
+
+func stringTags(v any) []string {
+	raw, _ := v.([]any)
+	tags := make([]string, 0, len(raw))
+	for _, t := range raw {
+		if s, ok := t.(string); ok {
+			tags = append(tags, s)
+		}
+	}
+	return tags
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(list []int, n int) bool {
+	for _, v := range list {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+func joinInts(list []int) string {
+	sorted := append([]int(nil), list...)
+	sort.Ints(sorted)
+	parts := make([]string, len(sorted))
+	for i, n := range sorted {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// idString formats a decoded id the way it appears in paths.
+func idString(v any) string {
+	switch id := v.(type) {
+	case string:
+		return id
+	case float64:
+		return strconv.FormatInt(int64(id), 10)
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package policy
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParse_Starter(t *testing.T) {
+	p, err := Parse([]byte(Starter))
+	if err != nil {
+		t.Fatalf("Parse(Starter) error = %v", err)
+	}
+	if len(p.RequiredTags) == 0 || len(p.AllowedPriorities) == 0 || len(p.MessagePatterns) == 0 ||
+		len(p.ForbiddenTargets) == 0 || p.NotifyNoData == nil || !p.ForbidEmail || p.RenotifyInterval == nil {
+		t.Errorf("Parse(Starter) = %+v, want every rule set", p)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, data := range []string{
+		"required_tag: [team]",                     // typo
+		"allowed_priorities: [high]",               // not a number
+		"required_message_patterns: [\"(\"]",       // bad regex
+		"required_message_patterns:\n  - foo: x",   // unknown field
+		"- team",                                   // not a mapping
+		"renotify_interval:\n  min: 60\n  max: 30", // empty range
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Parse(%q) expected an error", data)
+		}
+	}
+}
+
+func TestFindTarget(t *testing.T) {
+	tests := []struct {
+		message, target, want string
+	}{
+		{"ping @all now", "@all", "@all"},
+		{"ping @ALL", "@all", "@ALL"},
+		{"ping @alliance", "@all", ""},
+		{"ping @team-@all", "@all", ""},
+		{"ping @pagerduty-legacy-api.", "@pagerduty-legacy*", "@pagerduty-legacy-api"},
+		{"ping @pagerduty-new", "@pagerduty-legacy*", ""},
+		{"ping @all", "", ""},
+	}
+	for _, tt := range tests {
+		if got := findTarget(tt.message, tt.target); got != tt.want {
+			t.Errorf("findTarget(%q, %q) = %q, want %q", tt.message, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+	p, err := Load(filepath.Join("testdata", "policy.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	result, err := Lint(filepath.Join("testdata", "data"), p)
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	if len(result.Files) != 4 {
+		t.Errorf("Lint() checked %d files, want the 4 monitors", len(result.Files))
+	}
+
+	var got []string
+	for _, v := range result.Violations {
+		got = append(got, v.ID+" "+v.Rule+": "+v.Message)
+	}
+	want := []string{
+		"2 priority: priority 5 is not allowed (allowed: 1, 2, 3)",
+		"2 forbidden-target: message notifies @all",
+		"2 forbidden-target: message notifies @pagerduty-legacy-api",
+		"2 notify-no-data: notify_no_data is not enabled",
+		`3 required-tag: missing tag "team"`,
+		"3 priority: priority is not set",
+		`3 message-pattern: message does not match "@"`,
+		"4 email-target: message notifies the email address @jane.doe@example.com",
+		"4 renotify-interval: renotify_interval 2880 is not within 10-1440 minutes",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Lint() violations =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestDisable(t *testing.T) {
+	p, err := Parse([]byte(Starter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Disable(RulePriority, RuleRequiredTag); err != nil {
+		t.Fatalf("Disable() error = %v", err)
+	}
+	got := p.Check(map[string]any{"message": "@jane@example.com"})
+	var rules []string
+	for _, v := range got {
+		rules = append(rules, v.Rule)
+	}
+	if want := []string{RuleEmailTarget}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("Check() with priority and required-tag disabled = %+v, want only %v", got, want)
+	}
+
+	if err := p.Disable("priorty"); err == nil {
+		t.Error("Disable(priorty) expected an error")
+	}
+}
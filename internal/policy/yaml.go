@@ -0,0 +1,296 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseYAML decodes the subset of YAML a policy file needs: block mappings
+// and sequences, flow sequences ([a, b]), quoted and plain scalars, and
+// comments. Values come back in the shapes encoding/json produces, so they
+// can be re-encoded and decoded into a struct.
+func parseYAML(data []byte) (any, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(string(data), "\n") {
+		text := strings.TrimRight(stripComment(raw), " \t\r")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		lines = append(lines, yamlLine{number: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+
+	p := &yamlParser{lines: lines}
+	value, err := p.block(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].number)
+	}
+	return value, nil
+}
+
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// block parses the mapping or sequence starting at the current line.
+func (p *yamlParser) block(indent int) (any, error) {
+	if isSequenceItem(p.lines[p.pos].text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) sequence(indent int) (any, error) {
+	items := []any{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent || !isSequenceItem(line.text) {
+			break
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if rest == "" {
+			p.pos++
+			value, err := p.nested(indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+			continue
+		}
+		if _, _, ok := splitKey(rest); ok || isSequenceItem(rest) {
+			// "- key: value" starts a mapping (or "- - x" a sequence) indented
+			// to where its first entry begins
+			child := indent + len(line.text) - len(rest)
+			p.lines[p.pos] = yamlLine{number: line.number, indent: child, text: rest}
+			value, err := p.block(child)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+			continue
+		}
+		value, err := parseScalar(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.number, err)
+		}
+		items = append(items, value)
+		p.pos++
+	}
+	return items, nil
+}
+
+func (p *yamlParser) mapping(indent int) (any, error) {
+	m := map[string]any{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.number)
+		}
+		if isSequenceItem(line.text) {
+			break
+		}
+		key, rest, ok := splitKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", line.number)
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.number, key)
+		}
+		p.pos++
+		if rest == "" {
+			value, err := p.nested(indent)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = value
+			continue
+		}
+		value, err := parseScalar(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.number, err)
+		}
+		m[key] = value
+	}
+	return m, nil
+}
+
+// nested parses the value of a key or item with nothing after it: a block
+// indented further, a sequence at the same indent as a mapping key, or null.
+func (p *yamlParser) nested(indent int) (any, error) {
+	if p.pos >= len(p.lines) {
+		return nil, nil
+	}
+	next := p.lines[p.pos]
+	if next.indent > indent || (next.indent == indent && isSequenceItem(next.text)) {
+		return p.block(next.indent)
+	}
+	return nil, nil
+}
+
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitKey splits "key: value" into its key and value. Keys may be quoted.
+func splitKey(text string) (key, rest string, ok bool) {
+	if text[0] == '"' || text[0] == '\'' {
+		end := closingQuote(text)
+		if end < 0 || end+1 >= len(text) || text[end+1] != ':' {
+			return "", "", false
+		}
+		k, err := parseScalar(text[:end+1])
+		if err != nil {
+			return "", "", false
+		}
+		return k.(string), strings.TrimSpace(text[end+2:]), true
+	}
+	if text[0] == '[' || text[0] == '{' {
+		return "", "", false
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// closingQuote returns the index of the quote closing the string that
+// starts text, or -1.
+func closingQuote(text string) int {
+	q := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case q == '"' && text[i] == '\\':
+			i++
+		case q == '\'' && text[i] == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == q:
+			return i
+		}
+	}
+	return -1
+}
+
+// stripComment removes a "#" comment that isn't inside quotes.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if quote == '"' && c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseScalar(text string) (any, error) {
+	switch text[0] {
+	case '"':
+		if closingQuote(text) != len(text)-1 {
+			return nil, fmt.Errorf("unterminated or trailing text after string %s", text)
+		}
+		var s string
+		if err := json.Unmarshal([]byte(text), &s); err != nil {
+			return nil, fmt.Errorf("invalid string %s: %w", text, err)
+		}
+		return s, nil
+	case '\'':
+		if closingQuote(text) != len(text)-1 {
+			return nil, fmt.Errorf("unterminated or trailing text after string %s", text)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	case '[':
+		return parseFlowSequence(text)
+	case '{', '|', '>', '&', '*', '!':
+		return nil, fmt.Errorf("unsupported YAML syntax %q", text)
+	}
+
+	switch text {
+	case "null", "~":
+		return nil, nil
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return float64(n), nil
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return f, nil
+	}
+	return text, nil
+}
+
+// parseFlowSequence parses "[a, 'b', 3]". Nested collections aren't
+// supported.
+func parseFlowSequence(text string) (any, error) {
+	if !strings.HasSuffix(text, "]") {
+		return nil, fmt.Errorf("unterminated sequence %s", text)
+	}
+	inner := strings.TrimSpace(text[1 : len(text)-1])
+	items := []any{}
+	for inner != "" {
+		var item string
+		if inner[0] == '"' || inner[0] == '\'' {
+			end := closingQuote(inner)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string in %s", text)
+			}
+			item, inner = inner[:end+1], strings.TrimSpace(inner[end+1:])
+			if inner != "" && inner[0] != ',' {
+				return nil, fmt.Errorf("expected \",\" after %s in %s", item, text)
+			}
+		} else {
+			end := strings.IndexByte(inner, ',')
+			if end < 0 {
+				end = len(inner)
+			}
+			item, inner = strings.TrimSpace(inner[:end]), inner[end:]
+			if item == "" {
+				return nil, fmt.Errorf("empty item in %s", text)
+			}
+		}
+		inner = strings.TrimSpace(strings.TrimPrefix(inner, ","))
+		value, err := parseScalar(item)
+		if err != nil {
+			return nil, err
+		}
+		if _, nested := value.([]any); nested {
+			return nil, fmt.Errorf("nested sequences are not supported in %s", text)
+		}
+		items = append(items, value)
+	}
+	return items, nil
+}
@@ -0,0 +1,75 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	data := `# comment
+name: value # trailing comment
+quoted: "a # not a comment: \"x\""
+single: 'it''s'
+number: 3
+float: 1.5
+yes: true
+nothing: ~
+flow: [1, "two", 'three', four]
+empty: []
+list:
+  - a
+  - b
+same-indent:
+- c
+objects:
+  - pattern: "@"
+    description: mention
+  - pattern: x
+nested:
+  inner:
+    deep: 1
+"quoted key": 2
+`
+	got, err := parseYAML([]byte(data))
+	if err != nil {
+		t.Fatalf("parseYAML() error = %v", err)
+	}
+	want := map[string]any{
+		"name":        "value",
+		"quoted":      `a # not a comment: "x"`,
+		"single":      "it's",
+		"number":      float64(3),
+		"float":       1.5,
+		"yes":         true,
+		"nothing":     nil,
+		"flow":        []any{float64(1), "two", "three", "four"},
+		"empty":       []any{},
+		"list":        []any{"a", "b"},
+		"same-indent": []any{"c"},
+		"objects": []any{
+			map[string]any{"pattern": "@", "description": "mention"},
+			map[string]any{"pattern": "x"},
+		},
+		"nested":     map[string]any{"inner": map[string]any{"deep": float64(1)}},
+		"quoted key": float64(2),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseYAML() =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestParseYAML_Errors(t *testing.T) {
+	for _, data := range []string{
+		"a: 1\n  b: 2",
+		"a: 1\na: 2",
+		"a: \"unterminated",
+		"a: [1, 2",
+		"a: {b: 1}",
+		"just text",
+		"a: |\n  block",
+	} {
+		if _, err := parseYAML([]byte(data)); err == nil {
+			t.Errorf("parseYAML(%q) expected an error", data)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+// Package references finds the IDs that downloaded resources use to refer to
+// other resources (monitors in alert widgets, SLOs in SLO widgets, …) and
+// checks that the referenced resources exist.
+package references
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+// KindSLO is the kind of SLO references. dd-tf doesn't download SLOs, so
+// they can only be checked remotely.
+const KindSLO = "slo"
+
+// Reference statuses.
+const (
+	StatusMissingLocal = "missing-local" // not in the local files; not checked remotely, or exists remotely
+	StatusMissing      = "missing"       // not in the local files, nor remotely
+	StatusUnchecked    = "unchecked"     // no local files of this kind to check against
+)
+
+// Reference is an ID in one resource referring to another resource.
+type Reference struct {
+	Kind   string `json:"kind"`  // kind of the referenced resource
+	ID     string `json:"id"`    // ID of the referenced resource
+	Field  string `json:"field"` // where the ID was found, e.g. "widgets[0].definition.alert_id"
+	Status string `json:"status,omitempty"`
+}
+
+// widgetFields maps widget types to definition fields holding the ID of
+// another resource, and that resource's kind.
+var widgetFields = map[string]map[string]string{
+	"alert_graph": {"alert_id": resource.KindMonitor},
+	"alert_value": {"alert_id": resource.KindMonitor},
+	"slo":         {"slo_id": KindSLO},
+}
+
+var (
+	// linkPatterns find resource IDs in custom link URLs.
+	linkPatterns = []struct {
+		kind string
+		re   *regexp.Regexp
+	}{
+		{resource.KindDashboard, regexp.MustCompile(`/dashboard/([a-z0-9]+-[a-z0-9]+-[a-z0-9]+)`)},
+		{resource.KindMonitor, regexp.MustCompile(`/monitors/(\d+)`)},
+		{KindSLO, regexp.MustCompile(`/slo[?/].*\bslo_id=([a-f0-9]+)`)},
+	}
+
+	// compositeIDRegex matches monitor IDs in a composite monitor query.
+	compositeIDRegex = regexp.MustCompile(`\b\d+\b`)
+
+	// sloQueryRegex matches the SLO ID in an SLO alert query, e.g.
+	// error_budget("abc123").over("7d") > 10.
+	sloQueryRegex = regexp.MustCompile(`(?:error_budget|burn_rate)\("([a-zA-Z0-9]+)"\)`)
+)
+
+// Extract returns the references in a decoded resource of the given kind, in
+// document order.
+func Extract(kind string, content map[string]any) []Reference {
+	var refs []Reference
+	switch kind {
+	case resource.KindDashboard:
+		widgets, _ := content["widgets"].([]any)
+		refs = extractWidgets(widgets, "widgets", refs)
+	case resource.KindMonitor:
+		query, _ := content["query"].(string)
+		switch content["type"] {
+		case "composite":
+			for _, id := range compositeIDRegex.FindAllString(query, -1) {
+				refs = append(refs, Reference{Kind: resource.KindMonitor, ID: id, Field: "query"})
+			}
+		case "slo alert":
+			for _, m := range sloQueryRegex.FindAllStringSubmatch(query, -1) {
+				refs = append(refs, Reference{Kind: KindSLO, ID: m[1], Field: "query"})
+			}
+		}
+	}
+	return refs
+}
+
+// extractWidgets appends the references in a widgets array, recursing into
+// group and powerpack widgets.
+func extractWidgets(widgets []any, path string, refs []Reference) []Reference {
+	for i, w := range widgets {
+		widget, _ := w.(map[string]any)
+		def, _ := widget["definition"].(map[string]any)
+		if def == nil {
+			continue
+		}
+		defPath := fmt.Sprintf("%s[%d].definition", path, i)
+		typ, _ := def["type"].(string)
+
+		fields := widgetFields[typ]
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if id := idString(def[name]); id != "" {
+				refs = append(refs, Reference{Kind: fields[name], ID: id, Field: defPath + "." + name})
+			}
+		}
+
+		links, _ := def["custom_links"].([]any)
+		for j, l := range links {
+			link, _ := l.(map[string]any)
+			url, _ := link["link"].(string)
+			for _, p := range linkPatterns {
+				for _, m := range p.re.FindAllStringSubmatch(url, -1) {
+					refs = append(refs, Reference{Kind: p.kind, ID: m[1], Field: fmt.Sprintf("%s.custom_links[%d].link", defPath, j)})
+				}
+			}
+		}
+
+		if nested, ok := def["widgets"].([]any); ok {
+			refs = extractWidgets(nested, defPath+".widgets", refs)
+		}
+	}
+	return refs
+}
+
+// idString formats a decoded ID; alert_id is a string, but may be numeric in
+// hand-written files.
+func idString(v any) string {
+	switch id := v.(type) {
+	case string:
+		return id
+	case float64:
+		return strconv.FormatInt(int64(id), 10)
+	}
+	return ""
+}
+
+// Options configures Run.
+type Options struct {
+	// Remote, when set, is asked about references missing locally. It
+	// reports whether the resource exists.
+	Remote func(ctx context.Context, kind, id string) (bool, error)
+}
+
+// Result maps each referencing file to its references to resources that
+// don't exist locally.
+type Result map[string][]Reference
+
+// Run extracts the references from every resource file under dir and
+// returns those to resources that aren't in dir.
+func Run(ctx context.Context, dir string, opts Options) (Result, error) {
+	files := storage.FileBackend{}
+	paths, err := files.List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	local := map[string]map[string]bool{} // kind -> id -> exists
+	found := map[string][]Reference{}
+	var order []string
+	for _, path := range paths {
+		if !strings.HasSuffix(path, ".json") || storage.IsSidecarPath(path) {
+			continue
+		}
+		data, err := files.Read(path)
+		if err != nil {
+			return nil, err
+		}
+		var content map[string]any
+		if err := json.Unmarshal(data, &content); err != nil {
+			continue // verify reports invalid files
+		}
+		kind := resource.GuessKind(content)
+		if kind == "" {
+			continue
+		}
+		if local[kind] == nil {
+			local[kind] = map[string]bool{}
+		}
+		local[kind][idString(content["id"])] = true
+		if refs := Extract(kind, content); len(refs) > 0 {
+			found[path] = refs
+			order = append(order, path)
+		}
+	}
+
+	remote := map[string]bool{} // kind:id -> exists, so each is fetched once
+	result := Result{}
+	for _, path := range order {
+		for _, ref := range found[path] {
+			ids, checked := local[ref.Kind]
+			if ids[ref.ID] {
+				continue
+			}
+			ref.Status = StatusMissingLocal
+			if !checked {
+				ref.Status = StatusUnchecked
+			}
+			if opts.Remote != nil {
+				key := ref.Kind + ":" + ref.ID
+				exists, ok := remote[key]
+				if !ok {
+					exists, err = opts.Remote(ctx, ref.Kind, ref.ID)
+					if err != nil {
+						return nil, fmt.Errorf("failed to check %s %s: %w", ref.Kind, ref.ID, err)
+					}
+					remote[key] = exists
+				}
+				if exists {
+					ref.Status = StatusMissingLocal
+				} else {
+					ref.Status = StatusMissing
+				}
+			}
+			result[path] = append(result[path], ref)
+		}
+	}
+	return result, nil
+}
+
+// apiPaths are the API endpoints for a single resource of each kind.
+var apiPaths = map[string]string{
+	resource.KindDashboard: "/api/v1/dashboard/",
+	resource.KindMonitor:   "/api/v1/monitor/",
+	KindSLO:                "/api/v1/slo/",
+}
+
+// RemoteChecker returns an Options.Remote that looks resources up with the
+// API. A 404 means the resource doesn't exist.
+func RemoteChecker(client resource.HTTPClient, settings *config.Settings) func(ctx context.Context, kind, id string) (bool, error) {
+	return func(ctx context.Context, kind, id string) (bool, error) {
+		path, ok := apiPaths[kind]
+		if !ok {
+			return false, fmt.Errorf("unknown kind %q", kind)
+		}
+		_, err := resource.FetchRawFromAPI(ctx, client, settings.APIBaseURL()+path+id, settings)
+		if errors.Is(err, resource.ErrNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+}
@@ -0,0 +1,143 @@
+package references
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+)
+
+var dataDir = filepath.Join("testdata", "data")
+
+func readFixture(t *testing.T, path string) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dataDir, path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content map[string]any
+	if err := json.Unmarshal(data, &content); err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+func TestExtract_Dashboard(t *testing.T) {
+	got := Extract("dashboard", readFixture(t, "dashboards/abc-def-ghi.json"))
+	want := []Reference{
+		{Kind: "monitor", ID: "100", Field: "widgets[0].definition.alert_id"},
+		{Kind: "monitor", ID: "200", Field: "widgets[1].definition.alert_id"},
+		{Kind: "slo", ID: "abc123", Field: "widgets[2].definition.widgets[0].definition.slo_id"},
+		{Kind: "dashboard", ID: "xyz-uvw-rst", Field: "widgets[2].definition.widgets[1].definition.custom_links[0].link"},
+		{Kind: "monitor", ID: "100", Field: "widgets[2].definition.widgets[1].definition.custom_links[1].link"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Extract() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestExtract_Monitors(t *testing.T) {
+	tests := []struct {
+		file string
+		want []Reference
+	}{
+		{"monitors/100.json", nil},
+		{"monitors/101.json", []Reference{{Kind: "monitor", ID: "100", Field: "query"}, {Kind: "monitor", ID: "300", Field: "query"}}},
+		{"monitors/102.json", []Reference{{Kind: "slo", ID: "abc123", Field: "query"}}},
+	}
+	for _, tt := range tests {
+		if got := Extract("monitor", readFixture(t, tt.file)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Extract(%s) = %+v, want %+v", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	result, err := Run(context.Background(), dataDir, Options{})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	dashboard := filepath.Join(dataDir, "dashboards", "abc-def-ghi.json")
+	want := Result{
+		dashboard: {
+			{Kind: "monitor", ID: "200", Field: "widgets[1].definition.alert_id", Status: StatusMissingLocal},
+			{Kind: "slo", ID: "abc123", Field: "widgets[2].definition.widgets[0].definition.slo_id", Status: StatusUnchecked},
+			{Kind: "dashboard", ID: "xyz-uvw-rst", Field: "widgets[2].definition.widgets[1].definition.custom_links[0].link", Status: StatusMissingLocal},
+		},
+		filepath.Join(dataDir, "monitors", "101.json"): {
+			{Kind: "monitor", ID: "300", Field: "query", Status: StatusMissingLocal},
+		},
+		filepath.Join(dataDir, "monitors", "102.json"): {
+			{Kind: "slo", ID: "abc123", Field: "query", Status: StatusUnchecked},
+		},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Run() =\n%+v\nwant\n%+v", result, want)
+	}
+}
+
+func TestRun_CheckRemote(t *testing.T) {
+	var calls []string
+	remote := func(_ context.Context, kind, id string) (bool, error) {
+		calls = append(calls, kind+":"+id)
+		return id == "abc123", nil
+	}
+	result, err := Run(context.Background(), dataDir, Options{Remote: remote})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	statuses := map[string]string{}
+	for _, refs := range result {
+		for _, ref := range refs {
+			statuses[ref.Kind+":"+ref.ID] = ref.Status
+		}
+	}
+	want := map[string]string{
+		"monitor:200":           StatusMissing,
+		"monitor:300":           StatusMissing,
+		"slo:abc123":            StatusMissingLocal,
+		"dashboard:xyz-uvw-rst": StatusMissing,
+	}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("statuses = %v, want %v", statuses, want)
+	}
+	if len(calls) != 4 {
+		t.Errorf("remote calls = %v, want each reference looked up once", calls)
+	}
+
+	failing := func(context.Context, string, string) (bool, error) { return false, errors.New("boom") }
+	if _, err := Run(context.Background(), dataDir, Options{Remote: failing}); err == nil {
+		t.Error("Run() expected error when the remote check fails")
+	}
+}
+
+func TestRemoteChecker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/slo/abc123" {
+			w.Write([]byte(`{"data":{"id":"abc123"}}`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	settings := &config.Settings{Site: server.URL, HTTPMaxBodySize: 1024}
+	check := RemoteChecker(internalhttp.NewClient(&config.Settings{APIKey: "k", AppKey: "a"}), settings)
+
+	if exists, err := check(context.Background(), KindSLO, "abc123"); err != nil || !exists {
+		t.Errorf("check(slo abc123) = %v, %v, want true", exists, err)
+	}
+	if exists, err := check(context.Background(), "monitor", "200"); err != nil || exists {
+		t.Errorf("check(monitor 200) = %v, %v, want false", exists, err)
+	}
+	if _, err := check(context.Background(), "notebook", "1"); err == nil {
+		t.Error("check() expected error for an unknown kind")
+	}
+}
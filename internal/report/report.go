@@ -0,0 +1,286 @@
+// Package report renders the structured result of a check (verify, diff, …)
+// as JSON, JUnit XML, or SARIF.
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Output formats accepted by --report-format.
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatJUnit = "junit"
+	FormatSARIF = "sarif"
+)
+
+// Rules describes every rule id a report can contain. SARIF output includes
+// the description of each rule used.
+var Rules = map[string]string{
+	"invalid-json":     "File is not valid JSON",
+	"unknown-shape":    "File is not recognised as a Datadog resource",
+	"id-mismatch":      "Resource id does not match the file name",
+	"too-large":        "File exceeds the configured size limit",
+	"duplicate-id":     "Resource id is used by more than one file",
+	"secret":           "File contains something that looks like a credential",
+	"format":           "File is not formatted the way dd-tf writes it",
+	"schema":           "File does not match the JSON Schema for its kind",
+	"required-tag":     "Monitor is missing a tag the policy requires",
+	"priority":         "Monitor priority is not set or not allowed by the policy",
+	"message-pattern":  "Monitor message does not match a pattern the policy requires",
+	"forbidden-target": "Monitor message notifies a target the policy forbids",
+	"notify-no-data":   "Monitor must enable notify_no_data under the policy",
+	"drifted":          "Local file differs from the remote resource",
+	"missing-remote":   "Local file has no matching remote resource",
+	"missing-local":    "Remote resource has no local file",
+	"compare-failed":   "Resource could not be compared with the remote",
+}
+
+// Result is the structured outcome of a check.
+type Result struct {
+	Command string `json:"command"`
+	Version string `json:"version,omitempty"`
+	Items   []Item `json:"items"`
+}
+
+// Item is one checked resource. It passed if it has no findings. Path is
+// empty for a remote resource without a local file.
+type Item struct {
+	Path     string    `json:"path"`
+	Kind     string    `json:"kind,omitempty"`
+	ID       string    `json:"id,omitempty"`
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// Finding is a single problem with an item.
+type Finding struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// name is how an item is shown: its path, or its ID without one.
+func (i Item) name() string {
+	if i.Path == "" {
+		return i.ID
+	}
+	return i.Path
+}
+
+// Failures returns the number of items with findings.
+func (r *Result) Failures() int {
+	n := 0
+	for _, item := range r.Items {
+		if len(item.Findings) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// Write renders r to w in the given format.
+func Write(w io.Writer, format string, r *Result) error {
+	switch format {
+	case "", FormatText:
+		return writeText(w, r)
+	case FormatJSON:
+		return writeJSON(w, r)
+	case FormatJUnit:
+		return writeJUnit(w, r)
+	case FormatSARIF:
+		return writeSARIF(w, r)
+	default:
+		return fmt.Errorf("unknown report format %q (expected text, json, junit or sarif)", format)
+	}
+}
+
+func writeText(w io.Writer, r *Result) error {
+	for _, item := range r.Items {
+		for _, f := range item.Findings {
+			if _, err := fmt.Fprintf(w, "%s: [%s] %s\n", item.name(), f.Rule, f.Message); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, r *Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Type    string `xml:"type,attr"`
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// writeJUnit renders each item as a test case; findings become its failure.
+func writeJUnit(w io.Writer, r *Result) error {
+	name := "dd-tf " + r.Command
+	suite := junitSuite{Name: name, Tests: len(r.Items), Failures: r.Failures()}
+	for _, item := range r.Items {
+		kind := item.Kind
+		if kind == "" {
+			kind = "file"
+		}
+		tc := junitCase{ClassName: kind, Name: item.name()}
+		if len(item.Findings) > 0 {
+			var lines []string
+			for _, f := range item.Findings {
+				lines = append(lines, fmt.Sprintf("[%s] %s", f.Rule, f.Message))
+			}
+			message := item.Findings[0].Message
+			if len(item.Findings) > 1 {
+				message = fmt.Sprintf("%s (and %d more)", message, len(item.Findings)-1)
+			}
+			tc.Failure = &junitFailure{Type: item.Findings[0].Rule, Message: message, Body: strings.Join(lines, "\n")}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	doc := junitSuites{Name: name, Tests: suite.Tests, Failures: suite.Failures, Suites: []junitSuite{suite}}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// writeSARIF renders each finding as a SARIF result located at the item's
+// file, if it has one, with one rule per finding category.
+func writeSARIF(w io.Writer, r *Result) error {
+	used := map[string]bool{}
+	results := []sarifResult{}
+	for _, item := range r.Items {
+		for _, f := range item.Findings {
+			used[f.Rule] = true
+			result := sarifResult{RuleID: f.Rule, Level: "error", Message: sarifMessage{Text: f.Message}}
+			if item.Path != "" {
+				result.Locations = []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: toURI(item.Path)},
+				}}}
+			}
+			results = append(results, result)
+		}
+	}
+
+	ids := make([]string, 0, len(used))
+	for id := range used {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	rules := make([]sarifRule, 0, len(ids))
+	for _, id := range ids {
+		desc := Rules[id]
+		if desc == "" {
+			desc = id
+		}
+		rules = append(rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: desc}})
+	}
+
+	doc := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "dd-tf",
+				Version:        r.Version,
+				InformationURI: "https://github.com/AD7six/dd-tf",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// toURI converts a local path to the relative, slash-separated form SARIF
+// viewers resolve against the repository root.
+func toURI(path string) string {
+	return strings.TrimPrefix(strings.ReplaceAll(path, "\\", "/"), "./")
+}
@@ -0,0 +1,62 @@
+package report
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func sampleResult() *Result {
+	return &Result{
+		Command: "verify",
+		Version: "1.2.3",
+		Items: []Item{
+			{Path: "data/dashboards/abc-def-ghi.json", Kind: "dashboard", ID: "abc-def-ghi"},
+			{Path: "data/dashboards/xyz-uvw-rst.json", Kind: "dashboard", ID: "xyz-uvw-rst", Findings: []Finding{
+				{Rule: "drifted", Message: "Dashboard xyz-uvw-rst drifted from remote: 2 widgets changed"},
+			}},
+			{Path: "data/monitors/12.json", Kind: "monitor", ID: "123", Findings: []Finding{
+				{Rule: "id-mismatch", Message: `monitor id "123" does not match the file name`},
+				{Rule: "format", Message: "not formatted like a downloaded file <run with --fix>"},
+			}},
+			{Kind: "monitor", ID: "456", Findings: []Finding{
+				{Rule: "missing-local", Message: "remote monitor 456 has no local file"},
+			}},
+		},
+	}
+}
+
+func TestWrite_Golden(t *testing.T) {
+	for _, format := range []string{FormatText, FormatJSON, FormatJUnit, FormatSARIF} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, format, sampleResult()); err != nil {
+				t.Fatalf("Write(%s) error = %v", format, err)
+			}
+
+			golden := filepath.Join("testdata", "report."+format+".golden")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("missing golden file (run with -update): %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("Write(%s) =\n%s\nwant\n%s", format, buf.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, "html", sampleResult()); err == nil {
+		t.Error("Write() expected error for unknown format")
+	}
+}
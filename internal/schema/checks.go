@@ -0,0 +1,50 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Check is a structural check of a decoded resource that a schema can't
+// express, such as how its id is formed.
+type Check func(content map[string]any) []Violation
+
+// CheckID returns a Check reporting an id that valid rejects. A missing id is
+// fine: push creates the resource.
+func CheckID(valid func(id any) error) Check {
+	return func(content map[string]any) []Violation {
+		id, ok := content["id"]
+		if !ok {
+			return nil
+		}
+		if err := valid(id); err != nil {
+			return []Violation{{Pointer: "/id", Message: err.Error(), Rule: "id"}}
+		}
+		return nil
+	}
+}
+
+// CheckTags reports tags that aren't "key:value" strings with a key.
+func CheckTags(content map[string]any) []Violation {
+	tags, _ := content["tags"].([]any)
+	var violations []Violation
+	for i, tag := range tags {
+		s, _ := tag.(string)
+		if key, _, ok := strings.Cut(s, ":"); !ok || key == "" {
+			violations = append(violations, Violation{
+				Pointer: fmt.Sprintf("/tags/%d", i),
+				Message: fmt.Sprintf("tag %v is not key:value", tagString(tag)),
+				Rule:    "tags",
+			})
+		}
+	}
+	return violations
+}
+
+// tagString quotes a tag for a message, whatever its type.
+func tagString(tag any) string {
+	if s, ok := tag.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%v", tag)
+}
@@ -0,0 +1,130 @@
+package schema
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/templating"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+//go:embed schemas/*.json
+var embedded embed.FS
+
+// Load returns the schema for a resource kind: <dir>/<kind>.json if dir is
+// set and has one (SCHEMA_DIR), otherwise the embedded schema.
+func Load(kind, dir string) (*Schema, error) {
+	name := kind + ".json"
+	if dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			s, err := Parse(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", filepath.Join(dir, name), err)
+			}
+			return s, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	data, err := embedded.ReadFile("schemas/" + name)
+	if err != nil {
+		return nil, fmt.Errorf("no schema for %s", kind)
+	}
+	return Parse(data)
+}
+
+// File is a validated resource file.
+type File struct {
+	Path       string
+	Kind       string // resource kind, empty if not recognised
+	ID         string
+	Invalid    error       // set if the file isn't valid JSON
+	Violations []Violation // schema violations, including unknown properties
+}
+
+// Options configures Run.
+type Options struct {
+	Schemas       map[string]*Schema // resource kind -> schema
+	PathTemplates map[string]string  // resource kind -> path template, to recognise files too broken to guess
+	Checks        map[string][]Check // resource kind -> structural checks run after the schema
+}
+
+// Run validates every .json file under dir against the schema for its kind,
+// then runs its kind's checks, reporting every problem found. Files are in
+// path order.
+func Run(dir string, opts Options) ([]File, error) {
+	files := storage.FileBackend{}
+	paths, err := files.List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []File
+	for _, path := range paths {
+		if !strings.HasSuffix(path, ".json") || storage.IsSidecarPath(path) {
+			continue
+		}
+		data, err := files.Read(path)
+		if err != nil {
+			return nil, err
+		}
+		file := File{Path: path}
+		var content any
+		if err := json.Unmarshal(data, &content); err != nil {
+			file.Invalid = err
+			result = append(result, file)
+			continue
+		}
+		object, _ := content.(map[string]any)
+		file.Kind = kindOf(path, object, opts.PathTemplates)
+		if s := opts.Schemas[file.Kind]; s != nil {
+			file.ID = idString(object["id"])
+			file.Violations = s.Validate(content)
+		}
+		if object != nil {
+			for _, check := range opts.Checks[file.Kind] {
+				file.Violations = append(file.Violations, check(object)...)
+			}
+		}
+		result = append(result, file)
+	}
+	return result, nil
+}
+
+// kindOf guesses a file's kind from its content, falling back to the
+// directory its kind's path template writes to: a hand-edited file missing
+// a required field may not look like anything.
+func kindOf(path string, content map[string]any, templates map[string]string) string {
+	if kind := resource.GuessKind(content); kind != "" {
+		return kind
+	}
+	clean := filepath.Clean(path)
+	for kind, template := range templates {
+		prefix := templating.ExtractStaticPrefix(template)
+		if prefix != "" && strings.HasPrefix(clean, filepath.Clean(prefix)+string(filepath.Separator)) {
+			return kind
+		}
+	}
+	return ""
+}
+
+// idString formats a decoded id the way it appears in paths.
+func idString(v any) string {
+	switch id := v.(type) {
+	case string:
+		return id
+	case float64:
+		return strconv.FormatInt(int64(id), 10)
+	}
+	return ""
+}
@@ -0,0 +1,250 @@
+// Package schema validates downloaded resources against JSON Schemas. It
+// implements the subset of JSON Schema the embedded schemas use: type, enum,
+// required, properties, additionalProperties, items, minItems, minLength,
+// pattern and local $ref.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema is a parsed JSON Schema.
+type Schema struct {
+	Type                 Types              `json:"type"`
+	Enum                 []any              `json:"enum"`
+	Required             []string           `json:"required"`
+	Properties           map[string]*Schema `json:"properties"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	MinItems             *int               `json:"minItems"`
+	MinLength            *int               `json:"minLength"`
+	Pattern              string             `json:"pattern"`
+	Ref                  string             `json:"$ref"`
+	Defs                 map[string]*Schema `json:"$defs"`
+	Definitions          map[string]*Schema `json:"definitions"`
+
+	pattern *regexp.Regexp
+}
+
+// Types is a schema's "type", which may be a string or an array of strings.
+type Types []string
+
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = Types{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings")
+	}
+	*t = many
+	return nil
+}
+
+// Violation is a place where a value doesn't match its schema.
+type Violation struct {
+	Pointer string `json:"pointer"` // JSON pointer to the value, "" for the root
+	Message string `json:"message"`
+	Unknown bool   `json:"unknown,omitempty"` // a property the schema doesn't list; only a warning
+	Rule    string `json:"rule,omitempty"`    // the Check that found it, e.g. "tags"; empty for the schema
+}
+
+func (v Violation) String() string {
+	pointer := v.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return pointer + ": " + v.Message
+}
+
+// Parse parses a schema, compiling its patterns and checking its references.
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := s.compile(&s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *Schema) compile(root *Schema) error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	if s.Ref != "" {
+		if _, err := root.resolve(s.Ref); err != nil {
+			return err
+		}
+	}
+	children := []*Schema{s.Items}
+	for _, group := range []map[string]*Schema{s.Properties, s.Defs, s.Definitions} {
+		for _, child := range group {
+			children = append(children, child)
+		}
+	}
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+		if err := child.compile(root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve finds a local reference such as "#/$defs/widget".
+func (s *Schema) resolve(ref string) (*Schema, error) {
+	var defs map[string]*Schema
+	var name string
+	switch {
+	case strings.HasPrefix(ref, "#/$defs/"):
+		defs, name = s.Defs, strings.TrimPrefix(ref, "#/$defs/")
+	case strings.HasPrefix(ref, "#/definitions/"):
+		defs, name = s.Definitions, strings.TrimPrefix(ref, "#/definitions/")
+	default:
+		return nil, fmt.Errorf("unsupported $ref %q (only #/$defs/ and #/definitions/ are supported)", ref)
+	}
+	if def, ok := defs[name]; ok {
+		return def, nil
+	}
+	return nil, fmt.Errorf("$ref %q not found", ref)
+}
+
+// Validate checks a decoded JSON value against the schema. Violations are in
+// document order, with object properties sorted by name.
+func (s *Schema) Validate(value any) []Violation {
+	v := validator{root: s}
+	v.validate(s, value, "")
+	return v.violations
+}
+
+type validator struct {
+	root       *Schema
+	violations []Violation
+}
+
+func (v *validator) add(pointer string, unknown bool, format string, args ...any) {
+	v.violations = append(v.violations, Violation{Pointer: pointer, Message: fmt.Sprintf(format, args...), Unknown: unknown})
+}
+
+func (v *validator) validate(s *Schema, value any, pointer string) {
+	if s.Ref != "" {
+		s, _ = v.root.resolve(s.Ref) // checked by Parse
+	}
+	if len(s.Type) > 0 && !matchesType(s.Type, value) {
+		v.add(pointer, false, "expected %s, got %s", strings.Join(s.Type, " or "), typeOf(value))
+		return
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		v.add(pointer, false, "must be one of %s", formatEnum(s.Enum))
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := value[name]; !ok {
+				v.add(pointer, false, "missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			child := pointer + "/" + escape(name)
+			if prop, ok := s.Properties[name]; ok {
+				v.validate(prop, value[name], child)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				v.add(child, true, "unknown property %q", name)
+			}
+		}
+	case []any:
+		if s.MinItems != nil && len(value) < *s.MinItems {
+			v.add(pointer, false, "expected at least %d items, got %d", *s.MinItems, len(value))
+		}
+		if s.Items != nil {
+			for i, item := range value {
+				v.validate(s.Items, item, fmt.Sprintf("%s/%d", pointer, i))
+			}
+		}
+	case string:
+		if s.MinLength != nil && len([]rune(value)) < *s.MinLength {
+			v.add(pointer, false, "expected at least %d characters", *s.MinLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(value) {
+			v.add(pointer, false, "does not match %q", s.Pattern)
+		}
+	}
+}
+
+func matchesType(types []string, value any) bool {
+	actual := typeOf(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf returns the JSON Schema type of a decoded value.
+func typeOf(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if value == float64(int64(value)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// inEnum compares encoded values, as decoded objects and arrays can't be
+// compared with ==.
+func inEnum(enum []any, value any) bool {
+	want, _ := json.Marshal(value)
+	for _, e := range enum {
+		if got, _ := json.Marshal(e); string(got) == string(want) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []any) string {
+	parts := make([]string, len(enum))
+	for i, e := range enum {
+		b, _ := json.Marshal(e)
+		parts[i] = string(b)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// escape escapes a property name for a JSON pointer (RFC 6901).
+func escape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
@@ -0,0 +1,214 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+)
+
+func mustParse(t *testing.T, data string) *Schema {
+	t.Helper()
+	s, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return s
+}
+
+func decode(t *testing.T, data string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestValidate(t *testing.T) {
+	s := mustParse(t, `{
+		"type": "object",
+		"required": ["name", "items"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 2, "pattern": "^[a-z]+$"},
+			"count": {"type": "integer"},
+			"ratio": {"type": "number"},
+			"kind": {"enum": ["a", "b"]},
+			"items": {"type": "array", "minItems": 1, "items": {"$ref": "#/$defs/item"}},
+			"a/b": {"type": "boolean"}
+		},
+		"$defs": {
+			"item": {"type": ["object", "null"], "required": ["id"]}
+		}
+	}`)
+
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"valid", `{"name": "ok", "count": 2, "ratio": 2, "kind": "a", "items": [{"id": 1}, null]}`, nil},
+		{"missing required", `{"name": "ok"}`, []string{`/: missing required property "items"`}},
+		{"wrong types", `{"name": 1, "count": 1.5, "items": [3], "a/b": "x"}`, []string{
+			"/a~1b: expected boolean, got string",
+			"/count: expected integer, got number",
+			"/items/0: expected object or null, got integer",
+			"/name: expected string, got integer",
+		}},
+		{"enum, length, pattern", `{"name": "A", "kind": "c", "items": []}`, []string{
+			"/items: expected at least 1 items, got 0",
+			`/kind: must be one of "a", "b"`,
+			"/name: expected at least 2 characters",
+			`/name: does not match "^[a-z]+$"`,
+		}},
+		{"nested required", `{"name": "ok", "items": [{}, {"id": 2}]}`, []string{`/items/0: missing required property "id"`}},
+		{"unknown property", `{"name": "ok", "items": [null], "extra": 1}`, []string{`/extra: unknown property "extra"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, v := range s.Validate(decode(t, tt.value)) {
+				got = append(got, v.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_UnknownIsWarning(t *testing.T) {
+	s := mustParse(t, `{"type": "object", "additionalProperties": false, "properties": {"a": {"type": "string"}}}`)
+	violations := s.Validate(decode(t, `{"a": 1, "b": 2}`))
+	if len(violations) != 2 || violations[0].Unknown || !violations[1].Unknown {
+		t.Errorf("Validate() = %+v, want a type error then an unknown property", violations)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, data := range []string{
+		`{"type": 1}`,
+		`{"pattern": "("}`,
+		`{"$ref": "#/$defs/missing"}`,
+		`{"$ref": "other.json"}`,
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Parse(%s) expected an error", data)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for _, kind := range []string{resource.KindDashboard, resource.KindMonitor} {
+		if _, err := Load(kind, ""); err != nil {
+			t.Errorf("Load(%q) embedded schema error = %v", kind, err)
+		}
+	}
+	if _, err := Load("widget", ""); err == nil {
+		t.Error("Load() expected an error for an unknown kind")
+	}
+
+	// The override replaces the monitor schema; dashboards keep the embedded one
+	dir := filepath.Join("testdata", "schemas")
+	monitor, err := Load(resource.KindMonitor, dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := monitor.Validate(decode(t, `{"name": "x", "type": "metric alert", "query": "q", "message": "", "priority": 3}`)); len(got) != 1 || got[0].Pointer != "/priority" {
+		t.Errorf("Validate() with overridden schema = %+v, want a priority violation", got)
+	}
+	if _, err := Load(resource.KindDashboard, dir); err != nil {
+		t.Errorf("Load() without an override error = %v", err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dashboard, err := Load(resource.KindDashboard, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	monitor, err := Load(resource.KindMonitor, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join("testdata", "data")
+	files, err := Run(dir, Options{
+		Schemas:       map[string]*Schema{resource.KindDashboard: dashboard, resource.KindMonitor: monitor},
+		PathTemplates: map[string]string{resource.KindDashboard: filepath.Join(dir, "dashboards", "{id}.json")},
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	got := map[string][]string{}
+	for _, f := range files {
+		name := filepath.Base(f.Path)
+		if f.Invalid != nil {
+			got[name] = []string{"invalid"}
+			continue
+		}
+		for _, v := range f.Violations {
+			got[name] = append(got[name], v.String())
+		}
+		if f.Kind == "" {
+			t.Errorf("%s: kind not recognised", f.Path)
+		}
+	}
+	want := map[string][]string{
+		"abc-def-ghi.json": {`/brand_new_field: unknown property "brand_new_field"`},
+		"invalid.json":     {"invalid"},
+		"nested-bad.json": {
+			`/widgets/0/definition/widgets/0/definition: missing required property "type"`,
+			"/widgets/0/definition/widgets/0/layout/y: expected integer, got string",
+		},
+		"xyz-uvw-rst.json": {
+			`/: missing required property "widgets"`,
+			`/reflow_type: must be one of "auto", "fixed"`,
+			`/widget: unknown property "widget"`,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Run() violations:\n%s\nwant:\n%s", format(got), format(want))
+	}
+}
+
+func format(m map[string][]string) string {
+	var b strings.Builder
+	for k, v := range m {
+		b.WriteString(k + ": " + strings.Join(v, "; ") + "\n")
+	}
+	return b.String()
+}
+
+func TestChecks(t *testing.T) {
+	content := decode(t, `{"id": -1, "tags": ["team:web", "prod", ":x", 3]}`).(map[string]any)
+	positive := func(id any) error {
+		if n, _ := id.(float64); n <= 0 {
+			return errors.New("not positive")
+		}
+		return nil
+	}
+
+	var got []string
+	for _, v := range append(CheckID(positive)(content), CheckTags(content)...) {
+		got = append(got, v.String())
+	}
+	want := []string{
+		"/id: not positive",
+		`/tags/1: tag "prod" is not key:value`,
+		`/tags/2: tag ":x" is not key:value`,
+		"/tags/3: tag 3 is not key:value",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("checks = %q, want %q", got, want)
+	}
+
+	if v := CheckID(positive)(map[string]any{"name": "new"}); v != nil {
+		t.Errorf("CheckID() without an id = %v, want none", v)
+	}
+}
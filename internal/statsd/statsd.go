@@ -0,0 +1,76 @@
+// Package statsd sends metrics to DogStatsD over UDP. Sending is best
+// effort: errors are logged at debug level and never returned, so metrics
+// can't affect a run.
+package statsd
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AD7six/dd-tf/internal/logging"
+)
+
+// Client sends metrics to one DogStatsD address. A nil *Client is valid and
+// sends nothing.
+type Client struct {
+	conn net.Conn
+	tags []string
+}
+
+// New returns a client sending to addr (host:port), adding tags ("key:value")
+// to every metric. An empty addr returns a nil client.
+func New(addr string, tags ...string) (*Client, error) {
+	if addr == "" {
+		return nil, nil
+	}
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, tags: tags}, nil
+}
+
+// Tag formats a key:value tag, replacing the characters the DogStatsD
+// format reserves.
+func Tag(key, value string) string {
+	return sanitize(key) + ":" + sanitize(value)
+}
+
+func sanitize(s string) string {
+	return strings.NewReplacer("|", "_", ",", "_", "#", "_", "\n", "_").Replace(s)
+}
+
+// Count sends a counter.
+func (c *Client) Count(name string, value int64, tags ...string) {
+	c.send(name, strconv.FormatInt(value, 10), "c", tags)
+}
+
+// Timing sends a duration in milliseconds.
+func (c *Client) Timing(name string, d time.Duration, tags ...string) {
+	c.send(name, strconv.FormatInt(d.Milliseconds(), 10), "ms", tags)
+}
+
+// Close releases the client's socket.
+func (c *Client) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// send writes one datagram: name:value|type|#tag,tag
+func (c *Client) send(name, value, kind string, tags []string) {
+	if c == nil {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(name + ":" + value + "|" + kind)
+	if all := append(append([]string(nil), c.tags...), tags...); len(all) > 0 {
+		b.WriteString("|#" + strings.Join(all, ","))
+	}
+	if _, err := c.conn.Write([]byte(b.String())); err != nil {
+		logging.Logger.Debug("failed to send metric", "metric", name, "error", err)
+	}
+}
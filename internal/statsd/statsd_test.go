@@ -0,0 +1,72 @@
+package statsd
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestClient_WireFormat(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	c, err := New(conn.LocalAddr().String(), Tag("site", "datadoghq.eu"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	c.Count("ddtf.downloads.success", 12, Tag("command", "download"), Tag("resource", "a|b,c"))
+	c.Timing("ddtf.run.duration", 1500*time.Millisecond)
+
+	want := []string{
+		"ddtf.downloads.success:12|c|#site:datadoghq.eu,command:download,resource:a_b_c",
+		"ddtf.run.duration:1500|ms|#site:datadoghq.eu",
+	}
+	buf := make([]byte, 1024)
+	for _, w := range want {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("ReadFrom() error = %v", err)
+		}
+		if got := string(buf[:n]); got != w {
+			t.Errorf("datagram = %q, want %q", got, w)
+		}
+	}
+}
+
+func TestClient_Nil(t *testing.T) {
+	c, err := New("")
+	if err != nil || c != nil {
+		t.Fatalf("New(\"\") = %v, %v, want a nil client", c, err)
+	}
+	// A nil client must be usable and silent
+	c.Count("ddtf.downloads.success", 1)
+	c.Timing("ddtf.run.duration", time.Second)
+	if err := c.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestClient_UnreachableDoesNotFail(t *testing.T) {
+	// Nothing listens on the port: writes may fail, but only get logged
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := conn.LocalAddr().String()
+	conn.Close()
+
+	c, err := New(addr)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+	for i := 0; i < 3; i++ {
+		c.Count("ddtf.downloads.failed", 1)
+	}
+}
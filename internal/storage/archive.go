@@ -0,0 +1,326 @@
+package storage
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AD7six/dd-tf/internal/config"
+)
+
+// ManifestName is the archive entry holding the run metadata.
+const ManifestName = "manifest.json"
+
+// archiveModTime is used for every archive entry so that archives of the same
+// data have the same checksum.
+var archiveModTime = time.Unix(0, 0).UTC()
+
+// ArchiveManifest describes the contents of an archive.
+type ArchiveManifest struct {
+	Command   string        `json:"command"`
+	Version   string        `json:"version,omitempty"`
+	Site      string        `json:"site,omitempty"`
+	FailedIDs []string      `json:"failed_ids,omitempty"`
+	Files     []ArchiveFile `json:"files"` // filled in by ArchiveBackend.Close
+}
+
+// ArchiveFile is a manifest entry for one archived resource.
+type ArchiveFile struct {
+	Path   string `json:"path"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ArchiveBackend collects writes into a single tar.gz instead of individual
+// files. Writes are spooled to a temporary directory (so memory use doesn't
+// grow with the number of resources) and the archive is assembled by Close
+// with sorted entries and fixed mtimes. Reads and listings go to base, so
+// --update still scans the normal storage.
+type ArchiveBackend struct {
+	path  string
+	base  Backend
+	spool string
+
+	mu    sync.Mutex
+	files map[string]bool
+}
+
+// NewArchiveBackend returns a backend that will write the archive to
+// archivePath when closed.
+func NewArchiveBackend(archivePath string, base Backend) (*ArchiveBackend, error) {
+	spool, err := os.MkdirTemp("", "dd-tf-archive-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+	return &ArchiveBackend{path: archivePath, base: base, spool: spool, files: map[string]bool{}}, nil
+}
+
+// StartArchive routes every write for the rest of the run into an archive at
+// archivePath (see Override). Close or Abort the returned backend to finish.
+func StartArchive(archivePath string, settings *config.Settings) (*ArchiveBackend, error) {
+	base, err := NewBackend(settings)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := NewArchiveBackend(archivePath, base)
+	if err != nil {
+		return nil, err
+	}
+	Override(archive)
+	return archive, nil
+}
+
+// ArchiveEntryName converts a computed resource path to an archive entry
+// name: slash-separated, relative, and without "..".
+func ArchiveEntryName(p string) (string, error) {
+	name := strings.TrimLeft(path.Clean(filepath.ToSlash(p)), "/")
+	if name == "" || name == "." || name == ".." || strings.HasPrefix(name, "../") {
+		return "", fmt.Errorf("invalid archive path %q", p)
+	}
+	return name, nil
+}
+
+// Write spools data for inclusion in the archive.
+func (a *ArchiveBackend) Write(p string, data []byte) error {
+	name, err := ArchiveEntryName(p)
+	if err != nil {
+		return err
+	}
+	if name == ManifestName {
+		return fmt.Errorf("%s is reserved for the archive manifest", ManifestName)
+	}
+	if err := (FileBackend{}).Write(filepath.Join(a.spool, filepath.FromSlash(name)), data); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.files[name] = true
+	a.mu.Unlock()
+	return nil
+}
+
+// Read reads from the base backend.
+func (a *ArchiveBackend) Read(p string) ([]byte, error) {
+	return a.base.Read(p)
+}
+
+// List lists the base backend.
+func (a *ArchiveBackend) List(prefix string) ([]string, error) {
+	return a.base.List(prefix)
+}
+
+// Remove drops a file written earlier in the run from the archive. The
+// base backend is only read, so its files can't be removed.
+func (a *ArchiveBackend) Remove(p string) error {
+	name, err := ArchiveEntryName(p)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	spooled := a.files[name]
+	delete(a.files, name)
+	a.mu.Unlock()
+	if !spooled {
+		if _, err := a.base.Read(p); errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("can't remove %s: archive runs don't change stored files", p)
+	}
+	return (FileBackend{}).Remove(filepath.Join(a.spool, filepath.FromSlash(name)))
+}
+
+// Close writes the archive, including manifest, atomically (temporary file
+// plus rename) and removes the spool directory.
+func (a *ArchiveBackend) Close(manifest ArchiveManifest) (err error) {
+	defer a.Abort()
+
+	a.mu.Lock()
+	names := make([]string, 0, len(a.files))
+	for name := range a.files {
+		names = append(names, name)
+	}
+	a.mu.Unlock()
+	sort.Strings(names)
+
+	if dir := filepath.Dir(a.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(a.path), ".dd-tf-archive-*.tar.gz")
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	// No name or timestamp in the gzip header, for reproducible output
+	gz := gzip.NewWriter(tmp)
+	tw := tar.NewWriter(gz)
+
+	manifest.Files = make([]ArchiveFile, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(a.spool, filepath.FromSlash(name)))
+		if err != nil {
+			return fmt.Errorf("failed to read spooled file: %w", err)
+		}
+		if err := writeTarEntry(tw, name, data); err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		manifest.Files = append(manifest.Files, ArchiveFile{Path: name, Size: len(data), SHA256: hex.EncodeToString(sum[:])})
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := writeTarEntry(tw, ManifestName, append(manifestData, '\n')); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), a.path); err != nil {
+		return fmt.Errorf("failed to move archive into place: %w", err)
+	}
+	return nil
+}
+
+// Abort discards spooled data without writing the archive. It is safe to
+// call after Close.
+func (a *ArchiveBackend) Abort() {
+	overrideMu.Lock()
+	if override == a {
+		override = nil
+	}
+	overrideMu.Unlock()
+	os.RemoveAll(a.spool)
+}
+
+func writeTarEntry(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{
+		Name:     name,
+		Mode:     0o644,
+		Size:     int64(len(data)),
+		ModTime:  archiveModTime,
+		Typeflag: tar.TypeReg,
+		Format:   tar.FormatPAX,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write archive entry %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write archive entry %s: %w", name, err)
+	}
+	return nil
+}
+
+// ReadArchive calls fn for every resource in a tar.gz written by
+// ArchiveBackend, in archive order. The manifest is returned separately.
+// Entries with unsafe names are rejected.
+func ReadArchive(archivePath string, fn func(name string, data []byte) error) (*ArchiveManifest, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("not a gzip archive: %w", err)
+	}
+	defer gz.Close()
+
+	var manifest *ArchiveManifest
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name, err := ArchiveEntryName(hdr.Name)
+		if err != nil || name != hdr.Name {
+			return nil, fmt.Errorf("unsafe archive entry %q", hdr.Name)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxJSONFileSize*10))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if name == ManifestName {
+			manifest = &ArchiveManifest{}
+			if err := json.Unmarshal(data, manifest); err != nil {
+				return nil, fmt.Errorf("invalid manifest: %w", err)
+			}
+			continue
+		}
+		if err := fn(name, data); err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+// RestoreResult counts what RestoreArchive did.
+type RestoreResult struct {
+	Restored  int
+	Unchanged int
+	Skipped   int
+}
+
+// RestoreArchive unpacks an archive under dir. Files that exist with
+// different content are only replaced if confirm returns true; identical
+// files are left alone.
+func RestoreArchive(archivePath, dir string, confirm func(path string) bool) (RestoreResult, error) {
+	var result RestoreResult
+	files := FileBackend{}
+	_, err := ReadArchive(archivePath, func(name string, data []byte) error {
+		dest := filepath.Join(dir, filepath.FromSlash(name))
+		existing, err := files.Read(dest)
+		switch {
+		case err == nil && string(existing) == string(data):
+			result.Unchanged++
+			return nil
+		case err == nil && !confirm(dest):
+			result.Skipped++
+			return nil
+		case err != nil && !errors.Is(err, os.ErrNotExist):
+			return fmt.Errorf("failed to read %s: %w", dest, err)
+		}
+		if err := files.Write(dest, data); err != nil {
+			return fmt.Errorf("%s: %w", dest, err)
+		}
+		result.Restored++
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func writeTestArchive(t *testing.T, archivePath string, files map[string]string) {
+	t.Helper()
+	a, err := NewArchiveBackend(archivePath, FileBackend{})
+	if err != nil {
+		t.Fatalf("NewArchiveBackend() error = %v", err)
+	}
+	// Concurrent writes, as from the download goroutines
+	var wg sync.WaitGroup
+	for name, content := range files {
+		name, content := name, content
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := WriteRawJSON(a, name, []byte(content)); err != nil {
+				t.Errorf("WriteRawJSON(%s) error = %v", name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := a.Close(ArchiveManifest{Command: "dashboards download"}); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := os.Stat(a.spool); !os.IsNotExist(err) {
+		t.Errorf("spool directory not removed: %v", err)
+	}
+}
+
+func TestArchiveBackend(t *testing.T) {
+	files := map[string]string{
+		"data/dashboards/bbb.json":      `{"id":"bbb"}`,
+		"data/dashboards/team/aaa.json": `{"id":"aaa"}`,
+		"/abs/ccc.json":                 `{"id":"ccc"}`,
+	}
+	dir := t.TempDir()
+	first := filepath.Join(dir, "one.tar.gz")
+	second := filepath.Join(dir, "two.tar.gz")
+	writeTestArchive(t, first, files)
+	writeTestArchive(t, second, files)
+
+	a, _ := os.ReadFile(first)
+	b, _ := os.ReadFile(second)
+	if sha256.Sum256(a) != sha256.Sum256(b) {
+		t.Errorf("archives of the same data should be identical")
+	}
+
+	var names []string
+	manifest, err := ReadArchive(first, func(name string, data []byte) error {
+		names = append(names, name)
+		if !bytes.HasSuffix(data, []byte("}\n")) {
+			t.Errorf("%s not formatted: %q", name, data)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadArchive() error = %v", err)
+	}
+	want := []string{"abs/ccc.json", "data/dashboards/bbb.json", "data/dashboards/team/aaa.json"}
+	if len(names) != len(want) {
+		t.Fatalf("entries = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("entries = %v, want sorted %v", names, want)
+			break
+		}
+	}
+	if manifest == nil || manifest.Command != "dashboards download" || len(manifest.Files) != 3 {
+		t.Errorf("unexpected manifest: %+v", manifest)
+	}
+}
+
+func TestArchiveBackend_RejectsUnsafePaths(t *testing.T) {
+	a, err := NewArchiveBackend(filepath.Join(t.TempDir(), "x.tar.gz"), FileBackend{})
+	if err != nil {
+		t.Fatalf("NewArchiveBackend() error = %v", err)
+	}
+	defer a.Abort()
+	for _, p := range []string{"../escape.json", ManifestName, ""} {
+		if err := a.Write(p, []byte("{}")); err == nil {
+			t.Errorf("Write(%q) expected error", p)
+		}
+	}
+}
+
+func TestArchiveBackend_AbortLeavesNoFile(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "x.tar.gz")
+	a, err := NewArchiveBackend(archivePath, FileBackend{})
+	if err != nil {
+		t.Fatalf("NewArchiveBackend() error = %v", err)
+	}
+	a.Write("data/x.json", []byte("{}"))
+	a.Abort()
+	if _, err := os.Stat(archivePath); !os.IsNotExist(err) {
+		t.Errorf("aborted archive should not exist")
+	}
+}
+
+func TestRestoreArchive(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "backup.tar.gz")
+	writeTestArchive(t, archivePath, map[string]string{
+		"data/dashboards/new.json":      `{"id":"new"}`,
+		"data/dashboards/same.json":     `{"id":"same"}`,
+		"data/dashboards/changed.json":  `{"id":"changed","title":"remote"}`,
+		"data/dashboards/declined.json": `{"id":"declined","title":"remote"}`,
+	})
+
+	dir := t.TempDir()
+	local := func(name, content string) {
+		path := filepath.Join(dir, "data", "dashboards", name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte(content), 0o644)
+	}
+	local("same.json", "{\n  \"id\": \"same\"\n}\n")
+	local("changed.json", `{"id":"changed","title":"local"}`)
+	local("declined.json", `{"id":"declined","title":"local"}`)
+
+	var asked []string
+	result, err := RestoreArchive(archivePath, dir, func(path string) bool {
+		asked = append(asked, filepath.Base(path))
+		return filepath.Base(path) == "changed.json"
+	})
+	if err != nil {
+		t.Fatalf("RestoreArchive() error = %v", err)
+	}
+	if result != (RestoreResult{Restored: 2, Unchanged: 1, Skipped: 1}) {
+		t.Errorf("RestoreArchive() = %+v", result)
+	}
+	if len(asked) != 2 {
+		t.Errorf("confirm should only be asked for changed files, asked %v", asked)
+	}
+
+	declined, _ := os.ReadFile(filepath.Join(dir, "data", "dashboards", "declined.json"))
+	if !bytes.Contains(declined, []byte("local")) {
+		t.Errorf("declined file was overwritten: %s", declined)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "data", "dashboards", "new.json")); err != nil {
+		t.Errorf("new file not restored: %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/logging"
+)
+
+// Backend is where downloaded resources are stored. Paths are the ones
+// computed from the path templates, e.g. "data/dashboards/abc-def-ghi.json".
+type Backend interface {
+	// Write stores data at path, replacing any existing content.
+	Write(path string, data []byte) error
+	// Read returns the content stored at path. Missing paths return an
+	// error wrapping fs.ErrNotExist.
+	Read(path string) ([]byte, error)
+	// List returns every stored path under prefix (a directory for the
+	// filesystem backend, a key prefix for object stores).
+	List(prefix string) ([]string, error)
+	// Remove deletes what is stored at path. A missing path is not an
+	// error.
+	Remove(path string) error
+}
+
+// override replaces the configured backend for the rest of the process,
+// e.g. to collect a run into an archive.
+var (
+	overrideMu sync.RWMutex
+	override   Backend
+)
+
+// Override makes NewBackend return b regardless of settings. Pass nil to
+// restore the configured backend.
+func Override(b Backend) {
+	overrideMu.Lock()
+	defer overrideMu.Unlock()
+	override = b
+}
+
+// NewBackend returns the backend selected by STORAGE_BACKEND ("file" or "s3"),
+// unless one was set with Override.
+func NewBackend(settings *config.Settings) (Backend, error) {
+	overrideMu.RLock()
+	b := override
+	overrideMu.RUnlock()
+	if b != nil {
+		return b, nil
+	}
+
+	switch settings.StorageBackend {
+	case "", "file":
+		return FileBackend{}, nil
+	case "s3":
+		// As with the AWS SDKs, the ECS relative URI wins over a full one
+		containerURI := settings.AWSContainerCredentialsFullURI
+		if settings.AWSContainerCredentialsRelativeURI != "" {
+			containerURI = ecsCredentialsHost + settings.AWSContainerCredentialsRelativeURI
+		}
+		return NewS3Backend(S3Config{
+			Bucket:                  settings.StorageS3Bucket,
+			Prefix:                  settings.StorageS3Prefix,
+			Region:                  settings.StorageS3Region,
+			Endpoint:                settings.StorageS3Endpoint,
+			Timeout:                 settings.HTTPTimeout,
+			AccessKey:               settings.AWSAccessKeyID,
+			SecretKey:               settings.AWSSecretAccessKey,
+			SessionToken:            settings.AWSSessionToken,
+			WebIdentityTokenFile:    settings.AWSWebIdentityTokenFile,
+			RoleARN:                 settings.AWSRoleARN,
+			RoleSessionName:         settings.AWSRoleSessionName,
+			ContainerCredentialsURI: containerURI,
+			ContainerAuthToken:      settings.AWSContainerAuthorizationToken,
+			ContainerAuthTokenFile:  settings.AWSContainerAuthorizationTokenFile,
+			IMDS:                    !settings.AWSEC2MetadataDisabled,
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q (expected \"file\" or \"s3\")", settings.StorageBackend)
+	}
+}
+
+// FileBackend stores resources on the local filesystem. It is the default.
+type FileBackend struct{}
+
+// Write creates the parent directory if needed and writes data to path. The
+// data goes to a temporary file renamed over path, so an interrupted command
+// never leaves a half-written file.
+func (FileBackend) Write(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // Fails harmlessly once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// Read returns the content of the file at path.
+func (FileBackend) Read(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+// Remove removes the file at path.
+func (FileBackend) Remove(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
+
+// List walks dir recursively and returns the paths of all regular files,
+// except the HTTP cache file. Unreadable entries are logged and skipped. A missing dir returns an error
+// wrapping fs.ErrNotExist.
+func (FileBackend) List(dir string) ([]string, error) {
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		return nil, &fs.PathError{Op: "list", Path: dir, Err: fs.ErrNotExist}
+	}
+
+	var paths []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			logging.Logger.Warn("failed to access file", "path", path, "error", err)
+			return nil // Continue walking despite errors
+		}
+		if !info.IsDir() && info.Name() != CacheFile {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+	return paths, nil
+}
+
+// WriteTracker wraps a backend and records every path written through it,
+// noting the writes that created a file and those that didn't change the
+// stored content.
+type WriteTracker struct {
+	Backend
+
+	mu        sync.Mutex
+	paths     []string
+	created   map[string]bool
+	unchanged map[string]bool
+}
+
+// TrackWrites routes every write for the rest of the run through a
+// WriteTracker (see Override) and returns it.
+func TrackWrites(settings *config.Settings) (*WriteTracker, error) {
+	base, err := NewBackend(settings)
+	if err != nil {
+		return nil, err
+	}
+	tracker := &WriteTracker{Backend: base}
+	Override(tracker)
+	return tracker, nil
+}
+
+// Write writes to the wrapped backend and records path on success.
+func (t *WriteTracker) Write(path string, data []byte) error {
+	existing, err := t.Backend.Read(path)
+	same := err == nil && bytes.Equal(existing, data)
+	created := errors.Is(err, fs.ErrNotExist)
+	if err := t.Backend.Write(path, data); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.paths = append(t.paths, path)
+	if created {
+		if t.created == nil {
+			t.created = map[string]bool{}
+		}
+		t.created[path] = true
+	}
+	if same {
+		if t.unchanged == nil {
+			t.unchanged = map[string]bool{}
+		}
+		t.unchanged[path] = true
+	}
+	t.mu.Unlock()
+	return nil
+}
+
+// Created reports whether path didn't exist before it was written.
+func (t *WriteTracker) Created(path string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.created[path]
+}
+
+// Unchanged reports whether path was written with the content it already
+// had.
+func (t *WriteTracker) Unchanged(path string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.unchanged[path]
+}
+
+// Paths returns the paths written so far.
+func (t *WriteTracker) Paths() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.paths...)
+}
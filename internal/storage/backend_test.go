@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileBackend_Write(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "a.json")
+	for _, data := range []string{"{\"a\": 1}\n", "{}\n"} {
+		if err := (FileBackend{}).Write(path, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := os.ReadFile(path); string(got) != data {
+			t.Errorf("Write() wrote %q, want %q", got, data)
+		}
+	}
+	// The temporary file was renamed, leaving nothing else behind
+	entries, err := os.ReadDir(filepath.Join(dir, "sub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Write() left %d files, want 1", len(entries))
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o644 {
+		t.Errorf("Write() mode = %v, want 0644", info.Mode().Perm())
+	}
+}
+
+func TestFileBackend_List(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{CacheFile, filepath.Join("dashboards", "abc-def-ghi.json")} {
+		if err := (FileBackend{}).Write(filepath.Join(dir, name), []byte("{}\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	paths, err := (FileBackend{}).List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(dir, "dashboards", "abc-def-ghi.json")}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("List() = %v, want %v, without the cache file", paths, want)
+	}
+}
+
+func TestWriteTracker(t *testing.T) {
+	dir := t.TempDir()
+	same := filepath.Join(dir, "same.json")
+	changed := filepath.Join(dir, "changed.json")
+	if err := (FileBackend{}).Write(same, []byte("{}\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := (FileBackend{}).Write(changed, []byte("{}\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	tracker := &WriteTracker{Backend: FileBackend{}}
+	created := filepath.Join(dir, "new", "created.json")
+	for path, data := range map[string]string{same: "{}\n", changed: "{\"a\": 1}\n", created: "{}\n"} {
+		if err := tracker.Write(path, []byte(data)); err != nil {
+			t.Fatalf("Write(%s) error = %v", path, err)
+		}
+	}
+
+	if got := len(tracker.Paths()); got != 3 {
+		t.Errorf("Paths() has %d paths, want 3", got)
+	}
+	got := map[string]bool{same: tracker.Unchanged(same), changed: tracker.Unchanged(changed), created: tracker.Unchanged(created)}
+	want := map[string]bool{same: true, changed: false, created: false}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unchanged() = %v, want %v", got, want)
+	}
+	got = map[string]bool{same: tracker.Created(same), changed: tracker.Created(changed), created: tracker.Created(created)}
+	want = map[string]bool{same: false, changed: false, created: true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Created() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,292 @@
+package storage
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ecsCredentialsHost serves the credentials of an ECS task's role at
+	// AWS_CONTAINER_CREDENTIALS_RELATIVE_URI.
+	ecsCredentialsHost = "http://169.254.170.2"
+
+	// imdsEndpoint is the EC2 instance metadata service.
+	imdsEndpoint = "http://169.254.169.254"
+
+	// imdsTimeout bounds instance metadata requests, so a run outside EC2
+	// fails quickly rather than after HTTP_TIMEOUT.
+	imdsTimeout = 2 * time.Second
+
+	// credentialsRefreshWindow is how long before they expire temporary
+	// credentials are refreshed, so a request never goes out with
+	// credentials that expire in flight.
+	credentialsRefreshWindow = 5 * time.Minute
+)
+
+// awsCredentials sign S3 requests. Temporary credentials have an expiry.
+type awsCredentials struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Expires      time.Time // zero for static credentials
+}
+
+// credentialSource fetches credentials, e.g. from a container credentials
+// endpoint.
+type credentialSource interface {
+	retrieve() (awsCredentials, error)
+}
+
+// newCredentialSource picks the first configured source, in the order the
+// AWS SDKs use: static keys, a web identity token, a container credentials
+// endpoint, then the EC2 instance metadata service.
+func newCredentialSource(cfg S3Config, client *http.Client) (credentialSource, error) {
+	switch {
+	case cfg.AccessKey != "" || cfg.SecretKey != "":
+		if cfg.AccessKey == "" || cfg.SecretKey == "" {
+			return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set together")
+		}
+		return staticCredentials{awsCredentials{AccessKey: cfg.AccessKey, SecretKey: cfg.SecretKey, SessionToken: cfg.SessionToken}}, nil
+	case cfg.WebIdentityTokenFile != "":
+		if cfg.RoleARN == "" {
+			return nil, fmt.Errorf("AWS_ROLE_ARN must be set with AWS_WEB_IDENTITY_TOKEN_FILE")
+		}
+		return &webIdentityCredentials{
+			endpoint:    stsEndpoint(cfg.Region),
+			tokenFile:   cfg.WebIdentityTokenFile,
+			roleARN:     cfg.RoleARN,
+			sessionName: cfg.RoleSessionName,
+			client:      client,
+		}, nil
+	case cfg.ContainerCredentialsURI != "":
+		return &containerCredentials{
+			uri:       cfg.ContainerCredentialsURI,
+			token:     cfg.ContainerAuthToken,
+			tokenFile: cfg.ContainerAuthTokenFile,
+			client:    client,
+		}, nil
+	case cfg.IMDS:
+		return &imdsCredentials{endpoint: imdsEndpoint, client: &http.Client{Timeout: imdsTimeout}}, nil
+	}
+	return nil, fmt.Errorf("no AWS credentials for the s3 storage backend: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or run with a container or web identity role")
+}
+
+// stsEndpoint returns the regional STS endpoint.
+func stsEndpoint(region string) string {
+	if strings.HasPrefix(region, "cn-") {
+		return "https://sts." + region + ".amazonaws.com.cn"
+	}
+	return "https://sts." + region + ".amazonaws.com"
+}
+
+// cachedCredentials retrieves credentials from source when first needed,
+// and again shortly before they expire.
+type cachedCredentials struct {
+	source credentialSource
+	now    func() time.Time
+
+	mu    sync.Mutex
+	creds *awsCredentials
+}
+
+// get returns valid credentials, refreshing them if needed.
+func (c *cachedCredentials) get() (awsCredentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.creds != nil && (c.creds.Expires.IsZero() || c.now().Add(credentialsRefreshWindow).Before(c.creds.Expires)) {
+		return *c.creds, nil
+	}
+	creds, err := c.source.retrieve()
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to get AWS credentials: %w", err)
+	}
+	c.creds = &creds
+	return creds, nil
+}
+
+// staticCredentials are AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+type staticCredentials struct {
+	creds awsCredentials
+}
+
+func (s staticCredentials) retrieve() (awsCredentials, error) {
+	return s.creds, nil
+}
+
+// containerCredentials are fetched from the endpoint ECS and EKS Pod
+// Identity provide to containers.
+type containerCredentials struct {
+	uri       string
+	token     string
+	tokenFile string // read on each retrieve, as it is rotated
+	client    *http.Client
+}
+
+func (c *containerCredentials) retrieve() (awsCredentials, error) {
+	req, err := http.NewRequest(http.MethodGet, c.uri, nil)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	token := c.token
+	if c.tokenFile != "" {
+		data, err := os.ReadFile(c.tokenFile)
+		if err != nil {
+			return awsCredentials{}, fmt.Errorf("failed to read AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	body, err := credentialsRequest(c.client, req)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("container credentials: %w", err)
+	}
+	return decodeCredentialsJSON(body)
+}
+
+// webIdentityCredentials exchange a web identity token, such as the one EKS
+// mounts for a service account's role, for the credentials of a role.
+type webIdentityCredentials struct {
+	endpoint    string
+	tokenFile   string // read on each retrieve, as it is rotated
+	roleARN     string
+	sessionName string
+	client      *http.Client
+}
+
+func (w *webIdentityCredentials) retrieve() (awsCredentials, error) {
+	token, err := os.ReadFile(w.tokenFile)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to read AWS_WEB_IDENTITY_TOKEN_FILE: %w", err)
+	}
+	sessionName := w.sessionName
+	if sessionName == "" {
+		sessionName = fmt.Sprintf("dd-tf-%d", time.Now().UnixNano())
+	}
+	// AssumeRoleWithWebIdentity is authenticated by the token, not signed
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {w.roleARN},
+		"RoleSessionName":  {sessionName},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	req, err := http.NewRequest(http.MethodPost, w.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	body, err := credentialsRequest(w.client, req)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("assume role %s with web identity: %w", w.roleARN, err)
+	}
+	var resp struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.Unmarshal(body, &resp); err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to decode STS response: %w", err)
+	}
+	c := resp.Credentials
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return awsCredentials{}, fmt.Errorf("STS response has no credentials")
+	}
+	return awsCredentials{AccessKey: c.AccessKeyID, SecretKey: c.SecretAccessKey, SessionToken: c.SessionToken, Expires: c.Expiration}, nil
+}
+
+// imdsCredentials are an EC2 instance role's, from the instance metadata
+// service (IMDSv2).
+type imdsCredentials struct {
+	endpoint string
+	client   *http.Client
+}
+
+func (m *imdsCredentials) retrieve() (awsCredentials, error) {
+	req, err := http.NewRequest(http.MethodPut, m.endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", "300")
+	token, err := credentialsRequest(m.client, req)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("instance metadata: %w", err)
+	}
+
+	get := func(path string) ([]byte, error) {
+		req, err := http.NewRequest(http.MethodGet, m.endpoint+"/latest/meta-data/iam/security-credentials/"+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-Aws-Ec2-Metadata-Token", string(token))
+		body, err := credentialsRequest(m.client, req)
+		if err != nil {
+			return nil, fmt.Errorf("instance metadata: %w", err)
+		}
+		return body, nil
+	}
+	roles, err := get("")
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	role, _, _ := strings.Cut(strings.TrimSpace(string(roles)), "\n")
+	if role == "" {
+		return awsCredentials{}, fmt.Errorf("instance metadata: the instance has no role")
+	}
+	body, err := get(url.PathEscape(role))
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	return decodeCredentialsJSON(body)
+}
+
+// credentialsRequest sends req and returns the body of a successful
+// response.
+func credentialsRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if len(body) > 4096 {
+			body = body[:4096]
+		}
+		return nil, fmt.Errorf("%s\n%s", resp.Status, body)
+	}
+	return body, nil
+}
+
+// decodeCredentialsJSON decodes the credentials of the container and
+// instance metadata endpoints.
+func decodeCredentialsJSON(body []byte) (awsCredentials, error) {
+	var c struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		Token           string    `json:"Token"`
+		Expiration      time.Time `json:"Expiration"`
+	}
+	if err := json.Unmarshal(body, &c); err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to decode credentials: %w", err)
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return awsCredentials{}, fmt.Errorf("response has no credentials")
+	}
+	return awsCredentials{AccessKey: c.AccessKeyID, SecretKey: c.SecretAccessKey, SessionToken: c.Token, Expires: c.Expiration}, nil
+}
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestS3Backend_ContainerCredentials(t *testing.T) {
+	expires := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	var fetches int
+	creds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/credentials/task" || r.Header.Get("Authorization") != "pod-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fetches++
+		fmt.Fprintf(w, `{"AccessKeyId":"ASIA%d","SecretAccessKey":"secret","Token":"session-%d","Expiration":%q}`, fetches, fetches, expires.Format(time.RFC3339))
+	}))
+	defer creds.Close()
+
+	var mu sync.Mutex
+	var tokens []string
+	fake := &fakeS3{objects: map[string][]byte{}}
+	s3 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		tokens = append(tokens, r.Header.Get("X-Amz-Security-Token"))
+		mu.Unlock()
+		fake.ServeHTTP(w, r)
+	}))
+	defer s3.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(tokenFile, []byte("pod-token\n"), 0o600)
+	b, err := NewS3Backend(S3Config{
+		Bucket:                  "bucket",
+		Endpoint:                s3.URL,
+		ContainerCredentialsURI: creds.URL + "/v2/credentials/task",
+		ContainerAuthTokenFile:  tokenFile,
+	})
+	if err != nil {
+		t.Fatalf("NewS3Backend() error = %v", err)
+	}
+	now := time.Date(2030, 1, 1, 11, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if err := b.Write("data/a.json", []byte(`{}`)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	// Close to expiry: refreshed before the next request
+	now = expires.Add(-time.Minute)
+	if _, err := b.Read("data/a.json"); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if want := []string{"session-1", "session-1", "session-2"}; strings.Join(tokens, ",") != strings.Join(want, ",") {
+		t.Errorf("session tokens = %v, want %v", tokens, want)
+	}
+}
+
+func TestWebIdentityCredentials(t *testing.T) {
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Method != http.MethodPost || r.Form.Get("Action") != "AssumeRoleWithWebIdentity" ||
+			r.Form.Get("RoleArn") != "arn:aws:iam::123456789012:role/dd-tf" ||
+			r.Form.Get("RoleSessionName") != "backup" || r.Form.Get("WebIdentityToken") != "jwt" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "unexpected request %s %v", r.Method, r.Form)
+			return
+		}
+		w.Write([]byte(`<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>ASIAEXAMPLE</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>session</SessionToken>
+      <Expiration>2030-01-01T12:00:00Z</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`))
+	}))
+	defer sts.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(tokenFile, []byte("jwt"), 0o600)
+	source := &webIdentityCredentials{
+		endpoint:    sts.URL,
+		tokenFile:   tokenFile,
+		roleARN:     "arn:aws:iam::123456789012:role/dd-tf",
+		sessionName: "backup",
+		client:      sts.Client(),
+	}
+	got, err := source.retrieve()
+	if err != nil {
+		t.Fatalf("retrieve() error = %v", err)
+	}
+	want := awsCredentials{AccessKey: "ASIAEXAMPLE", SecretKey: "secret", SessionToken: "session", Expires: time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)}
+	if got != want {
+		t.Errorf("retrieve() = %+v, want %+v", got, want)
+	}
+
+	source.roleARN = "arn:aws:iam::123456789012:role/other"
+	if _, err := source.retrieve(); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("retrieve() error = %v, want the STS error", err)
+	}
+}
+
+func TestIMDSCredentials(t *testing.T) {
+	imds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && r.URL.Path == "/latest/api/token" {
+			w.Write([]byte("imds-token"))
+			return
+		}
+		if r.Header.Get("X-Aws-Ec2-Metadata-Token") != "imds-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/latest/meta-data/iam/security-credentials/":
+			w.Write([]byte("backup-role"))
+		case "/latest/meta-data/iam/security-credentials/backup-role":
+			w.Write([]byte(`{"Code":"Success","AccessKeyId":"ASIAEXAMPLE","SecretAccessKey":"secret","Token":"session","Expiration":"2030-01-01T12:00:00Z"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer imds.Close()
+
+	got, err := (&imdsCredentials{endpoint: imds.URL, client: imds.Client()}).retrieve()
+	if err != nil {
+		t.Fatalf("retrieve() error = %v", err)
+	}
+	if got.AccessKey != "ASIAEXAMPLE" || got.SessionToken != "session" || got.Expires.IsZero() {
+		t.Errorf("retrieve() = %+v", got)
+	}
+}
+
+func TestNewCredentialSource(t *testing.T) {
+	for name, tt := range map[string]struct {
+		cfg     S3Config
+		want    string
+		wantErr bool
+	}{
+		"static":                {cfg: S3Config{AccessKey: "a", SecretKey: "s", WebIdentityTokenFile: "token", RoleARN: "role"}, want: "storage.staticCredentials"},
+		"web identity":          {cfg: S3Config{WebIdentityTokenFile: "token", RoleARN: "role", ContainerCredentialsURI: "http://127.0.0.1/"}, want: "*storage.webIdentityCredentials"},
+		"container":             {cfg: S3Config{ContainerCredentialsURI: "http://127.0.0.1/", IMDS: true}, want: "*storage.containerCredentials"},
+		"instance role":         {cfg: S3Config{IMDS: true}, want: "*storage.imdsCredentials"},
+		"none":                  {cfg: S3Config{}, wantErr: true},
+		"key without secret":    {cfg: S3Config{AccessKey: "a"}, wantErr: true},
+		"web identity sans ARN": {cfg: S3Config{WebIdentityTokenFile: "token"}, wantErr: true},
+	} {
+		source, err := newCredentialSource(tt.cfg, http.DefaultClient)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: newCredentialSource() error = %v", name, err)
+			continue
+		}
+		if got := fmt.Sprintf("%T", source); err == nil && got != tt.want {
+			t.Errorf("%s: newCredentialSource() = %s, want %s", name, got, tt.want)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -15,91 +18,233 @@ const (
 	// maxJSONFileSize all files should be less than 1MB so use that as a cut
 	// off to avoid reading invalid, extremely large, files
 	maxJSONFileSize = 1024 * 1024 // 1MB
+
+	// PresetsSuffix replaces ".json" in a dashboard's path to name the file
+	// holding its template variable presets (DASHBOARDS_SPLIT_PRESETS).
+	PresetsSuffix = ".presets.json"
+
+	// SummarySuffix replaces ".json" in a dashboard's path to name its
+	// Markdown summary (DASHBOARDS_WRITE_SUMMARY).
+	SummarySuffix = ".md"
+
+	// PolicySuffix replaces ".json" in a resource's path to name the file
+	// holding its restriction policy (--with-restriction-policy).
+	PolicySuffix = ".policy.json"
+
+	// TerraformSuffix replaces ".json" in a monitor's path to name the copy
+	// without read-only fields that generated Terraform reads
+	// (dd-tf tf generate monitors). Terraform itself reads *.tf.json files,
+	// so it must not be that.
+	TerraformSuffix = ".terraform.json"
+
+	// CacheFile names the file in DATA_DIR holding the ETags of downloaded
+	// resources (HTTP_CACHE). FileBackend.List skips it.
+	CacheFile = ".dd-tf-cache.json"
+
+	// SplitSkeletonName is the file in a split dashboard directory
+	// (DASHBOARDS_SPLIT_WIDGETS) holding everything except the widgets.
+	SplitSkeletonName = "dashboard.json"
 )
 
 var (
 	// nonAlphanumericRegex matches any non-alphanumeric characters for filename sanitization
 	nonAlphanumericRegex = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+	// SplitWidgetRegex matches widget files in a split dashboard directory,
+	// e.g. "001-timeseries.json". The number sets the widget's position.
+	SplitWidgetRegex = regexp.MustCompile(`^(\d+)(-[a-zA-Z0-9-]*)?\.json$`)
 )
 
 // WriteJSONFile writes data as JSON to the specified path with indentation.
-// Creates the parent directory if it doesn't exist.
+// A json.RawMessage keeps its key order. Creates the parent directory if it
+// doesn't exist.
 func WriteJSONFile(path string, data any) error {
-	// Ensure directory exists
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
 	}
+	return FileBackend{}.Write(path, buf.Bytes())
+}
+
+// WriteRawJSONFile pretty-prints raw JSON bytes to the specified path using
+// the same indentation as WriteJSONFile, without decoding the document.
+// Key order is preserved as received. Creates the parent directory if it
+// doesn't exist.
+func WriteRawJSONFile(path string, raw []byte) error {
+	return WriteRawJSON(FileBackend{}, path, raw)
+}
 
-	// Write JSON file
-	f, err := os.Create(path)
+// WriteRawJSON is WriteRawJSONFile for any storage backend.
+func WriteRawJSON(b Backend, path string, raw []byte) error {
+	data, err := FormatJSON(raw)
 	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+		return err
 	}
-	defer f.Close()
 
-	enc := json.NewEncoder(f)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(data); err != nil {
+	if err := b.Write(path, data); err != nil {
 		return fmt.Errorf("failed to write JSON: %w", err)
 	}
 
 	return nil
 }
 
-// SanitizeFilename replaces non-alphanumeric characters with hyphens and trims.
-func SanitizeFilename(name string) string {
-	return strings.Trim(nonAlphanumericRegex.ReplaceAllString(name, "-"), "-")
+// FormatJSON indents raw JSON the way resource files are written: two
+// spaces, API key order preserved, trailing newline.
+func FormatJSON(raw []byte) ([]byte, error) {
+	// json.Indent keeps surrounding whitespace; drop it so formatting an
+	// already formatted file is a no-op
+	raw = bytes.TrimSpace(raw)
+	var buf bytes.Buffer
+	buf.Grow(len(raw) + len(raw)/2)
+	if err := json.Indent(&buf, raw, "", "  "); err != nil {
+		return nil, fmt.Errorf("failed to format JSON: %w", err)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
 }
 
-// ExtractIDsFromJSONFiles scans a directory recursively for JSON files and extracts IDs from their content.
-// Returns a map of id -> absolute file path.
-// Each JSON file must have an "id" field at the top level.
-func ExtractIDsFromJSONFiles(dir string) (map[string]string, error) {
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		return nil, fmt.Errorf("directory does not exist: %s", dir)
-	}
+// PresetsPath returns the presets file for the dashboard at path, e.g.
+// "abc-def-ghi.json" -> "abc-def-ghi.presets.json".
+func PresetsPath(path string) string {
+	return strings.TrimSuffix(path, ".json") + PresetsSuffix
+}
 
-	result := make(map[string]string)
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			logging.Logger.Warn("failed to access file", "path", path, "error", err)
-			return nil // Continue walking despite errors
-		}
+// IsPresetsPath reports whether path is a presets file rather than a
+// resource.
+func IsPresetsPath(path string) bool {
+	return strings.HasSuffix(path, PresetsSuffix)
+}
 
-		// Skip directories
-		if info.IsDir() {
-			return nil
-		}
+// SummaryPath returns the summary file for the dashboard at path, e.g.
+// "abc-def-ghi.json" -> "abc-def-ghi.md".
+func SummaryPath(path string) string {
+	return strings.TrimSuffix(path, ".json") + SummarySuffix
+}
 
-		// Only process .json files
-		if !strings.HasSuffix(info.Name(), ".json") {
-			return nil
-		}
+// PolicyPath returns the restriction policy file for the resource at path,
+// e.g. "abc-def-ghi.json" -> "abc-def-ghi.policy.json".
+func PolicyPath(path string) string {
+	return strings.TrimSuffix(path, ".json") + PolicySuffix
+}
 
-		// Check file size before reading
-		if info.Size() > maxJSONFileSize {
-			logging.Logger.Warn("skipping file (too large)", "path", path, "size", info.Size(), "max", maxJSONFileSize)
-			return nil
+// IsPolicyPath reports whether path is a restriction policy file rather than
+// a resource.
+func IsPolicyPath(path string) bool {
+	return strings.HasSuffix(path, PolicySuffix)
+}
+
+// TerraformPath returns the Terraform copy of the monitor at path, e.g.
+// "123.json" -> "123.terraform.json".
+func TerraformPath(path string) string {
+	return strings.TrimSuffix(path, ".json") + TerraformSuffix
+}
+
+// IsTerraformPath reports whether path is a Terraform copy rather than a
+// resource.
+func IsTerraformPath(path string) bool {
+	return strings.HasSuffix(path, TerraformSuffix)
+}
+
+// IsSidecarPath reports whether path is a file written next to a resource,
+// a presets, restriction policy or Terraform copy, rather than a resource.
+func IsSidecarPath(path string) bool {
+	return IsPresetsPath(path) || IsPolicyPath(path) || IsTerraformPath(path)
+}
+
+// IsSplitDir reports whether dir holds a split dashboard: a skeleton with
+// widget files next to it. The skeleton alone has no widgets, so such a
+// directory must be read whole.
+func IsSplitDir(b Backend, dir string) bool {
+	paths, err := b.List(dir)
+	if err != nil {
+		return false
+	}
+	dir = filepath.Clean(dir)
+	var skeleton, widgets bool
+	for _, p := range paths {
+		if filepath.Dir(p) != dir {
+			continue
 		}
+		name := filepath.Base(p)
+		skeleton = skeleton || name == SplitSkeletonName
+		widgets = widgets || SplitWidgetRegex.MatchString(name)
+	}
+	return skeleton && widgets
+}
+
+// CompanionPaths returns the files that belong to the resource at path and
+// move with it: a dashboard's presets file and summary, a restriction
+// policy and a Terraform copy.
+func CompanionPaths(path string) []string {
+	return []string{PresetsPath(path), SummaryPath(path), PolicyPath(path), TerraformPath(path)}
+}
 
-		// Read JSON file and extract "id" field
-		data, err := os.ReadFile(path)
+// RemoveWithCompanions removes the resource file at path and its companion
+// files, returning the paths removed. Missing files are ignored.
+func RemoveWithCompanions(path string) ([]string, error) {
+	var removed []string
+	for _, p := range append([]string{path}, CompanionPaths(path)...) {
+		err := os.Remove(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
 		if err != nil {
-			logging.Logger.Warn("failed to read file", "path", path, "error", err)
-			return nil
+			return removed, fmt.Errorf("failed to remove %s: %w", p, err)
 		}
+		removed = append(removed, p)
+	}
+	return removed, nil
+}
 
-		var content map[string]any
-		if err := json.Unmarshal(data, &content); err != nil {
-			logging.Logger.Warn("failed to parse JSON", "path", path, "error", err)
-			return nil
-		}
+// Move moves the content stored at from to to. Backends can't rename, so to
+// is written before from is removed.
+func Move(b Backend, from, to string) error {
+	data, err := b.Read(from)
+	if err != nil {
+		return fmt.Errorf("failed to move %s: %w", from, err)
+	}
+	if err := b.Write(to, data); err != nil {
+		return fmt.Errorf("failed to move %s: %w", from, err)
+	}
+	return b.Remove(from)
+}
+
+// SanitizeFilename replaces non-alphanumeric characters with hyphens and trims.
+func SanitizeFilename(name string) string {
+	return strings.Trim(nonAlphanumericRegex.ReplaceAllString(name, "-"), "-")
+}
+
+// ExtractIDsFromJSONFiles scans a directory recursively for JSON files and extracts IDs from their content.
+// Returns a map of id -> absolute file path.
+// Each JSON file must have an "id" field at the top level.
+func ExtractIDsFromJSONFiles(dir string) (map[string]string, error) {
+	return ExtractIDs(FileBackend{}, dir)
+}
+
+// ExtractIDs is ExtractIDsFromJSONFiles for any storage backend.
+func ExtractIDs(b Backend, dir string) (map[string]string, error) {
+	return ExtractField(b, dir, "id")
+}
 
-		id, ok := content["id"].(string)
+// ExtractFieldFromJSONFiles is ExtractIDsFromJSONFiles keyed on another
+// string field, for resources without an id such as log indexes, which are
+// identified by their name. A dotted field such as "attributes.handle" reads
+// a nested field.
+func ExtractFieldFromJSONFiles(dir, field string) (map[string]string, error) {
+	return ExtractField(FileBackend{}, dir, field)
+}
+
+// ExtractField is ExtractFieldFromJSONFiles for any storage backend.
+func ExtractField(b Backend, dir, field string) (map[string]string, error) {
+	result := make(map[string]string)
+	err := readJSONFiles(b, dir, func(path string, content map[string]any) {
+		id, ok := lookupField(content, field).(string)
 		if !ok || id == "" {
-			logging.Logger.Warn("no valid id field", "path", path)
-			return nil
+			logging.Logger.Warn("no valid "+field+" field", "path", path)
+			return
 		}
 
 		// Store the first occurrence; duplicates are logged but not stored
@@ -108,71 +253,91 @@ func ExtractIDsFromJSONFiles(dir string) (map[string]string, error) {
 		} else {
 			result[id] = path
 		}
-
-		return nil
 	})
-
 	if err != nil {
-		return nil, fmt.Errorf("failed to walk directory: %w", err)
+		return nil, err
 	}
-
 	return result, nil
 }
 
+// lookupField returns the value of a dotted field path in content, or nil.
+func lookupField(content map[string]any, field string) any {
+	var value any = content
+	for _, key := range strings.Split(field, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
 // ExtractIntIDsFromJSONFiles scans a directory recursively for JSON files and extracts integer IDs from their content.
 // Returns a map of id -> absolute file path.
 // Each JSON file must have an "id" field at the top level that is a number (Datadog monitors use integer IDs).
 func ExtractIntIDsFromJSONFiles(dir string) (map[int]string, error) {
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		return nil, fmt.Errorf("directory does not exist: %s", dir)
-	}
+	return ExtractIntIDs(FileBackend{}, dir)
+}
 
+// ExtractIntIDs is ExtractIntIDsFromJSONFiles for any storage backend.
+func ExtractIntIDs(b Backend, dir string) (map[int]string, error) {
 	result := make(map[int]string)
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			logging.Logger.Warn("failed to access file", "path", path, "error", err)
-			return nil // Continue walking despite errors
+	err := readJSONFiles(b, dir, func(path string, content map[string]any) {
+		// JSON decoder uses float64 for numbers by default
+		f, ok := content["id"].(float64)
+		if !ok {
+			logging.Logger.Warn("no numeric id field", "path", path)
+			return
 		}
-
-		if info.IsDir() {
-			return nil
+		id := int(f)
+		if id == 0 {
+			logging.Logger.Warn("invalid id value", "path", path)
+			return
 		}
-		if !strings.HasSuffix(info.Name(), ".json") {
-			return nil
+		if existing, exists := result[id]; exists {
+			logging.Logger.Warn("duplicate id", "id", id, "path", path, "existing", existing)
+		} else {
+			result[id] = path
 		}
-		if info.Size() > maxJSONFileSize {
-			logging.Logger.Warn("skipping file (too large)", "path", path, "size", info.Size(), "max", maxJSONFileSize)
-			return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// readJSONFiles lists dir on the backend and calls fn with the decoded
+// content of every .json file. Unreadable, oversized, or invalid files are
+// logged and skipped.
+func readJSONFiles(b Backend, dir string, fn func(path string, content map[string]any)) error {
+	paths, err := b.List(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		// Only process .json files, skipping presets and policies
+		if !strings.HasSuffix(path, ".json") || IsSidecarPath(path) {
+			continue
 		}
-		data, err := os.ReadFile(path)
+
+		data, err := b.Read(path)
 		if err != nil {
 			logging.Logger.Warn("failed to read file", "path", path, "error", err)
-			return nil
+			continue
 		}
+		if len(data) > maxJSONFileSize {
+			logging.Logger.Warn("skipping file (too large)", "path", path, "size", len(data), "max", maxJSONFileSize)
+			continue
+		}
+
 		var content map[string]any
 		if err := json.Unmarshal(data, &content); err != nil {
 			logging.Logger.Warn("failed to parse JSON", "path", path, "error", err)
-			return nil
+			continue
 		}
-		// JSON decoder uses float64 for numbers by default
-		if f, ok := content["id"].(float64); ok {
-			id := int(f)
-			if id == 0 {
-				logging.Logger.Warn("invalid id value", "path", path)
-				return nil
-			}
-			if existing, exists := result[id]; exists {
-				logging.Logger.Warn("duplicate id", "id", id, "path", path, "existing", existing)
-			} else {
-				result[id] = path
-			}
-		} else {
-			logging.Logger.Warn("no numeric id field", "path", path)
-		}
-		return nil
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to walk directory: %w", err)
+		fn(path, content)
 	}
-	return result, nil
+	return nil
 }
@@ -3,6 +3,9 @@ package storage
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -81,8 +84,8 @@ func TestSanitizeFilename(t *testing.T) {
 func TestExtractIDsFromJSONFiles(t *testing.T) {
 	t.Run("returns error when directory does not exist", func(t *testing.T) {
 		_, err := ExtractIDsFromJSONFiles("/nonexistent/path/that/does/not/exist")
-		if err == nil {
-			t.Error("ExtractIDsFromJSONFiles() expected error for nonexistent directory, got nil")
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("ExtractIDsFromJSONFiles() error = %v, want one wrapping fs.ErrNotExist", err)
 		}
 	})
 
@@ -94,6 +97,8 @@ func TestExtractIDsFromJSONFiles(t *testing.T) {
 		files := map[string]string{
 			"dashboard1.json": `{"id": "abc-123", "title": "Dashboard 1"}`,
 			"dashboard2.json": `{"id": "def-456", "title": "Dashboard 2"}`,
+			// Presets files aren't resources and are skipped
+			"dashboard2.presets.json": `[{"name": "Production"}]`,
 		}
 
 		for filename, content := range files {
@@ -357,6 +362,55 @@ func TestExtractIDsFromJSONFiles(t *testing.T) {
 	})
 }
 
+func TestExtractFieldFromJSONFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"main.json":    `{"name": "main", "filter": {"query": "*"}}`,
+		"archive.json": `{"name": "archive"}`,
+		"no-name.json": `{"id": "abc-123"}`,
+	}
+	for filename, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, filename), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", filename, err)
+		}
+	}
+
+	got, err := ExtractFieldFromJSONFiles(tmpDir, "name")
+	if err != nil {
+		t.Fatalf("ExtractFieldFromJSONFiles() unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"main":    filepath.Join(tmpDir, "main.json"),
+		"archive": filepath.Join(tmpDir, "archive.json"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractFieldFromJSONFiles() = %v, want %v", got, want)
+	}
+}
+
+func TestExtractFieldFromJSONFiles_Nested(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"sre.json":       `{"id": "t1", "attributes": {"handle": "sre"}}`,
+		"no-handle.json": `{"id": "t2", "attributes": {"name": "Web"}}`,
+		"flat.json":      `{"id": "t3", "attributes": "sre"}`,
+	}
+	for filename, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, filename), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", filename, err)
+		}
+	}
+
+	got, err := ExtractFieldFromJSONFiles(tmpDir, "attributes.handle")
+	if err != nil {
+		t.Fatalf("ExtractFieldFromJSONFiles() unexpected error: %v", err)
+	}
+	want := map[string]string{"sre": filepath.Join(tmpDir, "sre.json")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractFieldFromJSONFiles() = %v, want %v", got, want)
+	}
+}
+
 func TestWriteJSONFile(t *testing.T) {
 	t.Run("writes valid JSON file", func(t *testing.T) {
 		tmpDir := t.TempDir()
@@ -401,6 +455,18 @@ func TestWriteJSONFile(t *testing.T) {
 		}
 	})
 
+	t.Run("keeps raw JSON as written", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "raw.json")
+		if err := WriteJSONFile(path, json.RawMessage(`{"z":1,"query":"a > b && c < d","a":[1,2]}`)); err != nil {
+			t.Fatalf("WriteJSONFile() unexpected error: %v", err)
+		}
+		content, _ := os.ReadFile(path)
+		want := "{\n  \"z\": 1,\n  \"query\": \"a > b && c < d\",\n  \"a\": [\n    1,\n    2\n  ]\n}\n"
+		if string(content) != want {
+			t.Errorf("WriteJSONFile() wrote %q, want %q", content, want)
+		}
+	})
+
 	t.Run("creates nested directories", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		path := filepath.Join(tmpDir, "level1", "level2", "level3", "test.json")
@@ -542,3 +608,117 @@ func TestWriteJSONFile(t *testing.T) {
 		}
 	})
 }
+
+func TestWriteRawJSONFile(t *testing.T) {
+	t.Run("preserves key order and indents", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "nested", "test.json")
+
+		err := WriteRawJSONFile(path, []byte(`{"title":"T","id":"abc","widgets":[{"z":1,"a":"<b>"}]}`))
+		if err != nil {
+			t.Fatalf("WriteRawJSONFile() unexpected error: %v", err)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read written file: %v", err)
+		}
+
+		want := "{\n  \"title\": \"T\",\n  \"id\": \"abc\",\n  \"widgets\": [\n    {\n      \"z\": 1,\n      \"a\": \"<b>\"\n    }\n  ]\n}\n"
+		if string(content) != want {
+			t.Errorf("WriteRawJSONFile() wrote\n%s\nwant\n%s", content, want)
+		}
+	})
+
+	t.Run("rejects invalid JSON", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bad.json")
+		if err := WriteRawJSONFile(path, []byte(`{"id":`)); err == nil {
+			t.Error("WriteRawJSONFile() expected error for invalid JSON")
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Error("WriteRawJSONFile() should not create a file for invalid JSON")
+		}
+	})
+}
+
+// largeDashboardFixture builds a dashboard payload of a few hundred KB,
+// comparable to the big shared dashboards seen in practice.
+func largeDashboardFixture(widgets int) []byte {
+	var b bytes.Buffer
+	b.WriteString(`{"id":"abc-def-ghi","title":"Large dashboard","layout_type":"ordered","tags":["team:platform"],"widgets":[`)
+	for i := 0; i < widgets; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `{"id":%d,"definition":{"title":"Widget %d","type":"timeseries","requests":[{"q":"avg:system.cpu.user{service:svc-%d} by {host}","display_type":"line","style":{"palette":"dog_classic","line_type":"solid","line_width":"normal"}}],"yaxis":{"scale":"linear","min":"auto","max":"auto","include_zero":true}},"layout":{"x":%d,"y":%d,"width":4,"height":2}}`, i, i, i, i%12, i/3)
+	}
+	b.WriteString(`]}`)
+	return b.Bytes()
+}
+
+// BenchmarkWriteDashboard_DecodeReencode measures the previous write path:
+// decode the whole payload into a map and re-encode it.
+func BenchmarkWriteDashboard_DecodeReencode(b *testing.B) {
+	raw := largeDashboardFixture(1000)
+	path := filepath.Join(b.TempDir(), "dash.json")
+	b.SetBytes(int64(len(raw)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var data map[string]any
+		if err := json.Unmarshal(raw, &data); err != nil {
+			b.Fatal(err)
+		}
+		if err := WriteJSONFile(path, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkWriteDashboard_RawIndent measures the raw write path: decode only
+// the fields needed for templating and indent the original bytes.
+func BenchmarkWriteDashboard_RawIndent(b *testing.B) {
+	raw := largeDashboardFixture(1000)
+	path := filepath.Join(b.TempDir(), "dash.json")
+	b.SetBytes(int64(len(raw)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var meta struct {
+			ID    string   `json:"id"`
+			Title string   `json:"title"`
+			Tags  []string `json:"tags"`
+		}
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			b.Fatal(err)
+		}
+		if err := WriteRawJSONFile(path, raw); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestRemoveWithCompanions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "abc-def-ghi.json")
+	presets := PresetsPath(path)
+	other := filepath.Join(dir, "other.json")
+	for _, p := range []string{path, presets, other} {
+		if err := os.WriteFile(p, []byte("{}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := RemoveWithCompanions(path)
+	if err != nil {
+		t.Fatalf("RemoveWithCompanions() error = %v", err)
+	}
+	if want := []string{path, presets}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("RemoveWithCompanions() = %v, want %v", removed, want)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("other file: %v, want it kept", err)
+	}
+
+	removed, err = RemoveWithCompanions(path)
+	if err != nil || len(removed) != 0 {
+		t.Errorf("RemoveWithCompanions() of a removed file = %v, %v, want nothing", removed, err)
+	}
+}
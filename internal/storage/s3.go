@@ -0,0 +1,301 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config configures an S3Backend.
+type S3Config struct {
+	Bucket   string
+	Prefix   string // prepended to every path to form the object key
+	Region   string // defaults to us-east-1
+	Endpoint string // optional, e.g. http://localhost:9000 for S3-compatible stores; uses path-style URLs
+	Timeout  time.Duration
+
+	// Credentials come from the first of these set, as with the AWS SDKs
+	AccessKey               string // static credentials, with SecretKey
+	SecretKey               string
+	SessionToken            string // optional, for temporary static credentials
+	WebIdentityTokenFile    string // exchanged with STS for RoleARN's credentials
+	RoleARN                 string
+	RoleSessionName         string // optional, defaults to dd-tf-<timestamp>
+	ContainerCredentialsURI string // ECS or EKS Pod Identity credentials endpoint
+	ContainerAuthToken      string // optional Authorization header for ContainerCredentialsURI
+	ContainerAuthTokenFile  string // optional file holding ContainerAuthToken
+	IMDS                    bool   // fall back to the EC2 instance role
+}
+
+// S3Backend stores resources as objects in an S3 bucket. Requests are signed
+// with AWS Signature Version 4 using only the standard library, so no AWS SDK
+// dependency is needed.
+type S3Backend struct {
+	cfg    S3Config
+	client *http.Client
+	creds  *cachedCredentials
+	now    func() time.Time // overridden in tests
+}
+
+// NewS3Backend validates cfg and returns an S3 backend.
+func NewS3Backend(cfg S3Config) (*S3Backend, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("STORAGE_S3_BUCKET must be set for the s3 storage backend")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	cfg.Prefix = strings.Trim(cfg.Prefix, "/")
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	client := &http.Client{Timeout: cfg.Timeout}
+	source, err := newCredentialSource(cfg, client)
+	if err != nil {
+		return nil, err
+	}
+	b := &S3Backend{cfg: cfg, client: client, now: time.Now}
+	b.creds = &cachedCredentials{source: source, now: func() time.Time { return b.now() }}
+	return b, nil
+}
+
+// Write uploads data to the object for path.
+func (b *S3Backend) Write(path string, data []byte) error {
+	resp, err := b.do(http.MethodPut, b.key(path), nil, data)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", path, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Read downloads the object for path.
+func (b *S3Backend) Read(path string) ([]byte, error) {
+	resp, err := b.do(http.MethodGet, b.key(path), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// Remove deletes the object for path. S3 doesn't report missing objects.
+func (b *S3Backend) Remove(path string) error {
+	resp, err := b.do(http.MethodDelete, b.key(path), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// List returns the paths of all objects whose path starts with prefix.
+func (b *S3Backend) List(prefix string) ([]string, error) {
+	var paths []string
+	query := url.Values{"list-type": {"2"}, "prefix": {b.key(prefix)}}
+	for {
+		resp, err := b.do(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+		}
+		var result struct {
+			Contents []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode object list: %w", err)
+		}
+		for _, obj := range result.Contents {
+			paths = append(paths, b.path(obj.Key))
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return paths, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+// key maps a storage path to an object key.
+func (b *S3Backend) key(path string) string {
+	path = strings.TrimPrefix(strings.ReplaceAll(path, "\\", "/"), "/")
+	if b.cfg.Prefix == "" {
+		return path
+	}
+	return b.cfg.Prefix + "/" + path
+}
+
+// path maps an object key back to a storage path.
+func (b *S3Backend) path(key string) string {
+	if b.cfg.Prefix == "" {
+		return key
+	}
+	return strings.TrimPrefix(key, b.cfg.Prefix+"/")
+}
+
+// objectURL returns the URL for key: virtual-hosted style on AWS, path
+// style for custom endpoints.
+func (b *S3Backend) objectURL(key string) *url.URL {
+	if b.cfg.Endpoint != "" {
+		u, err := url.Parse(b.cfg.Endpoint)
+		if err == nil {
+			u.Path = "/" + b.cfg.Bucket + "/" + key
+			u.RawPath = uriEscape(u.Path, false)
+			return u
+		}
+	}
+	return &url.URL{
+		Scheme:  "https",
+		Host:    fmt.Sprintf("%s.s3.%s.amazonaws.com", b.cfg.Bucket, b.cfg.Region),
+		Path:    "/" + key,
+		RawPath: uriEscape("/"+key, false),
+	}
+}
+
+// do sends a signed request and returns the response if it succeeded.
+func (b *S3Backend) do(method, key string, query url.Values, body []byte) (*http.Response, error) {
+	u := b.objectURL(key)
+	u.RawQuery = canonicalQuery(query)
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	creds, err := b.creds.get()
+	if err != nil {
+		return nil, err
+	}
+	b.sign(req, u, body, creds)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound && method == http.MethodGet && key != "" {
+		resp.Body.Close()
+		return nil, fs.ErrNotExist
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		return nil, fmt.Errorf("S3 error: %s\n%s", resp.Status, msg)
+	}
+	return resp, nil
+}
+
+// sign adds AWS Signature Version 4 headers to req, signed with creds.
+func (b *S3Backend) sign(req *http.Request, u *url.URL, body []byte, creds awsCredentials) {
+	now := b.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{
+		"host":                 u.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if creds.SessionToken != "" {
+		headers["x-amz-security-token"] = creds.SessionToken
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		u.EscapedPath(),
+		u.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + b.cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretKey), date)
+	key = hmacSHA256(key, b.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query parameters sorted by key, as SigV4 requires.
+func canonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, uriEscape(k, true)+"="+uriEscape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEscape percent-encodes everything except unreserved characters (and
+// "/" unless encodeSlash is set).
+func uriEscape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,78 @@
+// Package storagetest has a storage backend for tests.
+package storagetest
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+var _ storage.Backend = (*Memory)(nil)
+
+// Memory is a storage.Backend keeping files in memory, standing in for
+// backends other than the file system, such as S3.
+type Memory struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// NewMemory returns a Memory backend holding files, a map of paths to
+// content.
+func NewMemory(files map[string]string) *Memory {
+	m := &Memory{files: map[string][]byte{}}
+	for path, content := range files {
+		m.files[path] = []byte(content)
+	}
+	return m
+}
+
+// Write stores data at path.
+func (m *Memory) Write(path string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[path] = append([]byte(nil), data...)
+	return nil
+}
+
+// Read returns the data stored at path.
+func (m *Memory) Read(path string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[path]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", path, fs.ErrNotExist)
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// List returns the stored paths starting with prefix, sorted.
+func (m *Memory) List(prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var paths []string
+	for path := range m.files {
+		if strings.HasPrefix(path, prefix) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Remove deletes path.
+func (m *Memory) Remove(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, path)
+	return nil
+}
+
+// Paths returns every stored path, sorted.
+func (m *Memory) Paths() []string {
+	paths, _ := m.List("")
+	return paths
+}
@@ -0,0 +1,136 @@
+// Package tagreport aggregates the tags of downloaded dashboards and
+// monitors, to measure tag hygiene.
+package tagreport
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/templating"
+	"github.com/AD7six/dd-tf/internal/storage"
+)
+
+// Options configures Run.
+type Options struct {
+	Key     string   // only report this tag key; empty reports every key
+	Require []string // tag keys every resource must have
+}
+
+// Result is the tag usage across the resources in a directory.
+type Result struct {
+	Resources int        `json:"resources"` // dashboards and monitors scanned
+	Untagged  int        `json:"untagged"`  // resources without any key:value tag
+	Keys      []KeyUsage `json:"keys"`
+	Missing   []Missing  `json:"missing,omitempty"` // resources missing a required key
+}
+
+// KeyUsage is how one tag key is used.
+type KeyUsage struct {
+	Key     string       `json:"key"`
+	Values  []ValueCount `json:"values"`  // most used first
+	Missing int          `json:"missing"` // resources without this key
+}
+
+// ValueCount is the number of resources with a tag value.
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Missing is a resource without some of the required tag keys.
+type Missing struct {
+	Path string   `json:"path"`
+	Kind string   `json:"kind"`
+	ID   string   `json:"id"`
+	Keys []string `json:"keys"`
+}
+
+// Run reads every dashboard and monitor under dir and aggregates their tags.
+// Keys are sorted by name; missing resources are in path order.
+func Run(dir string, opts Options) (*Result, error) {
+	files := storage.FileBackend{}
+	paths, err := files.List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	counts := map[string]map[string]int{} // key -> value -> resources
+	for _, path := range paths {
+		if !strings.HasSuffix(path, ".json") || storage.IsSidecarPath(path) {
+			continue
+		}
+		data, err := files.Read(path)
+		if err != nil {
+			return nil, err
+		}
+		var content map[string]any
+		if err := json.Unmarshal(data, &content); err != nil {
+			continue // verify reports invalid files
+		}
+		kind := resource.GuessKind(content)
+		if kind == "" {
+			continue
+		}
+
+		result.Resources++
+		tags := templating.ExtractTagMap(content["tags"], false)
+		if len(tags) == 0 {
+			result.Untagged++
+		}
+		for key, value := range tags {
+			if opts.Key != "" && key != opts.Key {
+				continue
+			}
+			if counts[key] == nil {
+				counts[key] = map[string]int{}
+			}
+			counts[key][value]++
+		}
+
+		var missing []string
+		for _, key := range opts.Require {
+			if _, ok := tags[key]; !ok {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			result.Missing = append(result.Missing, Missing{Path: path, Kind: kind, ID: idString(content["id"]), Keys: missing})
+		}
+	}
+
+	if opts.Key != "" && counts[opts.Key] == nil {
+		counts[opts.Key] = map[string]int{}
+	}
+	for key, values := range counts {
+		usage := KeyUsage{Key: key, Missing: result.Resources}
+		for value, n := range values {
+			usage.Values = append(usage.Values, ValueCount{Value: value, Count: n})
+			usage.Missing -= n
+		}
+		sort.Slice(usage.Values, func(i, j int) bool {
+			a, b := usage.Values[i], usage.Values[j]
+			if a.Count != b.Count {
+				return a.Count > b.Count
+			}
+			return a.Value < b.Value
+		})
+		result.Keys = append(result.Keys, usage)
+	}
+	sort.Slice(result.Keys, func(i, j int) bool { return result.Keys[i].Key < result.Keys[j].Key })
+	return result, nil
+}
+
+// idString formats a decoded id the way it appears in paths.
+func idString(v any) string {
+	switch id := v.(type) {
+	case string:
+		return id
+	case float64:
+		return strconv.FormatInt(int64(id), 10)
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package tagreport
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var dataDir = filepath.Join("testdata", "data")
+
+func TestRun(t *testing.T) {
+	result, err := Run(dataDir, Options{})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Resources != 4 || result.Untagged != 1 {
+		t.Errorf("Resources = %d, Untagged = %d, want 4 and 1", result.Resources, result.Untagged)
+	}
+	want := []KeyUsage{
+		{Key: "env", Values: []ValueCount{{"prod", 1}, {"staging", 1}}, Missing: 2},
+		{Key: "team", Values: []ValueCount{{"platform", 2}, {"storage", 1}}, Missing: 1},
+	}
+	if !reflect.DeepEqual(result.Keys, want) {
+		t.Errorf("Keys = %+v, want %+v", result.Keys, want)
+	}
+	if result.Missing != nil {
+		t.Errorf("Missing = %+v, want none without Require", result.Missing)
+	}
+}
+
+func TestRun_Key(t *testing.T) {
+	result, err := Run(dataDir, Options{Key: "owner"})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := []KeyUsage{{Key: "owner", Missing: 4}}
+	if !reflect.DeepEqual(result.Keys, want) {
+		t.Errorf("Keys = %+v, want %+v", result.Keys, want)
+	}
+}
+
+func TestRun_Require(t *testing.T) {
+	result, err := Run(dataDir, Options{Require: []string{"team", "env"}})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := []Missing{
+		{Path: filepath.Join(dataDir, "dashboards", "xyz-uvw-rst.json"), Kind: "dashboard", ID: "xyz-uvw-rst", Keys: []string{"team", "env"}},
+		{Path: filepath.Join(dataDir, "monitors", "2.json"), Kind: "monitor", ID: "2", Keys: []string{"env"}},
+	}
+	if !reflect.DeepEqual(result.Missing, want) {
+		t.Errorf("Missing = %+v, want %+v", result.Missing, want)
+	}
+}
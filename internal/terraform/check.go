@@ -0,0 +1,170 @@
+package terraform
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+)
+
+// Status is how a dashboard or monitor compares with a Terraform state.
+type Status string
+
+const (
+	Managed       Status = "managed"        // In the state, and downloaded or in Datadog
+	MissingLocal  Status = "missing-local"  // In the state, but not downloaded
+	MissingRemote Status = "missing-remote" // In the state, but not in Datadog
+	Unmanaged     Status = "unmanaged"      // Downloaded or in Datadog, but not in the state
+	Mismatched    Status = "mismatched"     // Its address is in the state with another ID
+)
+
+// StateEntry is the outcome for one resource.
+type StateEntry struct {
+	Kind    string `json:"kind"`
+	ID      string `json:"id"`
+	Status  Status `json:"status"`
+	Address string `json:"address,omitempty"`  // The state's address; for Unmanaged, the one import-blocks would use
+	Path    string `json:"path,omitempty"`     // The local file, if downloaded
+	StateID string `json:"state_id,omitempty"` // For Mismatched, the ID in the state
+	// LocalAddress is, for a Managed resource, the address import-blocks
+	// now gives its file if it isn't the state's, e.g. after its title or
+	// the name template changed
+	LocalAddress string `json:"local_address,omitempty"`
+}
+
+// StateResult is the outcome of CheckState, in kind then ID order.
+type StateResult struct {
+	Entries []StateEntry
+}
+
+// Count returns the number of entries with status.
+func (r *StateResult) Count(status Status) int {
+	n := 0
+	for _, e := range r.Entries {
+		if e.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// With returns the entries with status.
+func (r *StateResult) With(status Status) []StateEntry {
+	entries := []StateEntry{}
+	for _, e := range r.Entries {
+		if e.Status == status {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Moved returns the Managed entries whose file now has another address.
+func (r *StateResult) Moved() []StateEntry {
+	entries := []StateEntry{}
+	for _, e := range r.Entries {
+		if e.Status == Managed && e.LocalAddress != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Err returns a *resource.DifferencesError if any resource isn't Managed.
+func (r *StateResult) Err() error {
+	if n := len(r.Entries) - r.Count(Managed); n > 0 {
+		return &resource.DifferencesError{Kind: "resource", Count: n}
+	}
+	return nil
+}
+
+// CheckState compares the resources in a state with the downloaded ones,
+// if local isn't nil, and with the IDs in Datadog by kind, if remote isn't
+// nil. A local file named as a state resource, by kind and name, but with
+// another ID is Mismatched rather than both MissingLocal and Unmanaged.
+// Local addresses are in module, as for import-script; a managed resource
+// whose file has another address there has its LocalAddress set, unless the
+// state's address has an index key.
+func CheckState(state []StateResource, local []Resource, remote map[string]map[string]bool, module string) *StateResult {
+	prefix := ModulePrefix(module)
+	type key struct{ kind, id string }
+	inState := map[key]StateResource{}
+	byName := map[key]StateResource{}
+	for _, s := range state {
+		inState[key{s.Kind, s.ID}] = s
+		byName[key{s.Kind, s.Name}] = s
+	}
+	downloaded := map[key]Resource{}
+	for _, r := range local {
+		downloaded[key{r.Kind, r.ID}] = r
+	}
+
+	result := &StateResult{}
+	mismatched := map[key]bool{}
+	for _, r := range local {
+		s, ok := byName[key{r.Kind, r.Name}]
+		if !ok || s.ID == r.ID {
+			continue
+		}
+		if _, managed := inState[key{r.Kind, r.ID}]; managed {
+			continue
+		}
+		if _, found := downloaded[key{s.Kind, s.ID}]; found {
+			continue
+		}
+		mismatched[key{r.Kind, r.ID}], mismatched[key{s.Kind, s.ID}] = true, true
+		result.Entries = append(result.Entries, StateEntry{Kind: r.Kind, ID: r.ID, Status: Mismatched, Address: s.Address, Path: r.Path, StateID: s.ID})
+	}
+
+	for _, s := range state {
+		k := key{s.Kind, s.ID}
+		if mismatched[k] {
+			continue
+		}
+		entry := StateEntry{Kind: s.Kind, ID: s.ID, Status: Managed, Address: s.Address}
+		if r, ok := downloaded[k]; ok {
+			entry.Path = r.Path
+			if address := prefix + r.Address(); address != s.Address && !strings.HasSuffix(s.Address, "]") {
+				entry.LocalAddress = address
+			}
+		}
+		switch {
+		case remote != nil && remote[s.Kind] != nil && !remote[s.Kind][s.ID]:
+			entry.Status = MissingRemote
+		case local != nil && entry.Path == "":
+			entry.Status = MissingLocal
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+
+	unmanaged := map[key]StateEntry{}
+	for _, r := range local {
+		k := key{r.Kind, r.ID}
+		if _, ok := inState[k]; !ok && !mismatched[k] {
+			unmanaged[k] = StateEntry{Kind: r.Kind, ID: r.ID, Status: Unmanaged, Address: r.Address(), Path: r.Path}
+		}
+	}
+	for kind, ids := range remote {
+		for id := range ids {
+			k := key{kind, id}
+			if _, ok := inState[k]; ok || mismatched[k] {
+				continue
+			}
+			if _, ok := unmanaged[k]; !ok {
+				unmanaged[k] = StateEntry{Kind: kind, ID: id, Status: Unmanaged}
+			}
+		}
+	}
+	for _, e := range unmanaged {
+		result.Entries = append(result.Entries, e)
+	}
+
+	sort.SliceStable(result.Entries, func(i, j int) bool {
+		a, b := result.Entries[i], result.Entries[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.ID < b.ID
+	})
+	return result
+}
@@ -0,0 +1,89 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// Output formats accepted by --format.
+const (
+	FormatTable    = "table"
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+)
+
+// WriteStateResult renders r to w in the given format. Managed resources
+// are only counted; the others are listed.
+func WriteStateResult(w io.Writer, format string, r *StateResult) error {
+	switch format {
+	case "", FormatTable:
+		return writeStateTable(w, r)
+	case FormatJSON:
+		return writeStateJSON(w, r)
+	default:
+		return fmt.Errorf("unknown format %q (expected table or json)", format)
+	}
+}
+
+// statuses are the statuses of a report, in the order they are counted.
+var statuses = []Status{Managed, MissingLocal, MissingRemote, Unmanaged, Mismatched}
+
+func writeStateTable(w io.Writer, r *StateResult) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tCOUNT")
+	for _, s := range statuses {
+		fmt.Fprintf(tw, "%s\t%d\n", s, r.Count(s))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(r.Entries) == r.Count(Managed) {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tID\tSTATUS\tADDRESS\tPATH\tSTATE ID")
+	for _, e := range r.Entries {
+		if e.Status != Managed {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Kind, e.ID, e.Status, orDash(e.Address), orDash(e.Path), orDash(e.StateID))
+		}
+	}
+	return tw.Flush()
+}
+
+// StateReport is the JSON output of a state check: the managed count, then
+// the other resources by status, then the managed resources whose files
+// have moved. It is also read back by commands acting on a check's
+// findings, such as tf generate removals.
+type StateReport struct {
+	Managed       int          `json:"managed"`
+	MissingLocal  []StateEntry `json:"missing_local"`
+	MissingRemote []StateEntry `json:"missing_remote"`
+	Unmanaged     []StateEntry `json:"unmanaged"`
+	Mismatched    []StateEntry `json:"mismatched"`
+	Moved         []StateEntry `json:"moved"`
+}
+
+func writeStateJSON(w io.Writer, r *StateResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(StateReport{
+		Managed:       r.Count(Managed),
+		MissingLocal:  r.With(MissingLocal),
+		MissingRemote: r.With(MissingRemote),
+		Unmanaged:     r.With(Unmanaged),
+		Mismatched:    r.With(Mismatched),
+		Moved:         r.Moved(),
+	})
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,155 @@
+package terraform
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/terraform/naming"
+)
+
+// JSONTypes maps each resource kind to the Terraform resource type taking
+// its whole JSON document, as generated files declare them.
+var JSONTypes = map[string]string{
+	resource.KindDashboard: "datadog_dashboard_json",
+	resource.KindMonitor:   "datadog_monitor_json",
+}
+
+// jsonAttributes maps each resource kind to the attribute of its JSONTypes
+// type holding the JSON document.
+var jsonAttributes = map[string]string{
+	resource.KindDashboard: "dashboard",
+	resource.KindMonitor:   "monitor",
+}
+
+// generatedHeader starts every generated file. It has no timestamp, so
+// regenerating unchanged resources gives identical files.
+const generatedHeader = "# Generated by dd-tf from downloaded files; changes are overwritten.\n"
+
+// Layouts accepted by --layout.
+const (
+	LayoutFiles   = "files"    // Each .tf file next to the JSON files it reads
+	LayoutPerTeam = "per-team" // A module per team tag under modules/
+)
+
+// UnassignedModule is the module of resources without a team tag in
+// LayoutPerTeam.
+const UnassignedModule = "unassigned"
+
+// GenerateOptions configures Generate.
+type GenerateOptions struct {
+	ModuleRoot string // Directory ${path.module} is, default: each .tf file's directory
+	SingleFile string // If set, the name of the one file per directory holding its resources, e.g. dashboards.tf
+	Layout     string // LayoutFiles (default) or LayoutPerTeam
+	OutDir     string // With LayoutPerTeam, the directory holding modules/, default: the working directory
+}
+
+// TeamModule returns the module of r in LayoutPerTeam: its team tag as an
+// identifier, or UnassignedModule.
+func TeamModule(r Resource) string {
+	team := r.Tags["team"]
+	if team == "" {
+		return UnassignedModule
+	}
+	return naming.Sanitize(team)
+}
+
+// ModuleDir returns the directory of a module in LayoutPerTeam.
+func ModuleDir(outDir, module string) string {
+	return filepath.Join(outDir, "modules", module)
+}
+
+// File is a generated .tf file.
+type File struct {
+	Path      string
+	Content   []byte
+	Resources []Resource
+}
+
+// Generate returns the .tf files declaring each resource as its Type, one
+// of JSONTypes, reading its JSON file with file(): a sibling .tf file per
+// resource, or one file per directory with SingleFile. LayoutPerTeam puts
+// them in each resource's TeamModule directory instead, which is then the
+// module root. Files are in path order and their resources in name order.
+func Generate(resources []Resource, opts GenerateOptions) ([]File, error) {
+	byPath := map[string][]Resource{}
+	for _, r := range resources {
+		dir := filepath.Dir(r.Path)
+		if opts.Layout == LayoutPerTeam {
+			dir = ModuleDir(opts.OutDir, TeamModule(r))
+		}
+		path := filepath.Join(dir, strings.TrimSuffix(filepath.Base(r.Path), filepath.Ext(r.Path))+".tf")
+		if opts.SingleFile != "" {
+			path = filepath.Join(dir, opts.SingleFile)
+		}
+		byPath[path] = append(byPath[path], r)
+	}
+
+	files := make([]File, 0, len(byPath))
+	for path, resources := range byPath {
+		sort.Slice(resources, func(i, j int) bool { return resources[i].Address() < resources[j].Address() })
+		root := opts.ModuleRoot
+		if root == "" || opts.Layout == LayoutPerTeam {
+			root = filepath.Dir(path)
+		}
+		var buf bytes.Buffer
+		buf.WriteString(generatedHeader)
+		for _, r := range resources {
+			block, err := resourceBlock(r, root)
+			if err != nil {
+				return nil, err
+			}
+			buf.WriteString("\n")
+			buf.WriteString(block)
+		}
+		files = append(files, File{Path: path, Content: buf.Bytes(), Resources: resources})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// resourceBlock returns the resource block for r, with its JSON file's path
+// relative to the module root.
+func resourceBlock(r Resource, root string) (string, error) {
+	attribute, ok := jsonAttributes[r.Kind]
+	if !ok {
+		return "", fmt.Errorf("%s: no JSON resource type for %q", r.Path, r.Kind)
+	}
+	path := r.Path
+	if r.JSONPath != "" {
+		path = r.JSONPath
+	}
+	if filepath.IsAbs(root) != filepath.IsAbs(path) {
+		// e.g. a module under the working directory reading DATA_DIR
+		var err error
+		if root, err = filepath.Abs(root); err != nil {
+			return "", err
+		}
+		if path, err = filepath.Abs(path); err != nil {
+			return "", err
+		}
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", r.Path, err)
+	}
+	// ${path.module} is a template sequence, so only the path is escaped
+	file := `"${path.module}/` + hclEscaper.Replace(filepath.ToSlash(rel)) + `"`
+	return fmt.Sprintf("resource %s %s {\n  %s = file(%s)\n}\n", QuoteString(r.Type), QuoteString(r.Name), attribute, file), nil
+}
+
+// RootModules returns the main.tf in outDir calling each module of
+// LayoutPerTeam, in name order.
+func RootModules(outDir string, modules []string) File {
+	modules = append([]string(nil), modules...)
+	sort.Strings(modules)
+	var buf bytes.Buffer
+	buf.WriteString(generatedHeader)
+	for _, m := range modules {
+		fmt.Fprintf(&buf, "\nmodule %s {\n  source = %s\n}\n", QuoteString(m), QuoteString("./"+filepath.ToSlash(filepath.Join("modules", m))))
+	}
+	return File{Path: filepath.Join(outDir, "main.tf"), Content: buf.Bytes()}
+}
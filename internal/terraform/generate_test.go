@@ -0,0 +1,104 @@
+package terraform
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	resources := []Resource{
+		{Kind: "dashboard", Type: "datadog_dashboard_json", Name: "web_cpu", Path: filepath.Join("data", "dashboards", "web", "abc-def-ghi.json")},
+		{Kind: "dashboard", Type: "datadog_dashboard_json", Name: "web_api", Path: filepath.Join("data", "dashboards", "web", "abc-def-jkl.json")},
+		{Kind: "dashboard", Type: "datadog_dashboard_json", Name: "db", Path: filepath.Join("data", "dashboards", "${x}.json")},
+	}
+
+	files, err := Generate(resources, GenerateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 || files[1].Path != filepath.Join("data", "dashboards", "web", "abc-def-ghi.tf") {
+		t.Fatalf("Generate() files = %+v", files)
+	}
+	want := generatedHeader + `
+resource "datadog_dashboard_json" "web_cpu" {
+  dashboard = file("${path.module}/abc-def-ghi.json")
+}
+`
+	if string(files[1].Content) != want {
+		t.Errorf("Generate() content =\n%s\nwant\n%s", files[1].Content, want)
+	}
+	if !strings.Contains(string(files[0].Content), `file("${path.module}/$${x}.json")`) {
+		t.Errorf("Generate() didn't escape the path:\n%s", files[0].Content)
+	}
+
+	files, err = Generate(resources, GenerateOptions{ModuleRoot: "data", SingleFile: "dashboards.tf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	for _, f := range files {
+		buf.WriteString("# " + filepath.ToSlash(f.Path) + "\n")
+		buf.Write(f.Content)
+	}
+	golden := filepath.Join("testdata", "dashboards.tf.golden")
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wantFiles, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("missing golden file (run with -update): %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), wantFiles) {
+		t.Errorf("Generate() with SingleFile =\n%s\nwant\n%s", buf.Bytes(), wantFiles)
+	}
+
+	// Regenerating, in any order, gives the same files
+	again, _ := Generate([]Resource{resources[2], resources[1], resources[0]}, GenerateOptions{ModuleRoot: "data", SingleFile: "dashboards.tf"})
+	for i := range files {
+		if !bytes.Equal(files[i].Content, again[i].Content) {
+			t.Errorf("Generate() isn't stable for %s", files[i].Path)
+		}
+	}
+}
+
+func TestGenerate_PerTeam(t *testing.T) {
+	resources := []Resource{
+		{Kind: "monitor", Type: "datadog_monitor_json", Name: "cpu", Tags: map[string]string{"team": "Web Platform"}, Path: filepath.Join("data", "monitors", "1.json")},
+		{Kind: "monitor", Type: "datadog_monitor_json", Name: "disk", Path: filepath.Join("data", "monitors", "2.json")},
+	}
+
+	files, err := Generate(resources, GenerateOptions{Layout: LayoutPerTeam, OutDir: "tf", SingleFile: "monitors.tf", ModuleRoot: "ignored"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, filepath.ToSlash(f.Path))
+	}
+	if want := []string{"tf/modules/unassigned/monitors.tf", "tf/modules/web_platform/monitors.tf"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("Generate() per team paths = %v, want %v", paths, want)
+	}
+	if !strings.Contains(string(files[1].Content), `monitor = file("${path.module}/../../../data/monitors/1.json")`) {
+		t.Errorf("Generate() per team content =\n%s", files[1].Content)
+	}
+
+	root := RootModules("tf", []string{"web_platform", UnassignedModule})
+	want := generatedHeader + `
+module "unassigned" {
+  source = "./modules/unassigned"
+}
+
+module "web_platform" {
+  source = "./modules/web_platform"
+}
+`
+	if root.Path != filepath.Join("tf", "main.tf") || string(root.Content) != want {
+		t.Errorf("RootModules() = %s:\n%s\nwant\n%s", root.Path, root.Content, want)
+	}
+}
@@ -0,0 +1,80 @@
+package terraform
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteImportBlocks writes a Terraform 1.5+ import block for each resource,
+// in order.
+func WriteImportBlocks(w io.Writer, resources []Resource) error {
+	for i, r := range resources {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "import {\n  to = %s\n  id = %s\n}\n", r.Address(), QuoteString(r.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hclEscaper escapes a string for an HCL quoted string, including the
+// ${ and %{ template sequences.
+var hclEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+	"${", "$${",
+	"%{", "%%{",
+)
+
+// QuoteString returns s as an HCL quoted string.
+func QuoteString(s string) string {
+	return `"` + hclEscaper.Replace(s) + `"`
+}
+
+// ScriptOptions configures WriteImportScript.
+type ScriptOptions struct {
+	Module   string // Module the resources are in, e.g. monitors or team.monitors; empty for the root module
+	QuoteIDs bool   // Single-quote IDs for the shell
+}
+
+// WriteImportScript writes a terraform import command for each resource, in
+// order, for Terraform versions without import blocks.
+func WriteImportScript(w io.Writer, resources []Resource, opts ScriptOptions) error {
+	prefix := ModulePrefix(opts.Module)
+	for _, r := range resources {
+		id := r.ID
+		if opts.QuoteIDs {
+			id = shellQuote(id)
+		}
+		if _, err := fmt.Fprintf(w, "terraform import %s %s\n", shellQuote(prefix+r.Address()), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ModulePrefix returns the address prefix of module, e.g. module.a.module.b.
+// for a.b. A module already written as an address is kept as it is.
+func ModulePrefix(module string) string {
+	module = strings.Trim(module, ".")
+	switch {
+	case module == "":
+		return ""
+	case strings.HasPrefix(module, "module."):
+		return module + "."
+	}
+	return "module." + strings.ReplaceAll(module, ".", ".module.") + "."
+}
+
+// shellQuote single-quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
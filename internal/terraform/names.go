@@ -0,0 +1,26 @@
+package terraform
+
+import (
+	"fmt"
+
+	"github.com/AD7six/dd-tf/internal/terraform/naming"
+)
+
+// Fields returns the values r's name is rendered from.
+func (r Resource) Fields() naming.Fields {
+	return naming.Fields{ID: r.ID, Title: r.Title, Priority: r.Priority, Tags: r.Tags}
+}
+
+// AssignNames sets the Name of each resource, in order, de-duplicating
+// names by resource type, so with resources in a stable order the
+// addresses are too.
+func AssignNames(resources []Resource, namer *naming.Namer) error {
+	for i := range resources {
+		name, err := namer.Unique(resources[i].Type, resources[i].Fields())
+		if err != nil {
+			return fmt.Errorf("%s: %w", resources[i].Path, err)
+		}
+		resources[i].Name = name
+	}
+	return nil
+}
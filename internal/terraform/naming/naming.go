@@ -0,0 +1,135 @@
+// Package naming renders the Terraform resource names of dashboards and
+// monitors from a template, so every generated file, import block and
+// state check gives a resource the same address.
+package naming
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/datadog/templating"
+)
+
+// DefaultTemplate is the template names are rendered from unless
+// TF_RESOURCE_NAME_TEMPLATE or --name-template is set.
+const DefaultTemplate = "{team}_{title}"
+
+// MaxLength is the longest name returned, de-duplication suffix included.
+// Terraform has no limit, but addresses this long are already unwieldy.
+const MaxLength = 64
+
+var (
+	// invalidChars matches runs of characters not allowed in a Terraform
+	// identifier, or not wanted in one.
+	invalidChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+	// IdentifierRegex matches the names Sanitize returns.
+	IdentifierRegex = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+	// folder replaces accented Latin letters with their ASCII base, so
+	// "Überblick" becomes "uberblick" rather than "berblick".
+	folder = strings.NewReplacer(
+		"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a", "æ", "ae",
+		"ç", "c", "è", "e", "é", "e", "ê", "e", "ë", "e",
+		"ì", "i", "í", "i", "î", "i", "ï", "i", "ð", "d", "ñ", "n",
+		"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o", "œ", "oe",
+		"ù", "u", "ú", "u", "û", "u", "ü", "u", "ý", "y", "ÿ", "y", "þ", "th", "ß", "ss",
+		"ą", "a", "ć", "c", "č", "c", "ď", "d", "ę", "e", "ě", "e", "ğ", "g", "ı", "i",
+		"ł", "l", "ń", "n", "ň", "n", "ő", "o", "ř", "r", "ś", "s", "š", "s", "ş", "s",
+		"ť", "t", "ů", "u", "ű", "u", "ź", "z", "ż", "z", "ž", "z",
+	)
+)
+
+// builtins maps template placeholders to Fields; other placeholders are
+// tags.
+var builtins = map[string]string{
+	"{id}":       "{{.ID}}",
+	"{title}":    "{{.Title}}",
+	"{name}":     "{{.Title}}",
+	"{priority}": "{{.Priority}}",
+}
+
+// Fields are the values a name template is rendered from.
+type Fields struct {
+	ID       string
+	Title    string // A dashboard's title or a monitor's name
+	Priority string // A monitor's priority, empty if unset
+	Tags     map[string]string
+}
+
+// Sanitize turns s into a Terraform identifier of at most MaxLength
+// characters: lowercase, accents removed, and every run of other characters
+// than ASCII letters, digits and underscores replaced by one underscore. A
+// leading digit is prefixed with an underscore, and an empty result is
+// "resource".
+func Sanitize(s string) string {
+	s = invalidChars.ReplaceAllString(folder.Replace(strings.ToLower(s)), "_")
+	s = strings.Trim(s, "_")
+	switch {
+	case s == "":
+		return "resource"
+	case s[0] >= '0' && s[0] <= '9':
+		s = "_" + s
+	}
+	return truncate(s, MaxLength)
+}
+
+// truncate shortens the identifier s to at most n characters, without a
+// trailing underscore.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if t := strings.TrimRight(s[:n], "_"); t != "" {
+		return t
+	}
+	return s[:n]
+}
+
+// Namer renders names from a template.
+type Namer struct {
+	pattern string
+	taken   map[string]bool
+}
+
+// New returns a Namer for template, DefaultTemplate if empty, which may use
+// {id}, {title}, {name}, {priority} and tag placeholders such as {team}. A
+// tag a resource doesn't have renders as "none", as in path templates.
+func New(template string) (*Namer, error) {
+	if template == "" {
+		template = DefaultTemplate
+	}
+	n := &Namer{pattern: templating.TranslatePlaceholders(template, builtins), taken: map[string]bool{}}
+	if _, err := n.Name(Fields{}); err != nil {
+		return nil, fmt.Errorf("invalid name template %q: %w", template, err)
+	}
+	return n, nil
+}
+
+// Name returns the sanitized name of f, without de-duplication.
+func (n *Namer) Name(f Fields) (string, error) {
+	name, err := templating.ComputePathFromTemplate(n.pattern, f)
+	if err != nil {
+		return "", err
+	}
+	return Sanitize(name), nil
+}
+
+// Unique returns the name of f, suffixed _2, _3 and so on if an earlier call
+// with the same scope, such as the Terraform resource type, returned it.
+// Given the same calls in the same order, the names are the same.
+func (n *Namer) Unique(scope string, f Fields) (string, error) {
+	name, err := n.Name(f)
+	if err != nil {
+		return "", err
+	}
+	unique := name
+	for i := 2; n.taken[scope+"."+unique]; i++ {
+		suffix := "_" + strconv.Itoa(i)
+		unique = truncate(name, MaxLength-len(suffix)) + suffix
+	}
+	n.taken[scope+"."+unique] = true
+	return unique, nil
+}
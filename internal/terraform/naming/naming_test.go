@@ -0,0 +1,93 @@
+package naming
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := map[string]string{
+		"web_CPU usage":        "web_cpu_usage",
+		"5xx rate > 10%":       "_5xx_rate_10",
+		"2024":                 "_2024",
+		"__a--b__":             "a_b",
+		"CPU \"usage\" ${env}": "cpu_usage_env",
+		"Überblick Straße":     "uberblick_strasse",
+		"Crème brûlée":         "creme_brulee",
+		"Zażółć gęślą jaźń":    "zazolc_gesla_jazn",
+		"web_ダッシュボード":          "web",
+		"ダッシュボード":              "resource",
+		"":                     "resource",
+		"!!!":                  "resource",
+		"already_valid_123":    "already_valid_123",
+		"line\nbreak":          "line_break",
+	}
+	for in, want := range tests {
+		got := Sanitize(in)
+		if got != want {
+			t.Errorf("Sanitize(%q) = %q, want %q", in, got, want)
+		}
+		if !IdentifierRegex.MatchString(got) {
+			t.Errorf("Sanitize(%q) = %q is not an identifier", in, got)
+		}
+	}
+}
+
+func TestSanitize_Long(t *testing.T) {
+	long := strings.Repeat("very long title ", 10)
+	got := Sanitize(long)
+	if len(got) > MaxLength || !strings.HasPrefix(got, "very_long_title_very") || strings.HasSuffix(got, "_") {
+		t.Errorf("Sanitize(long) = %q (%d characters)", got, len(got))
+	}
+	if got := Sanitize(strings.Repeat("9", 100)); len(got) != MaxLength || got[0] != '_' {
+		t.Errorf("Sanitize(digits) = %q", got)
+	}
+}
+
+func TestNamer(t *testing.T) {
+	n, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := []struct {
+		scope  string
+		fields Fields
+		want   string
+	}{
+		{"datadog_monitor", Fields{Title: "CPU", Tags: map[string]string{"team": "web"}}, "web_cpu"},
+		{"datadog_monitor", Fields{Title: "cpu", Tags: map[string]string{"team": "Web"}}, "web_cpu_2"},
+		{"datadog_dashboard_json", Fields{Title: "CPU", Tags: map[string]string{"team": "web"}}, "web_cpu"},
+		{"datadog_monitor", Fields{Title: "CPU"}, "none_cpu"},
+		{"datadog_monitor", Fields{Title: "cpu!", Tags: map[string]string{"team": "web"}}, "web_cpu_3"},
+	}
+	for _, c := range calls {
+		if got, err := n.Unique(c.scope, c.fields); err != nil || got != c.want {
+			t.Errorf("Unique(%s, %+v) = %q, %v, want %q", c.scope, c.fields, got, err, c.want)
+		}
+	}
+
+	long := Fields{Title: strings.Repeat("x", 100)}
+	first, _ := n.Unique("datadog_monitor", long)
+	second, _ := n.Unique("datadog_monitor", long)
+	if len(first) != MaxLength || len(second) != MaxLength || !strings.HasSuffix(second, "_2") || first == second {
+		t.Errorf("Unique() of long names = %q, %q", first, second)
+	}
+
+	for template, want := range map[string]string{
+		"{priority}-{id}":   "_3_1",
+		"{name}":            "cpu",
+		"{env}_{id}":        "prod_1",
+		"{service}_{title}": "none_cpu",
+	} {
+		n, err := New(template)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := n.Name(Fields{ID: "1", Priority: "3", Title: "CPU", Tags: map[string]string{"env": "prod"}}); got != want {
+			t.Errorf("Name(%s) = %q, want %q", template, got, want)
+		}
+	}
+	if _, err := New("{{.Missing"); err == nil {
+		t.Error("New() of an invalid template expected an error")
+	}
+}
@@ -0,0 +1,139 @@
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/logging"
+)
+
+// Findings are the entries of a check-state or drift JSON report that
+// removals act on.
+type Findings struct {
+	// MissingRemote are the resources deleted from Datadog. A check-state
+	// report gives their addresses; a drift report only their files.
+	MissingRemote []StateEntry `json:"missing_remote"`
+	// Moved are the managed resources whose files now have another
+	// address, from a check-state report.
+	Moved []StateEntry `json:"moved"`
+}
+
+// ReadFindings decodes a tf check-state or drift report written with
+// --format json.
+func ReadFindings(r io.Reader) (Findings, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Findings{}, fmt.Errorf("failed to read report: %w", err)
+	}
+	var report struct {
+		Findings
+		Managed *int `json:"managed"` // check-state
+		InSync  *int `json:"in_sync"` // drift
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return Findings{}, fmt.Errorf("failed to decode report: %w", err)
+	}
+	if report.Managed == nil && report.InSync == nil {
+		return Findings{}, fmt.Errorf("not a tf check-state or drift report written with --format json")
+	}
+	return report.Findings, nil
+}
+
+// Removal is a resource to remove from the state.
+type Removal struct {
+	Kind    string
+	ID      string
+	Address string
+}
+
+// Move is a resource whose address changed.
+type Move struct {
+	Kind string
+	ID   string
+	From string
+	To   string
+	Path string
+}
+
+// Removals are the state changes a report's findings call for.
+type Removals struct {
+	Removed []Removal
+	Moved   []Move
+}
+
+// PlanRemovals returns the removals and moves of f. A deleted resource
+// without an address, from a drift report, is looked up by its file in
+// local, as import-blocks names it in module; one that can't be is logged
+// and skipped.
+func PlanRemovals(f Findings, local []Resource, module string) Removals {
+	byPath := map[string]Resource{}
+	for _, r := range local {
+		byPath[filepath.Clean(r.Path)] = r
+	}
+	var plan Removals
+	for _, e := range f.MissingRemote {
+		address := e.Address
+		if address == "" {
+			r, ok := byPath[filepath.Clean(e.Path)]
+			if !ok || e.Path == "" {
+				logging.Logger.Warn("no address for deleted resource, skipping", "kind", e.Kind, "id", e.ID, "path", e.Path)
+				continue
+			}
+			address = ModulePrefix(module) + r.Address()
+		}
+		plan.Removed = append(plan.Removed, Removal{Kind: e.Kind, ID: e.ID, Address: address})
+	}
+	for _, e := range f.Moved {
+		plan.Moved = append(plan.Moved, Move{Kind: e.Kind, ID: e.ID, From: e.Address, To: e.LocalAddress, Path: e.Path})
+	}
+	return plan
+}
+
+// WriteRemovedBlocks writes a removed block (Terraform 1.7+) per removal,
+// keeping the deleted resource out of a destroy, then the moves as comments
+// to review. removed blocks can't name an instance, so a removal whose
+// address has an index key is a comment with its state rm command instead.
+func WriteRemovedBlocks(w io.Writer, plan Removals) error {
+	var buf bytes.Buffer
+	buf.WriteString(generatedHeader)
+	for _, r := range plan.Removed {
+		fmt.Fprintf(&buf, "\n# %s %s was deleted from Datadog\n", r.Kind, r.ID)
+		if strings.HasSuffix(r.Address, "]") {
+			fmt.Fprintf(&buf, "# removed blocks can't name an instance, run instead:\n#   terraform state rm %s\n", shellQuote(r.Address))
+			continue
+		}
+		fmt.Fprintf(&buf, "removed {\n  from = %s\n\n  lifecycle {\n    destroy = false\n  }\n}\n", r.Address)
+	}
+	writeMoveNotes(&buf, plan.Moved)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteStateRmScript writes a terraform state rm command per removal, for
+// Terraform versions without removed blocks, then the moves as comments.
+func WriteStateRmScript(w io.Writer, plan Removals) error {
+	var buf bytes.Buffer
+	for _, r := range plan.Removed {
+		fmt.Fprintf(&buf, "terraform state rm %s\n", shellQuote(r.Address))
+	}
+	writeMoveNotes(&buf, plan.Moved)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeMoveNotes writes a comment per move, mapping the state's address to
+// the one the resource's file now has. They're notes rather than moved
+// blocks as a move between modules also needs the modules rewired.
+func writeMoveNotes(buf *bytes.Buffer, moves []Move) {
+	if len(moves) == 0 {
+		return
+	}
+	buf.WriteString("\n# Moved, update the state or configuration to match:\n")
+	for _, m := range moves {
+		fmt.Fprintf(buf, "#   %s -> %s (%s %s, %s)\n", m.From, m.To, m.Kind, m.ID, m.Path)
+	}
+}
@@ -0,0 +1,91 @@
+package terraform
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadFindings(t *testing.T) {
+	f, err := ReadFindings(strings.NewReader(`{"managed":1,"missing_remote":[{"kind":"monitor","id":"777","status":"missing-remote","address":"datadog_monitor.load"}],"moved":[]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.MissingRemote) != 1 || f.MissingRemote[0].Address != "datadog_monitor.load" {
+		t.Errorf("ReadFindings(check-state) = %+v", f)
+	}
+
+	f, err = ReadFindings(strings.NewReader(`{"in_sync":0,"drifted":[],"missing_remote":[{"kind":"monitor","id":"123","path":"data/monitors/123.json","status":"missing-remote"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.MissingRemote) != 1 || f.MissingRemote[0].Path != "data/monitors/123.json" {
+		t.Errorf("ReadFindings(drift) = %+v", f)
+	}
+
+	for _, invalid := range []string{`{`, `{"missing_remote":[]}`} {
+		if _, err := ReadFindings(strings.NewReader(invalid)); err == nil {
+			t.Errorf("ReadFindings(%s) expected an error", invalid)
+		}
+	}
+}
+
+func TestPlanRemovals(t *testing.T) {
+	local := []Resource{{Kind: "monitor", ID: "123", Type: "datadog_monitor", Name: "cpu", Path: filepath.Join("data", "monitors", "123.json")}}
+	f := Findings{
+		MissingRemote: []StateEntry{
+			{Kind: "monitor", ID: "777", Address: `datadog_monitor.by_env[1]`},
+			{Kind: "monitor", ID: "123", Path: "data/monitors/123.json"},
+			{Kind: "monitor", ID: "999", Path: "data/monitors/999.json"}, // Not downloaded: skipped
+			{Kind: "dashboard", ID: "abc-def-ghi", Address: "module.web.datadog_dashboard_json.web_cpu"},
+		},
+		Moved: []StateEntry{
+			{Kind: "dashboard", ID: "abc-def-jkl", Address: "datadog_dashboard_json.web_api", LocalAddress: "module.web.datadog_dashboard_json.web_api", Path: "data/dashboards/web/abc-def-jkl.json"},
+		},
+	}
+	plan := PlanRemovals(f, local, "monitors")
+	want := []Removal{
+		{Kind: "monitor", ID: "777", Address: `datadog_monitor.by_env[1]`},
+		{Kind: "monitor", ID: "123", Address: "module.monitors.datadog_monitor.cpu"},
+		{Kind: "dashboard", ID: "abc-def-ghi", Address: "module.web.datadog_dashboard_json.web_cpu"},
+	}
+	if !reflect.DeepEqual(plan.Removed, want) {
+		t.Errorf("PlanRemovals() removed = %+v, want %+v", plan.Removed, want)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteRemovedBlocks(&buf, plan); err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "removed.tf.golden")
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wantBlocks, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("missing golden file (run with -update): %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), wantBlocks) {
+		t.Errorf("WriteRemovedBlocks() =\n%s\nwant\n%s", buf.Bytes(), wantBlocks)
+	}
+
+	buf.Reset()
+	if err := WriteStateRmScript(&buf, plan); err != nil {
+		t.Fatal(err)
+	}
+	wantScript := `terraform state rm 'datadog_monitor.by_env[1]'
+terraform state rm 'module.monitors.datadog_monitor.cpu'
+terraform state rm 'module.web.datadog_dashboard_json.web_cpu'
+
+# Moved, update the state or configuration to match:
+#   datadog_dashboard_json.web_api -> module.web.datadog_dashboard_json.web_api (dashboard abc-def-jkl, data/dashboards/web/abc-def-jkl.json)
+`
+	if buf.String() != wantScript {
+		t.Errorf("WriteStateRmScript() =\n%s\nwant\n%s", buf.String(), wantScript)
+	}
+}
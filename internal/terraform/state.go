@@ -0,0 +1,141 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+)
+
+// StateTypes maps the Terraform resource types managing dashboards and
+// monitors to their kind. Other datadog_dashboard* and datadog_monitor*
+// types, such as datadog_dashboard_list, manage other resources.
+var StateTypes = map[string]string{
+	"datadog_dashboard":      resource.KindDashboard,
+	"datadog_dashboard_json": resource.KindDashboard,
+	"datadog_monitor":        resource.KindMonitor,
+	"datadog_monitor_json":   resource.KindMonitor,
+}
+
+// StateResource is a dashboard or monitor managed in a Terraform state.
+type StateResource struct {
+	Kind    string `json:"kind"`
+	Address string `json:"address"` // e.g. module.team.datadog_monitor.cpu["prod"]
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	ID      string `json:"id"`
+}
+
+// rawState decodes both a state file (version 4) and the output of
+// terraform show -json, which nests resources in modules.
+type rawState struct {
+	Version   int `json:"version"`
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey   any            `json:"index_key"`
+			Attributes map[string]any `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+	FormatVersion string `json:"format_version"`
+	Values        *struct {
+		RootModule showModule `json:"root_module"`
+	} `json:"values"`
+}
+
+// showModule is a module in terraform show -json output.
+type showModule struct {
+	Resources []struct {
+		Address string         `json:"address"`
+		Mode    string         `json:"mode"`
+		Type    string         `json:"type"`
+		Name    string         `json:"name"`
+		Values  map[string]any `json:"values"`
+	} `json:"resources"`
+	ChildModules []showModule `json:"child_modules"`
+}
+
+// ReadState returns the dashboards and monitors managed in a Terraform
+// state, read either from a state file or from terraform show -json, in
+// the state's order.
+func ReadState(r io.Reader) ([]StateResource, error) {
+	var state rawState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to decode state: %w", err)
+	}
+	switch {
+	case state.FormatVersion != "":
+		var resources []StateResource
+		if state.Values != nil {
+			resources = appendShowModule(resources, state.Values.RootModule)
+		}
+		return resources, nil
+	case state.Version == 4:
+		return stateFileResources(state), nil
+	}
+	return nil, fmt.Errorf("unsupported state: expected a version 4 state file or terraform show -json output")
+}
+
+func stateFileResources(state rawState) []StateResource {
+	var resources []StateResource
+	for _, r := range state.Resources {
+		kind, ok := StateTypes[r.Type]
+		if !ok || r.Mode != "managed" {
+			continue
+		}
+		address := r.Type + "." + r.Name
+		if r.Module != "" {
+			address = r.Module + "." + address
+		}
+		for _, instance := range r.Instances {
+			resources = append(resources, StateResource{
+				Kind:    kind,
+				Address: address + indexSuffix(instance.IndexKey),
+				Type:    r.Type,
+				Name:    r.Name,
+				ID:      stateID(instance.Attributes["id"]),
+			})
+		}
+	}
+	return resources
+}
+
+func appendShowModule(resources []StateResource, module showModule) []StateResource {
+	for _, r := range module.Resources {
+		if kind, ok := StateTypes[r.Type]; ok && r.Mode == "managed" {
+			resources = append(resources, StateResource{Kind: kind, Address: r.Address, Type: r.Type, Name: r.Name, ID: stateID(r.Values["id"])})
+		}
+	}
+	for _, child := range module.ChildModules {
+		resources = appendShowModule(resources, child)
+	}
+	return resources
+}
+
+// indexSuffix returns the address suffix of a count or for_each instance.
+func indexSuffix(key any) string {
+	switch k := key.(type) {
+	case float64:
+		return "[" + strconv.FormatInt(int64(k), 10) + "]"
+	case string:
+		return "[" + strconv.Quote(k) + "]"
+	}
+	return ""
+}
+
+// stateID formats an id attribute, a string for both kinds in practice.
+func stateID(v any) string {
+	switch id := v.(type) {
+	case string:
+		return strings.TrimSpace(id)
+	case float64:
+		return strconv.FormatInt(int64(id), 10)
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/terraform/naming"
+)
+
+func readState(t *testing.T, name string) []StateResource {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	state, err := ReadState(f)
+	if err != nil {
+		t.Fatalf("ReadState(%s) error = %v", name, err)
+	}
+	return state
+}
+
+func TestReadState(t *testing.T) {
+	want := []StateResource{
+		{Kind: resource.KindDashboard, Address: "datadog_dashboard_json.web_cpu_usage_env_uberblick", Type: "datadog_dashboard_json", Name: "web_cpu_usage_env_uberblick", ID: "abc-def-ghi"},
+		{Kind: resource.KindMonitor, Address: "module.api.datadog_monitor.api_5xx_rate_10", Type: "datadog_monitor", Name: "api_5xx_rate_10", ID: "999"},
+		{Kind: resource.KindMonitor, Address: `datadog_monitor.by_env["prod"]`, Type: "datadog_monitor", Name: "by_env", ID: "456"},
+		{Kind: resource.KindMonitor, Address: "datadog_monitor.by_env[1]", Type: "datadog_monitor", Name: "by_env", ID: "777"},
+	}
+	if got := readState(t, "terraform.tfstate"); !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadState(state file) =\n%+v\nwant\n%+v", got, want)
+	}
+
+	want = []StateResource{
+		{Kind: resource.KindDashboard, Address: "datadog_dashboard_json.web", Type: "datadog_dashboard_json", Name: "web", ID: "abc-def-ghi"},
+		{Kind: resource.KindMonitor, Address: "module.api.datadog_monitor_json.cpu", Type: "datadog_monitor_json", Name: "cpu", ID: "123"},
+	}
+	if got := readState(t, "show.json"); !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadState(show -json) =\n%+v\nwant\n%+v", got, want)
+	}
+
+	for _, invalid := range []string{`{`, `{"version":3}`} {
+		if _, err := ReadState(strings.NewReader(invalid)); err == nil {
+			t.Errorf("ReadState(%s) expected an error", invalid)
+		}
+	}
+}
+
+func TestCheckState(t *testing.T) {
+	state := readState(t, "terraform.tfstate")
+	local, err := Scan(filepath.Join("testdata", "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := naming.New(naming.DefaultTemplate)
+	if err := AssignNames(local, n); err != nil {
+		t.Fatal(err)
+	}
+	remote := map[string]map[string]bool{
+		resource.KindDashboard: {"abc-def-ghi": true, "abc-def-jkl": true, "rem-ote-one": true},
+		resource.KindMonitor:   {"123": true, "456": true},
+	}
+
+	result := CheckState(state, local, remote, "")
+	got := map[string]Status{}
+	for _, e := range result.Entries {
+		got[e.Kind+"/"+e.ID] = e.Status
+	}
+	want := map[string]Status{
+		"dashboard/abc-def-ghi": Managed,
+		"dashboard/abc-def-jkl": Unmanaged,
+		"dashboard/rem-ote-one": Unmanaged,
+		"monitor/123":           Mismatched, // api_5xx_rate_10 is 999 in the state
+		"monitor/456":           Managed,
+		"monitor/777":           MissingRemote,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckState() = %v, want %v", got, want)
+	}
+	for _, e := range result.With(Mismatched) {
+		if e.StateID != "999" || e.Address != "module.api.datadog_monitor.api_5xx_rate_10" {
+			t.Errorf("mismatched entry = %+v", e)
+		}
+	}
+	if err := result.Err(); err == nil {
+		t.Error("Err() = nil, want differences")
+	}
+	if moved := result.Moved(); len(moved) != 0 {
+		t.Errorf("Moved() = %+v, want none", moved)
+	}
+
+	// In another module, the managed dashboard's file has moved; the
+	// monitor's state address has an index key, so it's left alone
+	moved := CheckState(state, local, remote, "web").Moved()
+	if len(moved) != 1 || moved[0].ID != "abc-def-ghi" || moved[0].LocalAddress != "module.web.datadog_dashboard_json.web_cpu_usage_env_uberblick" {
+		t.Errorf("Moved() in module web = %+v", moved)
+	}
+
+	// Without remote IDs, state resources not downloaded are missing locally
+	result = CheckState(state, local, nil, "")
+	if got := result.With(MissingLocal); len(got) != 1 || got[0].ID != "777" {
+		t.Errorf("CheckState() without remote missing locally = %+v", got)
+	}
+	if err := CheckState(nil, nil, nil, "").Err(); err != nil {
+		t.Errorf("Err() of an empty check = %v", err)
+	}
+}
+
+func TestWriteStateResult(t *testing.T) {
+	result := &StateResult{Entries: []StateEntry{
+		{Kind: "monitor", ID: "1", Status: Managed, Address: "datadog_monitor.a"},
+		{Kind: "monitor", ID: "2", Status: Unmanaged, Path: "data/monitors/2.json"},
+	}}
+	var buf bytes.Buffer
+	if err := WriteStateResult(&buf, FormatJSON, result); err != nil {
+		t.Fatal(err)
+	}
+	var report StateReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Managed != 1 || len(report.Unmanaged) != 1 || report.MissingLocal == nil || report.Moved == nil {
+		t.Errorf("WriteStateResult(json) = %s", buf.Bytes())
+	}
+
+	buf.Reset()
+	if err := WriteStateResult(&buf, FormatTable, result); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "monitor  2   unmanaged  -        data/monitors/2.json  -") {
+		t.Errorf("WriteStateResult(table) =\n%s", buf.String())
+	}
+	if err := WriteStateResult(&buf, "xml", result); err == nil {
+		t.Error("WriteStateResult(xml) expected an error")
+	}
+}
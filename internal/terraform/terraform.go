@@ -0,0 +1,115 @@
+// Package terraform generates Terraform configuration for downloaded
+// dashboards and monitors, so they can be imported into and managed by
+// Terraform.
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/datadog/templating"
+	"github.com/AD7six/dd-tf/internal/logging"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/AD7six/dd-tf/internal/terraform/naming"
+)
+
+// ResourceTypes maps each resource kind to the Terraform resource type that
+// manages it.
+var ResourceTypes = map[string]string{
+	resource.KindDashboard: "datadog_dashboard_json",
+	resource.KindMonitor:   "datadog_monitor",
+}
+
+// Resource is a downloaded resource file and its Terraform address.
+type Resource struct {
+	Kind     string // resource.KindDashboard or resource.KindMonitor
+	ID       string
+	Title    string // A dashboard's title or a monitor's name
+	Priority string // A monitor's priority, empty if unset
+	Tags     map[string]string
+	Path     string
+	JSONPath string // File generated resources read, if not Path
+	Type     string // Terraform resource type
+	Name     string // Terraform resource name, set by AssignNames
+}
+
+// Address returns the resource's Terraform address, e.g.
+// datadog_monitor.team_cpu.
+func (r Resource) Address() string {
+	return r.Type + "." + r.Name
+}
+
+// Scan reads the dashboards and monitors under dir, in path order. Files
+// that aren't valid JSON or don't look like either are skipped.
+func Scan(dir string) ([]Resource, error) {
+	files := storage.FileBackend{}
+	paths, err := files.List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []Resource
+	for _, path := range paths {
+		if !strings.HasSuffix(path, ".json") || storage.IsSidecarPath(path) {
+			continue
+		}
+		data, err := files.Read(path)
+		if err != nil {
+			return nil, err
+		}
+		var content map[string]any
+		if err := json.Unmarshal(data, &content); err != nil {
+			logging.Logger.Warn("skipping invalid JSON", "path", path, "error", err)
+			continue // validate reports invalid files
+		}
+		kind := resource.GuessKind(content)
+		if kind == "" {
+			continue
+		}
+		r := Resource{Kind: kind, Path: path, Type: ResourceTypes[kind], Tags: templating.ExtractTagMap(content["tags"], false)}
+		switch kind {
+		case resource.KindDashboard:
+			r.ID, _ = content["id"].(string)
+			r.Title, _ = content["title"].(string)
+		case resource.KindMonitor:
+			r.ID = strconv.FormatInt(int64(content["id"].(float64)), 10)
+			r.Title, _ = content["name"].(string)
+			if p, ok := content["priority"].(float64); ok {
+				r.Priority = strconv.FormatInt(int64(p), 10)
+			}
+		}
+		resources = append(resources, r)
+	}
+	sort.SliceStable(resources, func(i, j int) bool { return resources[i].Path < resources[j].Path })
+	return resources, nil
+}
+
+// ParseTypes parses kind=type overrides of ResourceTypes, e.g.
+// dashboard=datadog_dashboard, returning the resulting mapping.
+func ParseTypes(overrides []string) (map[string]string, error) {
+	types := make(map[string]string, len(ResourceTypes))
+	for kind, typ := range ResourceTypes {
+		types[kind] = typ
+	}
+	for _, o := range overrides {
+		kind, typ, ok := strings.Cut(o, "=")
+		kind, typ = strings.TrimSpace(kind), strings.TrimSpace(typ)
+		if _, known := ResourceTypes[kind]; !ok || !known || !naming.IdentifierRegex.MatchString(typ) {
+			return nil, fmt.Errorf("invalid resource type %q (expected dashboard=<type> or monitor=<type>)", o)
+		}
+		types[kind] = typ
+	}
+	return types, nil
+}
+
+// SetTypes sets the Type of each resource from types, a kind to Terraform
+// type mapping such as ParseTypes returns.
+func SetTypes(resources []Resource, types map[string]string) {
+	for i := range resources {
+		resources[i].Type = types[resources[i].Kind]
+	}
+}
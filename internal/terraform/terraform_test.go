@@ -0,0 +1,141 @@
+package terraform
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/terraform/naming"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func TestScan(t *testing.T) {
+	resources, err := Scan(filepath.Join("testdata", "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 4 {
+		t.Fatalf("Scan() found %d resources, want 4", len(resources))
+	}
+	got := resources[2]
+	if got.Kind != resource.KindMonitor || got.ID != "123" || got.Title != "5xx rate > 10%" || got.Priority != "2" || got.Tags["team"] != "api" || got.Type != "datadog_monitor" {
+		t.Errorf("Scan() monitor = %+v", got)
+	}
+	if _, err := Scan(filepath.Join("testdata", "missing")); err == nil {
+		t.Error("Scan() of a missing directory expected an error")
+	}
+}
+
+// importBlock matches a well-formed import block.
+var importBlock = regexp.MustCompile(`^import \{\n  to = datadog_(dashboard_json|monitor)\.[a-z_][a-z0-9_]*\n  id = "(?:[^"\\$%]|\\.|\$\$\{|%%\{)*"\n\}\n$`)
+
+func TestWriteImportBlocks_Golden(t *testing.T) {
+	resources, err := Scan(filepath.Join("testdata", "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := naming.New(naming.DefaultTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := AssignNames(resources, n); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteImportBlocks(&buf, resources); err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "imports.tf.golden")
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("missing golden file (run with -update): %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("WriteImportBlocks() =\n%s\nwant\n%s", buf.Bytes(), want)
+	}
+	for _, block := range bytes.Split(buf.Bytes(), []byte("\n\n")) {
+		if !importBlock.Match(append(bytes.TrimSuffix(block, []byte("\n")), '\n')) {
+			t.Errorf("invalid import block:\n%s", block)
+		}
+	}
+}
+
+func TestAssignNames(t *testing.T) {
+	n, _ := naming.New("{title}")
+	resources := []Resource{
+		{Type: "datadog_monitor", Title: "CPU"},
+		{Type: "datadog_dashboard_json", Title: "CPU"},
+		{Type: "datadog_monitor", Title: "cpu"},
+	}
+	if err := AssignNames(resources, n); err != nil {
+		t.Fatal(err)
+	}
+	if resources[0].Name != "cpu" || resources[1].Name != "cpu" || resources[2].Name != "cpu_2" {
+		t.Errorf("AssignNames() = %+v", resources)
+	}
+}
+
+func TestQuoteString(t *testing.T) {
+	if got, want := QuoteString("a \"b\" \\ ${c} %{d}\n"), `"a \"b\" \\ $${c} %%{d}\n"`; got != want {
+		t.Errorf("QuoteString() = %s, want %s", got, want)
+	}
+}
+
+func TestParseTypes(t *testing.T) {
+	types, err := ParseTypes([]string{"dashboard=datadog_dashboard"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if types[resource.KindDashboard] != "datadog_dashboard" || types[resource.KindMonitor] != "datadog_monitor" {
+		t.Errorf("ParseTypes() = %v", types)
+	}
+	if ResourceTypes[resource.KindDashboard] != "datadog_dashboard_json" {
+		t.Error("ParseTypes() changed ResourceTypes")
+	}
+	for _, invalid := range []string{"dashboard", "slo=datadog_slo", "monitor=not valid"} {
+		if _, err := ParseTypes([]string{invalid}); err == nil {
+			t.Errorf("ParseTypes(%q) expected an error", invalid)
+		}
+	}
+}
+
+func TestWriteImportScript(t *testing.T) {
+	resources := []Resource{
+		{ID: "abc-def-ghi", Type: "datadog_dashboard_json", Name: "web_cpu"},
+		{ID: "123", Type: "datadog_monitor", Name: "_5xx"},
+	}
+	tests := []struct {
+		opts ScriptOptions
+		want string
+	}{
+		{ScriptOptions{}, "terraform import 'datadog_dashboard_json.web_cpu' abc-def-ghi\nterraform import 'datadog_monitor._5xx' 123\n"},
+		{ScriptOptions{Module: "monitors", QuoteIDs: true}, "terraform import 'module.monitors.datadog_dashboard_json.web_cpu' 'abc-def-ghi'\nterraform import 'module.monitors.datadog_monitor._5xx' '123'\n"},
+		{ScriptOptions{Module: "team.monitors"}, "terraform import 'module.team.module.monitors.datadog_dashboard_json.web_cpu' abc-def-ghi\nterraform import 'module.team.module.monitors.datadog_monitor._5xx' 123\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := WriteImportScript(&buf, resources, tt.opts); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("WriteImportScript(%+v) =\n%s\nwant\n%s", tt.opts, buf.String(), tt.want)
+		}
+	}
+	if got := shellQuote("it's"); got != `'it'\''s'` {
+		t.Errorf("shellQuote() = %s", got)
+	}
+	if got := ModulePrefix("module.a.module.b"); got != "module.a.module.b." {
+		t.Errorf("ModulePrefix() = %s", got)
+	}
+}
@@ -0,0 +1,182 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+)
+
+// RemoteResource is a dashboard or monitor in Datadog, as listed by
+// tf unmanaged.
+type RemoteResource struct {
+	Kind   string   `json:"kind"`
+	ID     string   `json:"id"`
+	Title  string   `json:"title"`
+	Author string   `json:"author,omitempty"` // Dashboard author or monitor creator, if known
+	Tags   []string `json:"tags"`
+}
+
+// DecodeRemote decodes the title, author and tags of a dashboard or
+// monitor payload, from its list endpoint or its own.
+func DecodeRemote(kind, id string, raw []byte) (RemoteResource, error) {
+	var meta struct {
+		Title        string   `json:"title"`
+		Name         string   `json:"name"`
+		AuthorHandle string   `json:"author_handle"`
+		Tags         []string `json:"tags"`
+		Creator      struct {
+			Handle string `json:"handle"`
+			Email  string `json:"email"`
+			Name   string `json:"name"`
+		} `json:"creator"`
+	}
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return RemoteResource{}, fmt.Errorf("failed to decode %s %s: %w", kind, id, err)
+	}
+	r := RemoteResource{Kind: kind, ID: id, Title: meta.Title, Author: meta.AuthorHandle, Tags: meta.Tags}
+	if kind == resource.KindMonitor {
+		r.Title = meta.Name
+		r.Author = firstNonEmpty(meta.Creator.Handle, meta.Creator.Email, meta.Creator.Name)
+	}
+	if r.Tags == nil {
+		r.Tags = []string{}
+	}
+	return r, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// KindCount is how many resources of a kind are in Datadog, and how many of
+// those aren't in the state.
+type KindCount struct {
+	Kind      string `json:"kind"`
+	Total     int    `json:"total"`
+	Unmanaged int    `json:"unmanaged"`
+}
+
+// Summary returns e.g. "73/210 monitors unmanaged".
+func (c KindCount) Summary() string {
+	return fmt.Sprintf("%d/%d %ss unmanaged", c.Unmanaged, c.Total, c.Kind)
+}
+
+// UnmanagedReport lists the resources in Datadog that aren't in a
+// Terraform state.
+type UnmanagedReport struct {
+	Counts    []KindCount      `json:"counts"`
+	Unmanaged []RemoteResource `json:"unmanaged"`
+}
+
+// FindUnmanaged returns the resources in remote, by kind, whose ID isn't in
+// state. Every kind in remote is counted, even when it has no resources.
+func FindUnmanaged(state []StateResource, remote map[string][]RemoteResource) *UnmanagedReport {
+	type key struct{ kind, id string }
+	inState := map[key]bool{}
+	for _, s := range state {
+		inState[key{s.Kind, s.ID}] = true
+	}
+	kinds := make([]string, 0, len(remote))
+	for kind := range remote {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	report := &UnmanagedReport{Counts: []KindCount{}, Unmanaged: []RemoteResource{}}
+	for _, kind := range kinds {
+		count := KindCount{Kind: kind, Total: len(remote[kind])}
+		resources := append([]RemoteResource(nil), remote[kind]...)
+		sort.Slice(resources, func(i, j int) bool { return resources[i].ID < resources[j].ID })
+		for _, r := range resources {
+			if !inState[key{kind, r.ID}] {
+				count.Unmanaged++
+				report.Unmanaged = append(report.Unmanaged, r)
+			}
+		}
+		report.Counts = append(report.Counts, count)
+	}
+	return report
+}
+
+// WriteUnmanaged renders r to w as a table, JSON or Markdown, each with a
+// summary line per kind.
+func WriteUnmanaged(w io.Writer, format string, r *UnmanagedReport) error {
+	switch format {
+	case "", FormatTable:
+		return writeUnmanagedTable(w, r)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case FormatMarkdown:
+		return writeUnmanagedMarkdown(w, r)
+	default:
+		return fmt.Errorf("unknown format %q (expected table, json or markdown)", format)
+	}
+}
+
+func writeUnmanagedTable(w io.Writer, r *UnmanagedReport) error {
+	if len(r.Unmanaged) > 0 {
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "KIND\tID\tTITLE\tAUTHOR\tTAGS")
+		for _, u := range r.Unmanaged {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", u.Kind, u.ID, orDash(u.Title), orDash(u.Author), orDash(strings.Join(u.Tags, ",")))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+	for _, c := range r.Counts {
+		fmt.Fprintln(w, c.Summary())
+	}
+	return nil
+}
+
+func writeUnmanagedMarkdown(w io.Writer, r *UnmanagedReport) error {
+	var b strings.Builder
+	b.WriteString("## Unmanaged resources\n\n")
+	for _, c := range r.Counts {
+		fmt.Fprintf(&b, "- %s\n", c.Summary())
+	}
+	if len(r.Unmanaged) > 0 {
+		b.WriteString("\n| Kind | ID | Title | Author | Tags |\n| --- | --- | --- | --- | --- |\n")
+		for _, u := range r.Unmanaged {
+			tags := make([]string, len(u.Tags))
+			for i, t := range u.Tags {
+				tags[i] = markdownCode(t)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", u.Kind, markdownCode(u.ID), markdownCell(u.Title), markdownCell(u.Author), orDash(strings.Join(tags, " ")))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCode formats s as inline code in a table cell, or "-" if it is
+// empty.
+func markdownCode(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return "`" + strings.ReplaceAll(s, "|", `\|`) + "`"
+}
+
+// markdownCell escapes s for a table cell, or returns "-" if it is empty.
+func markdownCell(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "|", `\|`)
+}
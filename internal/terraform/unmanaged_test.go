@@ -0,0 +1,118 @@
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+)
+
+func TestDecodeRemote(t *testing.T) {
+	got, err := DecodeRemote(resource.KindMonitor, "123", []byte(`{"id":123,"name":"CPU high","creator":{"email":"jane@example.com","handle":"jane","name":"Jane"},"tags":["team:web"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := RemoteResource{Kind: resource.KindMonitor, ID: "123", Title: "CPU high", Author: "jane", Tags: []string{"team:web"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeRemote(monitor) = %+v, want %+v", got, want)
+	}
+
+	got, err = DecodeRemote(resource.KindDashboard, "abc-def-ghi", []byte(`{"id":"abc-def-ghi","title":"Web","author_handle":"bob@example.com"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = RemoteResource{Kind: resource.KindDashboard, ID: "abc-def-ghi", Title: "Web", Author: "bob@example.com", Tags: []string{}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeRemote(dashboard) = %+v, want %+v", got, want)
+	}
+
+	if _, err := DecodeRemote(resource.KindMonitor, "1", []byte(`{`)); err == nil {
+		t.Error("DecodeRemote(invalid) expected an error")
+	}
+}
+
+func unmanagedReport(t *testing.T) *UnmanagedReport {
+	t.Helper()
+	state := readState(t, "terraform.tfstate")
+	return FindUnmanaged(state, map[string][]RemoteResource{
+		resource.KindDashboard: {
+			{Kind: resource.KindDashboard, ID: "abc-def-ghi", Title: "Web", Tags: []string{}},
+		},
+		resource.KindMonitor: {
+			{Kind: resource.KindMonitor, ID: "888", Title: "Disk | full", Author: "jane", Tags: []string{"team:web", "env:prod"}},
+			{Kind: resource.KindMonitor, ID: "456", Title: "Latency", Tags: []string{}},
+			{Kind: resource.KindMonitor, ID: "123", Title: "CPU", Tags: []string{}},
+		},
+	})
+}
+
+func TestFindUnmanaged(t *testing.T) {
+	got := unmanagedReport(t)
+	wantCounts := []KindCount{
+		{Kind: resource.KindDashboard, Total: 1, Unmanaged: 0},
+		{Kind: resource.KindMonitor, Total: 3, Unmanaged: 2},
+	}
+	if !reflect.DeepEqual(got.Counts, wantCounts) {
+		t.Errorf("Counts = %+v, want %+v", got.Counts, wantCounts)
+	}
+	var ids []string
+	for _, u := range got.Unmanaged {
+		ids = append(ids, u.ID)
+	}
+	if want := []string{"123", "888"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Unmanaged IDs = %v, want %v", ids, want)
+	}
+	if s := got.Counts[1].Summary(); s != "2/3 monitors unmanaged" {
+		t.Errorf("Summary() = %q", s)
+	}
+}
+
+func TestWriteUnmanaged(t *testing.T) {
+	r := unmanagedReport(t)
+
+	var buf bytes.Buffer
+	if err := WriteUnmanaged(&buf, FormatTable, r); err != nil {
+		t.Fatal(err)
+	}
+	want := `KIND     ID   TITLE        AUTHOR  TAGS
+monitor  123  CPU          -       -
+monitor  888  Disk | full  jane    team:web,env:prod
+
+0/1 dashboards unmanaged
+2/3 monitors unmanaged
+`
+	if buf.String() != want {
+		t.Errorf("WriteUnmanaged(table) =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := WriteUnmanaged(&buf, FormatMarkdown, r); err != nil {
+		t.Fatal(err)
+	}
+	want = "## Unmanaged resources\n\n- 0/1 dashboards unmanaged\n- 2/3 monitors unmanaged\n\n" +
+		"| Kind | ID | Title | Author | Tags |\n| --- | --- | --- | --- | --- |\n" +
+		"| monitor | `123` | CPU | - | - |\n" +
+		"| monitor | `888` | Disk \\| full | jane | `team:web` `env:prod` |\n"
+	if buf.String() != want {
+		t.Errorf("WriteUnmanaged(markdown) =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := WriteUnmanaged(&buf, FormatJSON, r); err != nil {
+		t.Fatal(err)
+	}
+	var decoded UnmanagedReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&decoded, r) {
+		t.Errorf("WriteUnmanaged(json) round trip = %+v, want %+v", decoded, r)
+	}
+
+	if err := WriteUnmanaged(&buf, "yaml", r); err == nil || !strings.Contains(err.Error(), "yaml") {
+		t.Errorf("WriteUnmanaged(yaml) error = %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package utils
+
+import "regexp"
+
+// secretPatterns are credentials that should never end up in exported
+// resources. Keys are reported by name, never by value.
+var secretPatterns = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"datadog-api-key", regexp.MustCompile(`(?i)(dd|datadog)[_-]?api[_-]?key["'\s:=]+[0-9a-f]{32}\b`)},
+	{"datadog-app-key", regexp.MustCompile(`(?i)(dd|datadog)[_-]?app(lication)?[_-]?key["'\s:=]+[0-9a-f]{40}\b`)},
+	{"aws-access-key-id", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abposr]-[0-9A-Za-z-]{10,}`)},
+	{"slack-webhook", regexp.MustCompile(`https://hooks\.slack\.com/services/[A-Za-z0-9/]+`)},
+	{"github-token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+}
+
+// FindSecrets returns the names of the secret patterns that match data.
+func FindSecrets(data []byte) []string {
+	var found []string
+	for _, p := range secretPatterns {
+		if p.re.Match(data) {
+			found = append(found, p.name)
+		}
+	}
+	return found
+}
@@ -0,0 +1,198 @@
+// Package verify checks a directory of downloaded resources without calling
+// the API, for use in pre-commit hooks and CI.
+package verify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	"github.com/AD7six/dd-tf/internal/storage"
+	"github.com/AD7six/dd-tf/internal/utils"
+)
+
+// Rule identifiers, one per category of violation.
+const (
+	RuleInvalidJSON   = "invalid-json"
+	RuleUnknownShape  = "unknown-shape"
+	RuleIDMismatch    = "id-mismatch"
+	RuleTooLarge      = "too-large"
+	RuleDuplicateID   = "duplicate-id"
+	RuleSecret        = "secret"
+	RuleFormat        = "format"
+	RuleOrphanPresets = "orphan-presets"
+	RuleOrphanPolicy  = "orphan-policy"
+)
+
+// Violation is a problem found in one file.
+type Violation struct {
+	Path    string `json:"path"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: [%s] %s", v.Path, v.Rule, v.Message)
+}
+
+// Result is the outcome of Run: every file checked and the problems found.
+type Result struct {
+	Files      []File
+	Violations []Violation
+}
+
+// File is a checked resource file.
+type File struct {
+	Path string
+	Kind string // resource kind, empty if not recognised
+	ID   string
+}
+
+// Options configures Run.
+type Options struct {
+	MaxSize       int64             // files larger than this are rejected; 0 disables the check
+	PathTemplates map[string]string // resource kind -> path template, used for the id check
+	Fix           bool              // rewrite badly formatted files instead of reporting them
+}
+
+// Run checks every .json file under dir. Files and violations are in path
+// order.
+func Run(dir string, opts Options) (*Result, error) {
+	files := storage.FileBackend{}
+	paths, err := files.List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	report := func(path, rule, format string, args ...any) {
+		result.Violations = append(result.Violations, Violation{Path: path, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+	seen := map[string]string{} // kind:id -> first path
+	listed := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		listed[path] = true
+	}
+
+	for _, path := range paths {
+		if !strings.HasSuffix(path, ".json") {
+			continue
+		}
+		data, err := files.Read(path)
+		if err != nil {
+			return nil, err
+		}
+		if storage.IsPresetsPath(path) {
+			// Presets split out of a dashboard (DASHBOARDS_SPLIT_PRESETS)
+			// aren't resources, but need their dashboard next to them
+			if parent := strings.TrimSuffix(path, storage.PresetsSuffix) + ".json"; !listed[parent] {
+				report(path, RuleOrphanPresets, "no dashboard at %s", parent)
+			}
+			if !json.Valid(data) {
+				report(path, RuleInvalidJSON, "presets file is not valid JSON")
+			}
+			continue
+		}
+		if storage.IsPolicyPath(path) {
+			// Restriction policies (--with-restriction-policy) belong to
+			// the resource next to them
+			if parent := strings.TrimSuffix(path, storage.PolicySuffix) + ".json"; !listed[parent] {
+				report(path, RuleOrphanPolicy, "no resource at %s", parent)
+			}
+			if !json.Valid(data) {
+				report(path, RuleInvalidJSON, "restriction policy file is not valid JSON")
+			}
+			continue
+		}
+		result.Files = append(result.Files, File{Path: path})
+		file := &result.Files[len(result.Files)-1]
+		if opts.MaxSize > 0 && int64(len(data)) > opts.MaxSize {
+			report(path, RuleTooLarge, "file is %d bytes, limit is %d", len(data), opts.MaxSize)
+			continue
+		}
+
+		var content map[string]any
+		if err := json.Unmarshal(data, &content); err != nil {
+			report(path, RuleInvalidJSON, "%v", err)
+			continue
+		}
+
+		for _, name := range utils.FindSecrets(data) {
+			report(path, RuleSecret, "contains something that looks like a %s", name)
+		}
+
+		kind := resource.GuessKind(content)
+		if kind == "" {
+			report(path, RuleUnknownShape, "not recognised as a dashboard or monitor")
+		} else {
+			id := idString(content["id"])
+			file.Kind, file.ID = kind, id
+			if !filenameMatches(path, id, opts.PathTemplates[kind]) {
+				report(path, RuleIDMismatch, "%s id %q does not match the file name", kind, id)
+			}
+			key := kind + ":" + id
+			if first, ok := seen[key]; ok {
+				report(path, RuleDuplicateID, "%s id %q is also in %s", kind, id, first)
+			} else {
+				seen[key] = path
+			}
+		}
+
+		canonical, err := storage.FormatJSON(data)
+		if err != nil || bytes.Equal(canonical, data) {
+			continue
+		}
+		if !opts.Fix {
+			report(path, RuleFormat, "not formatted like a downloaded file (run with --fix)")
+			continue
+		}
+		if err := files.Write(path, canonical); err != nil {
+			return nil, fmt.Errorf("failed to fix %s: %w", path, err)
+		}
+	}
+	return result, nil
+}
+
+// idString formats a decoded id the way it appears in paths.
+func idString(v any) string {
+	switch id := v.(type) {
+	case string:
+		return id
+	case float64:
+		return strconv.FormatInt(int64(id), 10)
+	}
+	return ""
+}
+
+// filenameMatches reports whether a file name is consistent with id. The
+// check only applies when the template's file name contains {id}.
+func filenameMatches(path, id, template string) bool {
+	if !strings.Contains(filepath.Base(template), "{id}") {
+		return true
+	}
+	if id == "" {
+		return false
+	}
+	base := strings.TrimSuffix(filepath.Base(path), ".json")
+	// The id must appear as a whole token, so 12 doesn't match 123.json
+	for i := strings.Index(base, id); i >= 0; {
+		end := i + len(id)
+		if (i == 0 || !isAlphanumeric(base[i-1])) && (end == len(base) || !isAlphanumeric(base[end])) {
+			return true
+		}
+		next := strings.Index(base[i+1:], id)
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return false
+}
+
+func isAlphanumeric(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
@@ -0,0 +1,105 @@
+package verify
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var templates = map[string]string{
+	"dashboard": "data/dashboards/{id}.json",
+	"monitor":   "data/monitors/{id}.json",
+}
+
+func TestRun_Good(t *testing.T) {
+	result, err := Run(filepath.Join("testdata", "good"), Options{MaxSize: 1024, PathTemplates: templates})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Errorf("Run() found violations in good data: %v", result.Violations)
+	}
+	want := []File{
+		{Path: filepath.Join("testdata", "good", "dashboards", "abc-def-ghi.json"), Kind: "dashboard", ID: "abc-def-ghi"},
+		{Path: filepath.Join("testdata", "good", "monitors", "123.json"), Kind: "monitor", ID: "123"},
+	}
+	if !reflect.DeepEqual(result.Files, want) {
+		t.Errorf("Run() files = %v, want %v", result.Files, want)
+	}
+}
+
+func TestRun_Bad(t *testing.T) {
+	dir := filepath.Join("testdata", "bad")
+	result, err := Run(dir, Options{MaxSize: 1024, PathTemplates: templates})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	got := map[string][]string{}
+	for _, v := range result.Violations {
+		rel, _ := filepath.Rel(dir, v.Path)
+		got[filepath.ToSlash(rel)] = append(got[filepath.ToSlash(rel)], v.Rule)
+	}
+	want := map[string][]string{
+		"dashboards/abc-def-ghi.json":      {RuleFormat},
+		"dashboards/broken.json":           {RuleInvalidJSON},
+		"dashboards/copy-abc-def-ghi.json": {RuleDuplicateID},
+		"dashboards/gone.policy.json":      {RuleOrphanPolicy},
+		"dashboards/gone.presets.json":     {RuleOrphanPresets},
+		"dashboards/notes.json":            {RuleUnknownShape},
+		"dashboards/xyz-uvw-rst.json":      {RuleSecret},
+		"monitors/12.json":                 {RuleIDMismatch},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Run() violations =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestRun_TooLarge(t *testing.T) {
+	result, err := Run(filepath.Join("testdata", "good"), Options{MaxSize: 10, PathTemplates: templates})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.Violations) != 2 || result.Violations[0].Rule != RuleTooLarge {
+		t.Errorf("Run() = %v, want two too-large violations", result.Violations)
+	}
+}
+
+func TestRun_Fix(t *testing.T) {
+	dir := t.TempDir()
+	src, _ := os.ReadFile(filepath.Join("testdata", "bad", "dashboards", "abc-def-ghi.json"))
+	path := filepath.Join(dir, "abc-def-ghi.json")
+	os.WriteFile(path, src, 0o644)
+
+	result, err := Run(dir, Options{PathTemplates: templates, Fix: true})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Errorf("Run(--fix) = %v, want no violations", result.Violations)
+	}
+	fixed, _ := os.ReadFile(path)
+	want := "{\n  \"id\": \"abc-def-ghi\",\n  \"title\": \"Compact\",\n  \"layout_type\": \"ordered\",\n  \"widgets\": []\n}\n"
+	if string(fixed) != want {
+		t.Errorf("fixed file =\n%s\nwant\n%s", fixed, want)
+	}
+}
+
+func TestFilenameMatches(t *testing.T) {
+	tests := []struct {
+		path, id, template string
+		want               bool
+	}{
+		{"data/monitors/123.json", "123", "data/monitors/{id}.json", true},
+		{"data/monitors/12.json", "123", "data/monitors/{id}.json", false},
+		{"data/monitors/123.json", "12", "data/monitors/{id}.json", false},
+		{"data/monitors/High-load-123.json", "123", "data/monitors/{name}-{id}.json", true},
+		{"data/monitors/anything.json", "123", "data/monitors/{name}.json", true},
+	}
+	for _, tt := range tests {
+		if got := filenameMatches(tt.path, tt.id, tt.template); got != tt.want {
+			t.Errorf("filenameMatches(%q, %q, %q) = %v, want %v", tt.path, tt.id, tt.template, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+// Package ddtf is the Go API for embedding dd-tf in other tools.
+//
+// Configuration is passed explicitly as a Config: nothing is read from the
+// environment or a .env file (other than {ENV_VAR} placeholders in path
+// templates, which are resolved as in the CLI). Results are returned as a
+// Report; progress is logged to stderr as in the CLI.
+//
+// Stability: the exported identifiers in this package are the supported API.
+// Fields may be added to Config, Options and Report, but existing ones won't
+// be removed or change meaning without a major version bump. Everything under
+// internal/ may change at any time.
+package ddtf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AD7six/dd-tf/internal/config"
+	"github.com/AD7six/dd-tf/internal/datadog/dashboards"
+	"github.com/AD7six/dd-tf/internal/datadog/monitors"
+	"github.com/AD7six/dd-tf/internal/datadog/resource"
+	internalhttp "github.com/AD7six/dd-tf/internal/http"
+)
+
+// Errors that a Failure's Err (and Report.Err) can match with errors.Is and
+// errors.As.
+var (
+	ErrNotFound  = resource.ErrNotFound  // The API returned 404
+	ErrForbidden = resource.ErrForbidden // The API returned 403
+)
+
+// RateLimitedError is returned when the API was still rate limiting requests
+// after all retries; RetryAfter is the wait it last asked for.
+type RateLimitedError = internalhttp.RateLimitedError
+
+// Config holds the settings for a run. Zero values use the CLI defaults.
+type Config struct {
+	APIKey string // Required, Datadog API key
+	AppKey string // Required, Datadog application key
+
+	// Site is the Datadog site, e.g. "datadoghq.eu" (default "datadoghq.com").
+	// A value with a scheme, e.g. "http://127.0.0.1:8080", is used as the API
+	// base URL as is, which is handy for tests.
+	Site string
+
+	DashboardsPathTemplate string        // Default "data/dashboards/{id}.json"
+	MonitorsPathTemplate   string        // Default "data/monitors/{id}.json"
+	HTTPTimeout            time.Duration // Default 60s
+	PageSize               int           // Page size for list endpoints, default 1000
+	IncludeMonitorRuntime  bool          // Keep runtime fields such as matching_downtimes on monitors
+	MonitorGroupStates     string        // Store monitor group states: "all", "alert" or "warn"
+	StripWidgetIDs         bool          // Remove widget IDs from dashboards
+	SplitPresets           bool          // Write dashboard template variable presets to a sibling file
+}
+
+// Options selects which resources to download. Exactly one of IDs, All,
+// Team/Tags or Update is normally set, as with the CLI flags.
+type Options struct {
+	IDs        []string // Resource IDs (numeric strings for monitors)
+	All        bool     // Download every resource
+	Team       string   // Filter by the team:<Team> tag
+	Tags       []string // Filter by tags (all must match)
+	Update     bool     // Re-download resources that already have a local file
+	OutputPath string   // Path template overriding the Config template
+	Priority   int      // Monitors only: filter by priority
+	Hooks      Hooks    // Optional callbacks for download events
+}
+
+// Hooks are optional callbacks for download events, e.g. to record files in
+// another system as they are written. They may be called concurrently and
+// must be safe for concurrent use. A panicking hook is recovered and reported
+// as a Failure for that resource.
+type Hooks struct {
+	OnTargetDiscovered func(id string)            // A resource was selected, before it is downloaded
+	OnDownloaded       func(id, path string)      // A resource was written to path
+	OnError            func(id string, err error) // A resource failed; id is empty for listing errors
+}
+
+// Report is the outcome of a download run.
+type Report struct {
+	Downloaded []Downloaded
+	Failed     []Failure
+	Restricted []string // IDs the API refused with 403; each is also in Failed
+}
+
+// Downloaded is a resource that was written.
+type Downloaded struct {
+	ID   string
+	Path string
+}
+
+// Failure is a resource, or a listing step (empty ID), that failed.
+type Failure struct {
+	ID   string
+	Path string // Local path, when it was known before the failure
+	Err  error
+}
+
+// Err returns the failures joined into one error, or nil if there were none.
+func (r Report) Err() error {
+	errs := make([]error, 0, len(r.Failed))
+	for _, f := range r.Failed {
+		if f.ID == "" {
+			errs = append(errs, f.Err)
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", f.ID, f.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// DownloadDashboards downloads the dashboards selected by opts. Failures of
+// individual dashboards are returned in the Report; the error is only set if
+// the run couldn't start or ctx was cancelled.
+func DownloadDashboards(ctx context.Context, cfg Config, opts Options) (Report, error) {
+	settings, err := cfg.settings()
+	if err != nil {
+		return Report{}, err
+	}
+	client := internalhttp.NewClient(settings)
+
+	targets, err := dashboards.GenerateDashboardTargets(ctx, client, settings, dashboards.DownloadOptions{
+		BaseDownloadOptions: opts.base(),
+	})
+	if err != nil {
+		return Report{}, err
+	}
+	formatID := func(id string) string { return id }
+	return download(ctx, targets, resource.Downloader[string]{
+		Kind:     "dashboard",
+		FormatID: formatID,
+		Strict:   true,
+		Hooks:    hooksFor(opts.Hooks, formatID),
+		Download: func(ctx context.Context, target dashboards.DashboardTarget) (string, error) {
+			return dashboards.DownloadDashboardWithOptions(ctx, client, settings, target, opts.OutputPath)
+		},
+	})
+}
+
+// DownloadMonitors downloads the monitors selected by opts. Failures of
+// individual monitors are returned in the Report; the error is only set if
+// the run couldn't start or ctx was cancelled.
+func DownloadMonitors(ctx context.Context, cfg Config, opts Options) (Report, error) {
+	settings, err := cfg.settings()
+	if err != nil {
+		return Report{}, err
+	}
+	client := internalhttp.NewClient(settings)
+
+	targets, err := monitors.GenerateMonitorTargets(ctx, client, settings, monitors.DownloadOptions{
+		BaseDownloadOptions: opts.base(),
+		Priority:            opts.Priority,
+	})
+	if err != nil {
+		return Report{}, err
+	}
+	return download(ctx, targets, resource.Downloader[int]{
+		Kind:     "monitor",
+		FormatID: strconv.Itoa,
+		Strict:   true,
+		Hooks:    hooksFor(opts.Hooks, strconv.Itoa),
+		Download: func(ctx context.Context, target monitors.MonitorTarget) (string, error) {
+			return monitors.DownloadMonitorWithOptions(ctx, client, settings, target, opts.OutputPath)
+		},
+	})
+}
+
+// download runs a resource.Downloader over targets and converts its summary
+// into a Report.
+func download[T comparable](ctx context.Context, targets <-chan resource.TargetResult[T], downloader resource.Downloader[T]) (Report, error) {
+	summary := downloader.Run(ctx, targets)
+
+	report := Report{Restricted: summary.Restricted}
+	for _, d := range summary.Downloaded {
+		report.Downloaded = append(report.Downloaded, Downloaded{ID: d.ID, Path: d.Path})
+	}
+	for _, err := range summary.Errors {
+		var targetErr *resource.TargetError
+		if errors.As(err, &targetErr) {
+			report.Failed = append(report.Failed, Failure{ID: targetErr.ID, Path: targetErr.Path, Err: targetErr.Err})
+			continue
+		}
+		report.Failed = append(report.Failed, Failure{Err: err})
+	}
+	return report, ctx.Err()
+}
+
+// hooksFor adapts the public hooks to resource.Hooks for IDs of type T.
+func hooksFor[T comparable](h Hooks, formatID func(T) string) resource.Hooks[T] {
+	var hooks resource.Hooks[T]
+	if h.OnTargetDiscovered != nil {
+		hooks.OnTargetDiscovered = func(target resource.Target[T]) {
+			h.OnTargetDiscovered(formatID(target.ID))
+		}
+	}
+	if h.OnDownloaded != nil {
+		hooks.OnDownloaded = func(target resource.Target[T], path string) {
+			h.OnDownloaded(formatID(target.ID), path)
+		}
+	}
+	if h.OnError != nil {
+		hooks.OnError = func(target resource.Target[T], err error) {
+			var zero T
+			id := ""
+			if target.ID != zero {
+				id = formatID(target.ID)
+			}
+			h.OnError(id, err)
+		}
+	}
+	return hooks
+}
+
+// settings converts cfg into internal settings, starting from the embedded
+// defaults rather than the environment.
+func (cfg Config) settings() (*config.Settings, error) {
+	if cfg.APIKey == "" || cfg.AppKey == "" {
+		return nil, errors.New("ddtf: Config.APIKey and Config.AppKey are required")
+	}
+	settings, err := config.Defaults()
+	if err != nil {
+		return nil, err
+	}
+	settings.APIKey = cfg.APIKey
+	settings.AppKey = cfg.AppKey
+	if cfg.Site != "" {
+		settings.Site = strings.TrimPrefix(strings.ToLower(cfg.Site), "api.")
+	}
+	if cfg.DashboardsPathTemplate != "" {
+		settings.DashboardsPathTemplate = cfg.DashboardsPathTemplate
+	}
+	if cfg.MonitorsPathTemplate != "" {
+		settings.MonitorsPathTemplate = cfg.MonitorsPathTemplate
+	}
+	if cfg.HTTPTimeout > 0 {
+		settings.HTTPTimeout = cfg.HTTPTimeout
+	}
+	if cfg.PageSize > 0 {
+		settings.PageSize = cfg.PageSize
+		settings.DashboardsPageSize = cfg.PageSize
+		settings.MonitorsPageSize = cfg.PageSize
+	}
+	settings.MonitorsIncludeRuntime = cfg.IncludeMonitorRuntime
+	if err := config.ValidateGroupStates(cfg.MonitorGroupStates); err != nil {
+		return nil, fmt.Errorf("MonitorGroupStates: %w", err)
+	}
+	settings.MonitorsGroupStates = cfg.MonitorGroupStates
+	settings.DashboardsStripWidgetIDs = cfg.StripWidgetIDs
+	settings.DashboardsSplitPresets = cfg.SplitPresets
+	// Every selected resource is written and reported as Downloaded
+	settings.HTTPCache = false
+	return settings, nil
+}
+
+func (opts Options) base() resource.BaseDownloadOptions {
+	return resource.BaseDownloadOptions{
+		All:        opts.All,
+		Update:     opts.Update,
+		OutputPath: opts.OutputPath,
+		Team:       opts.Team,
+		Tags:       strings.Join(opts.Tags, ","),
+		IDs:        strings.Join(opts.IDs, ","),
+	}
+}
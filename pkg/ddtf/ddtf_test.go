@@ -0,0 +1,115 @@
+package ddtf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDownloadMonitors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("DD-API-KEY") != "api-key" {
+			t.Errorf("missing API key header")
+		}
+		switch r.URL.Path {
+		case "/api/v1/monitor/123":
+			fmt.Fprint(w, `{"id":123,"name":"CPU high","matching_downtimes":[{"id":1}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	hooks := Hooks{
+		OnDownloaded: func(id, path string) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, "downloaded "+id)
+		},
+		OnError: func(id string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, "error "+id)
+		},
+	}
+
+	dir := t.TempDir()
+	report, err := DownloadMonitors(context.Background(), Config{
+		APIKey:               "api-key",
+		AppKey:               "app-key",
+		Site:                 server.URL,
+		MonitorsPathTemplate: filepath.Join(dir, "{id}.json"),
+	}, Options{IDs: []string{"123", "456"}, Hooks: hooks})
+	if err != nil {
+		t.Fatalf("DownloadMonitors() error = %v", err)
+	}
+
+	if len(report.Downloaded) != 1 || report.Downloaded[0].ID != "123" {
+		t.Fatalf("Downloaded = %+v, want monitor 123", report.Downloaded)
+	}
+	data, err := os.ReadFile(report.Downloaded[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "matching_downtimes") {
+		t.Errorf("monitor was not normalised: %s", data)
+	}
+
+	if len(report.Failed) != 1 || report.Failed[0].ID != "456" {
+		t.Fatalf("Failed = %+v, want monitor 456", report.Failed)
+	}
+	if err := report.Err(); err == nil || !strings.Contains(err.Error(), "456") {
+		t.Errorf("Report.Err() = %v, want error mentioning 456", err)
+	}
+	if !errors.Is(report.Err(), ErrNotFound) || errors.Is(report.Err(), ErrForbidden) {
+		t.Errorf("Report.Err() = %v, want it to match ErrNotFound only", report.Err())
+	}
+
+	sort.Strings(events)
+	if fmt.Sprint(events) != "[downloaded 123 error 456]" {
+		t.Errorf("hook events = %v", events)
+	}
+}
+
+func TestDownloadDashboards_RequiresKeys(t *testing.T) {
+	_, err := DownloadDashboards(context.Background(), Config{}, Options{All: true})
+	if err == nil {
+		t.Error("DownloadDashboards() expected error without API keys")
+	}
+}
+
+func TestDownloadDashboards_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report, err := DownloadDashboards(ctx, Config{APIKey: "a", AppKey: "b", Site: "http://127.0.0.1:0"}, Options{IDs: []string{"abc-def-ghi"}})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("DownloadDashboards() error = %v, want context.Canceled", err)
+	}
+	if len(report.Downloaded) != 0 {
+		t.Errorf("Downloaded = %+v, want nothing after cancel", report.Downloaded)
+	}
+}
+
+func TestConfigSettings_NoHTTPCache(t *testing.T) {
+	settings, err := Config{APIKey: "a", AppKey: "b"}.settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Cached dashboards would be in neither Downloaded nor Failed
+	if settings.HTTPCache {
+		t.Error("HTTPCache = true, want false")
+	}
+}
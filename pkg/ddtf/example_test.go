@@ -0,0 +1,42 @@
+package ddtf_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/AD7six/dd-tf/pkg/ddtf"
+)
+
+func ExampleDownloadDashboards() {
+	// A stand-in for the Datadog API
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"abc-def-ghi","title":"Service overview","widgets":[]}`)
+	}))
+	defer server.Close()
+
+	dir, _ := os.MkdirTemp("", "ddtf-example")
+	defer os.RemoveAll(dir)
+
+	report, err := ddtf.DownloadDashboards(context.Background(), ddtf.Config{
+		APIKey:                 "api-key",
+		AppKey:                 "app-key",
+		Site:                   server.URL,
+		DashboardsPathTemplate: filepath.Join(dir, "{id}.json"),
+	}, ddtf.Options{IDs: []string{"abc-def-ghi"}})
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+
+	for _, d := range report.Downloaded {
+		fmt.Println(d.ID, filepath.Base(d.Path))
+	}
+	fmt.Println("failed:", len(report.Failed))
+	// Output:
+	// abc-def-ghi abc-def-ghi.json
+	// failed: 0
+}